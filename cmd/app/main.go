@@ -1,70 +1,206 @@
 package main
 
 import (
-	"log"
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net"
+	"net/smtp"
 	"os"
+	"strconv"
 
-	"github.com/alexedwards/scs/v2"
+	"github.com/alexedwards/scs/pgxstore"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/joho/godotenv"
 	"github.com/justinas/nosurf"
 	"github.com/nixpig/dunce/db"
 	app "github.com/nixpig/dunce/internal/app"
 	"github.com/nixpig/dunce/internal/app/errors"
+	"github.com/nixpig/dunce/internal/config"
+	"github.com/nixpig/dunce/internal/menu"
+	"github.com/nixpig/dunce/internal/site"
+	"github.com/nixpig/dunce/pkg/listener"
 	"github.com/nixpig/dunce/pkg/logging"
+	"github.com/nixpig/dunce/pkg/mail"
+	"github.com/nixpig/dunce/pkg/metrics"
 	"github.com/nixpig/dunce/pkg/session"
 	"github.com/nixpig/dunce/pkg/templates"
 	"github.com/nixpig/dunce/pkg/validation"
 )
 
 func main() {
-	var err error
+	ctx := context.Background()
 
-	appConfig := app.AppConfig{}
+	// logged once the logger's been configured
+	envErr := godotenv.Load(".env")
 
-	if err := godotenv.Load(".env"); err != nil {
-		log.Printf(
-			"unable to load config from env due to '%v' which may not be fatal; continuing...",
-			err,
-		)
+	conf, err := config.Load(os.Args[1:], os.Getenv)
+	if err != nil {
+		// usage has already been printed
+		if err == flag.ErrHelp {
+			os.Exit(0)
+		}
+
+		fmt.Fprintf(os.Stderr, "invalid config:\n%v\n", err)
+		os.Exit(1)
 	}
 
-	if err := db.MigrateUp(); err != nil {
-		log.Printf(
-			"did not run database migration due to '%v' which may not be fatal; continuing...",
-			err,
-		)
+	logger := logging.NewLogger(os.Stderr, conf.Log.Format, conf.Log.SlogLevel())
+
+	if envErr != nil {
+		logger.Warn(ctx, "unable to load .env, which may not be fatal; continuing...", "err", envErr)
 	}
 
-	appConfig.Db, err = db.Connect()
-	if err != nil {
-		log.Fatalf("unable to connect to database: %v", err)
+	// exiting is left until run has returned, so everything it deferred has
+	// been cleaned up
+	if err := run(ctx, conf, logger); err != nil {
+		logger.Error(ctx, "app stopped", "err", err)
 		os.Exit(1)
 	}
+}
+
+// run sets up the app from conf and serves it until it's stopped.
+func run(ctx context.Context, conf *config.Config, logger logging.Log) error {
+	var err error
+
+	appConfig := app.AppConfig{
+		Config:   *conf,
+		Logger:   logger,
+		ErrorLog: slog.NewLogLogger(logger.Handler(), slog.LevelError),
+	}
+
+	connectionString := conf.Database.ConnectionString()
+
+	if err := db.MigrateUp(conf.Paths.Migrations, connectionString); err != nil {
+		logger.Warn(ctx, "did not run database migration, which may not be fatal; continuing...", "err", err)
+	}
+
+	appConfig.Db, err = db.Connect(connectionString)
+	if err != nil {
+		return fmt.Errorf("unable to connect to database: %w", err)
+	}
+
+	defer appConfig.Db.Close()
 
 	appConfig.Validator, err = validation.NewValidator()
 	if err != nil {
-		log.Fatalf("unable to create validator: %v", err)
-		os.Exit(1)
+		return fmt.Errorf("unable to create validator: %w", err)
 	}
 
-	appConfig.TemplateCache, err = templates.NewTemplateCache()
+	appConfig.SiteService = site.NewSiteService(
+		site.NewSitePostgresRepository(appConfig.Db.Pool),
+		appConfig.Validator,
+	)
+
+	appConfig.MenuService = menu.NewMenuService(
+		menu.NewMenuPostgresRepository(appConfig.Db.Pool),
+		appConfig.Validator,
+	)
+
+	templateFuncs := site.TemplateFuncs(appConfig.SiteService)
+	maps.Copy(templateFuncs, menu.TemplateFuncs(appConfig.MenuService))
+
+	templateCache, err := templates.NewTemplateCache(conf.Paths.Templates, templateFuncs)
 	if err != nil {
-		log.Fatalf("unable to build template cache: %v", err)
-		os.Exit(1)
+		return fmt.Errorf("unable to build template cache: %w", err)
+	}
+
+	appConfig.TemplateCache = metrics.InstrumentTemplates(templateCache)
+
+	pool, ok := appConfig.Db.Pool.(*pgxpool.Pool)
+	if !ok {
+		return fmt.Errorf("unable to store sessions: database isn't a connection pool")
+	}
+
+	if err := metrics.RegisterPool(pool); err != nil {
+		return fmt.Errorf("unable to register database metrics: %w", err)
 	}
 
-	appConfig.SessionManager = session.NewSessionManagerImpl(scs.New())
+	// the store cleans up expired sessions in the background until stopped
+	sessionStore := pgxstore.New(pool)
+	defer sessionStore.StopCleanup()
 
-	appConfig.Logger = logging.NewLogger()
+	sessionManager := session.NewSessionManagerImpl(newScs(sessionStore, conf.Session))
+	appConfig.SessionManager = sessionManager
+	appConfig.Sessions = sessionManager
 
 	appConfig.CsrfToken = nosurf.Token
 
-	appConfig.ErrorHandlers = errors.NewErrorHandlersImpl(appConfig.TemplateCache)
+	appConfig.ErrorHandlers = errors.NewErrorHandlersImpl(appConfig.TemplateCache, logger)
 
-	appConfig.Port = os.Getenv("WEB_PORT")
+	// without an SMTP server, e.g. in development, emails are written to a
+	// file or the log instead of being sent
+	if mailConfig := conf.Mail; mailConfig.SmtpHost != "" {
+		appConfig.Mailer = mail.NewSMTPMailer(
+			mailConfig.SmtpHost,
+			strconv.Itoa(mailConfig.SmtpPort),
+			mailConfig.SmtpUsername,
+			mailConfig.SmtpPassword,
+			mailConfig.From,
+			smtp.SendMail,
+		)
+	} else if mailConfig.File != "" {
+		f, err := os.OpenFile(mailConfig.File, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+		if err != nil {
+			return fmt.Errorf("unable to open mail file: %w", err)
+		}
 
-	if err := app.Start(appConfig); err != nil {
-		log.Fatalf("unable to start app: %v", err)
-		os.Exit(1)
+		defer f.Close()
+
+		appConfig.Mailer = mail.NewLogMailer(f, mailConfig.From)
+	} else {
+		appConfig.Mailer = mail.NewLogMailer(os.Stdout, mailConfig.From)
+	}
+
+	appListener, err := listen(logger, "app", fmt.Sprintf(":%d", conf.Server.Port))
+	if err != nil {
+		return err
+	}
+
+	// listeners are handed to the new process on SIGHUP, by name
+	listeners := map[string]net.Listener{
+		"app": appListener,
 	}
+
+	appConfig.Listener = appListener
+
+	if conf.Metrics.Address != "" {
+		metricsListener, err := listen(logger, "metrics", conf.Metrics.Address)
+		if err != nil {
+			return err
+		}
+
+		listeners["metrics"] = metricsListener
+		appConfig.MetricsListener = metricsListener
+	}
+
+	appConfig.Ready = func() {
+		if err := listener.Ready(); err != nil {
+			logger.Error(ctx, "unable to report ready to the process handing over", "err", err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	go handleSignals(ctx, cancel, logger, listeners, conf.Server.HandoffOnSighup)
+
+	return app.Start(ctx, appConfig)
+}
+
+// listen listens on addr, or takes over the listener called name from the
+// process this one is replacing.
+func listen(logger logging.Logger, name, addr string) (net.Listener, error) {
+	l, inherited, err := listener.Listen(name, addr)
+	if err != nil {
+		return nil, fmt.Errorf("unable to listen for %s: %w", name, err)
+	}
+
+	if inherited {
+		logger.Info(context.Background(), "took over listener", "listener", name, "address", l.Addr().String())
+	}
+
+	return l, nil
 }
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"github.com/alexedwards/scs/pgxstore"
+	"github.com/alexedwards/scs/v2"
+	"github.com/nixpig/dunce/internal/config"
+)
+
+// newScs builds the session manager, storing sessions in the database so
+// they last across restarts.
+func newScs(store *pgxstore.PostgresStore, config config.Session) *scs.SessionManager {
+	sessionManager := scs.New()
+
+	sessionManager.Store = store
+
+	sessionManager.Lifetime = config.Lifetime
+	sessionManager.IdleTimeout = config.IdleTimeout
+	sessionManager.Cookie.HttpOnly = true
+	sessionManager.Cookie.SameSite = config.SameSiteMode()
+	sessionManager.Cookie.Secure = config.Secure
+
+	return sessionManager
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/nixpig/dunce/pkg/listener"
+	"github.com/nixpig/dunce/pkg/logging"
+)
+
+// handoffTimeout is how long a process started on SIGHUP gets to start
+// serving before it's given up on.
+const handoffTimeout = 2 * time.Minute
+
+// handleSignals cancels the app's context on SIGINT or SIGTERM, so it shuts
+// down gracefully. With handoff, SIGHUP first starts a new process that takes
+// over listeners, so the app is restarted without dropping any connections.
+// The app carries on serving until the new process is ready, and carries on
+// altogether if it exits or doesn't get ready in time.
+func handleSignals(ctx context.Context, cancel context.CancelFunc, logger logging.Logger, listeners map[string]net.Listener, handoff bool) {
+	stopSignals := []os.Signal{syscall.SIGINT, syscall.SIGTERM}
+	if handoff {
+		stopSignals = append(stopSignals, syscall.SIGHUP)
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, stopSignals...)
+
+	for sig := range signals {
+		if sig == syscall.SIGHUP {
+			child, err := listener.Handoff(listeners)
+			if err != nil {
+				logger.Error(ctx, "unable to hand off listeners, carrying on", "err", err)
+				continue
+			}
+
+			pid := child.Process.Pid
+
+			logger.Info(ctx, "handed off listeners, waiting for new process to be ready", "pid", pid)
+
+			if err := child.Wait(handoffTimeout); err != nil {
+				logger.Error(ctx, "new process didn't take over listeners, carrying on", "pid", pid, "err", err)
+				continue
+			}
+
+			logger.Info(ctx, "new process is ready", "pid", pid)
+		}
+
+		logger.Info(ctx, "shutting down", "signal", sig.String())
+
+		// a second signal kills the app without waiting for it to shut down
+		signal.Stop(signals)
+		cancel()
+
+		return
+	}
+}
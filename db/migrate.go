@@ -6,6 +6,7 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
@@ -15,16 +16,11 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-func MigrateUp() error {
-	env, err := loadDatabaseEnvironment()
-	if err != nil {
-		return err
-	}
-
-	connectionString := buildConnectionString(env)
-
+// MigrateUp runs the migrations in dir against the database at
+// connectionString.
+func MigrateUp(dir, connectionString string) error {
 	log.Print("creating database migration")
-	m, err := migrate.New("file://db/migrations", connectionString)
+	m, err := migrate.New("file://"+dir, connectionString)
 	if err != nil {
 		return err
 	}
@@ -37,68 +33,65 @@ func MigrateUp() error {
 	return nil
 }
 
+// LatestMigration is the version of the latest migration in dir, which is
+// what the database should be at once it's been migrated up.
+func LatestMigration(dir string) (uint, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, err
+	}
+
+	var latest uint
+
+	for _, entry := range entries {
+		// migrations are named like 000001_name.up.sql
+		name, ok := strings.CutSuffix(entry.Name(), ".up.sql")
+		if !ok {
+			continue
+		}
+
+		number, _, _ := strings.Cut(name, "_")
+
+		version, err := strconv.ParseUint(number, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid migration name '%s': %w", entry.Name(), err)
+		}
+
+		latest = max(latest, uint(version))
+	}
+
+	if latest == 0 {
+		return 0, fmt.Errorf("no migrations in '%s'", dir)
+	}
+
+	return latest, nil
+}
+
 type Dbpool struct {
 	Pool Dbconn
 }
 
 type Dbconn interface {
 	Begin(ctx context.Context) (pgx.Tx, error)
+	Ping(ctx context.Context) error
 	Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error)
 	Query(ctx context.Context, sql string, optionsAndArgs ...interface{}) (pgx.Rows, error)
 	QueryRow(ctx context.Context, sql string, optionsAndArgs ...interface{}) pgx.Row
 	SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults
 }
 
-type databaseEnvironment struct {
-	host     string
-	port     uint16
-	name     string
-	username string
-	password string
-}
-
-func Connect() (*Dbpool, error) {
-	env, err := loadDatabaseEnvironment()
-	if err != nil {
-		return nil, err
-	}
-
-	connectionString := buildConnectionString(env)
-
+func Connect(connectionString string) (*Dbpool, error) {
 	pool, err := pgxpool.New(context.Background(), connectionString)
 	if err != nil {
-		log.Fatalf("unable to connect to database: %v", err)
+		return nil, err
 	}
 
 	return &Dbpool{Pool: pool}, nil
 }
 
-func loadDatabaseEnvironment() (*databaseEnvironment, error) {
-	host := os.Getenv("DATABASE_HOST")
-	port := os.Getenv("DATABASE_PORT")
-	name := os.Getenv("POSTGRES_DB")
-	username := os.Getenv("POSTGRES_USER")
-	password := os.Getenv("POSTGRES_PASSWORD")
-
-	portNumber, err := strconv.ParseUint(port, 10, 16)
-	if err != nil {
-		log.Fatalf("unable to get valid port number from: %s", port)
-		return nil, err
+// Close closes the pool, waiting for any connections in use to be released.
+func (d *Dbpool) Close() {
+	if closer, ok := d.Pool.(interface{ Close() }); ok {
+		closer.Close()
 	}
-
-	return &databaseEnvironment{
-		host:     host,
-		port:     uint16(portNumber),
-		name:     name,
-		username: username,
-		password: password,
-	}, nil
-
-}
-
-func buildConnectionString(env *databaseEnvironment) string {
-	return fmt.Sprintf(
-		"postgres://%s:%s@%s:%d/%s?sslmode=disable",
-		env.username, env.password, env.host, env.port, env.name,
-	)
 }
@@ -0,0 +1,82 @@
+package db
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatestMigration(t *testing.T) {
+	scenarios := map[string]func(t *testing.T){
+		"latest of migrations": testLatestMigration,
+		"repo migrations":      testLatestMigrationRepo,
+		"no migrations":        testLatestMigrationNone,
+		"invalid migration":    testLatestMigrationInvalid,
+		"missing directory":    testLatestMigrationMissingDir,
+	}
+
+	for scenario, fn := range scenarios {
+		t.Run(scenario, func(t *testing.T) {
+			fn(t)
+		})
+	}
+}
+
+func migrationsDir(t *testing.T, names ...string) string {
+	dir := t.TempDir()
+
+	for _, name := range names {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), nil, 0o600))
+	}
+
+	return dir
+}
+
+func testLatestMigration(t *testing.T) {
+	dir := migrationsDir(
+		t,
+		"000002_tags.up.sql",
+		"000002_tags.down.sql",
+		"000010_users.up.sql",
+		"000011_users.down.sql",
+		"000001_articles.up.sql",
+		"README.md",
+	)
+
+	version, err := LatestMigration(dir)
+
+	require.NoError(t, err)
+	require.Equal(t, uint(10), version)
+}
+
+func testLatestMigrationRepo(t *testing.T) {
+	version, err := LatestMigration("migrations")
+
+	require.NoError(t, err)
+	require.NotZero(t, version)
+}
+
+func testLatestMigrationNone(t *testing.T) {
+	dir := migrationsDir(t, "README.md")
+
+	version, err := LatestMigration(dir)
+
+	require.Zero(t, version)
+	require.EqualError(t, err, "no migrations in '"+dir+"'")
+}
+
+func testLatestMigrationInvalid(t *testing.T) {
+	version, err := LatestMigration(migrationsDir(t, "first_articles.up.sql"))
+
+	require.Zero(t, version)
+	require.ErrorContains(t, err, "invalid migration name 'first_articles.up.sql'")
+}
+
+func testLatestMigrationMissingDir(t *testing.T) {
+	version, err := LatestMigration(filepath.Join(t.TempDir(), "missing"))
+
+	require.Zero(t, version)
+	require.Error(t, err)
+}
@@ -0,0 +1,2 @@
+alter table articles_ drop constraint if exists articles_status_check;
+alter table articles_ drop column if exists status_;
@@ -0,0 +1,5 @@
+-- existing articles were all live, so backfill them as published before
+-- switching the default over to draft for anything created from now on
+alter table articles_ add column if not exists status_ character varying(20) default 'published' not null;
+alter table articles_ alter column status_ set default 'draft';
+alter table articles_ add constraint articles_status_check check (status_ in ('draft', 'published', 'unlisted', 'archived'));
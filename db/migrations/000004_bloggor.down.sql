@@ -0,0 +1,6 @@
+drop index if exists articles_scheduled_idx;
+alter table articles_ drop constraint if exists articles_scheduled_check;
+update articles_ set status_ = 'draft' where status_ = 'scheduled';
+alter table articles_ drop constraint if exists articles_status_check;
+alter table articles_ add constraint articles_status_check check (status_ in ('draft', 'published', 'unlisted', 'archived'));
+alter table articles_ drop column if exists published_at_;
@@ -0,0 +1,6 @@
+alter table articles_ add column if not exists published_at_ timestamp without time zone;
+update articles_ set published_at_ = created_at_ where status_ in ('published', 'unlisted') and published_at_ is null;
+alter table articles_ drop constraint if exists articles_status_check;
+alter table articles_ add constraint articles_status_check check (status_ in ('draft', 'scheduled', 'published', 'unlisted', 'archived'));
+alter table articles_ add constraint articles_scheduled_check check (status_ <> 'scheduled' or published_at_ is not null);
+create index if not exists articles_scheduled_idx on articles_ (published_at_) where status_ = 'scheduled';
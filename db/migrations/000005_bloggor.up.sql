@@ -0,0 +1,12 @@
+create table if not exists article_revisions_ (
+    id_ integer primary key generated always as identity,
+    article_id_ integer not null references articles_(id_) on delete cascade,
+    title_ character varying(255),
+    subtitle_ character varying(255),
+    slug_ character varying(50) not null,
+    body_ text,
+    tag_ids_ integer[] default '{}' not null,
+    created_at_ timestamp without time zone default current_timestamp not null
+);
+
+create index if not exists article_revisions_article_id_idx on article_revisions_ (article_id_, created_at_ desc);
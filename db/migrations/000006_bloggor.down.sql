@@ -0,0 +1,4 @@
+drop index if exists articles_search_vector_idx;
+drop trigger if exists articles_search_vector_trigger_ on articles_;
+drop function if exists articles_search_vector_update_();
+alter table articles_ drop column if exists search_vector_;
@@ -0,0 +1,23 @@
+alter table articles_ add column if not exists search_vector_ tsvector;
+
+create or replace function articles_search_vector_update_() returns trigger as $$
+begin
+    new.search_vector_ :=
+        setweight(to_tsvector('english', coalesce(new.title_, '')), 'A') ||
+        setweight(to_tsvector('english', coalesce(new.subtitle_, '')), 'B') ||
+        setweight(to_tsvector('english', coalesce(new.body_, '')), 'C');
+    return new;
+end
+$$ language plpgsql;
+
+drop trigger if exists articles_search_vector_trigger_ on articles_;
+create trigger articles_search_vector_trigger_
+    before insert or update of title_, subtitle_, body_ on articles_
+    for each row execute function articles_search_vector_update_();
+
+update articles_ set search_vector_ =
+    setweight(to_tsvector('english', coalesce(title_, '')), 'A') ||
+    setweight(to_tsvector('english', coalesce(subtitle_, '')), 'B') ||
+    setweight(to_tsvector('english', coalesce(body_, '')), 'C');
+
+create index if not exists articles_search_vector_idx on articles_ using gin (search_vector_);
@@ -0,0 +1,2 @@
+alter table site_ alter column meta_ drop default;
+alter table site_ alter column value_ type character varying(255);
@@ -0,0 +1,2 @@
+alter table site_ alter column value_ type text;
+alter table site_ alter column meta_ set default '';
@@ -0,0 +1,2 @@
+drop index if exists menu_items_position_idx_;
+drop table if exists menu_items_;
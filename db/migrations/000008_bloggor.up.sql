@@ -0,0 +1,14 @@
+create table if not exists menu_items_ (
+    id_ integer primary key generated always as identity,
+    label_ character varying(50) not null,
+    kind_ character varying(20) not null check (kind_ in ('article', 'tag', 'page', 'url')),
+    target_ character varying(255) not null,
+    position_ integer not null
+);
+
+create index if not exists menu_items_position_idx_ on menu_items_ (position_);
+
+insert into menu_items_ (label_, kind_, target_, position_) values
+    ('Home', 'url', '/', 1),
+    ('Articles', 'url', '/articles', 2),
+    ('Search', 'url', '/search', 3);
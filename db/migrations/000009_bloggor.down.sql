@@ -0,0 +1,2 @@
+drop index if exists pages_parent_id_idx_;
+drop table if exists pages_;
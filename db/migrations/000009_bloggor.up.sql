@@ -0,0 +1,12 @@
+create table if not exists pages_ (
+    id_ integer primary key generated always as identity,
+    title_ character varying(255) not null,
+    slug_ character varying(50) unique not null,
+    body_ text not null,
+    parent_id_ integer references pages_(id_),
+    template_ character varying(20) not null default 'default',
+    created_at_ timestamp without time zone default current_timestamp not null,
+    updated_at_ timestamp without time zone default current_timestamp not null
+);
+
+create index if not exists pages_parent_id_idx_ on pages_ (parent_id_);
@@ -0,0 +1,4 @@
+drop index if exists articles_author_id_idx;
+alter table articles_ drop column if exists author_id_;
+alter table users_ drop constraint if exists users_role_check;
+alter table users_ drop column if exists role_;
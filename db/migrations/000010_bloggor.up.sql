@@ -0,0 +1,8 @@
+-- existing users could already do everything, so backfill them as admins
+-- before switching the default over to the least access for new users
+alter table users_ add column if not exists role_ character varying(20) default 'admin' not null;
+alter table users_ alter column role_ set default 'viewer';
+alter table users_ add constraint users_role_check check (role_ in ('admin', 'editor', 'author', 'viewer'));
+
+alter table articles_ add column if not exists author_id_ integer references users_(id_) on delete set null;
+create index if not exists articles_author_id_idx on articles_ (author_id_);
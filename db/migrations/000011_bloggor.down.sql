@@ -0,0 +1,3 @@
+alter table users_ drop column if exists avatar_url_;
+alter table users_ drop column if exists bio_;
+alter table users_ drop column if exists display_name_;
@@ -0,0 +1,3 @@
+alter table users_ add column if not exists display_name_ character varying(100) default '' not null;
+alter table users_ add column if not exists bio_ text default '' not null;
+alter table users_ add column if not exists avatar_url_ character varying(255) default '' not null;
@@ -0,0 +1 @@
+alter table users_ drop column if exists session_version_;
@@ -0,0 +1 @@
+alter table users_ add column if not exists session_version_ integer default 0 not null;
@@ -0,0 +1,10 @@
+create table if not exists password_resets_ (
+    id_ integer primary key generated always as identity,
+    user_id_ integer references users_(id_) on delete cascade not null,
+    token_hash_ character(64) unique not null,
+    expires_at_ timestamp with time zone not null,
+    used_at_ timestamp with time zone,
+    created_at_ timestamp with time zone default current_timestamp not null
+);
+
+create index if not exists password_resets_user_id_idx on password_resets_ (user_id_);
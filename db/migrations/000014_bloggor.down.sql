@@ -0,0 +1,5 @@
+drop index if exists user_recovery_codes_user_id_idx;
+drop table if exists user_recovery_codes_;
+alter table users_ drop column if exists totp_last_step_;
+alter table users_ drop column if exists totp_enabled_;
+alter table users_ drop column if exists totp_secret_;
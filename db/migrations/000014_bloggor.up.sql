@@ -0,0 +1,13 @@
+alter table users_ add column if not exists totp_secret_ character varying(64) default '' not null;
+alter table users_ add column if not exists totp_enabled_ boolean default false not null;
+alter table users_ add column if not exists totp_last_step_ bigint default 0 not null;
+
+create table if not exists user_recovery_codes_ (
+    id_ integer primary key generated always as identity,
+    user_id_ integer references users_(id_) on delete cascade not null,
+    code_hash_ character(64) not null,
+    used_at_ timestamp with time zone,
+    created_at_ timestamp with time zone default current_timestamp not null
+);
+
+create index if not exists user_recovery_codes_user_id_idx on user_recovery_codes_ (user_id_);
@@ -0,0 +1,6 @@
+create table if not exists login_failures_ (
+    key_ character varying(255) primary key,
+    failures_ integer default 0 not null,
+    last_failed_at_ timestamp with time zone not null,
+    locked_until_ timestamp with time zone default 'epoch' not null
+);
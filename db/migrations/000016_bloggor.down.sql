@@ -0,0 +1,2 @@
+drop index if exists sessions_expiry_idx;
+drop table if exists sessions;
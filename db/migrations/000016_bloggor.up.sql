@@ -0,0 +1,7 @@
+create table if not exists sessions (
+    token text primary key,
+    data bytea not null,
+    expiry timestamp with time zone not null
+);
+
+create index if not exists sessions_expiry_idx on sessions (expiry);
@@ -0,0 +1,2 @@
+drop index if exists api_tokens_user_id_idx;
+drop table if exists api_tokens_;
@@ -0,0 +1,12 @@
+create table if not exists api_tokens_ (
+    id_ integer primary key generated always as identity,
+    user_id_ integer references users_(id_) on delete cascade not null,
+    name_ character varying(100) not null,
+    token_hash_ character(64) unique not null,
+    scopes_ text[] not null,
+    expires_at_ timestamp with time zone,
+    last_used_at_ timestamp with time zone,
+    created_at_ timestamp with time zone default current_timestamp not null
+);
+
+create index if not exists api_tokens_user_id_idx on api_tokens_ (user_id_);
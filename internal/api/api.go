@@ -0,0 +1,205 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/nixpig/dunce/internal/user"
+	"github.com/nixpig/dunce/pkg/logging"
+	"github.com/nixpig/dunce/pkg/pagination"
+	"github.com/nixpig/dunce/pkg/session"
+)
+
+// maxBodySize is the most a request body can be, which is plenty for the
+// longest article.
+const maxBodySize = 1 << 20
+
+type ControllerConfig struct {
+	Log logging.Logger
+}
+
+// ErrorResponse is the body of every API error. Fields is only set when the
+// request was invalid, with a message for each field that failed validation.
+type ErrorResponse struct {
+	Error  string       `json:"error"`
+	Fields []FieldError `json:"fields,omitempty"`
+}
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// PageResponse is a page of a list. Next and Prev are links to the pages
+// either side of it, if there are any.
+type PageResponse[T any] struct {
+	Items  []T     `json:"items"`
+	Total  int     `json:"total"`
+	Limit  int     `json:"limit"`
+	Offset int     `json:"offset"`
+	Next   *string `json:"next"`
+	Prev   *string `json:"prev"`
+}
+
+func newPageResponse[T, U any](path string, page *pagination.Page[T], fn func(T) U) PageResponse[U] {
+	mapped := pagination.Map(page, fn)
+
+	response := PageResponse[U]{
+		Items:  mapped.Items,
+		Total:  mapped.Total,
+		Limit:  mapped.Request.Limit,
+		Offset: mapped.Request.Offset,
+	}
+
+	if mapped.HasNext() {
+		next := path + "?" + string(mapped.NextQuery())
+		response.Next = &next
+	}
+
+	if mapped.HasPrev() {
+		prev := path + "?" + string(mapped.PrevQuery())
+		response.Prev = &prev
+	}
+
+	return response
+}
+
+func writeJson(w http.ResponseWriter, r *http.Request, log logging.Logger, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	encoder := json.NewEncoder(w)
+	// links to other pages have query strings, which shouldn't be escaped
+	encoder.SetEscapeHTML(false)
+
+	if err := encoder.Encode(body); err != nil {
+		log.Error(r.Context(), "unable to write api response", "err", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, log logging.Logger, status int, message string) {
+	writeJson(w, r, log, status, ErrorResponse{Error: message})
+}
+
+func writeFieldErrors(w http.ResponseWriter, r *http.Request, log logging.Logger, fields ...FieldError) {
+	writeJson(w, r, log, http.StatusUnprocessableEntity, ErrorResponse{
+		Error:  "validation failed",
+		Fields: fields,
+	})
+}
+
+// writeServiceError writes the response for an error from a service, logging
+// anything that isn't the client's fault.
+func writeServiceError(w http.ResponseWriter, r *http.Request, log logging.Logger, err error, action string) {
+	var validationErrors validator.ValidationErrors
+	var pgErr *pgconn.PgError
+
+	switch {
+	case errors.As(err, &validationErrors):
+		writeFieldErrors(w, r, log, fieldErrors(validationErrors)...)
+
+	case errors.Is(err, pgx.ErrNoRows):
+		writeError(w, r, log, http.StatusNotFound, "not found")
+
+	case errors.As(err, &pgErr) && pgErr.Code == "23505":
+		// unique_violation, e.g. a slug or username that's already taken
+		writeError(w, r, log, http.StatusConflict, "already exists")
+
+	default:
+		log.Error(
+			r.Context(),
+			"internal server error",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"err", fmt.Errorf("unable to %s: %w", action, err),
+		)
+		writeError(w, r, log, http.StatusInternalServerError, "internal server error")
+	}
+}
+
+// decodeJson reads the request body into v, writing an error response and
+// returning false if it isn't valid JSON for v.
+func decodeJson(w http.ResponseWriter, r *http.Request, log logging.Logger, v any) bool {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(v); err != nil {
+		writeError(w, r, log, http.StatusBadRequest, fmt.Sprintf("invalid request body: %s", err))
+		return false
+	}
+
+	return true
+}
+
+// fieldErrors describes each validation error against the JSON name of the
+// field it's for. Request bodies name fields the same as the structs the
+// services validate, only in snake case, so the names can be derived.
+func fieldErrors(validationErrors validator.ValidationErrors) []FieldError {
+	fields := make([]FieldError, len(validationErrors))
+
+	for i, err := range validationErrors {
+		fields[i] = FieldError{
+			Field:   snakeCase(err.Field()),
+			Message: fieldMessage(err),
+		}
+	}
+
+	return fields
+}
+
+func fieldMessage(err validator.FieldError) string {
+	switch err.Tag() {
+	case "required":
+		return "is required"
+	case "min":
+		return fmt.Sprintf("must be at least %s characters", err.Param())
+	case "max":
+		return fmt.Sprintf("must be at most %s characters", err.Param())
+	case "oneof":
+		return fmt.Sprintf("must be one of: %s", strings.ReplaceAll(err.Param(), " ", ", "))
+	case "email":
+		return "must be an email address"
+	case "slug":
+		return "must only contain letters, numbers and hyphens"
+	case "lowercase":
+		return "must be lowercase"
+	case "http_url":
+		return "must be a http or https URL"
+	case "fqdn":
+		return "must be a domain name"
+	default:
+		return fmt.Sprintf("failed '%s' validation", err.Tag())
+	}
+}
+
+func snakeCase(name string) string {
+	var b strings.Builder
+
+	for i, r := range name {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+
+			r = unicode.ToLower(r)
+		}
+
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+// loggedInUser returns the user the API token belongs to.
+func loggedInUser(r *http.Request) (*user.UserResponseDto, bool) {
+	loggedInUser, ok := r.Context().Value(session.LOGGED_IN_USER_CONTEXT_KEY).(*user.UserResponseDto)
+
+	return loggedInUser, ok
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/nixpig/dunce/internal/user"
+	"github.com/nixpig/dunce/pkg/session"
+	"github.com/nixpig/dunce/pkg/validation"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var mockLogger = new(MockLogger)
+
+var errTest = errors.New("db_error")
+
+type MockLogger struct {
+	mock.Mock
+}
+
+func (l *MockLogger) Debug(ctx context.Context, msg string, args ...any) {
+	l.Called(msg, args)
+}
+
+func (l *MockLogger) Info(ctx context.Context, msg string, args ...any) {
+	l.Called(msg, args)
+}
+
+func (l *MockLogger) Warn(ctx context.Context, msg string, args ...any) {
+	l.Called(msg, args)
+}
+
+func (l *MockLogger) Error(ctx context.Context, msg string, args ...any) {
+	l.Called(msg, args)
+}
+
+func withLoggedInUser(r *http.Request, loggedInUser *user.UserResponseDto) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), session.LOGGED_IN_USER_CONTEXT_KEY, loggedInUser))
+}
+
+func TestSnakeCase(t *testing.T) {
+	scenarios := map[string]string{
+		"Title":       "title",
+		"PublishedAt": "published_at",
+		"TagIds":      "tag_ids",
+		"":            "",
+	}
+
+	for name, expected := range scenarios {
+		require.Equal(t, expected, snakeCase(name), "should convert '%s' to snake case", name)
+	}
+}
+
+func TestFieldErrors(t *testing.T) {
+	validate, err := validation.NewValidator()
+	if err != nil {
+		t.Fatal("unable to construct validator")
+	}
+
+	err = validate.Struct(user.UserNewRequestDto{
+		Username: "janedoe",
+		Email:    "jane@example.org",
+		Role:     "owner",
+	})
+
+	require.IsType(t, validator.ValidationErrors{}, err, "should return validation errors")
+	require.Equal(t, []FieldError{
+		{Field: "password", Message: "is required"},
+		{Field: "role", Message: "must be one of: admin, editor, author, viewer"},
+	}, fieldErrors(err.(validator.ValidationErrors)), "should describe each field")
+}
@@ -0,0 +1,316 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/nixpig/dunce/internal/article"
+	"github.com/nixpig/dunce/pkg/logging"
+	"github.com/nixpig/dunce/pkg/pagination"
+)
+
+var articleStatuses = []string{
+	article.StatusDraft,
+	article.StatusScheduled,
+	article.StatusPublished,
+	article.StatusUnlisted,
+	article.StatusArchived,
+}
+
+type ArticleController struct {
+	service article.ArticleService
+	log     logging.Logger
+}
+
+type Article struct {
+	Id          int            `json:"id"`
+	Title       string         `json:"title"`
+	Subtitle    string         `json:"subtitle"`
+	Slug        string         `json:"slug"`
+	Body        string         `json:"body"`
+	Status      string         `json:"status"`
+	PublishedAt *time.Time     `json:"published_at"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	Author      *ArticleAuthor `json:"author,omitempty"`
+	Tags        []Tag          `json:"tags"`
+}
+
+type ArticleAuthor struct {
+	Username    string `json:"username"`
+	DisplayName string `json:"display_name"`
+}
+
+// ArticleRequest is the body for creating or replacing an article. Articles
+// that go live without a published_at are published now, as on the article
+// form.
+type ArticleRequest struct {
+	Title       string     `json:"title"`
+	Subtitle    string     `json:"subtitle"`
+	Slug        string     `json:"slug"`
+	Body        string     `json:"body"`
+	Status      string     `json:"status"`
+	PublishedAt *time.Time `json:"published_at"`
+	TagIds      []int      `json:"tag_ids"`
+}
+
+func NewArticleController(service article.ArticleService, config ControllerConfig) ArticleController {
+	return ArticleController{
+		service: service,
+		log:     config.Log,
+	}
+}
+
+// ArticlesGet lists articles in every status. They can be filtered by one of
+// status, tag or author, or searched for with q, optionally within a status.
+// Like the public listings they're built on, tag and author only include
+// published articles.
+func (a ArticleController) ArticlesGet(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	status := query.Get("status")
+	tagSlug := query.Get("tag")
+	author := query.Get("author")
+	search := query.Get("q")
+
+	if status != "" && !slices.Contains(articleStatuses, status) {
+		writeFieldErrors(w, r, a.log, FieldError{
+			Field:   "status",
+			Message: fmt.Sprintf("must be one of: %s", strings.Join(articleStatuses, ", ")),
+		})
+		return
+	}
+
+	filters := 0
+	for _, filter := range []string{status, tagSlug, author} {
+		if filter != "" {
+			filters++
+		}
+	}
+
+	if filters > 1 || (search != "" && (tagSlug != "" || author != "")) {
+		writeError(w, r, a.log, http.StatusBadRequest, "only one of status, tag or author can be given, and q can only be combined with status")
+		return
+	}
+
+	page := pagination.FromQuery(query)
+	params := url.Values{}
+
+	var articles *pagination.Page[article.ArticleResponseDto]
+	var err error
+
+	switch {
+	case search != "":
+		var statuses []string
+		if status != "" {
+			statuses = append(statuses, status)
+			params.Set("status", status)
+		}
+
+		var results *article.ArticleSearchResponseDto
+
+		results, err = a.service.Search(search, page, statuses...)
+		if err == nil {
+			params.Set("q", results.Query)
+			articles = pagination.Map(results.Results, func(result article.ArticleSearchResultResponseDto) article.ArticleResponseDto {
+				return result.Article
+			})
+		}
+
+	case status != "":
+		params.Set("status", status)
+		articles, err = a.service.GetManyByAttribute("status", status, page)
+
+	case tagSlug != "":
+		params.Set("tag", tagSlug)
+		articles, err = a.service.GetManyByAttribute("tagSlug", tagSlug, page)
+
+	case author != "":
+		params.Set("author", author)
+		articles, err = a.service.GetManyByAttribute("authorUsername", author, page)
+
+	default:
+		articles, err = a.service.GetAll(page)
+	}
+
+	if err != nil {
+		writeServiceError(w, r, a.log, err, "list articles")
+		return
+	}
+
+	articles.Params = params
+
+	writeJson(w, r, a.log, http.StatusOK, newPageResponse(r.URL.Path, articles, newArticle))
+}
+
+func (a ArticleController) ArticleGet(w http.ResponseWriter, r *http.Request) {
+	found, err := a.service.GetByAttribute("slug", r.PathValue("slug"))
+	if err != nil {
+		writeServiceError(w, r, a.log, err, "get article")
+		return
+	}
+
+	writeJson(w, r, a.log, http.StatusOK, newArticle(*found))
+}
+
+// ArticlesPost creates an article written by the user the token belongs to.
+func (a ArticleController) ArticlesPost(w http.ResponseWriter, r *http.Request) {
+	loggedInUser, ok := loggedInUser(r)
+	if !ok {
+		writeError(w, r, a.log, http.StatusUnauthorized, "unauthorised")
+		return
+	}
+
+	var body ArticleRequest
+	if !decodeJson(w, r, a.log, &body) {
+		return
+	}
+
+	now := time.Now()
+	authorId := int(loggedInUser.Id)
+
+	created, err := a.service.Create(&article.ArticleNewRequestDto{
+		Title:       body.Title,
+		Subtitle:    body.Subtitle,
+		Slug:        body.Slug,
+		Body:        body.Body,
+		Status:      body.Status,
+		PublishedAt: publishedAt(body.PublishedAt, body.Status, now),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		AuthorId:    &authorId,
+		TagIds:      body.TagIds,
+	})
+	if err != nil {
+		a.writeError(w, r, err, "create article")
+		return
+	}
+
+	w.Header().Set("Location", "/api/v1/articles/"+created.Slug)
+
+	writeJson(w, r, a.log, http.StatusCreated, newArticle(*created))
+}
+
+// ArticlePut replaces the article with slug, keeping its author and when it
+// was created.
+func (a ArticleController) ArticlePut(w http.ResponseWriter, r *http.Request) {
+	existing, ok := a.authorize(w, r)
+	if !ok {
+		return
+	}
+
+	var body ArticleRequest
+	if !decodeJson(w, r, a.log, &body) {
+		return
+	}
+
+	updated, err := a.service.Update(&article.ArticleUpdateRequestDto{
+		Id:          existing.Id,
+		Title:       body.Title,
+		Subtitle:    body.Subtitle,
+		Slug:        body.Slug,
+		Body:        body.Body,
+		Status:      body.Status,
+		PublishedAt: publishedAt(body.PublishedAt, body.Status, time.Now()),
+		CreatedAt:   existing.CreatedAt,
+		UpdatedAt:   time.Now(),
+		TagIds:      body.TagIds,
+	})
+	if err != nil {
+		a.writeError(w, r, err, "update article")
+		return
+	}
+
+	updated.Author = existing.Author
+
+	writeJson(w, r, a.log, http.StatusOK, newArticle(*updated))
+}
+
+func (a ArticleController) ArticleDelete(w http.ResponseWriter, r *http.Request) {
+	existing, ok := a.authorize(w, r)
+	if !ok {
+		return
+	}
+
+	if err := a.service.DeleteById(existing.Id); err != nil {
+		writeServiceError(w, r, a.log, err, "delete article")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// authorize gets the article with the slug in the path and checks the token's
+// user can change it, e.g. that an author wrote it, writing an error response
+// if not.
+func (a ArticleController) authorize(w http.ResponseWriter, r *http.Request) (*article.ArticleResponseDto, bool) {
+	existing, err := a.service.GetByAttribute("slug", r.PathValue("slug"))
+	if err != nil {
+		writeServiceError(w, r, a.log, err, "get article")
+		return nil, false
+	}
+
+	loggedInUser, ok := loggedInUser(r)
+	if !ok || !loggedInUser.CanEdit(existing.AuthorId) {
+		writeError(w, r, a.log, http.StatusForbidden, "you can't change this article")
+		return nil, false
+	}
+
+	return existing, true
+}
+
+// writeError reports the checks the article service makes outside of
+// validation against the fields they're about.
+func (a ArticleController) writeError(w http.ResponseWriter, r *http.Request, err error, action string) {
+	switch {
+	case errors.Is(err, article.ErrArticleWithoutTags):
+		writeFieldErrors(w, r, a.log, FieldError{Field: "tag_ids", Message: "must have at least one tag"})
+
+	case errors.Is(err, article.ErrScheduledWithoutDate):
+		writeFieldErrors(w, r, a.log, FieldError{Field: "published_at", Message: "is required for scheduled articles"})
+
+	default:
+		writeServiceError(w, r, a.log, err, action)
+	}
+}
+
+func publishedAt(value *time.Time, status string, now time.Time) *time.Time {
+	if value == nil && (status == article.StatusPublished || status == article.StatusUnlisted) {
+		return &now
+	}
+
+	return value
+}
+
+func newArticle(a article.ArticleResponseDto) Article {
+	response := Article{
+		Id:          a.Id,
+		Title:       a.Title,
+		Subtitle:    a.Subtitle,
+		Slug:        a.Slug,
+		Body:        a.Body,
+		Status:      a.Status,
+		PublishedAt: a.PublishedAt,
+		CreatedAt:   a.CreatedAt,
+		UpdatedAt:   a.UpdatedAt,
+		Tags:        make([]Tag, len(a.Tags)),
+	}
+
+	if a.Author != nil {
+		response.Author = &ArticleAuthor{
+			Username:    a.Author.Username,
+			DisplayName: a.Author.DisplayName,
+		}
+	}
+
+	for i, t := range a.Tags {
+		response.Tags[i] = Tag{Id: t.Id, Name: t.Name, Slug: t.Slug}
+	}
+
+	return response
+}
@@ -0,0 +1,408 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/nixpig/dunce/internal/article"
+	"github.com/nixpig/dunce/internal/tag"
+	"github.com/nixpig/dunce/internal/user"
+	"github.com/nixpig/dunce/pkg/pagination"
+	"github.com/nixpig/dunce/pkg/validation"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var mockArticleService = new(MockArticleService)
+
+func TestArticleController(t *testing.T) {
+	scenarios := map[string]func(t *testing.T, ctrl ArticleController){
+		"list articles (success)":                    testApiArticlesGet,
+		"list articles (success - status filter)":    testApiArticlesGetByStatus,
+		"list articles (error - invalid status)":     testApiArticlesGetInvalidStatus,
+		"list articles (error - conflicting filter)": testApiArticlesGetConflictingFilters,
+		"get article (error - not found)":            testApiArticleGetNotFound,
+		"create article (success)":                   testApiArticlesPost,
+		"create article (error - validation)":        testApiArticlesPostValidationError,
+		"create article (error - no tags)":           testApiArticlesPostWithoutTags,
+		"create article (error - invalid json)":      testApiArticlesPostInvalidJson,
+		"update article (error - not author)":        testApiArticlePutNotAuthor,
+		"delete article (success)":                   testApiArticleDelete,
+	}
+
+	for scenario, fn := range scenarios {
+		t.Run(scenario, func(t *testing.T) {
+			ctrl := NewArticleController(mockArticleService, ControllerConfig{
+				Log: mockLogger,
+			})
+
+			fn(t, ctrl)
+		})
+	}
+}
+
+type MockArticleService struct {
+	mock.Mock
+}
+
+func (m *MockArticleService) DeleteById(id int) error {
+	args := m.Called(id)
+
+	return args.Error(0)
+}
+
+func (m *MockArticleService) Create(a *article.ArticleNewRequestDto) (*article.ArticleResponseDto, error) {
+	args := m.Called(a)
+
+	return args.Get(0).(*article.ArticleResponseDto), args.Error(1)
+}
+
+func (m *MockArticleService) GetAll(page pagination.Request) (*pagination.Page[article.ArticleResponseDto], error) {
+	args := m.Called(page)
+
+	return args.Get(0).(*pagination.Page[article.ArticleResponseDto]), args.Error(1)
+}
+
+func (m *MockArticleService) GetManyByAttribute(
+	attr, value string,
+	page pagination.Request,
+) (*pagination.Page[article.ArticleResponseDto], error) {
+	args := m.Called(attr, value, page)
+
+	return args.Get(0).(*pagination.Page[article.ArticleResponseDto]), args.Error(1)
+}
+
+func (m *MockArticleService) GetByAttribute(attr, value string) (*article.ArticleResponseDto, error) {
+	args := m.Called(attr, value)
+
+	return args.Get(0).(*article.ArticleResponseDto), args.Error(1)
+}
+
+func (m *MockArticleService) Update(a *article.ArticleUpdateRequestDto) (*article.ArticleResponseDto, error) {
+	args := m.Called(a)
+
+	return args.Get(0).(*article.ArticleResponseDto), args.Error(1)
+}
+
+func (m *MockArticleService) PublishScheduled(now time.Time) (*[]article.ArticleResponseDto, error) {
+	args := m.Called(now)
+
+	return args.Get(0).(*[]article.ArticleResponseDto), args.Error(1)
+}
+
+func (m *MockArticleService) GetRevisions(articleId int) (*[]article.ArticleRevisionResponseDto, error) {
+	args := m.Called(articleId)
+
+	return args.Get(0).(*[]article.ArticleRevisionResponseDto), args.Error(1)
+}
+
+func (m *MockArticleService) GetRevisionById(id int) (*article.ArticleRevisionResponseDto, error) {
+	args := m.Called(id)
+
+	return args.Get(0).(*article.ArticleRevisionResponseDto), args.Error(1)
+}
+
+func (m *MockArticleService) RestoreRevision(slug string, revisionId int) (*article.ArticleResponseDto, error) {
+	args := m.Called(slug, revisionId)
+
+	return args.Get(0).(*article.ArticleResponseDto), args.Error(1)
+}
+
+func (m *MockArticleService) Search(
+	query string,
+	page pagination.Request,
+	statuses ...string,
+) (*article.ArticleSearchResponseDto, error) {
+	args := m.Called(query, page, statuses)
+
+	return args.Get(0).(*article.ArticleSearchResponseDto), args.Error(1)
+}
+
+var testArticleCreatedAt = time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+func testArticle(authorId int) article.ArticleResponseDto {
+	return article.ArticleResponseDto{
+		Id:        23,
+		Title:     "Article title",
+		Subtitle:  "Article subtitle",
+		Slug:      "article-slug",
+		Body:      "Article body",
+		Status:    article.StatusDraft,
+		CreatedAt: testArticleCreatedAt,
+		UpdatedAt: testArticleCreatedAt,
+		AuthorId:  &authorId,
+		Author:    &article.ArticleAuthor{Username: "janedoe", DisplayName: "Jane Doe"},
+		Tags:      []tag.Tag{{Id: 1, Name: "Go", Slug: "go"}},
+	}
+}
+
+func testApiArticlesGet(t *testing.T, ctrl ArticleController) {
+	req := httptest.NewRequest("GET", "/api/v1/articles", nil)
+	rr := httptest.NewRecorder()
+
+	mockGetAll := mockArticleService.
+		On("GetAll", pagination.Request{}).
+		Return(&pagination.Page[article.ArticleResponseDto]{
+			Items:   []article.ArticleResponseDto{testArticle(7)},
+			Total:   1,
+			Request: pagination.Request{Limit: 20, Sort: "published", Direction: pagination.Desc},
+		}, nil)
+
+	http.HandlerFunc(ctrl.ArticlesGet).ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code, "should return status code ok")
+	require.Equal(t, "application/json", rr.Header().Get("Content-Type"), "should return json")
+	require.JSONEq(t, `{
+		"items": [{
+			"id": 23,
+			"title": "Article title",
+			"subtitle": "Article subtitle",
+			"slug": "article-slug",
+			"body": "Article body",
+			"status": "draft",
+			"published_at": null,
+			"created_at": "2024-03-01T12:00:00Z",
+			"updated_at": "2024-03-01T12:00:00Z",
+			"author": {"username": "janedoe", "display_name": "Jane Doe"},
+			"tags": [{"id": 1, "name": "Go", "slug": "go"}]
+		}],
+		"total": 1,
+		"limit": 20,
+		"offset": 0,
+		"next": null,
+		"prev": null
+	}`, rr.Body.String(), "should return page of articles")
+
+	mockGetAll.Unset()
+}
+
+func testApiArticlesGetByStatus(t *testing.T, ctrl ArticleController) {
+	req := httptest.NewRequest("GET", "/api/v1/articles?status=draft&limit=1", nil)
+	rr := httptest.NewRecorder()
+
+	sortable := pagination.Sortable{
+		Columns:          map[string]pagination.Column{"id": {Expr: "id_", Type: "integer"}},
+		Id:               "id_",
+		DefaultSort:      "id",
+		DefaultDirection: pagination.Asc,
+	}
+
+	page := pagination.NewPage(
+		sortable.Query(pagination.Request{Limit: 1}, 1),
+		[]article.ArticleResponseDto{testArticle(7)},
+		[]pagination.Key{{Value: "23", Id: 23}},
+		2,
+	)
+
+	mockGetManyByAttribute := mockArticleService.
+		On("GetManyByAttribute", "status", "draft", pagination.Request{Limit: 1}).
+		Return(page, nil)
+
+	http.HandlerFunc(ctrl.ArticlesGet).ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code, "should return status code ok")
+	require.Contains(
+		t,
+		rr.Body.String(),
+		`"next":"/api/v1/articles?dir=asc&limit=1&offset=1&sort=id&status=draft"`,
+		"should keep filter in link to next page",
+	)
+
+	mockGetManyByAttribute.Unset()
+}
+
+func testApiArticlesGetInvalidStatus(t *testing.T, ctrl ArticleController) {
+	req := httptest.NewRequest("GET", "/api/v1/articles?status=deleted", nil)
+	rr := httptest.NewRecorder()
+
+	http.HandlerFunc(ctrl.ArticlesGet).ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusUnprocessableEntity, rr.Code, "should return status code unprocessable entity")
+	require.JSONEq(t, `{
+		"error": "validation failed",
+		"fields": [{"field": "status", "message": "must be one of: draft, scheduled, published, unlisted, archived"}]
+	}`, rr.Body.String(), "should return status field error")
+}
+
+func testApiArticlesGetConflictingFilters(t *testing.T, ctrl ArticleController) {
+	req := httptest.NewRequest("GET", "/api/v1/articles?q=go&tag=go", nil)
+	rr := httptest.NewRecorder()
+
+	http.HandlerFunc(ctrl.ArticlesGet).ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusBadRequest, rr.Code, "should return status code bad request")
+}
+
+func testApiArticleGetNotFound(t *testing.T, ctrl ArticleController) {
+	req := httptest.NewRequest("GET", "/api/v1/articles/missing", nil)
+	req.SetPathValue("slug", "missing")
+
+	rr := httptest.NewRecorder()
+
+	mockGetByAttribute := mockArticleService.
+		On("GetByAttribute", "slug", "missing").
+		Return((*article.ArticleResponseDto)(nil), pgx.ErrNoRows)
+
+	http.HandlerFunc(ctrl.ArticleGet).ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusNotFound, rr.Code, "should return status code not found")
+	require.JSONEq(t, `{"error": "not found"}`, rr.Body.String(), "should return not found error")
+
+	mockGetByAttribute.Unset()
+}
+
+func testApiArticlesPost(t *testing.T, ctrl ArticleController) {
+	req := httptest.NewRequest("POST", "/api/v1/articles", strings.NewReader(`{
+		"title": "Article title",
+		"subtitle": "Article subtitle",
+		"slug": "article-slug",
+		"body": "Article body",
+		"status": "published",
+		"tag_ids": [1]
+	}`))
+	req = withLoggedInUser(req, &user.UserResponseDto{Id: 7, Username: "janedoe", Role: user.RoleAuthor})
+
+	rr := httptest.NewRecorder()
+
+	var created *article.ArticleNewRequestDto
+
+	createdArticle := testArticle(7)
+
+	mockCreate := mockArticleService.
+		On("Create", mock.Anything).
+		Run(func(args mock.Arguments) {
+			created = args.Get(0).(*article.ArticleNewRequestDto)
+		}).
+		Return(&createdArticle, nil)
+
+	http.HandlerFunc(ctrl.ArticlesPost).ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusCreated, rr.Code, "should return status code created")
+	require.Equal(t, "/api/v1/articles/article-slug", rr.Header().Get("Location"), "should link to article")
+	require.Equal(t, "Article title", created.Title, "should create article with title")
+	require.Equal(t, []int{1}, created.TagIds, "should create article with tags")
+	require.Equal(t, 7, *created.AuthorId, "should credit the token's user")
+	require.NotNil(t, created.PublishedAt, "should publish article now")
+
+	mockCreate.Unset()
+}
+
+func testApiArticlesPostValidationError(t *testing.T, ctrl ArticleController) {
+	req := httptest.NewRequest("POST", "/api/v1/articles", strings.NewReader(`{"status": "draft", "tag_ids": [1]}`))
+	req = withLoggedInUser(req, &user.UserResponseDto{Id: 7, Username: "janedoe", Role: user.RoleAuthor})
+
+	rr := httptest.NewRecorder()
+
+	validate, err := validation.NewValidator()
+	if err != nil {
+		t.Fatal("unable to construct validator")
+	}
+
+	validationErr := validate.Struct(article.ArticleNew{
+		Subtitle:  "Article subtitle",
+		Slug:      "article-slug",
+		Body:      "Article body",
+		Status:    "draft",
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		TagIds:    []int{1},
+	})
+
+	mockCreate := mockArticleService.
+		On("Create", mock.Anything).
+		Return((*article.ArticleResponseDto)(nil), validationErr)
+
+	http.HandlerFunc(ctrl.ArticlesPost).ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusUnprocessableEntity, rr.Code, "should return status code unprocessable entity")
+	require.JSONEq(t, `{
+		"error": "validation failed",
+		"fields": [{"field": "title", "message": "is required"}]
+	}`, rr.Body.String(), "should return field errors")
+
+	mockCreate.Unset()
+}
+
+func testApiArticlesPostWithoutTags(t *testing.T, ctrl ArticleController) {
+	req := httptest.NewRequest("POST", "/api/v1/articles", strings.NewReader(`{"title": "Article title", "tag_ids": []}`))
+	req = withLoggedInUser(req, &user.UserResponseDto{Id: 7, Username: "janedoe", Role: user.RoleAuthor})
+
+	rr := httptest.NewRecorder()
+
+	mockCreate := mockArticleService.
+		On("Create", mock.Anything).
+		Return((*article.ArticleResponseDto)(nil), article.ErrArticleWithoutTags)
+
+	http.HandlerFunc(ctrl.ArticlesPost).ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusUnprocessableEntity, rr.Code, "should return status code unprocessable entity")
+	require.JSONEq(t, `{
+		"error": "validation failed",
+		"fields": [{"field": "tag_ids", "message": "must have at least one tag"}]
+	}`, rr.Body.String(), "should return tags field error")
+
+	mockCreate.Unset()
+}
+
+func testApiArticlesPostInvalidJson(t *testing.T, ctrl ArticleController) {
+	req := httptest.NewRequest("POST", "/api/v1/articles", strings.NewReader(`{"title": "Article title", "author": "bob"}`))
+	req = withLoggedInUser(req, &user.UserResponseDto{Id: 7, Username: "janedoe", Role: user.RoleAuthor})
+
+	rr := httptest.NewRecorder()
+
+	http.HandlerFunc(ctrl.ArticlesPost).ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusBadRequest, rr.Code, "should return status code bad request")
+	require.Contains(t, rr.Body.String(), `unknown field \"author\"`, "should reject unknown fields")
+}
+
+func testApiArticlePutNotAuthor(t *testing.T, ctrl ArticleController) {
+	req := httptest.NewRequest("PUT", "/api/v1/articles/article-slug", strings.NewReader(`{}`))
+	req.SetPathValue("slug", "article-slug")
+	req = withLoggedInUser(req, &user.UserResponseDto{Id: 8, Username: "johndoe", Role: user.RoleAuthor})
+
+	rr := httptest.NewRecorder()
+
+	existing := testArticle(7)
+
+	mockGetByAttribute := mockArticleService.
+		On("GetByAttribute", "slug", "article-slug").
+		Return(&existing, nil)
+
+	http.HandlerFunc(ctrl.ArticlePut).ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusForbidden, rr.Code, "should return status code forbidden")
+
+	mockGetByAttribute.Unset()
+}
+
+func testApiArticleDelete(t *testing.T, ctrl ArticleController) {
+	req := httptest.NewRequest("DELETE", "/api/v1/articles/article-slug", nil)
+	req.SetPathValue("slug", "article-slug")
+	req = withLoggedInUser(req, &user.UserResponseDto{Id: 7, Username: "janedoe", Role: user.RoleAuthor})
+
+	rr := httptest.NewRecorder()
+
+	existing := testArticle(7)
+
+	mockGetByAttribute := mockArticleService.
+		On("GetByAttribute", "slug", "article-slug").
+		Return(&existing, nil)
+
+	mockDeleteById := mockArticleService.On("DeleteById", 23).Return(nil)
+
+	http.HandlerFunc(ctrl.ArticleDelete).ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusNoContent, rr.Code, "should return status code no content")
+
+	if res := mockArticleService.AssertExpectations(t); !res {
+		t.Error("should delete article")
+	}
+
+	mockGetByAttribute.Unset()
+	mockDeleteById.Unset()
+}
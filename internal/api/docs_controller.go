@@ -0,0 +1,64 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/nixpig/dunce/internal/app/errors"
+	"github.com/nixpig/dunce/pkg/logging"
+	"github.com/nixpig/dunce/pkg/templates"
+)
+
+type DocsController struct {
+	log           logging.Logger
+	templateCache templates.TemplateCache
+	errorHandlers errors.ErrorHandlers
+}
+
+type DocsControllerConfig struct {
+	Log           logging.Logger
+	TemplateCache templates.TemplateCache
+	ErrorHandlers errors.ErrorHandlers
+}
+
+type DocsView struct {
+	Path      string
+	Info      OpenApiInfo
+	Endpoints []Endpoint
+	Schemas   []NamedSchema
+}
+
+func NewDocsController(config DocsControllerConfig) DocsController {
+	return DocsController{
+		log:           config.Log,
+		templateCache: config.TemplateCache,
+		errorHandlers: config.ErrorHandlers,
+	}
+}
+
+func (d DocsController) OpenApiGet(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if _, err := w.Write(OpenApiSpec); err != nil {
+		d.log.Error(r.Context(), "unable to write openapi document", "err", err)
+	}
+}
+
+// DocsGet renders the OpenAPI document as a page, so the API can be browsed
+// without any other tools.
+func (d DocsController) DocsGet(w http.ResponseWriter, r *http.Request) {
+	openApi, err := ParseOpenApi(OpenApiSpec)
+	if err != nil {
+		d.errorHandlers.InternalServerError(w, r, fmt.Errorf("unable to parse openapi document: %w", err))
+		return
+	}
+
+	if err := d.templateCache["pages/public/api-docs.tmpl"].ExecuteTemplate(w, "public", DocsView{
+		Path:      r.URL.Path,
+		Info:      openApi.Info,
+		Endpoints: openApi.Endpoints(),
+		Schemas:   openApi.Schemas(),
+	}); err != nil {
+		d.errorHandlers.InternalServerError(w, r, err)
+	}
+}
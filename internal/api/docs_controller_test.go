@@ -0,0 +1,125 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nixpig/dunce/pkg/templates"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var mockTemplate = new(MockTemplate)
+var mockErrorHandlers = new(MockErrorHandlers)
+
+var mockTemplateCache = templates.TemplateCache{
+	"pages/public/api-docs.tmpl": mockTemplate,
+}
+
+func TestDocsController(t *testing.T) {
+	scenarios := map[string]func(t *testing.T, ctrl DocsController){
+		"get openapi document (success)": testOpenApiGet,
+		"get docs (success)":             testDocsGet,
+		"get docs (error - template)":    testDocsGetTemplateError,
+	}
+
+	for scenario, fn := range scenarios {
+		t.Run(scenario, func(t *testing.T) {
+			ctrl := NewDocsController(DocsControllerConfig{
+				Log:           mockLogger,
+				TemplateCache: mockTemplateCache,
+				ErrorHandlers: mockErrorHandlers,
+			})
+
+			fn(t, ctrl)
+		})
+	}
+}
+
+type MockTemplate struct {
+	mock.Mock
+}
+
+func (m *MockTemplate) ExecuteTemplate(wr io.Writer, name string, data any) error {
+	args := m.Called(wr, name, data)
+
+	return args.Error(0)
+}
+
+type MockErrorHandlers struct {
+	mock.Mock
+}
+
+func (e *MockErrorHandlers) NotFound(w http.ResponseWriter, r *http.Request) {
+	e.Called(w, r)
+}
+
+func (e *MockErrorHandlers) InternalServerError(w http.ResponseWriter, r *http.Request, err error) {
+	e.Called(w, r, err)
+}
+
+func (e *MockErrorHandlers) BadRequest(w http.ResponseWriter, r *http.Request) {
+	e.Called(w, r)
+}
+
+func (e *MockErrorHandlers) Forbidden(w http.ResponseWriter, r *http.Request) {
+	e.Called(w, r)
+}
+
+func testOpenApiGet(t *testing.T, ctrl DocsController) {
+	req := httptest.NewRequest("GET", "/api/openapi.json", nil)
+	rr := httptest.NewRecorder()
+
+	http.HandlerFunc(ctrl.OpenApiGet).ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code, "should return status code ok")
+	require.Equal(t, "application/json", rr.Header().Get("Content-Type"), "should return json")
+	require.Equal(t, OpenApiSpec, rr.Body.Bytes(), "should return openapi document")
+}
+
+func testDocsGet(t *testing.T, ctrl DocsController) {
+	req := httptest.NewRequest("GET", "/api/docs", nil)
+	rr := httptest.NewRecorder()
+
+	var view DocsView
+
+	mockExecuteTemplate := mockTemplate.
+		On("ExecuteTemplate", rr, "public", mock.Anything).
+		Run(func(args mock.Arguments) {
+			view = args.Get(2).(DocsView)
+		}).
+		Return(nil)
+
+	http.HandlerFunc(ctrl.DocsGet).ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code, "should return status code ok")
+	require.Equal(t, "/api/docs", view.Path, "should render docs page")
+	require.Equal(t, "dunce API", view.Info.Title, "should render title")
+	require.Equal(t, len((Controllers{}).Routes()), len(view.Endpoints), "should render every endpoint")
+	require.NotEmpty(t, view.Schemas, "should render schemas")
+
+	mockExecuteTemplate.Unset()
+}
+
+func testDocsGetTemplateError(t *testing.T, ctrl DocsController) {
+	req := httptest.NewRequest("GET", "/api/docs", nil)
+	rr := httptest.NewRecorder()
+
+	mockExecuteTemplate := mockTemplate.
+		On("ExecuteTemplate", rr, "public", mock.Anything).
+		Return(errors.New("template_error"))
+
+	mockInternalServerError := mockErrorHandlers.On("InternalServerError", rr, req, errors.New("template_error"))
+
+	http.HandlerFunc(ctrl.DocsGet).ServeHTTP(rr, req)
+
+	if res := mockErrorHandlers.AssertExpectations(t); !res {
+		t.Error("should return internal server error")
+	}
+
+	mockExecuteTemplate.Unset()
+	mockInternalServerError.Unset()
+}
@@ -0,0 +1,317 @@
+package api
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// OpenApiSpec is the OpenAPI document describing every API route and the
+// bodies they take and return.
+//
+//go:embed openapi.json
+var OpenApiSpec []byte
+
+// OpenApi is as much of an OpenAPI document as is needed to render the docs
+// page from it.
+type OpenApi struct {
+	OpenApi    string              `json:"openapi"`
+	Info       OpenApiInfo         `json:"info"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components struct {
+		Parameters map[string]Parameter `json:"parameters"`
+		Responses  map[string]Response  `json:"responses"`
+		Schemas    map[string]Schema    `json:"schemas"`
+	} `json:"components"`
+}
+
+type OpenApiInfo struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description"`
+}
+
+type PathItem struct {
+	Parameters []Parameter `json:"parameters"`
+	Get        *Operation  `json:"get"`
+	Post       *Operation  `json:"post"`
+	Put        *Operation  `json:"put"`
+	Patch      *Operation  `json:"patch"`
+	Delete     *Operation  `json:"delete"`
+}
+
+type MethodOperation struct {
+	Method    string
+	Operation *Operation
+}
+
+// Operations are the path's operations, in the order they're documented.
+func (p PathItem) Operations() []MethodOperation {
+	operations := []MethodOperation{}
+
+	for _, operation := range []MethodOperation{
+		{"GET", p.Get},
+		{"POST", p.Post},
+		{"PUT", p.Put},
+		{"PATCH", p.Patch},
+		{"DELETE", p.Delete},
+	} {
+		if operation.Operation != nil {
+			operations = append(operations, operation)
+		}
+	}
+
+	return operations
+}
+
+type Operation struct {
+	Summary     string              `json:"summary"`
+	Description string              `json:"description"`
+	Parameters  []Parameter         `json:"parameters"`
+	RequestBody *RequestBody        `json:"requestBody"`
+	Responses   map[string]Response `json:"responses"`
+}
+
+type Parameter struct {
+	Ref         string `json:"$ref"`
+	Name        string `json:"name"`
+	In          string `json:"in"`
+	Description string `json:"description"`
+	Required    bool   `json:"required"`
+	Schema      Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Content map[string]MediaType `json:"content"`
+}
+
+type Response struct {
+	Ref         string               `json:"$ref"`
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content"`
+}
+
+type MediaType struct {
+	Schema Schema `json:"schema"`
+}
+
+type Schema struct {
+	Ref         string            `json:"$ref"`
+	Type        SchemaType        `json:"type"`
+	Format      string            `json:"format"`
+	Description string            `json:"description"`
+	Enum        []string          `json:"enum"`
+	Properties  map[string]Schema `json:"properties"`
+	Required    []string          `json:"required"`
+	Items       *Schema           `json:"items"`
+}
+
+// SchemaType is a schema's type, which OpenAPI 3.1 allows to be either a
+// single type or a list of them, e.g. for nullable fields.
+type SchemaType []string
+
+func (s *SchemaType) UnmarshalJSON(b []byte) error {
+	var single string
+	if err := json.Unmarshal(b, &single); err == nil {
+		*s = SchemaType{single}
+		return nil
+	}
+
+	var many []string
+	if err := json.Unmarshal(b, &many); err != nil {
+		return err
+	}
+
+	*s = many
+
+	return nil
+}
+
+// Name is the name of the schema referred to, or a description of its type.
+func (s Schema) Name() string {
+	if s.Ref != "" {
+		return refName(s.Ref)
+	}
+
+	if slices.Contains(s.Type, "array") && s.Items != nil {
+		return "array of " + s.Items.Name()
+	}
+
+	name := strings.Join(s.Type, " or ")
+	if s.Format != "" {
+		name = fmt.Sprintf("%s (%s)", name, s.Format)
+	}
+
+	return name
+}
+
+func ParseOpenApi(spec []byte) (*OpenApi, error) {
+	var openApi OpenApi
+
+	if err := json.Unmarshal(spec, &openApi); err != nil {
+		return nil, err
+	}
+
+	return &openApi, nil
+}
+
+// Endpoint is an operation as shown on the docs page, with its parameters and
+// responses resolved from the document's components.
+type Endpoint struct {
+	Method      string
+	Path        string
+	Summary     string
+	Description string
+	Parameters  []Parameter
+	RequestBody string
+	Responses   []EndpointResponse
+}
+
+type EndpointResponse struct {
+	Status      string
+	Description string
+	Schema      string
+}
+
+type SchemaProperty struct {
+	Name        string
+	Type        string
+	Enum        []string
+	Required    bool
+	Description string
+}
+
+type NamedSchema struct {
+	Name        string
+	Description string
+	Properties  []SchemaProperty
+}
+
+// Endpoints lists every operation in the document, ordered by path.
+func (o OpenApi) Endpoints() []Endpoint {
+	paths := make([]string, 0, len(o.Paths))
+	for path := range o.Paths {
+		paths = append(paths, path)
+	}
+
+	sort.Strings(paths)
+
+	endpoints := []Endpoint{}
+
+	for _, path := range paths {
+		item := o.Paths[path]
+
+		for _, methodOperation := range item.Operations() {
+			operation := methodOperation.Operation
+
+			endpoint := Endpoint{
+				Method:      methodOperation.Method,
+				Path:        path,
+				Summary:     operation.Summary,
+				Description: operation.Description,
+			}
+
+			for _, parameter := range append(slices.Clone(item.Parameters), operation.Parameters...) {
+				endpoint.Parameters = append(endpoint.Parameters, o.parameter(parameter))
+			}
+
+			if operation.RequestBody != nil {
+				endpoint.RequestBody = operation.RequestBody.Content["application/json"].Schema.Name()
+			}
+
+			statuses := make([]string, 0, len(operation.Responses))
+			for status := range operation.Responses {
+				statuses = append(statuses, status)
+			}
+
+			sort.Strings(statuses)
+
+			for _, status := range statuses {
+				response := o.response(operation.Responses[status])
+
+				endpointResponse := EndpointResponse{
+					Status:      status,
+					Description: response.Description,
+				}
+
+				if media, ok := response.Content["application/json"]; ok {
+					endpointResponse.Schema = media.Schema.Name()
+				}
+
+				endpoint.Responses = append(endpoint.Responses, endpointResponse)
+			}
+
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+
+	return endpoints
+}
+
+// Schemas lists the document's schemas by name, with their properties in
+// alphabetical order.
+func (o OpenApi) Schemas() []NamedSchema {
+	names := make([]string, 0, len(o.Components.Schemas))
+	for name := range o.Components.Schemas {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	schemas := make([]NamedSchema, len(names))
+
+	for i, name := range names {
+		schema := o.Components.Schemas[name]
+
+		properties := make([]string, 0, len(schema.Properties))
+		for property := range schema.Properties {
+			properties = append(properties, property)
+		}
+
+		sort.Strings(properties)
+
+		schemas[i] = NamedSchema{Name: name, Description: schema.Description}
+
+		for _, property := range properties {
+			propertySchema := schema.Properties[property]
+
+			enum := propertySchema.Enum
+			if propertySchema.Items != nil {
+				enum = propertySchema.Items.Enum
+			}
+
+			schemas[i].Properties = append(schemas[i].Properties, SchemaProperty{
+				Name:        property,
+				Type:        propertySchema.Name(),
+				Enum:        enum,
+				Required:    slices.Contains(schema.Required, property),
+				Description: propertySchema.Description,
+			})
+		}
+	}
+
+	return schemas
+}
+
+func (o OpenApi) parameter(parameter Parameter) Parameter {
+	if parameter.Ref != "" {
+		return o.Components.Parameters[refName(parameter.Ref)]
+	}
+
+	return parameter
+}
+
+func (o OpenApi) response(response Response) Response {
+	if response.Ref != "" {
+		return o.Components.Responses[refName(response.Ref)]
+	}
+
+	return response
+}
+
+func refName(ref string) string {
+	return ref[strings.LastIndex(ref, "/")+1:]
+}
@@ -0,0 +1,154 @@
+package api
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/nixpig/dunce/internal/site"
+	"github.com/nixpig/dunce/internal/user"
+	"github.com/stretchr/testify/require"
+)
+
+// documentedResponses are the types the API responds with, by the name of the
+// schema describing them. Every field they always include must be required.
+var documentedResponses = map[string]any{
+	"ApiMe":         user.ApiMeResponse{},
+	"Article":       Article{},
+	"ArticleAuthor": ArticleAuthor{},
+	"ArticlePage":   PageResponse[Article]{},
+	"Tag":           Tag{},
+	"TagPage":       PageResponse[Tag]{},
+	"User":          User{},
+	"UserPage":      PageResponse[User]{},
+	"Error":         ErrorResponse{},
+	"FieldError":    FieldError{},
+}
+
+// documentedRequests are the types request bodies are read into, by the name
+// of the schema describing them.
+var documentedRequests = map[string]any{
+	"ArticleRequest":    ArticleRequest{},
+	"TagRequest":        TagRequest{},
+	"UserNewRequest":    UserNewRequest{},
+	"UserUpdateRequest": UserUpdateRequest{},
+}
+
+func TestOpenApi(t *testing.T) {
+	openApi, err := ParseOpenApi(OpenApiSpec)
+	require.NoError(t, err, "should parse openapi document")
+
+	require.Equal(t, "3.1.0", openApi.OpenApi, "should be an openapi 3.1 document")
+
+	t.Run("routes match spec", func(t *testing.T) {
+		documented := []string{}
+		for path, item := range openApi.Paths {
+			for _, operation := range item.Operations() {
+				documented = append(documented, operation.Method+" "+path)
+			}
+		}
+
+		registered := []string{}
+		for _, route := range (Controllers{}).Routes() {
+			registered = append(registered, route.Pattern())
+		}
+
+		require.ElementsMatch(t, registered, documented, "should document every route and nothing else")
+	})
+
+	t.Run("schemas match types", func(t *testing.T) {
+		for name, schema := range openApi.Components.Schemas {
+			if name == "SiteSettings" {
+				continue
+			}
+
+			response, isResponse := documentedResponses[name]
+			request, isRequest := documentedRequests[name]
+
+			require.True(t, isResponse || isRequest, "schema '%s' should describe a type", name)
+
+			if isResponse {
+				fields, always := jsonFields(reflect.TypeOf(response))
+
+				require.ElementsMatch(t, fields, propertyNames(schema), "schema '%s' should have a property for every field", name)
+				require.ElementsMatch(t, always, schema.Required, "schema '%s' should require fields that are always set", name)
+			}
+
+			if isRequest {
+				fields, _ := jsonFields(reflect.TypeOf(request))
+
+				require.ElementsMatch(t, fields, propertyNames(schema), "schema '%s' should have a property for every field", name)
+			}
+		}
+
+		for name := range documentedResponses {
+			require.Contains(t, openApi.Components.Schemas, name, "type for '%s' should be documented", name)
+		}
+
+		for name := range documentedRequests {
+			require.Contains(t, openApi.Components.Schemas, name, "type for '%s' should be documented", name)
+		}
+	})
+
+	t.Run("site settings match settings", func(t *testing.T) {
+		keys := []string{}
+		for _, setting := range site.Settings {
+			keys = append(keys, string(setting.Key))
+		}
+
+		require.ElementsMatch(t, keys, propertyNames(openApi.Components.Schemas["SiteSettings"]), "should document every site setting")
+	})
+
+	t.Run("refs resolve", func(t *testing.T) {
+		for _, endpoint := range openApi.Endpoints() {
+			for _, parameter := range endpoint.Parameters {
+				require.NotEmpty(t, parameter.Name, "parameters of '%s %s' should resolve", endpoint.Method, endpoint.Path)
+			}
+
+			for _, response := range endpoint.Responses {
+				require.NotEmpty(t, response.Description, "responses of '%s %s' should resolve", endpoint.Method, endpoint.Path)
+
+				if response.Schema != "" && !strings.Contains(response.Schema, " ") {
+					require.Contains(t, openApi.Components.Schemas, response.Schema, "schema of '%s %s' should exist", endpoint.Method, endpoint.Path)
+				}
+			}
+		}
+	})
+}
+
+// jsonFields returns the names t's fields are encoded with, along with the
+// names of those that are always encoded.
+func jsonFields(t reflect.Type) ([]string, []string) {
+	fields := []string{}
+	always := []string{}
+
+	for i := 0; i < t.NumField(); i++ {
+		tag := t.Field(i).Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name, options, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = t.Field(i).Name
+		}
+
+		fields = append(fields, name)
+
+		if !slices.Contains(strings.Split(options, ","), "omitempty") {
+			always = append(always, name)
+		}
+	}
+
+	return fields, always
+}
+
+func propertyNames(schema Schema) []string {
+	names := []string{}
+	for name := range schema.Properties {
+		names = append(names, name)
+	}
+
+	return names
+}
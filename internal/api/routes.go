@@ -0,0 +1,60 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/nixpig/dunce/internal/user"
+)
+
+// Route is an API endpoint along with the permission needed to call it. The
+// permission is checked against both the token's user and its scopes.
+type Route struct {
+	Method     string
+	Path       string
+	Permission user.Permission
+	Handler    http.HandlerFunc
+}
+
+// Pattern is the pattern to register the route with on a ServeMux.
+func (r Route) Pattern() string {
+	return r.Method + " " + r.Path
+}
+
+// Controllers are the handlers behind every API route. Me is the handler for
+// checking which token is being used, which lives with the tokens themselves.
+type Controllers struct {
+	Me       http.HandlerFunc
+	Articles ArticleController
+	Tags     TagController
+	Users    UserController
+	Site     SiteController
+}
+
+// Routes are all the API routes. Each of them is described in the OpenAPI
+// document, which is checked by the tests.
+func (c Controllers) Routes() []Route {
+	return []Route{
+		{"GET", "/api/v1/me", user.PermissionViewAdmin, c.Me},
+
+		{"GET", "/api/v1/articles", user.PermissionViewAdmin, c.Articles.ArticlesGet},
+		{"POST", "/api/v1/articles", user.PermissionWriteArticles, c.Articles.ArticlesPost},
+		{"GET", "/api/v1/articles/{slug}", user.PermissionViewAdmin, c.Articles.ArticleGet},
+		{"PUT", "/api/v1/articles/{slug}", user.PermissionWriteArticles, c.Articles.ArticlePut},
+		{"DELETE", "/api/v1/articles/{slug}", user.PermissionWriteArticles, c.Articles.ArticleDelete},
+
+		{"GET", "/api/v1/tags", user.PermissionViewAdmin, c.Tags.TagsGet},
+		{"POST", "/api/v1/tags", user.PermissionManageContent, c.Tags.TagsPost},
+		{"GET", "/api/v1/tags/{slug}", user.PermissionViewAdmin, c.Tags.TagGet},
+		{"PUT", "/api/v1/tags/{slug}", user.PermissionManageContent, c.Tags.TagPut},
+		{"DELETE", "/api/v1/tags/{slug}", user.PermissionManageContent, c.Tags.TagDelete},
+
+		{"GET", "/api/v1/users", user.PermissionManageUsers, c.Users.UsersGet},
+		{"POST", "/api/v1/users", user.PermissionManageUsers, c.Users.UsersPost},
+		{"GET", "/api/v1/users/{username}", user.PermissionManageUsers, c.Users.UserGet},
+		{"PUT", "/api/v1/users/{username}", user.PermissionManageUsers, c.Users.UserPut},
+		{"DELETE", "/api/v1/users/{username}", user.PermissionManageUsers, c.Users.UserDelete},
+
+		{"GET", "/api/v1/site", user.PermissionManageSite, c.Site.SiteGet},
+		{"PATCH", "/api/v1/site", user.PermissionManageSite, c.Site.SitePatch},
+	}
+}
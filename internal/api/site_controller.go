@@ -0,0 +1,76 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/nixpig/dunce/internal/site"
+	"github.com/nixpig/dunce/pkg/logging"
+)
+
+type SiteController struct {
+	service site.SiteService
+	log     logging.Logger
+}
+
+func NewSiteController(service site.SiteService, config ControllerConfig) SiteController {
+	return SiteController{
+		service: service,
+		log:     config.Log,
+	}
+}
+
+// SiteGet returns every site setting by key, with the default value for any
+// that haven't been set.
+func (s SiteController) SiteGet(w http.ResponseWriter, r *http.Request) {
+	settings, err := s.service.GetAll()
+	if err != nil {
+		writeServiceError(w, r, s.log, err, "get site settings")
+		return
+	}
+
+	values := make(map[site.Key]string, len(*settings))
+	for _, setting := range *settings {
+		values[setting.Key] = setting.Value
+	}
+
+	writeJson(w, r, s.log, http.StatusOK, values)
+}
+
+// SitePatch sets the settings given in the body, leaving the rest unchanged.
+// Nothing is changed if any of them are invalid.
+func (s SiteController) SitePatch(w http.ResponseWriter, r *http.Request) {
+	var body map[site.Key]string
+	if !decodeJson(w, r, s.log, &body) {
+		return
+	}
+
+	if err := s.service.Update(body); err != nil {
+		var invalidSetting *site.InvalidSettingError
+
+		if errors.As(err, &invalidSetting) {
+			writeFieldErrors(w, r, s.log, settingFieldError(invalidSetting))
+			return
+		}
+
+		writeServiceError(w, r, s.log, err, "update site settings")
+		return
+	}
+
+	s.SiteGet(w, r)
+}
+
+func settingFieldError(err *site.InvalidSettingError) FieldError {
+	fieldError := FieldError{Field: string(err.Key), Message: "is not a setting"}
+
+	var validationErrors validator.ValidationErrors
+
+	if errors.As(err, &validationErrors) && len(validationErrors) > 0 {
+		fieldError.Message = fieldMessage(validationErrors[0])
+	} else if err.Label != "" {
+		fieldError.Message = "is invalid"
+	}
+
+	return fieldError
+}
@@ -0,0 +1,160 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/nixpig/dunce/internal/site"
+	"github.com/nixpig/dunce/pkg/validation"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var mockSiteService = new(MockSiteService)
+
+func TestSiteController(t *testing.T) {
+	scenarios := map[string]func(t *testing.T, ctrl SiteController){
+		"get site (success)":                   testApiSiteGet,
+		"patch site (success)":                 testApiSitePatch,
+		"patch site (error - invalid value)":   testApiSitePatchInvalidValue,
+		"patch site (error - unknown setting)": testApiSitePatchUnknownSetting,
+	}
+
+	for scenario, fn := range scenarios {
+		t.Run(scenario, func(t *testing.T) {
+			ctrl := NewSiteController(mockSiteService, ControllerConfig{
+				Log: mockLogger,
+			})
+
+			fn(t, ctrl)
+		})
+	}
+}
+
+type MockSiteService struct {
+	mock.Mock
+}
+
+func (m *MockSiteService) Create(key site.Key, value string) (*site.SiteItemResponseDto, error) {
+	args := m.Called(key, value)
+
+	return args.Get(0).(*site.SiteItemResponseDto), args.Error(1)
+}
+
+func (m *MockSiteService) Get() (*site.Site, error) {
+	args := m.Called()
+
+	return args.Get(0).(*site.Site), args.Error(1)
+}
+
+func (m *MockSiteService) GetAll() (*[]site.SettingResponseDto, error) {
+	args := m.Called()
+
+	return args.Get(0).(*[]site.SettingResponseDto), args.Error(1)
+}
+
+func (m *MockSiteService) GetByKey(key site.Key) (*site.SettingResponseDto, error) {
+	args := m.Called(key)
+
+	return args.Get(0).(*site.SettingResponseDto), args.Error(1)
+}
+
+func (m *MockSiteService) Update(values map[site.Key]string) error {
+	args := m.Called(values)
+
+	return args.Error(0)
+}
+
+func (m *MockSiteService) DeleteByKey(key site.Key) error {
+	args := m.Called(key)
+
+	return args.Error(0)
+}
+
+var testSettings = []site.SettingResponseDto{
+	{Setting: site.Settings[0], Value: "My blog", IsSet: true},
+	{Setting: site.Settings[1], Value: ""},
+}
+
+func testApiSiteGet(t *testing.T, ctrl SiteController) {
+	req := httptest.NewRequest("GET", "/api/v1/site", nil)
+	rr := httptest.NewRecorder()
+
+	mockGetAll := mockSiteService.On("GetAll").Return(&testSettings, nil)
+
+	http.HandlerFunc(ctrl.SiteGet).ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code, "should return status code ok")
+	require.JSONEq(t, `{"name": "My blog", "tagline": ""}`, rr.Body.String(), "should return settings")
+
+	mockGetAll.Unset()
+}
+
+func testApiSitePatch(t *testing.T, ctrl SiteController) {
+	req := httptest.NewRequest("PATCH", "/api/v1/site", strings.NewReader(`{"name": "My blog"}`))
+	rr := httptest.NewRecorder()
+
+	mockUpdate := mockSiteService.On("Update", map[site.Key]string{site.NameKey: "My blog"}).Return(nil)
+	mockGetAll := mockSiteService.On("GetAll").Return(&testSettings, nil)
+
+	http.HandlerFunc(ctrl.SitePatch).ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code, "should return status code ok")
+	require.JSONEq(t, `{"name": "My blog", "tagline": ""}`, rr.Body.String(), "should return settings")
+
+	if res := mockSiteService.AssertExpectations(t); !res {
+		t.Error("should update settings")
+	}
+
+	mockUpdate.Unset()
+	mockGetAll.Unset()
+}
+
+func testApiSitePatchInvalidValue(t *testing.T, ctrl SiteController) {
+	req := httptest.NewRequest("PATCH", "/api/v1/site", strings.NewReader(`{"domain": "not a domain"}`))
+	rr := httptest.NewRecorder()
+
+	validate, err := validation.NewValidator()
+	if err != nil {
+		t.Fatal("unable to construct validator")
+	}
+
+	mockUpdate := mockSiteService.
+		On("Update", map[site.Key]string{site.DomainKey: "not a domain"}).
+		Return(&site.InvalidSettingError{
+			Key:   site.DomainKey,
+			Label: "Domain",
+			Err:   validate.Var("not a domain", "omitempty,fqdn"),
+		})
+
+	http.HandlerFunc(ctrl.SitePatch).ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusUnprocessableEntity, rr.Code, "should return status code unprocessable entity")
+	require.JSONEq(t, `{
+		"error": "validation failed",
+		"fields": [{"field": "domain", "message": "must be a domain name"}]
+	}`, rr.Body.String(), "should return setting field error")
+
+	mockUpdate.Unset()
+}
+
+func testApiSitePatchUnknownSetting(t *testing.T, ctrl SiteController) {
+	req := httptest.NewRequest("PATCH", "/api/v1/site", strings.NewReader(`{"theme": "dark"}`))
+	rr := httptest.NewRecorder()
+
+	mockUpdate := mockSiteService.
+		On("Update", map[site.Key]string{"theme": "dark"}).
+		Return(&site.InvalidSettingError{Key: "theme"})
+
+	http.HandlerFunc(ctrl.SitePatch).ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusUnprocessableEntity, rr.Code, "should return status code unprocessable entity")
+	require.JSONEq(t, `{
+		"error": "validation failed",
+		"fields": [{"field": "theme", "message": "is not a setting"}]
+	}`, rr.Body.String(), "should reject unknown setting")
+
+	mockUpdate.Unset()
+}
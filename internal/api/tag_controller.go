@@ -0,0 +1,121 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/nixpig/dunce/internal/tag"
+	"github.com/nixpig/dunce/pkg/logging"
+	"github.com/nixpig/dunce/pkg/pagination"
+)
+
+type TagController struct {
+	service tag.TagService
+	log     logging.Logger
+}
+
+type Tag struct {
+	Id   int    `json:"id"`
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+}
+
+// TagRequest is the body for creating or replacing a tag.
+type TagRequest struct {
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+}
+
+func NewTagController(service tag.TagService, config ControllerConfig) TagController {
+	return TagController{
+		service: service,
+		log:     config.Log,
+	}
+}
+
+func (t TagController) TagsGet(w http.ResponseWriter, r *http.Request) {
+	tags, err := t.service.GetAll(pagination.FromQuery(r.URL.Query()))
+	if err != nil {
+		writeServiceError(w, r, t.log, err, "list tags")
+		return
+	}
+
+	writeJson(w, r, t.log, http.StatusOK, newPageResponse(r.URL.Path, tags, newTag))
+}
+
+func (t TagController) TagGet(w http.ResponseWriter, r *http.Request) {
+	found, err := t.service.GetByAttribute("slug", r.PathValue("slug"))
+	if err != nil {
+		writeServiceError(w, r, t.log, err, "get tag")
+		return
+	}
+
+	writeJson(w, r, t.log, http.StatusOK, newTag(*found))
+}
+
+func (t TagController) TagsPost(w http.ResponseWriter, r *http.Request) {
+	var body TagRequest
+	if !decodeJson(w, r, t.log, &body) {
+		return
+	}
+
+	created, err := t.service.Create(&tag.TagNewRequestDto{
+		Name: body.Name,
+		Slug: body.Slug,
+	})
+	if err != nil {
+		writeServiceError(w, r, t.log, err, "create tag")
+		return
+	}
+
+	w.Header().Set("Location", "/api/v1/tags/"+created.Slug)
+
+	writeJson(w, r, t.log, http.StatusCreated, newTag(*created))
+}
+
+func (t TagController) TagPut(w http.ResponseWriter, r *http.Request) {
+	existing, err := t.service.GetByAttribute("slug", r.PathValue("slug"))
+	if err != nil {
+		writeServiceError(w, r, t.log, err, "get tag")
+		return
+	}
+
+	var body TagRequest
+	if !decodeJson(w, r, t.log, &body) {
+		return
+	}
+
+	updated, err := t.service.Update(&tag.TagUpdateRequestDto{
+		Id:   existing.Id,
+		Name: body.Name,
+		Slug: body.Slug,
+	})
+	if err != nil {
+		writeServiceError(w, r, t.log, err, "update tag")
+		return
+	}
+
+	writeJson(w, r, t.log, http.StatusOK, newTag(*updated))
+}
+
+func (t TagController) TagDelete(w http.ResponseWriter, r *http.Request) {
+	existing, err := t.service.GetByAttribute("slug", r.PathValue("slug"))
+	if err != nil {
+		writeServiceError(w, r, t.log, err, "get tag")
+		return
+	}
+
+	if err := t.service.DeleteById(existing.Id); err != nil {
+		writeServiceError(w, r, t.log, err, "delete tag")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func newTag(t tag.TagResponseDto) Tag {
+	return Tag{
+		Id:   t.Id,
+		Name: t.Name,
+		Slug: t.Slug,
+	}
+}
@@ -0,0 +1,204 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/nixpig/dunce/internal/tag"
+	"github.com/nixpig/dunce/pkg/pagination"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var mockTagService = new(MockTagService)
+
+func TestTagController(t *testing.T) {
+	scenarios := map[string]func(t *testing.T, ctrl TagController){
+		"list tags (success)":            testApiTagsGet,
+		"create tag (success)":           testApiTagsPost,
+		"create tag (error - duplicate)": testApiTagsPostDuplicate,
+		"update tag (success)":           testApiTagPut,
+		"delete tag (error - not found)": testApiTagDeleteNotFound,
+		"delete tag (error - db error)":  testApiTagDeleteDbError,
+	}
+
+	for scenario, fn := range scenarios {
+		t.Run(scenario, func(t *testing.T) {
+			ctrl := NewTagController(mockTagService, ControllerConfig{
+				Log: mockLogger,
+			})
+
+			fn(t, ctrl)
+		})
+	}
+}
+
+type MockTagService struct {
+	mock.Mock
+}
+
+func (m *MockTagService) Create(t *tag.TagNewRequestDto) (*tag.TagResponseDto, error) {
+	args := m.Called(t)
+
+	return args.Get(0).(*tag.TagResponseDto), args.Error(1)
+}
+
+func (m *MockTagService) DeleteById(id int) error {
+	args := m.Called(id)
+
+	return args.Error(0)
+}
+
+func (m *MockTagService) GetAll(page pagination.Request) (*pagination.Page[tag.TagResponseDto], error) {
+	args := m.Called(page)
+
+	return args.Get(0).(*pagination.Page[tag.TagResponseDto]), args.Error(1)
+}
+
+func (m *MockTagService) GetByAttribute(attr, value string) (*tag.TagResponseDto, error) {
+	args := m.Called(attr, value)
+
+	return args.Get(0).(*tag.TagResponseDto), args.Error(1)
+}
+
+func (m *MockTagService) Update(t *tag.TagUpdateRequestDto) (*tag.TagResponseDto, error) {
+	args := m.Called(t)
+
+	return args.Get(0).(*tag.TagResponseDto), args.Error(1)
+}
+
+func testApiTagsGet(t *testing.T, ctrl TagController) {
+	req := httptest.NewRequest("GET", "/api/v1/tags?offset=20", nil)
+	rr := httptest.NewRecorder()
+
+	mockGetAll := mockTagService.
+		On("GetAll", pagination.Request{Offset: 20}).
+		Return(&pagination.Page[tag.TagResponseDto]{
+			Items:   []tag.TagResponseDto{{Id: 1, Name: "Go", Slug: "go"}},
+			Total:   21,
+			Request: pagination.Request{Limit: 20, Offset: 20, Sort: "name", Direction: pagination.Asc},
+		}, nil)
+
+	http.HandlerFunc(ctrl.TagsGet).ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code, "should return status code ok")
+	require.JSONEq(t, `{
+		"items": [{"id": 1, "name": "Go", "slug": "go"}],
+		"total": 21,
+		"limit": 20,
+		"offset": 20,
+		"next": null,
+		"prev": null
+	}`, rr.Body.String(), "should return page of tags")
+
+	mockGetAll.Unset()
+}
+
+func testApiTagsPost(t *testing.T, ctrl TagController) {
+	req := httptest.NewRequest("POST", "/api/v1/tags", strings.NewReader(`{"name": "Go", "slug": "go"}`))
+	rr := httptest.NewRecorder()
+
+	mockCreate := mockTagService.
+		On("Create", &tag.TagNewRequestDto{Name: "Go", Slug: "go"}).
+		Return(&tag.TagResponseDto{Id: 1, Name: "Go", Slug: "go"}, nil)
+
+	http.HandlerFunc(ctrl.TagsPost).ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusCreated, rr.Code, "should return status code created")
+	require.Equal(t, "/api/v1/tags/go", rr.Header().Get("Location"), "should link to tag")
+	require.JSONEq(t, `{"id": 1, "name": "Go", "slug": "go"}`, rr.Body.String(), "should return created tag")
+
+	mockCreate.Unset()
+}
+
+func testApiTagsPostDuplicate(t *testing.T, ctrl TagController) {
+	req := httptest.NewRequest("POST", "/api/v1/tags", strings.NewReader(`{"name": "Go", "slug": "go"}`))
+	rr := httptest.NewRecorder()
+
+	mockCreate := mockTagService.
+		On("Create", &tag.TagNewRequestDto{Name: "Go", Slug: "go"}).
+		Return((*tag.TagResponseDto)(nil), &pgconn.PgError{Code: "23505"})
+
+	http.HandlerFunc(ctrl.TagsPost).ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusConflict, rr.Code, "should return status code conflict")
+
+	mockCreate.Unset()
+}
+
+func testApiTagPut(t *testing.T, ctrl TagController) {
+	req := httptest.NewRequest("PUT", "/api/v1/tags/go", strings.NewReader(`{"name": "Golang", "slug": "golang"}`))
+	req.SetPathValue("slug", "go")
+
+	rr := httptest.NewRecorder()
+
+	mockGetByAttribute := mockTagService.
+		On("GetByAttribute", "slug", "go").
+		Return(&tag.TagResponseDto{Id: 1, Name: "Go", Slug: "go"}, nil)
+
+	mockUpdate := mockTagService.
+		On("Update", &tag.TagUpdateRequestDto{Id: 1, Name: "Golang", Slug: "golang"}).
+		Return(&tag.TagResponseDto{Id: 1, Name: "Golang", Slug: "golang"}, nil)
+
+	http.HandlerFunc(ctrl.TagPut).ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code, "should return status code ok")
+	require.JSONEq(t, `{"id": 1, "name": "Golang", "slug": "golang"}`, rr.Body.String(), "should return updated tag")
+
+	mockGetByAttribute.Unset()
+	mockUpdate.Unset()
+}
+
+func testApiTagDeleteNotFound(t *testing.T, ctrl TagController) {
+	req := httptest.NewRequest("DELETE", "/api/v1/tags/go", nil)
+	req.SetPathValue("slug", "go")
+
+	rr := httptest.NewRecorder()
+
+	mockGetByAttribute := mockTagService.
+		On("GetByAttribute", "slug", "go").
+		Return((*tag.TagResponseDto)(nil), pgx.ErrNoRows)
+
+	http.HandlerFunc(ctrl.TagDelete).ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusNotFound, rr.Code, "should return status code not found")
+
+	mockGetByAttribute.Unset()
+}
+
+func testApiTagDeleteDbError(t *testing.T, ctrl TagController) {
+	req := httptest.NewRequest("DELETE", "/api/v1/tags/go", nil)
+	req.SetPathValue("slug", "go")
+
+	rr := httptest.NewRecorder()
+
+	mockGetByAttribute := mockTagService.
+		On("GetByAttribute", "slug", "go").
+		Return(&tag.TagResponseDto{Id: 1, Name: "Go", Slug: "go"}, nil)
+
+	mockDeleteById := mockTagService.On("DeleteById", 1).Return(errTest)
+
+	mockLoggerError := mockLogger.On("Error", "internal server error", []any{
+		"method", "DELETE",
+		"path", "/api/v1/tags/go",
+		"err", fmt.Errorf("unable to delete tag: %w", errTest),
+	})
+
+	http.HandlerFunc(ctrl.TagDelete).ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusInternalServerError, rr.Code, "should return status code internal server error")
+	require.JSONEq(t, `{"error": "internal server error"}`, rr.Body.String(), "should not leak error")
+
+	if res := mockLogger.AssertExpectations(t); !res {
+		t.Error("should log error")
+	}
+
+	mockGetByAttribute.Unset()
+	mockDeleteById.Unset()
+	mockLoggerError.Unset()
+}
@@ -0,0 +1,180 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/nixpig/dunce/internal/user"
+	"github.com/nixpig/dunce/pkg/logging"
+	"github.com/nixpig/dunce/pkg/pagination"
+)
+
+type UserController struct {
+	service  user.UserService
+	validate *validator.Validate
+	log      logging.Logger
+}
+
+// User is everything about a user except their password.
+type User struct {
+	Id               uint      `json:"id"`
+	Username         string    `json:"username"`
+	Email            string    `json:"email"`
+	Role             user.Role `json:"role"`
+	DisplayName      string    `json:"display_name"`
+	Bio              string    `json:"bio"`
+	AvatarUrl        string    `json:"avatar_url"`
+	TwoFactorEnabled bool      `json:"two_factor_enabled"`
+}
+
+type UserNewRequest struct {
+	Username string    `json:"username"`
+	Email    string    `json:"email"`
+	Password string    `json:"password"`
+	Role     user.Role `json:"role"`
+}
+
+// UserUpdateRequest is the body for replacing a user. Passwords can only be
+// changed by the user themselves, from their account page.
+type UserUpdateRequest struct {
+	Username    string    `json:"username"`
+	Email       string    `json:"email"`
+	Role        user.Role `json:"role"`
+	DisplayName string    `json:"display_name"`
+	Bio         string    `json:"bio"`
+	AvatarUrl   string    `json:"avatar_url"`
+}
+
+func NewUserController(
+	service user.UserService,
+	validate *validator.Validate,
+	config ControllerConfig,
+) UserController {
+	return UserController{
+		service:  service,
+		validate: validate,
+		log:      config.Log,
+	}
+}
+
+func (u UserController) UsersGet(w http.ResponseWriter, r *http.Request) {
+	users, err := u.service.GetAll(pagination.FromQuery(r.URL.Query()))
+	if err != nil {
+		writeServiceError(w, r, u.log, err, "list users")
+		return
+	}
+
+	writeJson(w, r, u.log, http.StatusOK, newPageResponse(r.URL.Path, users, newUser))
+}
+
+func (u UserController) UserGet(w http.ResponseWriter, r *http.Request) {
+	found, err := u.service.GetByAttribute("username", r.PathValue("username"))
+	if err != nil {
+		writeServiceError(w, r, u.log, err, "get user")
+		return
+	}
+
+	writeJson(w, r, u.log, http.StatusOK, newUser(*found))
+}
+
+func (u UserController) UsersPost(w http.ResponseWriter, r *http.Request) {
+	var body UserNewRequest
+	if !decodeJson(w, r, u.log, &body) {
+		return
+	}
+
+	newUserDto := user.UserNewRequestDto{
+		Username: body.Username,
+		Email:    body.Email,
+		Password: body.Password,
+		Role:     body.Role,
+	}
+
+	// the service only validates the user after hashing their password, by
+	// which point an empty password has become a hash
+	if err := u.validate.Struct(newUserDto); err != nil {
+		writeServiceError(w, r, u.log, err, "validate user")
+		return
+	}
+
+	created, err := u.service.Create(&newUserDto)
+	if err != nil {
+		writeServiceError(w, r, u.log, err, "create user")
+		return
+	}
+
+	w.Header().Set("Location", "/api/v1/users/"+created.Username)
+
+	writeJson(w, r, u.log, http.StatusCreated, newUser(*created))
+}
+
+func (u UserController) UserPut(w http.ResponseWriter, r *http.Request) {
+	existing, err := u.service.GetByAttribute("username", r.PathValue("username"))
+	if err != nil {
+		writeServiceError(w, r, u.log, err, "get user")
+		return
+	}
+
+	var body UserUpdateRequest
+	if !decodeJson(w, r, u.log, &body) {
+		return
+	}
+
+	updated, err := u.service.Update(&user.User{
+		Id:       existing.Id,
+		Username: body.Username,
+		Email:    body.Email,
+		Role:     body.Role,
+		Profile: user.Profile{
+			DisplayName: body.DisplayName,
+			Bio:         body.Bio,
+			AvatarUrl:   body.AvatarUrl,
+		},
+	})
+	if err != nil {
+		if err == user.ErrLastAdmin {
+			writeError(w, r, u.log, http.StatusConflict, err.Error())
+			return
+		}
+
+		writeServiceError(w, r, u.log, err, "update user")
+		return
+	}
+
+	updated.TwoFactorEnabled = existing.TwoFactorEnabled
+
+	writeJson(w, r, u.log, http.StatusOK, newUser(*updated))
+}
+
+func (u UserController) UserDelete(w http.ResponseWriter, r *http.Request) {
+	existing, err := u.service.GetByAttribute("username", r.PathValue("username"))
+	if err != nil {
+		writeServiceError(w, r, u.log, err, "get user")
+		return
+	}
+
+	if err := u.service.DeleteById(existing.Id); err != nil {
+		if err == user.ErrLastAdmin {
+			writeError(w, r, u.log, http.StatusConflict, err.Error())
+			return
+		}
+
+		writeServiceError(w, r, u.log, err, "delete user")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func newUser(u user.UserResponseDto) User {
+	return User{
+		Id:               u.Id,
+		Username:         u.Username,
+		Email:            u.Email,
+		Role:             u.Role,
+		DisplayName:      u.Profile.DisplayName,
+		Bio:              u.Profile.Bio,
+		AvatarUrl:        u.Profile.AvatarUrl,
+		TwoFactorEnabled: u.TwoFactorEnabled,
+	}
+}
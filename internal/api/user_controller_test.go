@@ -0,0 +1,330 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/nixpig/dunce/internal/user"
+	"github.com/nixpig/dunce/pkg/pagination"
+	"github.com/nixpig/dunce/pkg/validation"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var mockUserService = new(MockUserService)
+
+func TestUserController(t *testing.T) {
+	scenarios := map[string]func(t *testing.T, ctrl UserController){
+		"get user (success)":               testApiUserGet,
+		"get user (error - not found)":     testApiUserGetNotFound,
+		"create user (success)":            testApiUsersPost,
+		"create user (error - validation)": testApiUsersPostValidationError,
+		"update user (success)":            testApiUserPut,
+		"update user (error - last admin)": testApiUserPutLastAdmin,
+		"delete user (success)":            testApiUserDelete,
+		"delete user (error - last admin)": testApiUserDeleteLastAdmin,
+	}
+
+	for scenario, fn := range scenarios {
+		t.Run(scenario, func(t *testing.T) {
+			validate, err := validation.NewValidator()
+			if err != nil {
+				t.Fatal("unable to construct validator")
+			}
+
+			ctrl := NewUserController(mockUserService, validate, ControllerConfig{
+				Log: mockLogger,
+			})
+
+			fn(t, ctrl)
+		})
+	}
+}
+
+type MockUserService struct {
+	mock.Mock
+}
+
+func (m *MockUserService) Create(u *user.UserNewRequestDto) (*user.UserResponseDto, error) {
+	args := m.Called(u)
+
+	return args.Get(0).(*user.UserResponseDto), args.Error(1)
+}
+
+func (m *MockUserService) DeleteById(id uint) error {
+	args := m.Called(id)
+
+	return args.Error(0)
+}
+
+func (m *MockUserService) Exists(username string) (bool, error) {
+	args := m.Called(username)
+
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserService) GetAll(page pagination.Request) (*pagination.Page[user.UserResponseDto], error) {
+	args := m.Called(page)
+
+	return args.Get(0).(*pagination.Page[user.UserResponseDto]), args.Error(1)
+}
+
+func (m *MockUserService) GetByAttribute(attr, value string) (*user.UserResponseDto, error) {
+	args := m.Called(attr, value)
+
+	return args.Get(0).(*user.UserResponseDto), args.Error(1)
+}
+
+func (m *MockUserService) Update(u *user.User) (*user.UserResponseDto, error) {
+	args := m.Called(u)
+
+	return args.Get(0).(*user.UserResponseDto), args.Error(1)
+}
+
+func (m *MockUserService) UpdateRole(id uint, role user.Role) (*user.UserResponseDto, error) {
+	args := m.Called(id, role)
+
+	return args.Get(0).(*user.UserResponseDto), args.Error(1)
+}
+
+func (m *MockUserService) UpdateProfile(id uint, profile *user.Profile) (*user.UserResponseDto, error) {
+	args := m.Called(id, profile)
+
+	return args.Get(0).(*user.UserResponseDto), args.Error(1)
+}
+
+func (m *MockUserService) ChangePassword(username string, change *user.UserPasswordChangeRequestDto) (int, error) {
+	args := m.Called(username, change)
+
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockUserService) LoginWithUsernamePassword(username, password string) error {
+	args := m.Called(username, password)
+
+	return args.Error(0)
+}
+
+func testApiUserGet(t *testing.T, ctrl UserController) {
+	req := httptest.NewRequest("GET", "/api/v1/users/janedoe", nil)
+	req.SetPathValue("username", "janedoe")
+
+	rr := httptest.NewRecorder()
+
+	mockGetByAttribute := mockUserService.
+		On("GetByAttribute", "username", "janedoe").
+		Return(&user.UserResponseDto{
+			Id:               23,
+			Username:         "janedoe",
+			Email:            "jane@example.org",
+			Role:             user.RoleEditor,
+			Profile:          user.Profile{DisplayName: "Jane Doe"},
+			SessionVersion:   3,
+			TwoFactorEnabled: true,
+		}, nil)
+
+	http.HandlerFunc(ctrl.UserGet).ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code, "should return status code ok")
+	require.JSONEq(t, `{
+		"id": 23,
+		"username": "janedoe",
+		"email": "jane@example.org",
+		"role": "editor",
+		"display_name": "Jane Doe",
+		"bio": "",
+		"avatar_url": "",
+		"two_factor_enabled": true
+	}`, rr.Body.String(), "should return user")
+
+	mockGetByAttribute.Unset()
+}
+
+func testApiUserGetNotFound(t *testing.T, ctrl UserController) {
+	req := httptest.NewRequest("GET", "/api/v1/users/janedoe", nil)
+	req.SetPathValue("username", "janedoe")
+
+	rr := httptest.NewRecorder()
+
+	mockGetByAttribute := mockUserService.
+		On("GetByAttribute", "username", "janedoe").
+		Return((*user.UserResponseDto)(nil), pgx.ErrNoRows)
+
+	http.HandlerFunc(ctrl.UserGet).ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusNotFound, rr.Code, "should return status code not found")
+
+	mockGetByAttribute.Unset()
+}
+
+func testApiUsersPost(t *testing.T, ctrl UserController) {
+	req := httptest.NewRequest("POST", "/api/v1/users", strings.NewReader(`{
+		"username": "janedoe",
+		"email": "jane@example.org",
+		"password": "p4ssw0rd",
+		"role": "author"
+	}`))
+
+	rr := httptest.NewRecorder()
+
+	mockCreate := mockUserService.
+		On("Create", &user.UserNewRequestDto{
+			Username: "janedoe",
+			Email:    "jane@example.org",
+			Password: "p4ssw0rd",
+			Role:     user.RoleAuthor,
+		}).
+		Return(&user.UserResponseDto{
+			Id:       23,
+			Username: "janedoe",
+			Email:    "jane@example.org",
+			Role:     user.RoleAuthor,
+		}, nil)
+
+	http.HandlerFunc(ctrl.UsersPost).ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusCreated, rr.Code, "should return status code created")
+	require.Equal(t, "/api/v1/users/janedoe", rr.Header().Get("Location"), "should link to user")
+	require.NotContains(t, rr.Body.String(), "p4ssw0rd", "should not return password")
+
+	mockCreate.Unset()
+}
+
+func testApiUsersPostValidationError(t *testing.T, ctrl UserController) {
+	req := httptest.NewRequest("POST", "/api/v1/users", strings.NewReader(`{
+		"username": "janedoe",
+		"email": "jane@example.org",
+		"role": "author"
+	}`))
+
+	rr := httptest.NewRecorder()
+
+	http.HandlerFunc(ctrl.UsersPost).ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusUnprocessableEntity, rr.Code, "should return status code unprocessable entity")
+	require.JSONEq(t, `{
+		"error": "validation failed",
+		"fields": [{"field": "password", "message": "is required"}]
+	}`, rr.Body.String(), "should require a password")
+}
+
+func testApiUserPut(t *testing.T, ctrl UserController) {
+	req := httptest.NewRequest("PUT", "/api/v1/users/janedoe", strings.NewReader(`{
+		"username": "janedoe",
+		"email": "jane@example.com",
+		"role": "editor",
+		"display_name": "Jane Doe"
+	}`))
+	req.SetPathValue("username", "janedoe")
+
+	rr := httptest.NewRecorder()
+
+	mockGetByAttribute := mockUserService.
+		On("GetByAttribute", "username", "janedoe").
+		Return(&user.UserResponseDto{Id: 23, Username: "janedoe", Role: user.RoleAuthor, TwoFactorEnabled: true}, nil)
+
+	mockUpdate := mockUserService.
+		On("Update", &user.User{
+			Id:       23,
+			Username: "janedoe",
+			Email:    "jane@example.com",
+			Role:     user.RoleEditor,
+			Profile:  user.Profile{DisplayName: "Jane Doe"},
+		}).
+		Return(&user.UserResponseDto{
+			Id:       23,
+			Username: "janedoe",
+			Email:    "jane@example.com",
+			Role:     user.RoleEditor,
+			Profile:  user.Profile{DisplayName: "Jane Doe"},
+		}, nil)
+
+	http.HandlerFunc(ctrl.UserPut).ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code, "should return status code ok")
+	require.Contains(t, rr.Body.String(), `"role":"editor"`, "should return updated user")
+	require.Contains(t, rr.Body.String(), `"two_factor_enabled":true`, "should keep two-factor status")
+
+	mockGetByAttribute.Unset()
+	mockUpdate.Unset()
+}
+
+func testApiUserPutLastAdmin(t *testing.T, ctrl UserController) {
+	req := httptest.NewRequest("PUT", "/api/v1/users/admin", strings.NewReader(`{
+		"username": "admin",
+		"email": "admin@example.com",
+		"role": "viewer"
+	}`))
+	req.SetPathValue("username", "admin")
+
+	rr := httptest.NewRecorder()
+
+	mockGetByAttribute := mockUserService.
+		On("GetByAttribute", "username", "admin").
+		Return(&user.UserResponseDto{Id: 1, Username: "admin", Role: user.RoleAdmin}, nil)
+
+	mockUpdate := mockUserService.
+		On("Update", &user.User{
+			Id:       1,
+			Username: "admin",
+			Email:    "admin@example.com",
+			Role:     user.RoleViewer,
+		}).
+		Return((*user.UserResponseDto)(nil), user.ErrLastAdmin)
+
+	http.HandlerFunc(ctrl.UserPut).ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusConflict, rr.Code, "should return status code conflict")
+	require.JSONEq(t, `{"error":"the site needs at least one admin"}`, rr.Body.String(), "should return error")
+
+	mockGetByAttribute.Unset()
+	mockUpdate.Unset()
+}
+
+func testApiUserDeleteLastAdmin(t *testing.T, ctrl UserController) {
+	req := httptest.NewRequest("DELETE", "/api/v1/users/admin", nil)
+	req.SetPathValue("username", "admin")
+
+	rr := httptest.NewRecorder()
+
+	mockGetByAttribute := mockUserService.
+		On("GetByAttribute", "username", "admin").
+		Return(&user.UserResponseDto{Id: 1, Username: "admin", Role: user.RoleAdmin}, nil)
+
+	mockDeleteById := mockUserService.On("DeleteById", uint(1)).Return(user.ErrLastAdmin)
+
+	http.HandlerFunc(ctrl.UserDelete).ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusConflict, rr.Code, "should return status code conflict")
+	require.JSONEq(t, `{"error":"the site needs at least one admin"}`, rr.Body.String(), "should return error")
+
+	mockGetByAttribute.Unset()
+	mockDeleteById.Unset()
+}
+
+func testApiUserDelete(t *testing.T, ctrl UserController) {
+	req := httptest.NewRequest("DELETE", "/api/v1/users/janedoe", nil)
+	req.SetPathValue("username", "janedoe")
+
+	rr := httptest.NewRecorder()
+
+	mockGetByAttribute := mockUserService.
+		On("GetByAttribute", "username", "janedoe").
+		Return(&user.UserResponseDto{Id: 23, Username: "janedoe"}, nil)
+
+	mockDeleteById := mockUserService.On("DeleteById", uint(23)).Return(nil)
+
+	http.HandlerFunc(ctrl.UserDelete).ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusNoContent, rr.Code, "should return status code no content")
+
+	if res := mockUserService.AssertExpectations(t); !res {
+		t.Error("should delete user")
+	}
+
+	mockGetByAttribute.Unset()
+	mockDeleteById.Unset()
+}
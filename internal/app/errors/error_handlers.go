@@ -3,47 +3,64 @@ package errors
 import (
 	"net/http"
 
+	"github.com/nixpig/dunce/pkg/logging"
 	"github.com/nixpig/dunce/pkg/templates"
 )
 
 type ErrorHandlers interface {
 	NotFound(w http.ResponseWriter, r *http.Request)
-	InternalServerError(w http.ResponseWriter, r *http.Request)
+	// InternalServerError logs err, which caused the request to fail, along
+	// with the request's route, user and ID.
+	InternalServerError(w http.ResponseWriter, r *http.Request, err error)
 	BadRequest(w http.ResponseWriter, r *http.Request)
+	Forbidden(w http.ResponseWriter, r *http.Request)
 }
 
 type ErrorHandlersImpl struct {
 	templateCache templates.TemplateCache
+	log           logging.Logger
 }
 
 type ErrorView struct {
+	Path    string
 	Title   string
 	Message string
 }
 
-func NewErrorHandlersImpl(templateCache templates.TemplateCache) ErrorHandlers {
-	return ErrorHandlersImpl{templateCache}
+func NewErrorHandlersImpl(templateCache templates.TemplateCache, log logging.Logger) ErrorHandlers {
+	return ErrorHandlersImpl{templateCache, log}
 }
 
 func (e ErrorHandlersImpl) NotFound(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNotFound)
 	if err := e.templateCache["pages/errors/error.tmpl"].
 		ExecuteTemplate(w, "public", ErrorView{
+			Path:    r.URL.Path,
 			Title:   "404 Not Found",
 			Message: "Unable to find the requested resource.",
 		}); err != nil {
-		e.InternalServerError(w, r)
+		e.InternalServerError(w, r, err)
 	}
 }
 
-func (e ErrorHandlersImpl) InternalServerError(w http.ResponseWriter, r *http.Request) {
+func (e ErrorHandlersImpl) InternalServerError(w http.ResponseWriter, r *http.Request, err error) {
+	e.log.Error(
+		r.Context(),
+		"internal server error",
+		"method", r.Method,
+		"path", r.URL.Path,
+		"err", err,
+	)
+
 	w.WriteHeader(http.StatusInternalServerError)
 
 	if err := e.templateCache["pages/errors/error.tmpl"].
 		ExecuteTemplate(w, "public", ErrorView{
+			Path:    r.URL.Path,
 			Title:   "500 Internal Server Error",
 			Message: "Something went wrong. Please try again.",
 		}); err != nil {
+		e.log.Error(r.Context(), "unable to render error page", "err", err)
 		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 	}
 }
@@ -52,9 +69,22 @@ func (e ErrorHandlersImpl) BadRequest(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusBadRequest)
 	if err := e.templateCache["pages/errors/error.tmpl"].
 		ExecuteTemplate(w, "public", ErrorView{
+			Path:    r.URL.Path,
 			Title:   "400 Bad Request",
 			Message: "There was something wrong with your request. Please check and try again.",
 		}); err != nil {
-		e.InternalServerError(w, r)
+		e.InternalServerError(w, r, err)
+	}
+}
+
+func (e ErrorHandlersImpl) Forbidden(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusForbidden)
+	if err := e.templateCache["pages/errors/error.tmpl"].
+		ExecuteTemplate(w, "public", ErrorView{
+			Path:    r.URL.Path,
+			Title:   "403 Forbidden",
+			Message: "You don't have permission to do that.",
+		}); err != nil {
+		e.InternalServerError(w, r, err)
 	}
 }
@@ -2,13 +2,15 @@ package app
 
 import "net/http"
 
-func publicRootHandler(next http.HandlerFunc) http.HandlerFunc {
+// publicRootHandler serves the home page at the root, and hands every other
+// path to pages, which are served at the root too.
+func publicRootHandler(home, pages http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path != "/" && r.URL.Path != "" {
-			http.Error(w, "Not Found", 404)
+			pages(w, r)
 			return
 		}
 
-		next(w, r)
+		home(w, r)
 	}
 }
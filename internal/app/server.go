@@ -1,20 +1,32 @@
 package app
 
 import (
+	"context"
 	"fmt"
+	"log"
+	"net"
 	"net/http"
 	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/nixpig/dunce/db"
+	"github.com/nixpig/dunce/internal/api"
 	"github.com/nixpig/dunce/internal/app/errors"
 	"github.com/nixpig/dunce/internal/article"
+	"github.com/nixpig/dunce/internal/config"
+	"github.com/nixpig/dunce/internal/feed"
+	"github.com/nixpig/dunce/internal/health"
 	"github.com/nixpig/dunce/internal/home"
+	"github.com/nixpig/dunce/internal/menu"
+	"github.com/nixpig/dunce/internal/page"
 	"github.com/nixpig/dunce/internal/site"
+	"github.com/nixpig/dunce/internal/sitemap"
 	"github.com/nixpig/dunce/internal/tag"
 	"github.com/nixpig/dunce/internal/user"
 	"github.com/nixpig/dunce/pkg/crypto"
 	"github.com/nixpig/dunce/pkg/logging"
+	"github.com/nixpig/dunce/pkg/mail"
+	"github.com/nixpig/dunce/pkg/metrics"
 	"github.com/nixpig/dunce/pkg/middleware"
 	"github.com/nixpig/dunce/pkg/session"
 	"github.com/nixpig/dunce/pkg/templates"
@@ -22,17 +34,31 @@ import (
 )
 
 type AppConfig struct {
-	Port           string
-	Validator      *validator.Validate
-	Db             *db.Dbpool
-	TemplateCache  templates.TemplateCache
-	Logger         logging.Logger
-	SessionManager session.SessionManager
-	CsrfToken      func(*http.Request) string
-	ErrorHandlers  errors.ErrorHandlers
+	Config          config.Config
+	Validator       *validator.Validate
+	Db              *db.Dbpool
+	TemplateCache   templates.TemplateCache
+	Logger          logging.Logger
+	ErrorLog        *log.Logger
+	SessionManager  session.SessionManager
+	Sessions        session.SessionList
+	CsrfToken       func(*http.Request) string
+	ErrorHandlers   errors.ErrorHandlers
+	SiteService     site.SiteService
+	MenuService     menu.MenuService
+	Mailer          mail.Mailer
+	Listener        net.Listener
+	MetricsListener net.Listener
+
+	// Ready, if set, is called once the app's serving, e.g. to tell the
+	// process it took over the listeners from that it can shut down
+	Ready func()
 }
 
-func Start(appConfig AppConfig) error {
+// Start serves the app on appConfig.Listener until ctx is cancelled, then
+// shuts down gracefully, giving requests in flight until the shutdown timeout
+// to finish and stopping background workers before it returns.
+func Start(ctx context.Context, appConfig AppConfig) error {
 	mux := http.NewServeMux()
 
 	crypt := crypto.NewCryptoImpl(
@@ -40,8 +66,9 @@ func Start(appConfig AppConfig) error {
 		bcrypt.CompareHashAndPassword,
 	)
 
-	siteRepo := site.NewSitePostgresRepository(appConfig.Db.Pool)
-	siteService := site.NewSiteService(siteRepo)
+	// the site and menu services are built along with the template cache,
+	// which reads the site settings and menu through them
+	siteService := appConfig.SiteService
 	siteController := site.NewSiteController(siteService, site.SiteControllerConfig{
 		Log:            appConfig.Logger,
 		TemplateCache:  appConfig.TemplateCache,
@@ -50,9 +77,98 @@ func Start(appConfig AppConfig) error {
 		ErrorHandlers:  appConfig.ErrorHandlers,
 	})
 
+	menuController := menu.NewMenuController(appConfig.MenuService, menu.MenuControllerConfig{
+		Log:            appConfig.Logger,
+		TemplateCache:  appConfig.TemplateCache,
+		SessionManager: appConfig.SessionManager,
+		CsrfToken:      appConfig.CsrfToken,
+		ErrorHandlers:  appConfig.ErrorHandlers,
+	})
+
 	userRepo := user.NewUserPostgresRepository(appConfig.Db.Pool)
-	userService := user.NewUserService(userRepo, appConfig.Validator, crypt)
-	userController := user.NewUserController(userService, user.UserControllerConfig{
+	userService := user.NewUserService(userRepo, appConfig.Validator, crypt, appConfig.Config.Security.BcryptCost)
+	loginThrottleService := user.NewLoginThrottleService(
+		user.NewLoginThrottlePostgresRepository(appConfig.Db.Pool),
+		appConfig.Logger,
+		user.LoginThrottlePolicy{
+			FreeAttempts: 5,
+			BaseDelay:    30 * time.Second,
+			MaxDelay:     time.Hour,
+			Window:       24 * time.Hour,
+		},
+		// clients get more attempts, since many people can share an address
+		user.LoginThrottlePolicy{
+			FreeAttempts: 20,
+			BaseDelay:    30 * time.Second,
+			MaxDelay:     time.Hour,
+			Window:       time.Hour,
+		},
+	)
+	userController := user.NewUserController(userService, loginThrottleService, user.UserControllerConfig{
+		Log:            appConfig.Logger,
+		TemplateCache:  appConfig.TemplateCache,
+		SessionManager: appConfig.SessionManager,
+		CsrfToken:      appConfig.CsrfToken,
+		ErrorHandlers:  appConfig.ErrorHandlers,
+	})
+
+	passwordResetService := user.NewPasswordResetService(
+		user.NewPasswordResetPostgresRepository(appConfig.Db.Pool),
+		siteService,
+		appConfig.Validator,
+		crypt,
+		appConfig.Config.Security.BcryptCost,
+		appConfig.Mailer,
+		appConfig.Logger,
+		time.Hour,
+	)
+	passwordResetThrottleService := user.NewPasswordResetThrottleService(
+		user.NewLoginThrottlePostgresRepository(appConfig.Db.Pool),
+		appConfig.Logger,
+		user.LoginThrottlePolicy{
+			FreeAttempts: 3,
+			BaseDelay:    15 * time.Minute,
+			MaxDelay:     24 * time.Hour,
+			Window:       24 * time.Hour,
+		},
+		// clients get more requests, since many people can share an address
+		user.LoginThrottlePolicy{
+			FreeAttempts: 10,
+			BaseDelay:    15 * time.Minute,
+			MaxDelay:     24 * time.Hour,
+			Window:       time.Hour,
+		},
+	)
+	passwordResetController := user.NewPasswordResetController(
+		passwordResetService,
+		passwordResetThrottleService,
+		user.PasswordResetControllerConfig{
+			Log:            appConfig.Logger,
+			TemplateCache:  appConfig.TemplateCache,
+			SessionManager: appConfig.SessionManager,
+			CsrfToken:      appConfig.CsrfToken,
+			ErrorHandlers:  appConfig.ErrorHandlers,
+			BaseUrl:        appConfig.Config.Server.BaseUrl,
+		},
+	)
+
+	twoFactorService := user.NewTwoFactorService(
+		user.NewTwoFactorPostgresRepository(appConfig.Db.Pool),
+		siteService,
+	)
+	apiTokenService := user.NewApiTokenService(
+		user.NewApiTokenPostgresRepository(appConfig.Db.Pool),
+		appConfig.Validator,
+	)
+	apiTokenController := user.NewApiTokenController(apiTokenService, siteService, user.ApiTokenControllerConfig{
+		Log:            appConfig.Logger,
+		TemplateCache:  appConfig.TemplateCache,
+		SessionManager: appConfig.SessionManager,
+		CsrfToken:      appConfig.CsrfToken,
+		ErrorHandlers:  appConfig.ErrorHandlers,
+	})
+
+	sessionController := user.NewSessionController(appConfig.Sessions, user.SessionControllerConfig{
 		Log:            appConfig.Logger,
 		TemplateCache:  appConfig.TemplateCache,
 		SessionManager: appConfig.SessionManager,
@@ -60,6 +176,19 @@ func Start(appConfig AppConfig) error {
 		ErrorHandlers:  appConfig.ErrorHandlers,
 	})
 
+	twoFactorController := user.NewTwoFactorController(
+		twoFactorService,
+		userService,
+		loginThrottleService,
+		user.TwoFactorControllerConfig{
+			Log:            appConfig.Logger,
+			TemplateCache:  appConfig.TemplateCache,
+			SessionManager: appConfig.SessionManager,
+			CsrfToken:      appConfig.CsrfToken,
+			ErrorHandlers:  appConfig.ErrorHandlers,
+		},
+	)
+
 	tagRepository := tag.NewTagPostgresRepository(appConfig.Db.Pool)
 	tagService := tag.NewTagService(tagRepository, appConfig.Validator)
 	tagController := tag.NewTagController(tagService, tag.TagControllerConfig{
@@ -84,12 +213,46 @@ func Start(appConfig AppConfig) error {
 		},
 	)
 
-	isAuthenticated := middleware.NewAuthenticatedMiddleware(userService, appConfig.SessionManager, session.LOGGED_IN_USERNAME)
+	pageRepository := page.NewPagePostgresRepository(appConfig.Db.Pool)
+	pageService := page.NewPageService(pageRepository, appConfig.Validator)
+	pageController := page.NewPageController(pageService, page.PageControllerConfig{
+		Log:            appConfig.Logger,
+		TemplateCache:  appConfig.TemplateCache,
+		SessionManager: appConfig.SessionManager,
+		CsrfToken:      appConfig.CsrfToken,
+		ErrorHandlers:  appConfig.ErrorHandlers,
+	})
+
+	articlePublisher := article.NewArticlePublisher(
+		articleService,
+		appConfig.Logger,
+		time.Minute,
+	)
+
+	publisherCtx, stopPublisher := context.WithCancel(context.Background())
+	publisherDone := make(chan struct{})
+
+	go func() {
+		defer close(publisherDone)
+		articlePublisher.Start(publisherCtx)
+	}()
+
+	// the publisher is stopped however the server stops, and waited for so
+	// it's not cut off halfway through publishing
+	defer func() {
+		stopPublisher()
+		<-publisherDone
+	}()
+
+	isAuthenticated := middleware.NewAuthenticatedMiddleware(userService, siteService, appConfig.SessionManager, appConfig.ErrorHandlers, session.LOGGED_IN_USERNAME)
 	protected := middleware.NewProtectedMiddleware(appConfig.SessionManager)
+	sessionActivity := middleware.NewSessionActivityMiddleware(appConfig.SessionManager)
+	bearerToken := middleware.NewBearerTokenMiddleware(apiTokenService, userService, siteService, appConfig.Logger)
+	can := middleware.NewPermissionMiddleware(userService, appConfig.SessionManager, appConfig.ErrorHandlers)
 	noSurf := middleware.NewNoSurfMiddleware()
 	stripSlash := middleware.NewStripSlashMiddleware()
 
-	static := http.FileServer(http.Dir("./web/static/"))
+	static := http.FileServer(http.Dir(appConfig.Config.Paths.Static))
 
 	mux.Handle("GET /static/", http.StripPrefix("/static/", static))
 
@@ -103,36 +266,179 @@ func Start(appConfig AppConfig) error {
 		userController.UserLoginPost,
 		noSurf,
 	))
+	mux.HandleFunc("GET /admin/login/two-factor", applyMiddlewares(
+		twoFactorController.LoginTwoFactorGet,
+		noSurf,
+	))
+	mux.HandleFunc("POST /admin/login/two-factor", applyMiddlewares(
+		twoFactorController.LoginTwoFactorPost,
+		noSurf,
+	))
 	mux.HandleFunc("POST /admin/logout", applyMiddlewares(
 		userController.UserLogoutPost,
 		noSurf,
 	))
+	mux.HandleFunc("GET /admin/forgot-password", applyMiddlewares(
+		passwordResetController.ForgotPasswordGet,
+		noSurf,
+	))
+	mux.HandleFunc("POST /admin/forgot-password", applyMiddlewares(
+		passwordResetController.ForgotPasswordPost,
+		noSurf,
+	))
+	mux.HandleFunc("GET /admin/reset-password/{token}", applyMiddlewares(
+		passwordResetController.ResetPasswordGet,
+		noSurf,
+	))
+	mux.HandleFunc("POST /admin/reset-password/{token}", applyMiddlewares(
+		passwordResetController.ResetPasswordPost,
+		noSurf,
+	))
 	mux.HandleFunc("GET /admin/users/new", applyMiddlewares(
 		userController.CreateUserGet,
+		// middlewares are applied inside out, so can is listed before protected
+		// for permissions to only be checked once someone is logged in
+		can(user.PermissionManageUsers),
 		protected,
 		noSurf,
 		isAuthenticated,
 	))
 	mux.HandleFunc("GET /admin/users/{slug}", applyMiddlewares(
 		userController.UserGet,
+		can(user.PermissionManageUsers),
 		protected,
 		noSurf,
 		isAuthenticated,
 	))
 	mux.HandleFunc("GET /admin/users", applyMiddlewares(
 		userController.UsersGet,
+		can(user.PermissionManageUsers),
 		protected,
 		noSurf,
 		isAuthenticated,
 	))
 	mux.HandleFunc("POST /admin/users", applyMiddlewares(
 		userController.CreateUserPost,
+		can(user.PermissionManageUsers),
+		protected,
+		noSurf,
+		isAuthenticated,
+	))
+	mux.HandleFunc("GET /admin/account", applyMiddlewares(
+		userController.AccountGet,
+		can(user.PermissionViewAdmin),
+		protected,
+		noSurf,
+		isAuthenticated,
+	))
+	mux.HandleFunc("POST /admin/account", applyMiddlewares(
+		userController.AccountPost,
+		can(user.PermissionViewAdmin),
+		protected,
+		noSurf,
+		isAuthenticated,
+	))
+	mux.HandleFunc("POST /admin/account/password", applyMiddlewares(
+		userController.AccountPasswordPost,
+		can(user.PermissionViewAdmin),
+		protected,
+		noSurf,
+		isAuthenticated,
+	))
+	mux.HandleFunc("GET /admin/account/two-factor", applyMiddlewares(
+		twoFactorController.TwoFactorGet,
+		can(user.PermissionViewAdmin),
+		protected,
+		noSurf,
+		isAuthenticated,
+	))
+	mux.HandleFunc("POST /admin/account/two-factor", applyMiddlewares(
+		twoFactorController.TwoFactorEnablePost,
+		can(user.PermissionViewAdmin),
+		protected,
+		noSurf,
+		isAuthenticated,
+	))
+	mux.HandleFunc("POST /admin/account/two-factor/disable", applyMiddlewares(
+		twoFactorController.TwoFactorDisablePost,
+		can(user.PermissionViewAdmin),
+		protected,
+		noSurf,
+		isAuthenticated,
+	))
+	mux.HandleFunc("POST /admin/account/two-factor/recovery-codes", applyMiddlewares(
+		twoFactorController.RecoveryCodesPost,
+		can(user.PermissionViewAdmin),
+		protected,
+		noSurf,
+		isAuthenticated,
+	))
+	mux.HandleFunc("GET /admin/account/sessions", applyMiddlewares(
+		sessionController.SessionsGet,
+		can(user.PermissionViewAdmin),
+		protected,
+		noSurf,
+		isAuthenticated,
+	))
+	mux.HandleFunc("POST /admin/account/sessions/{id}/revoke", applyMiddlewares(
+		sessionController.SessionRevokePost,
+		can(user.PermissionViewAdmin),
+		protected,
+		noSurf,
+		isAuthenticated,
+	))
+	mux.HandleFunc("GET /admin/account/tokens", applyMiddlewares(
+		apiTokenController.ApiTokensGet,
+		can(user.PermissionViewAdmin),
+		protected,
+		noSurf,
+		isAuthenticated,
+	))
+	mux.HandleFunc("POST /admin/account/tokens", applyMiddlewares(
+		apiTokenController.ApiTokensPost,
+		can(user.PermissionViewAdmin),
+		protected,
+		noSurf,
+		isAuthenticated,
+	))
+	mux.HandleFunc("POST /admin/account/tokens/{id}/revoke", applyMiddlewares(
+		apiTokenController.ApiTokenRevokePost,
+		can(user.PermissionViewAdmin),
+		protected,
+		noSurf,
+		isAuthenticated,
+	))
+	mux.HandleFunc("POST /admin/users/{slug}/two-factor/reset", applyMiddlewares(
+		twoFactorController.UserTwoFactorResetPost,
+		can(user.PermissionManageUsers),
+		protected,
+		noSurf,
+		isAuthenticated,
+	))
+	mux.HandleFunc("POST /admin/users/{slug}/unlock", applyMiddlewares(
+		userController.UserUnlockPost,
+		can(user.PermissionManageUsers),
+		protected,
+		noSurf,
+		isAuthenticated,
+	))
+	mux.HandleFunc("POST /admin/users/{slug}/role", applyMiddlewares(
+		userController.UserRolePost,
+		can(user.PermissionManageUsers),
+		protected,
+		noSurf,
+		isAuthenticated,
+	))
+	mux.HandleFunc("POST /admin/users/{slug}/profile", applyMiddlewares(
+		userController.UserProfilePost,
+		can(user.PermissionManageUsers),
 		protected,
 		noSurf,
 		isAuthenticated,
 	))
 	mux.HandleFunc("POST /admin/users/{username}/delete", applyMiddlewares(
 		userController.DeleteUserPost,
+		can(user.PermissionManageUsers),
 		protected,
 		noSurf,
 		isAuthenticated,
@@ -140,35 +446,42 @@ func Start(appConfig AppConfig) error {
 
 	mux.HandleFunc("POST /admin/tags", applyMiddlewares(
 		tagController.AdminTagsHandler,
+		can(user.PermissionManageContent),
+		protected,
 		noSurf,
 		isAuthenticated,
 	))
 	mux.HandleFunc("GET /admin/tags", applyMiddlewares(
 		tagController.AdminTagsHandler,
+		can(user.PermissionViewAdmin),
 		protected,
 		noSurf,
 		isAuthenticated,
 	))
 	mux.HandleFunc("GET /admin/tags/new", applyMiddlewares(
 		tagController.GetAdminTagsNewHandler,
+		can(user.PermissionManageContent),
 		protected,
 		noSurf,
 		isAuthenticated,
 	))
 	mux.HandleFunc("GET /admin/tags/{slug}", applyMiddlewares(
 		tagController.AdminTagsSlugHandler,
+		can(user.PermissionViewAdmin),
 		protected,
 		noSurf,
 		isAuthenticated,
 	))
 	mux.HandleFunc("POST /admin/tags/{slug}", applyMiddlewares(
 		tagController.AdminTagsSlugHandler,
+		can(user.PermissionManageContent),
 		protected,
 		noSurf,
 		isAuthenticated,
 	))
 	mux.HandleFunc("POST /admin/tags/{slug}/delete", applyMiddlewares(
 		tagController.DeleteAdminTagsSlugHandler,
+		can(user.PermissionManageContent),
 		protected,
 		noSurf,
 		isAuthenticated,
@@ -176,43 +489,150 @@ func Start(appConfig AppConfig) error {
 
 	mux.HandleFunc("POST /admin/articles", applyMiddlewares(
 		articleController.CreateHandler,
+		can(user.PermissionWriteArticles),
 		protected,
 		noSurf,
 		isAuthenticated,
 	))
 	mux.HandleFunc("GET /admin/articles", applyMiddlewares(
 		articleController.GetAllHandler,
+		can(user.PermissionViewAdmin),
 		protected,
 		noSurf,
 		isAuthenticated,
 	))
 	mux.HandleFunc("GET /admin/articles/new", applyMiddlewares(
 		articleController.NewHandler,
+		can(user.PermissionWriteArticles),
 		protected,
 		noSurf,
 		isAuthenticated,
 	))
 	mux.HandleFunc("GET /admin/articles/{slug}", applyMiddlewares(
 		articleController.GetBySlugHander,
+		can(user.PermissionViewAdmin),
 		protected,
 		noSurf,
 		isAuthenticated,
 	))
 	mux.HandleFunc("POST /admin/articles/{slug}", applyMiddlewares(
 		articleController.UpdateHandler,
+		can(user.PermissionWriteArticles),
 		protected,
 		noSurf,
 		isAuthenticated,
 	))
 	mux.HandleFunc("POST /admin/articles/{slug}/delete", applyMiddlewares(
 		articleController.AdminArticlesDeleteHandler,
+		can(user.PermissionWriteArticles),
+		protected,
+		noSurf,
+		isAuthenticated,
+	))
+	mux.HandleFunc("GET /admin/articles/{slug}/revisions", applyMiddlewares(
+		articleController.RevisionsHandler,
+		can(user.PermissionViewAdmin),
+		protected,
+		noSurf,
+		isAuthenticated,
+	))
+	mux.HandleFunc("POST /admin/articles/{slug}/revisions/{id}/restore", applyMiddlewares(
+		articleController.RestoreRevisionHandler,
+		can(user.PermissionWriteArticles),
+		protected,
+		noSurf,
+		isAuthenticated,
+	))
+
+	mux.HandleFunc("GET /admin/pages", applyMiddlewares(
+		pageController.AdminPagesGet,
+		can(user.PermissionViewAdmin),
+		protected,
+		noSurf,
+		isAuthenticated,
+	))
+	mux.HandleFunc("POST /admin/pages", applyMiddlewares(
+		pageController.AdminPagesPost,
+		can(user.PermissionManageContent),
+		protected,
+		noSurf,
+		isAuthenticated,
+	))
+	mux.HandleFunc("GET /admin/pages/new", applyMiddlewares(
+		pageController.AdminPagesNewGet,
+		can(user.PermissionManageContent),
+		protected,
+		noSurf,
+		isAuthenticated,
+	))
+	mux.HandleFunc("GET /admin/pages/{slug}", applyMiddlewares(
+		pageController.AdminPageGet,
+		can(user.PermissionViewAdmin),
+		protected,
+		noSurf,
+		isAuthenticated,
+	))
+	mux.HandleFunc("POST /admin/pages/{slug}", applyMiddlewares(
+		pageController.AdminPagePost,
+		can(user.PermissionManageContent),
+		protected,
+		noSurf,
+		isAuthenticated,
+	))
+	mux.HandleFunc("POST /admin/pages/{slug}/delete", applyMiddlewares(
+		pageController.DeleteAdminPagePost,
+		can(user.PermissionManageContent),
 		protected,
 		noSurf,
 		isAuthenticated,
 	))
 
 	mux.HandleFunc("GET /admin/site", applyMiddlewares(
-		siteController.GetCreateSiteItems,
+		siteController.SiteSettingsGet,
+		can(user.PermissionManageSite),
+		protected,
+		noSurf,
+		isAuthenticated,
+	))
+	mux.HandleFunc("POST /admin/site", applyMiddlewares(
+		siteController.SiteSettingsPost,
+		can(user.PermissionManageSite),
+		protected,
+		noSurf,
+		isAuthenticated,
+	))
+	mux.HandleFunc("POST /admin/site/{key}/delete", applyMiddlewares(
+		siteController.DeleteSiteSettingPost,
+		can(user.PermissionManageSite),
+		protected,
+		noSurf,
+		isAuthenticated,
+	))
+
+	mux.HandleFunc("GET /admin/menu", applyMiddlewares(
+		menuController.AdminMenuGet,
+		can(user.PermissionViewAdmin),
+		protected,
+		noSurf,
+		isAuthenticated,
+	))
+	mux.HandleFunc("POST /admin/menu", applyMiddlewares(
+		menuController.AdminMenuPost,
+		can(user.PermissionManageContent),
+		protected,
+		noSurf,
+		isAuthenticated,
+	))
+	mux.HandleFunc("POST /admin/menu/{id}/delete", applyMiddlewares(
+		menuController.DeleteAdminMenuItemPost,
+		can(user.PermissionManageContent),
+		protected,
+		noSurf,
+		isAuthenticated,
+	))
+	mux.HandleFunc("POST /admin/menu/{id}/move", applyMiddlewares(
+		menuController.MoveAdminMenuItemPost,
+		can(user.PermissionManageContent),
 		protected,
 		noSurf,
 		isAuthenticated,
@@ -221,6 +641,7 @@ func Start(appConfig AppConfig) error {
 	homeController := home.NewHomeController(
 		tagService,
 		articleService,
+		userService,
 		home.HomeControllerConfig{
 			Log:            appConfig.Logger,
 			TemplateCache:  appConfig.TemplateCache,
@@ -230,27 +651,207 @@ func Start(appConfig AppConfig) error {
 		},
 	)
 
+	feedService := feed.NewFeedService(articleService, tagService, siteService)
+	feedController := feed.NewFeedController(feedService, feed.FeedControllerConfig{
+		Log:           appConfig.Logger,
+		ErrorHandlers: appConfig.ErrorHandlers,
+		BaseUrl:       appConfig.Config.Server.BaseUrl,
+	})
+
+	mux.HandleFunc("GET /feed.xml", feedController.RssHandler)
+	mux.HandleFunc("GET /atom.xml", feedController.AtomHandler)
+	mux.HandleFunc("GET /feed.json", feedController.JsonHandler)
+	mux.HandleFunc("GET /tags/{slug}/feed.xml", feedController.RssHandler)
+	mux.HandleFunc("GET /tags/{slug}/atom.xml", feedController.AtomHandler)
+	mux.HandleFunc("GET /tags/{slug}/feed.json", feedController.JsonHandler)
+
+	sitemapService := sitemap.NewSitemapService(articleService, tagService, pageService, siteService)
+	sitemapController := sitemap.NewSitemapController(sitemapService, sitemap.SitemapControllerConfig{
+		Log:           appConfig.Logger,
+		ErrorHandlers: appConfig.ErrorHandlers,
+		BaseUrl:       appConfig.Config.Server.BaseUrl,
+	})
+
+	apiConfig := api.ControllerConfig{Log: appConfig.Logger}
+	apiControllers := api.Controllers{
+		Me:       apiTokenController.ApiMeGet,
+		Articles: api.NewArticleController(articleService, apiConfig),
+		Tags:     api.NewTagController(tagService, apiConfig),
+		Users:    api.NewUserController(userService, appConfig.Validator, apiConfig),
+		Site:     api.NewSiteController(siteService, apiConfig),
+	}
+
+	for _, route := range apiControllers.Routes() {
+		mux.HandleFunc(route.Pattern(), applyMiddlewares(
+			route.Handler,
+			can(route.Permission),
+			bearerToken,
+		))
+	}
+
+	docsController := api.NewDocsController(api.DocsControllerConfig{
+		Log:           appConfig.Logger,
+		TemplateCache: appConfig.TemplateCache,
+		ErrorHandlers: appConfig.ErrorHandlers,
+	})
+
+	mux.HandleFunc("GET /api/openapi.json", docsController.OpenApiGet)
+	mux.HandleFunc("GET /api/docs", docsController.DocsGet)
+
+	mux.HandleFunc("GET /sitemap.xml", sitemapController.SitemapHandler)
+	mux.HandleFunc("GET /sitemaps/{page}", sitemapController.SitemapPageHandler)
+	mux.HandleFunc("GET /robots.txt", sitemapController.RobotsHandler)
+
 	mux.HandleFunc("GET /articles", homeController.HomeArticlesGet)
 	mux.HandleFunc("GET /articles/{slug}", articleController.PublicGetArticle)
+	mux.HandleFunc("GET /authors/{username}", homeController.HomeAuthorGet)
+	mux.HandleFunc("GET /search", articleController.PublicSearchHandler)
 	mux.HandleFunc("GET /tags", homeController.HomeTagsGet)
 	mux.HandleFunc("GET /tags/{slug}", homeController.HomeTagGet)
 
-	mux.HandleFunc("GET /", stripSlash(publicRootHandler(homeController.HomeGet)))
+	// anything not matched by a more specific route is a page, e.g. /about
+	mux.HandleFunc("GET /", stripSlash(publicRootHandler(
+		homeController.HomeGet,
+		pageController.PublicGetPage,
+	)))
+
+	migrationVersion, err := db.LatestMigration(appConfig.Config.Paths.Migrations)
+	if err != nil {
+		return fmt.Errorf("unable to find latest migration: %w", err)
+	}
+
+	healthController := health.NewHealthController(
+		health.NewHealthPostgresRepository(appConfig.Db.Pool),
+		health.HealthControllerConfig{
+			Log:              appConfig.Logger,
+			TemplateCache:    appConfig.TemplateCache,
+			MigrationVersion: migrationVersion,
+			BuildInfo:        health.ReadBuildInfo(),
+		},
+	)
+
+	// health checks are probed by load balancers, which have no session, so
+	// they're served ahead of the session middleware rather than through mux
+	root := http.NewServeMux()
+
+	root.HandleFunc("GET /healthz", healthController.HealthzGet)
+	root.HandleFunc("GET /readyz", healthController.ReadyzGet)
+	root.HandleFunc("GET /version", healthController.VersionGet)
+
+	root.Handle("/", appConfig.SessionManager.LoadAndSave(sessionActivity(mux.ServeHTTP)))
+
+	route := routePattern(root, mux)
+	instrument := middleware.NewMetricsMiddleware(route)
+	requestId := middleware.NewRequestIdMiddleware(route)
 
 	server := &http.Server{
-		Addr:         fmt.Sprintf(":%v", appConfig.Port),
-		Handler:      appConfig.SessionManager.LoadAndSave(mux),
-		IdleTimeout:  time.Minute,
-		ReadTimeout:  time.Second * 10,
-		WriteTimeout: time.Second * 10,
+		Handler:      applyMiddlewares(root.ServeHTTP, requestId, instrument),
+		ErrorLog:     appConfig.ErrorLog,
+		IdleTimeout:  appConfig.Config.Server.IdleTimeout,
+		ReadTimeout:  appConfig.Config.Server.ReadTimeout,
+		WriteTimeout: appConfig.Config.Server.WriteTimeout,
 	}
 
-	appConfig.Logger.Info("starting server on %s", appConfig.Port)
+	shutdownTimeout := appConfig.Config.Server.ShutdownTimeout
 
-	if err := server.ListenAndServe(); err != nil {
-		appConfig.Logger.Error("failed to start server: %s", err)
+	// the listeners are already open, so anything that connects from here on
+	// is served
+	if appConfig.Ready != nil {
+		appConfig.Ready()
 	}
 
+	if appConfig.MetricsListener == nil {
+		return serve(ctx, server, appConfig.Listener, shutdownTimeout, appConfig.Logger)
+	}
+
+	metricsServer := &http.Server{
+		Handler:      metricsMux(),
+		ErrorLog:     appConfig.ErrorLog,
+		ReadTimeout:  appConfig.Config.Server.ReadTimeout,
+		WriteTimeout: appConfig.Config.Server.WriteTimeout,
+	}
+
+	// the metrics server is stopped along with the app's, however that stops
+	metricsCtx, stopMetrics := context.WithCancel(ctx)
+	metricsDone := make(chan struct{})
+
+	go func() {
+		defer close(metricsDone)
+
+		if err := serve(metricsCtx, metricsServer, appConfig.MetricsListener, shutdownTimeout, appConfig.Logger); err != nil {
+			appConfig.Logger.Error(ctx, "metrics server stopped", "err", err)
+		}
+	}()
+
+	err = serve(ctx, server, appConfig.Listener, shutdownTimeout, appConfig.Logger)
+
+	stopMetrics()
+	<-metricsDone
+
+	return err
+}
+
+// metricsMux serves the metrics at /metrics, where Prometheus scrapes them by
+// default, and nothing anywhere else.
+func metricsMux() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.Handle("GET /metrics", metrics.Handler())
+
+	return mux
+}
+
+// routePattern finds the pattern of the route a request matches. Requests for
+// the app are passed on to mux by root's catch-all, so their route is found
+// in mux.
+func routePattern(root, mux *http.ServeMux) func(r *http.Request) string {
+	return func(r *http.Request) string {
+		if _, pattern := root.Handler(r); pattern != "/" {
+			return pattern
+		}
+
+		_, pattern := mux.Handler(r)
+
+		return pattern
+	}
+}
+
+// serve serves on l until ctx is cancelled, then stops accepting connections
+// and waits up to timeout for requests in flight to finish.
+func serve(
+	ctx context.Context,
+	server *http.Server,
+	l net.Listener,
+	timeout time.Duration,
+	log logging.Logger,
+) error {
+	serveErr := make(chan error, 1)
+
+	go func() {
+		log.Info(ctx, "starting server", "address", l.Addr().String())
+		serveErr <- server.Serve(l)
+	}()
+
+	select {
+	case err := <-serveErr:
+		return fmt.Errorf("failed to serve: %w", err)
+
+	case <-ctx.Done():
+	}
+
+	log.Info(ctx, "shutting down server", "address", l.Addr().String(), "timeout", timeout)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		// connections still open after the timeout are cut off
+		server.Close()
+		return fmt.Errorf("failed to shut down gracefully: %w", err)
+	}
+
+	log.Info(ctx, "server shut down", "address", l.Addr().String())
+
 	return nil
 }
 
@@ -0,0 +1,170 @@
+package app
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nixpig/dunce/pkg/logging"
+	"github.com/stretchr/testify/require"
+)
+
+var testLog = logging.NewLogger(io.Discard, logging.FormatText, slog.LevelInfo)
+
+func TestServe(t *testing.T) {
+	scenarios := map[string]func(t *testing.T){
+		"shutdown waits for requests": testServeShutdownWaitsForRequests,
+		"shutdown timeout":            testServeShutdownTimeout,
+		"listener closed":             testServeListenerClosed,
+	}
+
+	for scenario, fn := range scenarios {
+		t.Run(scenario, func(t *testing.T) {
+			fn(t)
+		})
+	}
+}
+
+// startServe serves handler until the returned context is cancelled. The
+// result of serve is sent on the returned channel.
+func startServe(
+	t *testing.T,
+	handler http.HandlerFunc,
+	timeout time.Duration,
+) (string, context.CancelFunc, chan error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+
+	go func() {
+		done <- serve(ctx, &http.Server{Handler: handler}, l, timeout, testLog)
+	}()
+
+	return "http://" + l.Addr().String(), cancel, done
+}
+
+func testServeShutdownWaitsForRequests(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	url, cancel, done := startServe(t, func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.Write([]byte("finished"))
+	}, time.Second)
+
+	response := make(chan *http.Response, 1)
+	go func() {
+		res, err := http.Get(url)
+		if err == nil {
+			response <- res
+		}
+		close(response)
+	}()
+
+	<-started
+	cancel()
+
+	// the server's shutting down, but the request in flight still finishes
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+
+	res, ok := <-response
+	require.True(t, ok, "request in flight should finish")
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	require.Equal(t, "finished", string(body))
+
+	require.NoError(t, <-done)
+
+	_, err = http.Get(url)
+	require.Error(t, err, "new connections shouldn't be accepted")
+}
+
+func testServeShutdownTimeout(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+
+	url, cancel, done := startServe(t, func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	}, 50*time.Millisecond)
+
+	go http.Get(url)
+
+	<-started
+	cancel()
+
+	require.ErrorContains(t, <-done, "failed to shut down gracefully")
+}
+
+func testServeListenerClosed(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	l.Close()
+
+	err = serve(context.Background(), &http.Server{}, l, time.Second, testLog)
+
+	require.ErrorContains(t, err, "failed to serve")
+}
+
+func TestRoutePattern(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /articles/{slug}", handler)
+	mux.HandleFunc("POST /admin/articles", handler)
+	mux.HandleFunc("GET /", handler)
+
+	root := http.NewServeMux()
+	root.HandleFunc("GET /healthz", handler)
+	root.Handle("/", mux)
+
+	route := routePattern(root, mux)
+
+	scenarios := map[string]string{
+		"GET /healthz":             "GET /healthz",
+		"GET /articles/hello":      "GET /articles/{slug}",
+		"POST /admin/articles":     "POST /admin/articles",
+		"GET /about":               "GET /",
+		"DELETE /admin/articles/1": "",
+	}
+
+	for request, expected := range scenarios {
+		method, path, _ := strings.Cut(request, " ")
+
+		require.Equal(t, expected, route(httptest.NewRequest(method, path, nil)), "should find route for '%s'", request)
+	}
+}
+
+func TestMetricsMux(t *testing.T) {
+	mux := metricsMux()
+
+	scenarios := map[string]int{
+		"GET /metrics":  http.StatusOK,
+		"GET /":         http.StatusNotFound,
+		"GET /admin":    http.StatusNotFound,
+		"GET /metrics/": http.StatusNotFound,
+		"POST /metrics": http.StatusMethodNotAllowed,
+	}
+
+	for request, expected := range scenarios {
+		method, path, _ := strings.Cut(request, " ")
+
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest(method, path, nil))
+
+		require.Equal(t, expected, rr.Code, "should serve '%s' with status %d", request, expected)
+	}
+}
@@ -1,77 +1,159 @@
 package article
 
 import (
+	"html/template"
 	"time"
 
 	"github.com/nixpig/dunce/internal/tag"
+	"github.com/nixpig/dunce/pkg/pagination"
+)
+
+const (
+	StatusDraft     = "draft"
+	StatusScheduled = "scheduled"
+	StatusPublished = "published"
+	StatusUnlisted  = "unlisted"
+	StatusArchived  = "archived"
 )
 
 type Article struct {
-	Id        int       `validate:"omitempty"`
-	Title     string    `validate:"required,max=255"`
-	Subtitle  string    `validate:"required,max=255"`
-	Slug      string    `validate:"required,min=2,max=50"`
-	Body      string    `validate:"required"`
-	CreatedAt time.Time `validate:"required"`
-	UpdatedAt time.Time `validate:"required"`
-	Tags      []tag.Tag `validate:"required"`
+	Id          int        `validate:"omitempty"`
+	Title       string     `validate:"required,max=255"`
+	Subtitle    string     `validate:"required,max=255"`
+	Slug        string     `validate:"required,min=2,max=50"`
+	Body        string     `validate:"required"`
+	Status      string     `validate:"required,oneof=draft scheduled published unlisted archived"`
+	PublishedAt *time.Time `validate:"omitempty"`
+	CreatedAt   time.Time  `validate:"required"`
+	UpdatedAt   time.Time  `validate:"required"`
+	AuthorId    *int       `validate:"omitempty"`
+	Author      *ArticleAuthor
+	Tags        []tag.Tag `validate:"required"`
 }
 
 type ArticleNewRequestDto struct {
-	Title     string    `validate:"required,max=255"`
-	Subtitle  string    `validate:"required,max=255"`
-	Slug      string    `validate:"required,min=2,max=50"`
-	Body      string    `validate:"required"`
-	CreatedAt time.Time `validate:"required"`
-	UpdatedAt time.Time `validate:"required"`
-	TagIds    []int     `validate:"required"`
+	Title       string     `validate:"required,max=255"`
+	Subtitle    string     `validate:"required,max=255"`
+	Slug        string     `validate:"required,min=2,max=50"`
+	Body        string     `validate:"required"`
+	Status      string     `validate:"required,oneof=draft scheduled published unlisted archived"`
+	PublishedAt *time.Time `validate:"omitempty"`
+	CreatedAt   time.Time  `validate:"required"`
+	UpdatedAt   time.Time  `validate:"required"`
+	AuthorId    *int       `validate:"omitempty"`
+	TagIds      []int      `validate:"required"`
 }
 
 type ArticleUpdateRequestDto struct {
-	Id        int       `validate:"omitempty"`
-	Title     string    `validate:"required,max=255"`
-	Subtitle  string    `validate:"required,max=255"`
-	Slug      string    `validate:"required,min=2,max=50"`
-	Body      string    `validate:"required"`
-	CreatedAt time.Time `validate:"required"`
-	UpdatedAt time.Time `validate:"required"`
-	TagIds    []int     `validate:"required"`
+	Id          int        `validate:"omitempty"`
+	Title       string     `validate:"required,max=255"`
+	Subtitle    string     `validate:"required,max=255"`
+	Slug        string     `validate:"required,min=2,max=50"`
+	Body        string     `validate:"required"`
+	Status      string     `validate:"required,oneof=draft scheduled published unlisted archived"`
+	PublishedAt *time.Time `validate:"omitempty"`
+	CreatedAt   time.Time  `validate:"required"`
+	UpdatedAt   time.Time  `validate:"required"`
+	TagIds      []int      `validate:"required"`
 }
 
 type UpdateArticle struct {
-	Id        int       `validate:"omitempty"`
-	Title     string    `validate:"required,max=255"`
-	Subtitle  string    `validate:"required,max=255"`
-	Slug      string    `validate:"required,min=2,max=50"`
-	Body      string    `validate:"required"`
-	CreatedAt time.Time `validate:"required"`
-	UpdatedAt time.Time `validate:"required"`
-	TagIds    []int     `validate:"required"`
+	Id          int        `validate:"omitempty"`
+	Title       string     `validate:"required,max=255"`
+	Subtitle    string     `validate:"required,max=255"`
+	Slug        string     `validate:"required,min=2,max=50"`
+	Body        string     `validate:"required"`
+	Status      string     `validate:"required,oneof=draft scheduled published unlisted archived"`
+	PublishedAt *time.Time `validate:"omitempty"`
+	CreatedAt   time.Time  `validate:"required"`
+	UpdatedAt   time.Time  `validate:"required"`
+	TagIds      []int      `validate:"required"`
 }
 
 type ArticleNew struct {
-	Title     string    `validate:"required,max=255"`
-	Subtitle  string    `validate:"required,max=255"`
-	Slug      string    `validate:"required,min=2,max=50"`
-	Body      string    `validate:"required"`
-	CreatedAt time.Time `validate:"required"`
-	UpdatedAt time.Time `validate:"required"`
-	TagIds    []int     `validate:"required"`
+	Title       string     `validate:"required,max=255"`
+	Subtitle    string     `validate:"required,max=255"`
+	Slug        string     `validate:"required,min=2,max=50"`
+	Body        string     `validate:"required"`
+	Status      string     `validate:"required,oneof=draft scheduled published unlisted archived"`
+	PublishedAt *time.Time `validate:"omitempty"`
+	CreatedAt   time.Time  `validate:"required"`
+	UpdatedAt   time.Time  `validate:"required"`
+	AuthorId    *int       `validate:"omitempty"`
+	TagIds      []int      `validate:"required"`
 }
 
 type ArticleResponseDto struct {
+	Id          int
+	Title       string
+	Subtitle    string
+	Slug        string
+	Body        string
+	Status      string
+	PublishedAt *time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	AuthorId    *int
+	Author      *ArticleAuthor
+	Tags        []tag.Tag
+}
+
+// ArticleAuthor is the public credit for an article, resolved from its
+// author_id_ when the article is loaded by slug.
+type ArticleAuthor struct {
+	Username    string
+	DisplayName string
+}
+
+// Name is the name to credit the author by, preferring their display name.
+func (a ArticleAuthor) Name() string {
+	if a.DisplayName != "" {
+		return a.DisplayName
+	}
+
+	return a.Username
+}
+
+type ArticleTag struct {
+	Id        int `validate:"required"`
+	ArticleId int `validate:"required"`
+	TagId     int `validate:"required"`
+}
+
+type ArticleRevision struct {
 	Id        int
+	ArticleId int
 	Title     string
 	Subtitle  string
 	Slug      string
 	Body      string
+	TagIds    []int
 	CreatedAt time.Time
-	UpdatedAt time.Time
-	Tags      []tag.Tag
 }
 
-type ArticleTag struct {
-	Id        int `validate:"required"`
-	ArticleId int `validate:"required"`
-	TagId     int `validate:"required"`
+type ArticleRevisionResponseDto struct {
+	Id        int
+	ArticleId int
+	Title     string
+	Subtitle  string
+	Slug      string
+	Body      string
+	TagIds    []int
+	CreatedAt time.Time
+}
+
+type ArticleSearchResult struct {
+	Article  Article
+	Headline string
+	Rank     float32
+}
+
+type ArticleSearchResultResponseDto struct {
+	Article ArticleResponseDto
+	Snippet template.HTML
+}
+
+type ArticleSearchResponseDto struct {
+	Query   string
+	Results *pagination.Page[ArticleSearchResultResponseDto]
 }
@@ -1,21 +1,30 @@
 package article
 
 import (
+	"fmt"
 	"html/template"
 	"net/http"
+	"net/url"
 	"strconv"
 	"time"
 
+	"github.com/go-playground/validator/v10"
 	"github.com/nixpig/dunce/internal/app/errors"
 	"github.com/nixpig/dunce/internal/tag"
+	"github.com/nixpig/dunce/internal/user"
+	"github.com/nixpig/dunce/pkg/diff"
 	"github.com/nixpig/dunce/pkg/logging"
 	"github.com/nixpig/dunce/pkg/markdown"
+	"github.com/nixpig/dunce/pkg/pagination"
 	"github.com/nixpig/dunce/pkg/session"
 	"github.com/nixpig/dunce/pkg/templates"
 )
 
 const longTimeFormat = "2006-01-02 15:04:05.999999999 -0700 MST"
 
+// matches the format of the datetime-local input on the article forms
+const publishedAtFormat = "2006-01-02T15:04"
+
 type ArticleController struct {
 	articleService ArticleService
 	tagService     tag.TagService
@@ -35,6 +44,7 @@ type ArticleControllerConfig struct {
 }
 
 type ArticleView struct {
+	Path            string
 	Message         string
 	Article         *ArticleResponseDto
 	Tags            *[]tag.TagResponseDto
@@ -45,11 +55,17 @@ type ArticleView struct {
 
 type ArticlesView struct {
 	Message         string
-	Articles        *[]ArticleResponseDto
+	Articles        *pagination.Page[ArticleResponseDto]
+	Search          *ArticleSearchResponseDto
 	CsrfToken       string
 	IsAuthenticated bool
 }
 
+type ArticleSearchView struct {
+	Path   string
+	Search *ArticleSearchResponseDto
+}
+
 type ArticlePublishView struct {
 	Message         string
 	Articles        *[]ArticleResponseDto
@@ -58,6 +74,21 @@ type ArticlePublishView struct {
 	IsAuthenticated bool
 }
 
+type ArticleRevisionsView struct {
+	Message         string
+	Article         *ArticleResponseDto
+	Revisions       *[]ArticleRevisionResponseDto
+	From            string
+	To              string
+	Diff            []diff.Line
+	CsrfToken       string
+	IsAuthenticated bool
+}
+
+// the revisions page offers the live version of the article alongside its
+// saved revisions, so it can be compared against any of them
+const currentRevision = "current"
+
 func NewArticleController(
 	service ArticleService,
 	tagsService tag.TagService,
@@ -90,25 +121,46 @@ func (a *ArticleController) CreateHandler(
 	for i, t := range tagsForm {
 		tagId, err := strconv.Atoi(t)
 		if err != nil {
-			a.errorHandlers.InternalServerError(w, r)
+			a.errorHandlers.InternalServerError(w, r, err)
 			return
 		}
 
 		tagIds[i] = tagId
 	}
 
+	status := r.FormValue("status")
+
+	publishedAt, err := parsePublishedAt(r.FormValue("published_at"), status)
+	if err != nil {
+		a.errorHandlers.BadRequest(w, r)
+		return
+	}
+
 	article := ArticleNewRequestDto{
-		Title:     r.FormValue("title"),
-		Subtitle:  r.FormValue("subtitle"),
-		Slug:      r.FormValue("slug"),
-		Body:      r.FormValue("body"),
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-		TagIds:    tagIds,
+		Title:       r.FormValue("title"),
+		Subtitle:    r.FormValue("subtitle"),
+		Slug:        r.FormValue("slug"),
+		Body:        r.FormValue("body"),
+		Status:      status,
+		PublishedAt: publishedAt,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+		TagIds:      tagIds,
+	}
+
+	if loggedInUser, ok := a.loggedInUser(r); ok {
+		authorId := int(loggedInUser.Id)
+		article.AuthorId = &authorId
 	}
 
 	if _, err := a.articleService.Create(&article); err != nil {
-		a.errorHandlers.InternalServerError(w, r)
+		if message, ok := articleErrorMessage(err); ok {
+			a.session.Put(r.Context(), session.SESSION_KEY_MESSAGE, message)
+			http.Redirect(w, r, "/admin/articles/new", http.StatusSeeOther)
+			return
+		}
+
+		a.errorHandlers.InternalServerError(w, r, fmt.Errorf("unable to create article: %w", err))
 		return
 	}
 
@@ -119,35 +171,60 @@ func (a *ArticleController) GetAllHandler(
 	w http.ResponseWriter,
 	r *http.Request,
 ) {
-	articles, err := a.articleService.GetAll()
-	if err != nil {
-		a.errorHandlers.InternalServerError(w, r)
-		return
+	var articles *pagination.Page[ArticleResponseDto]
+	var search *ArticleSearchResponseDto
+	var err error
+
+	page := pagination.FromQuery(r.URL.Query())
+
+	if query := r.URL.Query().Get("q"); query != "" {
+		// admins search across articles in every status
+		search, err = a.articleService.Search(query, page)
+		if err != nil {
+			a.errorHandlers.InternalServerError(w, r, err)
+			return
+		}
+
+		search.Results.Params = url.Values{"q": {search.Query}}
+
+		articles = pagination.Map(search.Results, func(result ArticleSearchResultResponseDto) ArticleResponseDto {
+			return result.Article
+		})
+	} else {
+		articles, err = a.articleService.GetAll(page)
+		if err != nil {
+			a.errorHandlers.InternalServerError(w, r, err)
+			return
+		}
 	}
 
 	if err := a.templates["pages/admin/articles.tmpl"].ExecuteTemplate(w, "admin", ArticlesView{
 		Articles:        articles,
+		Search:          search,
 		CsrfToken:       a.csrfToken(r),
 		IsAuthenticated: a.session.Exists(r.Context(), string(session.IS_LOGGED_IN_CONTEXT_KEY)),
 	}); err != nil {
-		a.errorHandlers.InternalServerError(w, r)
+		a.errorHandlers.InternalServerError(w, r, err)
 		return
 	}
 }
 
 func (a *ArticleController) NewHandler(w http.ResponseWriter, r *http.Request) {
-	availableTags, err := a.tagService.GetAll()
+	availableTags, err := a.tagService.GetAll(
+		pagination.Request{Limit: pagination.Unlimited},
+	)
 	if err != nil {
-		a.errorHandlers.InternalServerError(w, r)
+		a.errorHandlers.InternalServerError(w, r, err)
 		return
 	}
 
 	if err := a.templates["pages/admin/new-article.tmpl"].ExecuteTemplate(w, "admin", ArticlePublishView{
-		Tags:            availableTags,
+		Message:         a.session.PopString(r.Context(), session.SESSION_KEY_MESSAGE),
+		Tags:            &availableTags.Items,
 		CsrfToken:       a.csrfToken(r),
 		IsAuthenticated: a.session.Exists(r.Context(), string(session.IS_LOGGED_IN_CONTEXT_KEY)),
 	}); err != nil {
-		a.errorHandlers.InternalServerError(w, r)
+		a.errorHandlers.InternalServerError(w, r, err)
 		return
 	}
 }
@@ -164,9 +241,11 @@ func (a *ArticleController) GetBySlugHander(
 		return
 	}
 
-	allTags, err := a.tagService.GetAll()
+	allTags, err := a.tagService.GetAll(
+		pagination.Request{Limit: pagination.Unlimited},
+	)
 	if err != nil {
-		a.errorHandlers.InternalServerError(w, r)
+		a.errorHandlers.InternalServerError(w, r, err)
 		return
 	}
 
@@ -174,13 +253,14 @@ func (a *ArticleController) GetBySlugHander(
 		w,
 		"admin",
 		ArticleView{
+			Message:         a.session.PopString(r.Context(), session.SESSION_KEY_MESSAGE),
 			Article:         article,
-			Tags:            allTags,
+			Tags:            &allTags.Items,
 			CsrfToken:       a.csrfToken(r),
 			IsAuthenticated: a.session.Exists(r.Context(), string(session.IS_LOGGED_IN_CONTEXT_KEY)),
 		},
 	); err != nil {
-		a.errorHandlers.InternalServerError(w, r)
+		a.errorHandlers.InternalServerError(w, r, err)
 		return
 	}
 }
@@ -189,6 +269,11 @@ func (a ArticleController) UpdateHandler(
 	w http.ResponseWriter,
 	r *http.Request,
 ) {
+	existing, ok := a.authorize(w, r, r.PathValue("slug"))
+	if !ok {
+		return
+	}
+
 	if err := r.ParseForm(); err != nil {
 		a.errorHandlers.BadRequest(w, r)
 		return
@@ -201,7 +286,7 @@ func (a ArticleController) UpdateHandler(
 	for i, t := range tags {
 		id, err := strconv.Atoi(t)
 		if err != nil {
-			a.errorHandlers.InternalServerError(w, r)
+			a.errorHandlers.InternalServerError(w, r, err)
 			return
 		}
 
@@ -214,26 +299,44 @@ func (a ArticleController) UpdateHandler(
 		return
 	}
 
+	// only the article that was authorized can be changed, whatever id the
+	// form was sent with
 	articleId, err := strconv.Atoi(r.FormValue("id"))
+	if err != nil || articleId != existing.Id {
+		a.errorHandlers.BadRequest(w, r)
+		return
+	}
+
+	status := r.FormValue("status")
+
+	publishedAt, err := parsePublishedAt(r.FormValue("published_at"), status)
 	if err != nil {
 		a.errorHandlers.BadRequest(w, r)
 		return
 	}
 
 	article := ArticleUpdateRequestDto{
-		Id:        articleId,
-		Title:     r.FormValue("title"),
-		Subtitle:  r.FormValue("subtitle"),
-		Slug:      r.FormValue("slug"),
-		Body:      r.FormValue("body"),
-		CreatedAt: createdAt,
-		UpdatedAt: time.Now(),
-		TagIds:    tagIds,
+		Id:          articleId,
+		Title:       r.FormValue("title"),
+		Subtitle:    r.FormValue("subtitle"),
+		Slug:        r.FormValue("slug"),
+		Body:        r.FormValue("body"),
+		Status:      status,
+		PublishedAt: publishedAt,
+		CreatedAt:   createdAt,
+		UpdatedAt:   time.Now(),
+		TagIds:      tagIds,
 	}
 
 	_, err = a.articleService.Update(&article)
 	if err != nil {
-		a.errorHandlers.InternalServerError(w, r)
+		if message, ok := articleErrorMessage(err); ok {
+			a.session.Put(r.Context(), session.SESSION_KEY_MESSAGE, message)
+			http.Redirect(w, r, "/admin/articles/"+existing.Slug, http.StatusSeeOther)
+			return
+		}
+
+		a.errorHandlers.InternalServerError(w, r, fmt.Errorf("unable to update article: %w", err))
 		return
 	}
 
@@ -244,14 +347,21 @@ func (a ArticleController) AdminArticlesDeleteHandler(
 	w http.ResponseWriter,
 	r *http.Request,
 ) {
+	existing, ok := a.authorize(w, r, r.PathValue("slug"))
+	if !ok {
+		return
+	}
+
+	// only the article that was authorized can be deleted, whatever id the
+	// form was sent with
 	id, err := strconv.Atoi(r.FormValue("id"))
-	if err != nil {
+	if err != nil || id != existing.Id {
 		a.errorHandlers.BadRequest(w, r)
 		return
 	}
 
 	if err := a.articleService.DeleteById(id); err != nil {
-		a.errorHandlers.InternalServerError(w, r)
+		a.errorHandlers.InternalServerError(w, r, err)
 		return
 	}
 
@@ -270,6 +380,13 @@ func (a ArticleController) PublicGetArticle(
 		return
 	}
 
+	// unlisted articles aren't shown in any listings, but can still be
+	// opened by anyone who has the slug
+	if article.Status != StatusPublished && article.Status != StatusUnlisted {
+		a.errorHandlers.NotFound(w, r)
+		return
+	}
+
 	content, err := markdown.MdToHtml([]byte(article.Body))
 	if err != nil {
 		a.errorHandlers.BadRequest(w, r)
@@ -280,11 +397,246 @@ func (a ArticleController) PublicGetArticle(
 		w,
 		"public",
 		ArticleView{
+			Path:    r.URL.Path,
 			Article: article,
 			Content: template.HTML(content),
 		},
 	); err != nil {
-		a.errorHandlers.InternalServerError(w, r)
+		a.errorHandlers.InternalServerError(w, r, err)
+		return
+	}
+}
+
+func (a ArticleController) PublicSearchHandler(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	search, err := a.articleService.Search(
+		r.URL.Query().Get("q"),
+		pagination.FromQuery(r.URL.Query()),
+		StatusPublished,
+	)
+	if err != nil {
+		a.errorHandlers.InternalServerError(w, r, err)
 		return
 	}
+
+	search.Results.Params = url.Values{"q": {search.Query}}
+
+	if err := a.templates["pages/public/search.tmpl"].ExecuteTemplate(
+		w,
+		"public",
+		ArticleSearchView{
+			Path:   r.URL.Path,
+			Search: search,
+		},
+	); err != nil {
+		a.errorHandlers.InternalServerError(w, r, err)
+		return
+	}
+}
+
+func (a ArticleController) RevisionsHandler(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	slug := r.PathValue("slug")
+
+	article, err := a.articleService.GetByAttribute("slug", slug)
+	if err != nil {
+		a.errorHandlers.NotFound(w, r)
+		return
+	}
+
+	revisions, err := a.articleService.GetRevisions(article.Id)
+	if err != nil {
+		a.errorHandlers.InternalServerError(w, r, err)
+		return
+	}
+
+	from := r.URL.Query().Get("from")
+	to := r.URL.Query().Get("to")
+
+	var lines []diff.Line
+
+	if from != "" && to != "" {
+		fromText, err := a.revisionText(article, from)
+		if err != nil {
+			a.errorHandlers.BadRequest(w, r)
+			return
+		}
+
+		toText, err := a.revisionText(article, to)
+		if err != nil {
+			a.errorHandlers.BadRequest(w, r)
+			return
+		}
+
+		lines = diff.Lines(fromText, toText)
+	}
+
+	if err := a.templates["pages/admin/revisions.tmpl"].ExecuteTemplate(
+		w,
+		"admin",
+		ArticleRevisionsView{
+			Message:         a.session.PopString(r.Context(), session.SESSION_KEY_MESSAGE),
+			Article:         article,
+			Revisions:       revisions,
+			From:            from,
+			To:              to,
+			Diff:            lines,
+			CsrfToken:       a.csrfToken(r),
+			IsAuthenticated: a.session.Exists(r.Context(), string(session.IS_LOGGED_IN_CONTEXT_KEY)),
+		},
+	); err != nil {
+		a.errorHandlers.InternalServerError(w, r, err)
+		return
+	}
+}
+
+func (a ArticleController) RestoreRevisionHandler(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	slug := r.PathValue("slug")
+
+	if _, ok := a.authorize(w, r, slug); !ok {
+		return
+	}
+
+	revisionId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		a.errorHandlers.BadRequest(w, r)
+		return
+	}
+
+	article, err := a.articleService.RestoreRevision(slug, revisionId)
+	if err != nil {
+		switch err {
+		case ErrRevisionNotFound:
+			a.errorHandlers.NotFound(w, r)
+		case ErrRevisionNotForArticle:
+			a.errorHandlers.BadRequest(w, r)
+		default:
+			a.errorHandlers.InternalServerError(w, r, err)
+		}
+
+		return
+	}
+
+	a.session.Put(
+		r.Context(),
+		session.SESSION_KEY_MESSAGE,
+		fmt.Sprintf("Restored revision %d.", revisionId),
+	)
+
+	http.Redirect(
+		w,
+		r,
+		fmt.Sprintf("/admin/articles/%s/revisions", article.Slug),
+		http.StatusSeeOther,
+	)
+}
+
+// loggedInUser returns the user whose permissions were checked for the
+// request.
+func (a ArticleController) loggedInUser(r *http.Request) (*user.UserResponseDto, bool) {
+	loggedInUser, ok := r.Context().Value(session.LOGGED_IN_USER_CONTEXT_KEY).(*user.UserResponseDto)
+
+	return loggedInUser, ok
+}
+
+// authorize gets the article with slug and checks the logged in user can
+// change it, e.g. that an author wrote it, writing an error response if not.
+func (a ArticleController) authorize(
+	w http.ResponseWriter,
+	r *http.Request,
+	slug string,
+) (*ArticleResponseDto, bool) {
+	article, err := a.articleService.GetByAttribute("slug", slug)
+	if err != nil {
+		a.errorHandlers.NotFound(w, r)
+		return nil, false
+	}
+
+	loggedInUser, ok := a.loggedInUser(r)
+	if !ok || !loggedInUser.CanEdit(article.AuthorId) {
+		a.errorHandlers.Forbidden(w, r)
+		return nil, false
+	}
+
+	return article, true
+}
+
+// revisionText renders either the current article or one of its revisions as
+// a single block of text that can be compared line by line.
+func (a ArticleController) revisionText(
+	article *ArticleResponseDto,
+	revision string,
+) (string, error) {
+	if revision == currentRevision {
+		return snapshotText(article.Title, article.Subtitle, article.Slug, article.Body), nil
+	}
+
+	revisionId, err := strconv.Atoi(revision)
+	if err != nil {
+		return "", err
+	}
+
+	saved, err := a.articleService.GetRevisionById(revisionId)
+	if err != nil {
+		return "", err
+	}
+
+	if saved.ArticleId != article.Id {
+		return "", ErrRevisionNotForArticle
+	}
+
+	return snapshotText(saved.Title, saved.Subtitle, saved.Slug, saved.Body), nil
+}
+
+func snapshotText(title, subtitle, slug, body string) string {
+	return fmt.Sprintf(
+		"title: %s\nsubtitle: %s\nslug: %s\n\n%s",
+		title,
+		subtitle,
+		slug,
+		body,
+	)
+}
+
+// parsePublishedAt reads the publish date from the article form. Articles that
+// go live straight away without a date given are stamped with the current
+// time, so they sort and display correctly alongside scheduled ones.
+func parsePublishedAt(value, status string) (*time.Time, error) {
+	if value == "" {
+		if status == StatusPublished || status == StatusUnlisted {
+			now := time.Now()
+			return &now, nil
+		}
+
+		return nil, nil
+	}
+
+	publishedAt, err := time.ParseInLocation(publishedAtFormat, value, time.Local)
+	if err != nil {
+		return nil, err
+	}
+
+	return &publishedAt, nil
+}
+
+// articleErrorMessage describes errors caused by what was entered, so they can
+// be shown to the author rather than failing the request.
+func articleErrorMessage(err error) (string, bool) {
+	if _, ok := err.(validator.ValidationErrors); ok {
+		return "Unable to save article: check the title, subtitle, slug, body and status.", true
+	}
+
+	switch err {
+	case ErrArticleWithoutTags, ErrScheduledWithoutDate:
+		return fmt.Sprintf("Unable to save article: %s.", err), true
+	}
+
+	return "", false
 }
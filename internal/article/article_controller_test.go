@@ -1 +1,494 @@
 package article
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/nixpig/dunce/internal/tag"
+	"github.com/nixpig/dunce/internal/user"
+	"github.com/nixpig/dunce/pkg/pagination"
+	"github.com/nixpig/dunce/pkg/session"
+	"github.com/nixpig/dunce/pkg/templates"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var mockTemplateCache = templates.TemplateCache{
+	"pages/admin/new-article.tmpl": mockTemplate,
+	"pages/admin/article.tmpl":     mockTemplate,
+}
+
+var mockArticleService = new(MockArticleService)
+var mockTagService = new(MockTagService)
+var mockSessionManager = new(MockSessionManager)
+var mockErrorHandlers = new(MockErrorHandlers)
+
+func TestArticleController(t *testing.T) {
+	scenarios := map[string]func(t *testing.T, ctrl ArticleController){
+		"post create article (error - validation)":               testPostCreateArticleValidationError,
+		"post create article (error - no tags)":                  testPostCreateArticleWithoutTags,
+		"post update article (error - scheduled without date)":   testPostUpdateArticleScheduledWithoutDate,
+		"post update article (success)":                          testPostUpdateArticle,
+		"post update article (error - id of another article)":    testPostUpdateArticleOtherId,
+		"post update article (error - another author's article)": testPostUpdateArticleOtherAuthor,
+		"post delete article (success)":                          testPostDeleteArticle,
+		"post delete article (error - id of another article)":    testPostDeleteArticleOtherId,
+	}
+
+	for scenario, fn := range scenarios {
+		t.Run(scenario, func(t *testing.T) {
+			config := ArticleControllerConfig{
+				Log:            new(MockLogger),
+				TemplateCache:  mockTemplateCache,
+				SessionManager: mockSessionManager,
+				CsrfToken: func(r *http.Request) string {
+					return "mock-token"
+				},
+				ErrorHandlers: mockErrorHandlers,
+			}
+
+			ctrl := NewArticleController(mockArticleService, mockTagService, config)
+
+			fn(t, ctrl)
+		})
+	}
+}
+
+// the logged in author in these tests wrote 'own-article' (id 23), and another
+// author wrote 'other-article' (id 42)
+var testAuthor = &user.UserResponseDto{Id: 7, Username: "janedoe", Role: user.RoleAuthor}
+
+func authoredBy(id int) *int {
+	return &id
+}
+
+func newArticleFormRequest(t *testing.T, path, slug string, form url.Values) *http.Request {
+	req, err := http.NewRequest("POST", path, strings.NewReader(form.Encode()))
+	if err != nil {
+		t.Error("unable to construct request")
+	}
+
+	req.SetPathValue("slug", slug)
+	req.Header.Add("content-type", "application/x-www-form-urlencoded")
+
+	return req.WithContext(
+		context.WithValue(req.Context(), session.LOGGED_IN_USER_CONTEXT_KEY, testAuthor),
+	)
+}
+
+func updateArticleForm(id string) url.Values {
+	return url.Values{
+		"id":         {id},
+		"title":      {"Article title"},
+		"subtitle":   {"Article subtitle"},
+		"slug":       {"own-article"},
+		"body":       {"Article body"},
+		"status":     {StatusDraft},
+		"created_at": {time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC).Format(longTimeFormat)},
+		"tags[]":     {"1"},
+	}
+}
+
+func mockGetArticles() func() {
+	mockGetOwn := mockArticleService.
+		On("GetByAttribute", "slug", "own-article").
+		Return(&ArticleResponseDto{Id: 23, Slug: "own-article", AuthorId: authoredBy(7)}, nil)
+
+	mockGetOther := mockArticleService.
+		On("GetByAttribute", "slug", "other-article").
+		Return(&ArticleResponseDto{Id: 42, Slug: "other-article", AuthorId: authoredBy(8)}, nil)
+
+	return func() {
+		mockGetOwn.Unset()
+		mockGetOther.Unset()
+	}
+}
+
+func testPostCreateArticleValidationError(t *testing.T, ctrl ArticleController) {
+	form := updateArticleForm("")
+	form.Set("title", "")
+
+	req := newArticleFormRequest(t, "/admin/articles", "", form)
+
+	mockCreate := mockArticleService.
+		On("Create", mock.Anything).
+		Return(&ArticleResponseDto{}, validator.ValidationErrors{})
+	defer mockCreate.Unset()
+
+	mockSessionManagerPut := mockSessionManager.On(
+		"Put",
+		req.Context(),
+		session.SESSION_KEY_MESSAGE,
+		"Unable to save article: check the title, subtitle, slug, body and status.",
+	)
+	defer mockSessionManagerPut.Unset()
+
+	rr := httptest.NewRecorder()
+
+	http.HandlerFunc(ctrl.CreateHandler).ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusSeeOther, rr.Result().StatusCode, "should return status code see other")
+	require.Equal(t, "/admin/articles/new", rr.Result().Header.Get("Location"), "should redirect back to new article")
+
+	if res := mockSessionManager.AssertExpectations(t); !res {
+		t.Error("should put message in session context")
+	}
+}
+
+func testPostCreateArticleWithoutTags(t *testing.T, ctrl ArticleController) {
+	form := updateArticleForm("")
+	form.Del("tags[]")
+
+	req := newArticleFormRequest(t, "/admin/articles", "", form)
+
+	mockCreate := mockArticleService.
+		On("Create", mock.Anything).
+		Return(&ArticleResponseDto{}, ErrArticleWithoutTags)
+	defer mockCreate.Unset()
+
+	mockSessionManagerPut := mockSessionManager.On(
+		"Put",
+		req.Context(),
+		session.SESSION_KEY_MESSAGE,
+		"Unable to save article: article must have at least one tag.",
+	)
+	defer mockSessionManagerPut.Unset()
+
+	rr := httptest.NewRecorder()
+
+	http.HandlerFunc(ctrl.CreateHandler).ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusSeeOther, rr.Result().StatusCode, "should return status code see other")
+	require.Equal(t, "/admin/articles/new", rr.Result().Header.Get("Location"), "should redirect back to new article")
+
+	if res := mockSessionManager.AssertExpectations(t); !res {
+		t.Error("should put message in session context")
+	}
+}
+
+func testPostUpdateArticleScheduledWithoutDate(t *testing.T, ctrl ArticleController) {
+	defer mockGetArticles()()
+
+	form := updateArticleForm("23")
+	form.Set("status", StatusScheduled)
+
+	req := newArticleFormRequest(t, "/admin/articles/own-article", "own-article", form)
+
+	mockUpdate := mockArticleService.
+		On("Update", mock.Anything).
+		Return(&ArticleResponseDto{}, ErrScheduledWithoutDate)
+	defer mockUpdate.Unset()
+
+	mockSessionManagerPut := mockSessionManager.On(
+		"Put",
+		req.Context(),
+		session.SESSION_KEY_MESSAGE,
+		"Unable to save article: scheduled article must have a publish date.",
+	)
+	defer mockSessionManagerPut.Unset()
+
+	rr := httptest.NewRecorder()
+
+	http.HandlerFunc(ctrl.UpdateHandler).ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusSeeOther, rr.Result().StatusCode, "should return status code see other")
+	require.Equal(t, "/admin/articles/own-article", rr.Result().Header.Get("Location"), "should redirect back to article")
+
+	if res := mockSessionManager.AssertExpectations(t); !res {
+		t.Error("should put message in session context")
+	}
+}
+
+func testPostUpdateArticle(t *testing.T, ctrl ArticleController) {
+	defer mockGetArticles()()
+
+	req := newArticleFormRequest(t, "/admin/articles/own-article", "own-article", updateArticleForm("23"))
+
+	var updated *ArticleUpdateRequestDto
+
+	mockUpdate := mockArticleService.
+		On("Update", mock.Anything).
+		Run(func(args mock.Arguments) {
+			updated = args.Get(0).(*ArticleUpdateRequestDto)
+		}).
+		Return(&ArticleResponseDto{Id: 23, Slug: "own-article"}, nil)
+
+	defer mockUpdate.Unset()
+
+	rr := httptest.NewRecorder()
+
+	http.HandlerFunc(ctrl.UpdateHandler).ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusSeeOther, rr.Result().StatusCode, "should return status code see other")
+	require.Equal(t, "/admin/articles", rr.Result().Header.Get("Location"), "should redirect to articles")
+	require.Equal(t, 23, updated.Id, "should update the authorized article")
+}
+
+func testPostUpdateArticleOtherId(t *testing.T, ctrl ArticleController) {
+	defer mockGetArticles()()
+
+	// no expectation on Update, so updating either article would fail the test
+	req := newArticleFormRequest(t, "/admin/articles/own-article", "own-article", updateArticleForm("42"))
+
+	rr := httptest.NewRecorder()
+
+	mockBadRequest := mockErrorHandlers.On("BadRequest", rr, mock.Anything)
+	defer mockBadRequest.Unset()
+
+	http.HandlerFunc(ctrl.UpdateHandler).ServeHTTP(rr, req)
+
+	if res := mockErrorHandlers.AssertExpectations(t); !res {
+		t.Error("should return bad request")
+	}
+}
+
+func testPostUpdateArticleOtherAuthor(t *testing.T, ctrl ArticleController) {
+	defer mockGetArticles()()
+
+	req := newArticleFormRequest(t, "/admin/articles/other-article", "other-article", updateArticleForm("42"))
+
+	rr := httptest.NewRecorder()
+
+	mockForbidden := mockErrorHandlers.On("Forbidden", rr, mock.Anything)
+	defer mockForbidden.Unset()
+
+	http.HandlerFunc(ctrl.UpdateHandler).ServeHTTP(rr, req)
+
+	if res := mockErrorHandlers.AssertExpectations(t); !res {
+		t.Error("should return forbidden")
+	}
+}
+
+func testPostDeleteArticle(t *testing.T, ctrl ArticleController) {
+	defer mockGetArticles()()
+
+	req := newArticleFormRequest(t, "/admin/articles/own-article/delete", "own-article", url.Values{"id": {"23"}})
+
+	mockDelete := mockArticleService.On("DeleteById", 23).Return(nil)
+	defer mockDelete.Unset()
+
+	rr := httptest.NewRecorder()
+
+	http.HandlerFunc(ctrl.AdminArticlesDeleteHandler).ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusSeeOther, rr.Result().StatusCode, "should return status code see other")
+
+	mockArticleService.AssertCalled(t, "DeleteById", 23)
+}
+
+func testPostDeleteArticleOtherId(t *testing.T, ctrl ArticleController) {
+	defer mockGetArticles()()
+
+	// no expectation on DeleteById, so deleting either article would fail the
+	// test
+	req := newArticleFormRequest(t, "/admin/articles/own-article/delete", "own-article", url.Values{"id": {"42"}})
+
+	rr := httptest.NewRecorder()
+
+	mockBadRequest := mockErrorHandlers.On("BadRequest", rr, mock.Anything)
+	defer mockBadRequest.Unset()
+
+	http.HandlerFunc(ctrl.AdminArticlesDeleteHandler).ServeHTTP(rr, req)
+
+	if res := mockErrorHandlers.AssertExpectations(t); !res {
+		t.Error("should return bad request")
+	}
+}
+
+type MockArticleService struct {
+	mock.Mock
+}
+
+func (m *MockArticleService) DeleteById(id int) error {
+	args := m.Called(id)
+
+	return args.Error(0)
+}
+
+func (m *MockArticleService) Create(a *ArticleNewRequestDto) (*ArticleResponseDto, error) {
+	args := m.Called(a)
+
+	return args.Get(0).(*ArticleResponseDto), args.Error(1)
+}
+
+func (m *MockArticleService) GetAll(page pagination.Request) (*pagination.Page[ArticleResponseDto], error) {
+	args := m.Called(page)
+
+	return args.Get(0).(*pagination.Page[ArticleResponseDto]), args.Error(1)
+}
+
+func (m *MockArticleService) GetManyByAttribute(
+	attr, value string,
+	page pagination.Request,
+) (*pagination.Page[ArticleResponseDto], error) {
+	args := m.Called(attr, value, page)
+
+	return args.Get(0).(*pagination.Page[ArticleResponseDto]), args.Error(1)
+}
+
+func (m *MockArticleService) GetByAttribute(attr, value string) (*ArticleResponseDto, error) {
+	args := m.Called(attr, value)
+
+	return args.Get(0).(*ArticleResponseDto), args.Error(1)
+}
+
+func (m *MockArticleService) Update(a *ArticleUpdateRequestDto) (*ArticleResponseDto, error) {
+	args := m.Called(a)
+
+	return args.Get(0).(*ArticleResponseDto), args.Error(1)
+}
+
+func (m *MockArticleService) PublishScheduled(now time.Time) (*[]ArticleResponseDto, error) {
+	args := m.Called(now)
+
+	return args.Get(0).(*[]ArticleResponseDto), args.Error(1)
+}
+
+func (m *MockArticleService) GetRevisions(articleId int) (*[]ArticleRevisionResponseDto, error) {
+	args := m.Called(articleId)
+
+	return args.Get(0).(*[]ArticleRevisionResponseDto), args.Error(1)
+}
+
+func (m *MockArticleService) GetRevisionById(id int) (*ArticleRevisionResponseDto, error) {
+	args := m.Called(id)
+
+	return args.Get(0).(*ArticleRevisionResponseDto), args.Error(1)
+}
+
+func (m *MockArticleService) RestoreRevision(slug string, revisionId int) (*ArticleResponseDto, error) {
+	args := m.Called(slug, revisionId)
+
+	return args.Get(0).(*ArticleResponseDto), args.Error(1)
+}
+
+func (m *MockArticleService) Search(
+	query string,
+	page pagination.Request,
+	statuses ...string,
+) (*ArticleSearchResponseDto, error) {
+	args := m.Called(query, page, statuses)
+
+	return args.Get(0).(*ArticleSearchResponseDto), args.Error(1)
+}
+
+type MockTagService struct {
+	mock.Mock
+}
+
+func (m *MockTagService) Create(t *tag.TagNewRequestDto) (*tag.TagResponseDto, error) {
+	args := m.Called(t)
+
+	return args.Get(0).(*tag.TagResponseDto), args.Error(1)
+}
+
+func (m *MockTagService) DeleteById(id int) error {
+	args := m.Called(id)
+
+	return args.Error(0)
+}
+
+func (m *MockTagService) GetAll(page pagination.Request) (*pagination.Page[tag.TagResponseDto], error) {
+	args := m.Called(page)
+
+	return args.Get(0).(*pagination.Page[tag.TagResponseDto]), args.Error(1)
+}
+
+func (m *MockTagService) GetByAttribute(attr, value string) (*tag.TagResponseDto, error) {
+	args := m.Called(attr, value)
+
+	return args.Get(0).(*tag.TagResponseDto), args.Error(1)
+}
+
+func (m *MockTagService) Update(t *tag.TagUpdateRequestDto) (*tag.TagResponseDto, error) {
+	args := m.Called(t)
+
+	return args.Get(0).(*tag.TagResponseDto), args.Error(1)
+}
+
+var mockTemplate = new(MockTemplate)
+
+type MockTemplate struct {
+	mock.Mock
+}
+
+func (t *MockTemplate) ExecuteTemplate(wr io.Writer, name string, data any) error {
+	args := t.Called(wr, name, data)
+
+	return args.Error(0)
+}
+
+type MockSessionManager struct {
+	mock.Mock
+}
+
+func (s *MockSessionManager) Exists(ctx context.Context, key string) bool {
+	args := s.Called(ctx, key)
+
+	return args.Bool(0)
+}
+
+func (s *MockSessionManager) PopString(ctx context.Context, key string) string {
+	args := s.Called(ctx, key)
+
+	return args.String(0)
+}
+
+func (s *MockSessionManager) GetString(ctx context.Context, key string) string {
+	args := s.Called(ctx, key)
+
+	return args.String(0)
+}
+
+func (s *MockSessionManager) GetInt(ctx context.Context, key string) int {
+	args := s.Called(ctx, key)
+
+	return args.Int(0)
+}
+
+func (s *MockSessionManager) LoadAndSave(next http.Handler) http.Handler {
+	args := s.Called(next)
+
+	return args.Get(0).(http.Handler)
+}
+
+func (s *MockSessionManager) RenewToken(ctx context.Context) error {
+	args := s.Called(ctx)
+
+	return args.Error(0)
+}
+
+func (s *MockSessionManager) Put(ctx context.Context, key string, val interface{}) {
+	s.Called(ctx, key, val)
+}
+
+func (s *MockSessionManager) Remove(ctx context.Context, key string) {
+	s.Called(ctx, key)
+}
+
+type MockErrorHandlers struct {
+	mock.Mock
+}
+
+func (e *MockErrorHandlers) NotFound(w http.ResponseWriter, r *http.Request) {
+	e.Called(w, r)
+}
+
+func (e *MockErrorHandlers) InternalServerError(w http.ResponseWriter, r *http.Request, err error) {
+	e.Called(w, r, err)
+}
+
+func (e *MockErrorHandlers) BadRequest(w http.ResponseWriter, r *http.Request) {
+	e.Called(w, r)
+}
+
+func (e *MockErrorHandlers) Forbidden(w http.ResponseWriter, r *http.Request) {
+	e.Called(w, r)
+}
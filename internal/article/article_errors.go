@@ -0,0 +1,10 @@
+package article
+
+import "errors"
+
+var (
+	ErrScheduledWithoutDate  = errors.New("scheduled article must have a publish date")
+	ErrArticleWithoutTags    = errors.New("article must have at least one tag")
+	ErrRevisionNotForArticle = errors.New("revision does not belong to article")
+	ErrRevisionNotFound      = errors.New("revision not found")
+)
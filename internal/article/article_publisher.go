@@ -0,0 +1,69 @@
+package article
+
+import (
+	"context"
+	"time"
+
+	"github.com/nixpig/dunce/pkg/logging"
+)
+
+type ArticlePublisher struct {
+	articleService ArticleService
+	log            logging.Logger
+	interval       time.Duration
+}
+
+func NewArticlePublisher(
+	articleService ArticleService,
+	log logging.Logger,
+	interval time.Duration,
+) ArticlePublisher {
+	return ArticlePublisher{
+		articleService: articleService,
+		log:            log,
+		interval:       interval,
+	}
+}
+
+// Start publishes any scheduled articles that are due, then keeps checking on
+// every tick of the interval until ctx is cancelled. Scheduled articles live in
+// the database rather than in memory, so anything that fell due while the
+// server was down gets picked up on the first run after a restart.
+func (p ArticlePublisher) Start(ctx context.Context) {
+	p.log.Info(ctx, "starting scheduled article publisher", "interval", p.interval)
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	p.publishDue(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			p.log.Info(ctx, "stopping scheduled article publisher")
+			return
+		case <-ticker.C:
+			p.publishDue(ctx)
+		}
+	}
+}
+
+func (p ArticlePublisher) publishDue(ctx context.Context) {
+	articles, err := p.articleService.PublishScheduled(time.Now())
+	if err != nil {
+		p.log.Error(ctx, "failed to publish scheduled articles", "err", err)
+		return
+	}
+
+	for _, article := range *articles {
+		p.log.Info(
+			ctx,
+			"published scheduled article",
+			"slug", article.Slug,
+			"id", article.Id,
+			"from", StatusScheduled,
+			"to", article.Status,
+			"scheduled_for", article.PublishedAt,
+		)
+	}
+}
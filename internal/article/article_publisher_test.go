@@ -0,0 +1,118 @@
+package article
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockLogger struct {
+	mock.Mock
+}
+
+func (l *MockLogger) Debug(ctx context.Context, msg string, args ...any) {
+	l.Called(msg, args)
+}
+
+func (l *MockLogger) Info(ctx context.Context, msg string, args ...any) {
+	l.Called(msg, args)
+}
+
+func (l *MockLogger) Warn(ctx context.Context, msg string, args ...any) {
+	l.Called(msg, args)
+}
+
+func (l *MockLogger) Error(ctx context.Context, msg string, args ...any) {
+	l.Called(msg, args)
+}
+
+func TestArticlePublisher(t *testing.T) {
+	scenarios := map[string]func(t *testing.T, mockRepo *MockArticleRepository, mockLogger *MockLogger){
+		"publish due articles on start (success)": testArticlePublisherPublishesDueOnStart,
+		"publish due articles on start (error)":   testArticlePublisherLogsRepoError,
+	}
+
+	for scenario, fn := range scenarios {
+		t.Run(scenario, func(t *testing.T) {
+			fn(t, new(MockArticleRepository), new(MockLogger))
+		})
+	}
+}
+
+func testArticlePublisherPublishesDueOnStart(
+	t *testing.T,
+	mockRepo *MockArticleRepository,
+	mockLogger *MockLogger,
+) {
+	publishedAt := time.Now().Add(time.Minute * -1)
+
+	mockRepo.
+		On("PublishScheduled", mock.AnythingOfType("time.Time")).
+		Return(&[]Article{
+			{Id: 23, Slug: "article-slug", Status: StatusPublished, PublishedAt: &publishedAt},
+		}, nil)
+
+	mockLogger.On("Info", mock.Anything, mock.Anything)
+
+	publisher := NewArticlePublisher(
+		NewArticleService(mockRepo, validate),
+		mockLogger,
+		time.Hour,
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	publisher.Start(ctx)
+
+	if res := mockRepo.AssertExpectations(t); !res {
+		t.Error("should publish scheduled articles on start")
+	}
+
+	mockLogger.AssertCalled(
+		t,
+		"Info",
+		"published scheduled article",
+		[]any{
+			"slug", "article-slug",
+			"id", 23,
+			"from", StatusScheduled,
+			"to", StatusPublished,
+			"scheduled_for", &publishedAt,
+		},
+	)
+}
+
+func testArticlePublisherLogsRepoError(
+	t *testing.T,
+	mockRepo *MockArticleRepository,
+	mockLogger *MockLogger,
+) {
+	mockRepo.
+		On("PublishScheduled", mock.AnythingOfType("time.Time")).
+		Return(&[]Article{}, errors.New("repo_error"))
+
+	mockLogger.On("Info", mock.Anything, mock.Anything)
+	mockLogger.On("Error", mock.Anything, mock.Anything)
+
+	publisher := NewArticlePublisher(
+		NewArticleService(mockRepo, validate),
+		mockLogger,
+		time.Hour,
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	publisher.Start(ctx)
+
+	mockLogger.AssertCalled(
+		t,
+		"Error",
+		"failed to publish scheduled articles",
+		[]any{"err", errors.New("repo_error")},
+	)
+}
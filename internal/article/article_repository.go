@@ -5,19 +5,57 @@ import (
 	"errors"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/nixpig/dunce/db"
 	"github.com/nixpig/dunce/internal/tag"
+	"github.com/nixpig/dunce/pkg/pagination"
 )
 
 type ArticleRepository interface {
 	DeleteById(id int) error
 	Create(article *ArticleNew) (*Article, error)
-	GetAll() (*[]Article, error)
-	GetManyByAttribute(attr, value string) (*[]Article, error)
+	GetAll(page pagination.Request) (*pagination.Page[Article], error)
+	GetManyByAttribute(attr, value string, page pagination.Request) (*pagination.Page[Article], error)
 	GetByAttribute(attr, value string) (*Article, error)
 	Update(article *UpdateArticle) (*Article, error)
+	PublishScheduled(now time.Time) (*[]Article, error)
+	GetRevisions(articleId int) (*[]ArticleRevision, error)
+	GetRevisionById(id int) (*ArticleRevision, error)
+	Search(query string, page pagination.Request, statuses ...string) (*pagination.Page[ArticleSearchResult], error)
+}
+
+// ts_headline marks matches with these control characters rather than HTML,
+// so the snippet can be escaped before the matches are highlighted
+const (
+	headlineStartSel = "\x02"
+	headlineStopSel  = "\x03"
+	headlineOptions  = `StartSel="` + headlineStartSel + `", StopSel="` + headlineStopSel + `", MaxFragments=2, MaxWords=30, MinWords=10, FragmentDelimiter=" … "`
+)
+
+var articleSortable = pagination.Sortable{
+	Columns: map[string]pagination.Column{
+		"id":        {Expr: "a.id_", Type: "integer"},
+		"title":     {Expr: "a.title_", Type: "text"},
+		"published": {Expr: "coalesce(a.published_at_, a.created_at_)", Type: "timestamp"},
+		"updated":   {Expr: "a.updated_at_", Type: "timestamp"},
+	},
+	Id:               "a.id_",
+	DefaultSort:      "published",
+	DefaultDirection: pagination.Desc,
+}
+
+// search results can additionally be sorted by how well they match
+var articleSearchSortable = pagination.Sortable{
+	Columns: map[string]pagination.Column{
+		"rank":      {Expr: "ts_rank(a.search_vector_, q)", Type: "real"},
+		"title":     articleSortable.Columns["title"],
+		"published": articleSortable.Columns["published"],
+	},
+	Id:               "a.id_",
+	DefaultSort:      "rank",
+	DefaultDirection: pagination.Desc,
 }
 
 type articlePostgresRepository struct {
@@ -42,7 +80,7 @@ func (a articlePostgresRepository) DeleteById(id int) error {
 }
 
 func (a articlePostgresRepository) Create(article *ArticleNew) (*Article, error) {
-	articleInsertQuery := `insert into articles_ (title_, subtitle_, slug_, body_, created_at_, updated_at_) values ($1, $2, $3, $4, $5, $6) returning id_, title_, subtitle_, slug_, body_, created_at_, updated_at_`
+	articleInsertQuery := `insert into articles_ (title_, subtitle_, slug_, body_, status_, published_at_, created_at_, updated_at_, author_id_) values ($1, $2, $3, $4, $5, $6, $7, $8, $9) returning id_, title_, subtitle_, slug_, body_, status_, published_at_, created_at_, updated_at_, author_id_`
 	tagInsertQuery := `with tags as (select id_, name_, slug_ from tags_ where id_ = $2), article_tags as (insert into article_tags_ (article_id_, tag_id_) values ($1, $2)) select id_, name_, slug_ from tags`
 
 	tx, err := a.db.Begin(context.Background())
@@ -50,11 +88,11 @@ func (a articlePostgresRepository) Create(article *ArticleNew) (*Article, error)
 		return nil, err
 	}
 
-	row := tx.QueryRow(context.Background(), articleInsertQuery, article.Title, article.Subtitle, article.Slug, article.Body, article.CreatedAt, article.UpdatedAt)
+	row := tx.QueryRow(context.Background(), articleInsertQuery, article.Title, article.Subtitle, article.Slug, article.Body, article.Status, article.PublishedAt, article.CreatedAt, article.UpdatedAt, article.AuthorId)
 
 	var createdArticle Article
 
-	if err := row.Scan(&createdArticle.Id, &createdArticle.Title, &createdArticle.Subtitle, &createdArticle.Slug, &createdArticle.Body, &createdArticle.CreatedAt, &createdArticle.UpdatedAt); err != nil {
+	if err := row.Scan(&createdArticle.Id, &createdArticle.Title, &createdArticle.Subtitle, &createdArticle.Slug, &createdArticle.Body, &createdArticle.Status, &createdArticle.PublishedAt, &createdArticle.CreatedAt, &createdArticle.UpdatedAt, &createdArticle.AuthorId); err != nil {
 		return nil, err
 	}
 
@@ -98,10 +136,19 @@ func (a articlePostgresRepository) Create(article *ArticleNew) (*Article, error)
 	return &createdArticle, nil
 }
 
-func (a articlePostgresRepository) GetAll() (*[]Article, error) {
-	articlesQuery := `select a.id_, a.title_, a.subtitle_, a.slug_, a.body_, a.created_at_, a.updated_at_, array_to_string(array_agg(distinct t.tag_id_), ',', '*') from articles_ a join article_tags_ t on a.id_ = t.article_id_ group by a.id_`
+func (a articlePostgresRepository) GetAll(page pagination.Request) (*pagination.Page[Article], error) {
+	q := articleSortable.Query(page, 1)
+
+	countQuery := `select count(*) from articles_`
+	articlesQuery := `select a.id_, a.title_, a.subtitle_, a.slug_, a.body_, a.status_, a.published_at_, a.created_at_, a.updated_at_, array_to_string(array_agg(distinct t.tag_id_), ',', '*'), ` + q.Cursor + ` from articles_ a join article_tags_ t on a.id_ = t.article_id_ where ` + q.Where + ` group by a.id_ order by ` + q.OrderBy + ` ` + q.Limit
 	tagsQuery := `select id_, name_, slug_ from tags_`
 
+	var total int
+
+	if err := a.db.QueryRow(context.Background(), countQuery).Scan(&total); err != nil {
+		return nil, err
+	}
+
 	tagRows, err := a.db.Query(context.Background(), tagsQuery)
 	if err != nil {
 		return nil, err
@@ -121,7 +168,7 @@ func (a articlePostgresRepository) GetAll() (*[]Article, error) {
 		tagList[singleTag.Id] = singleTag
 	}
 
-	rows, err := a.db.Query(context.Background(), articlesQuery)
+	rows, err := a.db.Query(context.Background(), articlesQuery, q.Args...)
 	if err != nil {
 		return nil, err
 	}
@@ -129,15 +176,19 @@ func (a articlePostgresRepository) GetAll() (*[]Article, error) {
 	defer rows.Close()
 
 	var articles []Article
+	var keys []pagination.Key
 
 	for rows.Next() {
 		var article Article
 		var articleTagIdsConcat string
+		var key pagination.Key
 
-		if err := rows.Scan(&article.Id, &article.Title, &article.Subtitle, &article.Slug, &article.Body, &article.CreatedAt, &article.UpdatedAt, &articleTagIdsConcat); err != nil {
+		if err := rows.Scan(&article.Id, &article.Title, &article.Subtitle, &article.Slug, &article.Body, &article.Status, &article.PublishedAt, &article.CreatedAt, &article.UpdatedAt, &articleTagIdsConcat, &key.Value); err != nil {
 			return nil, err
 		}
 
+		key.Id = int64(article.Id)
+
 		articleTagIds := strings.Split(articleTagIdsConcat, ",")
 
 		articleTags := make([]tag.Tag, len(articleTagIds))
@@ -155,43 +206,122 @@ func (a articlePostgresRepository) GetAll() (*[]Article, error) {
 		article.Tags = articleTags
 
 		articles = append(articles, article)
+		keys = append(keys, key)
 	}
 
-	return &articles, nil
+	return pagination.NewPage(q, articles, keys, total), nil
 }
 
-func (a articlePostgresRepository) GetManyByAttribute(attr, value string) (*[]Article, error) {
-	var articleQuery string
-	var err error
+func (a articlePostgresRepository) GetManyByAttribute(attr, value string, page pagination.Request) (*pagination.Page[Article], error) {
+	var articlesFrom string
 
 	switch attr {
+	case "status":
+		articlesFrom = `from articles_ a where a.status_ = $1`
+
 	case "tagSlug":
-		articleQuery = `select a.id_, a.title_, a.subtitle_, a.slug_, a.body_, a.created_at_, a.updated_at_ from articles_ a inner join article_tags_ at on a.id_ = at.article_id_ inner join tags_ t on at.tag_id_ = t.id_ where t.slug_ = $1`
+		// tag listings are only ever public, so only include published articles
+		articlesFrom = `from articles_ a inner join article_tags_ at on a.id_ = at.article_id_ inner join tags_ t on at.tag_id_ = t.id_ where t.slug_ = $1 and a.status_ = 'published'`
+
+	case "authorUsername":
+		// author pages are public too, so likewise only published articles
+		articlesFrom = `from articles_ a inner join users_ u on a.author_id_ = u.id_ where u.username_ = $1 and a.status_ = 'published'`
 
 	default:
 		return nil, errors.New("unsupported attribute")
 	}
 
-	rows, err := a.db.Query(context.Background(), articleQuery, value)
+	q := articleSortable.Query(page, 2)
+
+	countQuery := `select count(*) ` + articlesFrom
+	articleQuery := `select a.id_, a.title_, a.subtitle_, a.slug_, a.body_, a.status_, a.published_at_, a.created_at_, a.updated_at_, ` + q.Cursor + ` ` + articlesFrom + ` and ` + q.Where + ` order by ` + q.OrderBy + ` ` + q.Limit
+
+	var total int
+
+	if err := a.db.QueryRow(context.Background(), countQuery, value).Scan(&total); err != nil {
+		return nil, err
+	}
+
+	rows, err := a.db.Query(context.Background(), articleQuery, append([]any{value}, q.Args...)...)
 	if err != nil {
 		return nil, err
 	}
 
+	defer rows.Close()
+
 	var articles []Article
+	var keys []pagination.Key
 
 	for rows.Next() {
 		var article Article
+		var key pagination.Key
 
-		if err := rows.Scan(&article.Id, &article.Title, &article.Subtitle, &article.Slug, &article.Body, &article.CreatedAt, &article.UpdatedAt); err != nil {
+		if err := rows.Scan(&article.Id, &article.Title, &article.Subtitle, &article.Slug, &article.Body, &article.Status, &article.PublishedAt, &article.CreatedAt, &article.UpdatedAt, &key.Value); err != nil {
 			return nil, err
 		}
 
+		key.Id = int64(article.Id)
+
 		articles = append(articles, article)
+		keys = append(keys, key)
 	}
 
-	// TODO: fetch and attach tags to article results
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
-	return &articles, nil
+	rows.Close()
+
+	if err := a.attachTags(articles); err != nil {
+		return nil, err
+	}
+
+	return pagination.NewPage(q, articles, keys, total), nil
+}
+
+// attachTags fetches the tags for all articles in a single query and adds them
+// to each article in place.
+func (a articlePostgresRepository) attachTags(articles []Article) error {
+	if len(articles) == 0 {
+		return nil
+	}
+
+	query := `select at.article_id_, t.id_, t.name_, t.slug_ from article_tags_ at inner join tags_ t on at.tag_id_ = t.id_ where at.article_id_ = any($1) order by t.name_`
+
+	ids := make([]int, len(articles))
+	for index, article := range articles {
+		ids[index] = article.Id
+	}
+
+	rows, err := a.db.Query(context.Background(), query, ids)
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	articleTags := map[int][]tag.Tag{}
+
+	for rows.Next() {
+		var articleId int
+		var articleTag tag.Tag
+
+		if err := rows.Scan(&articleId, &articleTag.Id, &articleTag.Name, &articleTag.Slug); err != nil {
+			return err
+		}
+
+		articleTags[articleId] = append(articleTags[articleId], articleTag)
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for index := range articles {
+		articles[index].Tags = articleTags[articles[index].Id]
+	}
+
+	return nil
 }
 
 func (a articlePostgresRepository) GetByAttribute(attr, value string) (*Article, error) {
@@ -199,7 +329,7 @@ func (a articlePostgresRepository) GetByAttribute(attr, value string) (*Article,
 
 	switch attr {
 	case "slug":
-		articleQuery = `select a.id_, a.title_, a.subtitle_, a.slug_, a.body_, a.created_at_, a.updated_at_, array_to_string(array_agg(distinct t.tag_id_), ',', '*') from articles_ a join article_tags_ t on a.id_ = t.article_id_ where a.slug_ = $1 group by a.id_`
+		articleQuery = `select a.id_, a.title_, a.subtitle_, a.slug_, a.body_, a.status_, a.published_at_, a.created_at_, a.updated_at_, a.author_id_, u.username_, u.display_name_, array_to_string(array_agg(distinct t.tag_id_), ',', '*') from articles_ a join article_tags_ t on a.id_ = t.article_id_ left join users_ u on a.author_id_ = u.id_ where a.slug_ = $1 group by a.id_, u.id_`
 	default:
 		return nil, errors.New("invalid attribute")
 	}
@@ -208,6 +338,7 @@ func (a articlePostgresRepository) GetByAttribute(attr, value string) (*Article,
 
 	var article Article
 	var articleTagIdsConcat string
+	var authorUsername, authorDisplayName *string
 
 	if err := row.Scan(
 		&article.Id,
@@ -215,13 +346,27 @@ func (a articlePostgresRepository) GetByAttribute(attr, value string) (*Article,
 		&article.Subtitle,
 		&article.Slug,
 		&article.Body,
+		&article.Status,
+		&article.PublishedAt,
 		&article.CreatedAt,
 		&article.UpdatedAt,
+		&article.AuthorId,
+		&authorUsername,
+		&authorDisplayName,
 		&articleTagIdsConcat,
 	); err != nil {
 		return nil, err
 	}
 
+	// author is a left join, so the credit is absent once the user is deleted
+	if authorUsername != nil {
+		article.Author = &ArticleAuthor{Username: *authorUsername}
+
+		if authorDisplayName != nil {
+			article.Author.DisplayName = *authorDisplayName
+		}
+	}
+
 	// TODO: hopefully pgx supports scanning postgres arrays so don't need to perform multiple queries or this funky string concat
 	tagsQuery := strings.Join(
 		[]string{
@@ -249,16 +394,19 @@ func (a articlePostgresRepository) GetByAttribute(attr, value string) (*Article,
 }
 
 func (a articlePostgresRepository) Update(article *UpdateArticle) (*Article, error) {
-	updateArticleQuery := `update articles_ set title_ = $2, subtitle_ = $3, slug_ = $4, body_ = $5, created_at_ = $6, updated_at_ = $7 where id_ = $1 returning id_, title_, subtitle_, slug_, body_, created_at_, updated_at_`
+	updateArticleQuery := `update articles_ set title_ = $2, subtitle_ = $3, slug_ = $4, body_ = $5, status_ = $6, published_at_ = $7, created_at_ = $8, updated_at_ = $9 where id_ = $1 returning id_, title_, subtitle_, slug_, body_, status_, published_at_, created_at_, updated_at_`
 	deleteTagsQuery := `delete from article_tags_ where article_id_ = $1`
-	updateTagsQuery := `insert into article_tags_ (article_id_, tag_id_) values ($1, $2) returning tag_id_`
+	updateTagsQuery := `insert into article_tags_ (article_id_, tag_id_) select $1, unnest($2::integer[])`
 	tagsQuery := `select id_, name_, slug_ from tags_`
+	insertRevisionQuery := `insert into article_revisions_ (article_id_, title_, subtitle_, slug_, body_, tag_ids_, created_at_) select a.id_, a.title_, a.subtitle_, a.slug_, a.body_, array(select at.tag_id_ from article_tags_ at where at.article_id_ = a.id_ order by at.tag_id_), a.updated_at_ from articles_ a where a.id_ = $1`
 
 	tagsRows, err := a.db.Query(context.Background(), tagsQuery)
 	if err != nil {
 		return nil, err
 	}
 
+	defer tagsRows.Close()
+
 	tagList := map[int]tag.Tag{}
 
 	for tagsRows.Next() {
@@ -271,53 +419,176 @@ func (a articlePostgresRepository) Update(article *UpdateArticle) (*Article, err
 		tagList[tag.Id] = tag
 	}
 
+	if err := tagsRows.Err(); err != nil {
+		return nil, err
+	}
+
 	tx, err := a.db.Begin(context.Background())
 	if err != nil {
 		return nil, err
 	}
 
-	row := tx.QueryRow(context.Background(), updateArticleQuery, &article.Id, &article.Title, &article.Subtitle, &article.Slug, &article.Body, &article.CreatedAt, &article.UpdatedAt)
+	// does nothing once the transaction's committed
+	defer tx.Rollback(context.Background())
+
+	// snapshot the article as it was before this update, so that an update
+	// can never lose content that isn't recorded somewhere
+	if _, err := tx.Exec(context.Background(), insertRevisionQuery, article.Id); err != nil {
+		return nil, err
+	}
+
+	row := tx.QueryRow(context.Background(), updateArticleQuery, &article.Id, &article.Title, &article.Subtitle, &article.Slug, &article.Body, &article.Status, &article.PublishedAt, &article.CreatedAt, &article.UpdatedAt)
 
 	updatedArticle := Article{}
 
-	if err := row.Scan(&updatedArticle.Id, &updatedArticle.Title, &updatedArticle.Subtitle, &updatedArticle.Slug, &updatedArticle.Body, &updatedArticle.CreatedAt, &updatedArticle.UpdatedAt); err != nil {
+	if err := row.Scan(&updatedArticle.Id, &updatedArticle.Title, &updatedArticle.Subtitle, &updatedArticle.Slug, &updatedArticle.Body, &updatedArticle.Status, &updatedArticle.PublishedAt, &updatedArticle.CreatedAt, &updatedArticle.UpdatedAt); err != nil {
 		return nil, err
 	}
 
-	_, err = tx.Exec(context.Background(), deleteTagsQuery, updatedArticle.Id)
+	if _, err := tx.Exec(context.Background(), deleteTagsQuery, updatedArticle.Id); err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec(context.Background(), updateTagsQuery, updatedArticle.Id, article.TagIds); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(context.Background()); err != nil {
+		return nil, err
+	}
+
+	for _, id := range article.TagIds {
+		updatedArticle.Tags = append(updatedArticle.Tags, tagList[id])
+	}
+
+	return &updatedArticle, nil
+}
+
+func (a articlePostgresRepository) PublishScheduled(now time.Time) (*[]Article, error) {
+	query := `update articles_ set status_ = 'published' where status_ = 'scheduled' and published_at_ <= $1 returning id_, title_, subtitle_, slug_, body_, status_, published_at_, created_at_, updated_at_`
+
+	rows, err := a.db.Query(context.Background(), query, now)
 	if err != nil {
 		return nil, err
 	}
 
-	batch := &pgx.Batch{}
+	defer rows.Close()
 
-	for _, t := range article.TagIds {
-		batch.Queue(updateTagsQuery, updatedArticle.Id, t)
+	var articles []Article
+
+	for rows.Next() {
+		var article Article
+
+		if err := rows.Scan(&article.Id, &article.Title, &article.Subtitle, &article.Slug, &article.Body, &article.Status, &article.PublishedAt, &article.CreatedAt, &article.UpdatedAt); err != nil {
+			return nil, err
+		}
+
+		articles = append(articles, article)
 	}
 
-	br := tx.SendBatch(context.Background(), batch)
+	return &articles, nil
+}
 
-	defer func() {
-		err := br.Close()
-		if err != nil {
-			tx.Rollback(context.Background())
-		} else {
-			tx.Commit(context.Background())
+func (a articlePostgresRepository) GetRevisions(articleId int) (*[]ArticleRevision, error) {
+	query := `select id_, article_id_, title_, subtitle_, slug_, body_, tag_ids_, created_at_ from article_revisions_ where article_id_ = $1 order by created_at_ desc, id_ desc`
+
+	rows, err := a.db.Query(context.Background(), query, articleId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var revisions []ArticleRevision
+
+	for rows.Next() {
+		var revision ArticleRevision
+
+		if err := rows.Scan(&revision.Id, &revision.ArticleId, &revision.Title, &revision.Subtitle, &revision.Slug, &revision.Body, &revision.TagIds, &revision.CreatedAt); err != nil {
+			return nil, err
 		}
-	}()
 
-	// FIXME: this doesn't seem right... check out 11 lines below
-	for range updatedArticle.Tags {
-		var updatedTagId int
+		revisions = append(revisions, revision)
+	}
+
+	return &revisions, nil
+}
+
+func (a articlePostgresRepository) GetRevisionById(id int) (*ArticleRevision, error) {
+	query := `select id_, article_id_, title_, subtitle_, slug_, body_, tag_ids_, created_at_ from article_revisions_ where id_ = $1`
+
+	row := a.db.QueryRow(context.Background(), query, id)
+
+	var revision ArticleRevision
+
+	if err := row.Scan(&revision.Id, &revision.ArticleId, &revision.Title, &revision.Subtitle, &revision.Slug, &revision.Body, &revision.TagIds, &revision.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	return &revision, nil
+}
+
+// Search ranks articles matching query against the weighted search vector
+// maintained by the articles_ trigger. Results can be limited to the given
+// statuses; with none, articles in any status are matched.
+func (a articlePostgresRepository) Search(query string, page pagination.Request, statuses ...string) (*pagination.Page[ArticleSearchResult], error) {
+	q := articleSearchSortable.Query(page, 4)
 
-		row := br.QueryRow()
+	searchFrom := `from articles_ a, websearch_to_tsquery('english', $1) q where a.search_vector_ @@ q and (coalesce(cardinality($2::text[]), 0) = 0 or a.status_ = any($2))`
 
-		if err := row.Scan(&updatedTagId); err != nil {
+	countQuery := `select count(*) ` + searchFrom
+	searchQuery := `select a.id_, a.title_, a.subtitle_, a.slug_, a.body_, a.status_, a.published_at_, a.created_at_, a.updated_at_, ts_headline('english', a.body_, q, $3), ts_rank(a.search_vector_, q), ` + q.Cursor + ` ` + searchFrom + ` and ` + q.Where + ` order by ` + q.OrderBy + ` ` + q.Limit
+
+	var total int
+
+	if err := a.db.QueryRow(context.Background(), countQuery, query, statuses).Scan(&total); err != nil {
+		return nil, err
+	}
+
+	rows, err := a.db.Query(
+		context.Background(),
+		searchQuery,
+		append([]any{query, statuses, headlineOptions}, q.Args...)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var results []ArticleSearchResult
+	var keys []pagination.Key
+
+	for rows.Next() {
+		var result ArticleSearchResult
+		var key pagination.Key
+
+		if err := rows.Scan(
+			&result.Article.Id,
+			&result.Article.Title,
+			&result.Article.Subtitle,
+			&result.Article.Slug,
+			&result.Article.Body,
+			&result.Article.Status,
+			&result.Article.PublishedAt,
+			&result.Article.CreatedAt,
+			&result.Article.UpdatedAt,
+			&result.Headline,
+			&result.Rank,
+			&key.Value,
+		); err != nil {
 			return nil, err
 		}
 
-		updatedArticle.Tags = append(updatedArticle.Tags, tagList[updatedTagId])
+		key.Id = int64(result.Article.Id)
+
+		results = append(results, result)
+		keys = append(keys, key)
 	}
 
-	return &updatedArticle, nil
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return pagination.NewPage(q, results, keys, total), nil
 }
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/nixpig/dunce/internal/tag"
+	"github.com/nixpig/dunce/pkg/pagination"
 	"github.com/pashagolub/pgxmock/v3"
 	"github.com/stretchr/testify/require"
 )
@@ -24,9 +25,21 @@ func TestArticleRepo(t *testing.T) {
 		"test get article (error - tags scan error)":               testArticleRepoGetArticleByAttrTagsScanError,
 		"test get many (error - by unknown attribute)":             testArticleRepoGetManyByUnknownAttr,
 		"test get many (success - single result - by tag slug)":    testArticleRepoGetManyArticlesByTagSlugSingleResult,
+		"test get many (success - by author username)":             testArticleRepoGetManyArticlesByAuthorUsername,
 		"test get many (success - multiple results - by tag slug)": testArticleRepoGetManyArticlesByTagSlugMultipleResults,
+		"test get many (success - by status)":                      testArticleRepoGetManyArticlesByStatus,
+		"test publish scheduled (success)":                         testArticleRepoPublishScheduled,
+		"test publish scheduled (handle db error)":                 testArticleRepoPublishScheduledDbError,
+		"test get revisions (success)":                             testArticleRepoGetRevisions,
+		"test get revision by id (success)":                        testArticleRepoGetRevisionById,
+		"test get revision by id (handle db error)":                testArticleRepoGetRevisionByIdDbError,
+		"test search (success)":                                    testArticleRepoSearch,
+		"test search (handle db error)":                            testArticleRepoSearchDbError,
 		"test get all (success - single result)":                   testArticleRepoGetAllArticlesSingleResult,
 		"test get all (success - multiple results)":                testArticleRepoGetAllArticlesMultipleResults,
+		"test update article (success)":                            testArticleRepoUpdateArticle,
+		"test update article (error - update rolls back)":          testArticleRepoUpdateArticleError,
+		"test update article (error - tags roll back)":             testArticleRepoUpdateArticleTagsError,
 
 		// read
 		// update
@@ -47,10 +60,12 @@ func TestArticleRepo(t *testing.T) {
 }
 
 func testArticleRepoCreateNewArticle(t *testing.T, mock pgxmock.PgxPoolIface, data ArticleRepository) {
-	articleInsertQuery := `insert into articles_ (title_, subtitle_, slug_, body_, created_at_, updated_at_) values ($1, $2, $3, $4, $5, $6) returning id_, title_, subtitle_, slug_, body_, created_at_, updated_at_`
+	articleInsertQuery := `insert into articles_ (title_, subtitle_, slug_, body_, status_, published_at_, created_at_, updated_at_, author_id_) values ($1, $2, $3, $4, $5, $6, $7, $8, $9) returning id_, title_, subtitle_, slug_, body_, status_, published_at_, created_at_, updated_at_, author_id_`
 
 	createdAt := time.Now()
+	publishedAt := createdAt
 	updatedAt := time.Now()
+	authorId := 7
 
 	articleMockRow := mock.NewRows([]string{
 		"id_",
@@ -58,31 +73,40 @@ func testArticleRepoCreateNewArticle(t *testing.T, mock pgxmock.PgxPoolIface, da
 		"subtitle_",
 		"slug_",
 		"body_",
+		"status_",
+		"published_at_",
 		"created_at_",
 		"updated_at_",
+		"author_id_",
 	}).AddRow(
 		13,
 		"article title",
 		"article subtitle",
 		"article-slug",
 		"Lorem ipsum dolar sit amet...",
+		"published",
+		&publishedAt,
 		createdAt,
 		updatedAt,
+		&authorId,
 	)
 
 	mock.ExpectBegin()
 
-	mock.ExpectQuery(regexp.QuoteMeta(articleInsertQuery)).WithArgs("article title", "article subtitle", "article-slug", "Lorem ipsum dolar sit amet...", createdAt, updatedAt).WillReturnRows(articleMockRow)
+	mock.ExpectQuery(regexp.QuoteMeta(articleInsertQuery)).WithArgs("article title", "article subtitle", "article-slug", "Lorem ipsum dolar sit amet...", "published", &publishedAt, createdAt, updatedAt, &authorId).WillReturnRows(articleMockRow)
 	mock.ExpectCommit()
 
 	newArticle := ArticleNew{
-		Title:     "article title",
-		Subtitle:  "article subtitle",
-		Slug:      "article-slug",
-		Body:      "Lorem ipsum dolar sit amet...",
-		CreatedAt: createdAt,
-		UpdatedAt: updatedAt,
-		TagIds:    []int{4},
+		Title:       "article title",
+		Subtitle:    "article subtitle",
+		Slug:        "article-slug",
+		Body:        "Lorem ipsum dolar sit amet...",
+		Status:      "published",
+		PublishedAt: &publishedAt,
+		CreatedAt:   createdAt,
+		UpdatedAt:   updatedAt,
+		AuthorId:    &authorId,
+		TagIds:      []int{4},
 	}
 
 	createdArticle, err := data.Create(&newArticle)
@@ -95,13 +119,16 @@ func testArticleRepoCreateNewArticle(t *testing.T, mock pgxmock.PgxPoolIface, da
 	require.NoError(t, err, "should not error out")
 
 	require.Equal(t, &Article{
-		Id:        13,
-		Title:     "article title",
-		Subtitle:  "article subtitle",
-		Slug:      "article-slug",
-		Body:      "Lorem ipsum dolar sit amet...",
-		CreatedAt: createdAt,
-		UpdatedAt: updatedAt,
+		Id:          13,
+		Title:       "article title",
+		Subtitle:    "article subtitle",
+		Slug:        "article-slug",
+		Body:        "Lorem ipsum dolar sit amet...",
+		Status:      "published",
+		PublishedAt: &publishedAt,
+		CreatedAt:   createdAt,
+		UpdatedAt:   updatedAt,
+		AuthorId:    &authorId,
 		// TODO: add back once pgxmock supports batch
 		// TagIds: []int{4},
 	}, createdArticle, "should return created article data with id")
@@ -165,10 +192,14 @@ func testArticleRepoDeleteArticleError(t *testing.T, mock pgxmock.PgxPoolIface,
 }
 
 func testArticleRepoGetArticleBySlug(t *testing.T, mock pgxmock.PgxPoolIface, repo ArticleRepository) {
-	articleQuery := `select a.id_, a.title_, a.subtitle_, a.slug_, a.body_, a.created_at_, a.updated_at_, array_to_string(array_agg(distinct t.tag_id_), ',', '*') from articles_ a join article_tags_ t on a.id_ = t.article_id_ where a.slug_ = $1 group by a.id_`
+	articleQuery := `select a.id_, a.title_, a.subtitle_, a.slug_, a.body_, a.status_, a.published_at_, a.created_at_, a.updated_at_, a.author_id_, u.username_, u.display_name_, array_to_string(array_agg(distinct t.tag_id_), ',', '*') from articles_ a join article_tags_ t on a.id_ = t.article_id_ left join users_ u on a.author_id_ = u.id_ where a.slug_ = $1 group by a.id_, u.id_`
 
 	createdAt := time.Now()
+	publishedAt := createdAt
 	updatedAt := time.Now().Add(time.Hour * 24)
+	authorId := 7
+	authorUsername := "janedoe"
+	authorDisplayName := ""
 
 	mockArticleRow := mock.NewRows([]string{
 		"id_",
@@ -176,8 +207,13 @@ func testArticleRepoGetArticleBySlug(t *testing.T, mock pgxmock.PgxPoolIface, re
 		"subtitle_",
 		"slug_",
 		"body_",
+		"status_",
+		"published_at_",
 		"created_at_",
 		"updated_at_",
+		"author_id_",
+		"username_",
+		"display_name_",
 		"tag_ids_",
 	}).AddRow(
 		23,
@@ -185,8 +221,13 @@ func testArticleRepoGetArticleBySlug(t *testing.T, mock pgxmock.PgxPoolIface, re
 		"Article subtitle",
 		"article-slug",
 		"Lorem ipsum dolar sit amet",
+		"published",
+		&publishedAt,
 		createdAt,
 		updatedAt,
+		&authorId,
+		&authorUsername,
+		&authorDisplayName,
 		"42,69",
 	)
 
@@ -214,13 +255,17 @@ func testArticleRepoGetArticleBySlug(t *testing.T, mock pgxmock.PgxPoolIface, re
 	require.Nil(t, err, "should not return error")
 
 	require.Equal(t, &Article{
-		Id:        23,
-		Title:     "Article title",
-		Subtitle:  "Article subtitle",
-		Slug:      "article-slug",
-		Body:      "Lorem ipsum dolar sit amet",
-		CreatedAt: createdAt,
-		UpdatedAt: updatedAt,
+		Id:          23,
+		Title:       "Article title",
+		Subtitle:    "Article subtitle",
+		Slug:        "article-slug",
+		Body:        "Lorem ipsum dolar sit amet",
+		Status:      "published",
+		PublishedAt: &publishedAt,
+		CreatedAt:   createdAt,
+		UpdatedAt:   updatedAt,
+		AuthorId:    &authorId,
+		Author:      &ArticleAuthor{Username: "janedoe"},
 		Tags: []tag.Tag{
 			{Id: 42, Name: "tag one", Slug: "tag-one"},
 			{Id: 69, Name: "tag two", Slug: "tag-two"},
@@ -236,7 +281,7 @@ func testArticleRepoGetArticleByInvalidAttr(t *testing.T, mock pgxmock.PgxPoolIf
 }
 
 func testArticleRepoGetArticleByAttrArticleDbError(t *testing.T, mock pgxmock.PgxPoolIface, repo ArticleRepository) {
-	articleQuery := `select a.id_, a.title_, a.subtitle_, a.slug_, a.body_, a.created_at_, a.updated_at_, array_to_string(array_agg(distinct t.tag_id_), ',', '*') from articles_ a join article_tags_ t on a.id_ = t.article_id_ where a.slug_ = $1 group by a.id_`
+	articleQuery := `select a.id_, a.title_, a.subtitle_, a.slug_, a.body_, a.status_, a.published_at_, a.created_at_, a.updated_at_, a.author_id_, u.username_, u.display_name_, array_to_string(array_agg(distinct t.tag_id_), ',', '*') from articles_ a join article_tags_ t on a.id_ = t.article_id_ left join users_ u on a.author_id_ = u.id_ where a.slug_ = $1 group by a.id_, u.id_`
 
 	mock.
 		ExpectQuery(regexp.QuoteMeta(articleQuery)).
@@ -255,9 +300,10 @@ func testArticleRepoGetArticleByAttrArticleDbError(t *testing.T, mock pgxmock.Pg
 }
 
 func testArticleRepoGetArticleByAttrTagsDbError(t *testing.T, mock pgxmock.PgxPoolIface, repo ArticleRepository) {
-	articleQuery := `select a.id_, a.title_, a.subtitle_, a.slug_, a.body_, a.created_at_, a.updated_at_, array_to_string(array_agg(distinct t.tag_id_), ',', '*') from articles_ a join article_tags_ t on a.id_ = t.article_id_ where a.slug_ = $1 group by a.id_`
+	articleQuery := `select a.id_, a.title_, a.subtitle_, a.slug_, a.body_, a.status_, a.published_at_, a.created_at_, a.updated_at_, a.author_id_, u.username_, u.display_name_, array_to_string(array_agg(distinct t.tag_id_), ',', '*') from articles_ a join article_tags_ t on a.id_ = t.article_id_ left join users_ u on a.author_id_ = u.id_ where a.slug_ = $1 group by a.id_, u.id_`
 
 	createdAt := time.Now()
+	publishedAt := createdAt
 	updatedAt := time.Now().Add(time.Hour * 24)
 
 	mockArticleRow := mock.NewRows([]string{
@@ -266,8 +312,13 @@ func testArticleRepoGetArticleByAttrTagsDbError(t *testing.T, mock pgxmock.PgxPo
 		"subtitle_",
 		"slug_",
 		"body_",
+		"status_",
+		"published_at_",
 		"created_at_",
 		"updated_at_",
+		"author_id_",
+		"username_",
+		"display_name_",
 		"tag_ids_",
 	}).AddRow(
 		23,
@@ -275,8 +326,13 @@ func testArticleRepoGetArticleByAttrTagsDbError(t *testing.T, mock pgxmock.PgxPo
 		"Article subtitle",
 		"article-slug",
 		"Lorem ipsum dolar sit amet",
+		"published",
+		&publishedAt,
 		createdAt,
 		updatedAt,
+		(*int)(nil),
+		(*string)(nil),
+		(*string)(nil),
 		"42,69",
 	)
 
@@ -304,35 +360,57 @@ func testArticleRepoGetArticleByAttrTagsDbError(t *testing.T, mock pgxmock.PgxPo
 }
 
 func testArticleRepoGetManyArticlesByTagSlugSingleResult(t *testing.T, mock pgxmock.PgxPoolIface, repo ArticleRepository) {
-	articleQuery := `select a.id_, a.title_, a.subtitle_, a.slug_, a.body_, a.created_at_, a.updated_at_ from articles_ a inner join article_tags_ at on a.id_ = at.article_id_ inner join tags_ t on at.tag_id_ = t.id_ where t.slug_ = $1`
+	countQuery := `select count(*) from articles_ a inner join article_tags_ at on a.id_ = at.article_id_ inner join tags_ t on at.tag_id_ = t.id_ where t.slug_ = $1 and a.status_ = 'published'`
+	articleQuery := `select a.id_, a.title_, a.subtitle_, a.slug_, a.body_, a.status_, a.published_at_, a.created_at_, a.updated_at_, (coalesce(a.published_at_, a.created_at_))::text from articles_ a inner join article_tags_ at on a.id_ = at.article_id_ inner join tags_ t on at.tag_id_ = t.id_ where t.slug_ = $1 and a.status_ = 'published' and true order by coalesce(a.published_at_, a.created_at_) desc, a.id_ desc limit $2 offset $3`
 
 	createdAt := time.Now().Add(time.Hour * -12)
+	publishedAt := createdAt
 	updatedAt := time.Now()
 
+	mock.
+		ExpectQuery(regexp.QuoteMeta(countQuery)).
+		WithArgs("some-slug").
+		WillReturnRows(mock.NewRows([]string{"count"}).AddRow(2))
+
 	mockArticleRow := mock.NewRows([]string{
 		"id_",
 		"title_",
 		"subtitle_",
 		"slug_",
 		"body_",
+		"status_",
+		"published_at_",
 		"created_at_",
 		"updated_at_",
+		"cursor",
 	}).AddRow(
 		23,
 		"Article title",
 		"Article subtitle",
 		"article-slug",
 		"Lorem ipsum dolar sit amet",
+		"published",
+		&publishedAt,
 		createdAt,
 		updatedAt,
+		"cursor",
 	)
 
 	mock.
 		ExpectQuery(regexp.QuoteMeta(articleQuery)).
-		WithArgs("some-slug").
+		WithArgs("some-slug", pagination.DefaultLimit+1, 0).
 		WillReturnRows(mockArticleRow)
 
-	got, err := repo.GetManyByAttribute("tagSlug", "some-slug")
+	tagsQuery := `select at.article_id_, t.id_, t.name_, t.slug_ from article_tags_ at inner join tags_ t on at.tag_id_ = t.id_ where at.article_id_ = any($1) order by t.name_`
+
+	mock.
+		ExpectQuery(regexp.QuoteMeta(tagsQuery)).
+		WithArgs([]int{23}).
+		WillReturnRows(mock.
+			NewRows([]string{"article_id_", "id_", "name_", "slug_"}).
+			AddRow(23, 7, "tag seven", "tag-seven"))
+
+	got, err := repo.GetManyByAttribute("tagSlug", "some-slug", pagination.Request{})
 
 	mock.Reset()
 	if err := mock.ExpectationsWereMet(); err != nil {
@@ -340,63 +418,165 @@ func testArticleRepoGetManyArticlesByTagSlugSingleResult(t *testing.T, mock pgxm
 	}
 
 	require.Nil(t, err, "should not return error")
-	require.Equal(t, &[]Article{{
-		Id:        23,
-		Title:     "Article title",
-		Subtitle:  "Article subtitle",
-		Slug:      "article-slug",
-		Body:      "Lorem ipsum dolar sit amet",
-		CreatedAt: createdAt,
-		UpdatedAt: updatedAt,
-		Tags:      []tag.Tag(nil),
-	}}, got, "should return the article")
+	require.Equal(t, []Article{{
+		Id:          23,
+		Title:       "Article title",
+		Subtitle:    "Article subtitle",
+		Slug:        "article-slug",
+		Body:        "Lorem ipsum dolar sit amet",
+		Status:      "published",
+		PublishedAt: &publishedAt,
+		CreatedAt:   createdAt,
+		UpdatedAt:   updatedAt,
+		Tags:        []tag.Tag{{Id: 7, Name: "tag seven", Slug: "tag-seven"}},
+	}}, got.Items, "should return the article")
+}
+
+func testArticleRepoGetManyArticlesByAuthorUsername(t *testing.T, mock pgxmock.PgxPoolIface, repo ArticleRepository) {
+	countQuery := `select count(*) from articles_ a inner join users_ u on a.author_id_ = u.id_ where u.username_ = $1 and a.status_ = 'published'`
+	articleQuery := `select a.id_, a.title_, a.subtitle_, a.slug_, a.body_, a.status_, a.published_at_, a.created_at_, a.updated_at_, (coalesce(a.published_at_, a.created_at_))::text from articles_ a inner join users_ u on a.author_id_ = u.id_ where u.username_ = $1 and a.status_ = 'published' and true order by coalesce(a.published_at_, a.created_at_) desc, a.id_ desc limit $2 offset $3`
+
+	createdAt := time.Now().Add(time.Hour * -12)
+	publishedAt := createdAt
+	updatedAt := time.Now()
+
+	mock.
+		ExpectQuery(regexp.QuoteMeta(countQuery)).
+		WithArgs("janedoe").
+		WillReturnRows(mock.NewRows([]string{"count"}).AddRow(2))
+
+	mockArticleRow := mock.NewRows([]string{
+		"id_",
+		"title_",
+		"subtitle_",
+		"slug_",
+		"body_",
+		"status_",
+		"published_at_",
+		"created_at_",
+		"updated_at_",
+		"cursor",
+	}).AddRow(
+		23,
+		"Article title",
+		"Article subtitle",
+		"article-slug",
+		"Lorem ipsum dolar sit amet",
+		"published",
+		&publishedAt,
+		createdAt,
+		updatedAt,
+		"cursor",
+	)
+
+	mock.
+		ExpectQuery(regexp.QuoteMeta(articleQuery)).
+		WithArgs("janedoe", pagination.DefaultLimit+1, 0).
+		WillReturnRows(mockArticleRow)
+
+	tagsQuery := `select at.article_id_, t.id_, t.name_, t.slug_ from article_tags_ at inner join tags_ t on at.tag_id_ = t.id_ where at.article_id_ = any($1) order by t.name_`
+
+	mock.
+		ExpectQuery(regexp.QuoteMeta(tagsQuery)).
+		WithArgs([]int{23}).
+		WillReturnRows(mock.
+			NewRows([]string{"article_id_", "id_", "name_", "slug_"}).
+			AddRow(23, 7, "tag seven", "tag-seven"))
+
+	got, err := repo.GetManyByAttribute("authorUsername", "janedoe", pagination.Request{})
+
+	mock.Reset()
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal("expectations not met: ", err)
+	}
+
+	require.Nil(t, err, "should not return error")
+	require.Equal(t, []Article{{
+		Id:          23,
+		Title:       "Article title",
+		Subtitle:    "Article subtitle",
+		Slug:        "article-slug",
+		Body:        "Lorem ipsum dolar sit amet",
+		Status:      "published",
+		PublishedAt: &publishedAt,
+		CreatedAt:   createdAt,
+		UpdatedAt:   updatedAt,
+		Tags:        []tag.Tag{{Id: 7, Name: "tag seven", Slug: "tag-seven"}},
+	}}, got.Items, "should return the article")
 }
 
 func testArticleRepoGetManyByUnknownAttr(t *testing.T, mock pgxmock.PgxPoolIface, repo ArticleRepository) {
-	got, err := repo.GetManyByAttribute("foo", "bar")
+	got, err := repo.GetManyByAttribute("foo", "bar", pagination.Request{})
 
 	require.Nil(t, got, "shouldn't return any articles")
 	require.EqualError(t, err, "unsupported attribute", "should return unsupported attribute error")
 }
 
 func testArticleRepoGetManyArticlesByTagSlugMultipleResults(t *testing.T, mock pgxmock.PgxPoolIface, repo ArticleRepository) {
-	articleQuery := `select a.id_, a.title_, a.subtitle_, a.slug_, a.body_, a.created_at_, a.updated_at_ from articles_ a inner join article_tags_ at on a.id_ = at.article_id_ inner join tags_ t on at.tag_id_ = t.id_ where t.slug_ = $1`
+	countQuery := `select count(*) from articles_ a inner join article_tags_ at on a.id_ = at.article_id_ inner join tags_ t on at.tag_id_ = t.id_ where t.slug_ = $1 and a.status_ = 'published'`
+	articleQuery := `select a.id_, a.title_, a.subtitle_, a.slug_, a.body_, a.status_, a.published_at_, a.created_at_, a.updated_at_, (coalesce(a.published_at_, a.created_at_))::text from articles_ a inner join article_tags_ at on a.id_ = at.article_id_ inner join tags_ t on at.tag_id_ = t.id_ where t.slug_ = $1 and a.status_ = 'published' and true order by coalesce(a.published_at_, a.created_at_) desc, a.id_ desc limit $2 offset $3`
 
 	createdAt := time.Now().Add(time.Hour * -12)
+	publishedAt := createdAt
 	updatedAt := time.Now()
 
+	mock.
+		ExpectQuery(regexp.QuoteMeta(countQuery)).
+		WithArgs("some-slug").
+		WillReturnRows(mock.NewRows([]string{"count"}).AddRow(2))
+
 	mockArticleRow := mock.NewRows([]string{
 		"id_",
 		"title_",
 		"subtitle_",
 		"slug_",
 		"body_",
+		"status_",
+		"published_at_",
 		"created_at_",
 		"updated_at_",
+		"cursor",
 	}).AddRow(
 		23,
 		"Article title one",
 		"Article subtitle one",
 		"article-slug-one",
 		"Lorem ipsum dolar sit amet one",
+		"published",
+		&publishedAt,
 		createdAt,
 		updatedAt,
+		"cursor",
 	).AddRow(
 		42,
 		"Article title two",
 		"Article subtitle two",
 		"article-slug-two",
 		"Lorem ipsum dolar sit amet two",
+		"published",
+		&publishedAt,
 		createdAt,
 		updatedAt,
+		"cursor",
 	)
 
 	mock.
 		ExpectQuery(regexp.QuoteMeta(articleQuery)).
-		WithArgs("some-slug").
+		WithArgs("some-slug", pagination.DefaultLimit+1, 0).
 		WillReturnRows(mockArticleRow)
 
-	got, err := repo.GetManyByAttribute("tagSlug", "some-slug")
+	tagsQuery := `select at.article_id_, t.id_, t.name_, t.slug_ from article_tags_ at inner join tags_ t on at.tag_id_ = t.id_ where at.article_id_ = any($1) order by t.name_`
+
+	mock.
+		ExpectQuery(regexp.QuoteMeta(tagsQuery)).
+		WithArgs([]int{23, 42}).
+		WillReturnRows(mock.
+			NewRows([]string{"article_id_", "id_", "name_", "slug_"}).
+			AddRow(23, 7, "tag seven", "tag-seven").
+			AddRow(42, 7, "tag seven", "tag-seven").
+			AddRow(42, 9, "tag nine", "tag-nine"))
+
+	got, err := repo.GetManyByAttribute("tagSlug", "some-slug", pagination.Request{})
 
 	mock.Reset()
 	if err := mock.ExpectationsWereMet(); err != nil {
@@ -404,33 +584,44 @@ func testArticleRepoGetManyArticlesByTagSlugMultipleResults(t *testing.T, mock p
 	}
 
 	require.Nil(t, err, "should not return error")
-	require.Equal(t, &[]Article{
+	require.Equal(t, []Article{
 		{
-			Id:        23,
-			Title:     "Article title one",
-			Subtitle:  "Article subtitle one",
-			Slug:      "article-slug-one",
-			Body:      "Lorem ipsum dolar sit amet one",
-			CreatedAt: createdAt,
-			UpdatedAt: updatedAt,
-			Tags:      []tag.Tag(nil),
+			Id:          23,
+			Title:       "Article title one",
+			Subtitle:    "Article subtitle one",
+			Slug:        "article-slug-one",
+			Body:        "Lorem ipsum dolar sit amet one",
+			Status:      "published",
+			PublishedAt: &publishedAt,
+			CreatedAt:   createdAt,
+			UpdatedAt:   updatedAt,
+			Tags:        []tag.Tag{{Id: 7, Name: "tag seven", Slug: "tag-seven"}},
 		},
 		{
-			Id:        42,
-			Title:     "Article title two",
-			Subtitle:  "Article subtitle two",
-			Slug:      "article-slug-two",
-			Body:      "Lorem ipsum dolar sit amet two",
-			CreatedAt: createdAt,
-			UpdatedAt: updatedAt,
-			Tags:      []tag.Tag(nil),
+			Id:          42,
+			Title:       "Article title two",
+			Subtitle:    "Article subtitle two",
+			Slug:        "article-slug-two",
+			Body:        "Lorem ipsum dolar sit amet two",
+			Status:      "published",
+			PublishedAt: &publishedAt,
+			CreatedAt:   createdAt,
+			UpdatedAt:   updatedAt,
+			Tags: []tag.Tag{
+				{Id: 7, Name: "tag seven", Slug: "tag-seven"},
+				{Id: 9, Name: "tag nine", Slug: "tag-nine"},
+			},
 		},
-	}, got, "should return the article")
+	}, got.Items, "should return the article")
 }
 
 func testArticleRepoGetAllArticlesSingleResult(t *testing.T, mock pgxmock.PgxPoolIface, repo ArticleRepository) {
+	countQuery := `select count(*) from articles_`
 	tagQuery := `select id_, name_, slug_ from tags_`
 
+	mock.ExpectQuery(regexp.QuoteMeta(countQuery)).
+		WillReturnRows(mock.NewRows([]string{"count"}).AddRow(2))
+
 	mockTagRows := mock.
 		NewRows([]string{"id_", "name_", "slug_"}).
 		AddRow(42, "tag one", "tag-one").
@@ -439,9 +630,10 @@ func testArticleRepoGetAllArticlesSingleResult(t *testing.T, mock pgxmock.PgxPoo
 	mock.ExpectQuery(regexp.QuoteMeta(tagQuery)).WillReturnRows(mockTagRows)
 
 	createdAt := time.Now()
+	publishedAt := createdAt
 	updatedAt := time.Now().Add(time.Hour * 24)
 
-	articleQuery := `select a.id_, a.title_, a.subtitle_, a.slug_, a.body_, a.created_at_, a.updated_at_, array_to_string(array_agg(distinct t.tag_id_), ',', '*') from articles_ a join article_tags_ t on a.id_ = t.article_id_ group by a.id_`
+	articleQuery := `select a.id_, a.title_, a.subtitle_, a.slug_, a.body_, a.status_, a.published_at_, a.created_at_, a.updated_at_, array_to_string(array_agg(distinct t.tag_id_), ',', '*'), (coalesce(a.published_at_, a.created_at_))::text from articles_ a join article_tags_ t on a.id_ = t.article_id_ where true group by a.id_ order by coalesce(a.published_at_, a.created_at_) desc, a.id_ desc limit $1 offset $2`
 
 	mockArticleRow := mock.NewRows([]string{
 		"id_",
@@ -449,25 +641,32 @@ func testArticleRepoGetAllArticlesSingleResult(t *testing.T, mock pgxmock.PgxPoo
 		"subtitle_",
 		"slug_",
 		"body_",
+		"status_",
+		"published_at_",
 		"created_at_",
 		"updated_at_",
 		"tag_ids_",
+		"cursor",
 	}).AddRow(
 		23,
 		"Article title",
 		"Article subtitle",
 		"article-slug",
 		"Lorem ipsum dolar sit amet",
+		"published",
+		&publishedAt,
 		createdAt,
 		updatedAt,
 		"42,69",
+		"cursor",
 	)
 
 	mock.
 		ExpectQuery(regexp.QuoteMeta(articleQuery)).
+		WithArgs(pagination.DefaultLimit+1, 0).
 		WillReturnRows(mockArticleRow)
 
-	got, err := repo.GetAll()
+	got, err := repo.GetAll(pagination.Request{})
 
 	mock.Reset()
 	if err := mock.ExpectationsWereMet(); err != nil {
@@ -476,26 +675,32 @@ func testArticleRepoGetAllArticlesSingleResult(t *testing.T, mock pgxmock.PgxPoo
 
 	require.Nil(t, err, "should not return error")
 
-	require.Equal(t, &[]Article{
+	require.Equal(t, []Article{
 		{
-			Id:        23,
-			Title:     "Article title",
-			Subtitle:  "Article subtitle",
-			Slug:      "article-slug",
-			Body:      "Lorem ipsum dolar sit amet",
-			CreatedAt: createdAt,
-			UpdatedAt: updatedAt,
+			Id:          23,
+			Title:       "Article title",
+			Subtitle:    "Article subtitle",
+			Slug:        "article-slug",
+			Body:        "Lorem ipsum dolar sit amet",
+			Status:      "published",
+			PublishedAt: &publishedAt,
+			CreatedAt:   createdAt,
+			UpdatedAt:   updatedAt,
 			Tags: []tag.Tag{
 				{Id: 42, Name: "tag one", Slug: "tag-one"},
 				{Id: 69, Name: "tag two", Slug: "tag-two"},
 			},
 		},
-	}, got, "should return article")
+	}, got.Items, "should return article")
 }
 
 func testArticleRepoGetAllArticlesMultipleResults(t *testing.T, mock pgxmock.PgxPoolIface, repo ArticleRepository) {
+	countQuery := `select count(*) from articles_`
 	tagQuery := `select id_, name_, slug_ from tags_`
 
+	mock.ExpectQuery(regexp.QuoteMeta(countQuery)).
+		WillReturnRows(mock.NewRows([]string{"count"}).AddRow(2))
+
 	mockTagRows := mock.
 		NewRows([]string{"id_", "name_", "slug_"}).
 		AddRow(42, "tag one", "tag-one").
@@ -504,9 +709,10 @@ func testArticleRepoGetAllArticlesMultipleResults(t *testing.T, mock pgxmock.Pgx
 	mock.ExpectQuery(regexp.QuoteMeta(tagQuery)).WillReturnRows(mockTagRows)
 
 	createdAt := time.Now()
+	publishedAt := createdAt
 	updatedAt := time.Now().Add(time.Hour * 24)
 
-	articleQuery := `select a.id_, a.title_, a.subtitle_, a.slug_, a.body_, a.created_at_, a.updated_at_, array_to_string(array_agg(distinct t.tag_id_), ',', '*') from articles_ a join article_tags_ t on a.id_ = t.article_id_ group by a.id_`
+	articleQuery := `select a.id_, a.title_, a.subtitle_, a.slug_, a.body_, a.status_, a.published_at_, a.created_at_, a.updated_at_, array_to_string(array_agg(distinct t.tag_id_), ',', '*'), (coalesce(a.published_at_, a.created_at_))::text from articles_ a join article_tags_ t on a.id_ = t.article_id_ where true group by a.id_ order by coalesce(a.published_at_, a.created_at_) desc, a.id_ desc limit $1 offset $2`
 
 	mockArticleRow := mock.NewRows([]string{
 		"id_",
@@ -514,34 +720,44 @@ func testArticleRepoGetAllArticlesMultipleResults(t *testing.T, mock pgxmock.Pgx
 		"subtitle_",
 		"slug_",
 		"body_",
+		"status_",
+		"published_at_",
 		"created_at_",
 		"updated_at_",
 		"tag_ids_",
+		"cursor",
 	}).AddRow(
 		23,
 		"Article title one",
 		"Article subtitle one",
 		"article-slug-one",
 		"Lorem ipsum dolar sit amet one",
+		"published",
+		&publishedAt,
 		createdAt,
 		updatedAt,
 		"42,69",
+		"cursor",
 	).AddRow(
 		42,
 		"Article title two",
 		"Article subtitle two",
 		"article-slug-two",
 		"Lorem ipsum dolar sit amet two",
+		"published",
+		&publishedAt,
 		createdAt,
 		updatedAt,
 		"42,69",
+		"cursor",
 	)
 
 	mock.
 		ExpectQuery(regexp.QuoteMeta(articleQuery)).
+		WithArgs(pagination.DefaultLimit+1, 0).
 		WillReturnRows(mockArticleRow)
 
-	got, err := repo.GetAll()
+	got, err := repo.GetAll(pagination.Request{})
 
 	mock.Reset()
 	if err := mock.ExpectationsWereMet(); err != nil {
@@ -550,40 +766,45 @@ func testArticleRepoGetAllArticlesMultipleResults(t *testing.T, mock pgxmock.Pgx
 
 	require.Nil(t, err, "should not return error")
 
-	require.Equal(t, &[]Article{
+	require.Equal(t, []Article{
 		{
-			Id:        23,
-			Title:     "Article title one",
-			Subtitle:  "Article subtitle one",
-			Slug:      "article-slug-one",
-			Body:      "Lorem ipsum dolar sit amet one",
-			CreatedAt: createdAt,
-			UpdatedAt: updatedAt,
+			Id:          23,
+			Title:       "Article title one",
+			Subtitle:    "Article subtitle one",
+			Slug:        "article-slug-one",
+			Body:        "Lorem ipsum dolar sit amet one",
+			Status:      "published",
+			PublishedAt: &publishedAt,
+			CreatedAt:   createdAt,
+			UpdatedAt:   updatedAt,
 			Tags: []tag.Tag{
 				{Id: 42, Name: "tag one", Slug: "tag-one"},
 				{Id: 69, Name: "tag two", Slug: "tag-two"},
 			},
 		},
 		{
-			Id:        42,
-			Title:     "Article title two",
-			Subtitle:  "Article subtitle two",
-			Slug:      "article-slug-two",
-			Body:      "Lorem ipsum dolar sit amet two",
-			CreatedAt: createdAt,
-			UpdatedAt: updatedAt,
+			Id:          42,
+			Title:       "Article title two",
+			Subtitle:    "Article subtitle two",
+			Slug:        "article-slug-two",
+			Body:        "Lorem ipsum dolar sit amet two",
+			Status:      "published",
+			PublishedAt: &publishedAt,
+			CreatedAt:   createdAt,
+			UpdatedAt:   updatedAt,
 			Tags: []tag.Tag{
 				{Id: 42, Name: "tag one", Slug: "tag-one"},
 				{Id: 69, Name: "tag two", Slug: "tag-two"},
 			},
 		},
-	}, got, "should return article")
+	}, got.Items, "should return article")
 }
 
 func testArticleRepoGetArticleByAttrTagsScanError(t *testing.T, mock pgxmock.PgxPoolIface, repo ArticleRepository) {
-	articleQuery := `select a.id_, a.title_, a.subtitle_, a.slug_, a.body_, a.created_at_, a.updated_at_, array_to_string(array_agg(distinct t.tag_id_), ',', '*') from articles_ a join article_tags_ t on a.id_ = t.article_id_ where a.slug_ = $1 group by a.id_`
+	articleQuery := `select a.id_, a.title_, a.subtitle_, a.slug_, a.body_, a.status_, a.published_at_, a.created_at_, a.updated_at_, a.author_id_, u.username_, u.display_name_, array_to_string(array_agg(distinct t.tag_id_), ',', '*') from articles_ a join article_tags_ t on a.id_ = t.article_id_ left join users_ u on a.author_id_ = u.id_ where a.slug_ = $1 group by a.id_, u.id_`
 
 	createdAt := time.Now()
+	publishedAt := createdAt
 	updatedAt := time.Now().Add(time.Hour * 24)
 
 	mockArticleRow := mock.NewRows([]string{
@@ -592,8 +813,13 @@ func testArticleRepoGetArticleByAttrTagsScanError(t *testing.T, mock pgxmock.Pgx
 		"subtitle_",
 		"slug_",
 		"body_",
+		"status_",
+		"published_at_",
 		"created_at_",
 		"updated_at_",
+		"author_id_",
+		"username_",
+		"display_name_",
 		"tag_ids_",
 	}).AddRow(
 		23,
@@ -601,8 +827,13 @@ func testArticleRepoGetArticleByAttrTagsScanError(t *testing.T, mock pgxmock.Pgx
 		"Article subtitle",
 		"article-slug",
 		"Lorem ipsum dolar sit amet",
+		"published",
+		&publishedAt,
 		createdAt,
 		updatedAt,
+		(*int)(nil),
+		(*string)(nil),
+		(*string)(nil),
 		"42,69",
 	)
 
@@ -632,3 +863,437 @@ func testArticleRepoGetArticleByAttrTagsScanError(t *testing.T, mock pgxmock.Pgx
 
 	require.Error(t, err, "should return error")
 }
+
+func testArticleRepoGetManyArticlesByStatus(t *testing.T, mock pgxmock.PgxPoolIface, repo ArticleRepository) {
+	countQuery := `select count(*) from articles_ a where a.status_ = $1`
+	articleQuery := `select a.id_, a.title_, a.subtitle_, a.slug_, a.body_, a.status_, a.published_at_, a.created_at_, a.updated_at_, (coalesce(a.published_at_, a.created_at_))::text from articles_ a where a.status_ = $1 and true order by coalesce(a.published_at_, a.created_at_) desc, a.id_ desc limit $2 offset $3`
+
+	createdAt := time.Now().Add(time.Hour * -12)
+	publishedAt := createdAt
+	updatedAt := time.Now()
+
+	mock.
+		ExpectQuery(regexp.QuoteMeta(countQuery)).
+		WithArgs("published").
+		WillReturnRows(mock.NewRows([]string{"count"}).AddRow(2))
+
+	mockArticleRow := mock.NewRows([]string{
+		"id_",
+		"title_",
+		"subtitle_",
+		"slug_",
+		"body_",
+		"status_",
+		"published_at_",
+		"created_at_",
+		"updated_at_",
+		"cursor",
+	}).AddRow(
+		23,
+		"Article title",
+		"Article subtitle",
+		"article-slug",
+		"Lorem ipsum dolar sit amet",
+		"published",
+		&publishedAt,
+		createdAt,
+		updatedAt,
+		"cursor",
+	)
+
+	mock.
+		ExpectQuery(regexp.QuoteMeta(articleQuery)).
+		WithArgs("published", pagination.DefaultLimit+1, 0).
+		WillReturnRows(mockArticleRow)
+
+	tagsQuery := `select at.article_id_, t.id_, t.name_, t.slug_ from article_tags_ at inner join tags_ t on at.tag_id_ = t.id_ where at.article_id_ = any($1) order by t.name_`
+
+	mock.
+		ExpectQuery(regexp.QuoteMeta(tagsQuery)).
+		WithArgs([]int{23}).
+		WillReturnRows(mock.NewRows([]string{"article_id_", "id_", "name_", "slug_"}))
+
+	got, err := repo.GetManyByAttribute("status", "published", pagination.Request{})
+
+	mock.Reset()
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal("expectations not met: ", err)
+	}
+
+	require.Nil(t, err, "should not return error")
+	require.Equal(t, []Article{{
+		Id:          23,
+		Title:       "Article title",
+		Subtitle:    "Article subtitle",
+		Slug:        "article-slug",
+		Body:        "Lorem ipsum dolar sit amet",
+		Status:      "published",
+		PublishedAt: &publishedAt,
+		CreatedAt:   createdAt,
+		UpdatedAt:   updatedAt,
+		Tags:        []tag.Tag(nil),
+	}}, got.Items, "should return the published article")
+}
+
+func testArticleRepoPublishScheduled(t *testing.T, mock pgxmock.PgxPoolIface, repo ArticleRepository) {
+	query := `update articles_ set status_ = 'published' where status_ = 'scheduled' and published_at_ <= $1 returning id_, title_, subtitle_, slug_, body_, status_, published_at_, created_at_, updated_at_`
+
+	now := time.Now()
+	publishedAt := now.Add(time.Minute * -5)
+	createdAt := now.Add(time.Hour * -24)
+
+	mockRows := mock.NewRows([]string{
+		"id_",
+		"title_",
+		"subtitle_",
+		"slug_",
+		"body_",
+		"status_",
+		"published_at_",
+		"created_at_",
+		"updated_at_",
+	}).AddRow(
+		23,
+		"Article title",
+		"Article subtitle",
+		"article-slug",
+		"Lorem ipsum dolar sit amet",
+		"published",
+		&publishedAt,
+		createdAt,
+		createdAt,
+	)
+
+	mock.
+		ExpectQuery(regexp.QuoteMeta(query)).
+		WithArgs(now).
+		WillReturnRows(mockRows)
+
+	got, err := repo.PublishScheduled(now)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal("expectations not met: ", err)
+	}
+
+	require.NoError(t, err, "should not return error")
+	require.Equal(t, &[]Article{{
+		Id:          23,
+		Title:       "Article title",
+		Subtitle:    "Article subtitle",
+		Slug:        "article-slug",
+		Body:        "Lorem ipsum dolar sit amet",
+		Status:      "published",
+		PublishedAt: &publishedAt,
+		CreatedAt:   createdAt,
+		UpdatedAt:   createdAt,
+	}}, got, "should return newly published articles")
+}
+
+func testArticleRepoPublishScheduledDbError(t *testing.T, mock pgxmock.PgxPoolIface, repo ArticleRepository) {
+	query := `update articles_ set status_ = 'published' where status_ = 'scheduled' and published_at_ <= $1`
+
+	now := time.Now()
+
+	mock.
+		ExpectQuery(regexp.QuoteMeta(query)).
+		WithArgs(now).
+		WillReturnError(errors.New("db_update_error"))
+
+	got, err := repo.PublishScheduled(now)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal("expectations not met: ", err)
+	}
+
+	require.Nil(t, got, "should not return articles")
+	require.EqualError(t, err, "db_update_error", "should return db error")
+}
+
+func testArticleRepoGetRevisions(t *testing.T, mock pgxmock.PgxPoolIface, repo ArticleRepository) {
+	query := `select id_, article_id_, title_, subtitle_, slug_, body_, tag_ids_, created_at_ from article_revisions_ where article_id_ = $1 order by created_at_ desc, id_ desc`
+
+	createdAt := time.Now()
+
+	mockRows := mock.
+		NewRows([]string{"id_", "article_id_", "title_", "subtitle_", "slug_", "body_", "tag_ids_", "created_at_"}).
+		AddRow(2, 23, "Title two", "Subtitle two", "article-slug", "Body two", []int{42, 69}, createdAt).
+		AddRow(1, 23, "Title one", "Subtitle one", "article-slug", "Body one", []int{42}, createdAt.Add(time.Hour*-1))
+
+	mock.
+		ExpectQuery(regexp.QuoteMeta(query)).
+		WithArgs(23).
+		WillReturnRows(mockRows)
+
+	got, err := repo.GetRevisions(23)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal("expectations not met: ", err)
+	}
+
+	require.NoError(t, err, "should not return error")
+	require.Equal(t, &[]ArticleRevision{
+		{Id: 2, ArticleId: 23, Title: "Title two", Subtitle: "Subtitle two", Slug: "article-slug", Body: "Body two", TagIds: []int{42, 69}, CreatedAt: createdAt},
+		{Id: 1, ArticleId: 23, Title: "Title one", Subtitle: "Subtitle one", Slug: "article-slug", Body: "Body one", TagIds: []int{42}, CreatedAt: createdAt.Add(time.Hour * -1)},
+	}, got, "should return revisions newest first")
+}
+
+func testArticleRepoGetRevisionById(t *testing.T, mock pgxmock.PgxPoolIface, repo ArticleRepository) {
+	query := `select id_, article_id_, title_, subtitle_, slug_, body_, tag_ids_, created_at_ from article_revisions_ where id_ = $1`
+
+	createdAt := time.Now()
+
+	mockRow := mock.
+		NewRows([]string{"id_", "article_id_", "title_", "subtitle_", "slug_", "body_", "tag_ids_", "created_at_"}).
+		AddRow(2, 23, "Title", "Subtitle", "article-slug", "Body", []int{42}, createdAt)
+
+	mock.
+		ExpectQuery(regexp.QuoteMeta(query)).
+		WithArgs(2).
+		WillReturnRows(mockRow)
+
+	got, err := repo.GetRevisionById(2)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal("expectations not met: ", err)
+	}
+
+	require.NoError(t, err, "should not return error")
+	require.Equal(t, &ArticleRevision{
+		Id:        2,
+		ArticleId: 23,
+		Title:     "Title",
+		Subtitle:  "Subtitle",
+		Slug:      "article-slug",
+		Body:      "Body",
+		TagIds:    []int{42},
+		CreatedAt: createdAt,
+	}, got, "should return revision")
+}
+
+func testArticleRepoGetRevisionByIdDbError(t *testing.T, mock pgxmock.PgxPoolIface, repo ArticleRepository) {
+	query := `select id_, article_id_, title_, subtitle_, slug_, body_, tag_ids_, created_at_ from article_revisions_ where id_ = $1`
+
+	mock.
+		ExpectQuery(regexp.QuoteMeta(query)).
+		WithArgs(2).
+		WillReturnError(errors.New("db_error"))
+
+	got, err := repo.GetRevisionById(2)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal("expectations not met: ", err)
+	}
+
+	require.Nil(t, got, "should not return revision")
+	require.EqualError(t, err, "db_error", "should return db error")
+}
+
+func testArticleRepoSearch(t *testing.T, mock pgxmock.PgxPoolIface, repo ArticleRepository) {
+	countQuery := `select count(*) from articles_ a, websearch_to_tsquery('english', $1) q where a.search_vector_ @@ q and (coalesce(cardinality($2::text[]), 0) = 0 or a.status_ = any($2))`
+	query := `select a.id_, a.title_, a.subtitle_, a.slug_, a.body_, a.status_, a.published_at_, a.created_at_, a.updated_at_, ts_headline('english', a.body_, q, $3), ts_rank(a.search_vector_, q), (ts_rank(a.search_vector_, q))::text from articles_ a, websearch_to_tsquery('english', $1) q where a.search_vector_ @@ q and (coalesce(cardinality($2::text[]), 0) = 0 or a.status_ = any($2)) and true order by ts_rank(a.search_vector_, q) desc, a.id_ desc limit $4 offset $5`
+
+	createdAt := time.Now()
+	publishedAt := createdAt
+
+	mock.
+		ExpectQuery(regexp.QuoteMeta(countQuery)).
+		WithArgs("go", []string{"published"}).
+		WillReturnRows(mock.NewRows([]string{"count"}).AddRow(12))
+
+	mockRows := mock.
+		NewRows([]string{"id_", "title_", "subtitle_", "slug_", "body_", "status_", "published_at_", "created_at_", "updated_at_", "ts_headline", "ts_rank", "cursor"}).
+		AddRow(23, "Go search", "Subtitle", "go-search", "Body about go", "published", &publishedAt, createdAt, createdAt, "Body about \x02go\x03", float32(0.6), "0.6")
+
+	mock.
+		ExpectQuery(regexp.QuoteMeta(query)).
+		WithArgs("go", []string{"published"}, headlineOptions, 11, 10).
+		WillReturnRows(mockRows)
+
+	got, err := repo.Search("go", pagination.Request{Limit: 10, Offset: 10}, "published")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal("expectations not met: ", err)
+	}
+
+	require.NoError(t, err, "should not return error")
+	require.Equal(t, []ArticleSearchResult{
+		{
+			Article: Article{
+				Id:          23,
+				Title:       "Go search",
+				Subtitle:    "Subtitle",
+				Slug:        "go-search",
+				Body:        "Body about go",
+				Status:      "published",
+				PublishedAt: &publishedAt,
+				CreatedAt:   createdAt,
+				UpdatedAt:   createdAt,
+			},
+			Headline: "Body about \x02go\x03",
+			Rank:     0.6,
+		},
+	}, got.Items, "should return ranked results")
+	require.Equal(t, 12, got.Total, "should return total")
+	require.True(t, got.HasPrev(), "should have previous page")
+	require.True(t, got.HasNext(), "should have next page")
+}
+
+func testArticleRepoSearchDbError(t *testing.T, mock pgxmock.PgxPoolIface, repo ArticleRepository) {
+	mock.
+		ExpectQuery("select count").
+		WithArgs("go", []string(nil)).
+		WillReturnError(errors.New("db_error"))
+
+	got, err := repo.Search("go", pagination.Request{})
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal("expectations not met: ", err)
+	}
+
+	require.Nil(t, got, "should not return results")
+	require.EqualError(t, err, "db_error", "should return db error")
+}
+
+const (
+	updateTagsListQuery   = `select id_, name_, slug_ from tags_`
+	updateRevisionQuery   = `insert into article_revisions_ (article_id_, title_, subtitle_, slug_, body_, tag_ids_, created_at_) select`
+	updateArticleQuery    = `update articles_ set title_ = $2, subtitle_ = $3, slug_ = $4, body_ = $5, status_ = $6, published_at_ = $7, created_at_ = $8, updated_at_ = $9 where id_ = $1 returning id_, title_, subtitle_, slug_, body_, status_, published_at_, created_at_, updated_at_`
+	updateDeleteTagsQuery = `delete from article_tags_ where article_id_ = $1`
+	updateInsertTagsQuery = `insert into article_tags_ (article_id_, tag_id_) select $1, unnest($2::integer[])`
+)
+
+func testUpdateArticle(updatedAt time.Time) *UpdateArticle {
+	return &UpdateArticle{
+		Id:        23,
+		Title:     "New title",
+		Subtitle:  "New subtitle",
+		Slug:      "article-slug",
+		Body:      "New body",
+		Status:    StatusDraft,
+		CreatedAt: updatedAt,
+		UpdatedAt: updatedAt,
+		TagIds:    []int{1, 2},
+	}
+}
+
+// expectUpdateArticle sets up the queries an update makes up to and including
+// updating the article itself.
+func expectUpdateArticle(mock pgxmock.PgxPoolIface, updatedAt time.Time) {
+	mock.
+		ExpectQuery(regexp.QuoteMeta(updateTagsListQuery)).
+		WillReturnRows(mock.
+			NewRows([]string{"id_", "name_", "slug_"}).
+			AddRow(1, "Go", "go").
+			AddRow(2, "Rust", "rust").
+			AddRow(3, "Zig", "zig"))
+
+	mock.ExpectBegin()
+
+	mock.
+		ExpectExec(regexp.QuoteMeta(updateRevisionQuery)).
+		WithArgs(23).
+		WillReturnResult(pgxmock.NewResult("insert", 1))
+
+	mock.
+		ExpectQuery(regexp.QuoteMeta(updateArticleQuery)).
+		WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg()).
+		WillReturnRows(mock.
+			NewRows([]string{"id_", "title_", "subtitle_", "slug_", "body_", "status_", "published_at_", "created_at_", "updated_at_"}).
+			AddRow(23, "New title", "New subtitle", "article-slug", "New body", StatusDraft, nil, updatedAt, updatedAt))
+}
+
+func testArticleRepoUpdateArticle(t *testing.T, mock pgxmock.PgxPoolIface, repo ArticleRepository) {
+	updatedAt := time.Now()
+
+	expectUpdateArticle(mock, updatedAt)
+
+	mock.
+		ExpectExec(regexp.QuoteMeta(updateDeleteTagsQuery)).
+		WithArgs(23).
+		WillReturnResult(pgxmock.NewResult("delete", 1))
+
+	mock.
+		ExpectExec(regexp.QuoteMeta(updateInsertTagsQuery)).
+		WithArgs(23, []int{1, 2}).
+		WillReturnResult(pgxmock.NewResult("insert", 2))
+
+	mock.ExpectCommit()
+
+	got, err := repo.Update(testUpdateArticle(updatedAt))
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal("expectations not met: ", err)
+	}
+
+	require.NoError(t, err, "should not return error")
+	require.Equal(t, &Article{
+		Id:        23,
+		Title:     "New title",
+		Subtitle:  "New subtitle",
+		Slug:      "article-slug",
+		Body:      "New body",
+		Status:    StatusDraft,
+		CreatedAt: updatedAt,
+		UpdatedAt: updatedAt,
+		Tags: []tag.Tag{
+			{Id: 1, Name: "Go", Slug: "go"},
+			{Id: 2, Name: "Rust", Slug: "rust"},
+		},
+	}, got, "should return updated article with its tags")
+}
+
+func testArticleRepoUpdateArticleError(t *testing.T, mock pgxmock.PgxPoolIface, repo ArticleRepository) {
+	mock.
+		ExpectQuery(regexp.QuoteMeta(updateTagsListQuery)).
+		WillReturnRows(mock.NewRows([]string{"id_", "name_", "slug_"}))
+
+	mock.ExpectBegin()
+
+	mock.
+		ExpectExec(regexp.QuoteMeta(updateRevisionQuery)).
+		WithArgs(23).
+		WillReturnResult(pgxmock.NewResult("insert", 1))
+
+	mock.
+		ExpectQuery(regexp.QuoteMeta(updateArticleQuery)).
+		WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg()).
+		WillReturnError(errors.New("db_error"))
+
+	mock.ExpectRollback()
+
+	got, err := repo.Update(testUpdateArticle(time.Now()))
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal("expectations not met: ", err)
+	}
+
+	require.Nil(t, got, "should not return article")
+	require.EqualError(t, err, "db_error", "should return db error")
+}
+
+func testArticleRepoUpdateArticleTagsError(t *testing.T, mock pgxmock.PgxPoolIface, repo ArticleRepository) {
+	updatedAt := time.Now()
+
+	expectUpdateArticle(mock, updatedAt)
+
+	mock.
+		ExpectExec(regexp.QuoteMeta(updateDeleteTagsQuery)).
+		WithArgs(23).
+		WillReturnResult(pgxmock.NewResult("delete", 1))
+
+	mock.
+		ExpectExec(regexp.QuoteMeta(updateInsertTagsQuery)).
+		WithArgs(23, []int{1, 2}).
+		WillReturnError(errors.New("tag_error"))
+
+	mock.ExpectRollback()
+
+	got, err := repo.Update(testUpdateArticle(updatedAt))
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal("expectations not met: ", err)
+	}
+
+	require.Nil(t, got, "should not return article")
+	require.EqualError(t, err, "tag_error", "should return tag error")
+}
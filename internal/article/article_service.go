@@ -2,17 +2,28 @@ package article
 
 import (
 	"errors"
+	"html/template"
+	"strings"
+	"time"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/jackc/pgx/v5"
+	"github.com/nixpig/dunce/pkg/metrics"
+	"github.com/nixpig/dunce/pkg/pagination"
 )
 
 type ArticleService interface {
 	DeleteById(id int) error
 	Create(article *ArticleNewRequestDto) (*ArticleResponseDto, error)
-	GetAll() (*[]ArticleResponseDto, error)
-	GetManyByAttribute(attr, value string) (*[]ArticleResponseDto, error)
+	GetAll(page pagination.Request) (*pagination.Page[ArticleResponseDto], error)
+	GetManyByAttribute(attr, value string, page pagination.Request) (*pagination.Page[ArticleResponseDto], error)
 	GetByAttribute(attr, value string) (*ArticleResponseDto, error)
 	Update(article *ArticleUpdateRequestDto) (*ArticleResponseDto, error)
+	PublishScheduled(now time.Time) (*[]ArticleResponseDto, error)
+	GetRevisions(articleId int) (*[]ArticleRevisionResponseDto, error)
+	GetRevisionById(id int) (*ArticleRevisionResponseDto, error)
+	RestoreRevision(slug string, revisionId int) (*ArticleResponseDto, error)
+	Search(query string, page pagination.Request, statuses ...string) (*ArticleSearchResponseDto, error)
 }
 
 type ArticleServiceImpl struct {
@@ -36,13 +47,16 @@ func (a ArticleServiceImpl) DeleteById(id int) error {
 
 func (a ArticleServiceImpl) Create(article *ArticleNewRequestDto) (*ArticleResponseDto, error) {
 	articleToCreate := ArticleNew{
-		Title:     article.Title,
-		Subtitle:  article.Subtitle,
-		Slug:      article.Slug,
-		Body:      article.Body,
-		CreatedAt: article.CreatedAt,
-		UpdatedAt: article.UpdatedAt,
-		TagIds:    article.TagIds,
+		Title:       article.Title,
+		Subtitle:    article.Subtitle,
+		Slug:        article.Slug,
+		Body:        article.Body,
+		Status:      article.Status,
+		PublishedAt: article.PublishedAt,
+		CreatedAt:   article.CreatedAt,
+		UpdatedAt:   article.UpdatedAt,
+		AuthorId:    article.AuthorId,
+		TagIds:      article.TagIds,
 	}
 
 	if err := a.validate.Struct(articleToCreate); err != nil {
@@ -50,8 +64,11 @@ func (a ArticleServiceImpl) Create(article *ArticleNewRequestDto) (*ArticleRespo
 	}
 
 	if len(article.TagIds) == 0 {
-		minTagsError := errors.New("article must have at least one tag")
-		return nil, minTagsError
+		return nil, ErrArticleWithoutTags
+	}
+
+	if article.Status == StatusScheduled && article.PublishedAt == nil {
+		return nil, ErrScheduledWithoutDate
 	}
 
 	createdArticle, err := a.repo.Create(&articleToCreate)
@@ -59,113 +76,262 @@ func (a ArticleServiceImpl) Create(article *ArticleNewRequestDto) (*ArticleRespo
 		return nil, err
 	}
 
+	metrics.ArticlesCreated.Inc()
+
 	return &ArticleResponseDto{
-		Id:        createdArticle.Id,
-		Title:     createdArticle.Title,
-		Subtitle:  createdArticle.Subtitle,
-		Slug:      createdArticle.Slug,
-		Body:      createdArticle.Body,
-		CreatedAt: createdArticle.CreatedAt,
-		UpdatedAt: createdArticle.UpdatedAt,
-		Tags:      createdArticle.Tags,
+		Id:          createdArticle.Id,
+		Title:       createdArticle.Title,
+		Subtitle:    createdArticle.Subtitle,
+		Slug:        createdArticle.Slug,
+		Body:        createdArticle.Body,
+		Status:      createdArticle.Status,
+		PublishedAt: createdArticle.PublishedAt,
+		CreatedAt:   createdArticle.CreatedAt,
+		UpdatedAt:   createdArticle.UpdatedAt,
+		AuthorId:    createdArticle.AuthorId,
+		Tags:        createdArticle.Tags,
 	}, nil
 }
 
-func (a ArticleServiceImpl) GetAll() (*[]ArticleResponseDto, error) {
-	articles, err := a.repo.GetAll()
+func (a ArticleServiceImpl) GetAll(page pagination.Request) (*pagination.Page[ArticleResponseDto], error) {
+	articles, err := a.repo.GetAll(page)
+	if err != nil {
+		return nil, err
+	}
+
+	return pagination.Map(articles, articleResponse), nil
+}
+
+func (a ArticleServiceImpl) GetManyByAttribute(attr, value string, page pagination.Request) (*pagination.Page[ArticleResponseDto], error) {
+	articles, err := a.repo.GetManyByAttribute(attr, value, page)
 	if err != nil {
 		return nil, err
 	}
 
-	allArticles := make([]ArticleResponseDto, len(*articles))
+	return pagination.Map(articles, articleResponse), nil
+}
+
+func (a ArticleServiceImpl) GetByAttribute(attr, value string) (*ArticleResponseDto, error) {
+	article, err := a.repo.GetByAttribute(attr, value)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ArticleResponseDto{
+		Id:          article.Id,
+		Title:       article.Title,
+		Subtitle:    article.Subtitle,
+		Slug:        article.Slug,
+		Body:        article.Body,
+		Status:      article.Status,
+		PublishedAt: article.PublishedAt,
+		CreatedAt:   article.CreatedAt,
+		UpdatedAt:   article.UpdatedAt,
+		AuthorId:    article.AuthorId,
+		Author:      article.Author,
+		Tags:        article.Tags,
+	}, nil
+}
+
+func (a ArticleServiceImpl) Update(article *ArticleUpdateRequestDto) (*ArticleResponseDto, error) {
+	articleToUpdate := UpdateArticle{
+		Id:          article.Id,
+		Title:       article.Title,
+		Subtitle:    article.Subtitle,
+		Slug:        article.Slug,
+		Body:        article.Body,
+		Status:      article.Status,
+		PublishedAt: article.PublishedAt,
+		CreatedAt:   article.CreatedAt,
+		UpdatedAt:   article.UpdatedAt,
+		TagIds:      article.TagIds,
+	}
+
+	if err := a.validate.Struct(articleToUpdate); err != nil {
+		return nil, err
+	}
+
+	if article.Status == StatusScheduled && article.PublishedAt == nil {
+		return nil, ErrScheduledWithoutDate
+	}
+
+	updatedArticle, err := a.repo.Update(&articleToUpdate)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics.ArticlesUpdated.Inc()
+
+	return &ArticleResponseDto{
+		Id:          updatedArticle.Id,
+		Title:       updatedArticle.Title,
+		Subtitle:    updatedArticle.Subtitle,
+		Slug:        updatedArticle.Slug,
+		Body:        updatedArticle.Body,
+		Status:      updatedArticle.Status,
+		PublishedAt: updatedArticle.PublishedAt,
+		CreatedAt:   updatedArticle.CreatedAt,
+		UpdatedAt:   updatedArticle.UpdatedAt,
+		Tags:        updatedArticle.Tags,
+	}, nil
+}
+
+func (a ArticleServiceImpl) PublishScheduled(now time.Time) (*[]ArticleResponseDto, error) {
+	articles, err := a.repo.PublishScheduled(now)
+	if err != nil {
+		return nil, err
+	}
+
+	publishedArticles := make([]ArticleResponseDto, len(*articles))
 
 	for index, article := range *articles {
-		allArticles[index] = ArticleResponseDto{
-			Id:        article.Id,
-			Title:     article.Title,
-			Subtitle:  article.Subtitle,
-			Slug:      article.Slug,
-			Body:      article.Body,
-			CreatedAt: article.CreatedAt,
-			UpdatedAt: article.UpdatedAt,
-			Tags:      article.Tags,
+		publishedArticles[index] = ArticleResponseDto{
+			Id:          article.Id,
+			Title:       article.Title,
+			Subtitle:    article.Subtitle,
+			Slug:        article.Slug,
+			Body:        article.Body,
+			Status:      article.Status,
+			PublishedAt: article.PublishedAt,
+			CreatedAt:   article.CreatedAt,
+			UpdatedAt:   article.UpdatedAt,
 		}
 	}
 
-	return &allArticles, nil
+	return &publishedArticles, nil
 }
 
-func (a ArticleServiceImpl) GetManyByAttribute(attr, value string) (*[]ArticleResponseDto, error) {
-	articles, err := a.repo.GetManyByAttribute(attr, value)
+func (a ArticleServiceImpl) GetRevisions(articleId int) (*[]ArticleRevisionResponseDto, error) {
+	revisions, err := a.repo.GetRevisions(articleId)
 	if err != nil {
 		return nil, err
 	}
 
-	allArticles := make([]ArticleResponseDto, len(*articles))
+	allRevisions := make([]ArticleRevisionResponseDto, len(*revisions))
 
-	for index, article := range *articles {
-		allArticles[index] = ArticleResponseDto{
-			Id:        article.Id,
-			Title:     article.Title,
-			Subtitle:  article.Subtitle,
-			Slug:      article.Slug,
-			Body:      article.Body,
-			CreatedAt: article.CreatedAt,
-			UpdatedAt: article.UpdatedAt,
-			Tags:      article.Tags,
+	for index, revision := range *revisions {
+		allRevisions[index] = ArticleRevisionResponseDto{
+			Id:        revision.Id,
+			ArticleId: revision.ArticleId,
+			Title:     revision.Title,
+			Subtitle:  revision.Subtitle,
+			Slug:      revision.Slug,
+			Body:      revision.Body,
+			TagIds:    revision.TagIds,
+			CreatedAt: revision.CreatedAt,
 		}
 	}
 
-	return &allArticles, nil
+	return &allRevisions, nil
 }
 
-func (a ArticleServiceImpl) GetByAttribute(attr, value string) (*ArticleResponseDto, error) {
-	article, err := a.repo.GetByAttribute(attr, value)
+func (a ArticleServiceImpl) GetRevisionById(id int) (*ArticleRevisionResponseDto, error) {
+	revision, err := a.repo.GetRevisionById(id)
 	if err != nil {
 		return nil, err
 	}
 
-	return &ArticleResponseDto{
-		Id:        article.Id,
-		Title:     article.Title,
-		Subtitle:  article.Subtitle,
-		Slug:      article.Slug,
-		Body:      article.Body,
-		CreatedAt: article.CreatedAt,
-		UpdatedAt: article.UpdatedAt,
-		Tags:      article.Tags,
+	return &ArticleRevisionResponseDto{
+		Id:        revision.Id,
+		ArticleId: revision.ArticleId,
+		Title:     revision.Title,
+		Subtitle:  revision.Subtitle,
+		Slug:      revision.Slug,
+		Body:      revision.Body,
+		TagIds:    revision.TagIds,
+		CreatedAt: revision.CreatedAt,
 	}, nil
 }
 
-func (a ArticleServiceImpl) Update(article *ArticleUpdateRequestDto) (*ArticleResponseDto, error) {
-	articleToUpdate := UpdateArticle{
-		Id:        article.Id,
-		Title:     article.Title,
-		Subtitle:  article.Subtitle,
-		Slug:      article.Slug,
-		Body:      article.Body,
-		CreatedAt: article.CreatedAt,
-		UpdatedAt: article.UpdatedAt,
-		TagIds:    article.TagIds,
+// RestoreRevision puts the content of a revision back onto the article it was
+// taken from. The article keeps its current slug, status and publish date, and
+// the restore goes through Update, so the content being replaced is itself
+// kept as a new revision.
+func (a ArticleServiceImpl) RestoreRevision(slug string, revisionId int) (*ArticleResponseDto, error) {
+	current, err := a.repo.GetByAttribute("slug", slug)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := a.validate.Struct(articleToUpdate); err != nil {
+	revision, err := a.repo.GetRevisionById(revisionId)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRevisionNotFound
+		}
+
 		return nil, err
 	}
 
-	updatedArticle, err := a.repo.Update(&articleToUpdate)
+	if revision.ArticleId != current.Id {
+		return nil, ErrRevisionNotForArticle
+	}
+
+	return a.Update(&ArticleUpdateRequestDto{
+		Id:          current.Id,
+		Title:       revision.Title,
+		Subtitle:    revision.Subtitle,
+		Slug:        current.Slug,
+		Body:        revision.Body,
+		Status:      current.Status,
+		PublishedAt: current.PublishedAt,
+		CreatedAt:   current.CreatedAt,
+		UpdatedAt:   time.Now(),
+		TagIds:      revision.TagIds,
+	})
+}
+
+func (a ArticleServiceImpl) Search(query string, page pagination.Request, statuses ...string) (*ArticleSearchResponseDto, error) {
+	query = strings.TrimSpace(query)
+
+	if query == "" {
+		return &ArticleSearchResponseDto{
+			Results: &pagination.Page[ArticleSearchResultResponseDto]{
+				Items:   []ArticleSearchResultResponseDto{},
+				Request: page,
+			},
+		}, nil
+	}
+
+	results, err := a.repo.Search(query, page, statuses...)
 	if err != nil {
 		return nil, err
 	}
 
-	return &ArticleResponseDto{
-		Id:        updatedArticle.Id,
-		Title:     updatedArticle.Title,
-		Subtitle:  updatedArticle.Subtitle,
-		Slug:      updatedArticle.Slug,
-		Body:      updatedArticle.Body,
-		CreatedAt: updatedArticle.CreatedAt,
-		UpdatedAt: updatedArticle.UpdatedAt,
-		Tags:      updatedArticle.Tags,
+	return &ArticleSearchResponseDto{
+		Query: query,
+		Results: pagination.Map(results, func(result ArticleSearchResult) ArticleSearchResultResponseDto {
+			return ArticleSearchResultResponseDto{
+				Article: articleResponse(result.Article),
+				Snippet: highlightHeadline(result.Headline),
+			}
+		}),
 	}, nil
 }
+
+func articleResponse(article Article) ArticleResponseDto {
+	return ArticleResponseDto{
+		Id:          article.Id,
+		Title:       article.Title,
+		Subtitle:    article.Subtitle,
+		Slug:        article.Slug,
+		Body:        article.Body,
+		Status:      article.Status,
+		PublishedAt: article.PublishedAt,
+		CreatedAt:   article.CreatedAt,
+		UpdatedAt:   article.UpdatedAt,
+		AuthorId:    article.AuthorId,
+		Author:      article.Author,
+		Tags:        article.Tags,
+	}
+}
+
+// highlightHeadline escapes a ts_headline snippet and swaps its match markers
+// for <mark> elements
+func highlightHeadline(headline string) template.HTML {
+	escaped := template.HTMLEscapeString(headline)
+
+	escaped = strings.ReplaceAll(escaped, headlineStartSel, "<mark>")
+	escaped = strings.ReplaceAll(escaped, headlineStopSel, "</mark>")
+
+	return template.HTML(escaped)
+}
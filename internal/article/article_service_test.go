@@ -6,7 +6,9 @@ import (
 	"time"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/jackc/pgx/v5"
 	"github.com/nixpig/dunce/internal/tag"
+	"github.com/nixpig/dunce/pkg/pagination"
 	"github.com/nixpig/dunce/pkg/validation"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -21,6 +23,16 @@ func TestArticleService(t *testing.T) {
 		"create article (success)":                      testArticleServiceCreateArticle,
 		"create article (error - fails validation)":     testArticleServiceCreateFailsValidation,
 		"create article (error - fail with no tags)":    testArticleServiceCreateArticleNoTags,
+		"create article (error - invalid status)":       testArticleServiceCreateInvalidStatus,
+		"create article (error - scheduled no date)":    testArticleServiceCreateScheduledWithoutDate,
+		"publish scheduled (success)":                   testArticleServicePublishScheduled,
+		"publish scheduled (error)":                     testArticleServicePublishScheduledError,
+		"get revisions (success)":                       testArticleServiceGetRevisions,
+		"restore revision (success)":                    testArticleServiceRestoreRevision,
+		"restore revision (error - wrong article)":      testArticleServiceRestoreRevisionWrongArticle,
+		"restore revision (error - not found)":          testArticleServiceRestoreRevisionNotFound,
+		"search (success)":                              testArticleServiceSearch,
+		"search (success - empty query)":                testArticleServiceSearchEmptyQuery,
 		"create article (error - repo error)":           testArticleServiceCreateArticleRepoError,
 		"get all articles (success - multiple results)": testArticleServiceGetAllArticles,
 		"get all articles (error)":                      testArticleServiceGetAllArticlesError,
@@ -53,10 +65,10 @@ func (m *MockArticleRepository) Create(article *ArticleNew) (*Article, error) {
 	return args.Get(0).(*Article), args.Error(1)
 }
 
-func (m *MockArticleRepository) GetAll() (*[]Article, error) {
-	args := m.Called()
+func (m *MockArticleRepository) GetAll(page pagination.Request) (*pagination.Page[Article], error) {
+	args := m.Called(page)
 
-	return args.Get(0).(*[]Article), args.Error(1)
+	return args.Get(0).(*pagination.Page[Article]), args.Error(1)
 }
 
 func (m *MockArticleRepository) GetByAttribute(attr, value string) (*Article, error) {
@@ -83,23 +95,50 @@ func (m *MockArticleRepository) Exists(article *Article) (bool, error) {
 	return args.Get(0).(bool), args.Error(1)
 }
 
-func (m *MockArticleRepository) GetManyByAttribute(attr, val string) (*[]Article, error) {
-	args := m.Called(attr, val)
+func (m *MockArticleRepository) GetManyByAttribute(attr, val string, page pagination.Request) (*pagination.Page[Article], error) {
+	args := m.Called(attr, val, page)
+
+	return args.Get(0).(*pagination.Page[Article]), args.Error(1)
+}
+
+func (m *MockArticleRepository) PublishScheduled(now time.Time) (*[]Article, error) {
+	args := m.Called(now)
 
 	return args.Get(0).(*[]Article), args.Error(1)
 }
 
+func (m *MockArticleRepository) GetRevisions(articleId int) (*[]ArticleRevision, error) {
+	args := m.Called(articleId)
+
+	return args.Get(0).(*[]ArticleRevision), args.Error(1)
+}
+
+func (m *MockArticleRepository) Search(query string, page pagination.Request, statuses ...string) (*pagination.Page[ArticleSearchResult], error) {
+	args := m.Called(query, page, statuses)
+
+	return args.Get(0).(*pagination.Page[ArticleSearchResult]), args.Error(1)
+}
+
+func (m *MockArticleRepository) GetRevisionById(id int) (*ArticleRevision, error) {
+	args := m.Called(id)
+
+	return args.Get(0).(*ArticleRevision), args.Error(1)
+}
+
 func testArticleServiceCreateArticle(t *testing.T, service ArticleService) {
 	createdAt := time.Now()
 	updatedAt := time.Now()
+	authorId := 7
 
 	mockArticleCall := ArticleNew{
 		Title:     "article title",
 		Subtitle:  "article subtitle",
 		Slug:      "article-slug",
 		Body:      "article body content",
+		Status:    "published",
 		CreatedAt: createdAt,
 		UpdatedAt: updatedAt,
+		AuthorId:  &authorId,
 		TagIds: []int{
 			1,
 			2,
@@ -111,8 +150,10 @@ func testArticleServiceCreateArticle(t *testing.T, service ArticleService) {
 		Subtitle:  "article subtitle",
 		Slug:      "article-slug",
 		Body:      "article body content",
+		Status:    "published",
 		CreatedAt: createdAt,
 		UpdatedAt: updatedAt,
+		AuthorId:  &authorId,
 		TagIds: []int{
 			1,
 			2,
@@ -125,8 +166,10 @@ func testArticleServiceCreateArticle(t *testing.T, service ArticleService) {
 		Subtitle:  newArticle.Subtitle,
 		Slug:      newArticle.Slug,
 		Body:      newArticle.Body,
+		Status:    "published",
 		CreatedAt: newArticle.CreatedAt,
 		UpdatedAt: newArticle.UpdatedAt,
+		AuthorId:  &authorId,
 		Tags: []tag.Tag{
 			{
 				Id:   1,
@@ -147,8 +190,10 @@ func testArticleServiceCreateArticle(t *testing.T, service ArticleService) {
 		Subtitle:  newArticle.Subtitle,
 		Slug:      newArticle.Slug,
 		Body:      newArticle.Body,
+		Status:    "published",
 		CreatedAt: newArticle.CreatedAt,
 		UpdatedAt: newArticle.UpdatedAt,
+		AuthorId:  &authorId,
 		Tags: []tag.Tag{
 			{
 				Id:   1,
@@ -189,6 +234,7 @@ func testArticleServiceCreateArticleNoTags(t *testing.T, service ArticleService)
 		Subtitle:  "article subtitle",
 		Slug:      "article-slug",
 		Body:      "article body content",
+		Status:    "published",
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now().Add(23),
 		TagIds:    []int{},
@@ -209,6 +255,7 @@ func testArticleServiceCreateArticleRepoError(t *testing.T, service ArticleServi
 		Subtitle:  "article subtitle",
 		Slug:      "article-slug",
 		Body:      "article body content",
+		Status:    "published",
 		CreatedAt: createdAt,
 		UpdatedAt: updatedAt,
 		TagIds:    []int{1, 2},
@@ -221,6 +268,7 @@ func testArticleServiceCreateArticleRepoError(t *testing.T, service ArticleServi
 		Subtitle:  "article subtitle",
 		Slug:      "article-slug",
 		Body:      "article body content",
+		Status:    "published",
 		CreatedAt: createdAt,
 		UpdatedAt: updatedAt,
 		TagIds:    []int{1, 2},
@@ -275,6 +323,7 @@ func testArticleServiceGetAllArticles(t *testing.T, service ArticleService) {
 			Subtitle:  "article one subtitle",
 			Slug:      "article-one-slug",
 			Body:      "article one body content",
+			Status:    "published",
 			CreatedAt: createdAt,
 			UpdatedAt: updatedAt,
 			Tags: []tag.Tag{
@@ -290,6 +339,7 @@ func testArticleServiceGetAllArticles(t *testing.T, service ArticleService) {
 			Subtitle:  "article two subtitle",
 			Slug:      "article-two-slug",
 			Body:      "article two body content",
+			Status:    "published",
 			CreatedAt: createdAt,
 			UpdatedAt: updatedAt,
 			Tags: []tag.Tag{
@@ -308,6 +358,7 @@ func testArticleServiceGetAllArticles(t *testing.T, service ArticleService) {
 			Subtitle:  "article one subtitle",
 			Slug:      "article-one-slug",
 			Body:      "article one body content",
+			Status:    "published",
 			CreatedAt: createdAt,
 			UpdatedAt: updatedAt,
 			Tags: []tag.Tag{
@@ -323,6 +374,7 @@ func testArticleServiceGetAllArticles(t *testing.T, service ArticleService) {
 			Subtitle:  "article two subtitle",
 			Slug:      "article-two-slug",
 			Body:      "article two body content",
+			Status:    "published",
 			CreatedAt: createdAt,
 			UpdatedAt: updatedAt,
 			Tags: []tag.Tag{
@@ -335,9 +387,10 @@ func testArticleServiceGetAllArticles(t *testing.T, service ArticleService) {
 		},
 	}
 
-	mockCall := mockData.On("GetAll").Return(&mockAllArticles, nil)
+	mockCall := mockData.On("GetAll", pagination.Request{}).
+		Return(&pagination.Page[Article]{Items: mockAllArticles, Total: 2}, nil)
 
-	articles, err := service.GetAll()
+	articles, err := service.GetAll(pagination.Request{})
 
 	if res := mockData.AssertExpectations(t); !res {
 		t.Error("unmet expectations")
@@ -345,15 +398,17 @@ func testArticleServiceGetAllArticles(t *testing.T, service ArticleService) {
 
 	require.Nil(t, err, "should not return error")
 
-	require.Equal(t, articles, &allArticles, "should return all articles")
+	require.Equal(t, allArticles, articles.Items, "should return all articles")
+	require.Equal(t, 2, articles.Total, "should return total")
 
 	mockCall.Unset()
 }
 
 func testArticleServiceGetAllArticlesError(t *testing.T, service ArticleService) {
-	mockCall := mockData.On("GetAll").Return(&[]Article{}, errors.New("repo_error"))
+	mockCall := mockData.On("GetAll", pagination.Request{}).
+		Return((*pagination.Page[Article])(nil), errors.New("repo_error"))
 
-	articles, err := service.GetAll()
+	articles, err := service.GetAll(pagination.Request{})
 
 	if res := mockData.AssertExpectations(t); !res {
 		t.Error("unmet expectations")
@@ -374,6 +429,7 @@ func testArticleServiceGetArticleBySlug(t *testing.T, service ArticleService) {
 		Subtitle:  "article one subtitle",
 		Slug:      "article-one-slug",
 		Body:      "article one body content",
+		Status:    "published",
 		CreatedAt: createdAt,
 		UpdatedAt: updatedAt,
 		Tags: []tag.Tag{
@@ -401,6 +457,7 @@ func testArticleServiceGetArticleBySlug(t *testing.T, service ArticleService) {
 		Subtitle:  "article one subtitle",
 		Slug:      "article-one-slug",
 		Body:      "article one body content",
+		Status:    "published",
 		CreatedAt: createdAt,
 		UpdatedAt: updatedAt,
 		Tags: []tag.Tag{
@@ -441,6 +498,7 @@ func testArticleServiceUpdateArticle(t *testing.T, service ArticleService) {
 		Subtitle:  "article one subtitle",
 		Slug:      "article-one-slug",
 		Body:      "article one body content",
+		Status:    "published",
 		CreatedAt: createdAt,
 		UpdatedAt: updatedAt,
 		TagIds:    []int{23},
@@ -451,6 +509,7 @@ func testArticleServiceUpdateArticle(t *testing.T, service ArticleService) {
 		Subtitle:  "article one subtitle",
 		Slug:      "article-one-slug",
 		Body:      "article one body content",
+		Status:    "published",
 		CreatedAt: createdAt,
 		UpdatedAt: updatedAt,
 		TagIds:    []int{23},
@@ -461,6 +520,7 @@ func testArticleServiceUpdateArticle(t *testing.T, service ArticleService) {
 		Subtitle:  "article one subtitle",
 		Slug:      "article-one-slug",
 		Body:      "article one body content",
+		Status:    "published",
 		CreatedAt: createdAt,
 		UpdatedAt: updatedAt,
 		Tags: []tag.Tag{
@@ -488,6 +548,7 @@ func testArticleServiceUpdateArticle(t *testing.T, service ArticleService) {
 		Subtitle:  "article one subtitle",
 		Slug:      "article-one-slug",
 		Body:      "article one body content",
+		Status:    "published",
 		CreatedAt: createdAt,
 		UpdatedAt: updatedAt,
 		Tags: []tag.Tag{
@@ -511,6 +572,7 @@ func testArticleServiceUpdateArticleError(t *testing.T, service ArticleService)
 		Subtitle:  "article one subtitle",
 		Slug:      "article-one-slug",
 		Body:      "article one body content",
+		Status:    "published",
 		CreatedAt: createdAt,
 		UpdatedAt: updatedAt,
 		TagIds:    []int{23},
@@ -521,6 +583,7 @@ func testArticleServiceUpdateArticleError(t *testing.T, service ArticleService)
 		Subtitle:  "article one subtitle",
 		Slug:      "article-one-slug",
 		Body:      "article one body content",
+		Status:    "published",
 		CreatedAt: createdAt,
 		UpdatedAt: updatedAt,
 		TagIds:    []int{23},
@@ -559,6 +622,7 @@ func testArticleServiceCreateFailsValidation(t *testing.T, service ArticleServic
 	require.Equal(t, "required", missingFieldErrs["Subtitle"], "should error for no Subtitle")
 	require.Equal(t, "required", missingFieldErrs["Slug"], "should error for no Slug")
 	require.Equal(t, "required", missingFieldErrs["Body"], "should error for no Body")
+	require.Equal(t, "required", missingFieldErrs["Status"], "should error for no Status")
 	require.Equal(t, "required", missingFieldErrs["CreatedAt"], "should error for no CreatedAt")
 	require.Equal(t, "required", missingFieldErrs["UpdatedAt"], "should error for no UpdatedAt")
 	require.Equal(t, "required", missingFieldErrs["TagIds"], "should error for no TagIds")
@@ -568,6 +632,7 @@ func testArticleServiceCreateFailsValidation(t *testing.T, service ArticleServic
 		Subtitle:  "abcde12345abcde12345abcde12345abcde12345abcde12345abcde12345abcde12345abcde12345abcde12345abcde12345abcde12345abcde12345abcde12345abcde12345abcde12345abcde12345abcde12345abcde12345abcde12345abcde12345abcde12345abcde12345abcde12345abcde12345abcde12345abcde12345abcde12345abcde12345abcde12345abcde12345",
 		Slug:      "abcdeabcdeabcdeabcdeabcdeabcdeabcdeabcdeabcdeabcdeabcde",
 		Body:      "Lorem ipsum dolar sit amet...",
+		Status:    "published",
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 		TagIds:    []int{1, 2, 3},
@@ -590,6 +655,7 @@ func testArticleServiceCreateFailsValidation(t *testing.T, service ArticleServic
 		Subtitle:  "Some subtitle",
 		Slug:      "a",
 		Body:      "Lorem ipsum dolar sit amet...",
+		Status:    "published",
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 		TagIds:    []int{1, 2, 3},
@@ -606,6 +672,29 @@ func testArticleServiceCreateFailsValidation(t *testing.T, service ArticleServic
 	require.Equal(t, "min", minValidationErrs["Slug"], "should not allow short Slug")
 }
 
+func testArticleServiceCreateInvalidStatus(t *testing.T, service ArticleService) {
+	got, err := service.Create(&ArticleNewRequestDto{
+		Title:     "Some title",
+		Subtitle:  "Some subtitle",
+		Slug:      "some-slug",
+		Body:      "Lorem ipsum dolar sit amet...",
+		Status:    "live",
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		TagIds:    []int{1, 2, 3},
+	})
+
+	validationErrs := make(map[string]string)
+
+	for _, v := range err.(validator.ValidationErrors) {
+		validationErrs[v.Field()] = v.Tag()
+	}
+
+	require.Nil(t, got, "should not create article")
+
+	require.Equal(t, "oneof", validationErrs["Status"], "should not allow unknown Status")
+}
+
 func testArticleServiceUpdateFailsValidation(t *testing.T, service ArticleService) {
 	gotMissingFields, err := service.Update(&ArticleUpdateRequestDto{})
 
@@ -623,6 +712,7 @@ func testArticleServiceUpdateFailsValidation(t *testing.T, service ArticleServic
 	require.Equal(t, "required", missingFieldErrs["Subtitle"], "should error for no Subtitle")
 	require.Equal(t, "required", missingFieldErrs["Slug"], "should error for no Slug")
 	require.Equal(t, "required", missingFieldErrs["Body"], "should error for no Body")
+	require.Equal(t, "required", missingFieldErrs["Status"], "should error for no Status")
 	require.Equal(t, "required", missingFieldErrs["CreatedAt"], "should error for no CreatedAt")
 	require.Equal(t, "required", missingFieldErrs["UpdatedAt"], "should error for no UpdatedAt")
 	require.Equal(t, "required", missingFieldErrs["TagIds"], "should error for no TagIds")
@@ -632,6 +722,7 @@ func testArticleServiceUpdateFailsValidation(t *testing.T, service ArticleServic
 		Subtitle:  "abcde12345abcde12345abcde12345abcde12345abcde12345abcde12345abcde12345abcde12345abcde12345abcde12345abcde12345abcde12345abcde12345abcde12345abcde12345abcde12345abcde12345abcde12345abcde12345abcde12345abcde12345abcde12345abcde12345abcde12345abcde12345abcde12345abcde12345abcde12345abcde12345abcde12345",
 		Slug:      "abcdeabcdeabcdeabcdeabcdeabcdeabcdeabcdeabcdeabcdeabcde",
 		Body:      "Lorem ipsum dolar sit amet...",
+		Status:    "published",
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 		TagIds:    []int{1, 2, 3},
@@ -654,6 +745,7 @@ func testArticleServiceUpdateFailsValidation(t *testing.T, service ArticleServic
 		Subtitle:  "Some subtitle",
 		Slug:      "a",
 		Body:      "Lorem ipsum dolar sit amet...",
+		Status:    "published",
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 		TagIds:    []int{1, 2, 3},
@@ -680,6 +772,7 @@ func testArticleServiceGetManyArticlesByTagSlug(t *testing.T, service ArticleSer
 			Subtitle:  "article one subtitle",
 			Slug:      "article-one-slug",
 			Body:      "article one body content",
+			Status:    "published",
 			CreatedAt: createdAt,
 			UpdatedAt: updatedAt,
 			Tags: []tag.Tag{
@@ -695,6 +788,7 @@ func testArticleServiceGetManyArticlesByTagSlug(t *testing.T, service ArticleSer
 			Subtitle:  "article two subtitle",
 			Slug:      "article-two-slug",
 			Body:      "article two body content",
+			Status:    "published",
 			CreatedAt: createdAt,
 			UpdatedAt: updatedAt,
 			Tags: []tag.Tag{
@@ -708,22 +802,23 @@ func testArticleServiceGetManyArticlesByTagSlug(t *testing.T, service ArticleSer
 	}
 
 	mockCall := mockData.
-		On("GetManyByAttribute", "tagSlug", "tag-one").
-		Return(&mockRepoArticles, nil)
+		On("GetManyByAttribute", "tagSlug", "tag-one", pagination.Request{}).
+		Return(&pagination.Page[Article]{Items: mockRepoArticles}, nil)
 
-	gotArticle, err := service.GetManyByAttribute("tagSlug", "tag-one")
+	gotArticle, err := service.GetManyByAttribute("tagSlug", "tag-one", pagination.Request{})
 
 	if res := mockData.AssertExpectations(t); !res {
 		t.Error("unmet expectations")
 	}
 
 	require.Nil(t, err, "should not return error")
-	require.Equal(t, &[]ArticleResponseDto{
+	require.Equal(t, []ArticleResponseDto{
 		{
 			Title:     "article one title",
 			Subtitle:  "article one subtitle",
 			Slug:      "article-one-slug",
 			Body:      "article one body content",
+			Status:    "published",
 			CreatedAt: createdAt,
 			UpdatedAt: updatedAt,
 			Tags: []tag.Tag{
@@ -739,6 +834,7 @@ func testArticleServiceGetManyArticlesByTagSlug(t *testing.T, service ArticleSer
 			Subtitle:  "article two subtitle",
 			Slug:      "article-two-slug",
 			Body:      "article two body content",
+			Status:    "published",
 			CreatedAt: createdAt,
 			UpdatedAt: updatedAt,
 			Tags: []tag.Tag{
@@ -749,17 +845,17 @@ func testArticleServiceGetManyArticlesByTagSlug(t *testing.T, service ArticleSer
 				},
 			},
 		},
-	}, gotArticle, "should return article by slug")
+	}, gotArticle.Items, "should return article by slug")
 
 	mockCall.Unset()
 }
 
 func testArticleServiceGetManyArticlesByTagSlugError(t *testing.T, service ArticleService) {
 	mockCall := mockData.
-		On("GetManyByAttribute", "tagSlug", "tag-one").
-		Return(&[]Article{}, errors.New("repo_error"))
+		On("GetManyByAttribute", "tagSlug", "tag-one", pagination.Request{}).
+		Return((*pagination.Page[Article])(nil), errors.New("repo_error"))
 
-	got, err := service.GetManyByAttribute("tagSlug", "tag-one")
+	got, err := service.GetManyByAttribute("tagSlug", "tag-one", pagination.Request{})
 
 	if res := mockData.AssertExpectations(t); !res {
 		t.Error("unmet expectations")
@@ -770,3 +866,295 @@ func testArticleServiceGetManyArticlesByTagSlugError(t *testing.T, service Artic
 
 	mockCall.Unset()
 }
+
+func testArticleServiceCreateScheduledWithoutDate(t *testing.T, service ArticleService) {
+	got, err := service.Create(&ArticleNewRequestDto{
+		Title:     "Some title",
+		Subtitle:  "Some subtitle",
+		Slug:      "some-slug",
+		Body:      "Lorem ipsum dolar sit amet...",
+		Status:    StatusScheduled,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		TagIds:    []int{1, 2, 3},
+	})
+
+	require.Nil(t, got, "should not create article")
+	require.ErrorIs(t, err, ErrScheduledWithoutDate, "should return scheduled without date error")
+}
+
+func testArticleServicePublishScheduled(t *testing.T, service ArticleService) {
+	now := time.Now()
+	publishedAt := now.Add(time.Minute * -5)
+
+	mockCall := mockData.
+		On("PublishScheduled", now).
+		Return(&[]Article{
+			{
+				Id:          23,
+				Title:       "article title",
+				Subtitle:    "article subtitle",
+				Slug:        "article-slug",
+				Body:        "article body",
+				Status:      StatusPublished,
+				PublishedAt: &publishedAt,
+				CreatedAt:   now,
+				UpdatedAt:   now,
+			},
+		}, nil)
+
+	got, err := service.PublishScheduled(now)
+
+	if res := mockData.AssertExpectations(t); !res {
+		t.Error("unmet expectations")
+	}
+
+	require.NoError(t, err, "should not return error")
+
+	require.Equal(t, &[]ArticleResponseDto{
+		{
+			Id:          23,
+			Title:       "article title",
+			Subtitle:    "article subtitle",
+			Slug:        "article-slug",
+			Body:        "article body",
+			Status:      StatusPublished,
+			PublishedAt: &publishedAt,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		},
+	}, got, "should return published articles")
+
+	mockCall.Unset()
+}
+
+func testArticleServicePublishScheduledError(t *testing.T, service ArticleService) {
+	now := time.Now()
+
+	mockCall := mockData.
+		On("PublishScheduled", now).
+		Return(&[]Article{}, errors.New("repo_error"))
+
+	got, err := service.PublishScheduled(now)
+
+	if res := mockData.AssertExpectations(t); !res {
+		t.Error("unmet expectations")
+	}
+
+	require.Nil(t, got, "should not return articles")
+	require.EqualError(t, err, "repo_error", "should return repo error")
+
+	mockCall.Unset()
+}
+
+func testArticleServiceGetRevisions(t *testing.T, service ArticleService) {
+	createdAt := time.Now()
+
+	mockCall := mockData.
+		On("GetRevisions", 23).
+		Return(&[]ArticleRevision{
+			{
+				Id:        2,
+				ArticleId: 23,
+				Title:     "article title",
+				Subtitle:  "article subtitle",
+				Slug:      "article-slug",
+				Body:      "article body",
+				TagIds:    []int{1, 2},
+				CreatedAt: createdAt,
+			},
+		}, nil)
+
+	got, err := service.GetRevisions(23)
+
+	if res := mockData.AssertExpectations(t); !res {
+		t.Error("unmet expectations")
+	}
+
+	require.NoError(t, err, "should not return error")
+
+	require.Equal(t, &[]ArticleRevisionResponseDto{
+		{
+			Id:        2,
+			ArticleId: 23,
+			Title:     "article title",
+			Subtitle:  "article subtitle",
+			Slug:      "article-slug",
+			Body:      "article body",
+			TagIds:    []int{1, 2},
+			CreatedAt: createdAt,
+		},
+	}, got, "should return revisions")
+
+	mockCall.Unset()
+}
+
+func testArticleServiceRestoreRevision(t *testing.T, service ArticleService) {
+	createdAt := time.Now().Add(time.Hour * -48)
+	publishedAt := createdAt
+
+	mockGetCall := mockData.
+		On("GetByAttribute", "slug", "current-slug").
+		Return(&Article{
+			Id:          23,
+			Title:       "current title",
+			Subtitle:    "current subtitle",
+			Slug:        "current-slug",
+			Body:        "current body",
+			Status:      StatusPublished,
+			PublishedAt: &publishedAt,
+			CreatedAt:   createdAt,
+			UpdatedAt:   createdAt,
+		}, nil)
+
+	mockRevisionCall := mockData.
+		On("GetRevisionById", 7).
+		Return(&ArticleRevision{
+			Id:        7,
+			ArticleId: 23,
+			Title:     "old title",
+			Subtitle:  "old subtitle",
+			Slug:      "old-slug",
+			Body:      "old body",
+			TagIds:    []int{4},
+			CreatedAt: createdAt,
+		}, nil)
+
+	var updated *UpdateArticle
+
+	mockUpdateCall := mockData.
+		On("Update", mock.Anything).
+		Run(func(args mock.Arguments) {
+			updated = args.Get(0).(*UpdateArticle)
+		}).
+		Return(&Article{
+			Id:          23,
+			Title:       "old title",
+			Subtitle:    "old subtitle",
+			Slug:        "current-slug",
+			Body:        "old body",
+			Status:      StatusPublished,
+			PublishedAt: &publishedAt,
+			CreatedAt:   createdAt,
+			UpdatedAt:   createdAt,
+		}, nil)
+
+	got, err := service.RestoreRevision("current-slug", 7)
+
+	if res := mockData.AssertExpectations(t); !res {
+		t.Error("should restore through update")
+	}
+
+	require.NoError(t, err, "should not return error")
+	require.Equal(t, "old body", got.Body, "should return restored article")
+
+	require.Equal(t, 23, updated.Id, "should update current article")
+	require.Equal(t, "old title", updated.Title, "should restore title")
+	require.Equal(t, "old subtitle", updated.Subtitle, "should restore subtitle")
+	require.Equal(t, "old body", updated.Body, "should restore body")
+	require.Equal(t, []int{4}, updated.TagIds, "should restore tags")
+	require.Equal(t, "current-slug", updated.Slug, "should keep current slug")
+	require.Equal(t, StatusPublished, updated.Status, "should keep current status")
+	require.Equal(t, &publishedAt, updated.PublishedAt, "should keep published date")
+
+	mockGetCall.Unset()
+	mockRevisionCall.Unset()
+	mockUpdateCall.Unset()
+}
+
+func testArticleServiceRestoreRevisionWrongArticle(t *testing.T, service ArticleService) {
+	mockGetCall := mockData.
+		On("GetByAttribute", "slug", "current-slug").
+		Return(&Article{Id: 23, Slug: "current-slug"}, nil)
+
+	mockRevisionCall := mockData.
+		On("GetRevisionById", 7).
+		Return(&ArticleRevision{Id: 7, ArticleId: 42}, nil)
+
+	got, err := service.RestoreRevision("current-slug", 7)
+
+	require.Nil(t, got, "should not restore revision")
+	require.ErrorIs(t, err, ErrRevisionNotForArticle, "should return wrong article error")
+
+	mockGetCall.Unset()
+	mockRevisionCall.Unset()
+}
+
+func testArticleServiceRestoreRevisionNotFound(t *testing.T, service ArticleService) {
+	mockGetCall := mockData.
+		On("GetByAttribute", "slug", "current-slug").
+		Return(&Article{Id: 23, Slug: "current-slug"}, nil)
+
+	mockRevisionCall := mockData.
+		On("GetRevisionById", 7).
+		Return(&ArticleRevision{}, pgx.ErrNoRows)
+
+	got, err := service.RestoreRevision("current-slug", 7)
+
+	require.Nil(t, got, "should not restore revision")
+	require.Equal(t, ErrRevisionNotFound, err, "should return revision not found error")
+
+	mockGetCall.Unset()
+	mockRevisionCall.Unset()
+}
+
+func testArticleServiceSearch(t *testing.T, service ArticleService) {
+	createdAt := time.Now()
+
+	mockCall := mockData.
+		On("Search", "go <script>", pagination.Request{}, []string{StatusPublished}).
+		Return(&pagination.Page[ArticleSearchResult]{
+			Items: []ArticleSearchResult{
+				{
+					Article: Article{
+						Id:        23,
+						Title:     "Go search",
+						Subtitle:  "Subtitle",
+						Slug:      "go-search",
+						Body:      "Body about go <script>",
+						Status:    StatusPublished,
+						CreatedAt: createdAt,
+						UpdatedAt: createdAt,
+					},
+					Headline: "Body about " + headlineStartSel + "go" + headlineStopSel + " <script>",
+					Rank:     0.6,
+				},
+			},
+			Total: 11,
+		}, nil)
+
+	got, err := service.Search("  go <script> ", pagination.Request{}, StatusPublished)
+
+	if res := mockData.AssertExpectations(t); !res {
+		t.Error("unmet expectations")
+	}
+
+	require.NoError(t, err, "should not return error")
+	require.Equal(t, "go <script>", got.Query, "should return trimmed query")
+	require.Equal(t, 11, got.Results.Total, "should return total")
+	require.Equal(t, []ArticleSearchResultResponseDto{
+		{
+			Article: ArticleResponseDto{
+				Id:        23,
+				Title:     "Go search",
+				Subtitle:  "Subtitle",
+				Slug:      "go-search",
+				Body:      "Body about go <script>",
+				Status:    StatusPublished,
+				CreatedAt: createdAt,
+				UpdatedAt: createdAt,
+			},
+			Snippet: "Body about <mark>go</mark> &lt;script&gt;",
+		},
+	}, got.Results.Items, "should return escaped, highlighted results")
+
+	mockCall.Unset()
+}
+
+func testArticleServiceSearchEmptyQuery(t *testing.T, service ArticleService) {
+	got, err := service.Search("   ", pagination.Request{})
+
+	require.NoError(t, err, "should not return error")
+	require.Equal(t, "", got.Query, "should return empty query")
+	require.Empty(t, got.Results.Items, "should return empty results")
+}
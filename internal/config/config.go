@@ -0,0 +1,455 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nixpig/dunce/pkg/logging"
+	"golang.org/x/crypto/bcrypt"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is everything the app can be configured with. It's loaded by Load,
+// which fills it from defaults, a config file, the environment and flags, in
+// that order, with each overriding the one before.
+type Config struct {
+	Server   Server   `yaml:"server"`
+	Database Database `yaml:"database"`
+	Session  Session  `yaml:"session"`
+	Mail     Mail     `yaml:"mail"`
+	Security Security `yaml:"security"`
+	Paths    Paths    `yaml:"paths"`
+	Metrics  Metrics  `yaml:"metrics"`
+	Log      Log      `yaml:"log"`
+}
+
+// Server is how requests are served. ShutdownTimeout is how long requests in
+// flight get to finish when the server's stopped, and HandoffOnSighup is
+// whether a SIGHUP starts a new process to take over the listener, for
+// restarts that don't drop any connections. BaseUrl is the absolute URL the
+// site is served at, e.g. https://example.org, used for links that leave the
+// site, such as in emails and feeds, when the site has no domain set. Links
+// are never made from the Host of a request, since anyone can set it.
+type Server struct {
+	Port            int           `yaml:"port"`
+	BaseUrl         string        `yaml:"base_url"`
+	ReadTimeout     time.Duration `yaml:"read_timeout"`
+	WriteTimeout    time.Duration `yaml:"write_timeout"`
+	IdleTimeout     time.Duration `yaml:"idle_timeout"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+	HandoffOnSighup bool          `yaml:"handoff_on_sighup"`
+}
+
+type Database struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	Name     string `yaml:"name"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
+// ConnectionString is the URL to connect to the database with.
+func (d Database) ConnectionString() string {
+	return fmt.Sprintf(
+		"postgres://%s:%s@%s:%d/%s?sslmode=disable",
+		d.Username, d.Password, d.Host, d.Port, d.Name,
+	)
+}
+
+type Session struct {
+	Lifetime    time.Duration `yaml:"lifetime"`
+	IdleTimeout time.Duration `yaml:"idle_timeout"`
+	SameSite    string        `yaml:"cookie_samesite"`
+	Secure      bool          `yaml:"cookie_secure"`
+}
+
+// SameSiteMode is the SameSite attribute to set on the session cookie.
+func (s Session) SameSiteMode() http.SameSite {
+	switch strings.ToLower(s.SameSite) {
+	case "strict":
+		return http.SameSiteStrictMode
+	case "none":
+		return http.SameSiteNoneMode
+	default:
+		return http.SameSiteLaxMode
+	}
+}
+
+// Mail is where emails are sent. Without an SMTP host, e.g. in development,
+// emails are written to File instead, or to stdout if that isn't set either.
+type Mail struct {
+	From         string `yaml:"from"`
+	File         string `yaml:"file"`
+	SmtpHost     string `yaml:"smtp_host"`
+	SmtpPort     int    `yaml:"smtp_port"`
+	SmtpUsername string `yaml:"smtp_username"`
+	SmtpPassword string `yaml:"smtp_password"`
+}
+
+type Security struct {
+	BcryptCost int `yaml:"bcrypt_cost"`
+}
+
+type Paths struct {
+	Templates  string `yaml:"templates"`
+	Static     string `yaml:"static"`
+	Migrations string `yaml:"migrations"`
+}
+
+// Metrics is where Prometheus metrics are served, e.g. "127.0.0.1:9090". It's
+// a separate listener from the site, so it can be kept private, and metrics
+// aren't served at all if Address isn't set.
+type Metrics struct {
+	Address string `yaml:"address"`
+}
+
+// Log is how the app logs: at what level, and whether as text or JSON.
+type Log struct {
+	Level  string `yaml:"level"`
+	Format string `yaml:"format"`
+}
+
+// SlogLevel is the least severe level to log at.
+func (l Log) SlogLevel() slog.Level {
+	var level slog.Level
+
+	// invalid levels are caught by Validate, so fall back to info
+	if err := level.UnmarshalText([]byte(l.Level)); err != nil {
+		return slog.LevelInfo
+	}
+
+	return level
+}
+
+// Default is the config used for anything that isn't set elsewhere.
+func Default() Config {
+	return Config{
+		Server: Server{
+			Port:            8080,
+			ReadTimeout:     10 * time.Second,
+			WriteTimeout:    10 * time.Second,
+			IdleTimeout:     time.Minute,
+			ShutdownTimeout: 30 * time.Second,
+		},
+		Database: Database{
+			Host: "localhost",
+			Port: 5432,
+		},
+		Session: Session{
+			Lifetime: 24 * time.Hour,
+			SameSite: "lax",
+			Secure:   true,
+		},
+		Mail: Mail{
+			From: "dunce@localhost",
+		},
+		Security: Security{
+			BcryptCost: 14,
+		},
+		Paths: Paths{
+			Templates:  filepath.Join("web", "templates"),
+			Static:     filepath.Join("web", "static"),
+			Migrations: filepath.Join("db", "migrations"),
+		},
+		Log: Log{
+			Level:  "info",
+			Format: logging.FormatText,
+		},
+	}
+}
+
+// setting is a single value that can be set from the environment or a flag.
+// Settings in the config file are set by their yaml tags instead.
+type setting struct {
+	env   string
+	flag  string
+	usage string
+	value any
+}
+
+func (c *Config) settings() []setting {
+	return []setting{
+		{"WEB_PORT", "port", "port to serve on", &c.Server.Port},
+		{"SERVER_BASE_URL", "base-url", "absolute URL the site is served at, e.g. https://example.org, for links when the site has no domain set", &c.Server.BaseUrl},
+		{"SERVER_READ_TIMEOUT", "read-timeout", "longest time to read a request", &c.Server.ReadTimeout},
+		{"SERVER_WRITE_TIMEOUT", "write-timeout", "longest time to write a response", &c.Server.WriteTimeout},
+		{"SERVER_IDLE_TIMEOUT", "idle-timeout", "longest time to keep an idle connection open", &c.Server.IdleTimeout},
+		{"SERVER_SHUTDOWN_TIMEOUT", "shutdown-timeout", "longest time to wait for requests to finish when stopping", &c.Server.ShutdownTimeout},
+		{"SERVER_HANDOFF_ON_SIGHUP", "handoff-on-sighup", "start a new process to take over the listener on SIGHUP", &c.Server.HandoffOnSighup},
+
+		{"DATABASE_HOST", "database-host", "database host", &c.Database.Host},
+		{"DATABASE_PORT", "database-port", "database port", &c.Database.Port},
+		{"POSTGRES_DB", "database-name", "database name", &c.Database.Name},
+		{"POSTGRES_USER", "database-username", "database username", &c.Database.Username},
+		{"POSTGRES_PASSWORD", "database-password", "database password", &c.Database.Password},
+
+		{"SESSION_LIFETIME", "session-lifetime", "longest a session lasts", &c.Session.Lifetime},
+		{"SESSION_IDLE_TIMEOUT", "session-idle-timeout", "how long a session lasts without being used, or 0 for no limit", &c.Session.IdleTimeout},
+		{"SESSION_COOKIE_SAMESITE", "session-cookie-samesite", "session cookie SameSite mode: lax, strict or none", &c.Session.SameSite},
+		{"SESSION_COOKIE_SECURE", "session-cookie-secure", "only send the session cookie over https", &c.Session.Secure},
+
+		{"MAIL_FROM", "mail-from", "address emails are sent from", &c.Mail.From},
+		{"MAIL_FILE", "mail-file", "file to write emails to when there's no SMTP host", &c.Mail.File},
+		{"SMTP_HOST", "smtp-host", "SMTP host to send emails through", &c.Mail.SmtpHost},
+		{"SMTP_PORT", "smtp-port", "SMTP port", &c.Mail.SmtpPort},
+		{"SMTP_USERNAME", "smtp-username", "SMTP username", &c.Mail.SmtpUsername},
+		{"SMTP_PASSWORD", "smtp-password", "SMTP password", &c.Mail.SmtpPassword},
+
+		{"BCRYPT_COST", "bcrypt-cost", "bcrypt cost to hash passwords with", &c.Security.BcryptCost},
+
+		{"TEMPLATES_DIR", "templates-dir", "directory of templates", &c.Paths.Templates},
+		{"STATIC_DIR", "static-dir", "directory of static files", &c.Paths.Static},
+		{"MIGRATIONS_DIR", "migrations-dir", "directory of database migrations", &c.Paths.Migrations},
+
+		{"METRICS_ADDRESS", "metrics-address", "address to serve Prometheus metrics on, e.g. 127.0.0.1:9090", &c.Metrics.Address},
+
+		{"LOG_LEVEL", "log-level", "least severe level to log: debug, info, warn or error", &c.Log.Level},
+		{"LOG_FORMAT", "log-format", "log format: text or json", &c.Log.Format},
+	}
+}
+
+func (s setting) set(value string) error {
+	switch v := s.value.(type) {
+	case *string:
+		*v = value
+
+	case *int:
+		i, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("'%s' isn't a number", value)
+		}
+
+		*v = i
+
+	case *bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("'%s' isn't true or false", value)
+		}
+
+		*v = b
+
+	case *time.Duration:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("'%s' isn't a duration, e.g. 30s or 1h", value)
+		}
+
+		*v = d
+	}
+
+	return nil
+}
+
+// Load builds the config from defaults, then the config file, then the
+// environment, then args, which are the command line flags without the
+// program name. The config file is given by the -config flag or CONFIG_FILE.
+// Everything is validated, and every problem found is returned at once.
+func Load(args []string, getenv func(string) string) (*Config, error) {
+	config := Default()
+	settings := config.settings()
+
+	var errs []error
+
+	flags := flag.NewFlagSet("dunce", flag.ContinueOnError)
+
+	configFile := flags.String("config", getenv("CONFIG_FILE"), "YAML config file")
+
+	// flags are set last, but have to be parsed first to find the config file
+	flagValues := map[string]string{}
+	for _, s := range settings {
+		record := func(value string) error {
+			flagValues[s.flag] = value
+			return nil
+		}
+
+		// bool flags can be given without a value, e.g. -session-cookie-secure
+		if _, ok := s.value.(*bool); ok {
+			flags.BoolFunc(s.flag, s.usage, record)
+		} else {
+			flags.Func(s.flag, s.usage, record)
+		}
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if *configFile != "" {
+		if err := config.loadFile(*configFile); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	for _, s := range settings {
+		if value := getenv(s.env); value != "" {
+			if err := s.set(value); err != nil {
+				errs = append(errs, fmt.Errorf("invalid %s: %w", s.env, err))
+			}
+		}
+	}
+
+	for _, s := range settings {
+		if value, ok := flagValues[s.flag]; ok {
+			if err := s.set(value); err != nil {
+				errs = append(errs, fmt.Errorf("invalid -%s: %w", s.flag, err))
+			}
+		}
+	}
+
+	// links are made by adding paths to the base URL
+	config.Server.BaseUrl = strings.TrimRight(config.Server.BaseUrl, "/")
+
+	if err := config.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	return &config, nil
+}
+
+func (c *Config) loadFile(name string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return fmt.Errorf("unable to open config file: %w", err)
+	}
+
+	defer f.Close()
+
+	decoder := yaml.NewDecoder(f)
+	decoder.KnownFields(true)
+
+	if err := decoder.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("invalid config file %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// Validate checks every value, returning all the problems found joined
+// together.
+func (c Config) Validate() error {
+	var errs []error
+
+	invalid := func(format string, values ...any) {
+		errs = append(errs, fmt.Errorf(format, values...))
+	}
+
+	if c.Server.Port < 1 || c.Server.Port > 65535 {
+		invalid("server port %d should be between 1 and 65535", c.Server.Port)
+	}
+
+	if c.Server.BaseUrl != "" {
+		if u, err := url.Parse(c.Server.BaseUrl); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			invalid("server base url '%s' should be an absolute http or https URL, e.g. https://example.org", c.Server.BaseUrl)
+		}
+	}
+
+	for _, timeout := range []struct {
+		name  string
+		value time.Duration
+	}{
+		{"read", c.Server.ReadTimeout},
+		{"write", c.Server.WriteTimeout},
+		{"idle", c.Server.IdleTimeout},
+		{"shutdown", c.Server.ShutdownTimeout},
+	} {
+		if timeout.value <= 0 {
+			invalid("server %s timeout %s should be more than 0", timeout.name, timeout.value)
+		}
+	}
+
+	if c.Database.Host == "" {
+		invalid("database host is required")
+	}
+
+	if c.Database.Port < 1 || c.Database.Port > 65535 {
+		invalid("database port %d should be between 1 and 65535", c.Database.Port)
+	}
+
+	if c.Database.Name == "" {
+		invalid("database name is required")
+	}
+
+	if c.Database.Username == "" {
+		invalid("database username is required")
+	}
+
+	if c.Session.Lifetime <= 0 {
+		invalid("session lifetime %s should be more than 0", c.Session.Lifetime)
+	}
+
+	if c.Session.IdleTimeout < 0 {
+		invalid("session idle timeout %s shouldn't be less than 0", c.Session.IdleTimeout)
+	}
+
+	switch strings.ToLower(c.Session.SameSite) {
+	case "lax", "strict":
+	case "none":
+		// browsers reject SameSite=None cookies that aren't secure
+		if !c.Session.Secure {
+			invalid("session cookie samesite none needs the cookie to be secure")
+		}
+	default:
+		invalid("session cookie samesite '%s' should be lax, strict or none", c.Session.SameSite)
+	}
+
+	if c.Mail.From == "" {
+		invalid("mail from address is required")
+	}
+
+	if c.Mail.SmtpHost != "" && (c.Mail.SmtpPort < 1 || c.Mail.SmtpPort > 65535) {
+		invalid("smtp port %d should be between 1 and 65535", c.Mail.SmtpPort)
+	}
+
+	if c.Security.BcryptCost < bcrypt.MinCost || c.Security.BcryptCost > bcrypt.MaxCost {
+		invalid("bcrypt cost %d should be between %d and %d", c.Security.BcryptCost, bcrypt.MinCost, bcrypt.MaxCost)
+	}
+
+	for _, dir := range []struct {
+		name string
+		path string
+	}{
+		{"templates", c.Paths.Templates},
+		{"static", c.Paths.Static},
+		{"migrations", c.Paths.Migrations},
+	} {
+		if info, err := os.Stat(dir.path); err != nil || !info.IsDir() {
+			invalid("%s directory '%s' doesn't exist", dir.name, dir.path)
+		}
+	}
+
+	if c.Metrics.Address != "" {
+		if _, port, err := net.SplitHostPort(c.Metrics.Address); err != nil || port == "" {
+			invalid("metrics address '%s' should be a host and port, e.g. 127.0.0.1:9090", c.Metrics.Address)
+		} else if port == strconv.Itoa(c.Server.Port) {
+			invalid("metrics address '%s' should be on a different port to the server", c.Metrics.Address)
+		}
+	}
+
+	switch strings.ToLower(c.Log.Level) {
+	case "debug", "info", "warn", "error":
+	default:
+		invalid("log level '%s' should be debug, info, warn or error", c.Log.Level)
+	}
+
+	if c.Log.Format != logging.FormatText && c.Log.Format != logging.FormatJson {
+		invalid("log format '%s' should be text or json", c.Log.Format)
+	}
+
+	return errors.Join(errs...)
+}
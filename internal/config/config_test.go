@@ -0,0 +1,302 @@
+package config
+
+import (
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig(t *testing.T) {
+	scenarios := map[string]func(t *testing.T){
+		"defaults":                   testDefaults,
+		"file overrides defaults":    testFileOverridesDefaults,
+		"env overrides file":         testEnvOverridesFile,
+		"flags override env":         testFlagsOverrideEnv,
+		"config file from env":       testConfigFileFromEnv,
+		"bool flag without value":    testBoolFlagWithoutValue,
+		"reports all problems":       testReportsAllProblems,
+		"unknown file field":         testUnknownFileField,
+		"missing file":               testMissingFile,
+		"unknown flag":               testUnknownFlag,
+		"samesite none needs secure": testSameSiteNoneNeedsSecure,
+		"smtp port needed with host": testSmtpPortNeededWithHost,
+		"connection string":          testConnectionString,
+		"samesite mode":              testSameSiteMode,
+		"metrics address":            testMetricsAddress,
+		"invalid metrics address":    testInvalidMetricsAddress,
+		"base url":                   testBaseUrl,
+		"invalid base url":           testInvalidBaseUrl,
+		"log":                        testLog,
+		"invalid log":                testInvalidLog,
+	}
+
+	for scenario, fn := range scenarios {
+		t.Run(scenario, func(t *testing.T) {
+			fn(t)
+		})
+	}
+}
+
+// testEnv is an environment with everything that's required set, and paths
+// to directories that exist.
+func testEnv(t *testing.T, values map[string]string) func(string) string {
+	dir := t.TempDir()
+
+	env := map[string]string{
+		"POSTGRES_DB":    "dunce",
+		"POSTGRES_USER":  "dunce",
+		"TEMPLATES_DIR":  dir,
+		"STATIC_DIR":     dir,
+		"MIGRATIONS_DIR": dir,
+	}
+
+	for key, value := range values {
+		env[key] = value
+	}
+
+	return func(key string) string {
+		return env[key]
+	}
+}
+
+func writeFile(t *testing.T, contents string) string {
+	name := filepath.Join(t.TempDir(), "config.yaml")
+
+	require.NoError(t, os.WriteFile(name, []byte(contents), 0o600))
+
+	return name
+}
+
+func testDefaults(t *testing.T) {
+	config, err := Load(nil, testEnv(t, nil))
+	require.NoError(t, err)
+
+	require.Equal(t, 8080, config.Server.Port)
+	require.Equal(t, 10*time.Second, config.Server.ReadTimeout)
+	require.Equal(t, 10*time.Second, config.Server.WriteTimeout)
+	require.Equal(t, time.Minute, config.Server.IdleTimeout)
+	require.Equal(t, 30*time.Second, config.Server.ShutdownTimeout)
+	require.False(t, config.Server.HandoffOnSighup)
+	require.Equal(t, "localhost", config.Database.Host)
+	require.Equal(t, 5432, config.Database.Port)
+	require.Equal(t, 24*time.Hour, config.Session.Lifetime)
+	require.Equal(t, time.Duration(0), config.Session.IdleTimeout)
+	require.Equal(t, "lax", config.Session.SameSite)
+	require.True(t, config.Session.Secure)
+	require.Equal(t, "dunce@localhost", config.Mail.From)
+	require.Equal(t, 14, config.Security.BcryptCost)
+	require.Empty(t, config.Metrics.Address)
+	require.Equal(t, "info", config.Log.Level)
+	require.Equal(t, "text", config.Log.Format)
+}
+
+func testFileOverridesDefaults(t *testing.T) {
+	file := writeFile(t, `
+server:
+  port: 9000
+  read_timeout: 5s
+database:
+  host: db
+session:
+  lifetime: 12h
+  cookie_secure: false
+security:
+  bcrypt_cost: 10
+`)
+
+	config, err := Load([]string{"-config", file}, testEnv(t, nil))
+	require.NoError(t, err)
+
+	require.Equal(t, 9000, config.Server.Port)
+	require.Equal(t, 5*time.Second, config.Server.ReadTimeout)
+	require.Equal(t, 10*time.Second, config.Server.WriteTimeout)
+	require.Equal(t, "db", config.Database.Host)
+	require.Equal(t, 12*time.Hour, config.Session.Lifetime)
+	require.False(t, config.Session.Secure)
+	require.Equal(t, 10, config.Security.BcryptCost)
+}
+
+func testEnvOverridesFile(t *testing.T) {
+	file := writeFile(t, "server:\n  port: 9000\ndatabase:\n  host: db\n")
+
+	config, err := Load([]string{"-config", file}, testEnv(t, map[string]string{
+		"WEB_PORT":         "9001",
+		"SESSION_LIFETIME": "1h",
+	}))
+	require.NoError(t, err)
+
+	require.Equal(t, 9001, config.Server.Port)
+	require.Equal(t, "db", config.Database.Host)
+	require.Equal(t, time.Hour, config.Session.Lifetime)
+}
+
+func testFlagsOverrideEnv(t *testing.T) {
+	config, err := Load(
+		[]string{"-port", "9002", "-bcrypt-cost", "12"},
+		testEnv(t, map[string]string{"WEB_PORT": "9001", "BCRYPT_COST": "11"}),
+	)
+	require.NoError(t, err)
+
+	require.Equal(t, 9002, config.Server.Port)
+	require.Equal(t, 12, config.Security.BcryptCost)
+}
+
+func testConfigFileFromEnv(t *testing.T) {
+	file := writeFile(t, "mail:\n  from: site@example.com\n")
+
+	config, err := Load(nil, testEnv(t, map[string]string{"CONFIG_FILE": file}))
+	require.NoError(t, err)
+
+	require.Equal(t, "site@example.com", config.Mail.From)
+}
+
+func testBoolFlagWithoutValue(t *testing.T) {
+	config, err := Load(
+		[]string{"-session-cookie-secure"},
+		testEnv(t, map[string]string{"SESSION_COOKIE_SECURE": "false"}),
+	)
+	require.NoError(t, err)
+
+	require.True(t, config.Session.Secure)
+}
+
+func testReportsAllProblems(t *testing.T) {
+	config, err := Load(
+		[]string{"-session-lifetime", "forever"},
+		testEnv(t, map[string]string{
+			"WEB_PORT":                "abc",
+			"DATABASE_PORT":           "70000",
+			"POSTGRES_DB":             "",
+			"SESSION_COOKIE_SAMESITE": "sometimes",
+			"BCRYPT_COST":             "50",
+			"STATIC_DIR":              "/does/not/exist",
+		}),
+	)
+
+	require.Nil(t, config)
+	require.EqualError(t, err, `invalid WEB_PORT: 'abc' isn't a number
+invalid -session-lifetime: 'forever' isn't a duration, e.g. 30s or 1h
+database port 70000 should be between 1 and 65535
+database name is required
+session cookie samesite 'sometimes' should be lax, strict or none
+bcrypt cost 50 should be between 4 and 31
+static directory '/does/not/exist' doesn't exist`)
+}
+
+func testUnknownFileField(t *testing.T) {
+	file := writeFile(t, "server:\n  prot: 9000\n")
+
+	config, err := Load([]string{"-config", file}, testEnv(t, nil))
+
+	require.Nil(t, config)
+	require.ErrorContains(t, err, "field prot not found")
+}
+
+func testMissingFile(t *testing.T) {
+	config, err := Load([]string{"-config", "/does/not/exist.yaml"}, testEnv(t, nil))
+
+	require.Nil(t, config)
+	require.ErrorContains(t, err, "unable to open config file")
+}
+
+func testUnknownFlag(t *testing.T) {
+	config, err := Load([]string{"-prot", "9000"}, testEnv(t, nil))
+
+	require.Nil(t, config)
+	require.EqualError(t, err, "flag provided but not defined: -prot")
+}
+
+func testSameSiteNoneNeedsSecure(t *testing.T) {
+	config, err := Load(nil, testEnv(t, map[string]string{
+		"SESSION_COOKIE_SAMESITE": "none",
+		"SESSION_COOKIE_SECURE":   "false",
+	}))
+
+	require.Nil(t, config)
+	require.EqualError(t, err, "session cookie samesite none needs the cookie to be secure")
+}
+
+func testSmtpPortNeededWithHost(t *testing.T) {
+	config, err := Load(nil, testEnv(t, map[string]string{"SMTP_HOST": "mail"}))
+
+	require.Nil(t, config)
+	require.EqualError(t, err, "smtp port 0 should be between 1 and 65535")
+}
+
+func testConnectionString(t *testing.T) {
+	database := Database{
+		Host:     "db",
+		Port:     5433,
+		Name:     "dunce",
+		Username: "user",
+		Password: "p4ssw0rd",
+	}
+
+	require.Equal(t, "postgres://user:p4ssw0rd@db:5433/dunce?sslmode=disable", database.ConnectionString())
+}
+
+func testSameSiteMode(t *testing.T) {
+	require.Equal(t, http.SameSiteLaxMode, Session{SameSite: "lax"}.SameSiteMode())
+	require.Equal(t, http.SameSiteStrictMode, Session{SameSite: "Strict"}.SameSiteMode())
+	require.Equal(t, http.SameSiteNoneMode, Session{SameSite: "none"}.SameSiteMode())
+}
+
+func testMetricsAddress(t *testing.T) {
+	config, err := Load([]string{"-metrics-address", "127.0.0.1:9090"}, testEnv(t, nil))
+	require.NoError(t, err)
+
+	require.Equal(t, "127.0.0.1:9090", config.Metrics.Address)
+}
+
+func testInvalidMetricsAddress(t *testing.T) {
+	config, err := Load(nil, testEnv(t, map[string]string{"METRICS_ADDRESS": "localhost"}))
+
+	require.Nil(t, config)
+	require.EqualError(t, err, "metrics address 'localhost' should be a host and port, e.g. 127.0.0.1:9090")
+
+	config, err = Load(nil, testEnv(t, map[string]string{"METRICS_ADDRESS": ":8080"}))
+
+	require.Nil(t, config)
+	require.EqualError(t, err, "metrics address ':8080' should be on a different port to the server")
+}
+
+func testBaseUrl(t *testing.T) {
+	config, err := Load(nil, testEnv(t, map[string]string{"SERVER_BASE_URL": "https://example.org/"}))
+	require.NoError(t, err)
+
+	require.Equal(t, "https://example.org", config.Server.BaseUrl, "should trim trailing slash")
+}
+
+func testInvalidBaseUrl(t *testing.T) {
+	config, err := Load(nil, testEnv(t, map[string]string{"SERVER_BASE_URL": "example.org"}))
+
+	require.Nil(t, config)
+	require.EqualError(t, err, "server base url 'example.org' should be an absolute http or https URL, e.g. https://example.org")
+}
+
+func testLog(t *testing.T) {
+	config, err := Load(
+		[]string{"-log-format", "json"},
+		testEnv(t, map[string]string{"LOG_LEVEL": "DEBUG"}),
+	)
+	require.NoError(t, err)
+
+	require.Equal(t, "json", config.Log.Format)
+	require.Equal(t, slog.LevelDebug, config.Log.SlogLevel())
+	require.Equal(t, slog.LevelWarn, Log{Level: "warn"}.SlogLevel())
+}
+
+func testInvalidLog(t *testing.T) {
+	config, err := Load(nil, testEnv(t, map[string]string{
+		"LOG_LEVEL":  "verbose",
+		"LOG_FORMAT": "xml",
+	}))
+
+	require.Nil(t, config)
+	require.EqualError(t, err, "log level 'verbose' should be debug, info, warn or error\nlog format 'xml' should be text or json")
+}
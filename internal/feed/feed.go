@@ -0,0 +1,271 @@
+package feed
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"time"
+)
+
+// FeedSize is the number of most recently published articles in a feed.
+const FeedSize = 20
+
+const (
+	RssContentType  = "application/rss+xml; charset=utf-8"
+	AtomContentType = "application/atom+xml; charset=utf-8"
+	JsonContentType = "application/feed+json; charset=utf-8"
+)
+
+const (
+	RssFile  = "/feed.xml"
+	AtomFile = "/atom.xml"
+	JsonFile = "/feed.json"
+)
+
+// Feed is a format-agnostic feed of articles, which can be rendered as RSS,
+// Atom or JSON Feed. FeedUrl is the URL the feed files are served under, so
+// the Atom feed is at FeedUrl + AtomFile.
+type Feed struct {
+	Title       string
+	Description string
+	HomeUrl     string
+	FeedUrl     string
+	Updated     time.Time
+	Items       []Item
+}
+
+type Category struct {
+	Name string
+	Slug string
+}
+
+type Item struct {
+	Url         string
+	Title       string
+	Summary     string
+	ContentHtml string
+	Categories  []Category
+	Published   time.Time
+	Updated     time.Time
+}
+
+type rss struct {
+	XMLName      xml.Name   `xml:"rss"`
+	Version      string     `xml:"version,attr"`
+	XmlnsAtom    string     `xml:"xmlns:atom,attr"`
+	XmlnsContent string     `xml:"xmlns:content,attr"`
+	Channel      rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	AtomLink      rssLink   `xml:"atom:link"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	Guid        rssGuid  `xml:"guid"`
+	Description string   `xml:"description"`
+	Content     rssCdata `xml:"content:encoded"`
+	Categories  []string `xml:"category"`
+	PubDate     string   `xml:"pubDate"`
+}
+
+type rssGuid struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssCdata struct {
+	Value string `xml:",cdata"`
+}
+
+// Rss renders the feed as RSS 2.0.
+func (f Feed) Rss() ([]byte, error) {
+	items := make([]rssItem, len(f.Items))
+
+	for index, item := range f.Items {
+		categories := make([]string, len(item.Categories))
+		for i, category := range item.Categories {
+			categories[i] = category.Name
+		}
+
+		items[index] = rssItem{
+			Title:       item.Title,
+			Link:        item.Url,
+			Guid:        rssGuid{IsPermaLink: true, Value: item.Url},
+			Description: item.Summary,
+			Content:     rssCdata{Value: item.ContentHtml},
+			Categories:  categories,
+			PubDate:     item.Published.UTC().Format(time.RFC1123Z),
+		}
+	}
+
+	return marshalXml(rss{
+		Version:      "2.0",
+		XmlnsAtom:    "http://www.w3.org/2005/Atom",
+		XmlnsContent: "http://purl.org/rss/1.0/modules/content/",
+		Channel: rssChannel{
+			Title:         f.Title,
+			Link:          f.HomeUrl,
+			Description:   f.Description,
+			LastBuildDate: f.Updated.UTC().Format(time.RFC1123Z),
+			AtomLink: rssLink{
+				Href: f.FeedUrl + RssFile,
+				Rel:  "self",
+				Type: "application/rss+xml",
+			},
+			Items: items,
+		},
+	})
+}
+
+type atomFeed struct {
+	XMLName  xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Id       string      `xml:"id"`
+	Title    string      `xml:"title"`
+	Subtitle string      `xml:"subtitle,omitempty"`
+	Updated  string      `xml:"updated"`
+	Author   atomAuthor  `xml:"author"`
+	Links    []atomLink  `xml:"link"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomEntry struct {
+	Id         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Link       atomLink       `xml:"link"`
+	Published  string         `xml:"published"`
+	Updated    string         `xml:"updated"`
+	Summary    string         `xml:"summary,omitempty"`
+	Content    atomContent    `xml:"content"`
+	Categories []atomCategory `xml:"category"`
+}
+
+type atomContent struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+type atomCategory struct {
+	Term  string `xml:"term,attr"`
+	Label string `xml:"label,attr,omitempty"`
+}
+
+// Atom renders the feed as Atom 1.0.
+func (f Feed) Atom() ([]byte, error) {
+	entries := make([]atomEntry, len(f.Items))
+
+	for index, item := range f.Items {
+		categories := make([]atomCategory, len(item.Categories))
+		for i, category := range item.Categories {
+			categories[i] = atomCategory{Term: category.Slug, Label: category.Name}
+		}
+
+		entries[index] = atomEntry{
+			Id:         item.Url,
+			Title:      item.Title,
+			Link:       atomLink{Href: item.Url, Rel: "alternate", Type: "text/html"},
+			Published:  item.Published.UTC().Format(time.RFC3339),
+			Updated:    item.Updated.UTC().Format(time.RFC3339),
+			Summary:    item.Summary,
+			Content:    atomContent{Type: "html", Value: item.ContentHtml},
+			Categories: categories,
+		}
+	}
+
+	return marshalXml(atomFeed{
+		Id:       f.FeedUrl + AtomFile,
+		Title:    f.Title,
+		Subtitle: f.Description,
+		Updated:  f.Updated.UTC().Format(time.RFC3339),
+		// articles don't have authors of their own, so credit the site
+		Author: atomAuthor{Name: f.Title},
+		Links: []atomLink{
+			{Href: f.HomeUrl, Rel: "alternate", Type: "text/html"},
+			{Href: f.FeedUrl + AtomFile, Rel: "self", Type: "application/atom+xml"},
+		},
+		Entries: entries,
+	})
+}
+
+type jsonFeed struct {
+	Version     string     `json:"version"`
+	Title       string     `json:"title"`
+	HomePageUrl string     `json:"home_page_url"`
+	FeedUrl     string     `json:"feed_url"`
+	Description string     `json:"description,omitempty"`
+	Items       []jsonItem `json:"items"`
+}
+
+type jsonItem struct {
+	Id            string   `json:"id"`
+	Url           string   `json:"url"`
+	Title         string   `json:"title"`
+	Summary       string   `json:"summary,omitempty"`
+	ContentHtml   string   `json:"content_html"`
+	DatePublished string   `json:"date_published"`
+	DateModified  string   `json:"date_modified"`
+	Tags          []string `json:"tags,omitempty"`
+}
+
+// Json renders the feed as JSON Feed 1.1.
+func (f Feed) Json() ([]byte, error) {
+	items := make([]jsonItem, len(f.Items))
+
+	for index, item := range f.Items {
+		tags := make([]string, len(item.Categories))
+		for i, category := range item.Categories {
+			tags[i] = category.Name
+		}
+
+		items[index] = jsonItem{
+			Id:            item.Url,
+			Url:           item.Url,
+			Title:         item.Title,
+			Summary:       item.Summary,
+			ContentHtml:   item.ContentHtml,
+			DatePublished: item.Published.UTC().Format(time.RFC3339),
+			DateModified:  item.Updated.UTC().Format(time.RFC3339),
+			Tags:          tags,
+		}
+	}
+
+	return json.MarshalIndent(jsonFeed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       f.Title,
+		HomePageUrl: f.HomeUrl,
+		FeedUrl:     f.FeedUrl + JsonFile,
+		Description: f.Description,
+		Items:       items,
+	}, "", "  ")
+}
+
+func marshalXml(v any) ([]byte, error) {
+	b, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte(xml.Header), b...), nil
+}
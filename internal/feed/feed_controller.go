@@ -0,0 +1,89 @@
+package feed
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/nixpig/dunce/internal/app/errors"
+	"github.com/nixpig/dunce/internal/site"
+	"github.com/nixpig/dunce/pkg/logging"
+)
+
+type FeedController struct {
+	service       FeedService
+	log           logging.Logger
+	errorHandlers errors.ErrorHandlers
+	baseUrl       string
+}
+
+type FeedControllerConfig struct {
+	Log           logging.Logger
+	ErrorHandlers errors.ErrorHandlers
+	BaseUrl       string
+}
+
+func NewFeedController(service FeedService, config FeedControllerConfig) FeedController {
+	return FeedController{
+		service:       service,
+		log:           config.Log,
+		errorHandlers: config.ErrorHandlers,
+		baseUrl:       config.BaseUrl,
+	}
+}
+
+func (f *FeedController) RssHandler(w http.ResponseWriter, r *http.Request) {
+	f.render(w, r, RssContentType, Feed.Rss)
+}
+
+func (f *FeedController) AtomHandler(w http.ResponseWriter, r *http.Request) {
+	f.render(w, r, AtomContentType, Feed.Atom)
+}
+
+func (f *FeedController) JsonHandler(w http.ResponseWriter, r *http.Request) {
+	f.render(w, r, JsonContentType, Feed.Json)
+}
+
+// render writes the feed for the request, which is the tag's feed if the
+// route has a tag slug and the feed of all articles otherwise.
+func (f *FeedController) render(
+	w http.ResponseWriter,
+	r *http.Request,
+	contentType string,
+	encode func(Feed) ([]byte, error),
+) {
+	var feed *Feed
+	var err error
+
+	if slug := r.PathValue("slug"); slug != "" {
+		feed, err = f.service.GetByTag(slug, f.baseUrl)
+	} else {
+		feed, err = f.service.GetAll(f.baseUrl)
+	}
+
+	if err == ErrTagNotFound {
+		f.errorHandlers.NotFound(w, r)
+		return
+	}
+
+	if err == site.ErrNoUrl {
+		// feeds need absolute links, so there aren't any until there's a url
+		// to make them from
+		f.log.Warn(r.Context(), "unable to serve feed", "err", err)
+		f.errorHandlers.NotFound(w, r)
+		return
+	}
+
+	if err != nil {
+		f.errorHandlers.InternalServerError(w, r, fmt.Errorf("unable to get feed: %w", err))
+		return
+	}
+
+	b, err := encode(*feed)
+	if err != nil {
+		f.errorHandlers.InternalServerError(w, r, fmt.Errorf("unable to encode feed: %w", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Write(b)
+}
@@ -0,0 +1,5 @@
+package feed
+
+import "errors"
+
+var ErrTagNotFound = errors.New("tag not found")
@@ -0,0 +1,158 @@
+package feed
+
+import (
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/nixpig/dunce/internal/article"
+	"github.com/nixpig/dunce/internal/site"
+	"github.com/nixpig/dunce/internal/tag"
+	"github.com/nixpig/dunce/pkg/markdown"
+	"github.com/nixpig/dunce/pkg/pagination"
+)
+
+type FeedService interface {
+	GetAll(baseUrl string) (*Feed, error)
+	GetByTag(slug, baseUrl string) (*Feed, error)
+}
+
+type FeedServiceImpl struct {
+	articleService article.ArticleService
+	tagService     tag.TagService
+	siteService    site.SiteService
+}
+
+func NewFeedService(
+	articleService article.ArticleService,
+	tagService tag.TagService,
+	siteService site.SiteService,
+) FeedServiceImpl {
+	return FeedServiceImpl{
+		articleService: articleService,
+		tagService:     tagService,
+		siteService:    siteService,
+	}
+}
+
+// GetAll builds the feed of the most recently published articles. Links are
+// made absolute using the site domain, or baseUrl if the domain isn't set, and
+// site.ErrNoUrl is returned if there's neither.
+func (f FeedServiceImpl) GetAll(baseUrl string) (*Feed, error) {
+	s, err := f.siteService.Get()
+	if err != nil {
+		return nil, err
+	}
+
+	baseUrl = s.Url(baseUrl)
+	if baseUrl == "" {
+		return nil, site.ErrNoUrl
+	}
+
+	articles, err := f.articleService.GetManyByAttribute(
+		"status",
+		article.StatusPublished,
+		feedPage(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return newFeed(Feed{
+		Title:       s.Name,
+		Description: s.Tagline,
+		HomeUrl:     baseUrl + "/",
+		FeedUrl:     baseUrl,
+	}, baseUrl, articles.Items)
+}
+
+// GetByTag builds the feed of the most recently published articles with the
+// tag, or returns ErrTagNotFound if there's no such tag.
+func (f FeedServiceImpl) GetByTag(slug, baseUrl string) (*Feed, error) {
+	s, err := f.siteService.Get()
+	if err != nil {
+		return nil, err
+	}
+
+	baseUrl = s.Url(baseUrl)
+	if baseUrl == "" {
+		return nil, site.ErrNoUrl
+	}
+
+	t, err := f.tagService.GetByAttribute("slug", slug)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrTagNotFound
+		}
+
+		return nil, err
+	}
+
+	articles, err := f.articleService.GetManyByAttribute("tagSlug", slug, feedPage())
+	if err != nil {
+		return nil, err
+	}
+
+	title := t.Name
+	if s.Name != "" {
+		title = s.Name + " - " + t.Name
+	}
+
+	return newFeed(Feed{
+		Title:       title,
+		Description: s.Tagline,
+		HomeUrl:     baseUrl + "/tags/" + t.Slug,
+		FeedUrl:     baseUrl + "/tags/" + t.Slug,
+	}, baseUrl, articles.Items)
+}
+
+func newFeed(feed Feed, baseUrl string, articles []article.ArticleResponseDto) (*Feed, error) {
+	feed.Items = make([]Item, len(articles))
+
+	for index, a := range articles {
+		content, err := markdown.MdToHtml([]byte(a.Body))
+		if err != nil {
+			return nil, err
+		}
+
+		categories := make([]Category, len(a.Tags))
+		for i, t := range a.Tags {
+			categories[i] = Category{Name: t.Name, Slug: t.Slug}
+		}
+
+		published := a.CreatedAt
+		if a.PublishedAt != nil {
+			published = *a.PublishedAt
+		}
+
+		feed.Items[index] = Item{
+			Url:         baseUrl + "/articles/" + a.Slug,
+			Title:       a.Title,
+			Summary:     a.Subtitle,
+			ContentHtml: content,
+			Categories:  categories,
+			Published:   published,
+			Updated:     a.UpdatedAt,
+		}
+
+		if a.UpdatedAt.After(feed.Updated) {
+			feed.Updated = a.UpdatedAt
+		}
+	}
+
+	// a feed with nothing in it yet still needs a valid updated time
+	if feed.Updated.IsZero() {
+		feed.Updated = time.Now()
+	}
+
+	return &feed, nil
+}
+
+func feedPage() pagination.Request {
+	return pagination.Request{
+		Limit:     FeedSize,
+		Sort:      "published",
+		Direction: pagination.Desc,
+		Keyset:    true,
+	}
+}
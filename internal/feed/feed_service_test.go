@@ -0,0 +1,428 @@
+package feed
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/nixpig/dunce/internal/article"
+	"github.com/nixpig/dunce/internal/site"
+	"github.com/nixpig/dunce/internal/tag"
+	"github.com/nixpig/dunce/pkg/pagination"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var mockArticleService = new(MockArticleService)
+var mockTagService = new(MockTagService)
+var mockSiteService = new(MockSiteService)
+
+func TestFeedService(t *testing.T) {
+	scenarios := map[string]func(t *testing.T, service FeedService){
+		"test get feed":                      testFeedServiceGetAll,
+		"test get feed uses site domain":     testFeedServiceGetAllSiteDomain,
+		"test get feed (error - site)":       testFeedServiceGetAllSiteError,
+		"test get feed (error - articles)":   testFeedServiceGetAllArticlesError,
+		"test get tag feed":                  testFeedServiceGetByTag,
+		"test get tag feed (error - no tag)": testFeedServiceGetByTagNotFound,
+		"test get tag feed (error - tag)":    testFeedServiceGetByTagError,
+		"test get feed (error - no url)":     testFeedServiceGetAllNoUrl,
+		"test get empty feed":                testFeedServiceGetAllEmpty,
+	}
+
+	for scenario, fn := range scenarios {
+		t.Run(scenario, func(t *testing.T) {
+			service := NewFeedService(mockArticleService, mockTagService, mockSiteService)
+
+			fn(t, service)
+		})
+	}
+}
+
+func testFeedServiceGetAll(t *testing.T, service FeedService) {
+	createdAt := time.Date(2024, 4, 30, 0, 0, 0, 0, time.UTC)
+	publishedAt := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	updatedOne := time.Date(2024, 5, 3, 0, 0, 0, 0, time.UTC)
+	updatedTwo := time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC)
+
+	siteCall := mockSiteService.
+		On("Get").
+		Return(&site.Site{Name: "Site name", Tagline: "Site tagline"}, nil)
+
+	articlesCall := mockArticleService.
+		On("GetManyByAttribute", "status", article.StatusPublished, feedPage()).
+		Return(&pagination.Page[article.ArticleResponseDto]{
+			Items: []article.ArticleResponseDto{
+				{
+					Title:       "Article one",
+					Subtitle:    "Article one subtitle",
+					Slug:        "article-one",
+					Body:        "Article *one*",
+					PublishedAt: &publishedAt,
+					CreatedAt:   createdAt,
+					UpdatedAt:   updatedOne,
+					Tags:        []tag.Tag{{Id: 1, Name: "Go lang", Slug: "go-lang"}},
+				},
+				{
+					Title:     "Article two",
+					Subtitle:  "Article two subtitle",
+					Slug:      "article-two",
+					Body:      "Article two",
+					CreatedAt: createdAt,
+					UpdatedAt: updatedTwo,
+				},
+			},
+		}, nil)
+
+	got, err := service.GetAll("http://localhost:8080")
+
+	if res := mockArticleService.AssertExpectations(t); !res {
+		t.Error("unmet expectations")
+	}
+
+	require.NoError(t, err, "should not return error")
+	require.Equal(t, &Feed{
+		Title:       "Site name",
+		Description: "Site tagline",
+		HomeUrl:     "http://localhost:8080/",
+		FeedUrl:     "http://localhost:8080",
+		Updated:     updatedOne,
+		Items: []Item{
+			{
+				Url:         "http://localhost:8080/articles/article-one",
+				Title:       "Article one",
+				Summary:     "Article one subtitle",
+				ContentHtml: "<p>Article <em>one</em></p>\n",
+				Categories:  []Category{{Name: "Go lang", Slug: "go-lang"}},
+				Published:   publishedAt,
+				Updated:     updatedOne,
+			},
+			{
+				Url:         "http://localhost:8080/articles/article-two",
+				Title:       "Article two",
+				Summary:     "Article two subtitle",
+				ContentHtml: "<p>Article two</p>\n",
+				Categories:  []Category{},
+				Published:   createdAt,
+				Updated:     updatedTwo,
+			},
+		},
+	}, got, "should return feed of articles")
+
+	siteCall.Unset()
+	articlesCall.Unset()
+}
+
+func testFeedServiceGetAllSiteDomain(t *testing.T, service FeedService) {
+	siteCall := mockSiteService.
+		On("Get").
+		Return(&site.Site{Name: "Site name", Domain: "example.com"}, nil)
+
+	articlesCall := mockArticleService.
+		On("GetManyByAttribute", "status", article.StatusPublished, feedPage()).
+		Return(&pagination.Page[article.ArticleResponseDto]{
+			Items: []article.ArticleResponseDto{},
+		}, nil)
+
+	got, err := service.GetAll("http://localhost:8080")
+
+	require.NoError(t, err, "should not return error")
+	require.Equal(t, "https://example.com/", got.HomeUrl, "should link to site domain")
+	require.Equal(t, "https://example.com", got.FeedUrl, "should link to site domain")
+	require.Empty(t, got.Items, "should return no items")
+
+	siteCall.Unset()
+	articlesCall.Unset()
+}
+
+func testFeedServiceGetAllSiteError(t *testing.T, service FeedService) {
+	siteCall := mockSiteService.
+		On("Get").
+		Return((*site.Site)(nil), errors.New("site_error"))
+
+	got, err := service.GetAll("http://localhost:8080")
+
+	require.Nil(t, got, "should not return feed")
+	require.EqualError(t, err, "site_error", "should return site error")
+
+	siteCall.Unset()
+}
+
+func testFeedServiceGetAllArticlesError(t *testing.T, service FeedService) {
+	siteCall := mockSiteService.
+		On("Get").
+		Return(&site.Site{}, nil)
+
+	articlesCall := mockArticleService.
+		On("GetManyByAttribute", "status", article.StatusPublished, feedPage()).
+		Return((*pagination.Page[article.ArticleResponseDto])(nil), errors.New("articles_error"))
+
+	got, err := service.GetAll("http://localhost:8080")
+
+	require.Nil(t, got, "should not return feed")
+	require.EqualError(t, err, "articles_error", "should return articles error")
+
+	siteCall.Unset()
+	articlesCall.Unset()
+}
+
+func testFeedServiceGetByTag(t *testing.T, service FeedService) {
+	updatedAt := time.Date(2024, 5, 3, 0, 0, 0, 0, time.UTC)
+
+	siteCall := mockSiteService.
+		On("Get").
+		Return(&site.Site{Name: "Site name", Tagline: "Site tagline"}, nil)
+
+	tagCall := mockTagService.
+		On("GetByAttribute", "slug", "go-lang").
+		Return(&tag.TagResponseDto{Id: 1, Name: "Go lang", Slug: "go-lang"}, nil)
+
+	articlesCall := mockArticleService.
+		On("GetManyByAttribute", "tagSlug", "go-lang", feedPage()).
+		Return(&pagination.Page[article.ArticleResponseDto]{
+			Items: []article.ArticleResponseDto{
+				{
+					Title:     "Article one",
+					Slug:      "article-one",
+					Body:      "Article one",
+					CreatedAt: updatedAt,
+					UpdatedAt: updatedAt,
+					Tags:      []tag.Tag{{Id: 1, Name: "Go lang", Slug: "go-lang"}},
+				},
+			},
+		}, nil)
+
+	got, err := service.GetByTag("go-lang", "http://localhost:8080")
+
+	if res := mockTagService.AssertExpectations(t); !res {
+		t.Error("unmet expectations")
+	}
+
+	require.NoError(t, err, "should not return error")
+	require.Equal(t, "Site name - Go lang", got.Title, "should include tag in title")
+	require.Equal(t, "http://localhost:8080/tags/go-lang", got.HomeUrl, "should link to tag page")
+	require.Equal(t, "http://localhost:8080/tags/go-lang", got.FeedUrl, "should serve feed under tag")
+	require.Equal(t, updatedAt, got.Updated)
+	require.Len(t, got.Items, 1)
+
+	siteCall.Unset()
+	tagCall.Unset()
+	articlesCall.Unset()
+}
+
+func testFeedServiceGetByTagNotFound(t *testing.T, service FeedService) {
+	siteCall := mockSiteService.
+		On("Get").
+		Return(&site.Site{}, nil)
+
+	tagCall := mockTagService.
+		On("GetByAttribute", "slug", "missing").
+		Return((*tag.TagResponseDto)(nil), pgx.ErrNoRows)
+
+	got, err := service.GetByTag("missing", "http://localhost:8080")
+
+	require.Nil(t, got, "should not return feed")
+	require.Equal(t, ErrTagNotFound, err, "should return tag not found error")
+
+	siteCall.Unset()
+	tagCall.Unset()
+}
+
+func testFeedServiceGetByTagError(t *testing.T, service FeedService) {
+	siteCall := mockSiteService.
+		On("Get").
+		Return(&site.Site{}, nil)
+
+	tagCall := mockTagService.
+		On("GetByAttribute", "slug", "go-lang").
+		Return((*tag.TagResponseDto)(nil), errors.New("tag_error"))
+
+	got, err := service.GetByTag("go-lang", "http://localhost:8080")
+
+	require.Nil(t, got, "should not return feed")
+	require.EqualError(t, err, "tag_error", "should return tag error")
+
+	siteCall.Unset()
+	tagCall.Unset()
+}
+
+func testFeedServiceGetAllNoUrl(t *testing.T, service FeedService) {
+	siteCall := mockSiteService.
+		On("Get").
+		Return(&site.Site{Name: "Site name"}, nil)
+
+	// no expectation on the article service, so getting articles would fail
+
+	got, err := service.GetAll("")
+
+	require.Nil(t, got, "should not return feed")
+	require.ErrorIs(t, err, site.ErrNoUrl, "should refuse to build feed without a url")
+
+	siteCall.Unset()
+}
+
+func testFeedServiceGetAllEmpty(t *testing.T, service FeedService) {
+	siteCall := mockSiteService.
+		On("Get").
+		Return(&site.Site{Name: "Site name"}, nil)
+
+	articlesCall := mockArticleService.
+		On("GetManyByAttribute", "status", article.StatusPublished, feedPage()).
+		Return(&pagination.Page[article.ArticleResponseDto]{
+			Items: []article.ArticleResponseDto{},
+		}, nil)
+
+	got, err := service.GetAll("http://localhost:8080")
+
+	require.NoError(t, err, "should not return error")
+	require.Empty(t, got.Items, "should return no items")
+	require.WithinDuration(t, time.Now(), got.Updated, time.Minute, "should be updated now")
+
+	siteCall.Unset()
+	articlesCall.Unset()
+}
+
+type MockArticleService struct {
+	mock.Mock
+}
+
+func (m *MockArticleService) DeleteById(id int) error {
+	args := m.Called(id)
+
+	return args.Error(0)
+}
+
+func (m *MockArticleService) Create(a *article.ArticleNewRequestDto) (*article.ArticleResponseDto, error) {
+	args := m.Called(a)
+
+	return args.Get(0).(*article.ArticleResponseDto), args.Error(1)
+}
+
+func (m *MockArticleService) GetAll(page pagination.Request) (*pagination.Page[article.ArticleResponseDto], error) {
+	args := m.Called(page)
+
+	return args.Get(0).(*pagination.Page[article.ArticleResponseDto]), args.Error(1)
+}
+
+func (m *MockArticleService) GetManyByAttribute(attr, value string, page pagination.Request) (*pagination.Page[article.ArticleResponseDto], error) {
+	args := m.Called(attr, value, page)
+
+	return args.Get(0).(*pagination.Page[article.ArticleResponseDto]), args.Error(1)
+}
+
+func (m *MockArticleService) GetByAttribute(attr, value string) (*article.ArticleResponseDto, error) {
+	args := m.Called(attr, value)
+
+	return args.Get(0).(*article.ArticleResponseDto), args.Error(1)
+}
+
+func (m *MockArticleService) Update(a *article.ArticleUpdateRequestDto) (*article.ArticleResponseDto, error) {
+	args := m.Called(a)
+
+	return args.Get(0).(*article.ArticleResponseDto), args.Error(1)
+}
+
+func (m *MockArticleService) PublishScheduled(now time.Time) (*[]article.ArticleResponseDto, error) {
+	args := m.Called(now)
+
+	return args.Get(0).(*[]article.ArticleResponseDto), args.Error(1)
+}
+
+func (m *MockArticleService) GetRevisions(articleId int) (*[]article.ArticleRevisionResponseDto, error) {
+	args := m.Called(articleId)
+
+	return args.Get(0).(*[]article.ArticleRevisionResponseDto), args.Error(1)
+}
+
+func (m *MockArticleService) GetRevisionById(id int) (*article.ArticleRevisionResponseDto, error) {
+	args := m.Called(id)
+
+	return args.Get(0).(*article.ArticleRevisionResponseDto), args.Error(1)
+}
+
+func (m *MockArticleService) RestoreRevision(slug string, revisionId int) (*article.ArticleResponseDto, error) {
+	args := m.Called(slug, revisionId)
+
+	return args.Get(0).(*article.ArticleResponseDto), args.Error(1)
+}
+
+func (m *MockArticleService) Search(query string, page pagination.Request, statuses ...string) (*article.ArticleSearchResponseDto, error) {
+	args := m.Called(query, page, statuses)
+
+	return args.Get(0).(*article.ArticleSearchResponseDto), args.Error(1)
+}
+
+type MockTagService struct {
+	mock.Mock
+}
+
+func (m *MockTagService) Create(t *tag.TagNewRequestDto) (*tag.TagResponseDto, error) {
+	args := m.Called(t)
+
+	return args.Get(0).(*tag.TagResponseDto), args.Error(1)
+}
+
+func (m *MockTagService) DeleteById(id int) error {
+	args := m.Called(id)
+
+	return args.Error(0)
+}
+
+func (m *MockTagService) GetAll(page pagination.Request) (*pagination.Page[tag.TagResponseDto], error) {
+	args := m.Called(page)
+
+	return args.Get(0).(*pagination.Page[tag.TagResponseDto]), args.Error(1)
+}
+
+func (m *MockTagService) GetByAttribute(attr, value string) (*tag.TagResponseDto, error) {
+	args := m.Called(attr, value)
+
+	return args.Get(0).(*tag.TagResponseDto), args.Error(1)
+}
+
+func (m *MockTagService) Update(t *tag.TagUpdateRequestDto) (*tag.TagResponseDto, error) {
+	args := m.Called(t)
+
+	return args.Get(0).(*tag.TagResponseDto), args.Error(1)
+}
+
+type MockSiteService struct {
+	mock.Mock
+}
+
+func (m *MockSiteService) Create(key site.Key, value string) (*site.SiteItemResponseDto, error) {
+	args := m.Called(key, value)
+
+	return args.Get(0).(*site.SiteItemResponseDto), args.Error(1)
+}
+
+func (m *MockSiteService) Get() (*site.Site, error) {
+	args := m.Called()
+
+	return args.Get(0).(*site.Site), args.Error(1)
+}
+
+func (m *MockSiteService) GetAll() (*[]site.SettingResponseDto, error) {
+	args := m.Called()
+
+	return args.Get(0).(*[]site.SettingResponseDto), args.Error(1)
+}
+
+func (m *MockSiteService) GetByKey(key site.Key) (*site.SettingResponseDto, error) {
+	args := m.Called(key)
+
+	return args.Get(0).(*site.SettingResponseDto), args.Error(1)
+}
+
+func (m *MockSiteService) Update(values map[site.Key]string) error {
+	args := m.Called(values)
+
+	return args.Error(0)
+}
+
+func (m *MockSiteService) DeleteByKey(key site.Key) error {
+	args := m.Called(key)
+
+	return args.Error(0)
+}
@@ -0,0 +1,152 @@
+package feed
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var testUpdated = time.Date(2024, 5, 2, 10, 30, 0, 0, time.UTC)
+var testPublished = time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+
+var testFeed = Feed{
+	Title:       "Site name",
+	Description: "Site tagline",
+	HomeUrl:     "https://example.com/",
+	FeedUrl:     "https://example.com",
+	Updated:     testUpdated,
+	Items: []Item{
+		{
+			Url:         "https://example.com/articles/article-one",
+			Title:       "Article one",
+			Summary:     "Article one subtitle",
+			ContentHtml: "<p>Article <em>one</em></p>",
+			Categories: []Category{
+				{Name: "Go lang", Slug: "go-lang"},
+			},
+			Published: testPublished,
+			Updated:   testUpdated,
+		},
+	},
+}
+
+func TestFeed(t *testing.T) {
+	scenarios := map[string]func(t *testing.T){
+		"test render rss":  testFeedRss,
+		"test render atom": testFeedAtom,
+		"test render json": testFeedJson,
+	}
+
+	for scenario, fn := range scenarios {
+		t.Run(scenario, func(t *testing.T) {
+			fn(t)
+		})
+	}
+}
+
+func testFeedRss(t *testing.T) {
+	b, err := testFeed.Rss()
+	require.NoError(t, err, "should not return error")
+
+	var got struct {
+		Version string `xml:"version,attr"`
+		Channel struct {
+			Title         string `xml:"title"`
+			Description   string `xml:"description"`
+			LastBuildDate string `xml:"lastBuildDate"`
+			Items         []struct {
+				Title      string   `xml:"title"`
+				Link       string   `xml:"link"`
+				Guid       string   `xml:"guid"`
+				Content    string   `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+				Categories []string `xml:"category"`
+				PubDate    string   `xml:"pubDate"`
+			} `xml:"item"`
+		} `xml:"channel"`
+	}
+
+	require.NoError(t, xml.Unmarshal(b, &got), "should be valid xml")
+
+	require.Equal(t, "2.0", got.Version)
+	require.Equal(t, "Site name", got.Channel.Title)
+	require.Contains(t, string(b), "<link>https://example.com/</link>", "should link to site")
+	require.Contains(t, string(b), `<atom:link href="https://example.com/feed.xml" rel="self"`, "should link to self")
+	require.Equal(t, "Site tagline", got.Channel.Description)
+	require.Equal(t, "Thu, 02 May 2024 10:30:00 +0000", got.Channel.LastBuildDate)
+	require.Len(t, got.Channel.Items, 1)
+	require.Equal(t, "Article one", got.Channel.Items[0].Title)
+	require.Equal(t, "https://example.com/articles/article-one", got.Channel.Items[0].Guid)
+	require.Equal(t, "<p>Article <em>one</em></p>", got.Channel.Items[0].Content, "should contain full html")
+	require.Equal(t, []string{"Go lang"}, got.Channel.Items[0].Categories, "should include tags as categories")
+	require.Equal(t, "Wed, 01 May 2024 09:00:00 +0000", got.Channel.Items[0].PubDate)
+}
+
+func testFeedAtom(t *testing.T) {
+	b, err := testFeed.Atom()
+	require.NoError(t, err, "should not return error")
+
+	var got struct {
+		XMLName xml.Name `xml:"http://www.w3.org/2005/Atom feed"`
+		Id      string   `xml:"id"`
+		Title   string   `xml:"title"`
+		Updated string   `xml:"updated"`
+		Entries []struct {
+			Id        string `xml:"id"`
+			Published string `xml:"published"`
+			Updated   string `xml:"updated"`
+			Content   struct {
+				Type  string `xml:"type,attr"`
+				Value string `xml:",chardata"`
+			} `xml:"content"`
+			Categories []struct {
+				Term  string `xml:"term,attr"`
+				Label string `xml:"label,attr"`
+			} `xml:"category"`
+		} `xml:"entry"`
+	}
+
+	require.NoError(t, xml.Unmarshal(b, &got), "should be valid atom")
+
+	require.Equal(t, "https://example.com/atom.xml", got.Id)
+	require.Equal(t, "Site name", got.Title)
+	require.Equal(t, "2024-05-02T10:30:00Z", got.Updated)
+	require.Len(t, got.Entries, 1)
+	require.Equal(t, "2024-05-01T09:00:00Z", got.Entries[0].Published)
+	require.Equal(t, "2024-05-02T10:30:00Z", got.Entries[0].Updated)
+	require.Equal(t, "html", got.Entries[0].Content.Type)
+	require.Equal(t, "<p>Article <em>one</em></p>", got.Entries[0].Content.Value)
+	require.Equal(t, "go-lang", got.Entries[0].Categories[0].Term)
+	require.Equal(t, "Go lang", got.Entries[0].Categories[0].Label)
+}
+
+func testFeedJson(t *testing.T) {
+	b, err := testFeed.Json()
+	require.NoError(t, err, "should not return error")
+
+	var got map[string]any
+
+	require.NoError(t, json.Unmarshal(b, &got), "should be valid json")
+
+	require.Equal(t, map[string]any{
+		"version":       "https://jsonfeed.org/version/1.1",
+		"title":         "Site name",
+		"home_page_url": "https://example.com/",
+		"feed_url":      "https://example.com/feed.json",
+		"description":   "Site tagline",
+		"items": []any{
+			map[string]any{
+				"id":             "https://example.com/articles/article-one",
+				"url":            "https://example.com/articles/article-one",
+				"title":          "Article one",
+				"summary":        "Article one subtitle",
+				"content_html":   "<p>Article <em>one</em></p>",
+				"date_published": "2024-05-01T09:00:00Z",
+				"date_modified":  "2024-05-02T10:30:00Z",
+				"tags":           []any{"Go lang"},
+			},
+		},
+	}, got, "should render json feed")
+}
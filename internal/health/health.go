@@ -0,0 +1,47 @@
+package health
+
+import (
+	"runtime/debug"
+)
+
+// BuildInfo is what the running binary was built from. BuildTime is the time
+// of the commit it was built from, which is as close to when it was built as
+// the Go toolchain records.
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Revision  string `json:"revision"`
+	BuildTime string `json:"build_time"`
+	Modified  bool   `json:"modified"`
+	GoVersion string `json:"go_version"`
+}
+
+// ReadBuildInfo reads the build info embedded in the binary. Anything that
+// wasn't recorded, e.g. the revision when built outside of a repo, is empty.
+func ReadBuildInfo() BuildInfo {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return BuildInfo{}
+	}
+
+	return newBuildInfo(info)
+}
+
+func newBuildInfo(info *debug.BuildInfo) BuildInfo {
+	buildInfo := BuildInfo{
+		Version:   info.Main.Version,
+		GoVersion: info.GoVersion,
+	}
+
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			buildInfo.Revision = setting.Value
+		case "vcs.time":
+			buildInfo.BuildTime = setting.Value
+		case "vcs.modified":
+			buildInfo.Modified = setting.Value == "true"
+		}
+	}
+
+	return buildInfo
+}
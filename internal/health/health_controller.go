@@ -0,0 +1,142 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/nixpig/dunce/pkg/logging"
+	"github.com/nixpig/dunce/pkg/templates"
+)
+
+// readyTimeout is the longest the readiness checks can take, so a probe gets
+// an answer before it gives up on the database.
+const readyTimeout = 2 * time.Second
+
+const (
+	StatusOk          = "ok"
+	StatusUnavailable = "unavailable"
+)
+
+type HealthController struct {
+	repo             HealthRepository
+	log              logging.Logger
+	templateCache    templates.TemplateCache
+	migrationVersion uint
+	buildInfo        BuildInfo
+}
+
+// HealthControllerConfig is what's needed to check the app is ready.
+// MigrationVersion is the version the database should be migrated to.
+type HealthControllerConfig struct {
+	Log              logging.Logger
+	TemplateCache    templates.TemplateCache
+	MigrationVersion uint
+	BuildInfo        BuildInfo
+}
+
+type HealthResponse struct {
+	Status string `json:"status"`
+}
+
+// ReadyResponse is the result of each readiness check, which is "ok" or why
+// it failed.
+type ReadyResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+func NewHealthController(repo HealthRepository, config HealthControllerConfig) HealthController {
+	return HealthController{
+		repo:             repo,
+		log:              config.Log,
+		templateCache:    config.TemplateCache,
+		migrationVersion: config.MigrationVersion,
+		buildInfo:        config.BuildInfo,
+	}
+}
+
+// HealthzGet reports that the process is up and serving requests.
+func (h HealthController) HealthzGet(w http.ResponseWriter, r *http.Request) {
+	h.writeJson(w, r, http.StatusOK, HealthResponse{Status: StatusOk})
+}
+
+// ReadyzGet reports whether the app can serve requests properly, i.e. the
+// database is reachable and migrated, and the templates are loaded.
+func (h HealthController) ReadyzGet(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), readyTimeout)
+	defer cancel()
+
+	response := ReadyResponse{
+		Status: StatusOk,
+		Checks: map[string]string{
+			"database":   h.checkDatabase(ctx),
+			"migrations": h.checkMigrations(ctx),
+			"templates":  h.checkTemplates(),
+		},
+	}
+
+	status := http.StatusOK
+
+	for _, check := range response.Checks {
+		if check != StatusOk {
+			response.Status = StatusUnavailable
+			status = http.StatusServiceUnavailable
+		}
+	}
+
+	h.writeJson(w, r, status, response)
+}
+
+// VersionGet reports what the running binary was built from.
+func (h HealthController) VersionGet(w http.ResponseWriter, r *http.Request) {
+	h.writeJson(w, r, http.StatusOK, h.buildInfo)
+}
+
+func (h HealthController) checkDatabase(ctx context.Context) string {
+	if err := h.repo.Ping(ctx); err != nil {
+		h.log.Error(ctx, "readiness check failed to ping database", "err", err)
+		return "unable to reach database"
+	}
+
+	return StatusOk
+}
+
+func (h HealthController) checkMigrations(ctx context.Context) string {
+	version, dirty, err := h.repo.MigrationVersion(ctx)
+	if err != nil {
+		h.log.Error(ctx, "readiness check failed to get migration version", "err", err)
+		return "unable to get migration version"
+	}
+
+	if dirty {
+		return fmt.Sprintf("migration %d failed", version)
+	}
+
+	if version != h.migrationVersion {
+		return fmt.Sprintf("at migration %d, expected %d", version, h.migrationVersion)
+	}
+
+	return StatusOk
+}
+
+func (h HealthController) checkTemplates() string {
+	if len(h.templateCache) == 0 {
+		return "no templates loaded"
+	}
+
+	return StatusOk
+}
+
+func (h HealthController) writeJson(w http.ResponseWriter, r *http.Request, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	// probes should always see the current state
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		h.log.Error(r.Context(), "unable to write health response", "err", err)
+	}
+}
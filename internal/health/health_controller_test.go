@@ -0,0 +1,230 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nixpig/dunce/pkg/templates"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var mockRepo = new(MockHealthRepository)
+var mockLogger = new(MockLogger)
+
+var errTest = errors.New("db_error")
+
+type MockHealthRepository struct {
+	mock.Mock
+}
+
+func (m *MockHealthRepository) Ping(ctx context.Context) error {
+	args := m.Called(ctx)
+
+	return args.Error(0)
+}
+
+func (m *MockHealthRepository) MigrationVersion(ctx context.Context) (uint, bool, error) {
+	args := m.Called(ctx)
+
+	return args.Get(0).(uint), args.Bool(1), args.Error(2)
+}
+
+type MockLogger struct {
+	mock.Mock
+}
+
+func (l *MockLogger) Debug(ctx context.Context, msg string, args ...any) {
+	l.Called(msg, args)
+}
+
+func (l *MockLogger) Info(ctx context.Context, msg string, args ...any) {
+	l.Called(msg, args)
+}
+
+func (l *MockLogger) Warn(ctx context.Context, msg string, args ...any) {
+	l.Called(msg, args)
+}
+
+func (l *MockLogger) Error(ctx context.Context, msg string, args ...any) {
+	l.Called(msg, args)
+}
+
+type MockTemplate struct{}
+
+func (m MockTemplate) ExecuteTemplate(wr io.Writer, name string, data any) error {
+	return nil
+}
+
+var testTemplateCache = templates.TemplateCache{
+	"pages/public/home.tmpl": MockTemplate{},
+}
+
+var testBuildInfo = BuildInfo{
+	Version:   "v1.2.3",
+	Revision:  "4b825dc642cb6eb9a060e54bf8d69288fbee4904",
+	BuildTime: "2024-05-02T10:30:00Z",
+	GoVersion: "go1.22.1",
+}
+
+func TestHealthController(t *testing.T) {
+	scenarios := map[string]func(t *testing.T, templateCache templates.TemplateCache){
+		"healthz":                       testHealthzGet,
+		"readyz (success)":              testReadyzGet,
+		"readyz (error - database)":     testReadyzGetDatabaseError,
+		"readyz (error - behind)":       testReadyzGetMigrationBehind,
+		"readyz (error - dirty)":        testReadyzGetMigrationDirty,
+		"readyz (error - no templates)": testReadyzGetNoTemplates,
+		"version":                       testVersionGet,
+	}
+
+	for scenario, fn := range scenarios {
+		t.Run(scenario, func(t *testing.T) {
+			fn(t, testTemplateCache)
+		})
+	}
+}
+
+func newController(templateCache templates.TemplateCache) HealthController {
+	return NewHealthController(mockRepo, HealthControllerConfig{
+		Log:              mockLogger,
+		TemplateCache:    templateCache,
+		MigrationVersion: 17,
+		BuildInfo:        testBuildInfo,
+	})
+}
+
+func readyz(t *testing.T, templateCache templates.TemplateCache) (*httptest.ResponseRecorder, ReadyResponse) {
+	req := httptest.NewRequest("GET", "/readyz", nil)
+	rr := httptest.NewRecorder()
+
+	newController(templateCache).ReadyzGet(rr, req)
+
+	var response ReadyResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+
+	return rr, response
+}
+
+func testHealthzGet(t *testing.T, templateCache templates.TemplateCache) {
+	req := httptest.NewRequest("GET", "/healthz", nil)
+	rr := httptest.NewRecorder()
+
+	newController(templateCache).HealthzGet(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+	require.Equal(t, "no-store", rr.Header().Get("Cache-Control"))
+	require.JSONEq(t, `{"status":"ok"}`, rr.Body.String())
+}
+
+func testReadyzGet(t *testing.T, templateCache templates.TemplateCache) {
+	ping := mockRepo.On("Ping", mock.Anything).Return(nil)
+	version := mockRepo.On("MigrationVersion", mock.Anything).Return(uint(17), false, nil)
+
+	rr, response := readyz(t, templateCache)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Equal(t, ReadyResponse{
+		Status: "ok",
+		Checks: map[string]string{
+			"database":   "ok",
+			"migrations": "ok",
+			"templates":  "ok",
+		},
+	}, response)
+
+	mockRepo.AssertExpectations(t)
+
+	ping.Unset()
+	version.Unset()
+}
+
+func testReadyzGetDatabaseError(t *testing.T, templateCache templates.TemplateCache) {
+	ping := mockRepo.On("Ping", mock.Anything).Return(errTest)
+	version := mockRepo.On("MigrationVersion", mock.Anything).Return(uint(0), false, errTest)
+	pingLog := mockLogger.On("Error", "readiness check failed to ping database", []any{"err", errTest})
+	versionLog := mockLogger.On("Error", "readiness check failed to get migration version", []any{"err", errTest})
+
+	rr, response := readyz(t, templateCache)
+
+	require.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	require.Equal(t, ReadyResponse{
+		Status: "unavailable",
+		Checks: map[string]string{
+			"database":   "unable to reach database",
+			"migrations": "unable to get migration version",
+			"templates":  "ok",
+		},
+	}, response)
+
+	mockRepo.AssertExpectations(t)
+	mockLogger.AssertExpectations(t)
+
+	ping.Unset()
+	version.Unset()
+	pingLog.Unset()
+	versionLog.Unset()
+}
+
+func testReadyzGetMigrationBehind(t *testing.T, templateCache templates.TemplateCache) {
+	ping := mockRepo.On("Ping", mock.Anything).Return(nil)
+	version := mockRepo.On("MigrationVersion", mock.Anything).Return(uint(16), false, nil)
+
+	rr, response := readyz(t, templateCache)
+
+	require.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	require.Equal(t, "unavailable", response.Status)
+	require.Equal(t, "at migration 16, expected 17", response.Checks["migrations"])
+
+	ping.Unset()
+	version.Unset()
+}
+
+func testReadyzGetMigrationDirty(t *testing.T, templateCache templates.TemplateCache) {
+	ping := mockRepo.On("Ping", mock.Anything).Return(nil)
+	version := mockRepo.On("MigrationVersion", mock.Anything).Return(uint(17), true, nil)
+
+	rr, response := readyz(t, templateCache)
+
+	require.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	require.Equal(t, "migration 17 failed", response.Checks["migrations"])
+
+	ping.Unset()
+	version.Unset()
+}
+
+func testReadyzGetNoTemplates(t *testing.T, templateCache templates.TemplateCache) {
+	ping := mockRepo.On("Ping", mock.Anything).Return(nil)
+	version := mockRepo.On("MigrationVersion", mock.Anything).Return(uint(17), false, nil)
+
+	rr, response := readyz(t, templates.TemplateCache{})
+
+	require.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	require.Equal(t, "no templates loaded", response.Checks["templates"])
+	require.Equal(t, "ok", response.Checks["database"])
+
+	ping.Unset()
+	version.Unset()
+}
+
+func testVersionGet(t *testing.T, templateCache templates.TemplateCache) {
+	req := httptest.NewRequest("GET", "/version", nil)
+	rr := httptest.NewRecorder()
+
+	newController(templateCache).VersionGet(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.JSONEq(t, `{
+		"version": "v1.2.3",
+		"revision": "4b825dc642cb6eb9a060e54bf8d69288fbee4904",
+		"build_time": "2024-05-02T10:30:00Z",
+		"modified": false,
+		"go_version": "go1.22.1"
+	}`, rr.Body.String())
+}
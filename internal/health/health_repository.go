@@ -0,0 +1,41 @@
+package health
+
+import (
+	"context"
+
+	"github.com/nixpig/dunce/db"
+)
+
+type HealthRepository interface {
+	Ping(ctx context.Context) error
+	MigrationVersion(ctx context.Context) (uint, bool, error)
+}
+
+type healthPostgresRepository struct {
+	db db.Dbconn
+}
+
+func NewHealthPostgresRepository(db db.Dbconn) healthPostgresRepository {
+	return healthPostgresRepository{
+		db: db,
+	}
+}
+
+func (h healthPostgresRepository) Ping(ctx context.Context) error {
+	return h.db.Ping(ctx)
+}
+
+// MigrationVersion is the version the database has been migrated to, and
+// whether the migration to it failed partway through, leaving it dirty.
+func (h healthPostgresRepository) MigrationVersion(ctx context.Context) (uint, bool, error) {
+	query := `select version, dirty from schema_migrations limit 1`
+
+	var version int64
+	var dirty bool
+
+	if err := h.db.QueryRow(ctx, query).Scan(&version, &dirty); err != nil {
+		return 0, false, err
+	}
+
+	return uint(version), dirty, nil
+}
@@ -0,0 +1,88 @@
+package health
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/pashagolub/pgxmock/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthRepo(t *testing.T) {
+	scenarios := map[string]func(t *testing.T, mock pgxmock.PgxPoolIface, repo HealthRepository){
+		"ping (success)":                       testHealthRepoPing,
+		"ping (error - db error)":              testHealthRepoPingError,
+		"migration version (success)":          testHealthRepoMigrationVersion,
+		"migration version (error - db error)": testHealthRepoMigrationVersionError,
+	}
+
+	for scenario, fn := range scenarios {
+		t.Run(scenario, func(t *testing.T) {
+			db, err := pgxmock.NewPool()
+			if err != nil {
+				t.Fatal("unable to create mock db pool")
+			}
+
+			defer db.Close()
+
+			repo := NewHealthPostgresRepository(db)
+
+			fn(t, db, repo)
+		})
+	}
+}
+
+func testHealthRepoPing(t *testing.T, mock pgxmock.PgxPoolIface, repo HealthRepository) {
+	mock.ExpectPing()
+
+	require.NoError(t, repo.Ping(context.Background()), "should not return error")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal("expectations not met: ", err)
+	}
+}
+
+func testHealthRepoPingError(t *testing.T, mock pgxmock.PgxPoolIface, repo HealthRepository) {
+	mock.ExpectPing().WillReturnError(errTest)
+
+	require.Equal(t, errTest, repo.Ping(context.Background()), "should return error")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal("expectations not met: ", err)
+	}
+}
+
+func testHealthRepoMigrationVersion(t *testing.T, mock pgxmock.PgxPoolIface, repo HealthRepository) {
+	query := `select version, dirty from schema_migrations limit 1`
+
+	mockRows := mock.NewRows([]string{"version", "dirty"}).AddRow(int64(17), false)
+
+	mock.ExpectQuery(regexp.QuoteMeta(query)).WillReturnRows(mockRows)
+
+	version, dirty, err := repo.MigrationVersion(context.Background())
+
+	require.NoError(t, err, "should not return error")
+	require.Equal(t, uint(17), version, "should return version")
+	require.False(t, dirty, "should not be dirty")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal("expectations not met: ", err)
+	}
+}
+
+func testHealthRepoMigrationVersionError(t *testing.T, mock pgxmock.PgxPoolIface, repo HealthRepository) {
+	query := `select version, dirty from schema_migrations limit 1`
+
+	mock.ExpectQuery(regexp.QuoteMeta(query)).WillReturnError(errTest)
+
+	version, dirty, err := repo.MigrationVersion(context.Background())
+
+	require.Equal(t, errTest, err, "should return error")
+	require.Zero(t, version, "should not return version")
+	require.False(t, dirty, "should not be dirty")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal("expectations not met: ", err)
+	}
+}
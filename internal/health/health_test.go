@@ -0,0 +1,42 @@
+package health
+
+import (
+	"runtime/debug"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewBuildInfo(t *testing.T) {
+	info := &debug.BuildInfo{
+		GoVersion: "go1.22.1",
+		Main:      debug.Module{Path: "github.com/nixpig/dunce", Version: "v1.2.3"},
+		Settings: []debug.BuildSetting{
+			{Key: "-compiler", Value: "gc"},
+			{Key: "vcs", Value: "git"},
+			{Key: "vcs.revision", Value: "4b825dc642cb6eb9a060e54bf8d69288fbee4904"},
+			{Key: "vcs.time", Value: "2024-05-02T10:30:00Z"},
+			{Key: "vcs.modified", Value: "true"},
+		},
+	}
+
+	require.Equal(t, BuildInfo{
+		Version:   "v1.2.3",
+		Revision:  "4b825dc642cb6eb9a060e54bf8d69288fbee4904",
+		BuildTime: "2024-05-02T10:30:00Z",
+		Modified:  true,
+		GoVersion: "go1.22.1",
+	}, newBuildInfo(info))
+}
+
+func TestNewBuildInfoWithoutVcs(t *testing.T) {
+	info := &debug.BuildInfo{
+		GoVersion: "go1.22.1",
+		Main:      debug.Module{Path: "github.com/nixpig/dunce", Version: "(devel)"},
+	}
+
+	require.Equal(t, BuildInfo{
+		Version:   "(devel)",
+		GoVersion: "go1.22.1",
+	}, newBuildInfo(info))
+}
@@ -1,12 +1,16 @@
 package home
 
 import (
+	"html/template"
 	"net/http"
 
 	"github.com/nixpig/dunce/internal/app/errors"
 	"github.com/nixpig/dunce/internal/article"
 	"github.com/nixpig/dunce/internal/tag"
+	"github.com/nixpig/dunce/internal/user"
 	"github.com/nixpig/dunce/pkg/logging"
+	"github.com/nixpig/dunce/pkg/markdown"
+	"github.com/nixpig/dunce/pkg/pagination"
 	"github.com/nixpig/dunce/pkg/session"
 	"github.com/nixpig/dunce/pkg/templates"
 )
@@ -14,6 +18,7 @@ import (
 type HomeController struct {
 	tagService     tag.TagService
 	articleService article.ArticleService
+	userService    user.UserService
 	log            logging.Logger
 	templateCache  templates.TemplateCache
 	sessionManager session.SessionManager
@@ -29,23 +34,44 @@ type HomeControllerConfig struct {
 }
 
 type HomeView struct {
+	Path     string
 	Tags     *[]tag.TagResponseDto
-	Articles *[]article.ArticleResponseDto
+	Articles *pagination.Page[article.ArticleResponseDto]
+}
+
+type ArticlesView struct {
+	Path     string
+	Articles *pagination.Page[article.ArticleResponseDto]
+}
+
+type TagsView struct {
+	Path string
+	Tags *pagination.Page[tag.TagResponseDto]
 }
 
 type TagView struct {
+	Path     string
 	Tag      *tag.TagResponseDto
-	Articles *[]article.ArticleResponseDto
+	Articles *pagination.Page[article.ArticleResponseDto]
+}
+
+type AuthorView struct {
+	Path     string
+	Author   *user.UserResponseDto
+	Bio      template.HTML
+	Articles *pagination.Page[article.ArticleResponseDto]
 }
 
 func NewHomeController(
 	tagService tag.TagService,
 	articleService article.ArticleService,
+	userService user.UserService,
 	config HomeControllerConfig,
 ) HomeController {
 	return HomeController{
 		tagService:     tagService,
 		articleService: articleService,
+		userService:    userService,
 		log:            config.Log,
 		templateCache:  config.TemplateCache,
 		sessionManager: config.SessionManager,
@@ -54,23 +80,30 @@ func NewHomeController(
 }
 
 func (h *HomeController) HomeGet(w http.ResponseWriter, r *http.Request) {
-	articles, err := h.articleService.GetAll()
+	articles, err := h.articleService.GetManyByAttribute(
+		"status",
+		article.StatusPublished,
+		publicPage(r),
+	)
 	if err != nil {
-		h.errorHandlers.InternalServerError(w, r)
+		h.errorHandlers.InternalServerError(w, r, err)
 		return
 	}
 
-	tags, err := h.tagService.GetAll()
+	tags, err := h.tagService.GetAll(
+		pagination.Request{Limit: pagination.Unlimited},
+	)
 	if err != nil {
-		h.errorHandlers.InternalServerError(w, r)
+		h.errorHandlers.InternalServerError(w, r, err)
 		return
 	}
 
 	if err := h.templateCache["pages/public/index.tmpl"].ExecuteTemplate(w, "public", HomeView{
+		Path:     r.URL.Path,
 		Articles: articles,
-		Tags:     tags,
+		Tags:     &tags.Items,
 	}); err != nil {
-		h.errorHandlers.InternalServerError(w, r)
+		h.errorHandlers.InternalServerError(w, r, err)
 		return
 	}
 }
@@ -79,15 +112,37 @@ func (h *HomeController) HomeArticlesGet(
 	w http.ResponseWriter,
 	r *http.Request,
 ) {
-	if err := h.templateCache["pages/public/articles.tmpl"].ExecuteTemplate(w, "public", HomeView{}); err != nil {
-		h.errorHandlers.InternalServerError(w, r)
+	articles, err := h.articleService.GetManyByAttribute(
+		"status",
+		article.StatusPublished,
+		publicPage(r),
+	)
+	if err != nil {
+		h.errorHandlers.InternalServerError(w, r, err)
+		return
+	}
+
+	if err := h.templateCache["pages/public/articles.tmpl"].ExecuteTemplate(w, "public", ArticlesView{
+		Path:     r.URL.Path,
+		Articles: articles,
+	}); err != nil {
+		h.errorHandlers.InternalServerError(w, r, err)
 		return
 	}
 }
 
 func (h *HomeController) HomeTagsGet(w http.ResponseWriter, r *http.Request) {
-	if err := h.templateCache["pages/public/tags.tmpl"].ExecuteTemplate(w, "public", HomeView{}); err != nil {
-		h.errorHandlers.InternalServerError(w, r)
+	tags, err := h.tagService.GetAll(publicPage(r))
+	if err != nil {
+		h.errorHandlers.InternalServerError(w, r, err)
+		return
+	}
+
+	if err := h.templateCache["pages/public/tags.tmpl"].ExecuteTemplate(w, "public", TagsView{
+		Path: r.URL.Path,
+		Tags: tags,
+	}); err != nil {
+		h.errorHandlers.InternalServerError(w, r, err)
 		return
 	}
 }
@@ -101,17 +156,67 @@ func (h *HomeController) HomeTagGet(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	articles, err := h.articleService.GetManyByAttribute("tagSlug", slug)
+	articles, err := h.articleService.GetManyByAttribute("tagSlug", slug, publicPage(r))
 	if err != nil {
 		h.errorHandlers.NotFound(w, r)
 		return
 	}
 
 	if err := h.templateCache["pages/public/tag.tmpl"].ExecuteTemplate(w, "public", TagView{
+		Path:     r.URL.Path,
 		Tag:      tag,
 		Articles: articles,
 	}); err != nil {
-		h.errorHandlers.InternalServerError(w, r)
+		h.errorHandlers.InternalServerError(w, r, err)
+		return
+	}
+}
+
+func (h *HomeController) HomeAuthorGet(w http.ResponseWriter, r *http.Request) {
+	username := r.PathValue("username")
+
+	author, err := h.userService.GetByAttribute("username", username)
+	if err != nil {
+		h.errorHandlers.NotFound(w, r)
+		return
+	}
+
+	// only users who can write articles get a public page, so usernames of
+	// viewers aren't leaked
+	if !author.Role.Can(user.PermissionWriteArticles) {
+		h.errorHandlers.NotFound(w, r)
+		return
+	}
+
+	bio, err := markdown.MdToHtml([]byte(author.Profile.Bio))
+	if err != nil {
+		h.errorHandlers.InternalServerError(w, r, err)
+		return
+	}
+
+	articles, err := h.articleService.GetManyByAttribute("authorUsername", username, publicPage(r))
+	if err != nil {
+		h.errorHandlers.InternalServerError(w, r, err)
+		return
+	}
+
+	if err := h.templateCache["pages/public/author.tmpl"].ExecuteTemplate(w, "public", AuthorView{
+		Path:     r.URL.Path,
+		Author:   author,
+		Bio:      template.HTML(bio),
+		Articles: articles,
+	}); err != nil {
+		h.errorHandlers.InternalServerError(w, r, err)
 		return
 	}
 }
+
+// public lists are paged by cursor, so links stay stable as new articles are
+// published, and deep pages of the archive don't need to scan past everything
+// before them
+func publicPage(r *http.Request) pagination.Request {
+	page := pagination.FromQuery(r.URL.Query())
+	page.Keyset = true
+
+	return page
+}
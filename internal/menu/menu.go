@@ -0,0 +1,113 @@
+package menu
+
+import (
+	"html/template"
+	"strings"
+)
+
+// Kind is what a menu item points at, which decides how its target becomes
+// a link.
+type Kind string
+
+const (
+	KindArticle Kind = "article"
+	KindTag     Kind = "tag"
+	KindPage    Kind = "page"
+	KindUrl     Kind = "url"
+)
+
+// Kinds are all the kinds of menu item, in the order they're offered.
+var Kinds = []KindOption{
+	{Kind: KindArticle, Label: "Article"},
+	{Kind: KindTag, Label: "Tag"},
+	{Kind: KindPage, Label: "Page"},
+	{Kind: KindUrl, Label: "URL"},
+}
+
+type KindOption struct {
+	Kind  Kind
+	Label string
+}
+
+// Direction is which way a menu item is moved when reordering.
+type Direction string
+
+const (
+	Up   Direction = "up"
+	Down Direction = "down"
+)
+
+type MenuItem struct {
+	Id       int    `validate:"omitempty"`
+	Label    string `validate:"required,max=50"`
+	Kind     Kind   `validate:"required,oneof=article tag page url"`
+	Target   string `validate:"required,max=255"`
+	Position int    `validate:"omitempty"`
+}
+
+type MenuItemNewRequestDto struct {
+	Label  string `validate:"required,max=50"`
+	Kind   Kind   `validate:"required,oneof=article tag page url"`
+	Target string `validate:"required,max=255"`
+}
+
+type MenuItemResponseDto struct {
+	Id       int
+	Label    string
+	Kind     Kind
+	Target   string
+	Position int
+}
+
+// Url is where the item links to. Articles and tags are linked by their slug,
+// pages by their path; URLs are used as they are.
+func (m MenuItemResponseDto) Url() string {
+	switch m.Kind {
+	case KindArticle:
+		return "/articles/" + m.Target
+	case KindTag:
+		return "/tags/" + m.Target
+	case KindPage:
+		return "/" + m.Target
+	default:
+		return m.Target
+	}
+}
+
+// IsActive reports whether the item links to the page at path, or to a page
+// above it, so 'Articles' stays active while reading an article. Home is
+// only active on the home page, and external links are never active.
+func (m MenuItemResponseDto) IsActive(path string) bool {
+	url := m.Url()
+
+	if !strings.HasPrefix(url, "/") || strings.HasPrefix(url, "//") {
+		return false
+	}
+
+	url, _, _ = strings.Cut(url, "#")
+	url, _, _ = strings.Cut(url, "?")
+
+	if url == "/" {
+		return path == "/"
+	}
+
+	url = strings.TrimSuffix(url, "/")
+
+	return path == url || strings.HasPrefix(path, url+"/")
+}
+
+// TemplateFuncs gives templates the menu through a menu function, so the
+// public base template can render it on every page. No menu is shown if it
+// can't be read, rather than failing the whole page.
+func TemplateFuncs(service MenuService) template.FuncMap {
+	return template.FuncMap{
+		"menu": func() []MenuItemResponseDto {
+			items, err := service.GetAll()
+			if err != nil {
+				return nil
+			}
+
+			return *items
+		},
+	}
+}
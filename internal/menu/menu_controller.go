@@ -0,0 +1,164 @@
+package menu
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/nixpig/dunce/internal/app/errors"
+	"github.com/nixpig/dunce/pkg/logging"
+	"github.com/nixpig/dunce/pkg/session"
+	"github.com/nixpig/dunce/pkg/templates"
+)
+
+type MenuController struct {
+	menuService   MenuService
+	log           logging.Logger
+	templates     templates.TemplateCache
+	session       session.SessionManager
+	csrfToken     func(r *http.Request) string
+	errorHandlers errors.ErrorHandlers
+}
+
+type MenuControllerConfig struct {
+	Log            logging.Logger
+	TemplateCache  templates.TemplateCache
+	SessionManager session.SessionManager
+	CsrfToken      func(*http.Request) string
+	ErrorHandlers  errors.ErrorHandlers
+}
+
+type MenuView struct {
+	Message         string
+	Items           *[]MenuItemResponseDto
+	Kinds           []KindOption
+	CsrfToken       string
+	IsAuthenticated bool
+}
+
+// IsLast reports whether the item at index is the last in the menu, so it
+// can't be moved down.
+func (v MenuView) IsLast(index int) bool {
+	return v.Items == nil || index >= len(*v.Items)-1
+}
+
+func NewMenuController(
+	menuService MenuService,
+	config MenuControllerConfig,
+) MenuController {
+	return MenuController{
+		menuService:   menuService,
+		log:           config.Log,
+		templates:     config.TemplateCache,
+		session:       config.SessionManager,
+		csrfToken:     config.CsrfToken,
+		errorHandlers: config.ErrorHandlers,
+	}
+}
+
+func (m *MenuController) AdminMenuGet(w http.ResponseWriter, r *http.Request) {
+	items, err := m.menuService.GetAll()
+	if err != nil {
+		m.errorHandlers.InternalServerError(w, r, err)
+		return
+	}
+
+	message := m.session.PopString(r.Context(), session.SESSION_KEY_MESSAGE)
+
+	if err := m.templates["pages/admin/menu.tmpl"].ExecuteTemplate(w, "admin", MenuView{
+		Message:         message,
+		Items:           items,
+		Kinds:           Kinds,
+		CsrfToken:       m.csrfToken(r),
+		IsAuthenticated: m.isAuthenticated(r),
+	}); err != nil {
+		m.errorHandlers.InternalServerError(w, r, err)
+		return
+	}
+}
+
+func (m *MenuController) AdminMenuPost(w http.ResponseWriter, r *http.Request) {
+	item := MenuItemNewRequestDto{
+		Label:  r.FormValue("label"),
+		Kind:   Kind(r.FormValue("kind")),
+		Target: r.FormValue("target"),
+	}
+
+	created, err := m.menuService.Create(&item)
+	if err != nil {
+		if _, ok := err.(validator.ValidationErrors); ok || err == ErrInvalidTarget {
+			m.session.Put(
+				r.Context(),
+				session.SESSION_KEY_MESSAGE,
+				fmt.Sprintf("Unable to add menu item '%s': check the label and what it points at.", item.Label),
+			)
+			http.Redirect(w, r, "/admin/menu", http.StatusSeeOther)
+			return
+		}
+
+		m.errorHandlers.InternalServerError(w, r, fmt.Errorf("unable to create menu item: %w", err))
+		return
+	}
+
+	m.session.Put(
+		r.Context(),
+		session.SESSION_KEY_MESSAGE,
+		fmt.Sprintf("Added menu item '%s'.", created.Label),
+	)
+
+	http.Redirect(w, r, "/admin/menu", http.StatusSeeOther)
+}
+
+func (m *MenuController) DeleteAdminMenuItemPost(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		m.errorHandlers.BadRequest(w, r)
+		return
+	}
+
+	if err := m.menuService.DeleteById(id); err != nil {
+		m.errorHandlers.InternalServerError(w, r, err)
+		return
+	}
+
+	m.session.Put(
+		r.Context(),
+		session.SESSION_KEY_MESSAGE,
+		fmt.Sprintf("Removed menu item '%s'.", r.FormValue("label")),
+	)
+
+	http.Redirect(w, r, "/admin/menu", http.StatusSeeOther)
+}
+
+func (m *MenuController) MoveAdminMenuItemPost(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		m.errorHandlers.BadRequest(w, r)
+		return
+	}
+
+	if err := m.menuService.Move(id, Direction(r.FormValue("direction"))); err != nil {
+		switch err {
+		case ErrItemNotFound:
+			m.errorHandlers.NotFound(w, r)
+		case ErrInvalidMove:
+			m.errorHandlers.BadRequest(w, r)
+		default:
+			m.errorHandlers.InternalServerError(w, r, fmt.Errorf("unable to move menu item: %w", err))
+		}
+
+		return
+	}
+
+	http.Redirect(w, r, "/admin/menu", http.StatusSeeOther)
+}
+
+func (m *MenuController) isAuthenticated(r *http.Request) bool {
+	isAuthenticated, ok := r.Context().Value(session.IS_LOGGED_IN_CONTEXT_KEY).(bool)
+	if !ok {
+		return false
+	}
+
+	return isAuthenticated
+}
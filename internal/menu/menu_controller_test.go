@@ -0,0 +1,614 @@
+package menu
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/nixpig/dunce/pkg/session"
+	"github.com/nixpig/dunce/pkg/templates"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var mockLogger = new(MockLogger)
+var mockSessionManager = new(MockSessionManager)
+var mockErrorHandlers = new(MockErrorHandlers)
+var mockService = new(MockMenuService)
+var mockTemplateCache = templates.TemplateCache{
+	"pages/admin/menu.tmpl": mockTemplate,
+}
+
+func TestMenuController(t *testing.T) {
+	scenarios := map[string]func(t *testing.T, ctrl MenuController){
+		"test get admin menu":                          testAdminMenuGet,
+		"test get admin menu (error - service)":        testAdminMenuGetServiceError,
+		"test post admin menu":                         testAdminMenuPost,
+		"test post admin menu (error - invalid)":       testAdminMenuPostInvalid,
+		"test post admin menu (error - service)":       testAdminMenuPostServiceError,
+		"test delete admin menu item":                  testDeleteAdminMenuItemPost,
+		"test delete admin menu item (error - bad id)": testDeleteAdminMenuItemPostBadId,
+		"test move admin menu item":                    testMoveAdminMenuItemPost,
+		"test move admin menu item (error - invalid)":  testMoveAdminMenuItemPostInvalid,
+		"test move admin menu item (error - missing)":  testMoveAdminMenuItemPostNotFound,
+	}
+
+	for scenario, fn := range scenarios {
+		t.Run(scenario, func(t *testing.T) {
+			ctrl := NewMenuController(mockService, MenuControllerConfig{
+				ErrorHandlers:  mockErrorHandlers,
+				Log:            mockLogger,
+				SessionManager: mockSessionManager,
+				TemplateCache:  mockTemplateCache,
+				CsrfToken: func(r *http.Request) string {
+					return "mock-token"
+				},
+			})
+
+			fn(t, ctrl)
+		})
+	}
+}
+
+func testAdminMenuGet(t *testing.T, ctrl MenuController) {
+	req, err := http.NewRequest("GET", "/admin/menu", nil)
+	if err != nil {
+		t.Error("unable to construct request")
+	}
+
+	req = req.WithContext(
+		context.WithValue(req.Context(), session.IS_LOGGED_IN_CONTEXT_KEY, true),
+	)
+
+	rr := httptest.NewRecorder()
+
+	items := &[]MenuItemResponseDto{
+		{Id: 1, Label: "Home", Kind: KindUrl, Target: "/", Position: 1},
+	}
+
+	mockServiceGetAll := mockService.On("GetAll").Return(items, nil)
+
+	mockSessionManagerPopString := mockSessionManager.
+		On("PopString", req.Context(), "message").
+		Return("session_message")
+
+	mockTemplateExecuteTemplate := mockTemplate.
+		On("ExecuteTemplate", rr, "admin", MenuView{
+			Message:         "session_message",
+			Items:           items,
+			Kinds:           Kinds,
+			CsrfToken:       "mock-token",
+			IsAuthenticated: true,
+		}).Return(nil)
+
+	handler := http.HandlerFunc(ctrl.AdminMenuGet)
+
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(
+		t,
+		http.StatusOK,
+		rr.Result().StatusCode,
+		"should return status code ok",
+	)
+
+	if res := mockService.AssertExpectations(t); !res {
+		t.Error("should call menu service to get all items")
+	}
+
+	if res := mockTemplate.AssertExpectations(t); !res {
+		t.Error("should execute template with menu items")
+	}
+
+	mockServiceGetAll.Unset()
+	mockSessionManagerPopString.Unset()
+	mockTemplateExecuteTemplate.Unset()
+}
+
+func testAdminMenuGetServiceError(t *testing.T, ctrl MenuController) {
+	req, err := http.NewRequest("GET", "/admin/menu", nil)
+	if err != nil {
+		t.Error("unable to construct request")
+	}
+
+	rr := httptest.NewRecorder()
+
+	mockServiceGetAll := mockService.
+		On("GetAll").
+		Return((*[]MenuItemResponseDto)(nil), errors.New("service_error"))
+
+	mockErrorHandlersInternalServerError := mockErrorHandlers.
+		On("InternalServerError", rr, req, errors.New("service_error")).
+		Run(func(args mock.Arguments) {
+			rr.WriteHeader(http.StatusInternalServerError)
+		})
+
+	handler := http.HandlerFunc(ctrl.AdminMenuGet)
+
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(
+		t,
+		http.StatusInternalServerError,
+		rr.Result().StatusCode,
+		"should return status code internal server error",
+	)
+
+	if res := mockErrorHandlers.AssertExpectations(t); !res {
+		t.Error("should call error handler")
+	}
+
+	mockServiceGetAll.Unset()
+	mockErrorHandlersInternalServerError.Unset()
+}
+
+func newMenuItemRequest(t *testing.T) *http.Request {
+	form := url.Values{}
+	form.Add("label", "Go")
+	form.Add("kind", "tag")
+	form.Add("target", "go")
+
+	req, err := http.NewRequest(
+		"POST",
+		"/admin/menu",
+		strings.NewReader(form.Encode()),
+	)
+	if err != nil {
+		t.Error("unable to construct request")
+	}
+
+	req.Header.Add("content-type", "application/x-www-form-urlencoded")
+
+	return req
+}
+
+func testAdminMenuPost(t *testing.T, ctrl MenuController) {
+	req := newMenuItemRequest(t)
+
+	rr := httptest.NewRecorder()
+
+	mockServiceCreate := mockService.On("Create", &MenuItemNewRequestDto{
+		Label:  "Go",
+		Kind:   KindTag,
+		Target: "go",
+	}).Return(&MenuItemResponseDto{
+		Id:       3,
+		Label:    "Go",
+		Kind:     KindTag,
+		Target:   "go",
+		Position: 3,
+	}, nil)
+
+	mockSessionManagerPut := mockSessionManager.
+		On("Put", req.Context(), "message", "Added menu item 'Go'.")
+
+	handler := http.HandlerFunc(ctrl.AdminMenuPost)
+
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(
+		t,
+		http.StatusSeeOther,
+		rr.Result().StatusCode,
+		"should return status code see other",
+	)
+	require.Equal(
+		t,
+		"/admin/menu",
+		rr.Result().Header.Get("Location"),
+		"should redirect to menu",
+	)
+
+	if res := mockService.AssertExpectations(t); !res {
+		t.Error("should call through to menu service to create item")
+	}
+
+	if res := mockSessionManager.AssertExpectations(t); !res {
+		t.Error("should put message in session")
+	}
+
+	mockServiceCreate.Unset()
+	mockSessionManagerPut.Unset()
+}
+
+func testAdminMenuPostInvalid(t *testing.T, ctrl MenuController) {
+	invalidErrors := []error{ErrInvalidTarget, validator.ValidationErrors{}}
+
+	for _, invalidErr := range invalidErrors {
+		req := newMenuItemRequest(t)
+
+		rr := httptest.NewRecorder()
+
+		mockServiceCreate := mockService.On("Create", &MenuItemNewRequestDto{
+			Label:  "Go",
+			Kind:   KindTag,
+			Target: "go",
+		}).Return((*MenuItemResponseDto)(nil), invalidErr)
+
+		mockSessionManagerPut := mockSessionManager.On(
+			"Put",
+			req.Context(),
+			"message",
+			"Unable to add menu item 'Go': check the label and what it points at.",
+		)
+
+		handler := http.HandlerFunc(ctrl.AdminMenuPost)
+
+		handler.ServeHTTP(rr, req)
+
+		require.Equal(
+			t,
+			http.StatusSeeOther,
+			rr.Result().StatusCode,
+			"should return status code see other",
+		)
+
+		if res := mockSessionManager.AssertExpectations(t); !res {
+			t.Error("should put validation message in session")
+		}
+
+		mockServiceCreate.Unset()
+		mockSessionManagerPut.Unset()
+	}
+}
+
+func testAdminMenuPostServiceError(t *testing.T, ctrl MenuController) {
+	req := newMenuItemRequest(t)
+
+	rr := httptest.NewRecorder()
+
+	mockServiceCreate := mockService.On("Create", &MenuItemNewRequestDto{
+		Label:  "Go",
+		Kind:   KindTag,
+		Target: "go",
+	}).Return((*MenuItemResponseDto)(nil), errors.New("service_error"))
+
+	mockErrorHandlersInternalServerError := mockErrorHandlers.
+		On("InternalServerError", rr, req, fmt.Errorf("unable to create menu item: %w", errors.New("service_error"))).
+		Run(func(args mock.Arguments) {
+			rr.WriteHeader(http.StatusInternalServerError)
+		})
+
+	handler := http.HandlerFunc(ctrl.AdminMenuPost)
+
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(
+		t,
+		http.StatusInternalServerError,
+		rr.Result().StatusCode,
+		"should return status code internal server error",
+	)
+
+	if res := mockErrorHandlers.AssertExpectations(t); !res {
+		t.Error("should call error handler")
+	}
+
+	mockServiceCreate.Unset()
+	mockErrorHandlersInternalServerError.Unset()
+}
+
+func testDeleteAdminMenuItemPost(t *testing.T, ctrl MenuController) {
+	form := url.Values{}
+	form.Add("label", "Go")
+
+	req, err := http.NewRequest(
+		"POST",
+		"/admin/menu/3/delete",
+		strings.NewReader(form.Encode()),
+	)
+	if err != nil {
+		t.Error("unable to construct request")
+	}
+
+	req.Header.Add("content-type", "application/x-www-form-urlencoded")
+	req.SetPathValue("id", "3")
+
+	rr := httptest.NewRecorder()
+
+	mockServiceDeleteById := mockService.On("DeleteById", 3).Return(nil)
+
+	mockSessionManagerPut := mockSessionManager.
+		On("Put", req.Context(), "message", "Removed menu item 'Go'.")
+
+	handler := http.HandlerFunc(ctrl.DeleteAdminMenuItemPost)
+
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(
+		t,
+		http.StatusSeeOther,
+		rr.Result().StatusCode,
+		"should return status code see other",
+	)
+
+	if res := mockService.AssertExpectations(t); !res {
+		t.Error("should call through to menu service to delete item")
+	}
+
+	if res := mockSessionManager.AssertExpectations(t); !res {
+		t.Error("should put message in session")
+	}
+
+	mockServiceDeleteById.Unset()
+	mockSessionManagerPut.Unset()
+}
+
+func testDeleteAdminMenuItemPostBadId(t *testing.T, ctrl MenuController) {
+	req, err := http.NewRequest("POST", "/admin/menu/abc/delete", nil)
+	if err != nil {
+		t.Error("unable to construct request")
+	}
+
+	req.SetPathValue("id", "abc")
+
+	rr := httptest.NewRecorder()
+
+	mockErrorHandlersBadRequest := mockErrorHandlers.
+		On("BadRequest", rr, req).
+		Run(func(args mock.Arguments) {
+			rr.WriteHeader(http.StatusBadRequest)
+		})
+
+	handler := http.HandlerFunc(ctrl.DeleteAdminMenuItemPost)
+
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(
+		t,
+		http.StatusBadRequest,
+		rr.Result().StatusCode,
+		"should return status code bad request",
+	)
+
+	mockErrorHandlersBadRequest.Unset()
+}
+
+func newMoveRequest(t *testing.T, id, direction string) *http.Request {
+	form := url.Values{}
+	form.Add("direction", direction)
+
+	req, err := http.NewRequest(
+		"POST",
+		"/admin/menu/"+id+"/move",
+		strings.NewReader(form.Encode()),
+	)
+	if err != nil {
+		t.Error("unable to construct request")
+	}
+
+	req.Header.Add("content-type", "application/x-www-form-urlencoded")
+	req.SetPathValue("id", id)
+
+	return req
+}
+
+func testMoveAdminMenuItemPost(t *testing.T, ctrl MenuController) {
+	req := newMoveRequest(t, "2", "up")
+
+	rr := httptest.NewRecorder()
+
+	mockServiceMove := mockService.On("Move", 2, Up).Return(nil)
+
+	handler := http.HandlerFunc(ctrl.MoveAdminMenuItemPost)
+
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(
+		t,
+		http.StatusSeeOther,
+		rr.Result().StatusCode,
+		"should return status code see other",
+	)
+	require.Equal(
+		t,
+		"/admin/menu",
+		rr.Result().Header.Get("Location"),
+		"should redirect to menu",
+	)
+
+	if res := mockService.AssertExpectations(t); !res {
+		t.Error("should call through to menu service to move item")
+	}
+
+	mockServiceMove.Unset()
+}
+
+func testMoveAdminMenuItemPostInvalid(t *testing.T, ctrl MenuController) {
+	req := newMoveRequest(t, "1", "up")
+
+	rr := httptest.NewRecorder()
+
+	mockServiceMove := mockService.On("Move", 1, Up).Return(ErrInvalidMove)
+
+	mockErrorHandlersBadRequest := mockErrorHandlers.
+		On("BadRequest", rr, req).
+		Run(func(args mock.Arguments) {
+			rr.WriteHeader(http.StatusBadRequest)
+		})
+
+	handler := http.HandlerFunc(ctrl.MoveAdminMenuItemPost)
+
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(
+		t,
+		http.StatusBadRequest,
+		rr.Result().StatusCode,
+		"should return status code bad request",
+	)
+
+	mockServiceMove.Unset()
+	mockErrorHandlersBadRequest.Unset()
+}
+
+func testMoveAdminMenuItemPostNotFound(t *testing.T, ctrl MenuController) {
+	req := newMoveRequest(t, "42", "down")
+
+	rr := httptest.NewRecorder()
+
+	mockServiceMove := mockService.On("Move", 42, Down).Return(ErrItemNotFound)
+
+	mockErrorHandlersNotFound := mockErrorHandlers.
+		On("NotFound", rr, req).
+		Run(func(args mock.Arguments) {
+			rr.WriteHeader(http.StatusNotFound)
+		})
+
+	handler := http.HandlerFunc(ctrl.MoveAdminMenuItemPost)
+
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(
+		t,
+		http.StatusNotFound,
+		rr.Result().StatusCode,
+		"should return status code not found",
+	)
+
+	mockServiceMove.Unset()
+	mockErrorHandlersNotFound.Unset()
+}
+
+type MockErrorHandlers struct {
+	mock.Mock
+}
+
+func (e *MockErrorHandlers) NotFound(w http.ResponseWriter, r *http.Request) {
+	e.Called(w, r)
+}
+
+func (e *MockErrorHandlers) InternalServerError(w http.ResponseWriter, r *http.Request, err error) {
+	e.Called(w, r, err)
+}
+
+func (e *MockErrorHandlers) BadRequest(w http.ResponseWriter, r *http.Request) {
+	e.Called(w, r)
+}
+
+func (e *MockErrorHandlers) Forbidden(w http.ResponseWriter, r *http.Request) {
+	e.Called(w, r)
+}
+
+type MockSessionManager struct {
+	mock.Mock
+}
+
+func (s *MockSessionManager) Exists(ctx context.Context, key string) bool {
+	args := s.Called(ctx, key)
+
+	return args.Bool(0)
+}
+
+func (s *MockSessionManager) PopString(ctx context.Context, key string) string {
+	args := s.Called(ctx, key)
+
+	return args.String(0)
+}
+
+func (s *MockSessionManager) GetString(ctx context.Context, key string) string {
+	args := s.Called(ctx, key)
+
+	return args.String(0)
+}
+
+func (s *MockSessionManager) GetInt(ctx context.Context, key string) int {
+	args := s.Called(ctx, key)
+
+	return args.Int(0)
+}
+
+func (s *MockSessionManager) LoadAndSave(next http.Handler) http.Handler {
+	args := s.Called(next)
+
+	return args.Get(0).(http.Handler)
+}
+
+func (s *MockSessionManager) RenewToken(ctx context.Context) error {
+	args := s.Called(ctx)
+
+	return args.Error(0)
+}
+
+func (s *MockSessionManager) Put(
+	ctx context.Context,
+	key string,
+	val interface{},
+) {
+	s.Called(ctx, key, val)
+}
+
+func (s *MockSessionManager) Remove(ctx context.Context, key string) {
+	s.Called(ctx, key)
+}
+
+type MockLogger struct {
+	mock.Mock
+}
+
+func (l *MockLogger) Debug(ctx context.Context, msg string, args ...any) {
+	l.Called(msg, args)
+}
+
+func (l *MockLogger) Info(ctx context.Context, msg string, args ...any) {
+	l.Called(msg, args)
+}
+
+func (l *MockLogger) Warn(ctx context.Context, msg string, args ...any) {
+	l.Called(msg, args)
+}
+
+func (l *MockLogger) Error(ctx context.Context, msg string, args ...any) {
+	l.Called(msg, args)
+}
+
+var mockTemplate = new(MockTemplate)
+
+type MockTemplate struct {
+	mock.Mock
+}
+
+func (t *MockTemplate) ExecuteTemplate(
+	wr io.Writer,
+	name string,
+	data any,
+) error {
+	args := t.Called(wr, name, data)
+
+	return args.Error(0)
+}
+
+type MockMenuService struct {
+	mock.Mock
+}
+
+func (m *MockMenuService) Create(item *MenuItemNewRequestDto) (*MenuItemResponseDto, error) {
+	args := m.Called(item)
+
+	return args.Get(0).(*MenuItemResponseDto), args.Error(1)
+}
+
+func (m *MockMenuService) DeleteById(id int) error {
+	args := m.Called(id)
+
+	return args.Error(0)
+}
+
+func (m *MockMenuService) GetAll() (*[]MenuItemResponseDto, error) {
+	args := m.Called()
+
+	return args.Get(0).(*[]MenuItemResponseDto), args.Error(1)
+}
+
+func (m *MockMenuService) Move(id int, direction Direction) error {
+	args := m.Called(id, direction)
+
+	return args.Error(0)
+}
@@ -0,0 +1,9 @@
+package menu
+
+import "errors"
+
+var (
+	ErrInvalidTarget = errors.New("menu item target is not valid for its kind")
+	ErrItemNotFound  = errors.New("menu item not found")
+	ErrInvalidMove   = errors.New("menu item can't be moved in that direction")
+)
@@ -0,0 +1,92 @@
+package menu
+
+import (
+	"context"
+
+	"github.com/nixpig/dunce/db"
+)
+
+type MenuRepository interface {
+	Create(item *MenuItem) (*MenuItem, error)
+	DeleteById(id int) error
+	GetAll() (*[]MenuItem, error)
+	Reorder(ids []int) error
+}
+
+type menuPostgresRepository struct {
+	db db.Dbconn
+}
+
+func NewMenuPostgresRepository(db db.Dbconn) menuPostgresRepository {
+	return menuPostgresRepository{
+		db: db,
+	}
+}
+
+// Create adds the item to the end of the menu.
+func (m menuPostgresRepository) Create(item *MenuItem) (*MenuItem, error) {
+	query := `insert into menu_items_ (label_, kind_, target_, position_) select $1, $2, $3, coalesce(max(position_), 0) + 1 from menu_items_ returning id_, label_, kind_, target_, position_`
+
+	row := m.db.QueryRow(context.Background(), query, item.Label, item.Kind, item.Target)
+
+	var createdItem MenuItem
+
+	if err := row.Scan(&createdItem.Id, &createdItem.Label, &createdItem.Kind, &createdItem.Target, &createdItem.Position); err != nil {
+		return nil, err
+	}
+
+	return &createdItem, nil
+}
+
+func (m menuPostgresRepository) DeleteById(id int) error {
+	query := `delete from menu_items_ where id_ = $1`
+
+	_, err := m.db.Exec(context.Background(), query, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (m menuPostgresRepository) GetAll() (*[]MenuItem, error) {
+	query := `select id_, label_, kind_, target_, position_ from menu_items_ order by position_, id_`
+
+	rows, err := m.db.Query(context.Background(), query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	items := []MenuItem{}
+
+	for rows.Next() {
+		var item MenuItem
+
+		if err := rows.Scan(&item.Id, &item.Label, &item.Kind, &item.Target, &item.Position); err != nil {
+			return nil, err
+		}
+
+		items = append(items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return &items, nil
+}
+
+// Reorder positions the items in the order of ids, in a single statement so
+// the menu is never seen half reordered.
+func (m menuPostgresRepository) Reorder(ids []int) error {
+	query := `update menu_items_ m set position_ = o.position_ from unnest($1::integer[]) with ordinality as o(id_, position_) where m.id_ = o.id_`
+
+	_, err := m.db.Exec(context.Background(), query, ids)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
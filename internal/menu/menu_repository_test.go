@@ -0,0 +1,143 @@
+package menu
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/pashagolub/pgxmock/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMenuRepository(t *testing.T) {
+	scenarios := map[string]func(t *testing.T, mock pgxmock.PgxPoolIface, repo MenuRepository){
+		"test create menu item":              testCreateMenuItem,
+		"test delete menu item":              testDeleteMenuItem,
+		"test get all menu items":            testGetAllMenuItems,
+		"test get all menu items (db error)": testGetAllMenuItemsError,
+		"test reorder menu items":            testReorderMenuItems,
+	}
+
+	for scenario, fn := range scenarios {
+		t.Run(scenario, func(t *testing.T) {
+			mock, err := pgxmock.NewPool()
+			if err != nil {
+				t.Fatal("unable to create mock db pool")
+			}
+
+			defer mock.Close()
+
+			repo := NewMenuPostgresRepository(mock)
+
+			fn(t, mock, repo)
+		})
+	}
+}
+
+func testCreateMenuItem(t *testing.T, mock pgxmock.PgxPoolIface, repo MenuRepository) {
+	query := `insert into menu_items_ (label_, kind_, target_, position_) select $1, $2, $3, coalesce(max(position_), 0) + 1 from menu_items_ returning id_, label_, kind_, target_, position_`
+
+	mockRow := mock.
+		NewRows([]string{"id_", "label_", "kind_", "target_", "position_"}).
+		AddRow(23, "About", KindPage, "about", 4)
+
+	mock.
+		ExpectQuery(regexp.QuoteMeta(query)).
+		WithArgs("About", KindPage, "about").
+		WillReturnRows(mockRow)
+
+	got, err := repo.Create(&MenuItem{
+		Label:  "About",
+		Kind:   KindPage,
+		Target: "about",
+	})
+
+	require.NoError(t, err, "should not return error")
+	require.Equal(t, &MenuItem{
+		Id:       23,
+		Label:    "About",
+		Kind:     KindPage,
+		Target:   "about",
+		Position: 4,
+	}, got, "should return created menu item")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error("unmet expectations")
+	}
+}
+
+func testDeleteMenuItem(t *testing.T, mock pgxmock.PgxPoolIface, repo MenuRepository) {
+	query := `delete from menu_items_ where id_ = $1`
+
+	mock.
+		ExpectExec(regexp.QuoteMeta(query)).
+		WithArgs(23).
+		WillReturnResult(pgxmock.NewResult("delete", 1))
+
+	err := repo.DeleteById(23)
+
+	require.NoError(t, err, "should not return error")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error("unmet expectations")
+	}
+}
+
+func testGetAllMenuItems(t *testing.T, mock pgxmock.PgxPoolIface, repo MenuRepository) {
+	query := `select id_, label_, kind_, target_, position_ from menu_items_ order by position_, id_`
+
+	mockRows := mock.
+		NewRows([]string{"id_", "label_", "kind_", "target_", "position_"}).
+		AddRow(1, "Home", KindUrl, "/", 1).
+		AddRow(2, "Go", KindTag, "go", 2)
+
+	mock.
+		ExpectQuery(regexp.QuoteMeta(query)).
+		WillReturnRows(mockRows)
+
+	got, err := repo.GetAll()
+
+	require.NoError(t, err, "should not return error")
+	require.Equal(t, &[]MenuItem{
+		{Id: 1, Label: "Home", Kind: KindUrl, Target: "/", Position: 1},
+		{Id: 2, Label: "Go", Kind: KindTag, Target: "go", Position: 2},
+	}, got, "should return menu items in order")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error("unmet expectations")
+	}
+}
+
+func testGetAllMenuItemsError(t *testing.T, mock pgxmock.PgxPoolIface, repo MenuRepository) {
+	query := `select id_, label_, kind_, target_, position_ from menu_items_ order by position_, id_`
+
+	mock.
+		ExpectQuery(regexp.QuoteMeta(query)).
+		WillReturnError(errors.New("db_error"))
+
+	got, err := repo.GetAll()
+
+	require.Nil(t, got, "should not return menu items")
+	require.EqualError(t, err, "db_error", "should return db error")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error("unmet expectations")
+	}
+}
+
+func testReorderMenuItems(t *testing.T, mock pgxmock.PgxPoolIface, repo MenuRepository) {
+	query := `update menu_items_ m set position_ = o.position_ from unnest($1::integer[]) with ordinality as o(id_, position_) where m.id_ = o.id_`
+
+	mock.
+		ExpectExec(regexp.QuoteMeta(query)).
+		WithArgs([]int{2, 1, 3}).
+		WillReturnResult(pgxmock.NewResult("update", 3))
+
+	err := repo.Reorder([]int{2, 1, 3})
+
+	require.NoError(t, err, "should not return error")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error("unmet expectations")
+	}
+}
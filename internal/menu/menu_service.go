@@ -0,0 +1,186 @@
+package menu
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/nixpig/dunce/pkg/cache"
+)
+
+type MenuService interface {
+	Create(item *MenuItemNewRequestDto) (*MenuItemResponseDto, error)
+	DeleteById(id int) error
+	GetAll() (*[]MenuItemResponseDto, error)
+	Move(id int, direction Direction) error
+}
+
+type MenuServiceImpl struct {
+	repo     MenuRepository
+	validate *validator.Validate
+	cache    *cache.Value[[]MenuItemResponseDto]
+}
+
+func NewMenuService(repo MenuRepository, validate *validator.Validate) MenuServiceImpl {
+	return MenuServiceImpl{
+		repo:     repo,
+		validate: validate,
+		cache:    &cache.Value[[]MenuItemResponseDto]{},
+	}
+}
+
+func (m MenuServiceImpl) Create(item *MenuItemNewRequestDto) (*MenuItemResponseDto, error) {
+	itemToCreate := MenuItem{
+		Label:  strings.TrimSpace(item.Label),
+		Kind:   item.Kind,
+		Target: strings.TrimSpace(item.Target),
+	}
+
+	if itemToCreate.Kind != KindUrl {
+		itemToCreate.Target = strings.ToLower(strings.Trim(itemToCreate.Target, "/"))
+	}
+
+	if err := m.validate.Struct(itemToCreate); err != nil {
+		return nil, err
+	}
+
+	if err := m.validateTarget(itemToCreate.Kind, itemToCreate.Target); err != nil {
+		return nil, err
+	}
+
+	createdItem, err := m.repo.Create(&itemToCreate)
+	if err != nil {
+		return nil, err
+	}
+
+	m.cache.Invalidate()
+
+	return &MenuItemResponseDto{
+		Id:       createdItem.Id,
+		Label:    createdItem.Label,
+		Kind:     createdItem.Kind,
+		Target:   createdItem.Target,
+		Position: createdItem.Position,
+	}, nil
+}
+
+func (m MenuServiceImpl) DeleteById(id int) error {
+	if err := m.repo.DeleteById(id); err != nil {
+		return err
+	}
+
+	m.cache.Invalidate()
+
+	return nil
+}
+
+// GetAll returns the menu items in the order they're shown. Every public page
+// reads them, so they're cached until the menu is next changed.
+func (m MenuServiceImpl) GetAll() (*[]MenuItemResponseDto, error) {
+	cached, err := m.cache.Get(func() ([]MenuItemResponseDto, error) {
+		items, err := m.repo.GetAll()
+		if err != nil {
+			return nil, err
+		}
+
+		menu := make([]MenuItemResponseDto, len(*items))
+
+		for index, item := range *items {
+			menu[index] = MenuItemResponseDto{
+				Id:       item.Id,
+				Label:    item.Label,
+				Kind:     item.Kind,
+				Target:   item.Target,
+				Position: item.Position,
+			}
+		}
+
+		return menu, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// callers are free to change what they get back, so they mustn't be
+	// handed the cached slice itself
+	menu := slices.Clone(cached)
+
+	return &menu, nil
+}
+
+// Move swaps the item with the one before or after it.
+func (m MenuServiceImpl) Move(id int, direction Direction) error {
+	items, err := m.repo.GetAll()
+	if err != nil {
+		return err
+	}
+
+	ids := make([]int, len(*items))
+	for index, item := range *items {
+		ids[index] = item.Id
+	}
+
+	index := slices.Index(ids, id)
+	if index == -1 {
+		return ErrItemNotFound
+	}
+
+	var other int
+
+	switch direction {
+	case Up:
+		other = index - 1
+	case Down:
+		other = index + 1
+	default:
+		return ErrInvalidMove
+	}
+
+	if other < 0 || other >= len(ids) {
+		return ErrInvalidMove
+	}
+
+	ids[index], ids[other] = ids[other], ids[index]
+
+	if err := m.repo.Reorder(ids); err != nil {
+		return err
+	}
+
+	m.cache.Invalidate()
+
+	return nil
+}
+
+// validateTarget checks articles and tags are pointed at by slug, pages by
+// their path of slugs, e.g. about/team, and URLs are either a path on this
+// site or an absolute http(s) URL.
+func (m MenuServiceImpl) validateTarget(kind Kind, target string) error {
+	switch kind {
+	case KindArticle, KindTag:
+		if err := m.validate.Var(target, "slug"); err != nil {
+			return ErrInvalidTarget
+		}
+
+		return nil
+	case KindPage:
+		for _, slug := range strings.Split(target, "/") {
+			if err := m.validate.Var(slug, "slug"); err != nil {
+				return ErrInvalidTarget
+			}
+		}
+
+		return nil
+	}
+
+	if strings.HasPrefix(target, "/") && !strings.HasPrefix(target, "//") {
+		return nil
+	}
+
+	u, err := url.Parse(target)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrInvalidTarget
+	}
+
+	return nil
+}
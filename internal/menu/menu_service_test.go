@@ -0,0 +1,325 @@
+package menu
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/nixpig/dunce/pkg/validation"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var mockRepo = new(MockMenuRepository)
+
+func TestMenuService(t *testing.T) {
+	scenarios := map[string]func(t *testing.T, service MenuService){
+		"test create menu item":                       testMenuServiceCreate,
+		"test create menu item (url)":                 testMenuServiceCreateUrl,
+		"test create menu item (page path)":           testMenuServiceCreatePagePath,
+		"test create menu item (error - validation)":  testMenuServiceCreateValidationError,
+		"test create menu item (error - target)":      testMenuServiceCreateTargetError,
+		"test delete menu item":                       testMenuServiceDelete,
+		"test get all menu items":                     testMenuServiceGetAll,
+		"test get all menu items is cached":           testMenuServiceGetAllCached,
+		"test get all menu items (error)":             testMenuServiceGetAllError,
+		"test move menu item up":                      testMenuServiceMoveUp,
+		"test move menu item down invalidates cache":  testMenuServiceMoveDownInvalidatesCache,
+		"test move menu item (error - past the end)":  testMenuServiceMovePastEnd,
+		"test move menu item (error - not found)":     testMenuServiceMoveNotFound,
+		"test move menu item (error - bad direction)": testMenuServiceMoveBadDirection,
+	}
+
+	validate, err := validation.NewValidator()
+	if err != nil {
+		t.Fatal("unable to create validator")
+	}
+
+	for scenario, fn := range scenarios {
+		t.Run(scenario, func(t *testing.T) {
+			service := NewMenuService(mockRepo, validate)
+
+			fn(t, service)
+		})
+	}
+}
+
+type MockMenuRepository struct {
+	mock.Mock
+}
+
+func (m *MockMenuRepository) Create(item *MenuItem) (*MenuItem, error) {
+	args := m.Called(item)
+
+	return args.Get(0).(*MenuItem), args.Error(1)
+}
+
+func (m *MockMenuRepository) DeleteById(id int) error {
+	args := m.Called(id)
+
+	return args.Error(0)
+}
+
+func (m *MockMenuRepository) GetAll() (*[]MenuItem, error) {
+	args := m.Called()
+
+	return args.Get(0).(*[]MenuItem), args.Error(1)
+}
+
+func (m *MockMenuRepository) Reorder(ids []int) error {
+	args := m.Called(ids)
+
+	return args.Error(0)
+}
+
+var threeItems = &[]MenuItem{
+	{Id: 1, Label: "Home", Kind: KindUrl, Target: "/", Position: 1},
+	{Id: 2, Label: "Articles", Kind: KindUrl, Target: "/articles", Position: 2},
+	{Id: 3, Label: "Go", Kind: KindTag, Target: "go", Position: 3},
+}
+
+func testMenuServiceCreate(t *testing.T, service MenuService) {
+	mockRepoCreate := mockRepo.On("Create", &MenuItem{
+		Label:  "Go",
+		Kind:   KindTag,
+		Target: "go",
+	}).Return(&MenuItem{
+		Id:       3,
+		Label:    "Go",
+		Kind:     KindTag,
+		Target:   "go",
+		Position: 3,
+	}, nil)
+
+	got, err := service.Create(&MenuItemNewRequestDto{
+		Label:  " Go ",
+		Kind:   KindTag,
+		Target: "Go",
+	})
+
+	require.NoError(t, err, "should not return error")
+	require.Equal(t, &MenuItemResponseDto{
+		Id:       3,
+		Label:    "Go",
+		Kind:     KindTag,
+		Target:   "go",
+		Position: 3,
+	}, got, "should return created menu item")
+
+	if res := mockRepo.AssertExpectations(t); !res {
+		t.Error("should call through to repo with trimmed, lowercased slug")
+	}
+
+	mockRepoCreate.Unset()
+}
+
+func testMenuServiceCreateUrl(t *testing.T, service MenuService) {
+	mockRepoCreate := mockRepo.On("Create", &MenuItem{
+		Label:  "GitHub",
+		Kind:   KindUrl,
+		Target: "https://github.com/nixpig",
+	}).Return(&MenuItem{
+		Id:       4,
+		Label:    "GitHub",
+		Kind:     KindUrl,
+		Target:   "https://github.com/nixpig",
+		Position: 4,
+	}, nil)
+
+	got, err := service.Create(&MenuItemNewRequestDto{
+		Label:  "GitHub",
+		Kind:   KindUrl,
+		Target: "https://github.com/nixpig",
+	})
+
+	require.NoError(t, err, "should not return error")
+	require.Equal(t, "https://github.com/nixpig", got.Url(), "should link to url as given")
+
+	mockRepoCreate.Unset()
+}
+
+func testMenuServiceCreatePagePath(t *testing.T, service MenuService) {
+	mockRepoCreate := mockRepo.On("Create", &MenuItem{
+		Label:  "Team",
+		Kind:   KindPage,
+		Target: "about/team",
+	}).Return(&MenuItem{
+		Id:       5,
+		Label:    "Team",
+		Kind:     KindPage,
+		Target:   "about/team",
+		Position: 5,
+	}, nil)
+
+	got, err := service.Create(&MenuItemNewRequestDto{
+		Label:  "Team",
+		Kind:   KindPage,
+		Target: "/About/Team/",
+	})
+
+	require.NoError(t, err, "should not return error")
+	require.Equal(t, "/about/team", got.Url(), "should link to page path")
+
+	mockRepoCreate.Unset()
+}
+
+func testMenuServiceCreateValidationError(t *testing.T, service MenuService) {
+	got, err := service.Create(&MenuItemNewRequestDto{
+		Label:  "",
+		Kind:   "somewhere",
+		Target: "go",
+	})
+
+	require.Nil(t, got, "should not return menu item")
+	require.IsType(t, validator.ValidationErrors{}, err, "should return validation errors")
+}
+
+func testMenuServiceCreateTargetError(t *testing.T, service MenuService) {
+	invalid := []MenuItemNewRequestDto{
+		{Label: "Bad slug", Kind: KindArticle, Target: "not a slug"},
+		{Label: "Nested slug", Kind: KindTag, Target: "go/lang"},
+		{Label: "Bad path", Kind: KindPage, Target: "about//team"},
+		{Label: "Scheme", Kind: KindUrl, Target: "javascript:alert(1)"},
+		{Label: "Protocol relative", Kind: KindUrl, Target: "//example.com"},
+		{Label: "No host", Kind: KindUrl, Target: "https://"},
+		{Label: "Relative", Kind: KindUrl, Target: "articles"},
+	}
+
+	for _, item := range invalid {
+		got, err := service.Create(&item)
+
+		require.Nil(t, got, "should not return menu item")
+		require.ErrorIs(t, err, ErrInvalidTarget, "should return invalid target error for '%s'", item.Label)
+	}
+}
+
+func testMenuServiceDelete(t *testing.T, service MenuService) {
+	mockRepoDeleteById := mockRepo.On("DeleteById", 2).Return(nil)
+
+	err := service.DeleteById(2)
+
+	require.NoError(t, err, "should not return error")
+
+	if res := mockRepo.AssertExpectations(t); !res {
+		t.Error("should call through to repo")
+	}
+
+	mockRepoDeleteById.Unset()
+}
+
+func testMenuServiceGetAll(t *testing.T, service MenuService) {
+	mockRepoGetAll := mockRepo.On("GetAll").Return(threeItems, nil)
+
+	got, err := service.GetAll()
+
+	require.NoError(t, err, "should not return error")
+	require.Equal(t, &[]MenuItemResponseDto{
+		{Id: 1, Label: "Home", Kind: KindUrl, Target: "/", Position: 1},
+		{Id: 2, Label: "Articles", Kind: KindUrl, Target: "/articles", Position: 2},
+		{Id: 3, Label: "Go", Kind: KindTag, Target: "go", Position: 3},
+	}, got, "should return menu items")
+
+	mockRepoGetAll.Unset()
+}
+
+func testMenuServiceGetAllCached(t *testing.T, service MenuService) {
+	mockRepoGetAll := mockRepo.On("GetAll").Return(threeItems, nil).Once()
+
+	first, err := service.GetAll()
+	require.NoError(t, err, "should not return error")
+
+	// a second call to the repo would panic, as only one is expected
+	second, err := service.GetAll()
+	require.NoError(t, err, "should not return error")
+
+	require.Equal(t, first, second, "should return cached menu")
+
+	(*second)[0].Label = "changed"
+
+	third, err := service.GetAll()
+	require.NoError(t, err, "should not return error")
+	require.Equal(t, "Home", (*third)[0].Label, "should not share cached menu")
+
+	mockRepoGetAll.Unset()
+}
+
+func testMenuServiceGetAllError(t *testing.T, service MenuService) {
+	mockRepoGetAll := mockRepo.
+		On("GetAll").
+		Return((*[]MenuItem)(nil), errors.New("repo_error"))
+
+	got, err := service.GetAll()
+
+	require.Nil(t, got, "should not return menu items")
+	require.EqualError(t, err, "repo_error", "should return repo error")
+
+	mockRepoGetAll.Unset()
+}
+
+func testMenuServiceMoveUp(t *testing.T, service MenuService) {
+	mockRepoGetAll := mockRepo.On("GetAll").Return(threeItems, nil)
+	mockRepoReorder := mockRepo.On("Reorder", []int{1, 3, 2}).Return(nil)
+
+	err := service.Move(3, Up)
+
+	require.NoError(t, err, "should not return error")
+
+	if res := mockRepo.AssertExpectations(t); !res {
+		t.Error("should swap with previous item")
+	}
+
+	mockRepoGetAll.Unset()
+	mockRepoReorder.Unset()
+}
+
+func testMenuServiceMoveDownInvalidatesCache(t *testing.T, service MenuService) {
+	mockRepoGetAll := mockRepo.On("GetAll").Return(threeItems, nil).Twice()
+
+	_, err := service.GetAll()
+	require.NoError(t, err, "should not return error")
+
+	mockRepoReorder := mockRepo.On("Reorder", []int{2, 1, 3}).Return(nil)
+
+	require.NoError(t, service.Move(1, Down))
+
+	mockRepoGetAll.Unset()
+
+	reordered := &[]MenuItem{
+		{Id: 2, Label: "Articles", Kind: KindUrl, Target: "/articles", Position: 1},
+		{Id: 1, Label: "Home", Kind: KindUrl, Target: "/", Position: 2},
+	}
+
+	mockRepoGetAllReordered := mockRepo.On("GetAll").Return(reordered, nil).Once()
+
+	got, err := service.GetAll()
+	require.NoError(t, err, "should not return error")
+	require.Equal(t, 2, (*got)[0].Id, "should read menu again after move")
+
+	mockRepoReorder.Unset()
+	mockRepoGetAllReordered.Unset()
+}
+
+func testMenuServiceMovePastEnd(t *testing.T, service MenuService) {
+	mockRepoGetAll := mockRepo.On("GetAll").Return(threeItems, nil)
+
+	require.ErrorIs(t, service.Move(1, Up), ErrInvalidMove, "should not move first item up")
+	require.ErrorIs(t, service.Move(3, Down), ErrInvalidMove, "should not move last item down")
+
+	mockRepoGetAll.Unset()
+}
+
+func testMenuServiceMoveNotFound(t *testing.T, service MenuService) {
+	mockRepoGetAll := mockRepo.On("GetAll").Return(threeItems, nil)
+
+	require.ErrorIs(t, service.Move(42, Up), ErrItemNotFound, "should return not found error")
+
+	mockRepoGetAll.Unset()
+}
+
+func testMenuServiceMoveBadDirection(t *testing.T, service MenuService) {
+	mockRepoGetAll := mockRepo.On("GetAll").Return(threeItems, nil)
+
+	require.ErrorIs(t, service.Move(2, "sideways"), ErrInvalidMove, "should return invalid move error")
+
+	mockRepoGetAll.Unset()
+}
@@ -0,0 +1,48 @@
+package menu
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMenuItem(t *testing.T) {
+	scenarios := map[string]func(t *testing.T){
+		"test menu item url":       testMenuItemUrl,
+		"test menu item is active": testMenuItemIsActive,
+	}
+
+	for scenario, fn := range scenarios {
+		t.Run(scenario, func(t *testing.T) {
+			fn(t)
+		})
+	}
+}
+
+func testMenuItemUrl(t *testing.T) {
+	require.Equal(t, "/articles/some-article", MenuItemResponseDto{Kind: KindArticle, Target: "some-article"}.Url())
+	require.Equal(t, "/tags/some-tag", MenuItemResponseDto{Kind: KindTag, Target: "some-tag"}.Url())
+	require.Equal(t, "/about", MenuItemResponseDto{Kind: KindPage, Target: "about"}.Url())
+	require.Equal(t, "https://example.com", MenuItemResponseDto{Kind: KindUrl, Target: "https://example.com"}.Url())
+}
+
+func testMenuItemIsActive(t *testing.T) {
+	home := MenuItemResponseDto{Kind: KindUrl, Target: "/"}
+	require.True(t, home.IsActive("/"), "home should be active on home page")
+	require.False(t, home.IsActive("/articles"), "home should only be active on home page")
+
+	articles := MenuItemResponseDto{Kind: KindUrl, Target: "/articles"}
+	require.True(t, articles.IsActive("/articles"), "should be active on its own page")
+	require.True(t, articles.IsActive("/articles/some-article"), "should be active on pages below it")
+	require.False(t, articles.IsActive("/articles-archive"), "should not be active on pages that only share a prefix")
+
+	tag := MenuItemResponseDto{Kind: KindTag, Target: "go"}
+	require.True(t, tag.IsActive("/tags/go"), "should be active on tag page")
+	require.False(t, tag.IsActive("/tags"), "should not be active on pages above it")
+
+	anchored := MenuItemResponseDto{Kind: KindUrl, Target: "/search?q=go#results"}
+	require.True(t, anchored.IsActive("/search"), "should ignore query and fragment")
+
+	external := MenuItemResponseDto{Kind: KindUrl, Target: "https://example.com/"}
+	require.False(t, external.IsActive("/"), "external links should never be active")
+}
@@ -0,0 +1,121 @@
+package page
+
+import (
+	"slices"
+	"time"
+)
+
+type Page struct {
+	Id        int       `validate:"omitempty"`
+	Title     string    `validate:"required,max=255"`
+	Slug      string    `validate:"required,slug,min=2,max=50,lowercase"`
+	Body      string    `validate:"required"`
+	ParentId  *int      `validate:"omitempty"`
+	Template  string    `validate:"required,oneof=default plain index"`
+	Path      string    `validate:"omitempty"`
+	CreatedAt time.Time `validate:"omitempty"`
+	UpdatedAt time.Time `validate:"omitempty"`
+}
+
+type PageNewRequestDto struct {
+	Title    string `validate:"required,max=255"`
+	Slug     string `validate:"required,slug,min=2,max=50"`
+	Body     string `validate:"required"`
+	ParentId *int   `validate:"omitempty"`
+	Template string `validate:"required"`
+}
+
+type PageUpdateRequestDto struct {
+	Id       int    `validate:"required"`
+	Title    string `validate:"required,max=255"`
+	Slug     string `validate:"required,slug,min=2,max=50"`
+	Body     string `validate:"required"`
+	ParentId *int   `validate:"omitempty"`
+	Template string `validate:"required"`
+}
+
+type PageResponseDto struct {
+	Id        int
+	Title     string
+	Slug      string
+	Body      string
+	ParentId  *int
+	Template  string
+	Path      string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Url is where the page is served, below its parents, e.g. /about/team.
+func (p PageResponseDto) Url() string {
+	return "/" + p.Path
+}
+
+// IsChildOf reports whether the page is directly below the page with id.
+func (p PageResponseDto) IsChildOf(id int) bool {
+	return p.ParentId != nil && *p.ParentId == id
+}
+
+// Template is a layout a page can be shown with.
+type Template struct {
+	Name        string
+	Label       string
+	Description string
+}
+
+// File is the page template in the template cache.
+func (t Template) File() string {
+	return "pages/public/page/" + t.Name + ".tmpl"
+}
+
+const DefaultTemplate = "default"
+
+// Templates are all the layouts a page can choose from.
+var Templates = []Template{
+	{
+		Name:        DefaultTemplate,
+		Label:       "Default",
+		Description: "Title followed by the content.",
+	},
+	{
+		Name:        "plain",
+		Label:       "Plain",
+		Description: "Content only, for pages that bring their own heading.",
+	},
+	{
+		Name:        "index",
+		Label:       "Index",
+		Description: "Title and content, followed by links to the page's children.",
+	},
+}
+
+// GetTemplate returns the template called name, or false if there's no such
+// template.
+func GetTemplate(name string) (Template, bool) {
+	for _, template := range Templates {
+		if template.Name == name {
+			return template, true
+		}
+	}
+
+	return Template{}, false
+}
+
+// ReservedSlugs can't be used by top-level pages, since they're served at the
+// root alongside the rest of the site.
+var ReservedSlugs = []string{
+	"admin",
+	"articles",
+	"authors",
+	"healthz",
+	"readyz",
+	"search",
+	"sitemaps",
+	"static",
+	"tags",
+	"version",
+}
+
+func isReserved(slug string) bool {
+	return slices.Contains(ReservedSlugs, slug)
+}
@@ -0,0 +1,335 @@
+package page
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/nixpig/dunce/internal/app/errors"
+	"github.com/nixpig/dunce/pkg/logging"
+	"github.com/nixpig/dunce/pkg/markdown"
+	"github.com/nixpig/dunce/pkg/session"
+	"github.com/nixpig/dunce/pkg/templates"
+)
+
+type PageController struct {
+	pageService   PageService
+	log           logging.Logger
+	templates     templates.TemplateCache
+	session       session.SessionManager
+	csrfToken     func(r *http.Request) string
+	errorHandlers errors.ErrorHandlers
+}
+
+type PageControllerConfig struct {
+	Log            logging.Logger
+	TemplateCache  templates.TemplateCache
+	SessionManager session.SessionManager
+	CsrfToken      func(*http.Request) string
+	ErrorHandlers  errors.ErrorHandlers
+}
+
+type PageView struct {
+	Path     string
+	Page     *PageResponseDto
+	Content  template.HTML
+	Children []PageResponseDto
+}
+
+type PagesView struct {
+	Message         string
+	Pages           *[]PageResponseDto
+	CsrfToken       string
+	IsAuthenticated bool
+}
+
+type PageEditView struct {
+	Message         string
+	Page            *PageResponseDto
+	Parents         *[]PageResponseDto
+	Templates       []Template
+	CsrfToken       string
+	IsAuthenticated bool
+}
+
+func NewPageController(
+	pageService PageService,
+	config PageControllerConfig,
+) PageController {
+	return PageController{
+		pageService:   pageService,
+		log:           config.Log,
+		templates:     config.TemplateCache,
+		session:       config.SessionManager,
+		csrfToken:     config.CsrfToken,
+		errorHandlers: config.ErrorHandlers,
+	}
+}
+
+func (p *PageController) AdminPagesGet(w http.ResponseWriter, r *http.Request) {
+	pages, err := p.pageService.GetAll()
+	if err != nil {
+		p.errorHandlers.InternalServerError(w, r, err)
+		return
+	}
+
+	message := p.session.PopString(r.Context(), session.SESSION_KEY_MESSAGE)
+
+	if err := p.templates["pages/admin/pages.tmpl"].ExecuteTemplate(w, "admin", PagesView{
+		Message:         message,
+		Pages:           pages,
+		CsrfToken:       p.csrfToken(r),
+		IsAuthenticated: p.isAuthenticated(r),
+	}); err != nil {
+		p.errorHandlers.InternalServerError(w, r, err)
+		return
+	}
+}
+
+func (p *PageController) AdminPagesNewGet(w http.ResponseWriter, r *http.Request) {
+	pages, err := p.pageService.GetAll()
+	if err != nil {
+		p.errorHandlers.InternalServerError(w, r, err)
+		return
+	}
+
+	message := p.session.PopString(r.Context(), session.SESSION_KEY_MESSAGE)
+
+	if err := p.templates["pages/admin/new-page.tmpl"].ExecuteTemplate(w, "admin", PageEditView{
+		Message:         message,
+		Parents:         pages,
+		Templates:       Templates,
+		CsrfToken:       p.csrfToken(r),
+		IsAuthenticated: p.isAuthenticated(r),
+	}); err != nil {
+		p.errorHandlers.InternalServerError(w, r, err)
+		return
+	}
+}
+
+func (p *PageController) AdminPagesPost(w http.ResponseWriter, r *http.Request) {
+	parentId, err := parseParentId(r.FormValue("parent_id"))
+	if err != nil {
+		p.errorHandlers.BadRequest(w, r)
+		return
+	}
+
+	page := PageNewRequestDto{
+		Title:    r.FormValue("title"),
+		Slug:     r.FormValue("slug"),
+		Body:     r.FormValue("body"),
+		ParentId: parentId,
+		Template: r.FormValue("template"),
+	}
+
+	created, err := p.pageService.Create(&page)
+	if err != nil {
+		if message, ok := pageErrorMessage(err); ok {
+			p.session.Put(r.Context(), session.SESSION_KEY_MESSAGE, message)
+			http.Redirect(w, r, "/admin/pages/new", http.StatusSeeOther)
+			return
+		}
+
+		p.errorHandlers.InternalServerError(w, r, fmt.Errorf("unable to create page: %w", err))
+		return
+	}
+
+	p.session.Put(
+		r.Context(),
+		session.SESSION_KEY_MESSAGE,
+		fmt.Sprintf("Created page '%s'.", created.Title),
+	)
+
+	http.Redirect(w, r, "/admin/pages", http.StatusSeeOther)
+}
+
+func (p *PageController) AdminPageGet(w http.ResponseWriter, r *http.Request) {
+	page, err := p.pageService.GetByAttribute("slug", r.PathValue("slug"))
+	if err != nil {
+		p.errorHandlers.NotFound(w, r)
+		return
+	}
+
+	pages, err := p.pageService.GetAll()
+	if err != nil {
+		p.errorHandlers.InternalServerError(w, r, err)
+		return
+	}
+
+	// a page can't be moved below itself or any of its children
+	parents := []PageResponseDto{}
+	for _, parent := range *pages {
+		if parent.Id != page.Id && !strings.HasPrefix(parent.Path, page.Path+"/") {
+			parents = append(parents, parent)
+		}
+	}
+
+	message := p.session.PopString(r.Context(), session.SESSION_KEY_MESSAGE)
+
+	if err := p.templates["pages/admin/page.tmpl"].ExecuteTemplate(w, "admin", PageEditView{
+		Message:         message,
+		Page:            page,
+		Parents:         &parents,
+		Templates:       Templates,
+		CsrfToken:       p.csrfToken(r),
+		IsAuthenticated: p.isAuthenticated(r),
+	}); err != nil {
+		p.errorHandlers.InternalServerError(w, r, err)
+		return
+	}
+}
+
+func (p *PageController) AdminPagePost(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.FormValue("id"))
+	if err != nil {
+		p.errorHandlers.BadRequest(w, r)
+		return
+	}
+
+	parentId, err := parseParentId(r.FormValue("parent_id"))
+	if err != nil {
+		p.errorHandlers.BadRequest(w, r)
+		return
+	}
+
+	page := PageUpdateRequestDto{
+		Id:       id,
+		Title:    r.FormValue("title"),
+		Slug:     r.FormValue("slug"),
+		Body:     r.FormValue("body"),
+		ParentId: parentId,
+		Template: r.FormValue("template"),
+	}
+
+	updated, err := p.pageService.Update(&page)
+	if err != nil {
+		if message, ok := pageErrorMessage(err); ok {
+			p.session.Put(r.Context(), session.SESSION_KEY_MESSAGE, message)
+			http.Redirect(w, r, "/admin/pages/"+r.PathValue("slug"), http.StatusSeeOther)
+			return
+		}
+
+		p.errorHandlers.InternalServerError(w, r, fmt.Errorf("unable to update page: %w", err))
+		return
+	}
+
+	p.session.Put(
+		r.Context(),
+		session.SESSION_KEY_MESSAGE,
+		fmt.Sprintf("Updated page '%s'.", updated.Title),
+	)
+
+	http.Redirect(w, r, "/admin/pages", http.StatusSeeOther)
+}
+
+func (p *PageController) DeleteAdminPagePost(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.FormValue("id"))
+	if err != nil {
+		p.errorHandlers.BadRequest(w, r)
+		return
+	}
+
+	if err := p.pageService.DeleteById(id); err != nil {
+		if err == ErrPageHasChildren {
+			p.session.Put(
+				r.Context(),
+				session.SESSION_KEY_MESSAGE,
+				fmt.Sprintf("Unable to delete page '%s': move or delete its children first.", r.FormValue("title")),
+			)
+			http.Redirect(w, r, "/admin/pages", http.StatusSeeOther)
+			return
+		}
+
+		p.errorHandlers.InternalServerError(w, r, err)
+		return
+	}
+
+	p.session.Put(
+		r.Context(),
+		session.SESSION_KEY_MESSAGE,
+		fmt.Sprintf("Deleted page '%s'.", r.FormValue("title")),
+	)
+
+	http.Redirect(w, r, "/admin/pages", http.StatusSeeOther)
+}
+
+// PublicGetPage shows the page at the request path, e.g. /about/team, with
+// the template the page chose.
+func (p *PageController) PublicGetPage(w http.ResponseWriter, r *http.Request) {
+	page, err := p.pageService.GetByAttribute("path", strings.Trim(r.URL.Path, "/"))
+	if err != nil {
+		p.errorHandlers.NotFound(w, r)
+		return
+	}
+
+	pageTemplate, ok := GetTemplate(page.Template)
+	if !ok {
+		pageTemplate, _ = GetTemplate(DefaultTemplate)
+	}
+
+	content, err := markdown.MdToHtml([]byte(page.Body))
+	if err != nil {
+		p.errorHandlers.BadRequest(w, r)
+		return
+	}
+
+	children, err := p.pageService.GetChildren(page.Id)
+	if err != nil {
+		p.errorHandlers.InternalServerError(w, r, err)
+		return
+	}
+
+	if err := p.templates[pageTemplate.File()].ExecuteTemplate(w, "public", PageView{
+		Path:     r.URL.Path,
+		Page:     page,
+		Content:  template.HTML(content),
+		Children: *children,
+	}); err != nil {
+		p.errorHandlers.InternalServerError(w, r, err)
+		return
+	}
+}
+
+func (p *PageController) isAuthenticated(r *http.Request) bool {
+	isAuthenticated, ok := r.Context().Value(session.IS_LOGGED_IN_CONTEXT_KEY).(bool)
+	if !ok {
+		return false
+	}
+
+	return isAuthenticated
+}
+
+// parseParentId reads the parent page from a form, where an empty value means
+// a top-level page.
+func parseParentId(value string) (*int, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	id, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return &id, nil
+}
+
+// pageErrorMessage describes errors caused by what was entered, so they can
+// be shown to the admin rather than failing the request.
+func pageErrorMessage(err error) (string, bool) {
+	if _, ok := err.(validator.ValidationErrors); ok {
+		return "Unable to save page: check the title, slug, content and template.", true
+	}
+
+	switch err {
+	case ErrReservedSlug:
+		return "Unable to save page: that slug is already used by the site, so choose another or a parent page.", true
+	case ErrParentNotFound, ErrParentCycle:
+		return fmt.Sprintf("Unable to save page: %s.", err), true
+	}
+
+	return "", false
+}
@@ -0,0 +1,609 @@
+package page
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/nixpig/dunce/pkg/session"
+	"github.com/nixpig/dunce/pkg/templates"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var mockLogger = new(MockLogger)
+var mockSessionManager = new(MockSessionManager)
+var mockErrorHandlers = new(MockErrorHandlers)
+var mockService = new(MockPageService)
+var mockTemplateCache = templates.TemplateCache{
+	"pages/admin/pages.tmpl":         mockTemplate,
+	"pages/public/page/default.tmpl": mockTemplate,
+	"pages/public/page/index.tmpl":   mockTemplate,
+}
+
+func TestPageController(t *testing.T) {
+	scenarios := map[string]func(t *testing.T, ctrl PageController){
+		"test get admin pages":                       testAdminPagesGet,
+		"test post admin pages":                      testAdminPagesPost,
+		"test post admin pages (error - reserved)":   testAdminPagesPostReserved,
+		"test post admin pages (error - service)":    testAdminPagesPostServiceError,
+		"test post admin pages (error - bad parent)": testAdminPagesPostBadParent,
+		"test delete admin page":                     testDeleteAdminPagePost,
+		"test delete admin page (error - children)":  testDeleteAdminPagePostHasChildren,
+		"test get public page":                       testPublicGetPage,
+		"test get public page (error - not found)":   testPublicGetPageNotFound,
+	}
+
+	for scenario, fn := range scenarios {
+		t.Run(scenario, func(t *testing.T) {
+			ctrl := NewPageController(mockService, PageControllerConfig{
+				ErrorHandlers:  mockErrorHandlers,
+				Log:            mockLogger,
+				SessionManager: mockSessionManager,
+				TemplateCache:  mockTemplateCache,
+				CsrfToken: func(r *http.Request) string {
+					return "mock-token"
+				},
+			})
+
+			fn(t, ctrl)
+		})
+	}
+}
+
+func testAdminPagesGet(t *testing.T, ctrl PageController) {
+	req, err := http.NewRequest("GET", "/admin/pages", nil)
+	if err != nil {
+		t.Error("unable to construct request")
+	}
+
+	req = req.WithContext(
+		context.WithValue(req.Context(), session.IS_LOGGED_IN_CONTEXT_KEY, true),
+	)
+
+	rr := httptest.NewRecorder()
+
+	pages := &[]PageResponseDto{
+		{Id: 1, Title: "About", Slug: "about", Template: "index", Path: "about"},
+	}
+
+	mockServiceGetAll := mockService.On("GetAll").Return(pages, nil)
+
+	mockSessionManagerPopString := mockSessionManager.
+		On("PopString", req.Context(), "message").
+		Return("session_message")
+
+	mockTemplateExecuteTemplate := mockTemplate.
+		On("ExecuteTemplate", rr, "admin", PagesView{
+			Message:         "session_message",
+			Pages:           pages,
+			CsrfToken:       "mock-token",
+			IsAuthenticated: true,
+		}).Return(nil)
+
+	handler := http.HandlerFunc(ctrl.AdminPagesGet)
+
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(
+		t,
+		http.StatusOK,
+		rr.Result().StatusCode,
+		"should return status code ok",
+	)
+
+	if res := mockTemplate.AssertExpectations(t); !res {
+		t.Error("should execute template with pages")
+	}
+
+	mockServiceGetAll.Unset()
+	mockSessionManagerPopString.Unset()
+	mockTemplateExecuteTemplate.Unset()
+}
+
+func newPageRequest(t *testing.T, parentId string) *http.Request {
+	form := url.Values{}
+	form.Add("title", "Team")
+	form.Add("slug", "team")
+	form.Add("body", "The team")
+	form.Add("parent_id", parentId)
+	form.Add("template", "default")
+
+	req, err := http.NewRequest(
+		"POST",
+		"/admin/pages",
+		strings.NewReader(form.Encode()),
+	)
+	if err != nil {
+		t.Error("unable to construct request")
+	}
+
+	req.Header.Add("content-type", "application/x-www-form-urlencoded")
+
+	return req
+}
+
+func testAdminPagesPost(t *testing.T, ctrl PageController) {
+	req := newPageRequest(t, "1")
+
+	rr := httptest.NewRecorder()
+
+	parentId := 1
+
+	mockServiceCreate := mockService.On("Create", &PageNewRequestDto{
+		Title:    "Team",
+		Slug:     "team",
+		Body:     "The team",
+		ParentId: &parentId,
+		Template: "default",
+	}).Return(&PageResponseDto{
+		Id:       2,
+		Title:    "Team",
+		Slug:     "team",
+		Body:     "The team",
+		ParentId: &parentId,
+		Template: "default",
+		Path:     "about/team",
+	}, nil)
+
+	mockSessionManagerPut := mockSessionManager.
+		On("Put", req.Context(), "message", "Created page 'Team'.")
+
+	handler := http.HandlerFunc(ctrl.AdminPagesPost)
+
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(
+		t,
+		http.StatusSeeOther,
+		rr.Result().StatusCode,
+		"should return status code see other",
+	)
+
+	require.Equal(
+		t,
+		"/admin/pages",
+		rr.Header().Get("Location"),
+		"should redirect to pages",
+	)
+
+	if res := mockService.AssertExpectations(t); !res {
+		t.Error("should call page service to create page")
+	}
+
+	if res := mockSessionManager.AssertExpectations(t); !res {
+		t.Error("should put message in session")
+	}
+
+	mockServiceCreate.Unset()
+	mockSessionManagerPut.Unset()
+}
+
+func testAdminPagesPostReserved(t *testing.T, ctrl PageController) {
+	req := newPageRequest(t, "")
+
+	rr := httptest.NewRecorder()
+
+	mockServiceCreate := mockService.
+		On("Create", mock.Anything).
+		Return((*PageResponseDto)(nil), ErrReservedSlug)
+
+	mockSessionManagerPut := mockSessionManager.On(
+		"Put",
+		req.Context(),
+		"message",
+		"Unable to save page: that slug is already used by the site, so choose another or a parent page.",
+	)
+
+	handler := http.HandlerFunc(ctrl.AdminPagesPost)
+
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(
+		t,
+		http.StatusSeeOther,
+		rr.Result().StatusCode,
+		"should return status code see other",
+	)
+
+	require.Equal(
+		t,
+		"/admin/pages/new",
+		rr.Header().Get("Location"),
+		"should redirect back to new page form",
+	)
+
+	if res := mockSessionManager.AssertExpectations(t); !res {
+		t.Error("should put message in session")
+	}
+
+	mockServiceCreate.Unset()
+	mockSessionManagerPut.Unset()
+}
+
+func testAdminPagesPostServiceError(t *testing.T, ctrl PageController) {
+	req := newPageRequest(t, "")
+
+	rr := httptest.NewRecorder()
+
+	serviceError := errors.New("service_error")
+
+	mockServiceCreate := mockService.
+		On("Create", mock.Anything).
+		Return((*PageResponseDto)(nil), serviceError)
+
+	mockErrorHandlersInternalServerError := mockErrorHandlers.
+		On("InternalServerError", rr, req, fmt.Errorf("unable to create page: %w", serviceError)).
+		Run(func(args mock.Arguments) {
+			rr.WriteHeader(http.StatusInternalServerError)
+		})
+
+	handler := http.HandlerFunc(ctrl.AdminPagesPost)
+
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(
+		t,
+		http.StatusInternalServerError,
+		rr.Result().StatusCode,
+		"should return status code internal server error",
+	)
+
+	mockServiceCreate.Unset()
+	mockErrorHandlersInternalServerError.Unset()
+}
+
+func testAdminPagesPostBadParent(t *testing.T, ctrl PageController) {
+	req := newPageRequest(t, "about")
+
+	rr := httptest.NewRecorder()
+
+	mockErrorHandlersBadRequest := mockErrorHandlers.
+		On("BadRequest", rr, req).
+		Run(func(args mock.Arguments) {
+			rr.WriteHeader(http.StatusBadRequest)
+		})
+
+	handler := http.HandlerFunc(ctrl.AdminPagesPost)
+
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(
+		t,
+		http.StatusBadRequest,
+		rr.Result().StatusCode,
+		"should return status code bad request",
+	)
+
+	mockErrorHandlersBadRequest.Unset()
+}
+
+func newDeletePageRequest(t *testing.T) *http.Request {
+	form := url.Values{}
+	form.Add("id", "1")
+	form.Add("title", "About")
+
+	req, err := http.NewRequest(
+		"POST",
+		"/admin/pages/about/delete",
+		strings.NewReader(form.Encode()),
+	)
+	if err != nil {
+		t.Error("unable to construct request")
+	}
+
+	req.Header.Add("content-type", "application/x-www-form-urlencoded")
+
+	return req
+}
+
+func testDeleteAdminPagePost(t *testing.T, ctrl PageController) {
+	req := newDeletePageRequest(t)
+
+	rr := httptest.NewRecorder()
+
+	mockServiceDeleteById := mockService.On("DeleteById", 1).Return(nil)
+
+	mockSessionManagerPut := mockSessionManager.
+		On("Put", req.Context(), "message", "Deleted page 'About'.")
+
+	handler := http.HandlerFunc(ctrl.DeleteAdminPagePost)
+
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(
+		t,
+		http.StatusSeeOther,
+		rr.Result().StatusCode,
+		"should return status code see other",
+	)
+
+	if res := mockService.AssertExpectations(t); !res {
+		t.Error("should call page service to delete page")
+	}
+
+	mockServiceDeleteById.Unset()
+	mockSessionManagerPut.Unset()
+}
+
+func testDeleteAdminPagePostHasChildren(t *testing.T, ctrl PageController) {
+	req := newDeletePageRequest(t)
+
+	rr := httptest.NewRecorder()
+
+	mockServiceDeleteById := mockService.
+		On("DeleteById", 1).
+		Return(ErrPageHasChildren)
+
+	mockSessionManagerPut := mockSessionManager.On(
+		"Put",
+		req.Context(),
+		"message",
+		"Unable to delete page 'About': move or delete its children first.",
+	)
+
+	handler := http.HandlerFunc(ctrl.DeleteAdminPagePost)
+
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(
+		t,
+		http.StatusSeeOther,
+		rr.Result().StatusCode,
+		"should return status code see other",
+	)
+
+	if res := mockSessionManager.AssertExpectations(t); !res {
+		t.Error("should put message in session")
+	}
+
+	mockServiceDeleteById.Unset()
+	mockSessionManagerPut.Unset()
+}
+
+func testPublicGetPage(t *testing.T, ctrl PageController) {
+	req, err := http.NewRequest("GET", "/about", nil)
+	if err != nil {
+		t.Error("unable to construct request")
+	}
+
+	rr := httptest.NewRecorder()
+
+	page := &PageResponseDto{
+		Id:       1,
+		Title:    "About",
+		Slug:     "about",
+		Body:     "About *me*",
+		Template: "index",
+		Path:     "about",
+	}
+
+	parentId := 1
+
+	children := &[]PageResponseDto{
+		{Id: 2, Title: "Team", Slug: "team", ParentId: &parentId, Template: "default", Path: "about/team"},
+	}
+
+	mockServiceGetByAttribute := mockService.
+		On("GetByAttribute", "path", "about").
+		Return(page, nil)
+
+	mockServiceGetChildren := mockService.
+		On("GetChildren", 1).
+		Return(children, nil)
+
+	mockTemplateExecuteTemplate := mockTemplate.
+		On("ExecuteTemplate", rr, "public", PageView{
+			Path:     "/about",
+			Page:     page,
+			Content:  template.HTML("<p>About <em>me</em></p>\n"),
+			Children: *children,
+		}).Return(nil)
+
+	handler := http.HandlerFunc(ctrl.PublicGetPage)
+
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(
+		t,
+		http.StatusOK,
+		rr.Result().StatusCode,
+		"should return status code ok",
+	)
+
+	if res := mockTemplate.AssertExpectations(t); !res {
+		t.Error("should execute page's template")
+	}
+
+	mockServiceGetByAttribute.Unset()
+	mockServiceGetChildren.Unset()
+	mockTemplateExecuteTemplate.Unset()
+}
+
+func testPublicGetPageNotFound(t *testing.T, ctrl PageController) {
+	req, err := http.NewRequest("GET", "/missing", nil)
+	if err != nil {
+		t.Error("unable to construct request")
+	}
+
+	rr := httptest.NewRecorder()
+
+	mockServiceGetByAttribute := mockService.
+		On("GetByAttribute", "path", "missing").
+		Return((*PageResponseDto)(nil), errors.New("no rows"))
+
+	mockErrorHandlersNotFound := mockErrorHandlers.
+		On("NotFound", rr, req).
+		Run(func(args mock.Arguments) {
+			rr.WriteHeader(http.StatusNotFound)
+		})
+
+	handler := http.HandlerFunc(ctrl.PublicGetPage)
+
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(
+		t,
+		http.StatusNotFound,
+		rr.Result().StatusCode,
+		"should return status code not found",
+	)
+
+	mockServiceGetByAttribute.Unset()
+	mockErrorHandlersNotFound.Unset()
+}
+
+type MockErrorHandlers struct {
+	mock.Mock
+}
+
+func (e *MockErrorHandlers) NotFound(w http.ResponseWriter, r *http.Request) {
+	e.Called(w, r)
+}
+
+func (e *MockErrorHandlers) InternalServerError(w http.ResponseWriter, r *http.Request, err error) {
+	e.Called(w, r, err)
+}
+
+func (e *MockErrorHandlers) BadRequest(w http.ResponseWriter, r *http.Request) {
+	e.Called(w, r)
+}
+
+func (e *MockErrorHandlers) Forbidden(w http.ResponseWriter, r *http.Request) {
+	e.Called(w, r)
+}
+
+type MockSessionManager struct {
+	mock.Mock
+}
+
+func (s *MockSessionManager) Exists(ctx context.Context, key string) bool {
+	args := s.Called(ctx, key)
+
+	return args.Bool(0)
+}
+
+func (s *MockSessionManager) PopString(ctx context.Context, key string) string {
+	args := s.Called(ctx, key)
+
+	return args.String(0)
+}
+
+func (s *MockSessionManager) GetString(ctx context.Context, key string) string {
+	args := s.Called(ctx, key)
+
+	return args.String(0)
+}
+
+func (s *MockSessionManager) GetInt(ctx context.Context, key string) int {
+	args := s.Called(ctx, key)
+
+	return args.Int(0)
+}
+
+func (s *MockSessionManager) LoadAndSave(next http.Handler) http.Handler {
+	args := s.Called(next)
+
+	return args.Get(0).(http.Handler)
+}
+
+func (s *MockSessionManager) RenewToken(ctx context.Context) error {
+	args := s.Called(ctx)
+
+	return args.Error(0)
+}
+
+func (s *MockSessionManager) Put(
+	ctx context.Context,
+	key string,
+	val interface{},
+) {
+	s.Called(ctx, key, val)
+}
+
+func (s *MockSessionManager) Remove(ctx context.Context, key string) {
+	s.Called(ctx, key)
+}
+
+type MockLogger struct {
+	mock.Mock
+}
+
+func (l *MockLogger) Debug(ctx context.Context, msg string, args ...any) {
+	l.Called(msg, args)
+}
+
+func (l *MockLogger) Info(ctx context.Context, msg string, args ...any) {
+	l.Called(msg, args)
+}
+
+func (l *MockLogger) Warn(ctx context.Context, msg string, args ...any) {
+	l.Called(msg, args)
+}
+
+func (l *MockLogger) Error(ctx context.Context, msg string, args ...any) {
+	l.Called(msg, args)
+}
+
+var mockTemplate = new(MockTemplate)
+
+type MockTemplate struct {
+	mock.Mock
+}
+
+func (t *MockTemplate) ExecuteTemplate(
+	wr io.Writer,
+	name string,
+	data any,
+) error {
+	args := t.Called(wr, name, data)
+
+	return args.Error(0)
+}
+
+type MockPageService struct {
+	mock.Mock
+}
+
+func (m *MockPageService) Create(page *PageNewRequestDto) (*PageResponseDto, error) {
+	args := m.Called(page)
+
+	return args.Get(0).(*PageResponseDto), args.Error(1)
+}
+
+func (m *MockPageService) DeleteById(id int) error {
+	args := m.Called(id)
+
+	return args.Error(0)
+}
+
+func (m *MockPageService) GetAll() (*[]PageResponseDto, error) {
+	args := m.Called()
+
+	return args.Get(0).(*[]PageResponseDto), args.Error(1)
+}
+
+func (m *MockPageService) GetByAttribute(attr, value string) (*PageResponseDto, error) {
+	args := m.Called(attr, value)
+
+	return args.Get(0).(*PageResponseDto), args.Error(1)
+}
+
+func (m *MockPageService) GetChildren(id int) (*[]PageResponseDto, error) {
+	args := m.Called(id)
+
+	return args.Get(0).(*[]PageResponseDto), args.Error(1)
+}
+
+func (m *MockPageService) Update(page *PageUpdateRequestDto) (*PageResponseDto, error) {
+	args := m.Called(page)
+
+	return args.Get(0).(*PageResponseDto), args.Error(1)
+}
@@ -0,0 +1,10 @@
+package page
+
+import "errors"
+
+var (
+	ErrReservedSlug    = errors.New("slug is reserved for top-level pages")
+	ErrParentNotFound  = errors.New("parent page not found")
+	ErrParentCycle     = errors.New("page can't be a child of itself or its children")
+	ErrPageHasChildren = errors.New("page has children")
+)
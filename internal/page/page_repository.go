@@ -0,0 +1,133 @@
+package page
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/nixpig/dunce/db"
+)
+
+type PageRepository interface {
+	Create(page *Page) (*Page, error)
+	DeleteById(id int) error
+	GetAll() (*[]Page, error)
+	GetByAttribute(attr, value string) (*Page, error)
+	Update(page *Page) (*Page, error)
+}
+
+// pagesWithPaths selects pages along with their path, which is the slugs of
+// the page and its parents joined by '/'
+const pagesWithPaths = `with recursive paths_ as (select id_, slug_::text as path_ from pages_ where parent_id_ is null union all select p.id_, paths_.path_ || '/' || p.slug_ from pages_ p inner join paths_ on p.parent_id_ = paths_.id_) select p.id_, p.title_, p.slug_, p.body_, p.parent_id_, p.template_, paths_.path_, p.created_at_, p.updated_at_ from pages_ p inner join paths_ on p.id_ = paths_.id_`
+
+type pagePostgresRepository struct {
+	db db.Dbconn
+}
+
+func NewPagePostgresRepository(db db.Dbconn) pagePostgresRepository {
+	return pagePostgresRepository{
+		db: db,
+	}
+}
+
+func (p pagePostgresRepository) Create(page *Page) (*Page, error) {
+	query := `insert into pages_ (title_, slug_, body_, parent_id_, template_) values ($1, $2, $3, $4, $5) returning id_, title_, slug_, body_, parent_id_, template_, created_at_, updated_at_`
+
+	row := p.db.QueryRow(context.Background(), query, page.Title, page.Slug, page.Body, page.ParentId, page.Template)
+
+	var createdPage Page
+
+	if err := row.Scan(&createdPage.Id, &createdPage.Title, &createdPage.Slug, &createdPage.Body, &createdPage.ParentId, &createdPage.Template, &createdPage.CreatedAt, &createdPage.UpdatedAt); err != nil {
+		return nil, err
+	}
+
+	return &createdPage, nil
+}
+
+func (p pagePostgresRepository) DeleteById(id int) error {
+	query := `delete from pages_ where id_ = $1`
+
+	_, err := p.db.Exec(context.Background(), query, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetAll returns every page ordered by path, so children follow their
+// parents.
+func (p pagePostgresRepository) GetAll() (*[]Page, error) {
+	query := pagesWithPaths + ` order by paths_.path_`
+
+	rows, err := p.db.Query(context.Background(), query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	pages := []Page{}
+
+	for rows.Next() {
+		var page Page
+
+		if err := rows.Scan(&page.Id, &page.Title, &page.Slug, &page.Body, &page.ParentId, &page.Template, &page.Path, &page.CreatedAt, &page.UpdatedAt); err != nil {
+			return nil, err
+		}
+
+		pages = append(pages, page)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return &pages, nil
+}
+
+func (p pagePostgresRepository) GetByAttribute(attr, value string) (*Page, error) {
+	var query string
+	var arg any = value
+
+	switch attr {
+	case "id":
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, err
+		}
+
+		query = pagesWithPaths + ` where p.id_ = $1`
+		arg = id
+	case "slug":
+		query = pagesWithPaths + ` where p.slug_ = $1`
+	case "path":
+		query = pagesWithPaths + ` where paths_.path_ = $1`
+	default:
+		return nil, errors.New("invalid attribute")
+	}
+
+	row := p.db.QueryRow(context.Background(), query, arg)
+
+	var page Page
+
+	if err := row.Scan(&page.Id, &page.Title, &page.Slug, &page.Body, &page.ParentId, &page.Template, &page.Path, &page.CreatedAt, &page.UpdatedAt); err != nil {
+		return nil, err
+	}
+
+	return &page, nil
+}
+
+func (p pagePostgresRepository) Update(page *Page) (*Page, error) {
+	query := `update pages_ set title_ = $2, slug_ = $3, body_ = $4, parent_id_ = $5, template_ = $6, updated_at_ = current_timestamp where id_ = $1 returning id_, title_, slug_, body_, parent_id_, template_, created_at_, updated_at_`
+
+	row := p.db.QueryRow(context.Background(), query, page.Id, page.Title, page.Slug, page.Body, page.ParentId, page.Template)
+
+	var updatedPage Page
+
+	if err := row.Scan(&updatedPage.Id, &updatedPage.Title, &updatedPage.Slug, &updatedPage.Body, &updatedPage.ParentId, &updatedPage.Template, &updatedPage.CreatedAt, &updatedPage.UpdatedAt); err != nil {
+		return nil, err
+	}
+
+	return &updatedPage, nil
+}
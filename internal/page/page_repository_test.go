@@ -0,0 +1,260 @@
+package page
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/pashagolub/pgxmock/v3"
+	"github.com/stretchr/testify/require"
+)
+
+var pageColumns = []string{"id_", "title_", "slug_", "body_", "parent_id_", "template_", "created_at_", "updated_at_"}
+var pageColumnsWithPath = []string{"id_", "title_", "slug_", "body_", "parent_id_", "template_", "path_", "created_at_", "updated_at_"}
+
+func TestPageRepository(t *testing.T) {
+	scenarios := map[string]func(t *testing.T, mock pgxmock.PgxPoolIface, repo PageRepository){
+		"test create page":                          testCreatePage,
+		"test delete page":                          testDeletePage,
+		"test get all pages":                        testGetAllPages,
+		"test get page by path":                     testGetPageByPath,
+		"test get page by id":                       testGetPageById,
+		"test get page by attribute (error - attr)": testGetPageByInvalidAttribute,
+		"test update page":                          testUpdatePage,
+		"test update page (error - db)":             testUpdatePageError,
+	}
+
+	for scenario, fn := range scenarios {
+		t.Run(scenario, func(t *testing.T) {
+			mock, err := pgxmock.NewPool()
+			if err != nil {
+				t.Fatal("unable to create mock db pool")
+			}
+
+			defer mock.Close()
+
+			repo := NewPagePostgresRepository(mock)
+
+			fn(t, mock, repo)
+		})
+	}
+}
+
+func testCreatePage(t *testing.T, mock pgxmock.PgxPoolIface, repo PageRepository) {
+	query := `insert into pages_ (title_, slug_, body_, parent_id_, template_) values ($1, $2, $3, $4, $5) returning id_, title_, slug_, body_, parent_id_, template_, created_at_, updated_at_`
+
+	createdAt := time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC)
+	parentId := 1
+
+	mockRow := mock.
+		NewRows(pageColumns).
+		AddRow(23, "Team", "team", "The team", &parentId, "default", createdAt, createdAt)
+
+	mock.
+		ExpectQuery(regexp.QuoteMeta(query)).
+		WithArgs("Team", "team", "The team", &parentId, "default").
+		WillReturnRows(mockRow)
+
+	got, err := repo.Create(&Page{
+		Title:    "Team",
+		Slug:     "team",
+		Body:     "The team",
+		ParentId: &parentId,
+		Template: "default",
+	})
+
+	require.NoError(t, err, "should not return error")
+	require.Equal(t, &Page{
+		Id:        23,
+		Title:     "Team",
+		Slug:      "team",
+		Body:      "The team",
+		ParentId:  &parentId,
+		Template:  "default",
+		CreatedAt: createdAt,
+		UpdatedAt: createdAt,
+	}, got, "should return created page")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error("unmet expectations")
+	}
+}
+
+func testDeletePage(t *testing.T, mock pgxmock.PgxPoolIface, repo PageRepository) {
+	query := `delete from pages_ where id_ = $1`
+
+	mock.
+		ExpectExec(regexp.QuoteMeta(query)).
+		WithArgs(23).
+		WillReturnResult(pgxmock.NewResult("delete", 1))
+
+	err := repo.DeleteById(23)
+
+	require.NoError(t, err, "should not return error")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error("unmet expectations")
+	}
+}
+
+func testGetAllPages(t *testing.T, mock pgxmock.PgxPoolIface, repo PageRepository) {
+	query := pagesWithPaths + ` order by paths_.path_`
+
+	createdAt := time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC)
+	parentId := 1
+
+	mockRows := mock.
+		NewRows(pageColumnsWithPath).
+		AddRow(1, "About", "about", "About me", (*int)(nil), "index", "about", createdAt, createdAt).
+		AddRow(2, "Team", "team", "The team", &parentId, "default", "about/team", createdAt, createdAt)
+
+	mock.
+		ExpectQuery(regexp.QuoteMeta(query)).
+		WillReturnRows(mockRows)
+
+	got, err := repo.GetAll()
+
+	require.NoError(t, err, "should not return error")
+	require.Equal(t, &[]Page{
+		{Id: 1, Title: "About", Slug: "about", Body: "About me", Template: "index", Path: "about", CreatedAt: createdAt, UpdatedAt: createdAt},
+		{Id: 2, Title: "Team", Slug: "team", Body: "The team", ParentId: &parentId, Template: "default", Path: "about/team", CreatedAt: createdAt, UpdatedAt: createdAt},
+	}, got, "should return pages with paths")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error("unmet expectations")
+	}
+}
+
+func testGetPageByPath(t *testing.T, mock pgxmock.PgxPoolIface, repo PageRepository) {
+	query := pagesWithPaths + ` where paths_.path_ = $1`
+
+	createdAt := time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC)
+	parentId := 1
+
+	mockRow := mock.
+		NewRows(pageColumnsWithPath).
+		AddRow(2, "Team", "team", "The team", &parentId, "default", "about/team", createdAt, createdAt)
+
+	mock.
+		ExpectQuery(regexp.QuoteMeta(query)).
+		WithArgs("about/team").
+		WillReturnRows(mockRow)
+
+	got, err := repo.GetByAttribute("path", "about/team")
+
+	require.NoError(t, err, "should not return error")
+	require.Equal(t, &Page{
+		Id:        2,
+		Title:     "Team",
+		Slug:      "team",
+		Body:      "The team",
+		ParentId:  &parentId,
+		Template:  "default",
+		Path:      "about/team",
+		CreatedAt: createdAt,
+		UpdatedAt: createdAt,
+	}, got, "should return page at path")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error("unmet expectations")
+	}
+}
+
+func testGetPageById(t *testing.T, mock pgxmock.PgxPoolIface, repo PageRepository) {
+	query := pagesWithPaths + ` where p.id_ = $1`
+
+	createdAt := time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC)
+
+	mockRow := mock.
+		NewRows(pageColumnsWithPath).
+		AddRow(1, "About", "about", "About me", (*int)(nil), "index", "about", createdAt, createdAt)
+
+	mock.
+		ExpectQuery(regexp.QuoteMeta(query)).
+		WithArgs(1).
+		WillReturnRows(mockRow)
+
+	got, err := repo.GetByAttribute("id", "1")
+
+	require.NoError(t, err, "should not return error")
+	require.Equal(t, "about", got.Path, "should return page with path")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error("unmet expectations")
+	}
+}
+
+func testGetPageByInvalidAttribute(t *testing.T, mock pgxmock.PgxPoolIface, repo PageRepository) {
+	got, err := repo.GetByAttribute("title", "About")
+
+	require.Nil(t, got, "should not return page")
+	require.EqualError(t, err, "invalid attribute", "should return invalid attribute error")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error("unmet expectations")
+	}
+}
+
+func testUpdatePage(t *testing.T, mock pgxmock.PgxPoolIface, repo PageRepository) {
+	query := `update pages_ set title_ = $2, slug_ = $3, body_ = $4, parent_id_ = $5, template_ = $6, updated_at_ = current_timestamp where id_ = $1 returning id_, title_, slug_, body_, parent_id_, template_, created_at_, updated_at_`
+
+	createdAt := time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC)
+	updatedAt := time.Date(2024, 5, 3, 0, 0, 0, 0, time.UTC)
+
+	mockRow := mock.
+		NewRows(pageColumns).
+		AddRow(1, "About us", "about", "About us", (*int)(nil), "plain", createdAt, updatedAt)
+
+	mock.
+		ExpectQuery(regexp.QuoteMeta(query)).
+		WithArgs(1, "About us", "about", "About us", (*int)(nil), "plain").
+		WillReturnRows(mockRow)
+
+	got, err := repo.Update(&Page{
+		Id:       1,
+		Title:    "About us",
+		Slug:     "about",
+		Body:     "About us",
+		Template: "plain",
+	})
+
+	require.NoError(t, err, "should not return error")
+	require.Equal(t, &Page{
+		Id:        1,
+		Title:     "About us",
+		Slug:      "about",
+		Body:      "About us",
+		Template:  "plain",
+		CreatedAt: createdAt,
+		UpdatedAt: updatedAt,
+	}, got, "should return updated page")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error("unmet expectations")
+	}
+}
+
+func testUpdatePageError(t *testing.T, mock pgxmock.PgxPoolIface, repo PageRepository) {
+	query := `update pages_ set title_ = $2, slug_ = $3, body_ = $4, parent_id_ = $5, template_ = $6, updated_at_ = current_timestamp where id_ = $1 returning id_, title_, slug_, body_, parent_id_, template_, created_at_, updated_at_`
+
+	mock.
+		ExpectQuery(regexp.QuoteMeta(query)).
+		WithArgs(1, "About", "about", "About", (*int)(nil), "default").
+		WillReturnError(errors.New("db_error"))
+
+	got, err := repo.Update(&Page{
+		Id:       1,
+		Title:    "About",
+		Slug:     "about",
+		Body:     "About",
+		Template: "default",
+	})
+
+	require.Nil(t, got, "should not return page")
+	require.EqualError(t, err, "db_error", "should return db error")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error("unmet expectations")
+	}
+}
@@ -0,0 +1,209 @@
+package page
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+type PageService interface {
+	Create(page *PageNewRequestDto) (*PageResponseDto, error)
+	DeleteById(id int) error
+	GetAll() (*[]PageResponseDto, error)
+	GetByAttribute(attr, value string) (*PageResponseDto, error)
+	GetChildren(id int) (*[]PageResponseDto, error)
+	Update(page *PageUpdateRequestDto) (*PageResponseDto, error)
+}
+
+type PageServiceImpl struct {
+	repo     PageRepository
+	validate *validator.Validate
+}
+
+func NewPageService(
+	repo PageRepository,
+	validate *validator.Validate,
+) PageServiceImpl {
+	return PageServiceImpl{
+		repo:     repo,
+		validate: validate,
+	}
+}
+
+func (p PageServiceImpl) Create(page *PageNewRequestDto) (*PageResponseDto, error) {
+	pageToCreate := Page{
+		Title:    page.Title,
+		Slug:     strings.ToLower(page.Slug),
+		Body:     page.Body,
+		ParentId: page.ParentId,
+		Template: templateOrDefault(page.Template),
+	}
+
+	if err := p.validate.Struct(pageToCreate); err != nil {
+		return nil, err
+	}
+
+	if err := p.checkPlacement(&pageToCreate); err != nil {
+		return nil, err
+	}
+
+	createdPage, err := p.repo.Create(&pageToCreate)
+	if err != nil {
+		return nil, err
+	}
+
+	// the path comes from the page's parents, so is read back rather than
+	// built here
+	return p.GetByAttribute("id", strconv.Itoa(createdPage.Id))
+}
+
+// DeleteById deletes a page, as long as it doesn't have children that would
+// be left without a parent.
+func (p PageServiceImpl) DeleteById(id int) error {
+	children, err := p.GetChildren(id)
+	if err != nil {
+		return err
+	}
+
+	if len(*children) > 0 {
+		return ErrPageHasChildren
+	}
+
+	return p.repo.DeleteById(id)
+}
+
+func (p PageServiceImpl) GetAll() (*[]PageResponseDto, error) {
+	pages, err := p.repo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	pagesResponse := make([]PageResponseDto, len(*pages))
+
+	for index, page := range *pages {
+		pagesResponse[index] = toResponse(page)
+	}
+
+	return &pagesResponse, nil
+}
+
+func (p PageServiceImpl) GetByAttribute(attr, value string) (*PageResponseDto, error) {
+	page, err := p.repo.GetByAttribute(attr, value)
+	if err != nil {
+		return nil, err
+	}
+
+	pageResponse := toResponse(*page)
+
+	return &pageResponse, nil
+}
+
+// GetChildren returns the pages directly below the page with id.
+func (p PageServiceImpl) GetChildren(id int) (*[]PageResponseDto, error) {
+	pages, err := p.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	children := []PageResponseDto{}
+
+	for _, page := range *pages {
+		if page.IsChildOf(id) {
+			children = append(children, page)
+		}
+	}
+
+	return &children, nil
+}
+
+func (p PageServiceImpl) Update(page *PageUpdateRequestDto) (*PageResponseDto, error) {
+	pageToUpdate := Page{
+		Id:       page.Id,
+		Title:    page.Title,
+		Slug:     strings.ToLower(page.Slug),
+		Body:     page.Body,
+		ParentId: page.ParentId,
+		Template: templateOrDefault(page.Template),
+	}
+
+	if err := p.validate.Struct(pageToUpdate); err != nil {
+		return nil, err
+	}
+
+	if err := p.checkPlacement(&pageToUpdate); err != nil {
+		return nil, err
+	}
+
+	updatedPage, err := p.repo.Update(&pageToUpdate)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.GetByAttribute("id", strconv.Itoa(updatedPage.Id))
+}
+
+// checkPlacement makes sure a top-level page doesn't take a slug the rest of
+// the site is served at, and a child page's parent exists and isn't the page
+// itself or one of its children.
+func (p PageServiceImpl) checkPlacement(page *Page) error {
+	if page.ParentId == nil {
+		if isReserved(page.Slug) {
+			return ErrReservedSlug
+		}
+
+		return nil
+	}
+
+	pages, err := p.repo.GetAll()
+	if err != nil {
+		return err
+	}
+
+	byId := make(map[int]Page, len(*pages))
+	for _, existing := range *pages {
+		byId[existing.Id] = existing
+	}
+
+	ancestor, ok := byId[*page.ParentId]
+	if !ok {
+		return ErrParentNotFound
+	}
+
+	for {
+		if page.Id != 0 && ancestor.Id == page.Id {
+			return ErrParentCycle
+		}
+
+		if ancestor.ParentId == nil {
+			return nil
+		}
+
+		ancestor, ok = byId[*ancestor.ParentId]
+		if !ok {
+			return nil
+		}
+	}
+}
+
+func templateOrDefault(template string) string {
+	if template == "" {
+		return DefaultTemplate
+	}
+
+	return template
+}
+
+func toResponse(page Page) PageResponseDto {
+	return PageResponseDto{
+		Id:        page.Id,
+		Title:     page.Title,
+		Slug:      page.Slug,
+		Body:      page.Body,
+		ParentId:  page.ParentId,
+		Template:  page.Template,
+		Path:      page.Path,
+		CreatedAt: page.CreatedAt,
+		UpdatedAt: page.UpdatedAt,
+	}
+}
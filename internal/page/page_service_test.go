@@ -0,0 +1,354 @@
+package page
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/nixpig/dunce/pkg/validation"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var mockRepo = new(MockPageRepository)
+
+func TestPageService(t *testing.T) {
+	scenarios := map[string]func(t *testing.T, service PageService){
+		"test create page":                          testPageServiceCreate,
+		"test create child page":                    testPageServiceCreateChild,
+		"test create page (error - invalid)":        testPageServiceCreateInvalid,
+		"test create page (error - reserved slug)":  testPageServiceCreateReserved,
+		"test create page (error - missing parent)": testPageServiceCreateParentNotFound,
+		"test update page (error - parent cycle)":   testPageServiceUpdateCycle,
+		"test update page":                          testPageServiceUpdate,
+		"test get children":                         testPageServiceGetChildren,
+		"test delete page":                          testPageServiceDelete,
+		"test delete page (error - has children)":   testPageServiceDeleteHasChildren,
+		"test get page by attribute (error - repo)": testPageServiceGetByAttributeError,
+	}
+
+	validator, err := validation.NewValidator()
+	if err != nil {
+		t.Fatal("unable to create validator")
+	}
+
+	for scenario, fn := range scenarios {
+		t.Run(scenario, func(t *testing.T) {
+			service := NewPageService(mockRepo, validator)
+
+			fn(t, service)
+		})
+	}
+}
+
+type MockPageRepository struct {
+	mock.Mock
+}
+
+func (m *MockPageRepository) Create(page *Page) (*Page, error) {
+	args := m.Called(page)
+
+	return args.Get(0).(*Page), args.Error(1)
+}
+
+func (m *MockPageRepository) DeleteById(id int) error {
+	args := m.Called(id)
+
+	return args.Error(0)
+}
+
+func (m *MockPageRepository) GetAll() (*[]Page, error) {
+	args := m.Called()
+
+	return args.Get(0).(*[]Page), args.Error(1)
+}
+
+func (m *MockPageRepository) GetByAttribute(attr, value string) (*Page, error) {
+	args := m.Called(attr, value)
+
+	return args.Get(0).(*Page), args.Error(1)
+}
+
+func (m *MockPageRepository) Update(page *Page) (*Page, error) {
+	args := m.Called(page)
+
+	return args.Get(0).(*Page), args.Error(1)
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+// pageTree is about > team > people, with contact alongside about.
+var pageTree = &[]Page{
+	{Id: 1, Title: "About", Slug: "about", Body: "About", Template: "index", Path: "about"},
+	{Id: 2, Title: "Team", Slug: "team", Body: "Team", ParentId: intPtr(1), Template: "index", Path: "about/team"},
+	{Id: 3, Title: "People", Slug: "people", Body: "People", ParentId: intPtr(2), Template: "default", Path: "about/team/people"},
+	{Id: 4, Title: "Contact", Slug: "contact", Body: "Contact", Template: "plain", Path: "contact"},
+}
+
+func testPageServiceCreate(t *testing.T, service PageService) {
+	createdAt := time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC)
+
+	mockRepoCreate := mockRepo.On("Create", &Page{
+		Title:    "About",
+		Slug:     "about",
+		Body:     "About me",
+		Template: "default",
+	}).Return(&Page{
+		Id:        1,
+		Title:     "About",
+		Slug:      "about",
+		Body:      "About me",
+		Template:  "default",
+		CreatedAt: createdAt,
+		UpdatedAt: createdAt,
+	}, nil)
+
+	mockRepoGetByAttribute := mockRepo.On("GetByAttribute", "id", "1").Return(&Page{
+		Id:        1,
+		Title:     "About",
+		Slug:      "about",
+		Body:      "About me",
+		Template:  "default",
+		Path:      "about",
+		CreatedAt: createdAt,
+		UpdatedAt: createdAt,
+	}, nil)
+
+	got, err := service.Create(&PageNewRequestDto{
+		Title: "About",
+		Slug:  "About",
+		Body:  "About me",
+	})
+
+	require.NoError(t, err, "should not return error")
+	require.Equal(t, &PageResponseDto{
+		Id:        1,
+		Title:     "About",
+		Slug:      "about",
+		Body:      "About me",
+		Template:  "default",
+		Path:      "about",
+		CreatedAt: createdAt,
+		UpdatedAt: createdAt,
+	}, got, "should return created page with path")
+
+	if res := mockRepo.AssertExpectations(t); !res {
+		t.Error("should create page and read back its path")
+	}
+
+	mockRepoCreate.Unset()
+	mockRepoGetByAttribute.Unset()
+}
+
+func testPageServiceCreateChild(t *testing.T, service PageService) {
+	mockRepoGetAll := mockRepo.On("GetAll").Return(pageTree, nil)
+
+	// reserved slugs are only reserved at the top level
+	mockRepoCreate := mockRepo.On("Create", &Page{
+		Title:    "Tags",
+		Slug:     "tags",
+		Body:     "How we tag things",
+		ParentId: intPtr(4),
+		Template: "plain",
+	}).Return(&Page{Id: 5}, nil)
+
+	mockRepoGetByAttribute := mockRepo.On("GetByAttribute", "id", "5").Return(&Page{
+		Id:       5,
+		Title:    "Tags",
+		Slug:     "tags",
+		Body:     "How we tag things",
+		ParentId: intPtr(4),
+		Template: "plain",
+		Path:     "contact/tags",
+	}, nil)
+
+	got, err := service.Create(&PageNewRequestDto{
+		Title:    "Tags",
+		Slug:     "tags",
+		Body:     "How we tag things",
+		ParentId: intPtr(4),
+		Template: "plain",
+	})
+
+	require.NoError(t, err, "should not return error")
+	require.Equal(t, "contact/tags", got.Path, "should return path below parent")
+	require.Equal(t, "/contact/tags", got.Url(), "should return url below parent")
+
+	if res := mockRepo.AssertExpectations(t); !res {
+		t.Error("should check parent and create page")
+	}
+
+	mockRepoGetAll.Unset()
+	mockRepoCreate.Unset()
+	mockRepoGetByAttribute.Unset()
+}
+
+func testPageServiceCreateInvalid(t *testing.T, service PageService) {
+	got, err := service.Create(&PageNewRequestDto{
+		Title:    "About",
+		Slug:     "about us",
+		Body:     "About me",
+		Template: "fancy",
+	})
+
+	require.Nil(t, got, "should not return page")
+	require.IsType(t, validator.ValidationErrors{}, err, "should return validation errors")
+}
+
+func testPageServiceCreateReserved(t *testing.T, service PageService) {
+	got, err := service.Create(&PageNewRequestDto{
+		Title: "Articles",
+		Slug:  "articles",
+		Body:  "All my articles",
+	})
+
+	require.Nil(t, got, "should not return page")
+	require.Equal(t, ErrReservedSlug, err, "should return reserved slug error")
+}
+
+func testPageServiceCreateParentNotFound(t *testing.T, service PageService) {
+	mockRepoGetAll := mockRepo.On("GetAll").Return(pageTree, nil)
+
+	got, err := service.Create(&PageNewRequestDto{
+		Title:    "History",
+		Slug:     "history",
+		Body:     "Our history",
+		ParentId: intPtr(23),
+	})
+
+	require.Nil(t, got, "should not return page")
+	require.Equal(t, ErrParentNotFound, err, "should return parent not found error")
+
+	mockRepoGetAll.Unset()
+}
+
+func testPageServiceUpdateCycle(t *testing.T, service PageService) {
+	mockRepoGetAll := mockRepo.On("GetAll").Return(pageTree, nil)
+
+	got, err := service.Update(&PageUpdateRequestDto{
+		Id:       1,
+		Title:    "About",
+		Slug:     "about",
+		Body:     "About",
+		ParentId: intPtr(3),
+		Template: "index",
+	})
+
+	require.Nil(t, got, "should not return page")
+	require.Equal(t, ErrParentCycle, err, "should not move page below its own children")
+
+	got, err = service.Update(&PageUpdateRequestDto{
+		Id:       1,
+		Title:    "About",
+		Slug:     "about",
+		Body:     "About",
+		ParentId: intPtr(1),
+		Template: "index",
+	})
+
+	require.Nil(t, got, "should not return page")
+	require.Equal(t, ErrParentCycle, err, "should not move page below itself")
+
+	mockRepoGetAll.Unset()
+}
+
+func testPageServiceUpdate(t *testing.T, service PageService) {
+	mockRepoGetAll := mockRepo.On("GetAll").Return(pageTree, nil)
+
+	mockRepoUpdate := mockRepo.On("Update", &Page{
+		Id:       3,
+		Title:    "People",
+		Slug:     "people",
+		Body:     "Everyone",
+		ParentId: intPtr(4),
+		Template: "default",
+	}).Return(&Page{Id: 3}, nil)
+
+	mockRepoGetByAttribute := mockRepo.On("GetByAttribute", "id", "3").Return(&Page{
+		Id:       3,
+		Title:    "People",
+		Slug:     "people",
+		Body:     "Everyone",
+		ParentId: intPtr(4),
+		Template: "default",
+		Path:     "contact/people",
+	}, nil)
+
+	got, err := service.Update(&PageUpdateRequestDto{
+		Id:       3,
+		Title:    "People",
+		Slug:     "people",
+		Body:     "Everyone",
+		ParentId: intPtr(4),
+	})
+
+	require.NoError(t, err, "should not return error")
+	require.Equal(t, "contact/people", got.Path, "should return new path")
+
+	if res := mockRepo.AssertExpectations(t); !res {
+		t.Error("should update page and read back its path")
+	}
+
+	mockRepoGetAll.Unset()
+	mockRepoUpdate.Unset()
+	mockRepoGetByAttribute.Unset()
+}
+
+func testPageServiceGetChildren(t *testing.T, service PageService) {
+	mockRepoGetAll := mockRepo.On("GetAll").Return(pageTree, nil)
+
+	got, err := service.GetChildren(1)
+
+	require.NoError(t, err, "should not return error")
+	require.Len(t, *got, 1, "should only return direct children")
+	require.Equal(t, "about/team", (*got)[0].Path, "should return child page")
+
+	got, err = service.GetChildren(4)
+
+	require.NoError(t, err, "should not return error")
+	require.Empty(t, *got, "should return no children")
+
+	mockRepoGetAll.Unset()
+}
+
+func testPageServiceDelete(t *testing.T, service PageService) {
+	mockRepoGetAll := mockRepo.On("GetAll").Return(pageTree, nil)
+	mockRepoDeleteById := mockRepo.On("DeleteById", 4).Return(nil)
+
+	err := service.DeleteById(4)
+
+	require.NoError(t, err, "should not return error")
+
+	if res := mockRepo.AssertExpectations(t); !res {
+		t.Error("should delete page")
+	}
+
+	mockRepoGetAll.Unset()
+	mockRepoDeleteById.Unset()
+}
+
+func testPageServiceDeleteHasChildren(t *testing.T, service PageService) {
+	mockRepoGetAll := mockRepo.On("GetAll").Return(pageTree, nil)
+
+	err := service.DeleteById(2)
+
+	require.Equal(t, ErrPageHasChildren, err, "should return has children error")
+
+	mockRepoGetAll.Unset()
+}
+
+func testPageServiceGetByAttributeError(t *testing.T, service PageService) {
+	mockRepoGetByAttribute := mockRepo.
+		On("GetByAttribute", "path", "missing").
+		Return((*Page)(nil), errors.New("repo_error"))
+
+	got, err := service.GetByAttribute("path", "missing")
+
+	require.Nil(t, got, "should not return page")
+	require.EqualError(t, err, "repo_error", "should return repo error")
+
+	mockRepoGetByAttribute.Unset()
+}
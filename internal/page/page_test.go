@@ -0,0 +1,43 @@
+package page
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPage(t *testing.T) {
+	scenarios := map[string]func(t *testing.T){
+		"test page url":         testPageUrl,
+		"test page is child of": testPageIsChildOf,
+		"test get template":     testGetTemplate,
+	}
+
+	for scenario, fn := range scenarios {
+		t.Run(scenario, func(t *testing.T) {
+			fn(t)
+		})
+	}
+}
+
+func testPageUrl(t *testing.T) {
+	require.Equal(t, "/about", PageResponseDto{Path: "about"}.Url())
+	require.Equal(t, "/about/team", PageResponseDto{Path: "about/team"}.Url())
+}
+
+func testPageIsChildOf(t *testing.T) {
+	parentId := 1
+
+	require.True(t, PageResponseDto{ParentId: &parentId}.IsChildOf(1), "should be child of parent")
+	require.False(t, PageResponseDto{ParentId: &parentId}.IsChildOf(2), "should not be child of other page")
+	require.False(t, PageResponseDto{}.IsChildOf(1), "top-level page should not be child of any page")
+}
+
+func testGetTemplate(t *testing.T) {
+	index, ok := GetTemplate("index")
+	require.True(t, ok, "should find template")
+	require.Equal(t, "pages/public/page/index.tmpl", index.File(), "should return template file")
+
+	_, ok = GetTemplate("fancy")
+	require.False(t, ok, "should not find unknown template")
+}
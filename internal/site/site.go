@@ -1,9 +1,147 @@
 package site
 
+import (
+	"errors"
+	"fmt"
+	"html/template"
+)
+
+// Key identifies a site setting stored in site_.
+type Key string
+
+const (
+	NameKey    Key = "name"
+	TaglineKey Key = "tagline"
+	DomainKey  Key = "domain"
+	FooterKey  Key = "footer"
+
+	RequireTwoFactorKey Key = "require_two_factor"
+)
+
+// Setting describes a site setting: how it's shown on the admin settings page,
+// the value used until it's set, and the validator tag its value must pass.
+type Setting struct {
+	Key         Key
+	Label       string
+	Description string
+	Multiline   bool
+	Checkbox    bool
+	Default     string
+	Validation  string
+}
+
+// Settings are all the settings a site has, in the order they're shown.
+var Settings = []Setting{
+	{
+		Key:         NameKey,
+		Label:       "Name",
+		Description: "Shown in the header, page titles and feeds.",
+		Default:     "dunce",
+		Validation:  "required,max=100",
+	},
+	{
+		Key:         TaglineKey,
+		Label:       "Tagline",
+		Description: "Shown under the name and used as the feed description.",
+		Validation:  "max=255",
+	},
+	{
+		Key:         DomainKey,
+		Label:       "Domain",
+		Description: "Used for absolute links in feeds and the sitemap, e.g. example.com. Links use the server's base url when empty, and feeds and the sitemap aren't available if that isn't configured either.",
+		Validation:  "omitempty,fqdn",
+	},
+	{
+		Key:         FooterKey,
+		Label:       "Footer",
+		Description: "Shown at the bottom of every page.",
+		Multiline:   true,
+		Validation:  "max=1000",
+	},
+	{
+		Key:         RequireTwoFactorKey,
+		Label:       "Require two-factor authentication",
+		Description: "Everyone has to set up two-factor authentication before they can use the admin.",
+		Checkbox:    true,
+		Default:     "false",
+		Validation:  "oneof=true false",
+	},
+}
+
+// GetSetting returns the setting for key, or false if there's no such setting.
+func GetSetting(key Key) (Setting, bool) {
+	for _, setting := range Settings {
+		if setting.Key == key {
+			return setting, true
+		}
+	}
+
+	return Setting{}, false
+}
+
 type Site struct {
-	Name    string
-	Tagline string
-	Domain  string
+	Name             string
+	Tagline          string
+	Domain           string
+	Footer           string
+	RequireTwoFactor bool
+}
+
+// Defaults is the site before any of its settings have been set.
+func Defaults() Site {
+	var site Site
+
+	for _, setting := range Settings {
+		site.set(setting.Key, setting.Default)
+	}
+
+	return site
+}
+
+func (s *Site) set(key Key, value string) {
+	switch key {
+	case NameKey:
+		s.Name = value
+	case TaglineKey:
+		s.Tagline = value
+	case DomainKey:
+		s.Domain = value
+	case FooterKey:
+		s.Footer = value
+	case RequireTwoFactorKey:
+		s.RequireTwoFactor = value == "true"
+	}
+}
+
+// ErrNoUrl is returned when an absolute link is needed, but the site has no
+// domain set and no base URL is configured to make it from.
+var ErrNoUrl = errors.New("site has no domain set and no base url is configured")
+
+// Url is the absolute base URL of the site, from its domain if one is set or
+// fallback otherwise.
+func (s Site) Url(fallback string) string {
+	if s.Domain != "" {
+		return "https://" + s.Domain
+	}
+
+	return fallback
+}
+
+// TemplateFuncs gives templates the site through a site function, so every
+// page can show its name, tagline and footer without each view carrying it.
+// The defaults are shown if the settings can't be read, rather than failing
+// the whole page.
+func TemplateFuncs(service SiteService) template.FuncMap {
+	return template.FuncMap{
+		"site": func() Site {
+			site, err := service.Get()
+			if err != nil {
+				return Defaults()
+			}
+
+			return *site
+		},
+	}
 }
 
 type SiteKv struct {
@@ -17,3 +155,31 @@ type SiteItemResponseDto struct {
 	Key   string
 	Value string
 }
+
+// SettingResponseDto is a setting along with its current value, which is its
+// default unless IsSet.
+type SettingResponseDto struct {
+	Setting
+	Value string
+	IsSet bool
+}
+
+// InvalidSettingError is returned when a value doesn't pass its setting's
+// validation, or there's no setting for the key.
+type InvalidSettingError struct {
+	Key   Key
+	Label string
+	Err   error
+}
+
+func (e *InvalidSettingError) Error() string {
+	if e.Label == "" {
+		return fmt.Sprintf("unknown setting '%s'", e.Key)
+	}
+
+	return fmt.Sprintf("invalid value for '%s'", e.Label)
+}
+
+func (e *InvalidSettingError) Unwrap() error {
+	return e.Err
+}
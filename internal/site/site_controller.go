@@ -1,7 +1,9 @@
 package site
 
 import (
+	"fmt"
 	"net/http"
+	"strconv"
 
 	"github.com/nixpig/dunce/internal/app/errors"
 	"github.com/nixpig/dunce/pkg/logging"
@@ -26,9 +28,9 @@ type SiteControllerConfig struct {
 	ErrorHandlers  errors.ErrorHandlers
 }
 
-type SiteItemsView struct {
+type SiteSettingsView struct {
 	Message         string
-	SiteItems       *[]SiteItemResponseDto
+	Settings        *[]SettingResponseDto
 	CsrfToken       string
 	IsAuthenticated bool
 }
@@ -44,9 +46,90 @@ func NewSiteController(service SiteService, config SiteControllerConfig) SiteCon
 	}
 }
 
-func (s *SiteController) GetCreateSiteItems(w http.ResponseWriter, r *http.Request) {
-	if err := s.templates["pages/admin/site.tmpl"].ExecuteTemplate(w, "admin", nil); err != nil {
-		s.errorHandlers.InternalServerError(w, r)
+func (s *SiteController) SiteSettingsGet(w http.ResponseWriter, r *http.Request) {
+	settings, err := s.service.GetAll()
+	if err != nil {
+		s.errorHandlers.InternalServerError(w, r, err)
 		return
 	}
+
+	message := s.session.PopString(r.Context(), session.SESSION_KEY_MESSAGE)
+
+	if err := s.templates["pages/admin/site.tmpl"].ExecuteTemplate(w, "admin", SiteSettingsView{
+		Message:         message,
+		Settings:        settings,
+		CsrfToken:       s.csrfToken(r),
+		IsAuthenticated: s.isAuthenticated(r),
+	}); err != nil {
+		s.errorHandlers.InternalServerError(w, r, err)
+		return
+	}
+}
+
+func (s *SiteController) SiteSettingsPost(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		s.errorHandlers.BadRequest(w, r)
+		return
+	}
+
+	values := map[Key]string{}
+
+	for _, setting := range Settings {
+		// unticked checkboxes aren't posted at all
+		if setting.Checkbox {
+			values[setting.Key] = strconv.FormatBool(r.PostForm.Get(string(setting.Key)) == "true")
+			continue
+		}
+
+		if r.PostForm.Has(string(setting.Key)) {
+			values[setting.Key] = r.PostForm.Get(string(setting.Key))
+		}
+	}
+
+	if err := s.service.Update(values); err != nil {
+		if invalid, ok := err.(*InvalidSettingError); ok {
+			s.session.Put(r.Context(), session.SESSION_KEY_MESSAGE, fmt.Sprintf("Unable to save settings: %s.", invalid.Error()))
+			http.Redirect(w, r, "/admin/site", http.StatusSeeOther)
+			return
+		}
+
+		s.errorHandlers.InternalServerError(w, r, fmt.Errorf("unable to update site settings: %w", err))
+		return
+	}
+
+	s.session.Put(r.Context(), session.SESSION_KEY_MESSAGE, "Saved settings.")
+
+	http.Redirect(w, r, "/admin/site", http.StatusSeeOther)
+}
+
+func (s *SiteController) DeleteSiteSettingPost(w http.ResponseWriter, r *http.Request) {
+	key := Key(r.PathValue("key"))
+
+	setting, ok := GetSetting(key)
+	if !ok {
+		s.errorHandlers.NotFound(w, r)
+		return
+	}
+
+	if err := s.service.DeleteByKey(key); err != nil {
+		s.errorHandlers.InternalServerError(w, r, err)
+		return
+	}
+
+	s.session.Put(
+		r.Context(),
+		session.SESSION_KEY_MESSAGE,
+		fmt.Sprintf("Reset '%s' to its default.", setting.Label),
+	)
+
+	http.Redirect(w, r, "/admin/site", http.StatusSeeOther)
+}
+
+func (s *SiteController) isAuthenticated(r *http.Request) bool {
+	isAuthenticated, ok := r.Context().Value(session.IS_LOGGED_IN_CONTEXT_KEY).(bool)
+	if !ok {
+		return false
+	}
+
+	return isAuthenticated
 }
@@ -2,12 +2,19 @@ package site
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
 
+	"github.com/nixpig/dunce/pkg/session"
 	"github.com/nixpig/dunce/pkg/templates"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 var mockLogger = new(MockLogger)
@@ -20,7 +27,13 @@ var mockTemplateCache = templates.TemplateCache{
 
 func TestSiteController(t *testing.T) {
 	scenarios := map[string]func(t *testing.T, ctrl SiteController){
-		"test create site item view": testSiteControllerViewCreateItem,
+		"test get site settings":                         testSiteControllerSettingsGet,
+		"test get site settings (error - service)":       testSiteControllerSettingsGetServiceError,
+		"test post site settings":                        testSiteControllerSettingsPost,
+		"test post site settings (error - invalid)":      testSiteControllerSettingsPostInvalid,
+		"test post site settings (error - service)":      testSiteControllerSettingsPostServiceError,
+		"test delete site setting":                       testSiteControllerDeleteSetting,
+		"test delete site setting (error - unknown key)": testSiteControllerDeleteSettingUnknown,
 	}
 
 	for scenario, fn := range scenarios {
@@ -40,8 +53,314 @@ func TestSiteController(t *testing.T) {
 	}
 }
 
-func testSiteControllerViewCreateItem(t *testing.T, ctrl SiteController) {
+func testSiteControllerSettingsGet(t *testing.T, ctrl SiteController) {
+	req, err := http.NewRequest("GET", "/admin/site", nil)
+	if err != nil {
+		t.Error("unable to construct request")
+	}
+
+	req = req.WithContext(
+		context.WithValue(req.Context(), session.IS_LOGGED_IN_CONTEXT_KEY, true),
+	)
+
+	rr := httptest.NewRecorder()
+
+	settings := &[]SettingResponseDto{
+		{Setting: Settings[0], Value: "Site name", IsSet: true},
+		{Setting: Settings[1], Value: "", IsSet: false},
+	}
+
+	mockServiceGetAll := mockService.On("GetAll").Return(settings, nil)
+
+	mockSessionManagerPopString := mockSessionManager.
+		On("PopString", req.Context(), "message").
+		Return("session_message")
+
+	mockTemplateExecuteTemplate := mockTemplate.
+		On("ExecuteTemplate", rr, "admin", SiteSettingsView{
+			Message:         "session_message",
+			Settings:        settings,
+			CsrfToken:       "mock-token",
+			IsAuthenticated: true,
+		}).Return(nil)
+
+	handler := http.HandlerFunc(ctrl.SiteSettingsGet)
+
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(
+		t,
+		http.StatusOK,
+		rr.Result().StatusCode,
+		"should return status code ok",
+	)
+
+	if res := mockService.AssertExpectations(t); !res {
+		t.Error("should call site service to get all settings")
+	}
+
+	if res := mockTemplate.AssertExpectations(t); !res {
+		t.Error("should execute template with settings")
+	}
+
+	mockServiceGetAll.Unset()
+	mockSessionManagerPopString.Unset()
+	mockTemplateExecuteTemplate.Unset()
+}
+
+func testSiteControllerSettingsGetServiceError(t *testing.T, ctrl SiteController) {
+	req, err := http.NewRequest("GET", "/admin/site", nil)
+	if err != nil {
+		t.Error("unable to construct request")
+	}
+
+	rr := httptest.NewRecorder()
+
+	mockServiceGetAll := mockService.
+		On("GetAll").
+		Return((*[]SettingResponseDto)(nil), errors.New("service_error"))
+
+	mockErrorHandlersInternalServerError := mockErrorHandlers.
+		On("InternalServerError", rr, req, errors.New("service_error")).
+		Run(func(args mock.Arguments) {
+			rr.WriteHeader(http.StatusInternalServerError)
+		})
+
+	handler := http.HandlerFunc(ctrl.SiteSettingsGet)
+
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(
+		t,
+		http.StatusInternalServerError,
+		rr.Result().StatusCode,
+		"should return status code internal server error",
+	)
+
+	if res := mockErrorHandlers.AssertExpectations(t); !res {
+		t.Error("should call error handler")
+	}
+
+	mockServiceGetAll.Unset()
+	mockErrorHandlersInternalServerError.Unset()
+}
+
+func newSettingsRequest(t *testing.T) *http.Request {
+	form := url.Values{}
+	form.Add("name", "Site name")
+	form.Add("domain", "example.com")
+	form.Add("colour", "blue")
+
+	req, err := http.NewRequest(
+		"POST",
+		"/admin/site",
+		strings.NewReader(form.Encode()),
+	)
+	if err != nil {
+		t.Error("unable to construct request")
+	}
+
+	req.Header.Add("content-type", "application/x-www-form-urlencoded")
+
+	return req
+}
+
+func testSiteControllerSettingsPost(t *testing.T, ctrl SiteController) {
+	req := newSettingsRequest(t)
+
+	rr := httptest.NewRecorder()
+
+	mockServiceUpdate := mockService.On("Update", map[Key]string{
+		NameKey:             "Site name",
+		DomainKey:           "example.com",
+		RequireTwoFactorKey: "false",
+	}).Return(nil)
+
+	mockSessionManagerPut := mockSessionManager.
+		On("Put", req.Context(), "message", "Saved settings.")
+
+	handler := http.HandlerFunc(ctrl.SiteSettingsPost)
+
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(
+		t,
+		http.StatusSeeOther,
+		rr.Result().StatusCode,
+		"should return status code see other",
+	)
+	require.Equal(
+		t,
+		"/admin/site",
+		rr.Result().Header.Get("Location"),
+		"should redirect to site settings",
+	)
+
+	if res := mockService.AssertExpectations(t); !res {
+		t.Error("should call through to site service with known settings")
+	}
+
+	if res := mockSessionManager.AssertExpectations(t); !res {
+		t.Error("should put message in session")
+	}
+
+	mockServiceUpdate.Unset()
+	mockSessionManagerPut.Unset()
+}
+
+func testSiteControllerSettingsPostInvalid(t *testing.T, ctrl SiteController) {
+	req := newSettingsRequest(t)
+
+	rr := httptest.NewRecorder()
+
+	mockServiceUpdate := mockService.On("Update", map[Key]string{
+		NameKey:             "Site name",
+		DomainKey:           "example.com",
+		RequireTwoFactorKey: "false",
+	}).Return(&InvalidSettingError{Key: DomainKey, Label: "Domain"})
+
+	mockSessionManagerPut := mockSessionManager.On(
+		"Put",
+		req.Context(),
+		"message",
+		"Unable to save settings: invalid value for 'Domain'.",
+	)
+
+	handler := http.HandlerFunc(ctrl.SiteSettingsPost)
+
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(
+		t,
+		http.StatusSeeOther,
+		rr.Result().StatusCode,
+		"should return status code see other",
+	)
+
+	if res := mockSessionManager.AssertExpectations(t); !res {
+		t.Error("should put validation message in session")
+	}
+
+	mockServiceUpdate.Unset()
+	mockSessionManagerPut.Unset()
+}
+
+func testSiteControllerSettingsPostServiceError(t *testing.T, ctrl SiteController) {
+	req := newSettingsRequest(t)
+
+	rr := httptest.NewRecorder()
+
+	mockServiceUpdate := mockService.On("Update", map[Key]string{
+		NameKey:             "Site name",
+		DomainKey:           "example.com",
+		RequireTwoFactorKey: "false",
+	}).Return(errors.New("service_error"))
+
+	mockErrorHandlersInternalServerError := mockErrorHandlers.
+		On("InternalServerError", rr, req, fmt.Errorf("unable to update site settings: %w", errors.New("service_error"))).
+		Run(func(args mock.Arguments) {
+			rr.WriteHeader(http.StatusInternalServerError)
+		})
+
+	handler := http.HandlerFunc(ctrl.SiteSettingsPost)
+
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(
+		t,
+		http.StatusInternalServerError,
+		rr.Result().StatusCode,
+		"should return status code internal server error",
+	)
+
+	if res := mockErrorHandlers.AssertExpectations(t); !res {
+		t.Error("should call error handler")
+	}
+
+	mockServiceUpdate.Unset()
+	mockErrorHandlersInternalServerError.Unset()
+}
+
+func testSiteControllerDeleteSetting(t *testing.T, ctrl SiteController) {
+	req, err := http.NewRequest("POST", "/admin/site/footer/delete", nil)
+	if err != nil {
+		t.Error("unable to construct request")
+	}
+
+	req.SetPathValue("key", "footer")
+
+	rr := httptest.NewRecorder()
+
+	mockServiceDeleteByKey := mockService.On("DeleteByKey", FooterKey).Return(nil)
+
+	mockSessionManagerPut := mockSessionManager.On(
+		"Put",
+		req.Context(),
+		"message",
+		"Reset 'Footer' to its default.",
+	)
+
+	handler := http.HandlerFunc(ctrl.DeleteSiteSettingPost)
+
+	handler.ServeHTTP(rr, req)
 
+	require.Equal(
+		t,
+		http.StatusSeeOther,
+		rr.Result().StatusCode,
+		"should return status code see other",
+	)
+	require.Equal(
+		t,
+		"/admin/site",
+		rr.Result().Header.Get("Location"),
+		"should redirect to site settings",
+	)
+
+	if res := mockService.AssertExpectations(t); !res {
+		t.Error("should call through to site service to delete setting")
+	}
+
+	if res := mockSessionManager.AssertExpectations(t); !res {
+		t.Error("should put message in session")
+	}
+
+	mockServiceDeleteByKey.Unset()
+	mockSessionManagerPut.Unset()
+}
+
+func testSiteControllerDeleteSettingUnknown(t *testing.T, ctrl SiteController) {
+	req, err := http.NewRequest("POST", "/admin/site/colour/delete", nil)
+	if err != nil {
+		t.Error("unable to construct request")
+	}
+
+	req.SetPathValue("key", "colour")
+
+	rr := httptest.NewRecorder()
+
+	mockErrorHandlersNotFound := mockErrorHandlers.
+		On("NotFound", rr, req).
+		Run(func(args mock.Arguments) {
+			rr.WriteHeader(http.StatusNotFound)
+		})
+
+	handler := http.HandlerFunc(ctrl.DeleteSiteSettingPost)
+
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(
+		t,
+		http.StatusNotFound,
+		rr.Result().StatusCode,
+		"should return status code not found",
+	)
+
+	if res := mockErrorHandlers.AssertExpectations(t); !res {
+		t.Error("should call not found handler")
+	}
+
+	mockErrorHandlersNotFound.Unset()
 }
 
 type MockErrorHandlers struct {
@@ -52,14 +371,18 @@ func (e *MockErrorHandlers) NotFound(w http.ResponseWriter, r *http.Request) {
 	e.Called(w, r)
 }
 
-func (e *MockErrorHandlers) InternalServerError(w http.ResponseWriter, r *http.Request) {
-	e.Called(w, r)
+func (e *MockErrorHandlers) InternalServerError(w http.ResponseWriter, r *http.Request, err error) {
+	e.Called(w, r, err)
 }
 
 func (e *MockErrorHandlers) BadRequest(w http.ResponseWriter, r *http.Request) {
 	e.Called(w, r)
 }
 
+func (e *MockErrorHandlers) Forbidden(w http.ResponseWriter, r *http.Request) {
+	e.Called(w, r)
+}
+
 type MockSessionManager struct {
 	mock.Mock
 }
@@ -82,6 +405,12 @@ func (s *MockSessionManager) GetString(ctx context.Context, key string) string {
 	return args.String(0)
 }
 
+func (s *MockSessionManager) GetInt(ctx context.Context, key string) int {
+	args := s.Called(ctx, key)
+
+	return args.Int(0)
+}
+
 func (s *MockSessionManager) LoadAndSave(next http.Handler) http.Handler {
 	args := s.Called(next)
 
@@ -110,12 +439,20 @@ type MockLogger struct {
 	mock.Mock
 }
 
-func (l *MockLogger) Info(format string, values ...any) {
-	l.Called(format, values)
+func (l *MockLogger) Debug(ctx context.Context, msg string, args ...any) {
+	l.Called(msg, args)
+}
+
+func (l *MockLogger) Info(ctx context.Context, msg string, args ...any) {
+	l.Called(msg, args)
 }
 
-func (l *MockLogger) Error(format string, values ...any) {
-	l.Called(format, values)
+func (l *MockLogger) Warn(ctx context.Context, msg string, args ...any) {
+	l.Called(msg, args)
+}
+
+func (l *MockLogger) Error(ctx context.Context, msg string, args ...any) {
+	l.Called(msg, args)
 }
 
 var mockTemplate = new(MockTemplate)
@@ -138,8 +475,38 @@ type MockService struct {
 	mock.Mock
 }
 
-func (s *MockService) Create(key, value string) (*SiteItemResponseDto, error) {
+func (s *MockService) Create(key Key, value string) (*SiteItemResponseDto, error) {
 	args := s.Called(key, value)
 
 	return args.Get(0).(*SiteItemResponseDto), args.Error(1)
 }
+
+func (s *MockService) Get() (*Site, error) {
+	args := s.Called()
+
+	return args.Get(0).(*Site), args.Error(1)
+}
+
+func (s *MockService) GetAll() (*[]SettingResponseDto, error) {
+	args := s.Called()
+
+	return args.Get(0).(*[]SettingResponseDto), args.Error(1)
+}
+
+func (s *MockService) GetByKey(key Key) (*SettingResponseDto, error) {
+	args := s.Called(key)
+
+	return args.Get(0).(*SettingResponseDto), args.Error(1)
+}
+
+func (s *MockService) Update(values map[Key]string) error {
+	args := s.Called(values)
+
+	return args.Error(0)
+}
+
+func (s *MockService) DeleteByKey(key Key) error {
+	args := s.Called(key)
+
+	return args.Error(0)
+}
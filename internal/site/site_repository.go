@@ -2,13 +2,16 @@ package site
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/nixpig/dunce/db"
 )
 
 type SiteRepository interface {
 	Create(key, value string) (*SiteKv, error)
+	GetAll() (*[]SiteKv, error)
+	GetByKey(key string) (*SiteKv, error)
+	Update(items []SiteKv) error
+	DeleteByKey(key string) error
 }
 
 type sitePostgresRepository struct {
@@ -30,7 +33,75 @@ func (s sitePostgresRepository) Create(key, value string) (*SiteKv, error) {
 		return nil, err
 	}
 
-	fmt.Println(" >>> got _something_")
-
 	return &created, nil
 }
+
+func (s sitePostgresRepository) GetAll() (*[]SiteKv, error) {
+	query := `select id_, key_, value_ from site_`
+
+	rows, err := s.db.Query(context.Background(), query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var items []SiteKv
+
+	for rows.Next() {
+		var item SiteKv
+
+		if err := rows.Scan(&item.Id, &item.Key, &item.Value); err != nil {
+			return nil, err
+		}
+
+		items = append(items, item)
+	}
+
+	return &items, nil
+}
+
+func (s sitePostgresRepository) GetByKey(key string) (*SiteKv, error) {
+	query := `select id_, key_, value_ from site_ where key_ = $1`
+
+	row := s.db.QueryRow(context.Background(), query, key)
+
+	var item SiteKv
+
+	if err := row.Scan(&item.Id, &item.Key, &item.Value); err != nil {
+		return nil, err
+	}
+
+	return &item, nil
+}
+
+// Update sets the value for the key of each item, creating any that haven't
+// been set before. They're written in one transaction, so either every value
+// is changed or none are.
+func (s sitePostgresRepository) Update(items []SiteKv) error {
+	query := `insert into site_ (key_, value_) values ($1, $2) on conflict (key_) do update set value_ = excluded.value_`
+
+	tx, err := s.db.Begin(context.Background())
+	if err != nil {
+		return err
+	}
+
+	// does nothing once the transaction's committed
+	defer tx.Rollback(context.Background())
+
+	for _, item := range items {
+		if _, err := tx.Exec(context.Background(), query, item.Key, item.Value); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(context.Background())
+}
+
+func (s sitePostgresRepository) DeleteByKey(key string) error {
+	query := `delete from site_ where key_ = $1`
+
+	_, err := s.db.Exec(context.Background(), query, key)
+
+	return err
+}
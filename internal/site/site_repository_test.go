@@ -1,6 +1,7 @@
 package site
 
 import (
+	"errors"
 	"regexp"
 	"testing"
 
@@ -10,7 +11,12 @@ import (
 
 func TestSiteRepository(t *testing.T) {
 	scenarios := map[string]func(t *testing.T, mock pgxmock.PgxPoolIface, repo SiteRepository){
-		"test create site key-value": testCreateSiteKeyValue,
+		"test create site key-value":                  testCreateSiteKeyValue,
+		"test get all site items":                     testGetAllSiteItems,
+		"test get site item by key":                   testGetSiteItemByKey,
+		"test update site items":                      testUpdateSiteItems,
+		"test update site items (error - rolls back)": testUpdateSiteItemsError,
+		"test delete site item":                       testDeleteSiteItem,
 	}
 
 	for scenario, fn := range scenarios {
@@ -51,3 +57,125 @@ func testCreateSiteKeyValue(t *testing.T, mock pgxmock.PgxPoolIface, repo SiteRe
 		t.Error("unmet expectations")
 	}
 }
+
+func testGetAllSiteItems(t *testing.T, mock pgxmock.PgxPoolIface, repo SiteRepository) {
+	query := `select id_, key_, value_ from site_`
+
+	mockRows := mock.
+		NewRows([]string{"id_", "key_", "value_"}).
+		AddRow(uint(23), "name", "site name").
+		AddRow(uint(42), "tagline", "site tagline")
+
+	mock.
+		ExpectQuery(regexp.QuoteMeta(query)).
+		WillReturnRows(mockRows)
+
+	got, err := repo.GetAll()
+
+	require.NoError(t, err, "should not return error")
+	require.Equal(t, &[]SiteKv{
+		{Id: 23, Key: "name", Value: "site name"},
+		{Id: 42, Key: "tagline", Value: "site tagline"},
+	}, got, "should return all site k/v")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error("unmet expectations")
+	}
+}
+
+func testGetSiteItemByKey(t *testing.T, mock pgxmock.PgxPoolIface, repo SiteRepository) {
+	query := `select id_, key_, value_ from site_ where key_ = $1`
+
+	mockRow := mock.
+		NewRows([]string{"id_", "key_", "value_"}).
+		AddRow(uint(23), "name", "site name")
+
+	mock.
+		ExpectQuery(regexp.QuoteMeta(query)).
+		WithArgs("name").
+		WillReturnRows(mockRow)
+
+	got, err := repo.GetByKey("name")
+
+	require.NoError(t, err, "should not return error")
+	require.Equal(t, &SiteKv{
+		Id: 23, Key: "name", Value: "site name",
+	}, got, "should return site k/v")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error("unmet expectations")
+	}
+}
+
+const updateQuery = `insert into site_ (key_, value_) values ($1, $2) on conflict (key_) do update set value_ = excluded.value_`
+
+func testUpdateSiteItems(t *testing.T, mock pgxmock.PgxPoolIface, repo SiteRepository) {
+	mock.ExpectBegin()
+
+	mock.
+		ExpectExec(regexp.QuoteMeta(updateQuery)).
+		WithArgs("name", "new name").
+		WillReturnResult(pgxmock.NewResult("insert", 1))
+
+	mock.
+		ExpectExec(regexp.QuoteMeta(updateQuery)).
+		WithArgs("domain", "example.com").
+		WillReturnResult(pgxmock.NewResult("insert", 1))
+
+	mock.ExpectCommit()
+
+	err := repo.Update([]SiteKv{
+		{Key: "name", Value: "new name"},
+		{Key: "domain", Value: "example.com"},
+	})
+
+	require.NoError(t, err, "should not return error")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error("unmet expectations")
+	}
+}
+
+func testUpdateSiteItemsError(t *testing.T, mock pgxmock.PgxPoolIface, repo SiteRepository) {
+	mock.ExpectBegin()
+
+	mock.
+		ExpectExec(regexp.QuoteMeta(updateQuery)).
+		WithArgs("name", "new name").
+		WillReturnResult(pgxmock.NewResult("insert", 1))
+
+	mock.
+		ExpectExec(regexp.QuoteMeta(updateQuery)).
+		WithArgs("domain", "example.com").
+		WillReturnError(errors.New("db_error"))
+
+	mock.ExpectRollback()
+
+	err := repo.Update([]SiteKv{
+		{Key: "name", Value: "new name"},
+		{Key: "domain", Value: "example.com"},
+	})
+
+	require.EqualError(t, err, "db_error", "should return db error")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error("unmet expectations")
+	}
+}
+
+func testDeleteSiteItem(t *testing.T, mock pgxmock.PgxPoolIface, repo SiteRepository) {
+	query := `delete from site_ where key_ = $1`
+
+	mock.
+		ExpectExec(regexp.QuoteMeta(query)).
+		WithArgs("footer").
+		WillReturnResult(pgxmock.NewResult("delete", 1))
+
+	err := repo.DeleteByKey("footer")
+
+	require.NoError(t, err, "should not return error")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error("unmet expectations")
+	}
+}
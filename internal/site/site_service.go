@@ -1,26 +1,178 @@
 package site
 
+import (
+	"github.com/go-playground/validator/v10"
+	"github.com/nixpig/dunce/pkg/cache"
+)
+
 type SiteService interface {
-	Create(key, value string) (*SiteItemResponseDto, error)
+	Create(key Key, value string) (*SiteItemResponseDto, error)
+	Get() (*Site, error)
+	GetAll() (*[]SettingResponseDto, error)
+	GetByKey(key Key) (*SettingResponseDto, error)
+	Update(values map[Key]string) error
+	DeleteByKey(key Key) error
 }
 
 type SiteServiceImpl struct {
-	repo SiteRepository
+	repo     SiteRepository
+	validate *validator.Validate
+	cache    *cache.Value[Site]
 }
 
-func NewSiteService(repo SiteRepository) SiteServiceImpl {
-	return SiteServiceImpl{repo: repo}
+func NewSiteService(repo SiteRepository, validate *validator.Validate) SiteServiceImpl {
+	return SiteServiceImpl{
+		repo:     repo,
+		validate: validate,
+		cache:    &cache.Value[Site]{},
+	}
 }
 
-func (s SiteServiceImpl) Create(key, value string) (*SiteItemResponseDto, error) {
-	item, err := s.repo.Create(key, value)
+func (s SiteServiceImpl) Create(key Key, value string) (*SiteItemResponseDto, error) {
+	if err := s.validateSetting(key, value); err != nil {
+		return nil, err
+	}
+
+	item, err := s.repo.Create(string(key), value)
 	if err != nil {
 		return nil, err
 	}
 
+	s.cache.Invalidate()
+
 	return &SiteItemResponseDto{
 		Id:    item.Id,
 		Key:   item.Key,
 		Value: item.Value,
 	}, nil
 }
+
+// Get builds the site from its settings, using the default for any that
+// haven't been set. Every public page reads it, so it's cached until the
+// settings are next written.
+func (s SiteServiceImpl) Get() (*Site, error) {
+	site, err := s.cache.Get(func() (Site, error) {
+		items, err := s.repo.GetAll()
+		if err != nil {
+			return Site{}, err
+		}
+
+		site := Defaults()
+
+		for _, item := range *items {
+			site.set(Key(item.Key), item.Value)
+		}
+
+		return site, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &site, nil
+}
+
+// GetAll returns every setting with its current value. Stored values for keys
+// that aren't settings are ignored.
+func (s SiteServiceImpl) GetAll() (*[]SettingResponseDto, error) {
+	items, err := s.repo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[Key]string, len(*items))
+	for _, item := range *items {
+		values[Key(item.Key)] = item.Value
+	}
+
+	settings := make([]SettingResponseDto, len(Settings))
+
+	for index, setting := range Settings {
+		value, isSet := values[setting.Key]
+		if !isSet {
+			value = setting.Default
+		}
+
+		settings[index] = SettingResponseDto{
+			Setting: setting,
+			Value:   value,
+			IsSet:   isSet,
+		}
+	}
+
+	return &settings, nil
+}
+
+func (s SiteServiceImpl) GetByKey(key Key) (*SettingResponseDto, error) {
+	settings, err := s.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, setting := range *settings {
+		if setting.Key == key {
+			return &setting, nil
+		}
+	}
+
+	return nil, &InvalidSettingError{Key: key}
+}
+
+// Update sets the values of several settings. Every value is validated before
+// any are written, and they're written together, so an invalid value or a
+// failed write leaves all settings unchanged.
+func (s SiteServiceImpl) Update(values map[Key]string) error {
+	for key := range values {
+		if _, ok := GetSetting(key); !ok {
+			return &InvalidSettingError{Key: key}
+		}
+	}
+
+	items := make([]SiteKv, 0, len(values))
+
+	for _, setting := range Settings {
+		value, ok := values[setting.Key]
+		if !ok {
+			continue
+		}
+
+		if err := s.validateSetting(setting.Key, value); err != nil {
+			return err
+		}
+
+		items = append(items, SiteKv{Key: string(setting.Key), Value: value})
+	}
+
+	defer s.cache.Invalidate()
+
+	return s.repo.Update(items)
+}
+
+// DeleteByKey removes the stored value for a setting, so it reverts to its
+// default.
+func (s SiteServiceImpl) DeleteByKey(key Key) error {
+	if _, ok := GetSetting(key); !ok {
+		return &InvalidSettingError{Key: key}
+	}
+
+	if err := s.repo.DeleteByKey(string(key)); err != nil {
+		return err
+	}
+
+	s.cache.Invalidate()
+
+	return nil
+}
+
+func (s SiteServiceImpl) validateSetting(key Key, value string) error {
+	setting, ok := GetSetting(key)
+	if !ok {
+		return &InvalidSettingError{Key: key}
+	}
+
+	if err := s.validate.Var(value, setting.Validation); err != nil {
+		return &InvalidSettingError{Key: key, Label: setting.Label, Err: err}
+	}
+
+	return nil
+}
@@ -1,8 +1,10 @@
 package site
 
 import (
+	"errors"
 	"testing"
 
+	"github.com/nixpig/dunce/pkg/validation"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
@@ -11,13 +13,30 @@ var mockRepo = new(MockSiteRepository)
 
 func TestSiteService(t *testing.T) {
 	scenarios := map[string]func(t *testing.T, service SiteService){
-		"test create site service kv": testSiteServiceCreateKv,
+		"test create site service kv":                testSiteServiceCreateKv,
+		"test create site service kv (unknown key)":  testSiteServiceCreateUnknownKey,
+		"test get site":                              testSiteServiceGet,
+		"test get site uses defaults":                testSiteServiceGetDefaults,
+		"test get site is cached":                    testSiteServiceGetCached,
+		"test get site error":                        testSiteServiceGetError,
+		"test get all settings":                      testSiteServiceGetAll,
+		"test get setting by key":                    testSiteServiceGetByKey,
+		"test update settings":                       testSiteServiceUpdate,
+		"test update settings invalidates cache":     testSiteServiceUpdateInvalidatesCache,
+		"test update settings (error - invalid)":     testSiteServiceUpdateInvalid,
+		"test update settings (error - unknown key)": testSiteServiceUpdateUnknownKey,
+		"test delete setting":                        testSiteServiceDeleteByKey,
+		"test delete setting (error - unknown key)":  testSiteServiceDeleteByKeyUnknown,
+	}
+
+	validator, err := validation.NewValidator()
+	if err != nil {
+		t.Fatal("unable to create validator")
 	}
 
 	for scenario, fn := range scenarios {
 		t.Run(scenario, func(t *testing.T) {
-
-			service := NewSiteService(mockRepo)
+			service := NewSiteService(mockRepo, validator)
 
 			fn(t, service)
 		})
@@ -34,17 +53,41 @@ func (s *MockSiteRepository) Create(key, value string) (*SiteKv, error) {
 	return args.Get(0).(*SiteKv), args.Error(1)
 }
 
+func (s *MockSiteRepository) GetAll() (*[]SiteKv, error) {
+	args := s.Called()
+
+	return args.Get(0).(*[]SiteKv), args.Error(1)
+}
+
+func (s *MockSiteRepository) GetByKey(key string) (*SiteKv, error) {
+	args := s.Called(key)
+
+	return args.Get(0).(*SiteKv), args.Error(1)
+}
+
+func (s *MockSiteRepository) Update(items []SiteKv) error {
+	args := s.Called(items)
+
+	return args.Error(0)
+}
+
+func (s *MockSiteRepository) DeleteByKey(key string) error {
+	args := s.Called(key)
+
+	return args.Error(0)
+}
+
 func testSiteServiceCreateKv(t *testing.T, service SiteService) {
 	mockSiteRepositoryCreate := mockRepo.
-		On("Create", "some name", "some description").
-		Return(&SiteKv{Key: "some name", Value: "some description"}, nil)
+		On("Create", "name", "some name").
+		Return(&SiteKv{Key: "name", Value: "some name"}, nil)
 
-	got, err := service.Create("some name", "some description")
+	got, err := service.Create(NameKey, "some name")
 
 	require.NoError(t, err, "should not return error")
 	require.Equal(t, &SiteItemResponseDto{
-		Key:   "some name",
-		Value: "some description",
+		Key:   "name",
+		Value: "some name",
 	}, got, "should return k/v pair")
 
 	if res := mockRepo.AssertExpectations(t); !res {
@@ -53,3 +96,226 @@ func testSiteServiceCreateKv(t *testing.T, service SiteService) {
 
 	mockSiteRepositoryCreate.Unset()
 }
+
+func testSiteServiceCreateUnknownKey(t *testing.T, service SiteService) {
+	got, err := service.Create("colour", "blue")
+
+	require.Nil(t, got, "should not return k/v pair")
+	require.EqualError(t, err, "unknown setting 'colour'", "should return unknown setting error")
+}
+
+func testSiteServiceGet(t *testing.T, service SiteService) {
+	mockSiteRepositoryGetAll := mockRepo.
+		On("GetAll").
+		Return(&[]SiteKv{
+			{Id: 1, Key: "name", Value: "Site name"},
+			{Id: 2, Key: "tagline", Value: "Site tagline"},
+			{Id: 3, Key: "footer", Value: "Site footer"},
+			{Id: 4, Key: "unknown", Value: "ignored"},
+		}, nil)
+
+	got, err := service.Get()
+
+	require.NoError(t, err, "should not return error")
+	require.Equal(t, &Site{
+		Name:    "Site name",
+		Tagline: "Site tagline",
+		Footer:  "Site footer",
+	}, got, "should return site built from k/v pairs")
+
+	if res := mockRepo.AssertExpectations(t); !res {
+		t.Error("should call through to repo")
+	}
+
+	mockSiteRepositoryGetAll.Unset()
+}
+
+func testSiteServiceGetDefaults(t *testing.T, service SiteService) {
+	mockSiteRepositoryGetAll := mockRepo.
+		On("GetAll").
+		Return(&[]SiteKv{}, nil)
+
+	got, err := service.Get()
+
+	require.NoError(t, err, "should not return error")
+	require.Equal(t, "dunce", got.Name, "should use default name")
+	require.Equal(t, Defaults(), *got, "should return defaults")
+
+	mockSiteRepositoryGetAll.Unset()
+}
+
+func testSiteServiceGetCached(t *testing.T, service SiteService) {
+	mockSiteRepositoryGetAll := mockRepo.
+		On("GetAll").
+		Return(&[]SiteKv{{Id: 1, Key: "name", Value: "Site name"}}, nil).
+		Once()
+
+	first, err := service.Get()
+	require.NoError(t, err, "should not return error")
+
+	// a second call to the repo would panic, as only one is expected
+	second, err := service.Get()
+	require.NoError(t, err, "should not return error")
+
+	require.Equal(t, first, second, "should return cached site")
+
+	second.Name = "changed"
+
+	third, err := service.Get()
+	require.NoError(t, err, "should not return error")
+	require.Equal(t, "Site name", third.Name, "should not share cached site")
+
+	mockSiteRepositoryGetAll.Unset()
+}
+
+func testSiteServiceGetError(t *testing.T, service SiteService) {
+	mockSiteRepositoryGetAll := mockRepo.
+		On("GetAll").
+		Return((*[]SiteKv)(nil), errors.New("repo_error"))
+
+	got, err := service.Get()
+
+	require.Nil(t, got, "should not return site")
+	require.EqualError(t, err, "repo_error", "should return repo error")
+
+	mockSiteRepositoryGetAll.Unset()
+}
+
+func testSiteServiceGetAll(t *testing.T, service SiteService) {
+	mockSiteRepositoryGetAll := mockRepo.
+		On("GetAll").
+		Return(&[]SiteKv{
+			{Id: 1, Key: "tagline", Value: "Site tagline"},
+			{Id: 2, Key: "unknown", Value: "ignored"},
+		}, nil)
+
+	got, err := service.GetAll()
+
+	require.NoError(t, err, "should not return error")
+	require.Len(t, *got, len(Settings), "should return every setting")
+
+	require.Equal(t, SettingResponseDto{
+		Setting: Settings[0],
+		Value:   "dunce",
+		IsSet:   false,
+	}, (*got)[0], "should return default for unset setting")
+
+	require.Equal(t, SettingResponseDto{
+		Setting: Settings[1],
+		Value:   "Site tagline",
+		IsSet:   true,
+	}, (*got)[1], "should return stored value for set setting")
+
+	mockSiteRepositoryGetAll.Unset()
+}
+
+func testSiteServiceGetByKey(t *testing.T, service SiteService) {
+	mockSiteRepositoryGetAll := mockRepo.
+		On("GetAll").
+		Return(&[]SiteKv{{Id: 1, Key: "domain", Value: "example.com"}}, nil)
+
+	got, err := service.GetByKey(DomainKey)
+
+	require.NoError(t, err, "should not return error")
+	require.Equal(t, "example.com", got.Value, "should return value")
+	require.True(t, got.IsSet, "should be set")
+
+	_, err = service.GetByKey("colour")
+	require.EqualError(t, err, "unknown setting 'colour'", "should return unknown setting error")
+
+	mockSiteRepositoryGetAll.Unset()
+}
+
+func testSiteServiceUpdate(t *testing.T, service SiteService) {
+	mockSiteRepositoryUpdate := mockRepo.
+		On("Update", []SiteKv{
+			{Key: "name", Value: "New name"},
+			{Key: "domain", Value: "example.com"},
+		}).
+		Return(nil)
+
+	err := service.Update(map[Key]string{
+		NameKey:   "New name",
+		DomainKey: "example.com",
+	})
+
+	require.NoError(t, err, "should not return error")
+
+	if res := mockRepo.AssertExpectations(t); !res {
+		t.Error("should update every setting together")
+	}
+
+	mockSiteRepositoryUpdate.Unset()
+}
+
+func testSiteServiceUpdateInvalidatesCache(t *testing.T, service SiteService) {
+	mockSiteRepositoryGetAll := mockRepo.
+		On("GetAll").
+		Return(&[]SiteKv{{Id: 1, Key: "name", Value: "Old name"}}, nil).
+		Once()
+
+	got, err := service.Get()
+	require.NoError(t, err, "should not return error")
+	require.Equal(t, "Old name", got.Name)
+
+	mockSiteRepositoryUpdate := mockRepo.
+		On("Update", []SiteKv{{Key: "name", Value: "New name"}}).
+		Return(nil)
+
+	require.NoError(t, service.Update(map[Key]string{NameKey: "New name"}))
+
+	mockSiteRepositoryGetAll.Unset()
+
+	mockSiteRepositoryGetAllUpdated := mockRepo.
+		On("GetAll").
+		Return(&[]SiteKv{{Id: 1, Key: "name", Value: "New name"}}, nil).
+		Once()
+
+	got, err = service.Get()
+	require.NoError(t, err, "should not return error")
+	require.Equal(t, "New name", got.Name, "should read settings again after write")
+
+	mockSiteRepositoryUpdate.Unset()
+	mockSiteRepositoryGetAllUpdated.Unset()
+}
+
+func testSiteServiceUpdateInvalid(t *testing.T, service SiteService) {
+	err := service.Update(map[Key]string{
+		NameKey:   "New name",
+		DomainKey: "not a domain",
+	})
+
+	var invalid *InvalidSettingError
+
+	require.ErrorAs(t, err, &invalid, "should return invalid setting error")
+	require.Equal(t, DomainKey, invalid.Key, "should return invalid key")
+	require.EqualError(t, err, "invalid value for 'Domain'", "should describe invalid setting")
+}
+
+func testSiteServiceUpdateUnknownKey(t *testing.T, service SiteService) {
+	err := service.Update(map[Key]string{"colour": "blue"})
+
+	require.EqualError(t, err, "unknown setting 'colour'", "should return unknown setting error")
+}
+
+func testSiteServiceDeleteByKey(t *testing.T, service SiteService) {
+	mockSiteRepositoryDelete := mockRepo.
+		On("DeleteByKey", "footer").
+		Return(nil)
+
+	err := service.DeleteByKey(FooterKey)
+
+	require.NoError(t, err, "should not return error")
+
+	if res := mockRepo.AssertExpectations(t); !res {
+		t.Error("should call through to repo")
+	}
+
+	mockSiteRepositoryDelete.Unset()
+}
+
+func testSiteServiceDeleteByKeyUnknown(t *testing.T, service SiteService) {
+	err := service.DeleteByKey("colour")
+
+	require.EqualError(t, err, "unknown setting 'colour'", "should return unknown setting error")
+}
@@ -0,0 +1,106 @@
+package sitemap
+
+import (
+	"encoding/xml"
+	"fmt"
+	"time"
+)
+
+// MaxUrls is the most URLs a single sitemap may contain. Sites with more are
+// split across several sitemaps, listed in a sitemap index.
+const MaxUrls = 50000
+
+const ContentType = "application/xml; charset=utf-8"
+
+const namespace = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+type Url struct {
+	Loc     string
+	LastMod *time.Time
+}
+
+type urlset struct {
+	XMLName xml.Name     `xml:"urlset"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	Urls    []sitemapUrl `xml:"url"`
+}
+
+type sitemapUrl struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+type sitemapIndex struct {
+	XMLName  xml.Name     `xml:"sitemapindex"`
+	Xmlns    string       `xml:"xmlns,attr"`
+	Sitemaps []sitemapUrl `xml:"sitemap"`
+}
+
+// Sitemap lists the public pages of the site, with BaseUrl the absolute URL
+// the site is served under.
+type Sitemap struct {
+	BaseUrl string
+	Urls    []Url
+}
+
+// Pages is the number of sitemaps needed to list all the urls.
+func (s Sitemap) Pages() int {
+	return (len(s.Urls) + MaxUrls - 1) / MaxUrls
+}
+
+// Page returns the urls in the 1-based page of the sitemap, or false if
+// there's no such page.
+func (s Sitemap) Page(page int) ([]Url, bool) {
+	if page < 1 || page > s.Pages() {
+		return nil, false
+	}
+
+	end := min(page*MaxUrls, len(s.Urls))
+
+	return s.Urls[(page-1)*MaxUrls : end], true
+}
+
+// PageUrl is the location of the 1-based page of the sitemap, when it's split
+// across several.
+func (s Sitemap) PageUrl(page int) string {
+	return fmt.Sprintf("%s/sitemaps/%d.xml", s.BaseUrl, page)
+}
+
+// Urlset renders urls as a sitemap.
+func Urlset(urls []Url) ([]byte, error) {
+	set := urlset{Xmlns: namespace, Urls: make([]sitemapUrl, len(urls))}
+
+	for index, url := range urls {
+		set.Urls[index] = sitemapUrl{Loc: url.Loc, LastMod: lastMod(url.LastMod)}
+	}
+
+	return marshalXml(set)
+}
+
+// Index renders a sitemap index listing sitemaps.
+func Index(sitemaps []Url) ([]byte, error) {
+	index := sitemapIndex{Xmlns: namespace, Sitemaps: make([]sitemapUrl, len(sitemaps))}
+
+	for i, sitemap := range sitemaps {
+		index.Sitemaps[i] = sitemapUrl{Loc: sitemap.Loc, LastMod: lastMod(sitemap.LastMod)}
+	}
+
+	return marshalXml(index)
+}
+
+func lastMod(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+
+	return t.UTC().Format(time.RFC3339)
+}
+
+func marshalXml(v any) ([]byte, error) {
+	b, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte(xml.Header), b...), nil
+}
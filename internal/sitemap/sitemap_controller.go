@@ -0,0 +1,129 @@
+package sitemap
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/nixpig/dunce/internal/app/errors"
+	"github.com/nixpig/dunce/internal/site"
+	"github.com/nixpig/dunce/pkg/logging"
+)
+
+type SitemapController struct {
+	service       SitemapService
+	log           logging.Logger
+	errorHandlers errors.ErrorHandlers
+	baseUrl       string
+}
+
+type SitemapControllerConfig struct {
+	Log           logging.Logger
+	ErrorHandlers errors.ErrorHandlers
+	BaseUrl       string
+}
+
+func NewSitemapController(service SitemapService, config SitemapControllerConfig) SitemapController {
+	return SitemapController{
+		service:       service,
+		log:           config.Log,
+		errorHandlers: config.ErrorHandlers,
+		baseUrl:       config.BaseUrl,
+	}
+}
+
+// SitemapHandler serves the sitemap, or a sitemap index pointing at each page
+// of it once there are too many urls for one.
+func (s *SitemapController) SitemapHandler(w http.ResponseWriter, r *http.Request) {
+	sitemap, err := s.service.Get(s.baseUrl)
+	if err == site.ErrNoUrl {
+		// sitemaps need absolute links, so there isn't one until there's a
+		// url to make them from
+		s.log.Warn(r.Context(), "unable to serve sitemap", "err", err)
+		s.errorHandlers.NotFound(w, r)
+		return
+	}
+
+	if err != nil {
+		s.errorHandlers.InternalServerError(w, r, fmt.Errorf("unable to get sitemap: %w", err))
+		return
+	}
+
+	var b []byte
+
+	if pages := sitemap.Pages(); pages > 1 {
+		sitemaps := make([]Url, pages)
+		for index := range sitemaps {
+			sitemaps[index] = Url{Loc: sitemap.PageUrl(index + 1)}
+		}
+
+		b, err = Index(sitemaps)
+	} else {
+		b, err = Urlset(sitemap.Urls)
+	}
+
+	if err != nil {
+		s.errorHandlers.InternalServerError(w, r, fmt.Errorf("unable to encode sitemap: %w", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", ContentType)
+	w.Write(b)
+}
+
+// SitemapPageHandler serves a single page of a sitemap that's split across
+// several.
+func (s *SitemapController) SitemapPageHandler(w http.ResponseWriter, r *http.Request) {
+	name, ok := strings.CutSuffix(r.PathValue("page"), ".xml")
+	if !ok {
+		s.errorHandlers.NotFound(w, r)
+		return
+	}
+
+	page, err := strconv.Atoi(name)
+	if err != nil {
+		s.errorHandlers.NotFound(w, r)
+		return
+	}
+
+	sitemap, err := s.service.Get(s.baseUrl)
+	if err == site.ErrNoUrl {
+		// sitemaps need absolute links, so there isn't one until there's a
+		// url to make them from
+		s.log.Warn(r.Context(), "unable to serve sitemap", "err", err)
+		s.errorHandlers.NotFound(w, r)
+		return
+	}
+
+	if err != nil {
+		s.errorHandlers.InternalServerError(w, r, fmt.Errorf("unable to get sitemap: %w", err))
+		return
+	}
+
+	urls, ok := sitemap.Page(page)
+	if !ok {
+		s.errorHandlers.NotFound(w, r)
+		return
+	}
+
+	b, err := Urlset(urls)
+	if err != nil {
+		s.errorHandlers.InternalServerError(w, r, fmt.Errorf("unable to encode sitemap: %w", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", ContentType)
+	w.Write(b)
+}
+
+func (s *SitemapController) RobotsHandler(w http.ResponseWriter, r *http.Request) {
+	robots, err := s.service.GetRobots(s.baseUrl)
+	if err != nil {
+		s.errorHandlers.InternalServerError(w, r, fmt.Errorf("unable to get robots.txt: %w", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write([]byte(robots))
+}
@@ -0,0 +1,127 @@
+package sitemap
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nixpig/dunce/internal/article"
+	"github.com/nixpig/dunce/internal/page"
+	"github.com/nixpig/dunce/internal/site"
+	"github.com/nixpig/dunce/internal/tag"
+	"github.com/nixpig/dunce/pkg/pagination"
+)
+
+type SitemapService interface {
+	Get(baseUrl string) (*Sitemap, error)
+	GetRobots(baseUrl string) (string, error)
+}
+
+type SitemapServiceImpl struct {
+	articleService article.ArticleService
+	tagService     tag.TagService
+	pageService    page.PageService
+	siteService    site.SiteService
+}
+
+func NewSitemapService(
+	articleService article.ArticleService,
+	tagService tag.TagService,
+	pageService page.PageService,
+	siteService site.SiteService,
+) SitemapServiceImpl {
+	return SitemapServiceImpl{
+		articleService: articleService,
+		tagService:     tagService,
+		pageService:    pageService,
+		siteService:    siteService,
+	}
+}
+
+// Get lists every public page of the site: the home page, pages, published
+// articles and tag pages. Links are made absolute using the site domain, or
+// baseUrl if the domain isn't set, and site.ErrNoUrl is returned if there's
+// neither.
+func (s SitemapServiceImpl) Get(baseUrl string) (*Sitemap, error) {
+	st, err := s.siteService.Get()
+	if err != nil {
+		return nil, err
+	}
+
+	baseUrl = st.Url(baseUrl)
+	if baseUrl == "" {
+		return nil, site.ErrNoUrl
+	}
+
+	articles, err := s.articleService.GetManyByAttribute(
+		"status",
+		article.StatusPublished,
+		pagination.Request{Limit: pagination.Unlimited},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	tags, err := s.tagService.GetAll(pagination.Request{Limit: pagination.Unlimited})
+	if err != nil {
+		return nil, err
+	}
+
+	pages, err := s.pageService.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	urls := make([]Url, 0, 1+len(*pages)+len(articles.Items)+len(tags.Items))
+	urls = append(urls, Url{Loc: baseUrl + "/"})
+
+	for _, p := range *pages {
+		updatedAt := p.UpdatedAt
+
+		urls = append(urls, Url{
+			Loc:     baseUrl + p.Url(),
+			LastMod: &updatedAt,
+		})
+	}
+
+	for _, a := range articles.Items {
+		updatedAt := a.UpdatedAt
+
+		// the home page lists the latest articles, so changes when they do
+		if urls[0].LastMod == nil || updatedAt.After(*urls[0].LastMod) {
+			urls[0].LastMod = &updatedAt
+		}
+
+		urls = append(urls, Url{
+			Loc:     baseUrl + "/articles/" + a.Slug,
+			LastMod: &updatedAt,
+		})
+	}
+
+	for _, t := range tags.Items {
+		urls = append(urls, Url{Loc: baseUrl + "/tags/" + t.Slug})
+	}
+
+	return &Sitemap{BaseUrl: baseUrl, Urls: urls}, nil
+}
+
+// GetRobots builds robots.txt, keeping crawlers out of the admin and pointing
+// them at the sitemap. The sitemap's left out if there's no url to link it
+// from.
+func (s SitemapServiceImpl) GetRobots(baseUrl string) (string, error) {
+	st, err := s.siteService.Get()
+	if err != nil {
+		return "", err
+	}
+
+	var robots strings.Builder
+
+	robots.WriteString("User-agent: *\n")
+	robots.WriteString("Disallow: /admin\n")
+
+	if baseUrl = st.Url(baseUrl); baseUrl != "" {
+		robots.WriteString("\n")
+		fmt.Fprintf(&robots, "Sitemap: %s/sitemap.xml\n", baseUrl)
+	}
+
+	return robots.String(), nil
+}
@@ -0,0 +1,409 @@
+package sitemap
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nixpig/dunce/internal/article"
+	"github.com/nixpig/dunce/internal/page"
+	"github.com/nixpig/dunce/internal/site"
+	"github.com/nixpig/dunce/internal/tag"
+	"github.com/nixpig/dunce/pkg/pagination"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var mockArticleService = new(MockArticleService)
+var mockTagService = new(MockTagService)
+var mockPageService = new(MockPageService)
+var mockSiteService = new(MockSiteService)
+
+var allPage = pagination.Request{Limit: pagination.Unlimited}
+
+func TestSitemapService(t *testing.T) {
+	scenarios := map[string]func(t *testing.T, service SitemapService){
+		"test get sitemap":                   testSitemapServiceGet,
+		"test get sitemap uses site domain":  testSitemapServiceGetSiteDomain,
+		"test get sitemap (error - tags)":    testSitemapServiceGetTagsError,
+		"test get robots":                    testSitemapServiceGetRobots,
+		"test get robots (error - site)":     testSitemapServiceGetRobotsError,
+		"test get sitemap (error - no url)":  testSitemapServiceGetNoUrl,
+		"test get robots (success - no url)": testSitemapServiceGetRobotsNoUrl,
+	}
+
+	for scenario, fn := range scenarios {
+		t.Run(scenario, func(t *testing.T) {
+			service := NewSitemapService(mockArticleService, mockTagService, mockPageService, mockSiteService)
+
+			fn(t, service)
+		})
+	}
+}
+
+func testSitemapServiceGet(t *testing.T, service SitemapService) {
+	updatedOne := time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC)
+	updatedTwo := time.Date(2024, 5, 3, 0, 0, 0, 0, time.UTC)
+
+	siteCall := mockSiteService.
+		On("Get").
+		Return(&site.Site{}, nil)
+
+	articlesCall := mockArticleService.
+		On("GetManyByAttribute", "status", article.StatusPublished, allPage).
+		Return(&pagination.Page[article.ArticleResponseDto]{
+			Items: []article.ArticleResponseDto{
+				{Slug: "article-one", UpdatedAt: updatedOne},
+				{Slug: "article-two", UpdatedAt: updatedTwo},
+			},
+		}, nil)
+
+	tagsCall := mockTagService.
+		On("GetAll", allPage).
+		Return(&pagination.Page[tag.TagResponseDto]{
+			Items: []tag.TagResponseDto{
+				{Id: 1, Name: "Go lang", Slug: "go-lang"},
+			},
+		}, nil)
+
+	parentId := 1
+
+	pagesCall := mockPageService.
+		On("GetAll").
+		Return(&[]page.PageResponseDto{
+			{Id: 1, Slug: "about", Path: "about", UpdatedAt: updatedOne},
+			{Id: 2, Slug: "team", Path: "about/team", ParentId: &parentId, UpdatedAt: updatedTwo},
+		}, nil)
+
+	got, err := service.Get("http://localhost:8080")
+
+	if res := mockArticleService.AssertExpectations(t); !res {
+		t.Error("unmet expectations")
+	}
+
+	if res := mockTagService.AssertExpectations(t); !res {
+		t.Error("unmet expectations")
+	}
+
+	require.NoError(t, err, "should not return error")
+	require.Equal(t, &Sitemap{
+		BaseUrl: "http://localhost:8080",
+		Urls: []Url{
+			{Loc: "http://localhost:8080/", LastMod: &updatedTwo},
+			{Loc: "http://localhost:8080/about", LastMod: &updatedOne},
+			{Loc: "http://localhost:8080/about/team", LastMod: &updatedTwo},
+			{Loc: "http://localhost:8080/articles/article-one", LastMod: &updatedOne},
+			{Loc: "http://localhost:8080/articles/article-two", LastMod: &updatedTwo},
+			{Loc: "http://localhost:8080/tags/go-lang"},
+		},
+	}, got, "should list home, pages, article and tag pages")
+
+	siteCall.Unset()
+	articlesCall.Unset()
+	tagsCall.Unset()
+	pagesCall.Unset()
+}
+
+func testSitemapServiceGetSiteDomain(t *testing.T, service SitemapService) {
+	siteCall := mockSiteService.
+		On("Get").
+		Return(&site.Site{Domain: "example.com"}, nil)
+
+	articlesCall := mockArticleService.
+		On("GetManyByAttribute", "status", article.StatusPublished, allPage).
+		Return(&pagination.Page[article.ArticleResponseDto]{
+			Items: []article.ArticleResponseDto{},
+		}, nil)
+
+	tagsCall := mockTagService.
+		On("GetAll", allPage).
+		Return(&pagination.Page[tag.TagResponseDto]{
+			Items: []tag.TagResponseDto{},
+		}, nil)
+
+	pagesCall := mockPageService.
+		On("GetAll").
+		Return(&[]page.PageResponseDto{}, nil)
+
+	got, err := service.Get("http://localhost:8080")
+
+	require.NoError(t, err, "should not return error")
+	require.Equal(t, &Sitemap{
+		BaseUrl: "https://example.com",
+		Urls:    []Url{{Loc: "https://example.com/"}},
+	}, got, "should link to site domain")
+
+	siteCall.Unset()
+	articlesCall.Unset()
+	tagsCall.Unset()
+	pagesCall.Unset()
+}
+
+func testSitemapServiceGetTagsError(t *testing.T, service SitemapService) {
+	siteCall := mockSiteService.
+		On("Get").
+		Return(&site.Site{}, nil)
+
+	articlesCall := mockArticleService.
+		On("GetManyByAttribute", "status", article.StatusPublished, allPage).
+		Return(&pagination.Page[article.ArticleResponseDto]{
+			Items: []article.ArticleResponseDto{},
+		}, nil)
+
+	tagsCall := mockTagService.
+		On("GetAll", allPage).
+		Return((*pagination.Page[tag.TagResponseDto])(nil), errors.New("tags_error"))
+
+	got, err := service.Get("http://localhost:8080")
+
+	require.Nil(t, got, "should not return sitemap")
+	require.EqualError(t, err, "tags_error", "should return tags error")
+
+	siteCall.Unset()
+	articlesCall.Unset()
+	tagsCall.Unset()
+}
+
+func testSitemapServiceGetRobots(t *testing.T, service SitemapService) {
+	siteCall := mockSiteService.
+		On("Get").
+		Return(&site.Site{Domain: "example.com"}, nil)
+
+	got, err := service.GetRobots("http://localhost:8080")
+
+	require.NoError(t, err, "should not return error")
+	require.Equal(t, `User-agent: *
+Disallow: /admin
+
+Sitemap: https://example.com/sitemap.xml
+`, got, "should point at sitemap")
+
+	siteCall.Unset()
+}
+
+func testSitemapServiceGetRobotsError(t *testing.T, service SitemapService) {
+	siteCall := mockSiteService.
+		On("Get").
+		Return((*site.Site)(nil), errors.New("site_error"))
+
+	got, err := service.GetRobots("http://localhost:8080")
+
+	require.Empty(t, got, "should not return robots.txt")
+	require.EqualError(t, err, "site_error", "should return site error")
+
+	siteCall.Unset()
+}
+
+func testSitemapServiceGetNoUrl(t *testing.T, service SitemapService) {
+	siteCall := mockSiteService.
+		On("Get").
+		Return(&site.Site{}, nil)
+
+	// no expectations on the other services, so listing content would fail
+
+	got, err := service.Get("")
+
+	require.Nil(t, got, "should not return sitemap")
+	require.ErrorIs(t, err, site.ErrNoUrl, "should refuse to build sitemap without a url")
+
+	siteCall.Unset()
+}
+
+func testSitemapServiceGetRobotsNoUrl(t *testing.T, service SitemapService) {
+	siteCall := mockSiteService.
+		On("Get").
+		Return(&site.Site{}, nil)
+
+	got, err := service.GetRobots("")
+
+	require.NoError(t, err, "should not return error")
+	require.Equal(t, `User-agent: *
+Disallow: /admin
+`, got, "should leave out sitemap")
+
+	siteCall.Unset()
+}
+
+type MockArticleService struct {
+	mock.Mock
+}
+
+func (m *MockArticleService) DeleteById(id int) error {
+	args := m.Called(id)
+
+	return args.Error(0)
+}
+
+func (m *MockArticleService) Create(a *article.ArticleNewRequestDto) (*article.ArticleResponseDto, error) {
+	args := m.Called(a)
+
+	return args.Get(0).(*article.ArticleResponseDto), args.Error(1)
+}
+
+func (m *MockArticleService) GetAll(page pagination.Request) (*pagination.Page[article.ArticleResponseDto], error) {
+	args := m.Called(page)
+
+	return args.Get(0).(*pagination.Page[article.ArticleResponseDto]), args.Error(1)
+}
+
+func (m *MockArticleService) GetManyByAttribute(attr, value string, page pagination.Request) (*pagination.Page[article.ArticleResponseDto], error) {
+	args := m.Called(attr, value, page)
+
+	return args.Get(0).(*pagination.Page[article.ArticleResponseDto]), args.Error(1)
+}
+
+func (m *MockArticleService) GetByAttribute(attr, value string) (*article.ArticleResponseDto, error) {
+	args := m.Called(attr, value)
+
+	return args.Get(0).(*article.ArticleResponseDto), args.Error(1)
+}
+
+func (m *MockArticleService) Update(a *article.ArticleUpdateRequestDto) (*article.ArticleResponseDto, error) {
+	args := m.Called(a)
+
+	return args.Get(0).(*article.ArticleResponseDto), args.Error(1)
+}
+
+func (m *MockArticleService) PublishScheduled(now time.Time) (*[]article.ArticleResponseDto, error) {
+	args := m.Called(now)
+
+	return args.Get(0).(*[]article.ArticleResponseDto), args.Error(1)
+}
+
+func (m *MockArticleService) GetRevisions(articleId int) (*[]article.ArticleRevisionResponseDto, error) {
+	args := m.Called(articleId)
+
+	return args.Get(0).(*[]article.ArticleRevisionResponseDto), args.Error(1)
+}
+
+func (m *MockArticleService) GetRevisionById(id int) (*article.ArticleRevisionResponseDto, error) {
+	args := m.Called(id)
+
+	return args.Get(0).(*article.ArticleRevisionResponseDto), args.Error(1)
+}
+
+func (m *MockArticleService) RestoreRevision(slug string, revisionId int) (*article.ArticleResponseDto, error) {
+	args := m.Called(slug, revisionId)
+
+	return args.Get(0).(*article.ArticleResponseDto), args.Error(1)
+}
+
+func (m *MockArticleService) Search(query string, page pagination.Request, statuses ...string) (*article.ArticleSearchResponseDto, error) {
+	args := m.Called(query, page, statuses)
+
+	return args.Get(0).(*article.ArticleSearchResponseDto), args.Error(1)
+}
+
+type MockTagService struct {
+	mock.Mock
+}
+
+func (m *MockTagService) Create(t *tag.TagNewRequestDto) (*tag.TagResponseDto, error) {
+	args := m.Called(t)
+
+	return args.Get(0).(*tag.TagResponseDto), args.Error(1)
+}
+
+func (m *MockTagService) DeleteById(id int) error {
+	args := m.Called(id)
+
+	return args.Error(0)
+}
+
+func (m *MockTagService) GetAll(page pagination.Request) (*pagination.Page[tag.TagResponseDto], error) {
+	args := m.Called(page)
+
+	return args.Get(0).(*pagination.Page[tag.TagResponseDto]), args.Error(1)
+}
+
+func (m *MockTagService) GetByAttribute(attr, value string) (*tag.TagResponseDto, error) {
+	args := m.Called(attr, value)
+
+	return args.Get(0).(*tag.TagResponseDto), args.Error(1)
+}
+
+func (m *MockTagService) Update(t *tag.TagUpdateRequestDto) (*tag.TagResponseDto, error) {
+	args := m.Called(t)
+
+	return args.Get(0).(*tag.TagResponseDto), args.Error(1)
+}
+
+type MockSiteService struct {
+	mock.Mock
+}
+
+func (m *MockSiteService) Create(key site.Key, value string) (*site.SiteItemResponseDto, error) {
+	args := m.Called(key, value)
+
+	return args.Get(0).(*site.SiteItemResponseDto), args.Error(1)
+}
+
+func (m *MockSiteService) Get() (*site.Site, error) {
+	args := m.Called()
+
+	return args.Get(0).(*site.Site), args.Error(1)
+}
+
+func (m *MockSiteService) GetAll() (*[]site.SettingResponseDto, error) {
+	args := m.Called()
+
+	return args.Get(0).(*[]site.SettingResponseDto), args.Error(1)
+}
+
+func (m *MockSiteService) GetByKey(key site.Key) (*site.SettingResponseDto, error) {
+	args := m.Called(key)
+
+	return args.Get(0).(*site.SettingResponseDto), args.Error(1)
+}
+
+func (m *MockSiteService) Update(values map[site.Key]string) error {
+	args := m.Called(values)
+
+	return args.Error(0)
+}
+
+func (m *MockSiteService) DeleteByKey(key site.Key) error {
+	args := m.Called(key)
+
+	return args.Error(0)
+}
+
+type MockPageService struct {
+	mock.Mock
+}
+
+func (m *MockPageService) Create(p *page.PageNewRequestDto) (*page.PageResponseDto, error) {
+	args := m.Called(p)
+
+	return args.Get(0).(*page.PageResponseDto), args.Error(1)
+}
+
+func (m *MockPageService) DeleteById(id int) error {
+	args := m.Called(id)
+
+	return args.Error(0)
+}
+
+func (m *MockPageService) GetAll() (*[]page.PageResponseDto, error) {
+	args := m.Called()
+
+	return args.Get(0).(*[]page.PageResponseDto), args.Error(1)
+}
+
+func (m *MockPageService) GetByAttribute(attr, value string) (*page.PageResponseDto, error) {
+	args := m.Called(attr, value)
+
+	return args.Get(0).(*page.PageResponseDto), args.Error(1)
+}
+
+func (m *MockPageService) GetChildren(id int) (*[]page.PageResponseDto, error) {
+	args := m.Called(id)
+
+	return args.Get(0).(*[]page.PageResponseDto), args.Error(1)
+}
+
+func (m *MockPageService) Update(p *page.PageUpdateRequestDto) (*page.PageResponseDto, error) {
+	args := m.Called(p)
+
+	return args.Get(0).(*page.PageResponseDto), args.Error(1)
+}
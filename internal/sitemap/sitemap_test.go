@@ -0,0 +1,112 @@
+package sitemap
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSitemap(t *testing.T) {
+	scenarios := map[string]func(t *testing.T){
+		"test render urlset":              testSitemapUrlset,
+		"test render index":               testSitemapIndex,
+		"test single page":                testSitemapSinglePage,
+		"test split across pages":         testSitemapSplitPages,
+		"test page out of range":          testSitemapPageOutOfRange,
+		"test page url":                   testSitemapPageUrl,
+		"test empty sitemap has no pages": testSitemapEmpty,
+	}
+
+	for scenario, fn := range scenarios {
+		t.Run(scenario, func(t *testing.T) {
+			fn(t)
+		})
+	}
+}
+
+func testSitemapUrlset(t *testing.T) {
+	lastMod := time.Date(2024, 5, 2, 10, 30, 0, 0, time.FixedZone("BST", 3600))
+
+	got, err := Urlset([]Url{
+		{Loc: "https://example.com/", LastMod: &lastMod},
+		{Loc: "https://example.com/tags/go"},
+	})
+
+	require.NoError(t, err, "should not return error")
+	require.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url>
+    <loc>https://example.com/</loc>
+    <lastmod>2024-05-02T09:30:00Z</lastmod>
+  </url>
+  <url>
+    <loc>https://example.com/tags/go</loc>
+  </url>
+</urlset>`, string(got), "should render sitemap")
+}
+
+func testSitemapIndex(t *testing.T) {
+	got, err := Index([]Url{
+		{Loc: "https://example.com/sitemaps/1.xml"},
+		{Loc: "https://example.com/sitemaps/2.xml"},
+	})
+
+	require.NoError(t, err, "should not return error")
+	require.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap>
+    <loc>https://example.com/sitemaps/1.xml</loc>
+  </sitemap>
+  <sitemap>
+    <loc>https://example.com/sitemaps/2.xml</loc>
+  </sitemap>
+</sitemapindex>`, string(got), "should render sitemap index")
+}
+
+func testSitemapSinglePage(t *testing.T) {
+	sitemap := Sitemap{Urls: make([]Url, MaxUrls)}
+
+	require.Equal(t, 1, sitemap.Pages(), "should fit on one page")
+}
+
+func testSitemapSplitPages(t *testing.T) {
+	urls := make([]Url, MaxUrls*2+1)
+	for index := range urls {
+		urls[index] = Url{Loc: fmt.Sprintf("https://example.com/articles/%d", index)}
+	}
+
+	sitemap := Sitemap{Urls: urls}
+
+	require.Equal(t, 3, sitemap.Pages(), "should split across pages")
+
+	first, ok := sitemap.Page(1)
+	require.True(t, ok)
+	require.Len(t, first, MaxUrls)
+	require.Equal(t, "https://example.com/articles/0", first[0].Loc)
+
+	last, ok := sitemap.Page(3)
+	require.True(t, ok)
+	require.Equal(t, []Url{{Loc: fmt.Sprintf("https://example.com/articles/%d", MaxUrls*2)}}, last)
+}
+
+func testSitemapPageOutOfRange(t *testing.T) {
+	sitemap := Sitemap{Urls: make([]Url, 3)}
+
+	_, ok := sitemap.Page(0)
+	require.False(t, ok, "should not have page zero")
+
+	_, ok = sitemap.Page(2)
+	require.False(t, ok, "should not have page past the end")
+}
+
+func testSitemapPageUrl(t *testing.T) {
+	sitemap := Sitemap{BaseUrl: "https://example.com"}
+
+	require.Equal(t, "https://example.com/sitemaps/2.xml", sitemap.PageUrl(2))
+}
+
+func testSitemapEmpty(t *testing.T) {
+	require.Equal(t, 0, Sitemap{}.Pages())
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/nixpig/dunce/internal/app/errors"
 	"github.com/nixpig/dunce/pkg/logging"
+	"github.com/nixpig/dunce/pkg/pagination"
 	"github.com/nixpig/dunce/pkg/session"
 	"github.com/nixpig/dunce/pkg/templates"
 )
@@ -37,7 +38,7 @@ type TagView struct {
 
 type TagsView struct {
 	Message         string
-	Tags            *[]TagResponseDto
+	Tags            *pagination.Page[TagResponseDto]
 	CsrfToken       string
 	IsAuthenticated bool
 }
@@ -73,7 +74,7 @@ func (t *TagController) AdminTagsHandler(
 		}
 
 		if _, err := t.tagService.Create(&tag); err != nil {
-			t.errorHandlers.InternalServerError(w, r)
+			t.errorHandlers.InternalServerError(w, r, err)
 			return
 		}
 
@@ -86,9 +87,9 @@ func (t *TagController) AdminTagsHandler(
 		http.Redirect(w, r, "/admin/tags", http.StatusSeeOther)
 
 	case "GET":
-		tags, err := t.tagService.GetAll()
+		tags, err := t.tagService.GetAll(pagination.FromQuery(r.URL.Query()))
 		if err != nil {
-			t.errorHandlers.InternalServerError(w, r)
+			t.errorHandlers.InternalServerError(w, r, err)
 			return
 		}
 
@@ -110,7 +111,7 @@ func (t *TagController) AdminTagsHandler(
 			tagView,
 		)
 		if err != nil {
-			t.errorHandlers.InternalServerError(w, r)
+			t.errorHandlers.InternalServerError(w, r, err)
 		}
 	}
 }
@@ -126,7 +127,7 @@ func (t *TagController) DeleteAdminTagsSlugHandler(
 	}
 
 	if err := t.tagService.DeleteById(id); err != nil {
-		t.errorHandlers.InternalServerError(w, r)
+		t.errorHandlers.InternalServerError(w, r, err)
 		return
 	}
 
@@ -149,7 +150,7 @@ func (t *TagController) AdminTagsSlugHandler(
 
 		tag, err := t.tagService.GetByAttribute("slug", slug)
 		if err != nil {
-			t.errorHandlers.InternalServerError(w, r)
+			t.errorHandlers.InternalServerError(w, r, err)
 			return
 		}
 
@@ -163,7 +164,7 @@ func (t *TagController) AdminTagsSlugHandler(
 		}
 
 		if err := t.templates["pages/admin/tag.tmpl"].ExecuteTemplate(w, "admin", tagView); err != nil {
-			t.errorHandlers.InternalServerError(w, r)
+			t.errorHandlers.InternalServerError(w, r, err)
 			return
 		}
 
@@ -181,7 +182,7 @@ func (t *TagController) AdminTagsSlugHandler(
 		}
 
 		if _, err := t.tagService.Update(&tag); err != nil {
-			t.errorHandlers.InternalServerError(w, r)
+			t.errorHandlers.InternalServerError(w, r, err)
 			return
 		}
 
@@ -208,7 +209,7 @@ func (t *TagController) GetAdminTagsNewHandler(
 	}
 
 	if err := t.templates["pages/admin/new-tag.tmpl"].ExecuteTemplate(w, "admin", tagView); err != nil {
-		t.errorHandlers.InternalServerError(w, r)
+		t.errorHandlers.InternalServerError(w, r, err)
 		return
 	}
 }
@@ -10,6 +10,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/nixpig/dunce/pkg/pagination"
 	"github.com/nixpig/dunce/pkg/session"
 	"github.com/nixpig/dunce/pkg/templates"
 	"github.com/stretchr/testify/mock"
@@ -73,14 +74,18 @@ func (e *MockErrorHandlers) NotFound(w http.ResponseWriter, r *http.Request) {
 	e.Called(w, r)
 }
 
-func (e *MockErrorHandlers) InternalServerError(w http.ResponseWriter, r *http.Request) {
-	e.Called(w, r)
+func (e *MockErrorHandlers) InternalServerError(w http.ResponseWriter, r *http.Request, err error) {
+	e.Called(w, r, err)
 }
 
 func (e *MockErrorHandlers) BadRequest(w http.ResponseWriter, r *http.Request) {
 	e.Called(w, r)
 }
 
+func (e *MockErrorHandlers) Forbidden(w http.ResponseWriter, r *http.Request) {
+	e.Called(w, r)
+}
+
 type MockSessionManager struct {
 	mock.Mock
 }
@@ -103,6 +108,12 @@ func (s *MockSessionManager) GetString(ctx context.Context, key string) string {
 	return args.String(0)
 }
 
+func (s *MockSessionManager) GetInt(ctx context.Context, key string) int {
+	args := s.Called(ctx, key)
+
+	return args.Int(0)
+}
+
 func (s *MockSessionManager) LoadAndSave(next http.Handler) http.Handler {
 	args := s.Called(next)
 
@@ -145,10 +156,10 @@ func (s *MockTagService) DeleteById(id int) error {
 	return args.Error(0)
 }
 
-func (s *MockTagService) GetAll() (*[]TagResponseDto, error) {
-	args := s.Called()
+func (s *MockTagService) GetAll(page pagination.Request) (*pagination.Page[TagResponseDto], error) {
+	args := s.Called(page)
 
-	return args.Get(0).(*[]TagResponseDto), args.Error(1)
+	return args.Get(0).(*pagination.Page[TagResponseDto]), args.Error(1)
 }
 
 func (s *MockTagService) GetByAttribute(
@@ -173,12 +184,20 @@ type MockLogger struct {
 	mock.Mock
 }
 
-func (l *MockLogger) Info(format string, values ...any) {
-	l.Called(format, values)
+func (l *MockLogger) Debug(ctx context.Context, msg string, args ...any) {
+	l.Called(msg, args)
 }
 
-func (l *MockLogger) Error(format string, values ...any) {
-	l.Called(format, values)
+func (l *MockLogger) Info(ctx context.Context, msg string, args ...any) {
+	l.Called(msg, args)
+}
+
+func (l *MockLogger) Warn(ctx context.Context, msg string, args ...any) {
+	l.Called(msg, args)
+}
+
+func (l *MockLogger) Error(ctx context.Context, msg string, args ...any) {
+	l.Called(msg, args)
 }
 
 var mockTemplate = new(MockTemplate)
@@ -257,7 +276,7 @@ func testGetAdminTagsNewHandlerTemplateError(t *testing.T, ctrl TagController) {
 		Return(errors.New("template_error"))
 
 	mockErrorHandlersInternalServerError := mockErrorHandlers.
-		On("InternalServerError", rr, req).
+		On("InternalServerError", rr, req, errors.New("template_error")).
 		Run(func(args mock.Arguments) {
 			rr.WriteHeader(http.StatusInternalServerError)
 		})
@@ -373,7 +392,7 @@ func testPostAdminTagsHandlerServiceError(t *testing.T, ctrl TagController) {
 	}).Return(&TagResponseDto{}, errors.New("service_error"))
 
 	mockErrorHandlersInternalServerError := mockErrorHandlers.
-		On("InternalServerError", rr, req).
+		On("InternalServerError", rr, req, errors.New("service_error")).
 		Run(func(args mock.Arguments) {
 			rr.WriteHeader(http.StatusInternalServerError)
 		})
@@ -527,7 +546,7 @@ func testPostAdminTagsDeleteHandlerServiceError(
 		Return(errors.New("service_error"))
 
 	mockErrorHandlersInternalServerError := mockErrorHandlers.
-		On("InternalServerError", rr, req).
+		On("InternalServerError", rr, req, errors.New("service_error")).
 		Run(func(args mock.Arguments) {
 			rr.WriteHeader(http.StatusInternalServerError)
 		})
@@ -563,7 +582,7 @@ func testGetAdminTagsHandler(t *testing.T, ctrl TagController) {
 
 	handler := http.HandlerFunc(ctrl.AdminTagsHandler)
 
-	mockServiceGetAll := mockService.On("GetAll").Return(&[]TagResponseDto{
+	mockServiceGetAll := mockService.On("GetAll", pagination.Request{}).Return(&pagination.Page[TagResponseDto]{Items: []TagResponseDto{
 		{
 			Id:   1,
 			Name: "tag one",
@@ -574,7 +593,7 @@ func testGetAdminTagsHandler(t *testing.T, ctrl TagController) {
 			Name: "tag two",
 			Slug: "tag-two",
 		},
-	}, nil)
+	}}, nil)
 
 	mockSessionManagerPopString := mockSessionManager.
 		On("PopString", req.Context(), "message").
@@ -590,7 +609,7 @@ func testGetAdminTagsHandler(t *testing.T, ctrl TagController) {
 	mockTemplateExecuteTemplate := mockTemplate.
 		On("ExecuteTemplate", rr, "admin", TagsView{
 			Message: "session_message",
-			Tags: &[]TagResponseDto{
+			Tags: &pagination.Page[TagResponseDto]{Items: []TagResponseDto{
 				{
 					Id:   1,
 					Name: "tag one",
@@ -601,7 +620,7 @@ func testGetAdminTagsHandler(t *testing.T, ctrl TagController) {
 					Name: "tag two",
 					Slug: "tag-two",
 				},
-			},
+			}},
 			CsrfToken:       "mock-token",
 			IsAuthenticated: true,
 		}).Return(nil)
@@ -643,11 +662,11 @@ func testGetAdminTagsHandlerServiceError(t *testing.T, ctrl TagController) {
 
 	handler := http.HandlerFunc(ctrl.AdminTagsHandler)
 
-	mockServiceGetAll := mockService.On("GetAll").
-		Return(&[]TagResponseDto{}, errors.New("service_error"))
+	mockServiceGetAll := mockService.On("GetAll", pagination.Request{}).
+		Return((*pagination.Page[TagResponseDto])(nil), errors.New("service_error"))
 
 	mockErrorHandlersInternalServerError := mockErrorHandlers.
-		On("InternalServerError", rr, req).
+		On("InternalServerError", rr, req, errors.New("service_error")).
 		Run(func(args mock.Arguments) {
 			rr.WriteHeader(http.StatusInternalServerError)
 		})
@@ -683,7 +702,7 @@ func testGetAdminTagsHandlerTemplateError(t *testing.T, ctrl TagController) {
 
 	handler := http.HandlerFunc(ctrl.AdminTagsHandler)
 
-	mockServiceGetAll := mockService.On("GetAll").Return(&[]TagResponseDto{
+	mockServiceGetAll := mockService.On("GetAll", pagination.Request{}).Return(&pagination.Page[TagResponseDto]{Items: []TagResponseDto{
 		{
 			Id:   1,
 			Name: "tag one",
@@ -694,7 +713,7 @@ func testGetAdminTagsHandlerTemplateError(t *testing.T, ctrl TagController) {
 			Name: "tag two",
 			Slug: "tag-two",
 		},
-	}, nil)
+	}}, nil)
 
 	mockSessionManagerPopString := mockSessionManager.On("PopString", req.Context(), "message").
 		Return("mock_message")
@@ -703,7 +722,7 @@ func testGetAdminTagsHandlerTemplateError(t *testing.T, ctrl TagController) {
 
 	mockTemplateExecuteTemplate := mockTemplate.On("ExecuteTemplate", rr, "admin", TagsView{
 		Message: "mock_message",
-		Tags: &[]TagResponseDto{
+		Tags: &pagination.Page[TagResponseDto]{Items: []TagResponseDto{
 			{
 				Id:   1,
 				Name: "tag one",
@@ -714,14 +733,14 @@ func testGetAdminTagsHandlerTemplateError(t *testing.T, ctrl TagController) {
 				Name: "tag two",
 				Slug: "tag-two",
 			},
-		},
+		}},
 		CsrfToken:       "mock-token",
 		IsAuthenticated: true,
 	}).
 		Return(errors.New("template_error"))
 
 	mockErrorHandlersInternalServerError := mockErrorHandlers.
-		On("InternalServerError", rr, req).
+		On("InternalServerError", rr, req, errors.New("template_error")).
 		Run(func(args mock.Arguments) {
 			rr.WriteHeader(http.StatusInternalServerError)
 		})
@@ -840,7 +859,7 @@ func testGetAdminTagsBySlugHandlerServiceError(
 		Return(&TagResponseDto{}, errors.New("service_error"))
 
 	mockErrorHandlersInternalServerError := mockErrorHandlers.
-		On("InternalServerError", rr, req).
+		On("InternalServerError", rr, req, errors.New("service_error")).
 		Run(func(args mock.Arguments) {
 			rr.WriteHeader(http.StatusInternalServerError)
 		})
@@ -892,7 +911,7 @@ func testGetAdminTagsBySlugHandlerTemplateError(
 		Return(true)
 
 	mockErrorHandlersInternalServerError := mockErrorHandlers.
-		On("InternalServerError", rr, req).
+		On("InternalServerError", rr, req, errors.New("template_error")).
 		Run(func(args mock.Arguments) {
 			rr.WriteHeader(http.StatusInternalServerError)
 		})
@@ -1084,7 +1103,7 @@ func testPostTagBySlugToUpdateHandlerServiceError(
 	}).Return(&TagResponseDto{}, errors.New("service_error"))
 
 	mockErrorHandlersInternalServerError := mockErrorHandlers.
-		On("InternalServerError", rr, req).
+		On("InternalServerError", rr, req, errors.New("service_error")).
 		Run(func(args mock.Arguments) {
 			rr.WriteHeader(http.StatusInternalServerError)
 		})
@@ -5,17 +5,29 @@ import (
 	"errors"
 
 	"github.com/nixpig/dunce/db"
+	"github.com/nixpig/dunce/pkg/pagination"
 )
 
 type TagRepository interface {
 	Create(tag *Tag) (*Tag, error)
 	DeleteById(id int) error
 	Exists(tag *Tag) (bool, error)
-	GetAll() (*[]Tag, error)
+	GetAll(page pagination.Request) (*pagination.Page[Tag], error)
 	GetByAttribute(attr, value string) (*Tag, error)
 	Update(tag *Tag) (*Tag, error)
 }
 
+var tagSortable = pagination.Sortable{
+	Columns: map[string]pagination.Column{
+		"id":   {Expr: "id_", Type: "integer"},
+		"name": {Expr: "name_", Type: "text"},
+		"slug": {Expr: "slug_", Type: "text"},
+	},
+	Id:               "id_",
+	DefaultSort:      "name",
+	DefaultDirection: pagination.Asc,
+}
+
 type tagPostgresRepository struct {
 	db db.Dbconn
 }
@@ -68,10 +80,20 @@ func (t tagPostgresRepository) Exists(tag *Tag) (bool, error) {
 	return false, nil
 }
 
-func (t tagPostgresRepository) GetAll() (*[]Tag, error) {
-	query := `select id_, name_, slug_ from tags_`
+func (t tagPostgresRepository) GetAll(page pagination.Request) (*pagination.Page[Tag], error) {
+	countQuery := `select count(*) from tags_`
+
+	q := tagSortable.Query(page, 1)
+
+	query := `select id_, name_, slug_, ` + q.Cursor + ` from tags_ where ` + q.Where + ` order by ` + q.OrderBy + ` ` + q.Limit
+
+	var total int
 
-	rows, err := t.db.Query(context.Background(), query)
+	if err := t.db.QueryRow(context.Background(), countQuery).Scan(&total); err != nil {
+		return nil, err
+	}
+
+	rows, err := t.db.Query(context.Background(), query, q.Args...)
 	if err != nil {
 		return nil, err
 	}
@@ -79,18 +101,23 @@ func (t tagPostgresRepository) GetAll() (*[]Tag, error) {
 	defer rows.Close()
 
 	var tags []Tag
+	var keys []pagination.Key
 
 	for rows.Next() {
 		var tag Tag
+		var key pagination.Key
 
-		if err := rows.Scan(&tag.Id, &tag.Name, &tag.Slug); err != nil {
+		if err := rows.Scan(&tag.Id, &tag.Name, &tag.Slug, &key.Value); err != nil {
 			return nil, err
 		}
 
+		key.Id = int64(tag.Id)
+
 		tags = append(tags, tag)
+		keys = append(keys, key)
 	}
 
-	return &tags, nil
+	return pagination.NewPage(q, tags, keys, total), nil
 }
 
 func (t tagPostgresRepository) GetByAttribute(attr, value string) (*Tag, error) {
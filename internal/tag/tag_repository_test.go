@@ -5,6 +5,7 @@ import (
 	"regexp"
 	"testing"
 
+	"github.com/nixpig/dunce/pkg/pagination"
 	"github.com/pashagolub/pgxmock/v3"
 	"github.com/stretchr/testify/require"
 )
@@ -188,16 +189,23 @@ func testTagRepoTagExistsError(t *testing.T, mock pgxmock.PgxPoolIface, repo Tag
 }
 
 func testTagRepoGetAllTagsNoResults(t *testing.T, mock pgxmock.PgxPoolIface, repo TagRepository) {
-	query := `select id_, name_, slug_ from tags_`
+	countQuery := `select count(*) from tags_`
+	query := `select id_, name_, slug_, (name_)::text from tags_ where true order by name_ asc, id_ asc limit $1 offset $2`
 
-	mockEmptyRows := mock.NewRows([]string{"id_", "name_", "slug_"}).AddRows()
+	mock.ExpectQuery(regexp.QuoteMeta(countQuery)).
+		WillReturnRows(mock.NewRows([]string{"count"}).AddRow(0))
 
-	mock.ExpectQuery(regexp.QuoteMeta(query)).WillReturnRows(mockEmptyRows)
+	mockEmptyRows := mock.NewRows([]string{"id_", "name_", "slug_", "cursor"}).AddRows()
 
-	tags, err := repo.GetAll()
+	mock.ExpectQuery(regexp.QuoteMeta(query)).
+		WithArgs(pagination.DefaultLimit+1, 0).
+		WillReturnRows(mockEmptyRows)
+
+	tags, err := repo.GetAll(pagination.Request{})
 
 	require.NoError(t, err, "should not return error")
-	require.Empty(t, tags, "should return zero results")
+	require.Empty(t, tags.Items, "should return zero results")
+	require.Equal(t, 0, tags.Total, "should return zero total")
 
 	if err := mock.ExpectationsWereMet(); err != nil {
 		t.Fatal("expectations were not met")
@@ -205,18 +213,24 @@ func testTagRepoGetAllTagsNoResults(t *testing.T, mock pgxmock.PgxPoolIface, rep
 }
 
 func testTagRepoGetAllTagsMultipleResults(t *testing.T, mock pgxmock.PgxPoolIface, repo TagRepository) {
-	query := `select id_, name_, slug_ from tags_`
+	countQuery := `select count(*) from tags_`
+	query := `select id_, name_, slug_, (name_)::text from tags_ where true order by name_ asc, id_ asc limit $1 offset $2`
 
-	singleResult := mock.
-		NewRows([]string{"id_", "name_", "slug_"}).
-		AddRow(23, "tagname1", "tag-slug-1").
-		AddRow(42, "tagname2", "tag-slug-2").
-		AddRow(69, "tagname3", "tag-slug-3")
+	mock.ExpectQuery(regexp.QuoteMeta(countQuery)).
+		WillReturnRows(mock.NewRows([]string{"count"}).AddRow(3))
 
-	mock.ExpectQuery(regexp.QuoteMeta(query)).WillReturnRows(singleResult)
+	multipleResults := mock.
+		NewRows([]string{"id_", "name_", "slug_", "cursor"}).
+		AddRow(23, "tagname1", "tag-slug-1", "tagname1").
+		AddRow(42, "tagname2", "tag-slug-2", "tagname2").
+		AddRow(69, "tagname3", "tag-slug-3", "tagname3")
 
-	tags, err := repo.GetAll()
-	require.Equal(t, &[]Tag{
+	mock.ExpectQuery(regexp.QuoteMeta(query)).
+		WithArgs(pagination.DefaultLimit+1, 0).
+		WillReturnRows(multipleResults)
+
+	tags, err := repo.GetAll(pagination.Request{})
+	require.Equal(t, []Tag{
 		{
 			Id:   23,
 			Name: "tagname1",
@@ -232,7 +246,9 @@ func testTagRepoGetAllTagsMultipleResults(t *testing.T, mock pgxmock.PgxPoolIfac
 			Name: "tagname3",
 			Slug: "tag-slug-3",
 		},
-	}, tags, "should return all tag results")
+	}, tags.Items, "should return all tag results")
+	require.Equal(t, 3, tags.Total, "should return total")
+	require.False(t, tags.HasNext(), "should not have next page")
 	require.NoError(t, err, "should not return an error")
 
 	if err := mock.ExpectationsWereMet(); err != nil {
@@ -241,23 +257,36 @@ func testTagRepoGetAllTagsMultipleResults(t *testing.T, mock pgxmock.PgxPoolIfac
 }
 
 func testTagRepoGetAllTagsSingleResult(t *testing.T, mock pgxmock.PgxPoolIface, repo TagRepository) {
-	query := `select id_, name_, slug_ from tags_`
+	countQuery := `select count(*) from tags_`
+	query := `select id_, name_, slug_, (slug_)::text from tags_ where true order by slug_ desc, id_ desc limit $1 offset $2`
 
-	singleResult := mock.
-		NewRows([]string{"id_", "name_", "slug_"}).
-		AddRow(23, "tagname", "tag-slug")
+	mock.ExpectQuery(regexp.QuoteMeta(countQuery)).
+		WillReturnRows(mock.NewRows([]string{"count"}).AddRow(4))
 
-	mock.ExpectQuery(regexp.QuoteMeta(query)).WillReturnRows(singleResult)
-
-	tags, err := repo.GetAll()
-	require.Equal(t, &[]Tag{
+	singleResult := mock.
+		NewRows([]string{"id_", "name_", "slug_", "cursor"}).
+		AddRow(23, "tagname", "tag-slug", "tag-slug")
+
+	mock.ExpectQuery(regexp.QuoteMeta(query)).
+		WithArgs(4, 3).
+		WillReturnRows(singleResult)
+
+	tags, err := repo.GetAll(pagination.Request{
+		Limit:     3,
+		Offset:    3,
+		Sort:      "slug",
+		Direction: pagination.Desc,
+	})
+	require.Equal(t, []Tag{
 		{
 
 			Id:   23,
 			Name: "tagname",
 			Slug: "tag-slug",
 		},
-	}, tags, "should return tag result")
+	}, tags.Items, "should return tag result")
+	require.True(t, tags.HasPrev(), "should have previous page")
+	require.False(t, tags.HasNext(), "should not have next page")
 	require.NoError(t, err, "should not return an error")
 
 	if err := mock.ExpectationsWereMet(); err != nil {
@@ -373,11 +402,17 @@ func testTagRepoFailCreateTagOnDbError(t *testing.T, mock pgxmock.PgxPoolIface,
 }
 
 func testTagRepoGetAllDbQueryError(t *testing.T, mock pgxmock.PgxPoolIface, repo TagRepository) {
-	query := `select id_, name_, slug_ from tags_`
+	countQuery := `select count(*) from tags_`
+	query := `select id_, name_, slug_, (name_)::text from tags_ where true order by name_ asc, id_ asc limit $1 offset $2`
+
+	mock.ExpectQuery(regexp.QuoteMeta(countQuery)).
+		WillReturnRows(mock.NewRows([]string{"count"}).AddRow(3))
 
-	mock.ExpectQuery(query).WillReturnError(errors.New("db_error"))
+	mock.ExpectQuery(regexp.QuoteMeta(query)).
+		WithArgs(pagination.DefaultLimit+1, 0).
+		WillReturnError(errors.New("db_error"))
 
-	tags, err := repo.GetAll()
+	tags, err := repo.GetAll(pagination.Request{})
 
 	require.Nil(t, tags, "should not return tags")
 
@@ -389,15 +424,21 @@ func testTagRepoGetAllDbQueryError(t *testing.T, mock pgxmock.PgxPoolIface, repo
 }
 
 func testTagRepoGetAllDbRowError(t *testing.T, mock pgxmock.PgxPoolIface, repo TagRepository) {
-	query := `select id_, name_, slug_ from tags_`
+	countQuery := `select count(*) from tags_`
+	query := `select id_, name_, slug_, (name_)::text from tags_ where true order by name_ asc, id_ asc limit $1 offset $2`
+
+	mock.ExpectQuery(regexp.QuoteMeta(countQuery)).
+		WillReturnRows(mock.NewRows([]string{"count"}).AddRow(3))
 
 	errorRow := mock.
-		NewRows([]string{"id_", "name_", "slug_"}).
-		AddRow("foo", "bar", "baz")
+		NewRows([]string{"id_", "name_", "slug_", "cursor"}).
+		AddRow("foo", "bar", "baz", "qux")
 
-	mock.ExpectQuery(query).WillReturnRows(errorRow)
+	mock.ExpectQuery(regexp.QuoteMeta(query)).
+		WithArgs(pagination.DefaultLimit+1, 0).
+		WillReturnRows(errorRow)
 
-	tags, err := repo.GetAll()
+	tags, err := repo.GetAll(pagination.Request{})
 
 	require.Empty(t, tags, "should not return tags")
 
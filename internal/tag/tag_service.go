@@ -4,12 +4,13 @@ import (
 	"strings"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/nixpig/dunce/pkg/pagination"
 )
 
 type TagService interface {
 	Create(tag *TagNewRequestDto) (*TagResponseDto, error)
 	DeleteById(id int) error
-	GetAll() (*[]TagResponseDto, error)
+	GetAll(page pagination.Request) (*pagination.Page[TagResponseDto], error)
 	GetByAttribute(attr, value string) (*TagResponseDto, error)
 	Update(tag *TagUpdateRequestDto) (*TagResponseDto, error)
 }
@@ -55,23 +56,19 @@ func (t TagServiceImpl) DeleteById(id int) error {
 	return t.repo.DeleteById(id)
 }
 
-func (t TagServiceImpl) GetAll() (*[]TagResponseDto, error) {
-	tags, err := t.repo.GetAll()
+func (t TagServiceImpl) GetAll(page pagination.Request) (*pagination.Page[TagResponseDto], error) {
+	tags, err := t.repo.GetAll(page)
 	if err != nil {
 		return nil, err
 	}
 
-	allTags := make([]TagResponseDto, len(*tags))
-
-	for index, tag := range *tags {
-		allTags[index] = TagResponseDto{
+	return pagination.Map(tags, func(tag Tag) TagResponseDto {
+		return TagResponseDto{
 			Id:   tag.Id,
 			Name: tag.Name,
 			Slug: tag.Slug,
 		}
-	}
-
-	return &allTags, nil
+	}), nil
 }
 
 func (t TagServiceImpl) GetByAttribute(attr, value string) (*TagResponseDto, error) {
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"testing"
 
+	"github.com/nixpig/dunce/pkg/pagination"
 	"github.com/nixpig/dunce/pkg/validation"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -71,10 +72,10 @@ func (m *MockTagRepository) Exists(tag *Tag) (bool, error) {
 	return args.Get(0).(bool), args.Error(1)
 }
 
-func (m *MockTagRepository) GetAll() (*[]Tag, error) {
-	args := m.Called()
+func (m *MockTagRepository) GetAll(page pagination.Request) (*pagination.Page[Tag], error) {
+	args := m.Called(page)
 
-	return args.Get(0).(*[]Tag), args.Error(1)
+	return args.Get(0).(*pagination.Page[Tag]), args.Error(1)
 }
 
 func (m *MockTagRepository) GetByAttribute(attr, slug string) (*Tag, error) {
@@ -373,22 +374,23 @@ func testTagServiceCreateExistingTag(t *testing.T, service TagService) {
 }
 
 func testTagServiceGetAllTagsNoResults(t *testing.T, service TagService) {
-	mockRepoGetAll := mockData.On("GetAll").Return(&[]Tag{}, nil)
+	mockRepoGetAll := mockData.On("GetAll", pagination.Request{}).
+		Return(&pagination.Page[Tag]{Items: []Tag{}}, nil)
 
-	got, err := service.GetAll()
+	got, err := service.GetAll(pagination.Request{})
 
 	if res := mockData.AssertExpectations(t); !res {
 		t.Error("unmet expectations")
 	}
 
 	require.Nil(t, err, "should not return error")
-	require.Empty(t, got, "should not return any tags")
+	require.Empty(t, got.Items, "should not return any tags")
 
 	mockRepoGetAll.Unset()
 }
 
 func testTagServiceGetAllTagsMultipleResults(t *testing.T, service TagService) {
-	mockRepoGetAll := mockData.On("GetAll").Return(&[]Tag{
+	mockRepoGetAll := mockData.On("GetAll", pagination.Request{}).Return(&pagination.Page[Tag]{Items: []Tag{
 		{
 			Id:   23,
 			Name: "tagname1",
@@ -404,16 +406,16 @@ func testTagServiceGetAllTagsMultipleResults(t *testing.T, service TagService) {
 			Name: "tagname3",
 			Slug: "tag-slug-3",
 		},
-	}, nil)
+	}, Total: 3}, nil)
 
-	got, err := service.GetAll()
+	got, err := service.GetAll(pagination.Request{})
 
 	if res := mockData.AssertExpectations(t); !res {
 		t.Error("unmet expectations")
 	}
 
 	require.Nil(t, err, "should not return error")
-	require.Equal(t, &[]TagResponseDto{
+	require.Equal(t, []TagResponseDto{
 		{
 			Id:   23,
 			Name: "tagname1",
@@ -429,34 +431,34 @@ func testTagServiceGetAllTagsMultipleResults(t *testing.T, service TagService) {
 			Name: "tagname3",
 			Slug: "tag-slug-3",
 		},
-	}, got, "should return all tags")
+	}, got.Items, "should return all tags")
 
 	mockRepoGetAll.Unset()
 }
 
 func testTagServiceGetAllTagsSingleResult(t *testing.T, service TagService) {
-	mockRepoGetAll := mockData.On("GetAll").Return(&[]Tag{
+	mockRepoGetAll := mockData.On("GetAll", pagination.Request{}).Return(&pagination.Page[Tag]{Items: []Tag{
 		{
 			Id:   69,
 			Name: "tagname3",
 			Slug: "tag-slug-3",
 		},
-	}, nil)
+	}, Total: 1}, nil)
 
-	tags, err := service.GetAll()
+	tags, err := service.GetAll(pagination.Request{})
 
 	if res := mockData.AssertExpectations(t); !res {
 		t.Error("unmet expectations")
 	}
 
 	require.Nil(t, err, "should not return error")
-	require.Equal(t, &[]TagResponseDto{
+	require.Equal(t, []TagResponseDto{
 		{
 			Id:   69,
 			Name: "tagname3",
 			Slug: "tag-slug-3",
 		},
-	}, tags, "should return all tags")
+	}, tags.Items, "should return all tags")
 
 	mockRepoGetAll.Unset()
 }
@@ -507,10 +509,10 @@ func testTagServiceGetBySlugTagDoesNotExist(t *testing.T, service TagService) {
 }
 
 func testTagServiceGetAllTagsRepoError(t *testing.T, service TagService) {
-	mockRepoGetAll := mockData.On("GetAll").
-		Return(&[]Tag{}, errors.New("getall_repo_error"))
+	mockRepoGetAll := mockData.On("GetAll", pagination.Request{}).
+		Return((*pagination.Page[Tag])(nil), errors.New("getall_repo_error"))
 
-	got, err := service.GetAll()
+	got, err := service.GetAll(pagination.Request{})
 
 	if res := mockData.AssertExpectations(t); !res {
 		t.Error("unmet expectations")
@@ -0,0 +1,261 @@
+package user
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/nixpig/dunce/internal/app/errors"
+	"github.com/nixpig/dunce/internal/site"
+	"github.com/nixpig/dunce/pkg/logging"
+	"github.com/nixpig/dunce/pkg/session"
+	"github.com/nixpig/dunce/pkg/templates"
+)
+
+type ApiTokenController struct {
+	service        ApiTokenService
+	siteService    site.SiteService
+	log            logging.Logger
+	templateCache  templates.TemplateCache
+	sessionManager session.SessionManager
+	csrfToken      func(r *http.Request) string
+	errorHandlers  errors.ErrorHandlers
+}
+
+type ApiTokenControllerConfig struct {
+	Log            logging.Logger
+	TemplateCache  templates.TemplateCache
+	SessionManager session.SessionManager
+	CsrfToken      func(*http.Request) string
+	ErrorHandlers  errors.ErrorHandlers
+}
+
+// ApiTokensView is the logged in user's API tokens. NewToken is only set
+// straight after a token is created, since it can't be shown again.
+type ApiTokensView struct {
+	Message         string
+	Tokens          []ApiToken
+	Scopes          []ApiScopeOption
+	NewToken        string
+	CsrfToken       string
+	IsAuthenticated bool
+}
+
+func NewApiTokenController(
+	service ApiTokenService,
+	siteService site.SiteService,
+	config ApiTokenControllerConfig,
+) ApiTokenController {
+	return ApiTokenController{
+		service:        service,
+		siteService:    siteService,
+		log:            config.Log,
+		templateCache:  config.TemplateCache,
+		sessionManager: config.SessionManager,
+		csrfToken:      config.CsrfToken,
+		errorHandlers:  config.ErrorHandlers,
+	}
+}
+
+func (a *ApiTokenController) ApiTokensGet(w http.ResponseWriter, r *http.Request) {
+	account, ok := a.loggedInUser(r)
+	if !ok {
+		a.errorHandlers.Forbidden(w, r)
+		return
+	}
+
+	a.apiTokens(
+		w,
+		r,
+		account,
+		a.sessionManager.PopString(r.Context(), session.SESSION_KEY_MESSAGE),
+		"",
+	)
+}
+
+// ApiTokensPost creates a token for the logged in user and shows it straight
+// away rather than redirecting, since it's never stored anywhere it could be
+// shown from again.
+func (a *ApiTokenController) ApiTokensPost(w http.ResponseWriter, r *http.Request) {
+	account, ok := a.loggedInUser(r)
+	if !ok {
+		a.errorHandlers.Forbidden(w, r)
+		return
+	}
+
+	// a token would let someone who hasn't set up two-factor authentication
+	// use the API without it
+	if !account.TwoFactorEnabled {
+		s, err := a.siteService.Get()
+		if err != nil {
+			a.errorHandlers.InternalServerError(w, r, fmt.Errorf("unable to get site settings: %w", err))
+			return
+		}
+
+		if s.RequireTwoFactor {
+			a.sessionManager.Put(
+				r.Context(),
+				session.SESSION_KEY_MESSAGE,
+				"You need to set up two-factor authentication before you can create API tokens.",
+			)
+			http.Redirect(w, r, "/admin/account/two-factor", http.StatusSeeOther)
+			return
+		}
+	}
+
+	if err := r.ParseForm(); err != nil {
+		a.errorHandlers.BadRequest(w, r)
+		return
+	}
+
+	expiresInDays, err := strconv.Atoi(r.PostForm.Get("expires_in_days"))
+	if err != nil {
+		a.errorHandlers.BadRequest(w, r)
+		return
+	}
+
+	scopes := []ApiScope{}
+
+	for _, scope := range r.PostForm["scopes"] {
+		scopes = append(scopes, ApiScope(scope))
+	}
+
+	token, secret, err := a.service.Create(account.Username, &ApiTokenNewRequestDto{
+		Name:          strings.TrimSpace(r.PostForm.Get("name")),
+		Scopes:        scopes,
+		ExpiresInDays: expiresInDays,
+	})
+	if err != nil {
+		if _, ok := err.(validator.ValidationErrors); ok {
+			a.sessionManager.Put(
+				r.Context(),
+				session.SESSION_KEY_MESSAGE,
+				"Unable to create token: give it a name and at least one scope.",
+			)
+			http.Redirect(w, r, "/admin/account/tokens", http.StatusSeeOther)
+			return
+		}
+
+		a.errorHandlers.InternalServerError(w, r, fmt.Errorf("unable to create api token: %w", err))
+		return
+	}
+
+	a.apiTokens(
+		w,
+		r,
+		account,
+		fmt.Sprintf("Created token '%s'. Copy it now, as it won't be shown again.", token.Name),
+		secret,
+	)
+}
+
+func (a *ApiTokenController) ApiTokenRevokePost(w http.ResponseWriter, r *http.Request) {
+	account, ok := a.loggedInUser(r)
+	if !ok {
+		a.errorHandlers.Forbidden(w, r)
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		a.errorHandlers.NotFound(w, r)
+		return
+	}
+
+	message := "Revoked token."
+
+	if err := a.service.Revoke(account.Username, uint(id)); err != nil {
+		if err != ErrInvalidApiToken {
+			a.errorHandlers.InternalServerError(w, r, fmt.Errorf("unable to revoke api token: %w", err))
+			return
+		}
+
+		message = "Unable to revoke token: it may have already been revoked."
+	}
+
+	a.sessionManager.Put(r.Context(), session.SESSION_KEY_MESSAGE, message)
+
+	http.Redirect(w, r, "/admin/account/tokens", http.StatusSeeOther)
+}
+
+// ApiMeResponse is who an API token belongs to and what it can be used for.
+type ApiMeResponse struct {
+	Username  string     `json:"username"`
+	Role      Role       `json:"role"`
+	TokenName string     `json:"token_name"`
+	Scopes    []ApiScope `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// ApiMeGet lets scripts check the token they're calling the API with.
+func (a *ApiTokenController) ApiMeGet(w http.ResponseWriter, r *http.Request) {
+	account, ok := a.loggedInUser(r)
+	if !ok {
+		a.errorHandlers.Forbidden(w, r)
+		return
+	}
+
+	apiToken, ok := r.Context().Value(session.API_TOKEN_CONTEXT_KEY).(*ApiToken)
+	if !ok {
+		a.errorHandlers.Forbidden(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(ApiMeResponse{
+		Username:  account.Username,
+		Role:      account.Role,
+		TokenName: apiToken.Name,
+		Scopes:    apiToken.Scopes,
+		ExpiresAt: apiToken.ExpiresAt,
+	}); err != nil {
+		a.log.Error(r.Context(), "unable to write api response", "err", err)
+	}
+}
+
+func (a *ApiTokenController) apiTokens(
+	w http.ResponseWriter,
+	r *http.Request,
+	account *UserResponseDto,
+	message string,
+	newToken string,
+) {
+	tokens, err := a.service.GetAll(account.Username)
+	if err != nil {
+		a.errorHandlers.InternalServerError(w, r, fmt.Errorf("unable to get api tokens: %w", err))
+		return
+	}
+
+	if err := a.templateCache["pages/admin/api-tokens.tmpl"].ExecuteTemplate(w, "admin", ApiTokensView{
+		Message:         message,
+		Tokens:          tokens,
+		Scopes:          ApiScopes,
+		NewToken:        newToken,
+		CsrfToken:       a.csrfToken(r),
+		IsAuthenticated: a.isAuthenticated(r),
+	}); err != nil {
+		a.errorHandlers.InternalServerError(w, r, err)
+	}
+}
+
+func (a *ApiTokenController) isAuthenticated(r *http.Request) bool {
+	isAuthenticated, ok := r.Context().Value(session.IS_LOGGED_IN_CONTEXT_KEY).(bool)
+	if !ok {
+		return false
+	}
+
+	return isAuthenticated
+}
+
+// loggedInUser returns the user whose permissions were checked for the
+// request.
+func (a *ApiTokenController) loggedInUser(r *http.Request) (*UserResponseDto, bool) {
+	loggedInUser, ok := r.Context().Value(session.LOGGED_IN_USER_CONTEXT_KEY).(*UserResponseDto)
+
+	return loggedInUser, ok
+}
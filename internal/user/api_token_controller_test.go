@@ -0,0 +1,353 @@
+package user
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/nixpig/dunce/internal/site"
+	"github.com/nixpig/dunce/pkg/session"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var mockApiTokenService = new(MockApiTokenService)
+
+func TestApiTokenController(t *testing.T) {
+	scenarios := map[string]func(t *testing.T, ctrl ApiTokenController){
+		"get api tokens (success)":                   testGetApiTokens,
+		"post api token (success)":                   testPostApiToken,
+		"post api token (error - validation)":        testPostApiTokenValidationError,
+		"post api token (error - two-factor needed)": testPostApiTokenTwoFactorNeeded,
+		"post revoke api token (success)":            testPostRevokeApiToken,
+		"post revoke api token (error - not found)":  testPostRevokeApiTokenNotFound,
+		"get api me (success)":                       testGetApiMe,
+	}
+
+	for scenario, fn := range scenarios {
+		t.Run(scenario, func(t *testing.T) {
+			config := ApiTokenControllerConfig{
+				Log:            mockLogger,
+				TemplateCache:  mockTemplateCache,
+				SessionManager: mockSessionManager,
+				CsrfToken: func(r *http.Request) string {
+					return "mock-token"
+				},
+				ErrorHandlers: mockErrorHandlers,
+			}
+
+			ctrl := NewApiTokenController(mockApiTokenService, mockSiteService, config)
+			fn(t, ctrl)
+		})
+	}
+}
+
+type MockApiTokenService struct {
+	mock.Mock
+}
+
+func (m *MockApiTokenService) GetAll(username string) ([]ApiToken, error) {
+	args := m.Called(username)
+
+	return args.Get(0).([]ApiToken), args.Error(1)
+}
+
+func (m *MockApiTokenService) Create(username string, token *ApiTokenNewRequestDto) (*ApiToken, string, error) {
+	args := m.Called(username, token)
+
+	return args.Get(0).(*ApiToken), args.String(1), args.Error(2)
+}
+
+func (m *MockApiTokenService) Authenticate(token string) (*ApiToken, error) {
+	args := m.Called(token)
+
+	return args.Get(0).(*ApiToken), args.Error(1)
+}
+
+func (m *MockApiTokenService) Revoke(username string, id uint) error {
+	args := m.Called(username, id)
+
+	return args.Error(0)
+}
+
+func testGetApiTokens(t *testing.T, ctrl ApiTokenController) {
+	req, err := http.NewRequest("GET", "/admin/account/tokens", nil)
+	if err != nil {
+		t.Error("unable to construct request")
+	}
+
+	req = withLoggedInUser(req, &UserResponseDto{Id: 23, Username: "janedoe", Role: RoleAuthor})
+
+	rr := httptest.NewRecorder()
+
+	handler := http.HandlerFunc(ctrl.ApiTokensGet)
+
+	tokens := []ApiToken{{Id: 1, Username: "janedoe", Name: "CI", Scopes: []ApiScope{ApiScopeRead}}}
+
+	mockSessionManagerPopString := mockSessionManager.
+		On("PopString", req.Context(), session.SESSION_KEY_MESSAGE).
+		Return("msg")
+
+	mockApiTokenServiceGetAll := mockApiTokenService.
+		On("GetAll", "janedoe").
+		Return(tokens, nil)
+
+	mockTemplateExecuteTemplate := mockTemplate.On(
+		"ExecuteTemplate",
+		rr,
+		"admin",
+		ApiTokensView{
+			Message:   "msg",
+			Tokens:    tokens,
+			Scopes:    ApiScopes,
+			CsrfToken: "mock-token",
+		},
+	).Return(nil)
+
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Result().StatusCode, "should return status code ok")
+
+	if res := mockTemplate.AssertExpectations(t); !res {
+		t.Error("should execute template with tokens")
+	}
+
+	mockSessionManagerPopString.Unset()
+	mockApiTokenServiceGetAll.Unset()
+	mockTemplateExecuteTemplate.Unset()
+}
+
+func testPostApiToken(t *testing.T, ctrl ApiTokenController) {
+	form := url.Values{}
+	form.Add("name", " CI ")
+	form.Add("scopes", "read")
+	form.Add("scopes", "write_articles")
+	form.Add("expires_in_days", "30")
+
+	req := newFormRequest(t, "/admin/account/tokens", form)
+	req = withLoggedInUser(req, &UserResponseDto{Id: 23, Username: "janedoe", Role: RoleAuthor})
+
+	rr := httptest.NewRecorder()
+
+	handler := http.HandlerFunc(ctrl.ApiTokensPost)
+
+	mockSiteServiceGet := mockSiteService.On("Get").Return(&site.Site{}, nil)
+
+	created := &ApiToken{Id: 2, Username: "janedoe", Name: "CI", Scopes: []ApiScope{ApiScopeRead, ApiScopeWriteArticles}}
+	tokens := []ApiToken{*created}
+
+	mockApiTokenServiceCreate := mockApiTokenService.
+		On("Create", "janedoe", &ApiTokenNewRequestDto{
+			Name:          "CI",
+			Scopes:        []ApiScope{ApiScopeRead, ApiScopeWriteArticles},
+			ExpiresInDays: 30,
+		}).
+		Return(created, "dunce_s3cr3t", nil)
+
+	mockApiTokenServiceGetAll := mockApiTokenService.
+		On("GetAll", "janedoe").
+		Return(tokens, nil)
+
+	mockTemplateExecuteTemplate := mockTemplate.On(
+		"ExecuteTemplate",
+		rr,
+		"admin",
+		ApiTokensView{
+			Message:   "Created token 'CI'. Copy it now, as it won't be shown again.",
+			Tokens:    tokens,
+			Scopes:    ApiScopes,
+			NewToken:  "dunce_s3cr3t",
+			CsrfToken: "mock-token",
+		},
+	).Return(nil)
+
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Result().StatusCode, "should return status code ok")
+
+	if res := mockTemplate.AssertExpectations(t); !res {
+		t.Error("should show the new token")
+	}
+
+	mockSiteServiceGet.Unset()
+	mockApiTokenServiceCreate.Unset()
+	mockApiTokenServiceGetAll.Unset()
+	mockTemplateExecuteTemplate.Unset()
+}
+
+func testPostApiTokenValidationError(t *testing.T, ctrl ApiTokenController) {
+	form := url.Values{}
+	form.Add("name", "")
+	form.Add("expires_in_days", "30")
+
+	req := newFormRequest(t, "/admin/account/tokens", form)
+	req = withLoggedInUser(req, &UserResponseDto{Id: 23, Username: "janedoe", Role: RoleAuthor})
+
+	rr := httptest.NewRecorder()
+
+	handler := http.HandlerFunc(ctrl.ApiTokensPost)
+
+	mockSiteServiceGet := mockSiteService.On("Get").Return(&site.Site{}, nil)
+
+	mockApiTokenServiceCreate := mockApiTokenService.
+		On("Create", "janedoe", &ApiTokenNewRequestDto{
+			Name:          "",
+			Scopes:        []ApiScope{},
+			ExpiresInDays: 30,
+		}).
+		Return((*ApiToken)(nil), "", validator.ValidationErrors{})
+
+	mockSessionManagerPut := mockSessionManager.On(
+		"Put",
+		req.Context(),
+		session.SESSION_KEY_MESSAGE,
+		"Unable to create token: give it a name and at least one scope.",
+	)
+
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusSeeOther, rr.Result().StatusCode, "should return status code see other")
+	require.Equal(t, "/admin/account/tokens", rr.Result().Header.Get("Location"), "should redirect to tokens page")
+
+	if res := mockSessionManager.AssertExpectations(t); !res {
+		t.Error("should put message in session")
+	}
+
+	mockSiteServiceGet.Unset()
+	mockApiTokenServiceCreate.Unset()
+	mockSessionManagerPut.Unset()
+}
+
+func testPostApiTokenTwoFactorNeeded(t *testing.T, ctrl ApiTokenController) {
+	form := url.Values{}
+	form.Add("name", "CI")
+	form.Add("scopes", "read")
+	form.Add("expires_in_days", "30")
+
+	req := newFormRequest(t, "/admin/account/tokens", form)
+	req = withLoggedInUser(req, &UserResponseDto{Id: 23, Username: "janedoe", Role: RoleAuthor})
+
+	rr := httptest.NewRecorder()
+
+	handler := http.HandlerFunc(ctrl.ApiTokensPost)
+
+	// there's no expectation for the token service, so creating a token fails
+	// the test
+	mockSiteServiceGet := mockSiteService.On("Get").Return(&site.Site{RequireTwoFactor: true}, nil)
+
+	mockSessionManagerPut := mockSessionManager.On(
+		"Put",
+		req.Context(),
+		session.SESSION_KEY_MESSAGE,
+		"You need to set up two-factor authentication before you can create API tokens.",
+	)
+
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusSeeOther, rr.Result().StatusCode, "should return status code see other")
+	require.Equal(t, "/admin/account/two-factor", rr.Result().Header.Get("Location"), "should redirect to set up two-factor")
+
+	if res := mockSessionManager.AssertExpectations(t); !res {
+		t.Error("should put message in session")
+	}
+
+	mockSiteServiceGet.Unset()
+	mockSessionManagerPut.Unset()
+}
+
+func testPostRevokeApiToken(t *testing.T, ctrl ApiTokenController) {
+	req, err := http.NewRequest("POST", "/admin/account/tokens/{id}/revoke", nil)
+	if err != nil {
+		t.Error("unable to construct request")
+	}
+
+	req.SetPathValue("id", "2")
+	req = withLoggedInUser(req, &UserResponseDto{Id: 23, Username: "janedoe", Role: RoleAuthor})
+
+	rr := httptest.NewRecorder()
+
+	handler := http.HandlerFunc(ctrl.ApiTokenRevokePost)
+
+	mockApiTokenServiceRevoke := mockApiTokenService.On("Revoke", "janedoe", uint(2)).Return(nil)
+
+	mockSessionManagerPut := mockSessionManager.
+		On("Put", req.Context(), session.SESSION_KEY_MESSAGE, "Revoked token.")
+
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusSeeOther, rr.Result().StatusCode, "should return status code see other")
+	require.Equal(t, "/admin/account/tokens", rr.Result().Header.Get("Location"), "should redirect to tokens page")
+
+	if res := mockApiTokenService.AssertExpectations(t); !res {
+		t.Error("should revoke token")
+	}
+
+	mockApiTokenServiceRevoke.Unset()
+	mockSessionManagerPut.Unset()
+}
+
+func testPostRevokeApiTokenNotFound(t *testing.T, ctrl ApiTokenController) {
+	req, err := http.NewRequest("POST", "/admin/account/tokens/{id}/revoke", nil)
+	if err != nil {
+		t.Error("unable to construct request")
+	}
+
+	req.SetPathValue("id", "2")
+	req = withLoggedInUser(req, &UserResponseDto{Id: 23, Username: "janedoe", Role: RoleAuthor})
+
+	rr := httptest.NewRecorder()
+
+	handler := http.HandlerFunc(ctrl.ApiTokenRevokePost)
+
+	mockApiTokenServiceRevoke := mockApiTokenService.On("Revoke", "janedoe", uint(2)).Return(ErrInvalidApiToken)
+
+	mockSessionManagerPut := mockSessionManager.On(
+		"Put",
+		req.Context(),
+		session.SESSION_KEY_MESSAGE,
+		"Unable to revoke token: it may have already been revoked.",
+	)
+
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusSeeOther, rr.Result().StatusCode, "should return status code see other")
+
+	if res := mockSessionManager.AssertExpectations(t); !res {
+		t.Error("should put message in session")
+	}
+
+	mockApiTokenServiceRevoke.Unset()
+	mockSessionManagerPut.Unset()
+}
+
+func testGetApiMe(t *testing.T, ctrl ApiTokenController) {
+	req, err := http.NewRequest("GET", "/api/v1/me", nil)
+	if err != nil {
+		t.Error("unable to construct request")
+	}
+
+	req = withLoggedInUser(req, &UserResponseDto{Id: 23, Username: "janedoe", Role: RoleAuthor})
+	req = req.WithContext(context.WithValue(
+		req.Context(),
+		session.API_TOKEN_CONTEXT_KEY,
+		&ApiToken{Id: 2, Username: "janedoe", Name: "CI", Scopes: []ApiScope{ApiScopeRead}},
+	))
+
+	rr := httptest.NewRecorder()
+
+	handler := http.HandlerFunc(ctrl.ApiMeGet)
+
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Result().StatusCode, "should return status code ok")
+	require.Equal(t, "application/json", rr.Result().Header.Get("Content-Type"), "should return json")
+	require.JSONEq(
+		t,
+		`{"username":"janedoe","role":"author","token_name":"CI","scopes":["read"],"expires_at":null}`,
+		rr.Body.String(),
+		"should return token user and scopes",
+	)
+}
@@ -0,0 +1,150 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/nixpig/dunce/db"
+)
+
+// ApiTokenRepository stores API tokens by their hash, so a leaked database
+// can't be used to call the API.
+type ApiTokenRepository interface {
+	GetAllByUsername(username string) ([]ApiToken, error)
+	Create(username, name, tokenHash string, scopes []ApiScope, expiresAt *time.Time) (*ApiToken, error)
+	Use(tokenHash string, now time.Time) (*ApiToken, error)
+	Delete(username string, id uint) error
+}
+
+type apiTokenPostgresRepository struct {
+	db db.Dbconn
+}
+
+func NewApiTokenPostgresRepository(db db.Dbconn) apiTokenPostgresRepository {
+	return apiTokenPostgresRepository{
+		db: db,
+	}
+}
+
+func (a apiTokenPostgresRepository) GetAllByUsername(username string) ([]ApiToken, error) {
+	query := `select t.id_, u.username_, t.name_, t.scopes_, t.expires_at_, t.last_used_at_, t.created_at_ from api_tokens_ t join users_ u on u.id_ = t.user_id_ where u.username_ = $1 order by t.created_at_ desc, t.id_ desc`
+
+	rows, err := a.db.Query(context.Background(), query, username)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	tokens := []ApiToken{}
+
+	for rows.Next() {
+		token, err := scanApiToken(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		tokens = append(tokens, *token)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+// Create stores a token for the user with username.
+func (a apiTokenPostgresRepository) Create(
+	username, name, tokenHash string,
+	scopes []ApiScope,
+	expiresAt *time.Time,
+) (*ApiToken, error) {
+	query := `insert into api_tokens_ (user_id_, name_, token_hash_, scopes_, expires_at_) select id_, $2, $3, $4, $5 from users_ where username_ = $1 returning id_, created_at_`
+
+	row := a.db.QueryRow(context.Background(), query, username, name, tokenHash, scopeStrings(scopes), expiresAt)
+
+	token := ApiToken{
+		Username:  username,
+		Name:      name,
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+	}
+
+	if err := row.Scan(&token.Id, &token.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+// Use returns the token with tokenHash, recording that it was used at now,
+// or ErrInvalidApiToken if there's no such token or it's expired.
+func (a apiTokenPostgresRepository) Use(tokenHash string, now time.Time) (*ApiToken, error) {
+	query := `update api_tokens_ t set last_used_at_ = $2 from users_ u where u.id_ = t.user_id_ and t.token_hash_ = $1 and (t.expires_at_ is null or t.expires_at_ > $2) returning t.id_, u.username_, t.name_, t.scopes_, t.expires_at_, t.last_used_at_, t.created_at_`
+
+	row := a.db.QueryRow(context.Background(), query, tokenHash, now)
+
+	token, err := scanApiToken(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrInvalidApiToken
+		}
+
+		return nil, err
+	}
+
+	return token, nil
+}
+
+// Delete removes the token with id, as long as it belongs to the user with
+// username.
+func (a apiTokenPostgresRepository) Delete(username string, id uint) error {
+	query := `delete from api_tokens_ t using users_ u where u.id_ = t.user_id_ and u.username_ = $1 and t.id_ = $2`
+
+	res, err := a.db.Exec(context.Background(), query, username, id)
+	if err != nil {
+		return err
+	}
+
+	if res.RowsAffected() == 0 {
+		return ErrInvalidApiToken
+	}
+
+	return nil
+}
+
+func scanApiToken(row pgx.Row) (*ApiToken, error) {
+	var token ApiToken
+	var scopes []string
+
+	if err := row.Scan(
+		&token.Id,
+		&token.Username,
+		&token.Name,
+		&scopes,
+		&token.ExpiresAt,
+		&token.LastUsedAt,
+		&token.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	for _, scope := range scopes {
+		token.Scopes = append(token.Scopes, ApiScope(scope))
+	}
+
+	return &token, nil
+}
+
+func scopeStrings(scopes []ApiScope) []string {
+	s := make([]string, len(scopes))
+
+	for i, scope := range scopes {
+		s[i] = string(scope)
+	}
+
+	return s
+}
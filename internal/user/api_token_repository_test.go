@@ -0,0 +1,208 @@
+package user
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/pashagolub/pgxmock/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApiTokenRepo(t *testing.T) {
+	scenarios := map[string]func(t *testing.T, mock pgxmock.PgxPoolIface, repo ApiTokenRepository){
+		"get all tokens by username (success)":          testApiTokenRepoGetAllByUsername,
+		"get all tokens by username (error - db error)": testApiTokenRepoGetAllByUsernameDbError,
+		"create token (success)":                        testApiTokenRepoCreate,
+		"use token (success)":                           testApiTokenRepoUse,
+		"use token (error - invalid)":                   testApiTokenRepoUseInvalid,
+		"delete token (success)":                        testApiTokenRepoDelete,
+		"delete token (error - not found)":              testApiTokenRepoDeleteNotFound,
+	}
+
+	for scenario, fn := range scenarios {
+		t.Run(scenario, func(t *testing.T) {
+			db, err := pgxmock.NewPool()
+			if err != nil {
+				t.Fatal("unable to create mock db pool")
+			}
+
+			defer db.Close()
+
+			repo := NewApiTokenPostgresRepository(db)
+
+			fn(t, db, repo)
+		})
+	}
+}
+
+func testApiTokenRepoGetAllByUsername(t *testing.T, mock pgxmock.PgxPoolIface, repo ApiTokenRepository) {
+	query := `select t.id_, u.username_, t.name_, t.scopes_, t.expires_at_, t.last_used_at_, t.created_at_ from api_tokens_ t join users_ u on u.id_ = t.user_id_ where u.username_ = $1 order by t.created_at_ desc, t.id_ desc`
+
+	createdAt := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	expiresAt := createdAt.AddDate(0, 0, 30)
+	lastUsedAt := createdAt.Add(time.Hour)
+
+	mockRows := mock.
+		NewRows([]string{"id_", "username_", "name_", "scopes_", "expires_at_", "last_used_at_", "created_at_"}).
+		AddRow(uint(2), "janedoe", "CI", []string{"read", "write_articles"}, &expiresAt, &lastUsedAt, createdAt).
+		AddRow(uint(1), "janedoe", "Laptop", []string{"admin"}, (*time.Time)(nil), (*time.Time)(nil), createdAt)
+
+	mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs("janedoe").WillReturnRows(mockRows)
+
+	tokens, err := repo.GetAllByUsername("janedoe")
+
+	require.NoError(t, err, "should not return error")
+	require.Equal(t, []ApiToken{
+		{
+			Id:         2,
+			Username:   "janedoe",
+			Name:       "CI",
+			Scopes:     []ApiScope{ApiScopeRead, ApiScopeWriteArticles},
+			ExpiresAt:  &expiresAt,
+			LastUsedAt: &lastUsedAt,
+			CreatedAt:  createdAt,
+		},
+		{
+			Id:        1,
+			Username:  "janedoe",
+			Name:      "Laptop",
+			Scopes:    []ApiScope{ApiScopeAdmin},
+			CreatedAt: createdAt,
+		},
+	}, tokens, "should return tokens")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal("expectations not met: ", err)
+	}
+}
+
+func testApiTokenRepoGetAllByUsernameDbError(t *testing.T, mock pgxmock.PgxPoolIface, repo ApiTokenRepository) {
+	query := `select t.id_, u.username_, t.name_, t.scopes_, t.expires_at_, t.last_used_at_, t.created_at_ from api_tokens_ t join users_ u on u.id_ = t.user_id_ where u.username_ = $1 order by t.created_at_ desc, t.id_ desc`
+
+	mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs("janedoe").WillReturnError(errors.New("db_error"))
+
+	tokens, err := repo.GetAllByUsername("janedoe")
+
+	require.EqualError(t, err, "db_error", "should return db error")
+	require.Nil(t, tokens, "should not return tokens")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal("expectations not met: ", err)
+	}
+}
+
+func testApiTokenRepoCreate(t *testing.T, mock pgxmock.PgxPoolIface, repo ApiTokenRepository) {
+	query := `insert into api_tokens_ (user_id_, name_, token_hash_, scopes_, expires_at_) select id_, $2, $3, $4, $5 from users_ where username_ = $1 returning id_, created_at_`
+
+	createdAt := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	expiresAt := createdAt.AddDate(0, 0, 30)
+
+	mockRows := mock.
+		NewRows([]string{"id_", "created_at_"}).
+		AddRow(uint(23), createdAt)
+
+	mock.
+		ExpectQuery(regexp.QuoteMeta(query)).
+		WithArgs("janedoe", "CI", "h4sh", []string{"read", "write_articles"}, &expiresAt).
+		WillReturnRows(mockRows)
+
+	token, err := repo.Create("janedoe", "CI", "h4sh", []ApiScope{ApiScopeRead, ApiScopeWriteArticles}, &expiresAt)
+
+	require.NoError(t, err, "should not return error")
+	require.Equal(t, &ApiToken{
+		Id:        23,
+		Username:  "janedoe",
+		Name:      "CI",
+		Scopes:    []ApiScope{ApiScopeRead, ApiScopeWriteArticles},
+		ExpiresAt: &expiresAt,
+		CreatedAt: createdAt,
+	}, token, "should return created token")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal("expectations not met: ", err)
+	}
+}
+
+func testApiTokenRepoUse(t *testing.T, mock pgxmock.PgxPoolIface, repo ApiTokenRepository) {
+	query := `update api_tokens_ t set last_used_at_ = $2 from users_ u where u.id_ = t.user_id_ and t.token_hash_ = $1 and (t.expires_at_ is null or t.expires_at_ > $2) returning t.id_, u.username_, t.name_, t.scopes_, t.expires_at_, t.last_used_at_, t.created_at_`
+
+	createdAt := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	now := createdAt.Add(time.Hour)
+
+	mockRows := mock.
+		NewRows([]string{"id_", "username_", "name_", "scopes_", "expires_at_", "last_used_at_", "created_at_"}).
+		AddRow(uint(23), "janedoe", "CI", []string{"read"}, (*time.Time)(nil), &now, createdAt)
+
+	mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs("h4sh", now).WillReturnRows(mockRows)
+
+	token, err := repo.Use("h4sh", now)
+
+	require.NoError(t, err, "should not return error")
+	require.Equal(t, &ApiToken{
+		Id:         23,
+		Username:   "janedoe",
+		Name:       "CI",
+		Scopes:     []ApiScope{ApiScopeRead},
+		LastUsedAt: &now,
+		CreatedAt:  createdAt,
+	}, token, "should return token")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal("expectations not met: ", err)
+	}
+}
+
+func testApiTokenRepoUseInvalid(t *testing.T, mock pgxmock.PgxPoolIface, repo ApiTokenRepository) {
+	query := `update api_tokens_ t set last_used_at_ = $2 from users_ u where u.id_ = t.user_id_ and t.token_hash_ = $1 and (t.expires_at_ is null or t.expires_at_ > $2) returning t.id_, u.username_, t.name_, t.scopes_, t.expires_at_, t.last_used_at_, t.created_at_`
+
+	now := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	mockRows := mock.NewRows([]string{"id_", "username_", "name_", "scopes_", "expires_at_", "last_used_at_", "created_at_"})
+
+	mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs("h4sh", now).WillReturnRows(mockRows)
+
+	token, err := repo.Use("h4sh", now)
+
+	require.Equal(t, ErrInvalidApiToken, err, "should return invalid token error")
+	require.Nil(t, token, "should not return token")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal("expectations not met: ", err)
+	}
+}
+
+func testApiTokenRepoDelete(t *testing.T, mock pgxmock.PgxPoolIface, repo ApiTokenRepository) {
+	query := `delete from api_tokens_ t using users_ u where u.id_ = t.user_id_ and u.username_ = $1 and t.id_ = $2`
+
+	mock.
+		ExpectExec(regexp.QuoteMeta(query)).
+		WithArgs("janedoe", uint(23)).
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+
+	err := repo.Delete("janedoe", 23)
+
+	require.NoError(t, err, "should not return error")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal("expectations not met: ", err)
+	}
+}
+
+func testApiTokenRepoDeleteNotFound(t *testing.T, mock pgxmock.PgxPoolIface, repo ApiTokenRepository) {
+	query := `delete from api_tokens_ t using users_ u where u.id_ = t.user_id_ and u.username_ = $1 and t.id_ = $2`
+
+	mock.
+		ExpectExec(regexp.QuoteMeta(query)).
+		WithArgs("janedoe", uint(23)).
+		WillReturnResult(pgxmock.NewResult("DELETE", 0))
+
+	err := repo.Delete("janedoe", 23)
+
+	require.Equal(t, ErrInvalidApiToken, err, "should return invalid token error")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal("expectations not met: ", err)
+	}
+}
@@ -0,0 +1,96 @@
+package user
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// apiTokenPrefix starts every API token, so they're easy to recognise, e.g.
+// by secret scanners.
+const apiTokenPrefix = "dunce_"
+
+type ApiTokenService interface {
+	GetAll(username string) ([]ApiToken, error)
+	Create(username string, token *ApiTokenNewRequestDto) (*ApiToken, string, error)
+	Authenticate(token string) (*ApiToken, error)
+	Revoke(username string, id uint) error
+}
+
+type ApiTokenServiceImpl struct {
+	repo     ApiTokenRepository
+	validate *validator.Validate
+}
+
+func NewApiTokenService(repo ApiTokenRepository, validate *validator.Validate) ApiTokenServiceImpl {
+	return ApiTokenServiceImpl{
+		repo:     repo,
+		validate: validate,
+	}
+}
+
+func (a ApiTokenServiceImpl) GetAll(username string) ([]ApiToken, error) {
+	return a.repo.GetAllByUsername(username)
+}
+
+// Create makes a new token for the user with username, returning it along
+// with the token itself, which can't be got again later.
+func (a ApiTokenServiceImpl) Create(username string, newToken *ApiTokenNewRequestDto) (*ApiToken, string, error) {
+	if err := a.validate.Struct(newToken); err != nil {
+		return nil, "", err
+	}
+
+	secret, err := newApiToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	var expiresAt *time.Time
+
+	if newToken.ExpiresInDays > 0 {
+		t := time.Now().AddDate(0, 0, newToken.ExpiresInDays)
+		expiresAt = &t
+	}
+
+	token, err := a.repo.Create(username, newToken.Name, hashApiToken(secret), newToken.Scopes, expiresAt)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return token, secret, nil
+}
+
+// Authenticate returns the token for an API request, or ErrInvalidApiToken
+// if it isn't one or has expired.
+func (a ApiTokenServiceImpl) Authenticate(token string) (*ApiToken, error) {
+	if !strings.HasPrefix(token, apiTokenPrefix) {
+		return nil, ErrInvalidApiToken
+	}
+
+	return a.repo.Use(hashApiToken(token), time.Now())
+}
+
+func (a ApiTokenServiceImpl) Revoke(username string, id uint) error {
+	return a.repo.Delete(username, id)
+}
+
+func newApiToken() (string, error) {
+	b := make([]byte, 32)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return apiTokenPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashApiToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,170 @@
+package user
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/nixpig/dunce/pkg/validation"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var mockApiTokenRepo = new(MockApiTokenRepo)
+
+func TestApiTokenService(t *testing.T) {
+	scenarios := map[string]func(t *testing.T, service ApiTokenService){
+		"create token (success)":                  testApiTokenServiceCreate,
+		"create token (success - never expires)":  testApiTokenServiceCreateNeverExpires,
+		"create token (error - validation)":       testApiTokenServiceCreateValidationError,
+		"authenticate (success)":                  testApiTokenServiceAuthenticate,
+		"authenticate (error - not an api token)": testApiTokenServiceAuthenticateNotApiToken,
+		"revoke token (success)":                  testApiTokenServiceRevoke,
+	}
+
+	for scenario, fn := range scenarios {
+		t.Run(scenario, func(t *testing.T) {
+			validator, err := validation.NewValidator()
+			if err != nil {
+				t.Fatal("unable to construct validator")
+			}
+
+			service := NewApiTokenService(mockApiTokenRepo, validator)
+
+			fn(t, service)
+		})
+	}
+}
+
+type MockApiTokenRepo struct {
+	mock.Mock
+}
+
+func (m *MockApiTokenRepo) GetAllByUsername(username string) ([]ApiToken, error) {
+	args := m.Called(username)
+
+	return args.Get(0).([]ApiToken), args.Error(1)
+}
+
+func (m *MockApiTokenRepo) Create(
+	username, name, tokenHash string,
+	scopes []ApiScope,
+	expiresAt *time.Time,
+) (*ApiToken, error) {
+	args := m.Called(username, name, tokenHash, scopes, expiresAt)
+
+	return args.Get(0).(*ApiToken), args.Error(1)
+}
+
+func (m *MockApiTokenRepo) Use(tokenHash string, now time.Time) (*ApiToken, error) {
+	args := m.Called(tokenHash, now)
+
+	return args.Get(0).(*ApiToken), args.Error(1)
+}
+
+func (m *MockApiTokenRepo) Delete(username string, id uint) error {
+	args := m.Called(username, id)
+
+	return args.Error(0)
+}
+
+func testApiTokenServiceCreate(t *testing.T, service ApiTokenService) {
+	var tokenHash string
+	var expiresAt *time.Time
+
+	created := &ApiToken{Id: 23, Username: "janedoe", Name: "CI"}
+
+	mockRepoCreate := mockApiTokenRepo.
+		On("Create", "janedoe", "CI", mock.Anything, []ApiScope{ApiScopeWriteArticles}, mock.Anything).
+		Run(func(args mock.Arguments) {
+			tokenHash = args.String(2)
+			expiresAt = args.Get(4).(*time.Time)
+		}).
+		Return(created, nil)
+
+	token, secret, err := service.Create("janedoe", &ApiTokenNewRequestDto{
+		Name:          "CI",
+		Scopes:        []ApiScope{ApiScopeWriteArticles},
+		ExpiresInDays: 30,
+	})
+
+	require.NoError(t, err, "should not return error")
+	require.Equal(t, created, token, "should return created token")
+	require.True(t, strings.HasPrefix(secret, "dunce_"), "should return prefixed token")
+	require.Equal(t, hashApiToken(secret), tokenHash, "should store hash of token")
+	require.NotEqual(t, secret, tokenHash, "should not store token")
+	require.WithinDuration(t, time.Now().AddDate(0, 0, 30), *expiresAt, time.Minute, "should expire in 30 days")
+
+	mockRepoCreate.Unset()
+}
+
+func testApiTokenServiceCreateNeverExpires(t *testing.T, service ApiTokenService) {
+	var expiresAt *time.Time
+
+	mockRepoCreate := mockApiTokenRepo.
+		On("Create", "janedoe", "CI", mock.Anything, []ApiScope{ApiScopeRead}, mock.Anything).
+		Run(func(args mock.Arguments) {
+			expiresAt = args.Get(4).(*time.Time)
+		}).
+		Return(&ApiToken{Id: 23}, nil)
+
+	_, _, err := service.Create("janedoe", &ApiTokenNewRequestDto{
+		Name:          "CI",
+		Scopes:        []ApiScope{ApiScopeRead},
+		ExpiresInDays: 0,
+	})
+
+	require.NoError(t, err, "should not return error")
+	require.Nil(t, expiresAt, "should not expire")
+
+	mockRepoCreate.Unset()
+}
+
+func testApiTokenServiceCreateValidationError(t *testing.T, service ApiTokenService) {
+	token, secret, err := service.Create("janedoe", &ApiTokenNewRequestDto{
+		Name:          "CI",
+		Scopes:        []ApiScope{"superuser"},
+		ExpiresInDays: 30,
+	})
+
+	require.IsType(t, validator.ValidationErrors{}, err, "should return validation error")
+	require.Nil(t, token, "should not return token")
+	require.Empty(t, secret, "should not return secret")
+}
+
+func testApiTokenServiceAuthenticate(t *testing.T, service ApiTokenService) {
+	apiToken := &ApiToken{Id: 23, Username: "janedoe", Scopes: []ApiScope{ApiScopeRead}}
+
+	mockRepoUse := mockApiTokenRepo.
+		On("Use", hashApiToken("dunce_s3cr3t"), mock.Anything).
+		Return(apiToken, nil)
+
+	token, err := service.Authenticate("dunce_s3cr3t")
+
+	require.NoError(t, err, "should not return error")
+	require.Equal(t, apiToken, token, "should return token")
+
+	mockRepoUse.Unset()
+}
+
+func testApiTokenServiceAuthenticateNotApiToken(t *testing.T, service ApiTokenService) {
+	token, err := service.Authenticate("s3cr3t")
+
+	require.Equal(t, ErrInvalidApiToken, err, "should return invalid token error")
+	require.Nil(t, token, "should not return token")
+}
+
+func testApiTokenServiceRevoke(t *testing.T, service ApiTokenService) {
+	mockRepoDelete := mockApiTokenRepo.On("Delete", "janedoe", uint(23)).Return(nil)
+
+	err := service.Revoke("janedoe", 23)
+
+	require.NoError(t, err, "should not return error")
+
+	if res := mockApiTokenRepo.AssertExpectations(t); !res {
+		t.Error("should delete token")
+	}
+
+	mockRepoDelete.Unset()
+}
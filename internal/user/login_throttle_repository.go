@@ -0,0 +1,101 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/nixpig/dunce/db"
+)
+
+// LoginThrottleRepository counts failed logins by key, which is what the
+// logins failed for prefixed with what it is, e.g. "username:janedoe" or
+// "ip:192.0.2.1".
+type LoginThrottleRepository interface {
+	Get(key string) (*LoginFailures, error)
+	LockedUntil(keys []string) (time.Time, error)
+	Fail(key string, now, since time.Time) (int, error)
+	Lock(key string, until time.Time) error
+	Delete(key string) error
+}
+
+type loginThrottlePostgresRepository struct {
+	db db.Dbconn
+}
+
+func NewLoginThrottlePostgresRepository(db db.Dbconn) loginThrottlePostgresRepository {
+	return loginThrottlePostgresRepository{
+		db: db,
+	}
+}
+
+// Get returns the failed logins for key, which are none if there's nothing
+// stored for it.
+func (l loginThrottlePostgresRepository) Get(key string) (*LoginFailures, error) {
+	query := `select failures_, locked_until_ from login_failures_ where key_ = $1`
+
+	row := l.db.QueryRow(context.Background(), query, key)
+
+	var failures LoginFailures
+
+	if err := row.Scan(&failures.Failures, &failures.LockedUntil); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return &LoginFailures{}, nil
+		}
+
+		return nil, err
+	}
+
+	return &failures, nil
+}
+
+// LockedUntil returns the latest time any of the keys are locked until, which
+// is in the past if none are locked.
+func (l loginThrottlePostgresRepository) LockedUntil(keys []string) (time.Time, error) {
+	query := `select coalesce(max(locked_until_), 'epoch') from login_failures_ where key_ = any($1)`
+
+	row := l.db.QueryRow(context.Background(), query, keys)
+
+	var lockedUntil time.Time
+
+	if err := row.Scan(&lockedUntil); err != nil {
+		return time.Time{}, err
+	}
+
+	return lockedUntil, nil
+}
+
+// Fail records a failed login for key at now, returning how many there have
+// been. Failures are counted from scratch again if the last one was before
+// since.
+func (l loginThrottlePostgresRepository) Fail(key string, now, since time.Time) (int, error) {
+	query := `insert into login_failures_ (key_, failures_, last_failed_at_) values ($1, 1, $2) on conflict (key_) do update set failures_ = case when login_failures_.last_failed_at_ < $3 then 1 else login_failures_.failures_ + 1 end, last_failed_at_ = $2 returning failures_`
+
+	row := l.db.QueryRow(context.Background(), query, key, now, since)
+
+	var failures int
+
+	if err := row.Scan(&failures); err != nil {
+		return 0, err
+	}
+
+	return failures, nil
+}
+
+func (l loginThrottlePostgresRepository) Lock(key string, until time.Time) error {
+	query := `update login_failures_ set locked_until_ = $2 where key_ = $1`
+
+	_, err := l.db.Exec(context.Background(), query, key, until)
+
+	return err
+}
+
+// Delete forgets the failed logins for key, which also unlocks it.
+func (l loginThrottlePostgresRepository) Delete(key string) error {
+	query := `delete from login_failures_ where key_ = $1`
+
+	_, err := l.db.Exec(context.Background(), query, key)
+
+	return err
+}
@@ -0,0 +1,210 @@
+package user
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/pashagolub/pgxmock/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoginThrottleRepo(t *testing.T) {
+	scenarios := map[string]func(t *testing.T, mock pgxmock.PgxPoolIface, repo LoginThrottleRepository){
+		"get failures (success)":              testLoginThrottleRepoGet,
+		"get failures (success - none)":       testLoginThrottleRepoGetNone,
+		"get locked until (success)":          testLoginThrottleRepoLockedUntil,
+		"get locked until (error - db error)": testLoginThrottleRepoLockedUntilDbError,
+		"record failure (success)":            testLoginThrottleRepoFail,
+		"record failure (error - db error)":   testLoginThrottleRepoFailDbError,
+		"lock (success)":                      testLoginThrottleRepoLock,
+		"delete failures (success)":           testLoginThrottleRepoDelete,
+		"delete failures (error - db error)":  testLoginThrottleRepoDeleteDbError,
+	}
+
+	for scenario, fn := range scenarios {
+		t.Run(scenario, func(t *testing.T) {
+			db, err := pgxmock.NewPool()
+			if err != nil {
+				t.Fatal("unable to create mock db pool")
+			}
+
+			defer db.Close()
+
+			repo := NewLoginThrottlePostgresRepository(db)
+
+			fn(t, db, repo)
+		})
+	}
+}
+
+func testLoginThrottleRepoGet(t *testing.T, mock pgxmock.PgxPoolIface, repo LoginThrottleRepository) {
+	query := `select failures_, locked_until_ from login_failures_ where key_ = $1`
+
+	lockedUntil := time.Date(2024, time.March, 1, 12, 30, 0, 0, time.UTC)
+
+	mockRows := mock.
+		NewRows([]string{"failures_", "locked_until_"}).
+		AddRow(7, lockedUntil)
+
+	mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs("username:janedoe").WillReturnRows(mockRows)
+
+	failures, err := repo.Get("username:janedoe")
+
+	require.NoError(t, err, "should not return error")
+	require.Equal(t, &LoginFailures{Failures: 7, LockedUntil: lockedUntil}, failures, "should return failures")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal("expectations not met: ", err)
+	}
+}
+
+func testLoginThrottleRepoGetNone(t *testing.T, mock pgxmock.PgxPoolIface, repo LoginThrottleRepository) {
+	query := `select failures_, locked_until_ from login_failures_ where key_ = $1`
+
+	mockRows := mock.NewRows([]string{"failures_", "locked_until_"})
+
+	mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs("username:janedoe").WillReturnRows(mockRows)
+
+	failures, err := repo.Get("username:janedoe")
+
+	require.NoError(t, err, "should not return error")
+	require.Equal(t, &LoginFailures{}, failures, "should return no failures")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal("expectations not met: ", err)
+	}
+}
+
+func testLoginThrottleRepoLockedUntil(t *testing.T, mock pgxmock.PgxPoolIface, repo LoginThrottleRepository) {
+	query := `select coalesce(max(locked_until_), 'epoch') from login_failures_ where key_ = any($1)`
+
+	keys := []string{"username:janedoe", "ip:192.0.2.1"}
+	lockedUntil := time.Date(2024, time.March, 1, 12, 30, 0, 0, time.UTC)
+
+	mockRows := mock.
+		NewRows([]string{"coalesce"}).
+		AddRow(lockedUntil)
+
+	mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(keys).WillReturnRows(mockRows)
+
+	until, err := repo.LockedUntil(keys)
+
+	require.NoError(t, err, "should not return error")
+	require.Equal(t, lockedUntil, until, "should return latest lock")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal("expectations not met: ", err)
+	}
+}
+
+func testLoginThrottleRepoLockedUntilDbError(t *testing.T, mock pgxmock.PgxPoolIface, repo LoginThrottleRepository) {
+	query := `select coalesce(max(locked_until_), 'epoch') from login_failures_ where key_ = any($1)`
+
+	keys := []string{"username:janedoe", "ip:192.0.2.1"}
+
+	mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(keys).WillReturnError(errors.New("db_error"))
+
+	until, err := repo.LockedUntil(keys)
+
+	require.EqualError(t, err, "db_error", "should return db error")
+	require.True(t, until.IsZero(), "should not return lock")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal("expectations not met: ", err)
+	}
+}
+
+func testLoginThrottleRepoFail(t *testing.T, mock pgxmock.PgxPoolIface, repo LoginThrottleRepository) {
+	query := `insert into login_failures_ (key_, failures_, last_failed_at_) values ($1, 1, $2) on conflict (key_) do update set failures_ = case when login_failures_.last_failed_at_ < $3 then 1 else login_failures_.failures_ + 1 end, last_failed_at_ = $2 returning failures_`
+
+	now := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	since := now.Add(-time.Hour)
+
+	mockRows := mock.
+		NewRows([]string{"failures_"}).
+		AddRow(4)
+
+	mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs("ip:192.0.2.1", now, since).WillReturnRows(mockRows)
+
+	failures, err := repo.Fail("ip:192.0.2.1", now, since)
+
+	require.NoError(t, err, "should not return error")
+	require.Equal(t, 4, failures, "should return failures")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal("expectations not met: ", err)
+	}
+}
+
+func testLoginThrottleRepoFailDbError(t *testing.T, mock pgxmock.PgxPoolIface, repo LoginThrottleRepository) {
+	query := `insert into login_failures_ (key_, failures_, last_failed_at_) values ($1, 1, $2) on conflict (key_) do update set failures_ = case when login_failures_.last_failed_at_ < $3 then 1 else login_failures_.failures_ + 1 end, last_failed_at_ = $2 returning failures_`
+
+	now := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	since := now.Add(-time.Hour)
+
+	mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs("ip:192.0.2.1", now, since).WillReturnError(errors.New("db_error"))
+
+	failures, err := repo.Fail("ip:192.0.2.1", now, since)
+
+	require.EqualError(t, err, "db_error", "should return db error")
+	require.Equal(t, 0, failures, "should not return failures")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal("expectations not met: ", err)
+	}
+}
+
+func testLoginThrottleRepoLock(t *testing.T, mock pgxmock.PgxPoolIface, repo LoginThrottleRepository) {
+	query := `update login_failures_ set locked_until_ = $2 where key_ = $1`
+
+	until := time.Date(2024, time.March, 1, 12, 30, 0, 0, time.UTC)
+
+	mock.
+		ExpectExec(regexp.QuoteMeta(query)).
+		WithArgs("username:janedoe", until).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+	err := repo.Lock("username:janedoe", until)
+
+	require.NoError(t, err, "should not return error")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal("expectations not met: ", err)
+	}
+}
+
+func testLoginThrottleRepoDelete(t *testing.T, mock pgxmock.PgxPoolIface, repo LoginThrottleRepository) {
+	query := `delete from login_failures_ where key_ = $1`
+
+	mock.
+		ExpectExec(regexp.QuoteMeta(query)).
+		WithArgs("username:janedoe").
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+
+	err := repo.Delete("username:janedoe")
+
+	require.NoError(t, err, "should not return error")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal("expectations not met: ", err)
+	}
+}
+
+func testLoginThrottleRepoDeleteDbError(t *testing.T, mock pgxmock.PgxPoolIface, repo LoginThrottleRepository) {
+	query := `delete from login_failures_ where key_ = $1`
+
+	mock.
+		ExpectExec(regexp.QuoteMeta(query)).
+		WithArgs("username:janedoe").
+		WillReturnError(errors.New("db_error"))
+
+	err := repo.Delete("username:janedoe")
+
+	require.EqualError(t, err, "db_error", "should return db error")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal("expectations not met: ", err)
+	}
+}
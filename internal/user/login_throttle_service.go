@@ -0,0 +1,163 @@
+package user
+
+import (
+	"context"
+	"time"
+
+	"github.com/nixpig/dunce/pkg/logging"
+)
+
+// LoginThrottlePolicy is how failed logins are throttled. Logins are blocked
+// for BaseDelay after the first failure past FreeAttempts, doubling with each
+// failure after that up to MaxDelay. Failures are forgotten once there
+// haven't been any for Window.
+type LoginThrottlePolicy struct {
+	FreeAttempts int
+	BaseDelay    time.Duration
+	MaxDelay     time.Duration
+	Window       time.Duration
+}
+
+func (p LoginThrottlePolicy) delay(failures int) time.Duration {
+	if failures <= p.FreeAttempts {
+		return 0
+	}
+
+	delay := p.BaseDelay
+
+	for i := p.FreeAttempts + 1; i < failures && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+
+	return min(delay, p.MaxDelay)
+}
+
+type LoginThrottleService interface {
+	Check(username, ip string) error
+	Fail(ctx context.Context, username, ip string) error
+	Succeed(username string) error
+	Get(username string) (*LoginFailures, error)
+	Unlock(ctx context.Context, username string) error
+}
+
+// LoginThrottleServiceImpl throttles failed logins both for each username,
+// against guessing one user's password, and from each client IP, against
+// trying a few passwords for many users.
+type LoginThrottleServiceImpl struct {
+	repo           LoginThrottleRepository
+	log            logging.Logger
+	usernamePolicy LoginThrottlePolicy
+	ipPolicy       LoginThrottlePolicy
+}
+
+func NewLoginThrottleService(
+	repo LoginThrottleRepository,
+	log logging.Logger,
+	usernamePolicy LoginThrottlePolicy,
+	ipPolicy LoginThrottlePolicy,
+) LoginThrottleServiceImpl {
+	return LoginThrottleServiceImpl{
+		repo:           repo,
+		log:            log,
+		usernamePolicy: usernamePolicy,
+		ipPolicy:       ipPolicy,
+	}
+}
+
+// Check returns a *LoginLockedError if logins for username or from ip are
+// blocked.
+func (l LoginThrottleServiceImpl) Check(username, ip string) error {
+	lockedUntil, err := l.repo.LockedUntil([]string{usernameKey(username), ipKey(ip)})
+	if err != nil {
+		return err
+	}
+
+	if lockedUntil.After(time.Now()) {
+		return &LoginLockedError{Until: lockedUntil}
+	}
+
+	return nil
+}
+
+// Fail records a failed login for username from ip, blocking further logins
+// for either once they've had too many.
+func (l LoginThrottleServiceImpl) Fail(ctx context.Context, username, ip string) error {
+	if err := l.fail(ctx, usernameKey(username), l.usernamePolicy); err != nil {
+		return err
+	}
+
+	return l.fail(ctx, ipKey(ip), l.ipPolicy)
+}
+
+// Succeed forgets the failed logins for username. Failures from the client
+// are kept, so logging in to one account doesn't let it carry on trying
+// others.
+func (l LoginThrottleServiceImpl) Succeed(username string) error {
+	return l.repo.Delete(usernameKey(username))
+}
+
+func (l LoginThrottleServiceImpl) Get(username string) (*LoginFailures, error) {
+	return l.repo.Get(usernameKey(username))
+}
+
+// Unlock lets username log in again straight away, for admins to let back in
+// users who've been locked out.
+func (l LoginThrottleServiceImpl) Unlock(ctx context.Context, username string) error {
+	if err := l.repo.Delete(usernameKey(username)); err != nil {
+		return err
+	}
+
+	l.log.Info(ctx, "unlocked logins", "key", usernameKey(username))
+
+	return nil
+}
+
+func (l LoginThrottleServiceImpl) fail(ctx context.Context, key string, policy LoginThrottlePolicy) error {
+	until, failures, err := record(l.repo, key, policy)
+	if err != nil || until.IsZero() {
+		return err
+	}
+
+	l.log.Info(
+		ctx,
+		"locked out logins after too many failures",
+		"key", key,
+		"until", until.Format(time.RFC3339),
+		"failures", failures,
+	)
+
+	return nil
+}
+
+// record counts another attempt for key, blocking it for the policy's delay
+// once there have been too many. It returns when key is blocked until, which
+// is zero if it isn't, along with the attempts counted.
+func record(repo LoginThrottleRepository, key string, policy LoginThrottlePolicy) (time.Time, int, error) {
+	now := time.Now()
+
+	attempts, err := repo.Fail(key, now, now.Add(-policy.Window))
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+
+	delay := policy.delay(attempts)
+	if delay == 0 {
+		return time.Time{}, attempts, nil
+	}
+
+	until := now.Add(delay)
+
+	if err := repo.Lock(key, until); err != nil {
+		return time.Time{}, 0, err
+	}
+
+	return until, attempts, nil
+}
+
+func usernameKey(username string) string {
+	return "username:" + username
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
@@ -0,0 +1,229 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var mockThrottleRepo = new(MockLoginThrottleRepo)
+
+var testUsernamePolicy = LoginThrottlePolicy{
+	FreeAttempts: 5,
+	BaseDelay:    30 * time.Second,
+	MaxDelay:     time.Hour,
+	Window:       24 * time.Hour,
+}
+
+var testIpPolicy = LoginThrottlePolicy{
+	FreeAttempts: 20,
+	BaseDelay:    30 * time.Second,
+	MaxDelay:     time.Hour,
+	Window:       time.Hour,
+}
+
+func TestLoginThrottleService(t *testing.T) {
+	scenarios := map[string]func(t *testing.T, service LoginThrottleService){
+		"check (success - not locked)":         testLoginThrottleServiceCheck,
+		"check (error - locked)":               testLoginThrottleServiceCheckLocked,
+		"check (error - repo error)":           testLoginThrottleServiceCheckRepoError,
+		"fail (success - under free attempts)": testLoginThrottleServiceFail,
+		"fail (success - locks out)":           testLoginThrottleServiceFailLocks,
+		"succeed (success)":                    testLoginThrottleServiceSucceed,
+		"unlock (success)":                     testLoginThrottleServiceUnlock,
+	}
+
+	for scenario, fn := range scenarios {
+		t.Run(scenario, func(t *testing.T) {
+			service := NewLoginThrottleService(mockThrottleRepo, mockLogger, testUsernamePolicy, testIpPolicy)
+
+			fn(t, service)
+		})
+	}
+}
+
+func TestLoginThrottlePolicyDelay(t *testing.T) {
+	scenarios := map[int]time.Duration{
+		0:  0,
+		5:  0,
+		6:  30 * time.Second,
+		7:  time.Minute,
+		8:  2 * time.Minute,
+		12: 32 * time.Minute,
+		13: time.Hour,
+		50: time.Hour,
+	}
+
+	for failures, delay := range scenarios {
+		require.Equal(t, delay, testUsernamePolicy.delay(failures), "should back off exponentially up to max delay")
+	}
+}
+
+type MockLoginThrottleRepo struct {
+	mock.Mock
+}
+
+func (m *MockLoginThrottleRepo) Get(key string) (*LoginFailures, error) {
+	args := m.Called(key)
+
+	return args.Get(0).(*LoginFailures), args.Error(1)
+}
+
+func (m *MockLoginThrottleRepo) LockedUntil(keys []string) (time.Time, error) {
+	args := m.Called(keys)
+
+	return args.Get(0).(time.Time), args.Error(1)
+}
+
+func (m *MockLoginThrottleRepo) Fail(key string, now, since time.Time) (int, error) {
+	args := m.Called(key, now, since)
+
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockLoginThrottleRepo) Lock(key string, until time.Time) error {
+	args := m.Called(key, until)
+
+	return args.Error(0)
+}
+
+func (m *MockLoginThrottleRepo) Delete(key string) error {
+	args := m.Called(key)
+
+	return args.Error(0)
+}
+
+func testLoginThrottleServiceCheck(t *testing.T, service LoginThrottleService) {
+	mockRepoLockedUntil := mockThrottleRepo.
+		On("LockedUntil", []string{"username:janedoe", "ip:192.0.2.1"}).
+		Return(time.Now().Add(-time.Minute), nil)
+
+	err := service.Check("janedoe", "192.0.2.1")
+
+	require.NoError(t, err, "should not return error")
+
+	mockRepoLockedUntil.Unset()
+}
+
+func testLoginThrottleServiceCheckLocked(t *testing.T, service LoginThrottleService) {
+	lockedUntil := time.Now().Add(time.Minute)
+
+	mockRepoLockedUntil := mockThrottleRepo.
+		On("LockedUntil", []string{"username:janedoe", "ip:192.0.2.1"}).
+		Return(lockedUntil, nil)
+
+	err := service.Check("janedoe", "192.0.2.1")
+
+	require.Equal(t, &LoginLockedError{Until: lockedUntil}, err, "should return locked error")
+
+	mockRepoLockedUntil.Unset()
+}
+
+func testLoginThrottleServiceCheckRepoError(t *testing.T, service LoginThrottleService) {
+	mockRepoLockedUntil := mockThrottleRepo.
+		On("LockedUntil", []string{"username:janedoe", "ip:192.0.2.1"}).
+		Return(time.Time{}, errors.New("repo_error"))
+
+	err := service.Check("janedoe", "192.0.2.1")
+
+	require.EqualError(t, err, "repo_error", "should return repo error")
+
+	mockRepoLockedUntil.Unset()
+}
+
+func testLoginThrottleServiceFail(t *testing.T, service LoginThrottleService) {
+	mockRepoFailUsername := mockThrottleRepo.
+		On("Fail", "username:janedoe", mock.Anything, mock.Anything).
+		Return(5, nil)
+
+	mockRepoFailIp := mockThrottleRepo.
+		On("Fail", "ip:192.0.2.1", mock.Anything, mock.Anything).
+		Return(20, nil)
+
+	err := service.Fail(context.Background(), "janedoe", "192.0.2.1")
+
+	require.NoError(t, err, "should not return error")
+
+	if res := mockThrottleRepo.AssertExpectations(t); !res {
+		t.Error("should record failures for username and ip")
+	}
+
+	mockRepoFailUsername.Unset()
+	mockRepoFailIp.Unset()
+}
+
+func testLoginThrottleServiceFailLocks(t *testing.T, service LoginThrottleService) {
+	var now, since, until time.Time
+
+	mockRepoFailUsername := mockThrottleRepo.
+		On("Fail", "username:janedoe", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			now = args.Get(1).(time.Time)
+			since = args.Get(2).(time.Time)
+		}).
+		Return(7, nil)
+
+	mockRepoLock := mockThrottleRepo.
+		On("Lock", "username:janedoe", mock.Anything).
+		Run(func(args mock.Arguments) {
+			until = args.Get(1).(time.Time)
+		}).
+		Return(nil)
+
+	mockLoggerInfo := mockLogger.
+		On("Info", "locked out logins after too many failures", mock.Anything)
+
+	mockRepoFailIp := mockThrottleRepo.
+		On("Fail", "ip:192.0.2.1", mock.Anything, mock.Anything).
+		Return(1, nil)
+
+	err := service.Fail(context.Background(), "janedoe", "192.0.2.1")
+
+	require.NoError(t, err, "should not return error")
+	require.Equal(t, 24*time.Hour, now.Sub(since), "should count failures within window")
+	require.Equal(t, time.Minute, until.Sub(now), "should lock out for backoff delay")
+
+	if res := mockLogger.AssertExpectations(t); !res {
+		t.Error("should log lockout")
+	}
+
+	mockRepoFailUsername.Unset()
+	mockRepoLock.Unset()
+	mockLoggerInfo.Unset()
+	mockRepoFailIp.Unset()
+}
+
+func testLoginThrottleServiceSucceed(t *testing.T, service LoginThrottleService) {
+	mockRepoDelete := mockThrottleRepo.On("Delete", "username:janedoe").Return(nil)
+
+	err := service.Succeed("janedoe")
+
+	require.NoError(t, err, "should not return error")
+
+	if res := mockThrottleRepo.AssertExpectations(t); !res {
+		t.Error("should forget username failures")
+	}
+
+	mockRepoDelete.Unset()
+}
+
+func testLoginThrottleServiceUnlock(t *testing.T, service LoginThrottleService) {
+	mockRepoDelete := mockThrottleRepo.On("Delete", "username:janedoe").Return(nil)
+
+	mockLoggerInfo := mockLogger.On("Info", "unlocked logins", []any{"key", "username:janedoe"})
+
+	err := service.Unlock(context.Background(), "janedoe")
+
+	require.NoError(t, err, "should not return error")
+
+	if res := mockLogger.AssertExpectations(t); !res {
+		t.Error("should log unlock")
+	}
+
+	mockRepoDelete.Unset()
+	mockLoggerInfo.Unset()
+}
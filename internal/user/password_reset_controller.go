@@ -0,0 +1,193 @@
+package user
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/nixpig/dunce/internal/app/errors"
+	"github.com/nixpig/dunce/pkg/logging"
+	"github.com/nixpig/dunce/pkg/session"
+	"github.com/nixpig/dunce/pkg/templates"
+)
+
+type PasswordResetController struct {
+	service        PasswordResetService
+	throttle       PasswordResetThrottleService
+	log            logging.Logger
+	templateCache  templates.TemplateCache
+	sessionManager session.SessionManager
+	csrfToken      func(r *http.Request) string
+	errorHandlers  errors.ErrorHandlers
+	baseUrl        string
+}
+
+// PasswordResetControllerConfig is the usual controller config, along with
+// the configured BaseUrl that reset links are made from when the site has no
+// domain set.
+type PasswordResetControllerConfig struct {
+	Log            logging.Logger
+	TemplateCache  templates.TemplateCache
+	SessionManager session.SessionManager
+	CsrfToken      func(*http.Request) string
+	ErrorHandlers  errors.ErrorHandlers
+	BaseUrl        string
+}
+
+type ForgotPasswordView struct {
+	Message         string
+	CsrfToken       string
+	IsAuthenticated bool
+}
+
+type ResetPasswordView struct {
+	Message         string
+	Token           string
+	CsrfToken       string
+	IsAuthenticated bool
+}
+
+func NewPasswordResetController(
+	service PasswordResetService,
+	throttle PasswordResetThrottleService,
+	config PasswordResetControllerConfig,
+) PasswordResetController {
+	return PasswordResetController{
+		service:        service,
+		throttle:       throttle,
+		log:            config.Log,
+		templateCache:  config.TemplateCache,
+		sessionManager: config.SessionManager,
+		csrfToken:      config.CsrfToken,
+		errorHandlers:  config.ErrorHandlers,
+		baseUrl:        config.BaseUrl,
+	}
+}
+
+func (p *PasswordResetController) ForgotPasswordGet(w http.ResponseWriter, r *http.Request) {
+	if err := p.templateCache["pages/admin/forgot-password.tmpl"].ExecuteTemplate(w, "admin", ForgotPasswordView{
+		Message:   p.sessionManager.PopString(r.Context(), session.SESSION_KEY_MESSAGE),
+		CsrfToken: p.csrfToken(r),
+	}); err != nil {
+		p.errorHandlers.InternalServerError(w, r, err)
+	}
+}
+
+// ForgotPasswordPost sends a reset link to the email. The response is the
+// same whether or not there's an account for the email, and even if sending
+// fails, so it can't be used to find out who has an account. Requests are
+// throttled for each email and client, so it can't be used to flood inboxes
+// either. The link is never made from the request's Host, which an attacker
+// could set to send the token to themselves.
+func (p *PasswordResetController) ForgotPasswordPost(w http.ResponseWriter, r *http.Request) {
+	email := r.FormValue("email")
+	ip := clientIp(r)
+
+	if err := p.throttle.Check(email, ip); err != nil {
+		if locked, ok := err.(*PasswordResetLockedError); ok {
+			p.sessionManager.Put(r.Context(), session.SESSION_KEY_MESSAGE, resetLockedMessage(locked))
+			http.Redirect(w, r, "/admin/forgot-password", http.StatusSeeOther)
+			return
+		}
+
+		p.errorHandlers.InternalServerError(w, r, fmt.Errorf("unable to check password reset requests: %w", err))
+		return
+	}
+
+	if err := p.throttle.Request(r.Context(), email, ip); err != nil {
+		p.errorHandlers.InternalServerError(w, r, fmt.Errorf("unable to record password reset request: %w", err))
+		return
+	}
+
+	if err := p.service.Request(email, p.baseUrl); err != nil {
+		p.log.Error(r.Context(), "unable to request password reset", "err", err)
+	}
+
+	p.sessionManager.Put(
+		r.Context(),
+		session.SESSION_KEY_MESSAGE,
+		"If there's an account for that email, we've sent it a link to reset the password.",
+	)
+
+	http.Redirect(w, r, "/admin/login", http.StatusSeeOther)
+}
+
+func (p *PasswordResetController) ResetPasswordGet(w http.ResponseWriter, r *http.Request) {
+	token := r.PathValue("token")
+
+	valid, err := p.service.Valid(token)
+	if err != nil {
+		p.errorHandlers.InternalServerError(w, r, err)
+		return
+	}
+
+	if !valid {
+		p.invalidToken(w, r)
+		return
+	}
+
+	if err := p.templateCache["pages/admin/reset-password.tmpl"].ExecuteTemplate(w, "admin", ResetPasswordView{
+		Message:   p.sessionManager.PopString(r.Context(), session.SESSION_KEY_MESSAGE),
+		Token:     token,
+		CsrfToken: p.csrfToken(r),
+	}); err != nil {
+		p.errorHandlers.InternalServerError(w, r, err)
+	}
+}
+
+func (p *PasswordResetController) ResetPasswordPost(w http.ResponseWriter, r *http.Request) {
+	token := r.PathValue("token")
+
+	if err := p.service.Reset(token, &UserPasswordResetRequestDto{
+		NewPassword:     r.FormValue("new_password"),
+		ConfirmPassword: r.FormValue("confirm_password"),
+	}); err != nil {
+		if err == ErrInvalidResetToken {
+			p.invalidToken(w, r)
+			return
+		}
+
+		if _, ok := err.(validator.ValidationErrors); ok {
+			p.sessionManager.Put(
+				r.Context(),
+				session.SESSION_KEY_MESSAGE,
+				"Unable to reset password: new password must be 8 to 72 characters and match the confirmation.",
+			)
+			http.Redirect(w, r, "/admin/reset-password/"+token, http.StatusSeeOther)
+			return
+		}
+
+		p.errorHandlers.InternalServerError(w, r, fmt.Errorf("unable to reset password: %w", err))
+		return
+	}
+
+	p.sessionManager.Put(
+		r.Context(),
+		session.SESSION_KEY_MESSAGE,
+		"Your password has been reset. Please log in.",
+	)
+
+	http.Redirect(w, r, "/admin/login", http.StatusSeeOther)
+}
+
+func (p *PasswordResetController) invalidToken(w http.ResponseWriter, r *http.Request) {
+	p.sessionManager.Put(
+		r.Context(),
+		session.SESSION_KEY_MESSAGE,
+		"That password reset link is invalid or has expired. Please request a new one.",
+	)
+
+	http.Redirect(w, r, "/admin/forgot-password", http.StatusSeeOther)
+}
+
+func resetLockedMessage(locked *PasswordResetLockedError) string {
+	minutes := int(math.Ceil(time.Until(locked.Until).Minutes()))
+
+	if minutes <= 1 {
+		return "Too many password reset requests. Try again in a minute."
+	}
+
+	return fmt.Sprintf("Too many password reset requests. Try again in %d minutes.", minutes)
+}
@@ -0,0 +1,461 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/nixpig/dunce/pkg/session"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var mockResetService = new(MockPasswordResetService)
+var mockResetThrottleService = new(MockPasswordResetThrottleService)
+
+func TestPasswordResetController(t *testing.T) {
+	scenarios := map[string]func(t *testing.T, ctrl PasswordResetController){
+		"get forgot password page (success)":          testGetForgotPassword,
+		"post forgot password (success)":              testPostForgotPassword,
+		"post forgot password (success - send error)": testPostForgotPasswordSendError,
+		"post forgot password (error - throttled)":    testPostForgotPasswordThrottled,
+		"get reset password page (success)":           testGetResetPassword,
+		"get reset password page (error - invalid)":   testGetResetPasswordInvalid,
+		"post reset password (success)":               testPostResetPassword,
+		"post reset password (error - invalid token)": testPostResetPasswordInvalidToken,
+		"post reset password (error - validation)":    testPostResetPasswordValidationError,
+	}
+
+	for scenario, fn := range scenarios {
+		t.Run(scenario, func(t *testing.T) {
+			config := PasswordResetControllerConfig{
+				Log:            mockLogger,
+				TemplateCache:  mockTemplateCache,
+				SessionManager: mockSessionManager,
+				CsrfToken: func(r *http.Request) string {
+					return "mock-token"
+				},
+				ErrorHandlers: mockErrorHandlers,
+				BaseUrl:       "https://dunce.example.org",
+			}
+
+			ctrl := NewPasswordResetController(mockResetService, mockResetThrottleService, config)
+			fn(t, ctrl)
+		})
+	}
+}
+
+type MockPasswordResetService struct {
+	mock.Mock
+}
+
+func (m *MockPasswordResetService) Request(email, baseUrl string) error {
+	args := m.Called(email, baseUrl)
+
+	return args.Error(0)
+}
+
+func (m *MockPasswordResetService) Valid(token string) (bool, error) {
+	args := m.Called(token)
+
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockPasswordResetService) Reset(token string, reset *UserPasswordResetRequestDto) error {
+	args := m.Called(token, reset)
+
+	return args.Error(0)
+}
+
+type MockPasswordResetThrottleService struct {
+	mock.Mock
+}
+
+func (m *MockPasswordResetThrottleService) Check(email, ip string) error {
+	args := m.Called(email, ip)
+
+	return args.Error(0)
+}
+
+func (m *MockPasswordResetThrottleService) Request(ctx context.Context, email, ip string) error {
+	args := m.Called(email, ip)
+
+	return args.Error(0)
+}
+
+func newFormRequest(t *testing.T, path string, form url.Values) *http.Request {
+	req, err := http.NewRequest("POST", path, strings.NewReader(form.Encode()))
+	if err != nil {
+		t.Error("unable to construct request")
+	}
+
+	req.Header.Add("content-type", "application/x-www-form-urlencoded")
+
+	return req
+}
+
+func testGetForgotPassword(t *testing.T, ctrl PasswordResetController) {
+	req, err := http.NewRequest("GET", "/admin/forgot-password", nil)
+	if err != nil {
+		t.Error("unable to construct request")
+	}
+
+	rr := httptest.NewRecorder()
+
+	handler := http.HandlerFunc(ctrl.ForgotPasswordGet)
+
+	mockSessionManagerPopString := mockSessionManager.
+		On("PopString", req.Context(), session.SESSION_KEY_MESSAGE).
+		Return("msg")
+
+	mockTemplateExecuteTemplate := mockTemplate.On(
+		"ExecuteTemplate",
+		rr,
+		"admin",
+		ForgotPasswordView{
+			Message:   "msg",
+			CsrfToken: "mock-token",
+		},
+	).Return(nil)
+
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Result().StatusCode, "should return status code ok")
+
+	if res := mockTemplate.AssertExpectations(t); !res {
+		t.Error("should execute template")
+	}
+
+	mockSessionManagerPopString.Unset()
+	mockTemplateExecuteTemplate.Unset()
+}
+
+func testPostForgotPassword(t *testing.T, ctrl PasswordResetController) {
+	form := url.Values{}
+	form.Add("email", "jane@example.org")
+
+	req := newFormRequest(t, "/admin/forgot-password", form)
+	// links shouldn't be made from a host anyone can set
+	req.Host = "attacker.example.com"
+	req.RemoteAddr = "192.0.2.1:54321"
+
+	rr := httptest.NewRecorder()
+
+	handler := http.HandlerFunc(ctrl.ForgotPasswordPost)
+
+	mockResetThrottleServiceCheck := mockResetThrottleService.
+		On("Check", "jane@example.org", "192.0.2.1").
+		Return(nil)
+
+	mockResetThrottleServiceRequest := mockResetThrottleService.
+		On("Request", "jane@example.org", "192.0.2.1").
+		Return(nil)
+
+	mockResetServiceRequest := mockResetService.
+		On("Request", "jane@example.org", "https://dunce.example.org").
+		Return(nil)
+
+	mockSessionManagerPut := mockSessionManager.On(
+		"Put",
+		req.Context(),
+		session.SESSION_KEY_MESSAGE,
+		"If there's an account for that email, we've sent it a link to reset the password.",
+	)
+
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusSeeOther, rr.Result().StatusCode, "should return status code see other")
+	require.Equal(t, "/admin/login", rr.Result().Header.Get("Location"), "should redirect to login page")
+
+	if res := mockResetThrottleService.AssertExpectations(t); !res {
+		t.Error("should check and record request against throttle")
+	}
+
+	if res := mockResetService.AssertExpectations(t); !res {
+		t.Error("should request password reset")
+	}
+
+	if res := mockSessionManager.AssertExpectations(t); !res {
+		t.Error("should put message in session context")
+	}
+
+	mockResetThrottleServiceCheck.Unset()
+	mockResetThrottleServiceRequest.Unset()
+	mockResetServiceRequest.Unset()
+	mockSessionManagerPut.Unset()
+}
+
+func testPostForgotPasswordSendError(t *testing.T, ctrl PasswordResetController) {
+	form := url.Values{}
+	form.Add("email", "jane@example.org")
+
+	req := newFormRequest(t, "/admin/forgot-password", form)
+	// links shouldn't be made from a host anyone can set
+	req.Host = "attacker.example.com"
+	req.RemoteAddr = "192.0.2.1:54321"
+
+	rr := httptest.NewRecorder()
+
+	handler := http.HandlerFunc(ctrl.ForgotPasswordPost)
+
+	mockResetThrottleServiceCheck := mockResetThrottleService.
+		On("Check", "jane@example.org", "192.0.2.1").
+		Return(nil)
+
+	mockResetThrottleServiceRequest := mockResetThrottleService.
+		On("Request", "jane@example.org", "192.0.2.1").
+		Return(nil)
+
+	mockResetServiceRequest := mockResetService.
+		On("Request", "jane@example.org", "https://dunce.example.org").
+		Return(errors.New("mailer_error"))
+
+	mockLoggerError := mockLogger.On("Error", "unable to request password reset", mock.Anything)
+
+	mockSessionManagerPut := mockSessionManager.On(
+		"Put",
+		req.Context(),
+		session.SESSION_KEY_MESSAGE,
+		"If there's an account for that email, we've sent it a link to reset the password.",
+	)
+
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusSeeOther, rr.Result().StatusCode, "should respond the same as when sent")
+	require.Equal(t, "/admin/login", rr.Result().Header.Get("Location"), "should redirect to login page")
+
+	if res := mockLogger.AssertExpectations(t); !res {
+		t.Error("should log error")
+	}
+
+	mockResetThrottleServiceCheck.Unset()
+	mockResetThrottleServiceRequest.Unset()
+	mockResetServiceRequest.Unset()
+	mockLoggerError.Unset()
+	mockSessionManagerPut.Unset()
+}
+
+func testPostForgotPasswordThrottled(t *testing.T, ctrl PasswordResetController) {
+	form := url.Values{}
+	form.Add("email", "jane@example.org")
+
+	req := newFormRequest(t, "/admin/forgot-password", form)
+	req.RemoteAddr = "192.0.2.1:54321"
+
+	rr := httptest.NewRecorder()
+
+	handler := http.HandlerFunc(ctrl.ForgotPasswordPost)
+
+	mockResetThrottleServiceCheck := mockResetThrottleService.
+		On("Check", "jane@example.org", "192.0.2.1").
+		Return(&PasswordResetLockedError{Until: time.Now().Add(10 * time.Minute)})
+
+	mockSessionManagerPut := mockSessionManager.On(
+		"Put",
+		req.Context(),
+		session.SESSION_KEY_MESSAGE,
+		"Too many password reset requests. Try again in 10 minutes.",
+	)
+
+	// no expectations on recording the request or requesting the reset, so
+	// either would fail the test
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusSeeOther, rr.Result().StatusCode, "should return status code see other")
+	require.Equal(t, "/admin/forgot-password", rr.Result().Header.Get("Location"), "should redirect back to forgot password page")
+
+	if res := mockSessionManager.AssertExpectations(t); !res {
+		t.Error("should put throttled message in session context")
+	}
+
+	mockResetThrottleServiceCheck.Unset()
+	mockSessionManagerPut.Unset()
+}
+
+func testGetResetPassword(t *testing.T, ctrl PasswordResetController) {
+	req, err := http.NewRequest("GET", "/admin/reset-password/{token}", nil)
+	if err != nil {
+		t.Error("unable to construct request")
+	}
+
+	req.SetPathValue("token", "t0ken")
+
+	rr := httptest.NewRecorder()
+
+	handler := http.HandlerFunc(ctrl.ResetPasswordGet)
+
+	mockResetServiceValid := mockResetService.On("Valid", "t0ken").Return(true, nil)
+
+	mockSessionManagerPopString := mockSessionManager.
+		On("PopString", req.Context(), session.SESSION_KEY_MESSAGE).
+		Return("")
+
+	mockTemplateExecuteTemplate := mockTemplate.On(
+		"ExecuteTemplate",
+		rr,
+		"admin",
+		ResetPasswordView{
+			Token:     "t0ken",
+			CsrfToken: "mock-token",
+		},
+	).Return(nil)
+
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Result().StatusCode, "should return status code ok")
+
+	if res := mockTemplate.AssertExpectations(t); !res {
+		t.Error("should execute template")
+	}
+
+	mockResetServiceValid.Unset()
+	mockSessionManagerPopString.Unset()
+	mockTemplateExecuteTemplate.Unset()
+}
+
+func testGetResetPasswordInvalid(t *testing.T, ctrl PasswordResetController) {
+	req, err := http.NewRequest("GET", "/admin/reset-password/{token}", nil)
+	if err != nil {
+		t.Error("unable to construct request")
+	}
+
+	req.SetPathValue("token", "t0ken")
+
+	rr := httptest.NewRecorder()
+
+	handler := http.HandlerFunc(ctrl.ResetPasswordGet)
+
+	mockResetServiceValid := mockResetService.On("Valid", "t0ken").Return(false, nil)
+
+	mockSessionManagerPut := mockSessionManager.On(
+		"Put",
+		req.Context(),
+		session.SESSION_KEY_MESSAGE,
+		"That password reset link is invalid or has expired. Please request a new one.",
+	)
+
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusSeeOther, rr.Result().StatusCode, "should return status code see other")
+	require.Equal(t, "/admin/forgot-password", rr.Result().Header.Get("Location"), "should redirect to forgot password page")
+
+	if res := mockSessionManager.AssertExpectations(t); !res {
+		t.Error("should put message in session context")
+	}
+
+	mockResetServiceValid.Unset()
+	mockSessionManagerPut.Unset()
+}
+
+func testPostResetPassword(t *testing.T, ctrl PasswordResetController) {
+	form := url.Values{}
+	form.Add("new_password", "n3wp4ssw0rd")
+	form.Add("confirm_password", "n3wp4ssw0rd")
+
+	req := newFormRequest(t, "/admin/reset-password/{token}", form)
+	req.SetPathValue("token", "t0ken")
+
+	rr := httptest.NewRecorder()
+
+	handler := http.HandlerFunc(ctrl.ResetPasswordPost)
+
+	mockResetServiceReset := mockResetService.On("Reset", "t0ken", &UserPasswordResetRequestDto{
+		NewPassword:     "n3wp4ssw0rd",
+		ConfirmPassword: "n3wp4ssw0rd",
+	}).Return(nil)
+
+	mockSessionManagerPut := mockSessionManager.On(
+		"Put",
+		req.Context(),
+		session.SESSION_KEY_MESSAGE,
+		"Your password has been reset. Please log in.",
+	)
+
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusSeeOther, rr.Result().StatusCode, "should return status code see other")
+	require.Equal(t, "/admin/login", rr.Result().Header.Get("Location"), "should redirect to login page")
+
+	if res := mockResetService.AssertExpectations(t); !res {
+		t.Error("should reset password")
+	}
+
+	if res := mockSessionManager.AssertExpectations(t); !res {
+		t.Error("should put message in session context")
+	}
+
+	mockResetServiceReset.Unset()
+	mockSessionManagerPut.Unset()
+}
+
+func testPostResetPasswordInvalidToken(t *testing.T, ctrl PasswordResetController) {
+	form := url.Values{}
+	form.Add("new_password", "n3wp4ssw0rd")
+	form.Add("confirm_password", "n3wp4ssw0rd")
+
+	req := newFormRequest(t, "/admin/reset-password/{token}", form)
+	req.SetPathValue("token", "t0ken")
+
+	rr := httptest.NewRecorder()
+
+	handler := http.HandlerFunc(ctrl.ResetPasswordPost)
+
+	mockResetServiceReset := mockResetService.On("Reset", "t0ken", &UserPasswordResetRequestDto{
+		NewPassword:     "n3wp4ssw0rd",
+		ConfirmPassword: "n3wp4ssw0rd",
+	}).Return(ErrInvalidResetToken)
+
+	mockSessionManagerPut := mockSessionManager.On(
+		"Put",
+		req.Context(),
+		session.SESSION_KEY_MESSAGE,
+		"That password reset link is invalid or has expired. Please request a new one.",
+	)
+
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusSeeOther, rr.Result().StatusCode, "should return status code see other")
+	require.Equal(t, "/admin/forgot-password", rr.Result().Header.Get("Location"), "should redirect to forgot password page")
+
+	mockResetServiceReset.Unset()
+	mockSessionManagerPut.Unset()
+}
+
+func testPostResetPasswordValidationError(t *testing.T, ctrl PasswordResetController) {
+	form := url.Values{}
+	form.Add("new_password", "short")
+	form.Add("confirm_password", "short")
+
+	req := newFormRequest(t, "/admin/reset-password/{token}", form)
+	req.SetPathValue("token", "t0ken")
+
+	rr := httptest.NewRecorder()
+
+	handler := http.HandlerFunc(ctrl.ResetPasswordPost)
+
+	mockResetServiceReset := mockResetService.On("Reset", "t0ken", &UserPasswordResetRequestDto{
+		NewPassword:     "short",
+		ConfirmPassword: "short",
+	}).Return(validator.ValidationErrors{})
+
+	mockSessionManagerPut := mockSessionManager.On(
+		"Put",
+		req.Context(),
+		session.SESSION_KEY_MESSAGE,
+		"Unable to reset password: new password must be 8 to 72 characters and match the confirmation.",
+	)
+
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusSeeOther, rr.Result().StatusCode, "should return status code see other")
+	require.Equal(t, "/admin/reset-password/t0ken", rr.Result().Header.Get("Location"), "should redirect back to reset page")
+
+	mockResetServiceReset.Unset()
+	mockSessionManagerPut.Unset()
+}
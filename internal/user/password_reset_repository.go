@@ -0,0 +1,91 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/nixpig/dunce/db"
+)
+
+// PasswordResetRepository stores password reset tokens by their hash, so a
+// leaked database can't be used to reset passwords.
+type PasswordResetRepository interface {
+	Create(email, tokenHash string, expiresAt time.Time) (bool, error)
+	Exists(tokenHash string, now time.Time) (bool, error)
+	Reset(tokenHash, password string, now time.Time) error
+}
+
+type passwordResetPostgresRepository struct {
+	db db.Dbconn
+}
+
+func NewPasswordResetPostgresRepository(db db.Dbconn) passwordResetPostgresRepository {
+	return passwordResetPostgresRepository{
+		db: db,
+	}
+}
+
+// Create stores a reset token for the user with email, reporting whether
+// there is one.
+func (p passwordResetPostgresRepository) Create(email, tokenHash string, expiresAt time.Time) (bool, error) {
+	query := `insert into password_resets_ (user_id_, token_hash_, expires_at_) select id_, $2, $3 from users_ where email_ = $1`
+
+	res, err := p.db.Exec(context.Background(), query, email, tokenHash, expiresAt)
+	if err != nil {
+		return false, err
+	}
+
+	return res.RowsAffected() > 0, nil
+}
+
+// Exists reports whether the token can still be used at now.
+func (p passwordResetPostgresRepository) Exists(tokenHash string, now time.Time) (bool, error) {
+	query := `select exists(select true from password_resets_ where token_hash_ = $1 and used_at_ is null and expires_at_ > $2)`
+
+	row := p.db.QueryRow(context.Background(), query, tokenHash, now)
+
+	var exists bool
+
+	if err := row.Scan(&exists); err != nil {
+		return false, err
+	}
+
+	return exists, nil
+}
+
+// Reset uses up the token, along with any other outstanding tokens for the
+// same user, and sets the user's hashed password, bumping their session
+// version to log out their sessions. Both happen in one transaction, so the
+// token is only used up if the password is changed.
+func (p passwordResetPostgresRepository) Reset(tokenHash, password string, now time.Time) error {
+	consumeQuery := `update password_resets_ r set used_at_ = $2 from users_ u where u.id_ = r.user_id_ and r.used_at_ is null and r.user_id_ = (select user_id_ from password_resets_ where token_hash_ = $1 and used_at_ is null and expires_at_ > $2) returning u.username_`
+	updatePasswordQuery := `update users_ set password_ = $2, session_version_ = session_version_ + 1 where username_ = $1`
+
+	tx, err := p.db.Begin(context.Background())
+	if err != nil {
+		return err
+	}
+
+	// does nothing once the transaction's committed
+	defer tx.Rollback(context.Background())
+
+	row := tx.QueryRow(context.Background(), consumeQuery, tokenHash, now)
+
+	var username string
+
+	if err := row.Scan(&username); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrInvalidResetToken
+		}
+
+		return err
+	}
+
+	if _, err := tx.Exec(context.Background(), updatePasswordQuery, username, password); err != nil {
+		return err
+	}
+
+	return tx.Commit(context.Background())
+}
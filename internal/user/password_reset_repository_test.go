@@ -0,0 +1,238 @@
+package user
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/pashagolub/pgxmock/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasswordResetRepo(t *testing.T) {
+	scenarios := map[string]func(t *testing.T, mock pgxmock.PgxPoolIface, repo PasswordResetRepository){
+		"create reset (success)":                         testPasswordResetRepoCreate,
+		"create reset (success - no user)":               testPasswordResetRepoCreateNoUser,
+		"create reset (error - db error)":                testPasswordResetRepoCreateDbError,
+		"reset exists (true)":                            testPasswordResetRepoExists,
+		"reset exists (error - db error)":                testPasswordResetRepoExistsDbError,
+		"reset password (success)":                       testPasswordResetRepoReset,
+		"reset password (error - invalid token)":         testPasswordResetRepoResetInvalid,
+		"reset password (error - db error)":              testPasswordResetRepoResetDbError,
+		"reset password (error - update password error)": testPasswordResetRepoResetUpdateError,
+	}
+
+	for scenario, fn := range scenarios {
+		t.Run(scenario, func(t *testing.T) {
+			db, err := pgxmock.NewPool()
+			if err != nil {
+				t.Fatal("unable to create mock db pool")
+			}
+
+			defer db.Close()
+
+			repo := NewPasswordResetPostgresRepository(db)
+
+			fn(t, db, repo)
+		})
+	}
+}
+
+func testPasswordResetRepoCreate(t *testing.T, mock pgxmock.PgxPoolIface, repo PasswordResetRepository) {
+	query := `insert into password_resets_ (user_id_, token_hash_, expires_at_) select id_, $2, $3 from users_ where email_ = $1`
+
+	expiresAt := time.Now().Add(time.Hour)
+
+	mock.
+		ExpectExec(regexp.QuoteMeta(query)).
+		WithArgs("jane@example.org", "h4sh", expiresAt).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+	exists, err := repo.Create("jane@example.org", "h4sh", expiresAt)
+
+	require.NoError(t, err, "should not return error")
+	require.True(t, exists, "should report a user has the email")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal("expectations not met: ", err)
+	}
+}
+
+func testPasswordResetRepoCreateNoUser(t *testing.T, mock pgxmock.PgxPoolIface, repo PasswordResetRepository) {
+	query := `insert into password_resets_ (user_id_, token_hash_, expires_at_) select id_, $2, $3 from users_ where email_ = $1`
+
+	expiresAt := time.Now().Add(time.Hour)
+
+	mock.
+		ExpectExec(regexp.QuoteMeta(query)).
+		WithArgs("nobody@example.org", "h4sh", expiresAt).
+		WillReturnResult(pgxmock.NewResult("INSERT", 0))
+
+	exists, err := repo.Create("nobody@example.org", "h4sh", expiresAt)
+
+	require.NoError(t, err, "should not return error")
+	require.False(t, exists, "should report no user has the email")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal("expectations not met: ", err)
+	}
+}
+
+func testPasswordResetRepoCreateDbError(t *testing.T, mock pgxmock.PgxPoolIface, repo PasswordResetRepository) {
+	query := `insert into password_resets_ (user_id_, token_hash_, expires_at_) select id_, $2, $3 from users_ where email_ = $1`
+
+	expiresAt := time.Now().Add(time.Hour)
+
+	mock.
+		ExpectExec(regexp.QuoteMeta(query)).
+		WithArgs("jane@example.org", "h4sh", expiresAt).
+		WillReturnError(errors.New("db_error"))
+
+	exists, err := repo.Create("jane@example.org", "h4sh", expiresAt)
+
+	require.EqualError(t, err, "db_error", "should return db error")
+	require.False(t, exists, "should not report a user has the email")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal("expectations not met: ", err)
+	}
+}
+
+func testPasswordResetRepoExists(t *testing.T, mock pgxmock.PgxPoolIface, repo PasswordResetRepository) {
+	query := `select exists(select true from password_resets_ where token_hash_ = $1 and used_at_ is null and expires_at_ > $2)`
+
+	now := time.Now()
+
+	mock.
+		ExpectQuery(regexp.QuoteMeta(query)).
+		WithArgs("h4sh", now).
+		WillReturnRows(mock.NewRows([]string{"exists"}).AddRow(true))
+
+	exists, err := repo.Exists("h4sh", now)
+
+	require.NoError(t, err, "should not return error")
+	require.True(t, exists, "should report token can be used")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal("expectations not met: ", err)
+	}
+}
+
+func testPasswordResetRepoExistsDbError(t *testing.T, mock pgxmock.PgxPoolIface, repo PasswordResetRepository) {
+	query := `select exists(select true from password_resets_ where token_hash_ = $1 and used_at_ is null and expires_at_ > $2)`
+
+	now := time.Now()
+
+	mock.
+		ExpectQuery(regexp.QuoteMeta(query)).
+		WithArgs("h4sh", now).
+		WillReturnError(errors.New("db_error"))
+
+	exists, err := repo.Exists("h4sh", now)
+
+	require.EqualError(t, err, "db_error", "should return db error")
+	require.False(t, exists, "should not report token can be used")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal("expectations not met: ", err)
+	}
+}
+
+const resetConsumeQuery = `update password_resets_ r set used_at_ = $2 from users_ u where u.id_ = r.user_id_ and r.used_at_ is null and r.user_id_ = (select user_id_ from password_resets_ where token_hash_ = $1 and used_at_ is null and expires_at_ > $2) returning u.username_`
+const resetUpdatePasswordQuery = `update users_ set password_ = $2, session_version_ = session_version_ + 1 where username_ = $1`
+
+func testPasswordResetRepoReset(t *testing.T, mock pgxmock.PgxPoolIface, repo PasswordResetRepository) {
+	now := time.Now()
+
+	mock.ExpectBegin()
+
+	mock.
+		ExpectQuery(regexp.QuoteMeta(resetConsumeQuery)).
+		WithArgs("h4sh", now).
+		WillReturnRows(mock.NewRows([]string{"username_"}).AddRow("janedoe"))
+
+	mock.
+		ExpectExec(regexp.QuoteMeta(resetUpdatePasswordQuery)).
+		WithArgs("janedoe", "h4shedp4ssw0rd").
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+	mock.ExpectCommit()
+
+	err := repo.Reset("h4sh", "h4shedp4ssw0rd", now)
+
+	require.NoError(t, err, "should not return error")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal("expectations not met: ", err)
+	}
+}
+
+func testPasswordResetRepoResetInvalid(t *testing.T, mock pgxmock.PgxPoolIface, repo PasswordResetRepository) {
+	now := time.Now()
+
+	mock.ExpectBegin()
+
+	mock.
+		ExpectQuery(regexp.QuoteMeta(resetConsumeQuery)).
+		WithArgs("h4sh", now).
+		WillReturnRows(mock.NewRows([]string{"username_"}))
+
+	mock.ExpectRollback()
+
+	err := repo.Reset("h4sh", "h4shedp4ssw0rd", now)
+
+	require.ErrorIs(t, err, ErrInvalidResetToken, "should return invalid token error")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal("expectations not met: ", err)
+	}
+}
+
+func testPasswordResetRepoResetDbError(t *testing.T, mock pgxmock.PgxPoolIface, repo PasswordResetRepository) {
+	now := time.Now()
+
+	mock.ExpectBegin()
+
+	mock.
+		ExpectQuery(regexp.QuoteMeta(resetConsumeQuery)).
+		WithArgs("h4sh", now).
+		WillReturnError(errors.New("db_error"))
+
+	mock.ExpectRollback()
+
+	err := repo.Reset("h4sh", "h4shedp4ssw0rd", now)
+
+	require.EqualError(t, err, "db_error", "should return db error")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal("expectations not met: ", err)
+	}
+}
+
+func testPasswordResetRepoResetUpdateError(t *testing.T, mock pgxmock.PgxPoolIface, repo PasswordResetRepository) {
+	now := time.Now()
+
+	mock.ExpectBegin()
+
+	mock.
+		ExpectQuery(regexp.QuoteMeta(resetConsumeQuery)).
+		WithArgs("h4sh", now).
+		WillReturnRows(mock.NewRows([]string{"username_"}).AddRow("janedoe"))
+
+	mock.
+		ExpectExec(regexp.QuoteMeta(resetUpdatePasswordQuery)).
+		WithArgs("janedoe", "h4shedp4ssw0rd").
+		WillReturnError(errors.New("db_error"))
+
+	// the token mustn't be used up if the password isn't changed
+	mock.ExpectRollback()
+
+	err := repo.Reset("h4sh", "h4shedp4ssw0rd", now)
+
+	require.EqualError(t, err, "db_error", "should return db error")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal("expectations not met: ", err)
+	}
+}
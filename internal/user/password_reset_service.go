@@ -0,0 +1,150 @@
+package user
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/nixpig/dunce/internal/site"
+	"github.com/nixpig/dunce/pkg/crypto"
+	"github.com/nixpig/dunce/pkg/logging"
+	"github.com/nixpig/dunce/pkg/mail"
+)
+
+type PasswordResetService interface {
+	Request(email, baseUrl string) error
+	Valid(token string) (bool, error)
+	Reset(token string, reset *UserPasswordResetRequestDto) error
+}
+
+type PasswordResetServiceImpl struct {
+	repo        PasswordResetRepository
+	siteService site.SiteService
+	validate    *validator.Validate
+	crypto      crypto.Crypto
+	cost        int
+	mailer      mail.Mailer
+	log         logging.Logger
+	ttl         time.Duration
+}
+
+func NewPasswordResetService(
+	repo PasswordResetRepository,
+	siteService site.SiteService,
+	validate *validator.Validate,
+	crypto crypto.Crypto,
+	cost int,
+	mailer mail.Mailer,
+	log logging.Logger,
+	ttl time.Duration,
+) PasswordResetServiceImpl {
+	return PasswordResetServiceImpl{
+		repo:        repo,
+		siteService: siteService,
+		validate:    validate,
+		crypto:      crypto,
+		cost:        cost,
+		mailer:      mailer,
+		log:         log,
+		ttl:         ttl,
+	}
+}
+
+// Request emails a link to reset the password of the user with email. Links
+// are made absolute using the site domain, or the configured baseUrl if the
+// domain isn't set, and site.ErrNoUrl is returned without sending anything if
+// neither is. Nothing is sent if there's no such user, without it being an
+// error, so callers can't be used to find out who has an account. The email
+// is sent in the background, with failures logged, so that how long it takes
+// doesn't give that away either.
+func (p PasswordResetServiceImpl) Request(email, baseUrl string) error {
+	s, err := p.siteService.Get()
+	if err != nil {
+		return err
+	}
+
+	siteUrl := s.Url(baseUrl)
+	if siteUrl == "" {
+		return site.ErrNoUrl
+	}
+
+	token, err := newResetToken()
+	if err != nil {
+		return err
+	}
+
+	exists, err := p.repo.Create(email, hashResetToken(token), time.Now().Add(p.ttl))
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		return nil
+	}
+
+	message := mail.Message{
+		To:      []string{email},
+		Subject: fmt.Sprintf("Reset your password for %s", s.Name),
+		Body: fmt.Sprintf(
+			"Someone asked to reset the password for your account on %s.\n\n"+
+				"Follow this link within %d minutes to choose a new password:\n\n"+
+				"%s/admin/reset-password/%s\n\n"+
+				"If it wasn't you, ignore this email and your password won't change.\n",
+			s.Name,
+			int(p.ttl.Minutes()),
+			siteUrl,
+			token,
+		),
+	}
+
+	go func() {
+		if err := p.mailer.Send(message); err != nil {
+			p.log.Error(context.Background(), "unable to send password reset", "err", err)
+		}
+	}()
+
+	return nil
+}
+
+// Valid reports whether the token can still be used to reset a password.
+func (p PasswordResetServiceImpl) Valid(token string) (bool, error) {
+	return p.repo.Exists(hashResetToken(token), time.Now())
+}
+
+// Reset sets a new password for the user the token was issued to. The token
+// can only be used once, and every session for the user is logged out. The
+// token is used up and the password set together, so a failure can't leave
+// the token spent with the password unchanged.
+func (p PasswordResetServiceImpl) Reset(token string, reset *UserPasswordResetRequestDto) error {
+	if err := p.validate.Struct(reset); err != nil {
+		return err
+	}
+
+	hashedPassword, err := p.crypto.GenerateFromPassword([]byte(reset.NewPassword), p.cost)
+	if err != nil {
+		return err
+	}
+
+	return p.repo.Reset(hashResetToken(token), string(hashedPassword), time.Now())
+}
+
+func newResetToken() (string, error) {
+	b := make([]byte, 32)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashResetToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(hash[:])
+}
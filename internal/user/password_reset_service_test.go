@@ -0,0 +1,367 @@
+package user
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nixpig/dunce/internal/site"
+	"github.com/nixpig/dunce/pkg/mail"
+	"github.com/nixpig/dunce/pkg/validation"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var mockResetRepo = new(MockPasswordResetRepo)
+var mockSiteService = new(MockSiteService)
+var mockMailer = new(MockMailer)
+
+func TestPasswordResetService(t *testing.T) {
+	scenarios := map[string]func(t *testing.T, service PasswordResetService){
+		"request reset (success)":                testPasswordResetServiceRequest,
+		"request reset (success - no user)":      testPasswordResetServiceRequestNoUser,
+		"request reset (success - mailer error)": testPasswordResetServiceRequestMailerError,
+		"request reset (success - base url)":     testPasswordResetServiceRequestBaseUrl,
+		"request reset (error - no url)":         testPasswordResetServiceRequestNoUrl,
+		"valid token (success)":                  testPasswordResetServiceValid,
+		"reset password (success)":               testPasswordResetServiceReset,
+		"reset password (error - invalid token)": testPasswordResetServiceResetInvalidToken,
+		"reset password (error - validation)":    testPasswordResetServiceResetValidationError,
+	}
+
+	for scenario, fn := range scenarios {
+		t.Run(scenario, func(t *testing.T) {
+			validator, err := validation.NewValidator()
+			if err != nil {
+				t.Fatal("unable to construct validator")
+			}
+
+			service := NewPasswordResetService(
+				mockResetRepo,
+				mockSiteService,
+				validator,
+				mockCrypto,
+				14,
+				mockMailer,
+				mockLogger,
+				time.Hour,
+			)
+
+			fn(t, service)
+		})
+	}
+}
+
+type MockPasswordResetRepo struct {
+	mock.Mock
+}
+
+func (m *MockPasswordResetRepo) Create(email, tokenHash string, expiresAt time.Time) (bool, error) {
+	args := m.Called(email, tokenHash, expiresAt)
+
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockPasswordResetRepo) Exists(tokenHash string, now time.Time) (bool, error) {
+	args := m.Called(tokenHash, now)
+
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockPasswordResetRepo) Reset(tokenHash, password string, now time.Time) error {
+	args := m.Called(tokenHash, password, now)
+
+	return args.Error(0)
+}
+
+type MockSiteService struct {
+	mock.Mock
+}
+
+func (m *MockSiteService) Create(key site.Key, value string) (*site.SiteItemResponseDto, error) {
+	args := m.Called(key, value)
+
+	return args.Get(0).(*site.SiteItemResponseDto), args.Error(1)
+}
+
+func (m *MockSiteService) Get() (*site.Site, error) {
+	args := m.Called()
+
+	return args.Get(0).(*site.Site), args.Error(1)
+}
+
+func (m *MockSiteService) GetAll() (*[]site.SettingResponseDto, error) {
+	args := m.Called()
+
+	return args.Get(0).(*[]site.SettingResponseDto), args.Error(1)
+}
+
+func (m *MockSiteService) GetByKey(key site.Key) (*site.SettingResponseDto, error) {
+	args := m.Called(key)
+
+	return args.Get(0).(*site.SettingResponseDto), args.Error(1)
+}
+
+func (m *MockSiteService) Update(values map[site.Key]string) error {
+	args := m.Called(values)
+
+	return args.Error(0)
+}
+
+func (m *MockSiteService) DeleteByKey(key site.Key) error {
+	args := m.Called(key)
+
+	return args.Error(0)
+}
+
+type MockMailer struct {
+	mock.Mock
+}
+
+func (m *MockMailer) Send(message mail.Message) error {
+	args := m.Called(message)
+
+	return args.Error(0)
+}
+
+// waitFor waits for done to be closed by something running in the
+// background, failing the test if it takes too long.
+func waitFor(t *testing.T, done <-chan struct{}, msg string) {
+	t.Helper()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal(msg)
+	}
+}
+
+func testPasswordResetServiceRequest(t *testing.T, service PasswordResetService) {
+	var tokenHash string
+	var expiresAt time.Time
+	var message mail.Message
+	sent := make(chan struct{})
+
+	mockResetRepoCreate := mockResetRepo.
+		On("Create", "jane@example.org", mock.Anything, mock.Anything).
+		Return(true, nil).
+		Run(func(args mock.Arguments) {
+			tokenHash = args.String(1)
+			expiresAt = args.Get(2).(time.Time)
+		})
+
+	mockSiteServiceGet := mockSiteService.
+		On("Get").
+		Return(&site.Site{Name: "Dunce", Domain: "example.org"}, nil)
+
+	mockMailerSend := mockMailer.
+		On("Send", mock.Anything).
+		Return(nil).
+		Run(func(args mock.Arguments) {
+			message = args.Get(0).(mail.Message)
+			close(sent)
+		})
+
+	err := service.Request("jane@example.org", "http://localhost:8080")
+
+	require.NoError(t, err, "should not return error")
+
+	waitFor(t, sent, "should send reset email")
+
+	require.Equal(t, []string{"jane@example.org"}, message.To, "should email the user")
+	require.Equal(t, "Reset your password for Dunce", message.Subject, "should have subject with site name")
+	require.WithinDuration(t, time.Now().Add(time.Hour), expiresAt, time.Minute, "should expire after ttl")
+
+	link := "https://example.org/admin/reset-password/"
+	require.Contains(t, message.Body, link, "should link to reset page on site domain")
+
+	token := strings.SplitN(strings.SplitN(message.Body, link, 2)[1], "\n", 2)[0]
+	require.Equal(t, hashResetToken(token), tokenHash, "should only store hash of emailed token")
+	require.NotEqual(t, token, tokenHash, "should not store token itself")
+
+	if res := mockResetRepo.AssertExpectations(t); !res {
+		t.Error("should store reset token")
+	}
+
+	if res := mockMailer.AssertExpectations(t); !res {
+		t.Error("should send reset email")
+	}
+
+	mockResetRepoCreate.Unset()
+	mockSiteServiceGet.Unset()
+	mockMailerSend.Unset()
+}
+
+func testPasswordResetServiceRequestNoUser(t *testing.T, service PasswordResetService) {
+	mockResetRepoCreate := mockResetRepo.
+		On("Create", "nobody@example.org", mock.Anything, mock.Anything).
+		Return(false, nil)
+
+	mockSiteServiceGet := mockSiteService.
+		On("Get").
+		Return(&site.Site{Name: "Dunce"}, nil)
+
+	// no expectations on the mailer, so sending an email would fail the test
+	err := service.Request("nobody@example.org", "http://localhost:8080")
+
+	require.NoError(t, err, "should not return error for unknown email")
+
+	if res := mockResetRepo.AssertExpectations(t); !res {
+		t.Error("should try to store reset token")
+	}
+
+	mockResetRepoCreate.Unset()
+	mockSiteServiceGet.Unset()
+}
+
+func testPasswordResetServiceRequestMailerError(t *testing.T, service PasswordResetService) {
+	logged := make(chan struct{})
+
+	mockResetRepoCreate := mockResetRepo.
+		On("Create", "jane@example.org", mock.Anything, mock.Anything).
+		Return(true, nil)
+
+	mockSiteServiceGet := mockSiteService.
+		On("Get").
+		Return(&site.Site{Name: "Dunce"}, nil)
+
+	mockMailerSend := mockMailer.
+		On("Send", mock.Anything).
+		Return(errors.New("mailer_error"))
+
+	mockLoggerError := mockLogger.
+		On("Error", "unable to send password reset", []any{"err", errors.New("mailer_error")}).
+		Run(func(args mock.Arguments) {
+			close(logged)
+		})
+
+	err := service.Request("jane@example.org", "http://localhost:8080")
+
+	// the email's sent in the background, so failing to send it can't be
+	// told apart from there being no such user
+	require.NoError(t, err, "should not return mailer error")
+
+	waitFor(t, logged, "should log mailer error")
+
+	mockResetRepoCreate.Unset()
+	mockSiteServiceGet.Unset()
+	mockMailerSend.Unset()
+	mockLoggerError.Unset()
+}
+
+func testPasswordResetServiceRequestBaseUrl(t *testing.T, service PasswordResetService) {
+	var message mail.Message
+	sent := make(chan struct{})
+
+	mockResetRepoCreate := mockResetRepo.
+		On("Create", "jane@example.org", mock.Anything, mock.Anything).
+		Return(true, nil)
+
+	mockSiteServiceGet := mockSiteService.
+		On("Get").
+		Return(&site.Site{Name: "Dunce"}, nil)
+
+	mockMailerSend := mockMailer.
+		On("Send", mock.Anything).
+		Return(nil).
+		Run(func(args mock.Arguments) {
+			message = args.Get(0).(mail.Message)
+			close(sent)
+		})
+
+	err := service.Request("jane@example.org", "https://dunce.example.org")
+
+	require.NoError(t, err, "should not return error")
+
+	waitFor(t, sent, "should send reset email")
+	require.Contains(t, message.Body, "https://dunce.example.org/admin/reset-password/", "should link to reset page on base url")
+
+	mockResetRepoCreate.Unset()
+	mockSiteServiceGet.Unset()
+	mockMailerSend.Unset()
+}
+
+func testPasswordResetServiceRequestNoUrl(t *testing.T, service PasswordResetService) {
+	mockSiteServiceGet := mockSiteService.
+		On("Get").
+		Return(&site.Site{Name: "Dunce"}, nil)
+
+	// no expectations on the repo or mailer, so storing a token or sending an
+	// email would fail the test
+	err := service.Request("jane@example.org", "")
+
+	require.ErrorIs(t, err, site.ErrNoUrl, "should refuse to send without a url")
+
+	mockSiteServiceGet.Unset()
+}
+
+func testPasswordResetServiceValid(t *testing.T, service PasswordResetService) {
+	mockResetRepoExists := mockResetRepo.
+		On("Exists", hashResetToken("t0ken"), mock.Anything).
+		Return(true, nil)
+
+	valid, err := service.Valid("t0ken")
+
+	require.NoError(t, err, "should not return error")
+	require.True(t, valid, "should report token is valid")
+
+	if res := mockResetRepo.AssertExpectations(t); !res {
+		t.Error("should look up token by its hash")
+	}
+
+	mockResetRepoExists.Unset()
+}
+
+func testPasswordResetServiceReset(t *testing.T, service PasswordResetService) {
+	mockCryptoGenerateFromPassword := mockCrypto.
+		On("GenerateFromPassword", []byte("n3wp4ssw0rd"), 14).
+		Return([]byte("h4shedn3wp4ssw0rd"), nil)
+
+	mockResetRepoReset := mockResetRepo.
+		On("Reset", hashResetToken("t0ken"), "h4shedn3wp4ssw0rd", mock.Anything).
+		Return(nil)
+
+	err := service.Reset("t0ken", &UserPasswordResetRequestDto{
+		NewPassword:     "n3wp4ssw0rd",
+		ConfirmPassword: "n3wp4ssw0rd",
+	})
+
+	require.NoError(t, err, "should not return error")
+
+	if res := mockResetRepo.AssertExpectations(t); !res {
+		t.Error("should reset password with token")
+	}
+
+	mockCryptoGenerateFromPassword.Unset()
+	mockResetRepoReset.Unset()
+}
+
+func testPasswordResetServiceResetInvalidToken(t *testing.T, service PasswordResetService) {
+	mockCryptoGenerateFromPassword := mockCrypto.
+		On("GenerateFromPassword", []byte("n3wp4ssw0rd"), 14).
+		Return([]byte("h4shedn3wp4ssw0rd"), nil)
+
+	mockResetRepoReset := mockResetRepo.
+		On("Reset", hashResetToken("t0ken"), "h4shedn3wp4ssw0rd", mock.Anything).
+		Return(ErrInvalidResetToken)
+
+	err := service.Reset("t0ken", &UserPasswordResetRequestDto{
+		NewPassword:     "n3wp4ssw0rd",
+		ConfirmPassword: "n3wp4ssw0rd",
+	})
+
+	require.ErrorIs(t, err, ErrInvalidResetToken, "should return invalid token error")
+
+	mockCryptoGenerateFromPassword.Unset()
+	mockResetRepoReset.Unset()
+}
+
+func testPasswordResetServiceResetValidationError(t *testing.T, service PasswordResetService) {
+	err := service.Reset("t0ken", &UserPasswordResetRequestDto{
+		NewPassword:     "n3wp4ssw0rd",
+		ConfirmPassword: "different",
+	})
+
+	require.Error(t, err, "should return validation error")
+}
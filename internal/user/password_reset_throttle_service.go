@@ -0,0 +1,93 @@
+package user
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/nixpig/dunce/pkg/logging"
+)
+
+type PasswordResetThrottleService interface {
+	Check(email, ip string) error
+	Request(ctx context.Context, email, ip string) error
+}
+
+// PasswordResetThrottleServiceImpl limits password reset requests both for
+// each email, against flooding one inbox, and from each client IP, against
+// sending mail to many. Requests are counted in the same store as failed
+// logins, under their own keys.
+type PasswordResetThrottleServiceImpl struct {
+	repo        LoginThrottleRepository
+	log         logging.Logger
+	emailPolicy LoginThrottlePolicy
+	ipPolicy    LoginThrottlePolicy
+}
+
+func NewPasswordResetThrottleService(
+	repo LoginThrottleRepository,
+	log logging.Logger,
+	emailPolicy LoginThrottlePolicy,
+	ipPolicy LoginThrottlePolicy,
+) PasswordResetThrottleServiceImpl {
+	return PasswordResetThrottleServiceImpl{
+		repo:        repo,
+		log:         log,
+		emailPolicy: emailPolicy,
+		ipPolicy:    ipPolicy,
+	}
+}
+
+// Check returns a *PasswordResetLockedError if password resets for email or
+// from ip are refused.
+func (p PasswordResetThrottleServiceImpl) Check(email, ip string) error {
+	lockedUntil, err := p.repo.LockedUntil([]string{resetEmailKey(email), resetIpKey(ip)})
+	if err != nil {
+		return err
+	}
+
+	if lockedUntil.After(time.Now()) {
+		return &PasswordResetLockedError{Until: lockedUntil}
+	}
+
+	return nil
+}
+
+// Request records a password reset requested for email from ip, refusing
+// more for either once they've had too many. Requests are counted whether or
+// not there's an account for the email, so being refused doesn't give that
+// away.
+func (p PasswordResetThrottleServiceImpl) Request(ctx context.Context, email, ip string) error {
+	if err := p.request(ctx, resetEmailKey(email), p.emailPolicy); err != nil {
+		return err
+	}
+
+	return p.request(ctx, resetIpKey(ip), p.ipPolicy)
+}
+
+func (p PasswordResetThrottleServiceImpl) request(ctx context.Context, key string, policy LoginThrottlePolicy) error {
+	until, requests, err := record(p.repo, key, policy)
+	if err != nil || until.IsZero() {
+		return err
+	}
+
+	p.log.Info(
+		ctx,
+		"refusing password resets after too many requests",
+		"key", key,
+		"until", until.Format(time.RFC3339),
+		"requests", requests,
+	)
+
+	return nil
+}
+
+// emails are matched ignoring case, so changing it doesn't get around the
+// limit
+func resetEmailKey(email string) string {
+	return "reset-email:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func resetIpKey(ip string) string {
+	return "reset-ip:" + ip
+}
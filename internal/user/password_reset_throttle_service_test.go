@@ -0,0 +1,133 @@
+package user
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var testResetEmailPolicy = LoginThrottlePolicy{
+	FreeAttempts: 3,
+	BaseDelay:    15 * time.Minute,
+	MaxDelay:     24 * time.Hour,
+	Window:       24 * time.Hour,
+}
+
+var testResetIpPolicy = LoginThrottlePolicy{
+	FreeAttempts: 10,
+	BaseDelay:    15 * time.Minute,
+	MaxDelay:     24 * time.Hour,
+	Window:       time.Hour,
+}
+
+func TestPasswordResetThrottleService(t *testing.T) {
+	scenarios := map[string]func(t *testing.T, service PasswordResetThrottleService){
+		"check (success - not locked)":            testPasswordResetThrottleServiceCheck,
+		"check (error - locked)":                  testPasswordResetThrottleServiceCheckLocked,
+		"request (success - under free attempts)": testPasswordResetThrottleServiceRequest,
+		"request (success - locks out)":           testPasswordResetThrottleServiceRequestLocks,
+	}
+
+	for scenario, fn := range scenarios {
+		t.Run(scenario, func(t *testing.T) {
+			service := NewPasswordResetThrottleService(mockThrottleRepo, mockLogger, testResetEmailPolicy, testResetIpPolicy)
+
+			fn(t, service)
+		})
+	}
+}
+
+func testPasswordResetThrottleServiceCheck(t *testing.T, service PasswordResetThrottleService) {
+	mockRepoLockedUntil := mockThrottleRepo.
+		On("LockedUntil", []string{"reset-email:jane@example.org", "reset-ip:192.0.2.1"}).
+		Return(time.Time{}, nil)
+
+	err := service.Check("Jane@Example.org", "192.0.2.1")
+
+	require.NoError(t, err, "should not return error")
+
+	if res := mockThrottleRepo.AssertExpectations(t); !res {
+		t.Error("should check email ignoring case and ip")
+	}
+
+	mockRepoLockedUntil.Unset()
+}
+
+func testPasswordResetThrottleServiceCheckLocked(t *testing.T, service PasswordResetThrottleService) {
+	lockedUntil := time.Now().Add(time.Minute)
+
+	mockRepoLockedUntil := mockThrottleRepo.
+		On("LockedUntil", []string{"reset-email:jane@example.org", "reset-ip:192.0.2.1"}).
+		Return(lockedUntil, nil)
+
+	err := service.Check("jane@example.org", "192.0.2.1")
+
+	require.Equal(t, &PasswordResetLockedError{Until: lockedUntil}, err, "should return locked error")
+
+	mockRepoLockedUntil.Unset()
+}
+
+func testPasswordResetThrottleServiceRequest(t *testing.T, service PasswordResetThrottleService) {
+	mockRepoFailEmail := mockThrottleRepo.
+		On("Fail", "reset-email:jane@example.org", mock.Anything, mock.Anything).
+		Return(3, nil)
+
+	mockRepoFailIp := mockThrottleRepo.
+		On("Fail", "reset-ip:192.0.2.1", mock.Anything, mock.Anything).
+		Return(10, nil)
+
+	err := service.Request(context.Background(), "jane@example.org", "192.0.2.1")
+
+	require.NoError(t, err, "should not return error")
+
+	if res := mockThrottleRepo.AssertExpectations(t); !res {
+		t.Error("should count requests for email and ip")
+	}
+
+	mockRepoFailEmail.Unset()
+	mockRepoFailIp.Unset()
+}
+
+func testPasswordResetThrottleServiceRequestLocks(t *testing.T, service PasswordResetThrottleService) {
+	var now, since, until time.Time
+
+	mockRepoFailEmail := mockThrottleRepo.
+		On("Fail", "reset-email:jane@example.org", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			now = args.Get(1).(time.Time)
+			since = args.Get(2).(time.Time)
+		}).
+		Return(4, nil)
+
+	mockRepoLock := mockThrottleRepo.
+		On("Lock", "reset-email:jane@example.org", mock.Anything).
+		Run(func(args mock.Arguments) {
+			until = args.Get(1).(time.Time)
+		}).
+		Return(nil)
+
+	mockLoggerInfo := mockLogger.
+		On("Info", "refusing password resets after too many requests", mock.Anything)
+
+	mockRepoFailIp := mockThrottleRepo.
+		On("Fail", "reset-ip:192.0.2.1", mock.Anything, mock.Anything).
+		Return(1, nil)
+
+	err := service.Request(context.Background(), "jane@example.org", "192.0.2.1")
+
+	require.NoError(t, err, "should not return error")
+	require.Equal(t, 24*time.Hour, now.Sub(since), "should count requests within window")
+	require.Equal(t, 15*time.Minute, until.Sub(now), "should refuse requests for backoff delay")
+
+	if res := mockLogger.AssertExpectations(t); !res {
+		t.Error("should log lockout")
+	}
+
+	mockRepoFailEmail.Unset()
+	mockRepoLock.Unset()
+	mockLoggerInfo.Unset()
+	mockRepoFailIp.Unset()
+}
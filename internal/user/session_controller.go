@@ -0,0 +1,122 @@
+package user
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/nixpig/dunce/internal/app/errors"
+	"github.com/nixpig/dunce/pkg/logging"
+	"github.com/nixpig/dunce/pkg/session"
+	"github.com/nixpig/dunce/pkg/templates"
+)
+
+type SessionController struct {
+	sessions       session.SessionList
+	log            logging.Logger
+	templateCache  templates.TemplateCache
+	sessionManager session.SessionManager
+	csrfToken      func(r *http.Request) string
+	errorHandlers  errors.ErrorHandlers
+}
+
+type SessionControllerConfig struct {
+	Log            logging.Logger
+	TemplateCache  templates.TemplateCache
+	SessionManager session.SessionManager
+	CsrfToken      func(*http.Request) string
+	ErrorHandlers  errors.ErrorHandlers
+}
+
+type SessionsView struct {
+	Message         string
+	Sessions        []session.Session
+	CsrfToken       string
+	IsAuthenticated bool
+}
+
+func NewSessionController(
+	sessions session.SessionList,
+	config SessionControllerConfig,
+) SessionController {
+	return SessionController{
+		sessions:       sessions,
+		log:            config.Log,
+		templateCache:  config.TemplateCache,
+		sessionManager: config.SessionManager,
+		csrfToken:      config.CsrfToken,
+		errorHandlers:  config.ErrorHandlers,
+	}
+}
+
+// SessionsGet lists the logged in user's sessions. Sessions from before their
+// password last changed are left out, since they've already been logged out.
+func (s *SessionController) SessionsGet(w http.ResponseWriter, r *http.Request) {
+	account, ok := s.loggedInUser(r)
+	if !ok {
+		s.errorHandlers.Forbidden(w, r)
+		return
+	}
+
+	all, err := s.sessions.List(r.Context(), account.Username)
+	if err != nil {
+		s.errorHandlers.InternalServerError(w, r, fmt.Errorf("unable to list sessions: %w", err))
+		return
+	}
+
+	sessions := []session.Session{}
+
+	for _, sess := range all {
+		if sess.Version == account.SessionVersion {
+			sessions = append(sessions, sess)
+		}
+	}
+
+	if err := s.templateCache["pages/admin/sessions.tmpl"].ExecuteTemplate(w, "admin", SessionsView{
+		Message:         s.sessionManager.PopString(r.Context(), session.SESSION_KEY_MESSAGE),
+		Sessions:        sessions,
+		CsrfToken:       s.csrfToken(r),
+		IsAuthenticated: s.isAuthenticated(r),
+	}); err != nil {
+		s.errorHandlers.InternalServerError(w, r, err)
+	}
+}
+
+func (s *SessionController) SessionRevokePost(w http.ResponseWriter, r *http.Request) {
+	account, ok := s.loggedInUser(r)
+	if !ok {
+		s.errorHandlers.Forbidden(w, r)
+		return
+	}
+
+	message := "Logged out of session."
+
+	if err := s.sessions.Revoke(r.Context(), account.Username, r.PathValue("id")); err != nil {
+		if err != session.ErrSessionNotFound {
+			s.errorHandlers.InternalServerError(w, r, fmt.Errorf("unable to revoke session: %w", err))
+			return
+		}
+
+		message = "Unable to log out of session: it may have already ended."
+	}
+
+	s.sessionManager.Put(r.Context(), session.SESSION_KEY_MESSAGE, message)
+
+	http.Redirect(w, r, "/admin/account/sessions", http.StatusSeeOther)
+}
+
+func (s *SessionController) isAuthenticated(r *http.Request) bool {
+	isAuthenticated, ok := r.Context().Value(session.IS_LOGGED_IN_CONTEXT_KEY).(bool)
+	if !ok {
+		return false
+	}
+
+	return isAuthenticated
+}
+
+// loggedInUser returns the user whose permissions were checked for the
+// request.
+func (s *SessionController) loggedInUser(r *http.Request) (*UserResponseDto, bool) {
+	loggedInUser, ok := r.Context().Value(session.LOGGED_IN_USER_CONTEXT_KEY).(*UserResponseDto)
+
+	return loggedInUser, ok
+}
@@ -0,0 +1,230 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nixpig/dunce/pkg/session"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var mockSessionList = new(MockSessionList)
+
+func TestSessionController(t *testing.T) {
+	scenarios := map[string]func(t *testing.T, ctrl SessionController){
+		"get sessions (success)":                  testGetSessions,
+		"get sessions (error - list error)":       testGetSessionsListError,
+		"post revoke session (success)":           testPostRevokeSession,
+		"post revoke session (error - not found)": testPostRevokeSessionNotFound,
+	}
+
+	for scenario, fn := range scenarios {
+		t.Run(scenario, func(t *testing.T) {
+			config := SessionControllerConfig{
+				Log:            mockLogger,
+				TemplateCache:  mockTemplateCache,
+				SessionManager: mockSessionManager,
+				CsrfToken: func(r *http.Request) string {
+					return "mock-token"
+				},
+				ErrorHandlers: mockErrorHandlers,
+			}
+
+			ctrl := NewSessionController(mockSessionList, config)
+			fn(t, ctrl)
+		})
+	}
+}
+
+type MockSessionList struct {
+	mock.Mock
+}
+
+func (m *MockSessionList) List(ctx context.Context, username string) ([]session.Session, error) {
+	args := m.Called(ctx, username)
+
+	return args.Get(0).([]session.Session), args.Error(1)
+}
+
+func (m *MockSessionList) Revoke(ctx context.Context, username, id string) error {
+	args := m.Called(ctx, username, id)
+
+	return args.Error(0)
+}
+
+func testGetSessions(t *testing.T, ctrl SessionController) {
+	req, err := http.NewRequest("GET", "/admin/account/sessions", nil)
+	if err != nil {
+		t.Error("unable to construct request")
+	}
+
+	req = withLoggedInUser(req, &UserResponseDto{Id: 23, Username: "janedoe", SessionVersion: 2})
+
+	rr := httptest.NewRecorder()
+
+	handler := http.HandlerFunc(ctrl.SessionsGet)
+
+	current := session.Session{
+		Id:        "c0ffee",
+		Username:  "janedoe",
+		Version:   2,
+		UserAgent: "Mozilla/5.0 (X11; Linux x86_64; rv:125.0) Gecko/20100101 Firefox/125.0",
+		Ip:        "192.0.2.1",
+		LastSeen:  time.Date(2024, time.March, 1, 12, 30, 0, 0, time.UTC),
+		Current:   true,
+	}
+
+	stale := session.Session{
+		Id:       "deadbeef",
+		Username: "janedoe",
+		Version:  1,
+		Ip:       "192.0.2.2",
+		LastSeen: time.Date(2024, time.February, 1, 12, 30, 0, 0, time.UTC),
+	}
+
+	mockSessionListList := mockSessionList.
+		On("List", req.Context(), "janedoe").
+		Return([]session.Session{current, stale}, nil)
+
+	mockSessionManagerPopString := mockSessionManager.
+		On("PopString", req.Context(), session.SESSION_KEY_MESSAGE).
+		Return("msg")
+
+	mockTemplateExecuteTemplate := mockTemplate.On(
+		"ExecuteTemplate",
+		rr,
+		"admin",
+		SessionsView{
+			Message:   "msg",
+			Sessions:  []session.Session{current},
+			CsrfToken: "mock-token",
+		},
+	).Return(nil)
+
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Result().StatusCode, "should return status code ok")
+
+	if res := mockTemplate.AssertExpectations(t); !res {
+		t.Error("should execute template with sessions from the current password")
+	}
+
+	mockSessionListList.Unset()
+	mockSessionManagerPopString.Unset()
+	mockTemplateExecuteTemplate.Unset()
+}
+
+func testGetSessionsListError(t *testing.T, ctrl SessionController) {
+	req, err := http.NewRequest("GET", "/admin/account/sessions", nil)
+	if err != nil {
+		t.Error("unable to construct request")
+	}
+
+	req = withLoggedInUser(req, &UserResponseDto{Id: 23, Username: "janedoe"})
+
+	rr := httptest.NewRecorder()
+
+	handler := http.HandlerFunc(ctrl.SessionsGet)
+
+	mockSessionListList := mockSessionList.
+		On("List", req.Context(), "janedoe").
+		Return([]session.Session{}, errors.New("store_error"))
+
+	mockErrorHandlersInternalServerError := mockErrorHandlers.
+		On("InternalServerError", rr, req, fmt.Errorf("unable to list sessions: %w", errors.New("store_error"))).
+		Run(func(args mock.Arguments) {
+			rr.WriteHeader(http.StatusInternalServerError)
+		})
+
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(
+		t,
+		http.StatusInternalServerError,
+		rr.Result().StatusCode,
+		"should return status code internal server error",
+	)
+
+	mockSessionListList.Unset()
+	mockErrorHandlersInternalServerError.Unset()
+}
+
+func testPostRevokeSession(t *testing.T, ctrl SessionController) {
+	req, err := http.NewRequest("POST", "/admin/account/sessions/{id}/revoke", nil)
+	if err != nil {
+		t.Error("unable to construct request")
+	}
+
+	req.SetPathValue("id", "deadbeef")
+	req = withLoggedInUser(req, &UserResponseDto{Id: 23, Username: "janedoe"})
+
+	rr := httptest.NewRecorder()
+
+	handler := http.HandlerFunc(ctrl.SessionRevokePost)
+
+	mockSessionListRevoke := mockSessionList.
+		On("Revoke", req.Context(), "janedoe", "deadbeef").
+		Return(nil)
+
+	mockSessionManagerPut := mockSessionManager.
+		On("Put", req.Context(), session.SESSION_KEY_MESSAGE, "Logged out of session.")
+
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusSeeOther, rr.Result().StatusCode, "should return status code see other")
+	require.Equal(t, "/admin/account/sessions", rr.Result().Header.Get("Location"), "should redirect to sessions page")
+
+	if res := mockSessionList.AssertExpectations(t); !res {
+		t.Error("should revoke session")
+	}
+
+	if res := mockSessionManager.AssertExpectations(t); !res {
+		t.Error("should put message in session")
+	}
+
+	mockSessionListRevoke.Unset()
+	mockSessionManagerPut.Unset()
+}
+
+func testPostRevokeSessionNotFound(t *testing.T, ctrl SessionController) {
+	req, err := http.NewRequest("POST", "/admin/account/sessions/{id}/revoke", nil)
+	if err != nil {
+		t.Error("unable to construct request")
+	}
+
+	req.SetPathValue("id", "deadbeef")
+	req = withLoggedInUser(req, &UserResponseDto{Id: 23, Username: "janedoe"})
+
+	rr := httptest.NewRecorder()
+
+	handler := http.HandlerFunc(ctrl.SessionRevokePost)
+
+	mockSessionListRevoke := mockSessionList.
+		On("Revoke", req.Context(), "janedoe", "deadbeef").
+		Return(session.ErrSessionNotFound)
+
+	mockSessionManagerPut := mockSessionManager.On(
+		"Put",
+		req.Context(),
+		session.SESSION_KEY_MESSAGE,
+		"Unable to log out of session: it may have already ended.",
+	)
+
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusSeeOther, rr.Result().StatusCode, "should return status code see other")
+	require.Equal(t, "/admin/account/sessions", rr.Result().Header.Get("Location"), "should redirect to sessions page")
+
+	if res := mockSessionManager.AssertExpectations(t); !res {
+		t.Error("should put message in session")
+	}
+
+	mockSessionListRevoke.Unset()
+	mockSessionManagerPut.Unset()
+}
@@ -0,0 +1,335 @@
+package user
+
+import (
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"net/http"
+
+	"github.com/nixpig/dunce/internal/app/errors"
+	"github.com/nixpig/dunce/pkg/logging"
+	"github.com/nixpig/dunce/pkg/session"
+	"github.com/nixpig/dunce/pkg/templates"
+)
+
+type TwoFactorController struct {
+	service        TwoFactorService
+	userService    UserService
+	throttle       LoginThrottleService
+	log            logging.Logger
+	templateCache  templates.TemplateCache
+	sessionManager session.SessionManager
+	csrfToken      func(r *http.Request) string
+	errorHandlers  errors.ErrorHandlers
+}
+
+type TwoFactorControllerConfig struct {
+	Log            logging.Logger
+	TemplateCache  templates.TemplateCache
+	SessionManager session.SessionManager
+	CsrfToken      func(*http.Request) string
+	ErrorHandlers  errors.ErrorHandlers
+}
+
+type TwoFactorLoginView struct {
+	Message         string
+	CsrfToken       string
+	IsAuthenticated bool
+}
+
+// TwoFactorView is the two-factor authentication section of the account.
+// Key is only set while two-factor authentication is being set up.
+type TwoFactorView struct {
+	Message         string
+	TwoFactor       *TwoFactor
+	Key             *TwoFactorKey
+	QrCode          template.URL
+	CsrfToken       string
+	IsAuthenticated bool
+}
+
+type RecoveryCodesView struct {
+	Message         string
+	RecoveryCodes   []string
+	CsrfToken       string
+	IsAuthenticated bool
+}
+
+func NewTwoFactorController(
+	service TwoFactorService,
+	userService UserService,
+	throttle LoginThrottleService,
+	config TwoFactorControllerConfig,
+) TwoFactorController {
+	return TwoFactorController{
+		service:        service,
+		userService:    userService,
+		throttle:       throttle,
+		log:            config.Log,
+		templateCache:  config.TemplateCache,
+		sessionManager: config.SessionManager,
+		csrfToken:      config.CsrfToken,
+		errorHandlers:  config.ErrorHandlers,
+	}
+}
+
+func (t *TwoFactorController) LoginTwoFactorGet(w http.ResponseWriter, r *http.Request) {
+	if t.sessionManager.GetString(r.Context(), session.PENDING_TWO_FACTOR_USERNAME) == "" {
+		http.Redirect(w, r, "/admin/login", http.StatusSeeOther)
+		return
+	}
+
+	if err := t.templateCache["pages/admin/login-two-factor.tmpl"].ExecuteTemplate(w, "admin", TwoFactorLoginView{
+		Message:   t.sessionManager.PopString(r.Context(), session.SESSION_KEY_MESSAGE),
+		CsrfToken: t.csrfToken(r),
+	}); err != nil {
+		t.errorHandlers.InternalServerError(w, r, err)
+	}
+}
+
+// LoginTwoFactorPost finishes logging in someone who's already given their
+// password, once they give a code from their authenticator or a recovery
+// code.
+func (t *TwoFactorController) LoginTwoFactorPost(w http.ResponseWriter, r *http.Request) {
+	username := t.sessionManager.GetString(r.Context(), session.PENDING_TWO_FACTOR_USERNAME)
+	if username == "" {
+		http.Redirect(w, r, "/admin/login", http.StatusSeeOther)
+		return
+	}
+
+	ip := clientIp(r)
+
+	// codes are throttled along with passwords, since they're much easier to
+	// guess
+	if err := t.throttle.Check(username, ip); err != nil {
+		if locked, ok := err.(*LoginLockedError); ok {
+			t.sessionManager.Put(r.Context(), session.SESSION_KEY_MESSAGE, lockedMessage(locked))
+			http.Redirect(w, r, "/admin/login/two-factor", http.StatusSeeOther)
+			return
+		}
+
+		t.errorHandlers.InternalServerError(w, r, fmt.Errorf("unable to check failed logins: %w", err))
+		return
+	}
+
+	if err := t.service.Verify(username, r.FormValue("code")); err != nil {
+		if err == ErrInvalidTwoFactorCode {
+			if err := t.throttle.Fail(r.Context(), username, ip); err != nil {
+				t.log.Error(r.Context(), "unable to record failed login", "err", err)
+			}
+
+			t.sessionManager.Put(r.Context(), session.SESSION_KEY_MESSAGE, "Invalid code.")
+			http.Redirect(w, r, "/admin/login/two-factor", http.StatusSeeOther)
+			return
+		}
+
+		t.errorHandlers.InternalServerError(w, r, fmt.Errorf("unable to verify two-factor code: %w", err))
+		return
+	}
+
+	if err := t.sessionManager.RenewToken(r.Context()); err != nil {
+		t.log.Error(r.Context(), "unable to renew session token", "err", err)
+		http.Redirect(w, r, "/admin/login", http.StatusSeeOther)
+		return
+	}
+
+	loggedInUser, err := t.userService.GetByAttribute("username", username)
+	if err != nil {
+		t.errorHandlers.InternalServerError(w, r, err)
+		return
+	}
+
+	if err := t.throttle.Succeed(username); err != nil {
+		t.log.Error(r.Context(), "unable to clear failed logins", "err", err)
+	}
+
+	t.sessionManager.Remove(r.Context(), session.PENDING_TWO_FACTOR_USERNAME)
+	t.sessionManager.Put(r.Context(), session.LOGGED_IN_USERNAME, username)
+	t.sessionManager.Put(r.Context(), session.SESSION_VERSION, loggedInUser.SessionVersion)
+
+	http.Redirect(w, r, "/admin/articles", http.StatusSeeOther)
+}
+
+// TwoFactorGet shows whether the logged in user has two-factor authentication
+// turned on and, if not, a key to set it up with. The key's secret is kept in
+// the session until it's confirmed, so the same key is shown until then.
+func (t *TwoFactorController) TwoFactorGet(w http.ResponseWriter, r *http.Request) {
+	account, ok := t.loggedInUser(r)
+	if !ok {
+		t.errorHandlers.Forbidden(w, r)
+		return
+	}
+
+	twoFactor, err := t.service.Get(account.Username)
+	if err != nil {
+		t.errorHandlers.InternalServerError(w, r, fmt.Errorf("unable to get two-factor authentication: %w", err))
+		return
+	}
+
+	view := TwoFactorView{
+		Message:         t.sessionManager.PopString(r.Context(), session.SESSION_KEY_MESSAGE),
+		TwoFactor:       twoFactor,
+		CsrfToken:       t.csrfToken(r),
+		IsAuthenticated: t.isAuthenticated(r),
+	}
+
+	if !twoFactor.Enabled {
+		key, err := t.service.Enrol(
+			account.Username,
+			t.sessionManager.GetString(r.Context(), session.TWO_FACTOR_SECRET),
+		)
+		if err != nil {
+			t.errorHandlers.InternalServerError(w, r, fmt.Errorf("unable to enrol in two-factor authentication: %w", err))
+			return
+		}
+
+		t.sessionManager.Put(r.Context(), session.TWO_FACTOR_SECRET, key.Secret)
+
+		view.Key = key
+		view.QrCode = template.URL("data:image/png;base64," + base64.StdEncoding.EncodeToString(key.QrCode))
+	}
+
+	if err := t.templateCache["pages/admin/two-factor.tmpl"].ExecuteTemplate(w, "admin", view); err != nil {
+		t.errorHandlers.InternalServerError(w, r, err)
+		return
+	}
+}
+
+// TwoFactorEnablePost turns on two-factor authentication for the logged in
+// user with the key they were shown, and shows their recovery codes.
+func (t *TwoFactorController) TwoFactorEnablePost(w http.ResponseWriter, r *http.Request) {
+	account, ok := t.loggedInUser(r)
+	if !ok {
+		t.errorHandlers.Forbidden(w, r)
+		return
+	}
+
+	secret := t.sessionManager.GetString(r.Context(), session.TWO_FACTOR_SECRET)
+	if secret == "" {
+		http.Redirect(w, r, "/admin/account/two-factor", http.StatusSeeOther)
+		return
+	}
+
+	recoveryCodes, err := t.service.Enable(account.Username, secret, r.FormValue("code"))
+	if err != nil {
+		if err == ErrInvalidTwoFactorCode {
+			t.sessionManager.Put(
+				r.Context(),
+				session.SESSION_KEY_MESSAGE,
+				"Unable to turn on two-factor authentication: invalid code.",
+			)
+			http.Redirect(w, r, "/admin/account/two-factor", http.StatusSeeOther)
+			return
+		}
+
+		t.errorHandlers.InternalServerError(w, r, fmt.Errorf("unable to enable two-factor authentication: %w", err))
+		return
+	}
+
+	t.sessionManager.Remove(r.Context(), session.TWO_FACTOR_SECRET)
+
+	t.recoveryCodes(w, r, "Turned on two-factor authentication.", recoveryCodes)
+}
+
+func (t *TwoFactorController) TwoFactorDisablePost(w http.ResponseWriter, r *http.Request) {
+	account, ok := t.loggedInUser(r)
+	if !ok {
+		t.errorHandlers.Forbidden(w, r)
+		return
+	}
+
+	message := "Turned off two-factor authentication."
+
+	if err := t.service.Disable(account.Username, r.FormValue("code")); err != nil {
+		if err != ErrInvalidTwoFactorCode {
+			t.errorHandlers.InternalServerError(w, r, fmt.Errorf("unable to disable two-factor authentication: %w", err))
+			return
+		}
+
+		message = "Unable to turn off two-factor authentication: invalid code."
+	}
+
+	t.sessionManager.Put(r.Context(), session.SESSION_KEY_MESSAGE, message)
+
+	http.Redirect(w, r, "/admin/account/two-factor", http.StatusSeeOther)
+}
+
+func (t *TwoFactorController) RecoveryCodesPost(w http.ResponseWriter, r *http.Request) {
+	account, ok := t.loggedInUser(r)
+	if !ok {
+		t.errorHandlers.Forbidden(w, r)
+		return
+	}
+
+	recoveryCodes, err := t.service.RegenerateRecoveryCodes(account.Username, r.FormValue("code"))
+	if err != nil {
+		if err == ErrInvalidTwoFactorCode {
+			t.sessionManager.Put(
+				r.Context(),
+				session.SESSION_KEY_MESSAGE,
+				"Unable to replace recovery codes: invalid code.",
+			)
+			http.Redirect(w, r, "/admin/account/two-factor", http.StatusSeeOther)
+			return
+		}
+
+		t.errorHandlers.InternalServerError(w, r, fmt.Errorf("unable to regenerate recovery codes: %w", err))
+		return
+	}
+
+	t.recoveryCodes(w, r, "Replaced your recovery codes. The old ones can no longer be used.", recoveryCodes)
+}
+
+// UserTwoFactorResetPost lets admins turn off two-factor authentication for
+// a user who can no longer log in with it.
+func (t *TwoFactorController) UserTwoFactorResetPost(w http.ResponseWriter, r *http.Request) {
+	username := r.PathValue("slug")
+
+	if err := t.service.Reset(username); err != nil {
+		t.errorHandlers.InternalServerError(w, r, fmt.Errorf("unable to reset two-factor authentication: %w", err))
+		return
+	}
+
+	t.sessionManager.Put(
+		r.Context(),
+		session.SESSION_KEY_MESSAGE,
+		fmt.Sprintf("Turned off two-factor authentication for '%s'.", username),
+	)
+
+	http.Redirect(w, r, "/admin/users/"+username, http.StatusSeeOther)
+}
+
+// recoveryCodes shows recovery codes straight away rather than redirecting,
+// since they're never stored anywhere they could be shown from again.
+func (t *TwoFactorController) recoveryCodes(
+	w http.ResponseWriter,
+	r *http.Request,
+	message string,
+	recoveryCodes []string,
+) {
+	if err := t.templateCache["pages/admin/recovery-codes.tmpl"].ExecuteTemplate(w, "admin", RecoveryCodesView{
+		Message:         message,
+		RecoveryCodes:   recoveryCodes,
+		CsrfToken:       t.csrfToken(r),
+		IsAuthenticated: t.isAuthenticated(r),
+	}); err != nil {
+		t.errorHandlers.InternalServerError(w, r, err)
+	}
+}
+
+func (t *TwoFactorController) isAuthenticated(r *http.Request) bool {
+	isAuthenticated, ok := r.Context().Value(session.IS_LOGGED_IN_CONTEXT_KEY).(bool)
+	if !ok {
+		return false
+	}
+
+	return isAuthenticated
+}
+
+// loggedInUser returns the user whose permissions were checked for the
+// request.
+func (t *TwoFactorController) loggedInUser(r *http.Request) (*UserResponseDto, bool) {
+	loggedInUser, ok := r.Context().Value(session.LOGGED_IN_USER_CONTEXT_KEY).(*UserResponseDto)
+
+	return loggedInUser, ok
+}
@@ -0,0 +1,525 @@
+package user
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/nixpig/dunce/pkg/session"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var mockTwoFactorService = new(MockTwoFactorService)
+
+func TestTwoFactorController(t *testing.T) {
+	scenarios := map[string]func(t *testing.T, ctrl TwoFactorController){
+		"get two-factor login page (error - no pending login)": testGetLoginTwoFactorNoPendingLogin,
+		"post two-factor login (success)":                      testPostLoginTwoFactor,
+		"post two-factor login (error - invalid code)":         testPostLoginTwoFactorInvalidCode,
+		"post two-factor login (error - no pending login)":     testPostLoginTwoFactorNoPendingLogin,
+		"get two-factor page (success - enrol)":                testGetTwoFactorEnrol,
+		"get two-factor page (success - enabled)":              testGetTwoFactorEnabled,
+		"post enable two-factor (success)":                     testPostTwoFactorEnable,
+		"post enable two-factor (error - invalid code)":        testPostTwoFactorEnableInvalidCode,
+		"post disable two-factor (success)":                    testPostTwoFactorDisable,
+		"post reset user two-factor (success)":                 testPostUserTwoFactorReset,
+	}
+
+	for scenario, fn := range scenarios {
+		t.Run(scenario, func(t *testing.T) {
+			config := TwoFactorControllerConfig{
+				Log:            mockLogger,
+				TemplateCache:  mockTemplateCache,
+				SessionManager: mockSessionManager,
+				CsrfToken: func(r *http.Request) string {
+					return "mock-token"
+				},
+				ErrorHandlers: mockErrorHandlers,
+			}
+
+			ctrl := NewTwoFactorController(mockTwoFactorService, mockService, mockThrottle, config)
+			fn(t, ctrl)
+		})
+	}
+}
+
+type MockTwoFactorService struct {
+	mock.Mock
+}
+
+func (m *MockTwoFactorService) Get(username string) (*TwoFactor, error) {
+	args := m.Called(username)
+
+	return args.Get(0).(*TwoFactor), args.Error(1)
+}
+
+func (m *MockTwoFactorService) Enrol(username, secret string) (*TwoFactorKey, error) {
+	args := m.Called(username, secret)
+
+	return args.Get(0).(*TwoFactorKey), args.Error(1)
+}
+
+func (m *MockTwoFactorService) Enable(username, secret, code string) ([]string, error) {
+	args := m.Called(username, secret, code)
+
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockTwoFactorService) Disable(username, code string) error {
+	args := m.Called(username, code)
+
+	return args.Error(0)
+}
+
+func (m *MockTwoFactorService) Reset(username string) error {
+	args := m.Called(username)
+
+	return args.Error(0)
+}
+
+func (m *MockTwoFactorService) RegenerateRecoveryCodes(username, code string) ([]string, error) {
+	args := m.Called(username, code)
+
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockTwoFactorService) Verify(username, code string) error {
+	args := m.Called(username, code)
+
+	return args.Error(0)
+}
+
+func testGetLoginTwoFactorNoPendingLogin(t *testing.T, ctrl TwoFactorController) {
+	req, err := http.NewRequest("GET", "/admin/login/two-factor", nil)
+	if err != nil {
+		t.Error("unable to construct request")
+	}
+
+	rr := httptest.NewRecorder()
+
+	handler := http.HandlerFunc(ctrl.LoginTwoFactorGet)
+
+	mockSessionManagerGetString := mockSessionManager.
+		On("GetString", req.Context(), session.PENDING_TWO_FACTOR_USERNAME).
+		Return("")
+
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusSeeOther, rr.Result().StatusCode, "should return status code see other")
+	require.Equal(t, "/admin/login", rr.Result().Header.Get("Location"), "should redirect to login page")
+
+	mockSessionManagerGetString.Unset()
+}
+
+func testPostLoginTwoFactor(t *testing.T, ctrl TwoFactorController) {
+	form := url.Values{}
+	form.Add("code", "123456")
+
+	req := newFormRequest(t, "/admin/login/two-factor", form)
+	req.RemoteAddr = "192.0.2.1:1234"
+
+	rr := httptest.NewRecorder()
+
+	handler := http.HandlerFunc(ctrl.LoginTwoFactorPost)
+
+	mockSessionManagerGetString := mockSessionManager.
+		On("GetString", req.Context(), session.PENDING_TWO_FACTOR_USERNAME).
+		Return("janedoe")
+
+	mockThrottleCheck := mockThrottle.On("Check", "janedoe", "192.0.2.1").Return(nil)
+
+	mockTwoFactorServiceVerify := mockTwoFactorService.
+		On("Verify", "janedoe", "123456").
+		Return(nil)
+
+	mockSessionManagerRenewToken := mockSessionManager.
+		On("RenewToken", req.Context()).
+		Return(nil)
+
+	mockServiceGetByAttribute := mockService.
+		On("GetByAttribute", "username", "janedoe").
+		Return(&UserResponseDto{Id: 23, Username: "janedoe", SessionVersion: 3, TwoFactorEnabled: true}, nil)
+
+	mockThrottleSucceed := mockThrottle.On("Succeed", "janedoe").Return(nil)
+
+	mockSessionManagerRemove := mockSessionManager.
+		On("Remove", req.Context(), session.PENDING_TWO_FACTOR_USERNAME)
+
+	mockSessionManagerPut := mockSessionManager.
+		On("Put", req.Context(), session.LOGGED_IN_USERNAME, "janedoe")
+
+	mockSessionManagerPutVersion := mockSessionManager.
+		On("Put", req.Context(), session.SESSION_VERSION, 3)
+
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusSeeOther, rr.Result().StatusCode, "should return status code see other")
+	require.Equal(t, "/admin/articles", rr.Result().Header.Get("Location"), "should redirect to articles page")
+
+	if res := mockSessionManager.AssertExpectations(t); !res {
+		t.Error("should log in the pending user")
+	}
+
+	if res := mockThrottle.AssertExpectations(t); !res {
+		t.Error("should clear failed logins")
+	}
+
+	mockSessionManagerGetString.Unset()
+	mockThrottleCheck.Unset()
+	mockThrottleSucceed.Unset()
+	mockTwoFactorServiceVerify.Unset()
+	mockSessionManagerRenewToken.Unset()
+	mockServiceGetByAttribute.Unset()
+	mockSessionManagerRemove.Unset()
+	mockSessionManagerPut.Unset()
+	mockSessionManagerPutVersion.Unset()
+}
+
+func testPostLoginTwoFactorInvalidCode(t *testing.T, ctrl TwoFactorController) {
+	form := url.Values{}
+	form.Add("code", "000000")
+
+	req := newFormRequest(t, "/admin/login/two-factor", form)
+	req.RemoteAddr = "192.0.2.1:1234"
+
+	rr := httptest.NewRecorder()
+
+	handler := http.HandlerFunc(ctrl.LoginTwoFactorPost)
+
+	mockSessionManagerGetString := mockSessionManager.
+		On("GetString", req.Context(), session.PENDING_TWO_FACTOR_USERNAME).
+		Return("janedoe")
+
+	mockThrottleCheck := mockThrottle.On("Check", "janedoe", "192.0.2.1").Return(nil)
+
+	mockTwoFactorServiceVerify := mockTwoFactorService.
+		On("Verify", "janedoe", "000000").
+		Return(ErrInvalidTwoFactorCode)
+
+	mockThrottleFail := mockThrottle.On("Fail", "janedoe", "192.0.2.1").Return(nil)
+
+	mockSessionManagerPut := mockSessionManager.
+		On("Put", req.Context(), session.SESSION_KEY_MESSAGE, "Invalid code.")
+
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusSeeOther, rr.Result().StatusCode, "should return status code see other")
+	require.Equal(t, "/admin/login/two-factor", rr.Result().Header.Get("Location"), "should redirect back to two-factor login page")
+
+	if res := mockSessionManager.AssertExpectations(t); !res {
+		t.Error("should put message in session")
+	}
+
+	if res := mockThrottle.AssertExpectations(t); !res {
+		t.Error("should record failed login")
+	}
+
+	mockSessionManagerGetString.Unset()
+	mockThrottleCheck.Unset()
+	mockThrottleFail.Unset()
+	mockTwoFactorServiceVerify.Unset()
+	mockSessionManagerPut.Unset()
+}
+
+func testPostLoginTwoFactorNoPendingLogin(t *testing.T, ctrl TwoFactorController) {
+	form := url.Values{}
+	form.Add("code", "123456")
+
+	req := newFormRequest(t, "/admin/login/two-factor", form)
+
+	rr := httptest.NewRecorder()
+
+	handler := http.HandlerFunc(ctrl.LoginTwoFactorPost)
+
+	mockSessionManagerGetString := mockSessionManager.
+		On("GetString", req.Context(), session.PENDING_TWO_FACTOR_USERNAME).
+		Return("")
+
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusSeeOther, rr.Result().StatusCode, "should return status code see other")
+	require.Equal(t, "/admin/login", rr.Result().Header.Get("Location"), "should redirect to login page")
+
+	mockSessionManagerGetString.Unset()
+}
+
+func testGetTwoFactorEnrol(t *testing.T, ctrl TwoFactorController) {
+	req, err := http.NewRequest("GET", "/admin/account/two-factor", nil)
+	if err != nil {
+		t.Error("unable to construct request")
+	}
+
+	req = withLoggedInUser(req, &UserResponseDto{Id: 23, Username: "janedoe", Role: RoleAuthor})
+
+	rr := httptest.NewRecorder()
+
+	handler := http.HandlerFunc(ctrl.TwoFactorGet)
+
+	twoFactor := &TwoFactor{}
+	key := &TwoFactorKey{Secret: "S3CR3T", QrCode: []byte("png")}
+
+	mockTwoFactorServiceGet := mockTwoFactorService.
+		On("Get", "janedoe").
+		Return(twoFactor, nil)
+
+	mockSessionManagerPopString := mockSessionManager.
+		On("PopString", req.Context(), session.SESSION_KEY_MESSAGE).
+		Return("")
+
+	mockSessionManagerGetString := mockSessionManager.
+		On("GetString", req.Context(), session.TWO_FACTOR_SECRET).
+		Return("")
+
+	mockTwoFactorServiceEnrol := mockTwoFactorService.
+		On("Enrol", "janedoe", "").
+		Return(key, nil)
+
+	mockSessionManagerPut := mockSessionManager.
+		On("Put", req.Context(), session.TWO_FACTOR_SECRET, "S3CR3T")
+
+	mockTemplateExecuteTemplate := mockTemplate.On(
+		"ExecuteTemplate",
+		rr,
+		"admin",
+		TwoFactorView{
+			TwoFactor: twoFactor,
+			Key:       key,
+			QrCode:    template.URL("data:image/png;base64,cG5n"),
+			CsrfToken: "mock-token",
+		},
+	).Return(nil)
+
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Result().StatusCode, "should return status code ok")
+
+	if res := mockSessionManager.AssertExpectations(t); !res {
+		t.Error("should keep secret in session until it's confirmed")
+	}
+
+	if res := mockTemplate.AssertExpectations(t); !res {
+		t.Error("should execute template with QR code")
+	}
+
+	mockTwoFactorServiceGet.Unset()
+	mockSessionManagerPopString.Unset()
+	mockSessionManagerGetString.Unset()
+	mockTwoFactorServiceEnrol.Unset()
+	mockSessionManagerPut.Unset()
+	mockTemplateExecuteTemplate.Unset()
+}
+
+func testGetTwoFactorEnabled(t *testing.T, ctrl TwoFactorController) {
+	req, err := http.NewRequest("GET", "/admin/account/two-factor", nil)
+	if err != nil {
+		t.Error("unable to construct request")
+	}
+
+	req = withLoggedInUser(req, &UserResponseDto{Id: 23, Username: "janedoe", Role: RoleAuthor})
+
+	rr := httptest.NewRecorder()
+
+	handler := http.HandlerFunc(ctrl.TwoFactorGet)
+
+	twoFactor := &TwoFactor{Enabled: true, RecoveryCodesLeft: 7}
+
+	mockTwoFactorServiceGet := mockTwoFactorService.
+		On("Get", "janedoe").
+		Return(twoFactor, nil)
+
+	mockSessionManagerPopString := mockSessionManager.
+		On("PopString", req.Context(), session.SESSION_KEY_MESSAGE).
+		Return("msg")
+
+	mockTemplateExecuteTemplate := mockTemplate.On(
+		"ExecuteTemplate",
+		rr,
+		"admin",
+		TwoFactorView{
+			Message:   "msg",
+			TwoFactor: twoFactor,
+			CsrfToken: "mock-token",
+		},
+	).Return(nil)
+
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Result().StatusCode, "should return status code ok")
+
+	if res := mockTemplate.AssertExpectations(t); !res {
+		t.Error("should execute template without a key")
+	}
+
+	mockTwoFactorServiceGet.Unset()
+	mockSessionManagerPopString.Unset()
+	mockTemplateExecuteTemplate.Unset()
+}
+
+func testPostTwoFactorEnable(t *testing.T, ctrl TwoFactorController) {
+	form := url.Values{}
+	form.Add("code", "123456")
+
+	req := withLoggedInUser(
+		newFormRequest(t, "/admin/account/two-factor", form),
+		&UserResponseDto{Id: 23, Username: "janedoe", Role: RoleAuthor},
+	)
+
+	rr := httptest.NewRecorder()
+
+	handler := http.HandlerFunc(ctrl.TwoFactorEnablePost)
+
+	recoveryCodes := []string{"abcd-efgh-ijkl-mnop"}
+
+	mockSessionManagerGetString := mockSessionManager.
+		On("GetString", req.Context(), session.TWO_FACTOR_SECRET).
+		Return("S3CR3T")
+
+	mockTwoFactorServiceEnable := mockTwoFactorService.
+		On("Enable", "janedoe", "S3CR3T", "123456").
+		Return(recoveryCodes, nil)
+
+	mockSessionManagerRemove := mockSessionManager.
+		On("Remove", req.Context(), session.TWO_FACTOR_SECRET)
+
+	mockTemplateExecuteTemplate := mockTemplate.On(
+		"ExecuteTemplate",
+		rr,
+		"admin",
+		RecoveryCodesView{
+			Message:       "Turned on two-factor authentication.",
+			RecoveryCodes: recoveryCodes,
+			CsrfToken:     "mock-token",
+		},
+	).Return(nil)
+
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Result().StatusCode, "should return status code ok")
+
+	if res := mockSessionManager.AssertExpectations(t); !res {
+		t.Error("should remove secret from session")
+	}
+
+	if res := mockTemplate.AssertExpectations(t); !res {
+		t.Error("should show recovery codes")
+	}
+
+	mockSessionManagerGetString.Unset()
+	mockTwoFactorServiceEnable.Unset()
+	mockSessionManagerRemove.Unset()
+	mockTemplateExecuteTemplate.Unset()
+}
+
+func testPostTwoFactorEnableInvalidCode(t *testing.T, ctrl TwoFactorController) {
+	form := url.Values{}
+	form.Add("code", "000000")
+
+	req := withLoggedInUser(
+		newFormRequest(t, "/admin/account/two-factor", form),
+		&UserResponseDto{Id: 23, Username: "janedoe", Role: RoleAuthor},
+	)
+
+	rr := httptest.NewRecorder()
+
+	handler := http.HandlerFunc(ctrl.TwoFactorEnablePost)
+
+	mockSessionManagerGetString := mockSessionManager.
+		On("GetString", req.Context(), session.TWO_FACTOR_SECRET).
+		Return("S3CR3T")
+
+	mockTwoFactorServiceEnable := mockTwoFactorService.
+		On("Enable", "janedoe", "S3CR3T", "000000").
+		Return([]string(nil), ErrInvalidTwoFactorCode)
+
+	mockSessionManagerPut := mockSessionManager.On(
+		"Put",
+		req.Context(),
+		session.SESSION_KEY_MESSAGE,
+		"Unable to turn on two-factor authentication: invalid code.",
+	)
+
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusSeeOther, rr.Result().StatusCode, "should return status code see other")
+	require.Equal(t, "/admin/account/two-factor", rr.Result().Header.Get("Location"), "should redirect back to two-factor page")
+
+	if res := mockSessionManager.AssertExpectations(t); !res {
+		t.Error("should put message in session")
+	}
+
+	mockSessionManagerGetString.Unset()
+	mockTwoFactorServiceEnable.Unset()
+	mockSessionManagerPut.Unset()
+}
+
+func testPostTwoFactorDisable(t *testing.T, ctrl TwoFactorController) {
+	form := url.Values{}
+	form.Add("code", "123456")
+
+	req := withLoggedInUser(
+		newFormRequest(t, "/admin/account/two-factor/disable", form),
+		&UserResponseDto{Id: 23, Username: "janedoe", Role: RoleAuthor},
+	)
+
+	rr := httptest.NewRecorder()
+
+	handler := http.HandlerFunc(ctrl.TwoFactorDisablePost)
+
+	mockTwoFactorServiceDisable := mockTwoFactorService.
+		On("Disable", "janedoe", "123456").
+		Return(nil)
+
+	mockSessionManagerPut := mockSessionManager.On(
+		"Put",
+		req.Context(),
+		session.SESSION_KEY_MESSAGE,
+		"Turned off two-factor authentication.",
+	)
+
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusSeeOther, rr.Result().StatusCode, "should return status code see other")
+	require.Equal(t, "/admin/account/two-factor", rr.Result().Header.Get("Location"), "should redirect to two-factor page")
+
+	if res := mockTwoFactorService.AssertExpectations(t); !res {
+		t.Error("should disable two-factor authentication")
+	}
+
+	mockTwoFactorServiceDisable.Unset()
+	mockSessionManagerPut.Unset()
+}
+
+func testPostUserTwoFactorReset(t *testing.T, ctrl TwoFactorController) {
+	req := newFormRequest(t, "/admin/users/janedoe/two-factor/reset", url.Values{})
+	req.SetPathValue("slug", "janedoe")
+
+	rr := httptest.NewRecorder()
+
+	handler := http.HandlerFunc(ctrl.UserTwoFactorResetPost)
+
+	mockTwoFactorServiceReset := mockTwoFactorService.
+		On("Reset", "janedoe").
+		Return(nil)
+
+	mockSessionManagerPut := mockSessionManager.On(
+		"Put",
+		req.Context(),
+		session.SESSION_KEY_MESSAGE,
+		"Turned off two-factor authentication for 'janedoe'.",
+	)
+
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusSeeOther, rr.Result().StatusCode, "should return status code see other")
+	require.Equal(t, "/admin/users/janedoe", rr.Result().Header.Get("Location"), "should redirect to user page")
+
+	if res := mockTwoFactorService.AssertExpectations(t); !res {
+		t.Error("should reset two-factor authentication")
+	}
+
+	mockTwoFactorServiceReset.Unset()
+	mockSessionManagerPut.Unset()
+}
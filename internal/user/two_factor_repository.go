@@ -0,0 +1,119 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/nixpig/dunce/db"
+)
+
+// TwoFactorRepository stores each user's TOTP secret along with their
+// recovery codes, which are stored by their hash like password reset tokens.
+type TwoFactorRepository interface {
+	GetByUsername(username string) (*TwoFactor, error)
+	Enable(username, secret string, step int64, recoveryCodeHashes []string) error
+	Disable(username string) error
+	ReplaceRecoveryCodes(username string, recoveryCodeHashes []string) error
+	UseStep(username string, step int64) (bool, error)
+	UseRecoveryCode(username, codeHash string, now time.Time) (bool, error)
+}
+
+type twoFactorPostgresRepository struct {
+	db db.Dbconn
+}
+
+func NewTwoFactorPostgresRepository(db db.Dbconn) twoFactorPostgresRepository {
+	return twoFactorPostgresRepository{
+		db: db,
+	}
+}
+
+func (t twoFactorPostgresRepository) GetByUsername(username string) (*TwoFactor, error) {
+	query := `select totp_secret_, totp_enabled_, totp_last_step_, (select count(*) from user_recovery_codes_ c where c.user_id_ = u.id_ and c.used_at_ is null) from users_ u where u.username_ = $1`
+
+	row := t.db.QueryRow(context.Background(), query, username)
+
+	var twoFactor TwoFactor
+
+	if err := row.Scan(
+		&twoFactor.Secret,
+		&twoFactor.Enabled,
+		&twoFactor.LastStep,
+		&twoFactor.RecoveryCodesLeft,
+	); err != nil {
+		return nil, err
+	}
+
+	return &twoFactor, nil
+}
+
+// Enable turns on two-factor authentication for username with secret, having
+// already accepted a code for step, and replaces their recovery codes.
+func (t twoFactorPostgresRepository) Enable(username, secret string, step int64, recoveryCodeHashes []string) error {
+	query := `with u as (update users_ set totp_secret_ = $2, totp_enabled_ = true, totp_last_step_ = $3 where username_ = $1 returning id_), d as (delete from user_recovery_codes_ where user_id_ in (select id_ from u)) insert into user_recovery_codes_ (user_id_, code_hash_) select u.id_, unnest($4::text[]) from u`
+
+	res, err := t.db.Exec(context.Background(), query, username, secret, step, recoveryCodeHashes)
+	if err != nil {
+		return err
+	}
+
+	if res.RowsAffected() == 0 {
+		return errors.New("no user updated")
+	}
+
+	return nil
+}
+
+// Disable turns off two-factor authentication for username, forgetting their
+// secret and recovery codes.
+func (t twoFactorPostgresRepository) Disable(username string) error {
+	query := `with u as (update users_ set totp_secret_ = '', totp_enabled_ = false, totp_last_step_ = 0 where username_ = $1 returning id_) delete from user_recovery_codes_ where user_id_ in (select id_ from u)`
+
+	_, err := t.db.Exec(context.Background(), query, username)
+
+	return err
+}
+
+// ReplaceRecoveryCodes throws away every recovery code for username, used or
+// not, in favour of new ones.
+func (t twoFactorPostgresRepository) ReplaceRecoveryCodes(username string, recoveryCodeHashes []string) error {
+	query := `with u as (select id_ from users_ where username_ = $1 and totp_enabled_), d as (delete from user_recovery_codes_ where user_id_ in (select id_ from u)) insert into user_recovery_codes_ (user_id_, code_hash_) select u.id_, unnest($2::text[]) from u`
+
+	res, err := t.db.Exec(context.Background(), query, username, recoveryCodeHashes)
+	if err != nil {
+		return err
+	}
+
+	if res.RowsAffected() == 0 {
+		return errors.New("no recovery codes replaced")
+	}
+
+	return nil
+}
+
+// UseStep records that a code for step has been accepted for username,
+// reporting false if one for the same or a later step already has been.
+func (t twoFactorPostgresRepository) UseStep(username string, step int64) (bool, error) {
+	query := `update users_ set totp_last_step_ = $2 where username_ = $1 and totp_last_step_ < $2`
+
+	res, err := t.db.Exec(context.Background(), query, username, step)
+	if err != nil {
+		return false, err
+	}
+
+	return res.RowsAffected() > 0, nil
+}
+
+// UseRecoveryCode uses up the recovery code for username, reporting false if
+// they don't have it or it's already been used.
+func (t twoFactorPostgresRepository) UseRecoveryCode(username, codeHash string, now time.Time) (bool, error) {
+	query := `update user_recovery_codes_ set used_at_ = $3 where code_hash_ = $2 and used_at_ is null and user_id_ = (select id_ from users_ where username_ = $1)`
+
+	res, err := t.db.Exec(context.Background(), query, username, codeHash, now)
+	if err != nil {
+		return false, err
+	}
+
+	return res.RowsAffected() > 0, nil
+}
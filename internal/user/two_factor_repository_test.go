@@ -0,0 +1,250 @@
+package user
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/pashagolub/pgxmock/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTwoFactorRepo(t *testing.T) {
+	scenarios := map[string]func(t *testing.T, mock pgxmock.PgxPoolIface, repo TwoFactorRepository){
+		"get two-factor by username (success)":          testTwoFactorRepoGetByUsername,
+		"get two-factor by username (error - db error)": testTwoFactorRepoGetByUsernameDbError,
+		"enable two-factor (success)":                   testTwoFactorRepoEnable,
+		"enable two-factor (error - no user)":           testTwoFactorRepoEnableNoUser,
+		"disable two-factor (success)":                  testTwoFactorRepoDisable,
+		"replace recovery codes (success)":              testTwoFactorRepoReplaceRecoveryCodes,
+		"replace recovery codes (error - not enabled)":  testTwoFactorRepoReplaceRecoveryCodesNotEnabled,
+		"use step (success)":                            testTwoFactorRepoUseStep,
+		"use step (success - already used)":             testTwoFactorRepoUseStepAlreadyUsed,
+		"use recovery code (success)":                   testTwoFactorRepoUseRecoveryCode,
+		"use recovery code (error - db error)":          testTwoFactorRepoUseRecoveryCodeDbError,
+	}
+
+	for scenario, fn := range scenarios {
+		t.Run(scenario, func(t *testing.T) {
+			db, err := pgxmock.NewPool()
+			if err != nil {
+				t.Fatal("unable to create mock db pool")
+			}
+
+			defer db.Close()
+
+			repo := NewTwoFactorPostgresRepository(db)
+
+			fn(t, db, repo)
+		})
+	}
+}
+
+func testTwoFactorRepoGetByUsername(t *testing.T, mock pgxmock.PgxPoolIface, repo TwoFactorRepository) {
+	query := `select totp_secret_, totp_enabled_, totp_last_step_, (select count(*) from user_recovery_codes_ c where c.user_id_ = u.id_ and c.used_at_ is null) from users_ u where u.username_ = $1`
+
+	mockRows := mock.
+		NewRows([]string{"totp_secret_", "totp_enabled_", "totp_last_step_", "count"}).
+		AddRow("S3CR3T", true, int64(56789), 8)
+
+	mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs("janedoe").WillReturnRows(mockRows)
+
+	twoFactor, err := repo.GetByUsername("janedoe")
+
+	require.NoError(t, err, "should not return error")
+	require.Equal(t, &TwoFactor{
+		Secret:            "S3CR3T",
+		Enabled:           true,
+		LastStep:          56789,
+		RecoveryCodesLeft: 8,
+	}, twoFactor, "should return two-factor authentication")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal("expectations not met: ", err)
+	}
+}
+
+func testTwoFactorRepoGetByUsernameDbError(t *testing.T, mock pgxmock.PgxPoolIface, repo TwoFactorRepository) {
+	query := `select totp_secret_, totp_enabled_, totp_last_step_, (select count(*) from user_recovery_codes_ c where c.user_id_ = u.id_ and c.used_at_ is null) from users_ u where u.username_ = $1`
+
+	mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs("janedoe").WillReturnError(errors.New("db_error"))
+
+	twoFactor, err := repo.GetByUsername("janedoe")
+
+	require.EqualError(t, err, "db_error", "should return db error")
+	require.Nil(t, twoFactor, "should not return two-factor authentication")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal("expectations not met: ", err)
+	}
+}
+
+func testTwoFactorRepoEnable(t *testing.T, mock pgxmock.PgxPoolIface, repo TwoFactorRepository) {
+	query := `with u as (update users_ set totp_secret_ = $2, totp_enabled_ = true, totp_last_step_ = $3 where username_ = $1 returning id_), d as (delete from user_recovery_codes_ where user_id_ in (select id_ from u)) insert into user_recovery_codes_ (user_id_, code_hash_) select u.id_, unnest($4::text[]) from u`
+
+	hashes := []string{"h4sh1", "h4sh2"}
+
+	mock.
+		ExpectExec(regexp.QuoteMeta(query)).
+		WithArgs("janedoe", "S3CR3T", int64(56789), hashes).
+		WillReturnResult(pgxmock.NewResult("INSERT", 2))
+
+	err := repo.Enable("janedoe", "S3CR3T", 56789, hashes)
+
+	require.NoError(t, err, "should not return error")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal("expectations not met: ", err)
+	}
+}
+
+func testTwoFactorRepoEnableNoUser(t *testing.T, mock pgxmock.PgxPoolIface, repo TwoFactorRepository) {
+	query := `with u as (update users_ set totp_secret_ = $2, totp_enabled_ = true, totp_last_step_ = $3 where username_ = $1 returning id_), d as (delete from user_recovery_codes_ where user_id_ in (select id_ from u)) insert into user_recovery_codes_ (user_id_, code_hash_) select u.id_, unnest($4::text[]) from u`
+
+	hashes := []string{"h4sh1", "h4sh2"}
+
+	mock.
+		ExpectExec(regexp.QuoteMeta(query)).
+		WithArgs("nobody", "S3CR3T", int64(56789), hashes).
+		WillReturnResult(pgxmock.NewResult("INSERT", 0))
+
+	err := repo.Enable("nobody", "S3CR3T", 56789, hashes)
+
+	require.Error(t, err, "should return error")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal("expectations not met: ", err)
+	}
+}
+
+func testTwoFactorRepoDisable(t *testing.T, mock pgxmock.PgxPoolIface, repo TwoFactorRepository) {
+	query := `with u as (update users_ set totp_secret_ = '', totp_enabled_ = false, totp_last_step_ = 0 where username_ = $1 returning id_) delete from user_recovery_codes_ where user_id_ in (select id_ from u)`
+
+	mock.
+		ExpectExec(regexp.QuoteMeta(query)).
+		WithArgs("janedoe").
+		WillReturnResult(pgxmock.NewResult("DELETE", 10))
+
+	err := repo.Disable("janedoe")
+
+	require.NoError(t, err, "should not return error")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal("expectations not met: ", err)
+	}
+}
+
+func testTwoFactorRepoReplaceRecoveryCodes(t *testing.T, mock pgxmock.PgxPoolIface, repo TwoFactorRepository) {
+	query := `with u as (select id_ from users_ where username_ = $1 and totp_enabled_), d as (delete from user_recovery_codes_ where user_id_ in (select id_ from u)) insert into user_recovery_codes_ (user_id_, code_hash_) select u.id_, unnest($2::text[]) from u`
+
+	hashes := []string{"h4sh1", "h4sh2"}
+
+	mock.
+		ExpectExec(regexp.QuoteMeta(query)).
+		WithArgs("janedoe", hashes).
+		WillReturnResult(pgxmock.NewResult("INSERT", 2))
+
+	err := repo.ReplaceRecoveryCodes("janedoe", hashes)
+
+	require.NoError(t, err, "should not return error")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal("expectations not met: ", err)
+	}
+}
+
+func testTwoFactorRepoReplaceRecoveryCodesNotEnabled(t *testing.T, mock pgxmock.PgxPoolIface, repo TwoFactorRepository) {
+	query := `with u as (select id_ from users_ where username_ = $1 and totp_enabled_), d as (delete from user_recovery_codes_ where user_id_ in (select id_ from u)) insert into user_recovery_codes_ (user_id_, code_hash_) select u.id_, unnest($2::text[]) from u`
+
+	hashes := []string{"h4sh1", "h4sh2"}
+
+	mock.
+		ExpectExec(regexp.QuoteMeta(query)).
+		WithArgs("janedoe", hashes).
+		WillReturnResult(pgxmock.NewResult("INSERT", 0))
+
+	err := repo.ReplaceRecoveryCodes("janedoe", hashes)
+
+	require.Error(t, err, "should return error")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal("expectations not met: ", err)
+	}
+}
+
+func testTwoFactorRepoUseStep(t *testing.T, mock pgxmock.PgxPoolIface, repo TwoFactorRepository) {
+	query := `update users_ set totp_last_step_ = $2 where username_ = $1 and totp_last_step_ < $2`
+
+	mock.
+		ExpectExec(regexp.QuoteMeta(query)).
+		WithArgs("janedoe", int64(56790)).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+	used, err := repo.UseStep("janedoe", 56790)
+
+	require.NoError(t, err, "should not return error")
+	require.True(t, used, "should report step was used")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal("expectations not met: ", err)
+	}
+}
+
+func testTwoFactorRepoUseStepAlreadyUsed(t *testing.T, mock pgxmock.PgxPoolIface, repo TwoFactorRepository) {
+	query := `update users_ set totp_last_step_ = $2 where username_ = $1 and totp_last_step_ < $2`
+
+	mock.
+		ExpectExec(regexp.QuoteMeta(query)).
+		WithArgs("janedoe", int64(56789)).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+
+	used, err := repo.UseStep("janedoe", 56789)
+
+	require.NoError(t, err, "should not return error")
+	require.False(t, used, "should report step was already used")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal("expectations not met: ", err)
+	}
+}
+
+func testTwoFactorRepoUseRecoveryCode(t *testing.T, mock pgxmock.PgxPoolIface, repo TwoFactorRepository) {
+	query := `update user_recovery_codes_ set used_at_ = $3 where code_hash_ = $2 and used_at_ is null and user_id_ = (select id_ from users_ where username_ = $1)`
+
+	now := time.Now()
+
+	mock.
+		ExpectExec(regexp.QuoteMeta(query)).
+		WithArgs("janedoe", "h4sh", now).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+	used, err := repo.UseRecoveryCode("janedoe", "h4sh", now)
+
+	require.NoError(t, err, "should not return error")
+	require.True(t, used, "should report recovery code was used")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal("expectations not met: ", err)
+	}
+}
+
+func testTwoFactorRepoUseRecoveryCodeDbError(t *testing.T, mock pgxmock.PgxPoolIface, repo TwoFactorRepository) {
+	query := `update user_recovery_codes_ set used_at_ = $3 where code_hash_ = $2 and used_at_ is null and user_id_ = (select id_ from users_ where username_ = $1)`
+
+	now := time.Now()
+
+	mock.
+		ExpectExec(regexp.QuoteMeta(query)).
+		WithArgs("janedoe", "h4sh", now).
+		WillReturnError(errors.New("db_error"))
+
+	used, err := repo.UseRecoveryCode("janedoe", "h4sh", now)
+
+	require.EqualError(t, err, "db_error", "should return db error")
+	require.False(t, used, "should not report recovery code was used")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal("expectations not met: ", err)
+	}
+}
@@ -0,0 +1,249 @@
+package user
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/hex"
+	"image/png"
+	"strings"
+	"time"
+
+	"github.com/nixpig/dunce/internal/site"
+	"github.com/pquerna/otp/totp"
+)
+
+const (
+	totpPeriod        = 30
+	recoveryCodeCount = 10
+	qrCodeSize        = 200
+)
+
+type TwoFactorService interface {
+	Get(username string) (*TwoFactor, error)
+	Enrol(username, secret string) (*TwoFactorKey, error)
+	Enable(username, secret, code string) ([]string, error)
+	Disable(username, code string) error
+	Reset(username string) error
+	RegenerateRecoveryCodes(username, code string) ([]string, error)
+	Verify(username, code string) error
+}
+
+type TwoFactorServiceImpl struct {
+	repo        TwoFactorRepository
+	siteService site.SiteService
+}
+
+func NewTwoFactorService(
+	repo TwoFactorRepository,
+	siteService site.SiteService,
+) TwoFactorServiceImpl {
+	return TwoFactorServiceImpl{
+		repo:        repo,
+		siteService: siteService,
+	}
+}
+
+func (t TwoFactorServiceImpl) Get(username string) (*TwoFactor, error) {
+	return t.repo.GetByUsername(username)
+}
+
+// Enrol returns the key for username to add to their authenticator, for secret
+// or a new secret if it's empty. Nothing is stored until the key is confirmed
+// with Enable.
+func (t TwoFactorServiceImpl) Enrol(username, secret string) (*TwoFactorKey, error) {
+	s, err := t.siteService.Get()
+	if err != nil {
+		return nil, err
+	}
+
+	opts := totp.GenerateOpts{
+		Issuer:      s.Name,
+		AccountName: username,
+	}
+
+	if secret != "" {
+		opts.Secret, err = base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	key, err := totp.Generate(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	image, err := key.Image(qrCodeSize, qrCodeSize)
+	if err != nil {
+		return nil, err
+	}
+
+	var qrCode bytes.Buffer
+
+	if err := png.Encode(&qrCode, image); err != nil {
+		return nil, err
+	}
+
+	return &TwoFactorKey{
+		Secret: key.Secret(),
+		QrCode: qrCode.Bytes(),
+	}, nil
+}
+
+// Enable turns on two-factor authentication for username once code shows
+// their authenticator has secret, returning their recovery codes. The codes
+// are only stored hashed, so can't be shown again.
+func (t TwoFactorServiceImpl) Enable(username, secret, code string) ([]string, error) {
+	step, ok := matchTotp(secret, normaliseCode(code), time.Now())
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	recoveryCodes, recoveryCodeHashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := t.repo.Enable(username, secret, step, recoveryCodeHashes); err != nil {
+		return nil, err
+	}
+
+	return recoveryCodes, nil
+}
+
+// Disable turns off two-factor authentication for username, once they've
+// given a code to show it's really them.
+func (t TwoFactorServiceImpl) Disable(username, code string) error {
+	if err := t.Verify(username, code); err != nil {
+		return err
+	}
+
+	return t.repo.Disable(username)
+}
+
+// Reset turns off two-factor authentication for username without a code, for
+// admins to let back in users who've lost their authenticator and recovery
+// codes.
+func (t TwoFactorServiceImpl) Reset(username string) error {
+	return t.repo.Disable(username)
+}
+
+// RegenerateRecoveryCodes replaces every recovery code for username with new
+// ones, once they've given a code to show it's really them.
+func (t TwoFactorServiceImpl) RegenerateRecoveryCodes(username, code string) ([]string, error) {
+	if err := t.Verify(username, code); err != nil {
+		return nil, err
+	}
+
+	recoveryCodes, recoveryCodeHashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := t.repo.ReplaceRecoveryCodes(username, recoveryCodeHashes); err != nil {
+		return nil, err
+	}
+
+	return recoveryCodes, nil
+}
+
+// Verify checks code is either the current TOTP code from the authenticator
+// of username or one of their unused recovery codes, which is then used up.
+// Each TOTP code is only accepted once.
+func (t TwoFactorServiceImpl) Verify(username, code string) error {
+	twoFactor, err := t.repo.GetByUsername(username)
+	if err != nil {
+		return err
+	}
+
+	if !twoFactor.Enabled {
+		return ErrInvalidTwoFactorCode
+	}
+
+	code = normaliseCode(code)
+
+	var ok bool
+
+	if len(code) == 6 {
+		step, matched := matchTotp(twoFactor.Secret, code, time.Now())
+		if !matched || step <= twoFactor.LastStep {
+			return ErrInvalidTwoFactorCode
+		}
+
+		ok, err = t.repo.UseStep(username, step)
+	} else {
+		ok, err = t.repo.UseRecoveryCode(username, hashRecoveryCode(code), time.Now())
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return ErrInvalidTwoFactorCode
+	}
+
+	return nil
+}
+
+// matchTotp returns the time step code is for, allowing one step either side
+// of now for clock drift.
+func matchTotp(secret, code string, now time.Time) (int64, bool) {
+	current := now.Unix() / totpPeriod
+
+	for _, step := range []int64{current, current - 1, current + 1} {
+		expected, err := totp.GenerateCode(secret, time.Unix(step*totpPeriod, 0))
+		if err != nil {
+			return 0, false
+		}
+
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+
+	return 0, false
+}
+
+// newRecoveryCodes returns recovery codes formatted to be written down, along
+// with the hashes to store.
+func newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+
+	for i := range codes {
+		b := make([]byte, 10)
+
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+
+		code := strings.ToLower(base32.StdEncoding.EncodeToString(b))
+
+		codes[i] = code[0:4] + "-" + code[4:8] + "-" + code[8:12] + "-" + code[12:16]
+		hashes[i] = hashRecoveryCode(code)
+	}
+
+	return codes, hashes, nil
+}
+
+func hashRecoveryCode(code string) string {
+	hash := sha256.Sum256([]byte(code))
+
+	return hex.EncodeToString(hash[:])
+}
+
+// normaliseCode strips the spaces and dashes people type or paste along with
+// codes, and lowercases recovery codes.
+func normaliseCode(code string) string {
+	return strings.Map(func(r rune) rune {
+		if r == ' ' || r == '-' {
+			return -1
+		}
+
+		return r
+	}, strings.ToLower(strings.TrimSpace(code)))
+}
@@ -0,0 +1,337 @@
+package user
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/nixpig/dunce/internal/site"
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var mockTwoFactorRepo = new(MockTwoFactorRepo)
+
+func TestTwoFactorService(t *testing.T) {
+	scenarios := map[string]func(t *testing.T, service TwoFactorService){
+		"enrol (success - new secret)":                  testTwoFactorServiceEnrolNewSecret,
+		"enrol (success - existing secret)":             testTwoFactorServiceEnrolExistingSecret,
+		"enable (success)":                              testTwoFactorServiceEnable,
+		"enable (error - invalid code)":                 testTwoFactorServiceEnableInvalidCode,
+		"verify totp code (success)":                    testTwoFactorServiceVerifyTotp,
+		"verify totp code (error - replayed)":           testTwoFactorServiceVerifyTotpReplayed,
+		"verify recovery code (success)":                testTwoFactorServiceVerifyRecoveryCode,
+		"verify recovery code (error - used)":           testTwoFactorServiceVerifyRecoveryCodeUsed,
+		"verify (error - not enabled)":                  testTwoFactorServiceVerifyNotEnabled,
+		"disable (success)":                             testTwoFactorServiceDisable,
+		"disable (error - invalid code)":                testTwoFactorServiceDisableInvalidCode,
+		"regenerate recovery codes (success)":           testTwoFactorServiceRegenerateRecoveryCodes,
+		"regenerate recovery codes (error - not valid)": testTwoFactorServiceRegenerateRecoveryCodesInvalidCode,
+	}
+
+	for scenario, fn := range scenarios {
+		t.Run(scenario, func(t *testing.T) {
+			service := NewTwoFactorService(mockTwoFactorRepo, mockSiteService)
+
+			fn(t, service)
+		})
+	}
+}
+
+type MockTwoFactorRepo struct {
+	mock.Mock
+}
+
+func (m *MockTwoFactorRepo) GetByUsername(username string) (*TwoFactor, error) {
+	args := m.Called(username)
+
+	return args.Get(0).(*TwoFactor), args.Error(1)
+}
+
+func (m *MockTwoFactorRepo) Enable(username, secret string, step int64, recoveryCodeHashes []string) error {
+	args := m.Called(username, secret, step, recoveryCodeHashes)
+
+	return args.Error(0)
+}
+
+func (m *MockTwoFactorRepo) Disable(username string) error {
+	args := m.Called(username)
+
+	return args.Error(0)
+}
+
+func (m *MockTwoFactorRepo) ReplaceRecoveryCodes(username string, recoveryCodeHashes []string) error {
+	args := m.Called(username, recoveryCodeHashes)
+
+	return args.Error(0)
+}
+
+func (m *MockTwoFactorRepo) UseStep(username string, step int64) (bool, error) {
+	args := m.Called(username, step)
+
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockTwoFactorRepo) UseRecoveryCode(username, codeHash string, now time.Time) (bool, error) {
+	args := m.Called(username, codeHash, now)
+
+	return args.Bool(0), args.Error(1)
+}
+
+func newTestSecret(t *testing.T) string {
+	key, err := totp.Generate(totp.GenerateOpts{Issuer: "Dunce", AccountName: "janedoe"})
+	if err != nil {
+		t.Fatal("unable to generate secret")
+	}
+
+	return key.Secret()
+}
+
+func newTestCode(t *testing.T, secret string) string {
+	code, err := totp.GenerateCode(secret, time.Now())
+	if err != nil {
+		t.Fatal("unable to generate code")
+	}
+
+	return code
+}
+
+func testTwoFactorServiceEnrolNewSecret(t *testing.T, service TwoFactorService) {
+	mockSiteServiceGet := mockSiteService.
+		On("Get").
+		Return(&site.Site{Name: "Dunce"}, nil)
+
+	key, err := service.Enrol("janedoe", "")
+
+	require.NoError(t, err, "should not return error")
+	require.NotEmpty(t, key.Secret, "should generate a secret")
+	require.True(t, bytes.HasPrefix(key.QrCode, []byte("\x89PNG")), "should return QR code as a PNG")
+
+	mockSiteServiceGet.Unset()
+}
+
+func testTwoFactorServiceEnrolExistingSecret(t *testing.T, service TwoFactorService) {
+	mockSiteServiceGet := mockSiteService.
+		On("Get").
+		Return(&site.Site{Name: "Dunce"}, nil)
+
+	secret := newTestSecret(t)
+
+	key, err := service.Enrol("janedoe", secret)
+
+	require.NoError(t, err, "should not return error")
+	require.Equal(t, secret, key.Secret, "should keep the existing secret")
+
+	mockSiteServiceGet.Unset()
+}
+
+func testTwoFactorServiceEnable(t *testing.T, service TwoFactorService) {
+	secret := newTestSecret(t)
+
+	var step int64
+	var hashes []string
+
+	mockTwoFactorRepoEnable := mockTwoFactorRepo.
+		On("Enable", "janedoe", secret, mock.Anything, mock.Anything).
+		Return(nil).
+		Run(func(args mock.Arguments) {
+			step = args.Get(2).(int64)
+			hashes = args.Get(3).([]string)
+		})
+
+	recoveryCodes, err := service.Enable("janedoe", secret, newTestCode(t, secret))
+
+	require.NoError(t, err, "should not return error")
+	require.InDelta(t, time.Now().Unix()/totpPeriod, step, 1, "should store the step the code was for")
+	require.Len(t, recoveryCodes, recoveryCodeCount, "should return recovery codes")
+	require.Len(t, hashes, recoveryCodeCount, "should store a hash for every recovery code")
+
+	for i, code := range recoveryCodes {
+		require.Equal(t, hashRecoveryCode(normaliseCode(code)), hashes[i], "should only store hash of recovery code")
+	}
+
+	mockTwoFactorRepoEnable.Unset()
+}
+
+func testTwoFactorServiceEnableInvalidCode(t *testing.T, service TwoFactorService) {
+	recoveryCodes, err := service.Enable("janedoe", newTestSecret(t), "000000x")
+
+	require.Equal(t, ErrInvalidTwoFactorCode, err, "should return invalid code error")
+	require.Nil(t, recoveryCodes, "should not return recovery codes")
+
+}
+
+func testTwoFactorServiceVerifyTotp(t *testing.T, service TwoFactorService) {
+	secret := newTestSecret(t)
+
+	mockTwoFactorRepoGetByUsername := mockTwoFactorRepo.
+		On("GetByUsername", "janedoe").
+		Return(&TwoFactor{Secret: secret, Enabled: true}, nil)
+
+	mockTwoFactorRepoUseStep := mockTwoFactorRepo.
+		On("UseStep", "janedoe", mock.Anything).
+		Return(true, nil)
+
+	err := service.Verify("janedoe", newTestCode(t, secret))
+
+	require.NoError(t, err, "should accept current code")
+
+	if res := mockTwoFactorRepo.AssertExpectations(t); !res {
+		t.Error("should record the step as used")
+	}
+
+	mockTwoFactorRepoGetByUsername.Unset()
+	mockTwoFactorRepoUseStep.Unset()
+}
+
+func testTwoFactorServiceVerifyTotpReplayed(t *testing.T, service TwoFactorService) {
+	secret := newTestSecret(t)
+
+	mockTwoFactorRepoGetByUsername := mockTwoFactorRepo.
+		On("GetByUsername", "janedoe").
+		Return(&TwoFactor{Secret: secret, Enabled: true, LastStep: time.Now().Unix()/totpPeriod + 1}, nil)
+
+	err := service.Verify("janedoe", newTestCode(t, secret))
+
+	require.Equal(t, ErrInvalidTwoFactorCode, err, "should not accept code for a step already used")
+
+	mockTwoFactorRepoGetByUsername.Unset()
+}
+
+func testTwoFactorServiceVerifyRecoveryCode(t *testing.T, service TwoFactorService) {
+	mockTwoFactorRepoGetByUsername := mockTwoFactorRepo.
+		On("GetByUsername", "janedoe").
+		Return(&TwoFactor{Secret: newTestSecret(t), Enabled: true}, nil)
+
+	mockTwoFactorRepoUseRecoveryCode := mockTwoFactorRepo.
+		On("UseRecoveryCode", "janedoe", hashRecoveryCode("abcdefghijklmnop"), mock.Anything).
+		Return(true, nil)
+
+	err := service.Verify("janedoe", " ABCD-efgh-ijkl-mnop ")
+
+	require.NoError(t, err, "should accept recovery code however it's typed")
+
+	if res := mockTwoFactorRepo.AssertExpectations(t); !res {
+		t.Error("should use up the recovery code")
+	}
+
+	mockTwoFactorRepoGetByUsername.Unset()
+	mockTwoFactorRepoUseRecoveryCode.Unset()
+}
+
+func testTwoFactorServiceVerifyRecoveryCodeUsed(t *testing.T, service TwoFactorService) {
+	mockTwoFactorRepoGetByUsername := mockTwoFactorRepo.
+		On("GetByUsername", "janedoe").
+		Return(&TwoFactor{Secret: newTestSecret(t), Enabled: true}, nil)
+
+	mockTwoFactorRepoUseRecoveryCode := mockTwoFactorRepo.
+		On("UseRecoveryCode", "janedoe", hashRecoveryCode("abcdefghijklmnop"), mock.Anything).
+		Return(false, nil)
+
+	err := service.Verify("janedoe", "abcd-efgh-ijkl-mnop")
+
+	require.Equal(t, ErrInvalidTwoFactorCode, err, "should not accept used recovery code")
+
+	mockTwoFactorRepoGetByUsername.Unset()
+	mockTwoFactorRepoUseRecoveryCode.Unset()
+}
+
+func testTwoFactorServiceVerifyNotEnabled(t *testing.T, service TwoFactorService) {
+	mockTwoFactorRepoGetByUsername := mockTwoFactorRepo.
+		On("GetByUsername", "janedoe").
+		Return(&TwoFactor{}, nil)
+
+	err := service.Verify("janedoe", "123456")
+
+	require.Equal(t, ErrInvalidTwoFactorCode, err, "should not accept any code")
+
+	mockTwoFactorRepoGetByUsername.Unset()
+}
+
+func testTwoFactorServiceDisable(t *testing.T, service TwoFactorService) {
+	mockTwoFactorRepoGetByUsername := mockTwoFactorRepo.
+		On("GetByUsername", "janedoe").
+		Return(&TwoFactor{Secret: newTestSecret(t), Enabled: true}, nil)
+
+	mockTwoFactorRepoUseRecoveryCode := mockTwoFactorRepo.
+		On("UseRecoveryCode", "janedoe", hashRecoveryCode("abcdefghijklmnop"), mock.Anything).
+		Return(true, nil)
+
+	mockTwoFactorRepoDisable := mockTwoFactorRepo.
+		On("Disable", "janedoe").
+		Return(nil)
+
+	err := service.Disable("janedoe", "abcd-efgh-ijkl-mnop")
+
+	require.NoError(t, err, "should not return error")
+
+	if res := mockTwoFactorRepo.AssertExpectations(t); !res {
+		t.Error("should disable two-factor authentication")
+	}
+
+	mockTwoFactorRepoGetByUsername.Unset()
+	mockTwoFactorRepoUseRecoveryCode.Unset()
+	mockTwoFactorRepoDisable.Unset()
+}
+
+func testTwoFactorServiceDisableInvalidCode(t *testing.T, service TwoFactorService) {
+	mockTwoFactorRepoGetByUsername := mockTwoFactorRepo.
+		On("GetByUsername", "janedoe").
+		Return(&TwoFactor{Secret: newTestSecret(t), Enabled: true}, nil)
+
+	mockTwoFactorRepoUseRecoveryCode := mockTwoFactorRepo.
+		On("UseRecoveryCode", "janedoe", mock.Anything, mock.Anything).
+		Return(false, nil)
+
+	err := service.Disable("janedoe", "not-a-code")
+
+	require.Equal(t, ErrInvalidTwoFactorCode, err, "should return invalid code error")
+
+	mockTwoFactorRepoGetByUsername.Unset()
+	mockTwoFactorRepoUseRecoveryCode.Unset()
+}
+
+func testTwoFactorServiceRegenerateRecoveryCodes(t *testing.T, service TwoFactorService) {
+	secret := newTestSecret(t)
+
+	var hashes []string
+
+	mockTwoFactorRepoGetByUsername := mockTwoFactorRepo.
+		On("GetByUsername", "janedoe").
+		Return(&TwoFactor{Secret: secret, Enabled: true}, nil)
+
+	mockTwoFactorRepoUseStep := mockTwoFactorRepo.
+		On("UseStep", "janedoe", mock.Anything).
+		Return(true, nil)
+
+	mockTwoFactorRepoReplaceRecoveryCodes := mockTwoFactorRepo.
+		On("ReplaceRecoveryCodes", "janedoe", mock.Anything).
+		Return(nil).
+		Run(func(args mock.Arguments) {
+			hashes = args.Get(1).([]string)
+		})
+
+	recoveryCodes, err := service.RegenerateRecoveryCodes("janedoe", newTestCode(t, secret))
+
+	require.NoError(t, err, "should not return error")
+	require.Len(t, recoveryCodes, recoveryCodeCount, "should return new recovery codes")
+	require.Equal(t, hashRecoveryCode(normaliseCode(recoveryCodes[0])), hashes[0], "should only store hash of recovery code")
+
+	mockTwoFactorRepoGetByUsername.Unset()
+	mockTwoFactorRepoUseStep.Unset()
+	mockTwoFactorRepoReplaceRecoveryCodes.Unset()
+}
+
+func testTwoFactorServiceRegenerateRecoveryCodesInvalidCode(t *testing.T, service TwoFactorService) {
+	mockTwoFactorRepoGetByUsername := mockTwoFactorRepo.
+		On("GetByUsername", "janedoe").
+		Return(&TwoFactor{Secret: newTestSecret(t), Enabled: true}, nil)
+
+	recoveryCodes, err := service.RegenerateRecoveryCodes("janedoe", "000000")
+
+	require.Equal(t, ErrInvalidTwoFactorCode, err, "should return invalid code error")
+	require.Nil(t, recoveryCodes, "should not return recovery codes")
+
+	mockTwoFactorRepoGetByUsername.Unset()
+}
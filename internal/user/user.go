@@ -1,13 +1,105 @@
 package user
 
-import "time"
+import (
+	"errors"
+	"slices"
+	"time"
+)
+
+var (
+	ErrIncorrectPassword    = errors.New("incorrect password")
+	ErrInvalidResetToken    = errors.New("invalid or expired password reset token")
+	ErrInvalidTwoFactorCode = errors.New("invalid two-factor code")
+	ErrInvalidApiToken      = errors.New("invalid or expired api token")
+)
+
+// Role decides what a user can do in the admin.
+type Role string
+
+const (
+	RoleAdmin  Role = "admin"
+	RoleEditor Role = "editor"
+	RoleAuthor Role = "author"
+	RoleViewer Role = "viewer"
+)
+
+type RoleOption struct {
+	Role        Role
+	Label       string
+	Description string
+}
+
+// Roles lists every role, from most to least access, in the order offered to
+// admins when creating a user.
+var Roles = []RoleOption{
+	{Role: RoleAdmin, Label: "Admin", Description: "Everything, including users and site settings."},
+	{Role: RoleEditor, Label: "Editor", Description: "All articles, tags, pages and the menu."},
+	{Role: RoleAuthor, Label: "Author", Description: "Their own articles."},
+	{Role: RoleViewer, Label: "Viewer", Description: "Read-only access to the admin."},
+}
+
+// Permission is something a route or action needs the logged in user's role
+// to allow.
+type Permission string
+
+const (
+	PermissionViewAdmin       Permission = "view_admin"
+	PermissionWriteArticles   Permission = "write_articles"
+	PermissionEditAllArticles Permission = "edit_all_articles"
+	PermissionManageContent   Permission = "manage_content"
+	PermissionManageUsers     Permission = "manage_users"
+	PermissionManageSite      Permission = "manage_site"
+)
+
+var rolePermissions = map[Role][]Permission{
+	RoleAdmin: {
+		PermissionViewAdmin,
+		PermissionWriteArticles,
+		PermissionEditAllArticles,
+		PermissionManageContent,
+		PermissionManageUsers,
+		PermissionManageSite,
+	},
+	RoleEditor: {
+		PermissionViewAdmin,
+		PermissionWriteArticles,
+		PermissionEditAllArticles,
+		PermissionManageContent,
+	},
+	RoleAuthor: {
+		PermissionViewAdmin,
+		PermissionWriteArticles,
+	},
+	RoleViewer: {
+		PermissionViewAdmin,
+	},
+}
+
+// Can reports whether the role allows permission. Unknown roles allow nothing.
+func (r Role) Can(permission Permission) bool {
+	return slices.Contains(rolePermissions[r], permission)
+}
+
+// Profile is how a user is credited on the public site, e.g. on their
+// articles and author page.
+type Profile struct {
+	DisplayName string `validate:"max=100"`
+	Bio         string `validate:"max=2000"`
+	AvatarUrl   string `validate:"omitempty,http_url,max=255"`
+}
 
 type User struct {
 	Id        uint   `validate:"omitempty"`
 	Username  string `validate:"required"`
-	Email     string `validate:"required"`
+	Email     string `validate:"required,email"`
 	Password  string `validate:"required"`
+	Role      Role   `validate:"required,oneof=admin editor author viewer"`
+	Profile   Profile
 	CreatedAt time.Time
+	// SessionVersion is bumped whenever the password changes, so sessions
+	// logged in with an older version are no longer valid.
+	SessionVersion   int
+	TwoFactorEnabled bool
 }
 
 type UserLoginRequestDto struct {
@@ -19,10 +111,162 @@ type UserNewRequestDto struct {
 	Username string `validate:"required"`
 	Email    string `validate:"required"`
 	Password string `validate:"required"`
+	Role     Role   `validate:"required,oneof=admin editor author viewer"`
+}
+
+type UserPasswordChangeRequestDto struct {
+	CurrentPassword string `validate:"required"`
+	NewPassword     string `validate:"required,min=8,max=72"`
+	ConfirmPassword string `validate:"eqfield=NewPassword"`
+}
+
+type UserPasswordResetRequestDto struct {
+	NewPassword     string `validate:"required,min=8,max=72"`
+	ConfirmPassword string `validate:"eqfield=NewPassword"`
 }
 
 type UserResponseDto struct {
-	Id       uint   `validate:"required"`
-	Username string `validate:"required"`
-	Email    string `validate:"required"`
+	Id               uint   `validate:"required"`
+	Username         string `validate:"required"`
+	Email            string `validate:"required"`
+	Role             Role   `validate:"required"`
+	Profile          Profile
+	SessionVersion   int
+	TwoFactorEnabled bool
+}
+
+// TwoFactor is a user's two-factor authentication: the TOTP secret shared with
+// their authenticator, the last time step a code was accepted for, so codes
+// can't be replayed, and how many of their recovery codes are unused.
+type TwoFactor struct {
+	Secret            string
+	Enabled           bool
+	LastStep          int64
+	RecoveryCodesLeft int
+}
+
+// LoginFailures is how many logins have failed recently for a username or
+// from a client, and when they're blocked until because of it.
+type LoginFailures struct {
+	Failures    int
+	LockedUntil time.Time
+}
+
+// Locked reports whether logins are blocked at now.
+func (l LoginFailures) Locked(now time.Time) bool {
+	return l.LockedUntil.After(now)
+}
+
+// TwoFactorKey is what's needed to add a TOTP secret to an authenticator app,
+// either by typing in the secret or scanning the QR code, which is a PNG.
+type TwoFactorKey struct {
+	Secret string
+	QrCode []byte
+}
+
+// ApiScope limits what an API token can be used for. Tokens can never do more
+// than their user's role allows, whatever their scopes.
+type ApiScope string
+
+const (
+	ApiScopeRead          ApiScope = "read"
+	ApiScopeWriteArticles ApiScope = "write_articles"
+	ApiScopeManageTags    ApiScope = "manage_tags"
+	ApiScopeAdmin         ApiScope = "admin"
+)
+
+type ApiScopeOption struct {
+	Scope       ApiScope
+	Label       string
+	Description string
+}
+
+// ApiScopes lists every scope, in the order offered when creating a token.
+var ApiScopes = []ApiScopeOption{
+	{Scope: ApiScopeRead, Label: "Read", Description: "Read content in the admin."},
+	{Scope: ApiScopeWriteArticles, Label: "Write articles", Description: "Create, edit and delete articles."},
+	{Scope: ApiScopeManageTags, Label: "Manage tags", Description: "Create, edit and delete tags, pages and the menu."},
+	{Scope: ApiScopeAdmin, Label: "Admin", Description: "Everything, including users and site settings."},
+}
+
+var scopePermissions = map[ApiScope][]Permission{
+	ApiScopeRead: {
+		PermissionViewAdmin,
+	},
+	ApiScopeWriteArticles: {
+		PermissionViewAdmin,
+		PermissionWriteArticles,
+		PermissionEditAllArticles,
+	},
+	ApiScopeManageTags: {
+		PermissionViewAdmin,
+		PermissionManageContent,
+	},
+	ApiScopeAdmin: {
+		PermissionViewAdmin,
+		PermissionWriteArticles,
+		PermissionEditAllArticles,
+		PermissionManageContent,
+		PermissionManageUsers,
+		PermissionManageSite,
+	},
+}
+
+// ApiToken is a token for a user to call the API with. Only its hash is
+// stored, so the token itself is only seen when it's created.
+type ApiToken struct {
+	Id         uint
+	Username   string
+	Name       string
+	Scopes     []ApiScope
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	CreatedAt  time.Time
+}
+
+// Allows reports whether any of the token's scopes grant permission.
+func (a ApiToken) Allows(permission Permission) bool {
+	for _, scope := range a.Scopes {
+		if slices.Contains(scopePermissions[scope], permission) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HasScope reports whether the token was given scope, for showing tokens'
+// scopes.
+func (a ApiToken) HasScope(scope ApiScope) bool {
+	return slices.Contains(a.Scopes, scope)
+}
+
+type ApiTokenNewRequestDto struct {
+	Name   string     `validate:"required,max=100"`
+	Scopes []ApiScope `validate:"required,min=1,dive,oneof=read write_articles manage_tags admin"`
+	// ExpiresInDays is how long the token lasts, or 0 for it to never expire.
+	ExpiresInDays int `validate:"oneof=0 7 30 90 365"`
+}
+
+// Name is what the user is credited as on the public site, which falls back
+// to their username when they haven't set a display name.
+func (u UserResponseDto) Name() string {
+	if u.Profile.DisplayName != "" {
+		return u.Profile.DisplayName
+	}
+
+	return u.Username
+}
+
+// CanEdit reports whether the user can change content written by the user
+// with authorId. Authors can only change their own content, which rules out
+// content without an author.
+func (u UserResponseDto) CanEdit(authorId *int) bool {
+	if u.Role.Can(PermissionEditAllArticles) {
+		return true
+	}
+
+	return u.Role.Can(PermissionWriteArticles) &&
+		authorId != nil &&
+		*authorId == int(u.Id)
 }
@@ -2,17 +2,24 @@ package user
 
 import (
 	"fmt"
+	"math"
+	"net"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/go-playground/validator/v10"
 	"github.com/nixpig/dunce/internal/app/errors"
 	"github.com/nixpig/dunce/pkg/logging"
+	"github.com/nixpig/dunce/pkg/pagination"
 	"github.com/nixpig/dunce/pkg/session"
 	"github.com/nixpig/dunce/pkg/templates"
 )
 
 type UserController struct {
 	service        UserService
+	throttle       LoginThrottleService
 	log            logging.Logger
 	templateCache  templates.TemplateCache
 	sessionManager session.SessionManager
@@ -31,13 +38,15 @@ type UserControllerConfig struct {
 type UserView struct {
 	Message         string
 	User            *UserResponseDto
+	Roles           []RoleOption
+	LoginFailures   *LoginFailures
 	CsrfToken       string
 	IsAuthenticated bool
 }
 
 type UsersView struct {
 	Message         string
-	Users           *[]UserResponseDto
+	Users           *pagination.Page[UserResponseDto]
 	CsrfToken       string
 	IsAuthenticated bool
 }
@@ -48,17 +57,27 @@ type UserLoginView struct {
 	IsAuthenticated bool
 }
 
+type AccountView struct {
+	Message         string
+	User            *UserResponseDto
+	CsrfToken       string
+	IsAuthenticated bool
+}
+
 type UserCreateView struct {
+	Roles           []RoleOption
 	CsrfToken       string
 	IsAuthenticated bool
 }
 
 func NewUserController(
 	service UserService,
+	throttle LoginThrottleService,
 	config UserControllerConfig,
 ) UserController {
 	return UserController{
 		service:        service,
+		throttle:       throttle,
 		log:            config.Log,
 		templateCache:  config.TemplateCache,
 		sessionManager: config.SessionManager,
@@ -78,31 +97,67 @@ func (u *UserController) UserLoginGet(w http.ResponseWriter, r *http.Request) {
 		CsrfToken:       u.csrfToken(r),
 		IsAuthenticated: u.IsAuthenticated(r),
 	}); err != nil {
-		u.errorHandlers.InternalServerError(w, r)
+		u.errorHandlers.InternalServerError(w, r, err)
 	}
 }
 
 func (u *UserController) UserLoginPost(w http.ResponseWriter, r *http.Request) {
 	username := r.FormValue("username")
 	password := r.FormValue("password")
+	ip := clientIp(r)
+
+	if err := u.throttle.Check(username, ip); err != nil {
+		if locked, ok := err.(*LoginLockedError); ok {
+			u.sessionManager.Put(r.Context(), session.SESSION_KEY_MESSAGE, lockedMessage(locked))
+			http.Redirect(w, r, "/admin/login", http.StatusSeeOther)
+			return
+		}
+
+		u.errorHandlers.InternalServerError(w, r, fmt.Errorf("unable to check failed logins: %w", err))
+		return
+	}
 
 	if err := u.service.LoginWithUsernamePassword(
 		username,
 		password,
 	); err != nil {
-		u.log.Error(err.Error())
+		u.log.Warn(r.Context(), "login failed", "username", username, "err", err)
+
+		if err := u.throttle.Fail(r.Context(), username, ip); err != nil {
+			u.log.Error(r.Context(), "unable to record failed login", "err", err)
+		}
+
 		u.sessionManager.Put(r.Context(), session.SESSION_KEY_MESSAGE, "Login failed.")
 		http.Redirect(w, r, "/admin/login", http.StatusSeeOther)
 		return
 	}
 
 	if err := u.sessionManager.RenewToken(r.Context()); err != nil {
-		u.log.Error(err.Error())
+		u.log.Error(r.Context(), "unable to renew session token", "err", err)
 		http.Redirect(w, r, "/admin/login", http.StatusSeeOther)
 		return
 	}
 
+	loggedInUser, err := u.service.GetByAttribute("username", username)
+	if err != nil {
+		u.errorHandlers.InternalServerError(w, r, err)
+		return
+	}
+
+	// users with two-factor authentication aren't logged in until they've
+	// also given a code
+	if loggedInUser.TwoFactorEnabled {
+		u.sessionManager.Put(r.Context(), session.PENDING_TWO_FACTOR_USERNAME, username)
+		http.Redirect(w, r, "/admin/login/two-factor", http.StatusSeeOther)
+		return
+	}
+
+	if err := u.throttle.Succeed(username); err != nil {
+		u.log.Error(r.Context(), "unable to clear failed logins", "err", err)
+	}
+
 	u.sessionManager.Put(r.Context(), session.LOGGED_IN_USERNAME, username)
+	u.sessionManager.Put(r.Context(), session.SESSION_VERSION, loggedInUser.SessionVersion)
 
 	http.Redirect(w, r, "/admin/articles", http.StatusSeeOther)
 }
@@ -112,6 +167,8 @@ func (u *UserController) UserLogoutPost(
 	r *http.Request,
 ) {
 	u.sessionManager.Remove(r.Context(), session.LOGGED_IN_USERNAME)
+	u.sessionManager.Remove(r.Context(), session.SESSION_VERSION)
+	u.sessionManager.Remove(r.Context(), session.PENDING_TWO_FACTOR_USERNAME)
 	u.sessionManager.Put(
 		r.Context(),
 		session.SESSION_KEY_MESSAGE,
@@ -123,10 +180,11 @@ func (u *UserController) UserLogoutPost(
 
 func (u *UserController) CreateUserGet(w http.ResponseWriter, r *http.Request) {
 	if err := u.templateCache["pages/admin/new-user.tmpl"].ExecuteTemplate(w, "admin", UserCreateView{
+		Roles:           Roles,
 		CsrfToken:       u.csrfToken(r),
 		IsAuthenticated: u.IsAuthenticated(r),
 	}); err != nil {
-		u.errorHandlers.InternalServerError(w, r)
+		u.errorHandlers.InternalServerError(w, r, err)
 		return
 	}
 }
@@ -139,11 +197,12 @@ func (u *UserController) CreateUserPost(
 		Username: r.FormValue("username"),
 		Password: r.FormValue("password"),
 		Email:    r.FormValue("email"),
+		Role:     Role(r.FormValue("role")),
 	}
 
 	createdUser, err := u.service.Create(&user)
 	if err != nil {
-		u.errorHandlers.InternalServerError(w, r)
+		u.errorHandlers.InternalServerError(w, r, err)
 		return
 	}
 
@@ -157,9 +216,9 @@ func (u *UserController) CreateUserPost(
 }
 
 func (u *UserController) UsersGet(w http.ResponseWriter, r *http.Request) {
-	users, err := u.service.GetAll()
+	users, err := u.service.GetAll(pagination.FromQuery(r.URL.Query()))
 	if err != nil {
-		u.errorHandlers.InternalServerError(w, r)
+		u.errorHandlers.InternalServerError(w, r, err)
 		return
 	}
 
@@ -171,7 +230,7 @@ func (u *UserController) UsersGet(w http.ResponseWriter, r *http.Request) {
 		CsrfToken:       u.csrfToken(r),
 		IsAuthenticated: u.IsAuthenticated(r),
 	}); err != nil {
-		u.errorHandlers.InternalServerError(w, r)
+		u.errorHandlers.InternalServerError(w, r, err)
 		return
 	}
 }
@@ -179,19 +238,236 @@ func (u *UserController) UsersGet(w http.ResponseWriter, r *http.Request) {
 func (u *UserController) UserGet(w http.ResponseWriter, r *http.Request) {
 	user, err := u.service.GetByAttribute("username", r.PathValue("slug"))
 	if err != nil {
-		u.errorHandlers.InternalServerError(w, r)
+		u.errorHandlers.InternalServerError(w, r, err)
+		return
+	}
+
+	loginFailures, err := u.throttle.Get(user.Username)
+	if err != nil {
+		u.errorHandlers.InternalServerError(w, r, err)
 		return
 	}
 
+	message := u.sessionManager.PopString(r.Context(), session.SESSION_KEY_MESSAGE)
+
 	if err := u.templateCache["pages/admin/user.tmpl"].ExecuteTemplate(w, "admin", UserView{
-		Message:         "",
+		Message:         message,
 		User:            user,
+		Roles:           Roles,
+		LoginFailures:   loginFailures,
+		CsrfToken:       u.csrfToken(r),
+		IsAuthenticated: u.IsAuthenticated(r),
+	}); err != nil {
+		u.errorHandlers.InternalServerError(w, r, err)
+		return
+	}
+}
+
+func (u *UserController) UserProfilePost(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.FormValue("id"))
+	if err != nil {
+		u.errorHandlers.BadRequest(w, r)
+		return
+	}
+
+	profile := Profile{
+		DisplayName: strings.TrimSpace(r.FormValue("display_name")),
+		Bio:         strings.TrimSpace(r.FormValue("bio")),
+		AvatarUrl:   strings.TrimSpace(r.FormValue("avatar_url")),
+	}
+
+	updatedUser, err := u.service.UpdateProfile(uint(id), &profile)
+	if err != nil {
+		if _, ok := err.(validator.ValidationErrors); ok {
+			u.sessionManager.Put(
+				r.Context(),
+				session.SESSION_KEY_MESSAGE,
+				"Unable to update profile: check the display name, bio and avatar URL.",
+			)
+			http.Redirect(w, r, "/admin/users/"+r.PathValue("slug"), http.StatusSeeOther)
+			return
+		}
+
+		u.errorHandlers.InternalServerError(w, r, fmt.Errorf("unable to update profile: %w", err))
+		return
+	}
+
+	u.sessionManager.Put(
+		r.Context(),
+		session.SESSION_KEY_MESSAGE,
+		fmt.Sprintf("Updated profile for '%s'.", updatedUser.Username),
+	)
+
+	http.Redirect(w, r, "/admin/users/"+updatedUser.Username, http.StatusSeeOther)
+}
+
+func (u *UserController) AccountGet(w http.ResponseWriter, r *http.Request) {
+	account, ok := u.loggedInUser(r)
+	if !ok {
+		u.errorHandlers.Forbidden(w, r)
+		return
+	}
+
+	message := u.sessionManager.PopString(r.Context(), session.SESSION_KEY_MESSAGE)
+
+	if err := u.templateCache["pages/admin/account.tmpl"].ExecuteTemplate(w, "admin", AccountView{
+		Message:         message,
+		User:            account,
 		CsrfToken:       u.csrfToken(r),
 		IsAuthenticated: u.IsAuthenticated(r),
 	}); err != nil {
-		u.errorHandlers.InternalServerError(w, r)
+		u.errorHandlers.InternalServerError(w, r, err)
+		return
+	}
+}
+
+// AccountPost lets the logged in user change their own email and profile.
+// Username and role stay as they are, since only admins can change those.
+func (u *UserController) AccountPost(w http.ResponseWriter, r *http.Request) {
+	account, ok := u.loggedInUser(r)
+	if !ok {
+		u.errorHandlers.Forbidden(w, r)
+		return
+	}
+
+	if _, err := u.service.Update(&User{
+		Id:       account.Id,
+		Username: account.Username,
+		Email:    strings.TrimSpace(r.FormValue("email")),
+		Role:     account.Role,
+		Profile: Profile{
+			DisplayName: strings.TrimSpace(r.FormValue("display_name")),
+			Bio:         strings.TrimSpace(r.FormValue("bio")),
+			AvatarUrl:   strings.TrimSpace(r.FormValue("avatar_url")),
+		},
+	}); err != nil {
+		if _, ok := err.(validator.ValidationErrors); ok {
+			u.sessionManager.Put(
+				r.Context(),
+				session.SESSION_KEY_MESSAGE,
+				"Unable to update account: check the email, display name, bio and avatar URL.",
+			)
+			http.Redirect(w, r, "/admin/account", http.StatusSeeOther)
+			return
+		}
+
+		u.errorHandlers.InternalServerError(w, r, fmt.Errorf("unable to update account: %w", err))
+		return
+	}
+
+	u.sessionManager.Put(r.Context(), session.SESSION_KEY_MESSAGE, "Updated your account.")
+
+	http.Redirect(w, r, "/admin/account", http.StatusSeeOther)
+}
+
+// AccountPasswordPost changes the logged in user's password. The session is
+// renewed and moved on to the new session version, so every other session
+// for the user is logged out.
+func (u *UserController) AccountPasswordPost(w http.ResponseWriter, r *http.Request) {
+	account, ok := u.loggedInUser(r)
+	if !ok {
+		u.errorHandlers.Forbidden(w, r)
+		return
+	}
+
+	sessionVersion, err := u.service.ChangePassword(account.Username, &UserPasswordChangeRequestDto{
+		CurrentPassword: r.FormValue("current_password"),
+		NewPassword:     r.FormValue("new_password"),
+		ConfirmPassword: r.FormValue("confirm_password"),
+	})
+	if err != nil {
+		var message string
+
+		if err == ErrIncorrectPassword {
+			message = "Unable to change password: current password is incorrect."
+		} else if _, ok := err.(validator.ValidationErrors); ok {
+			message = "Unable to change password: new password must be 8 to 72 characters and match the confirmation."
+		} else {
+			u.errorHandlers.InternalServerError(w, r, fmt.Errorf("unable to change password: %w", err))
+			return
+		}
+
+		u.sessionManager.Put(r.Context(), session.SESSION_KEY_MESSAGE, message)
+		http.Redirect(w, r, "/admin/account", http.StatusSeeOther)
+		return
+	}
+
+	if err := u.sessionManager.RenewToken(r.Context()); err != nil {
+		u.errorHandlers.InternalServerError(w, r, err)
+		return
+	}
+
+	u.sessionManager.Put(r.Context(), session.SESSION_VERSION, sessionVersion)
+	u.sessionManager.Put(
+		r.Context(),
+		session.SESSION_KEY_MESSAGE,
+		"Changed your password. Any other sessions have been logged out.",
+	)
+
+	http.Redirect(w, r, "/admin/account", http.StatusSeeOther)
+}
+
+// UserRolePost changes what a user can do. The last admin can't be given
+// another role, so there's always someone who can manage users.
+func (u *UserController) UserRolePost(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.FormValue("id"))
+	if err != nil {
+		u.errorHandlers.BadRequest(w, r)
+		return
+	}
+
+	updatedUser, err := u.service.UpdateRole(uint(id), Role(r.FormValue("role")))
+	if err != nil {
+		if err == ErrLastAdmin {
+			u.sessionManager.Put(
+				r.Context(),
+				session.SESSION_KEY_MESSAGE,
+				fmt.Sprintf("Unable to change role: '%s' is the only admin.", r.PathValue("slug")),
+			)
+			http.Redirect(w, r, "/admin/users/"+r.PathValue("slug"), http.StatusSeeOther)
+			return
+		}
+
+		if _, ok := err.(validator.ValidationErrors); ok {
+			u.sessionManager.Put(
+				r.Context(),
+				session.SESSION_KEY_MESSAGE,
+				"Unable to change role: choose one of the roles.",
+			)
+			http.Redirect(w, r, "/admin/users/"+r.PathValue("slug"), http.StatusSeeOther)
+			return
+		}
+
+		u.errorHandlers.InternalServerError(w, r, fmt.Errorf("unable to change role: %w", err))
 		return
 	}
+
+	u.sessionManager.Put(
+		r.Context(),
+		session.SESSION_KEY_MESSAGE,
+		fmt.Sprintf("Changed role for '%s' to %s.", updatedUser.Username, updatedUser.Role),
+	)
+
+	http.Redirect(w, r, "/admin/users/"+updatedUser.Username, http.StatusSeeOther)
+}
+
+// UserUnlockPost lets a user who's been locked out by failed logins log in
+// again straight away.
+func (u *UserController) UserUnlockPost(w http.ResponseWriter, r *http.Request) {
+	username := r.PathValue("slug")
+
+	if err := u.throttle.Unlock(r.Context(), username); err != nil {
+		u.errorHandlers.InternalServerError(w, r, fmt.Errorf("unable to unlock user: %w", err))
+		return
+	}
+
+	u.sessionManager.Put(
+		r.Context(),
+		session.SESSION_KEY_MESSAGE,
+		fmt.Sprintf("Unlocked '%s'.", username),
+	)
+
+	http.Redirect(w, r, "/admin/users/"+username, http.StatusSeeOther)
 }
 
 func (u *UserController) DeleteUserPost(
@@ -206,7 +482,17 @@ func (u *UserController) DeleteUserPost(
 	}
 
 	if err := u.service.DeleteById(uint(id)); err != nil {
-		u.errorHandlers.InternalServerError(w, r)
+		if err == ErrLastAdmin {
+			u.sessionManager.Put(
+				r.Context(),
+				session.SESSION_KEY_MESSAGE,
+				fmt.Sprintf("Unable to delete user: '%s' is the only admin.", username),
+			)
+			http.Redirect(w, r, "/admin/users/"+username, http.StatusSeeOther)
+			return
+		}
+
+		u.errorHandlers.InternalServerError(w, r, err)
 		return
 	}
 
@@ -227,3 +513,31 @@ func (u *UserController) IsAuthenticated(r *http.Request) bool {
 
 	return isAuthenticated
 }
+
+// loggedInUser returns the user whose permissions were checked for the
+// request.
+func (u *UserController) loggedInUser(r *http.Request) (*UserResponseDto, bool) {
+	loggedInUser, ok := r.Context().Value(session.LOGGED_IN_USER_CONTEXT_KEY).(*UserResponseDto)
+
+	return loggedInUser, ok
+}
+
+// clientIp is the address the request came from, without its port.
+func clientIp(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+func lockedMessage(locked *LoginLockedError) string {
+	minutes := int(math.Ceil(time.Until(locked.Until).Minutes()))
+
+	if minutes <= 1 {
+		return "Too many failed logins. Try again in a minute."
+	}
+
+	return fmt.Sprintf("Too many failed logins. Try again in %d minutes.", minutes)
+}
@@ -9,7 +9,10 @@ import (
 	"net/url"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/go-playground/validator/v10"
+	"github.com/nixpig/dunce/pkg/pagination"
 	"github.com/nixpig/dunce/pkg/session"
 	"github.com/nixpig/dunce/pkg/templates"
 	"github.com/stretchr/testify/mock"
@@ -21,12 +24,24 @@ var mockTemplateCache = templates.TemplateCache{
 	"pages/admin/new-user.tmpl": mockTemplate,
 	"pages/admin/users.tmpl":    mockTemplate,
 	"pages/admin/user.tmpl":     mockTemplate,
+	"pages/admin/account.tmpl":  mockTemplate,
+
+	"pages/admin/forgot-password.tmpl": mockTemplate,
+	"pages/admin/reset-password.tmpl":  mockTemplate,
+
+	"pages/admin/login-two-factor.tmpl": mockTemplate,
+	"pages/admin/two-factor.tmpl":       mockTemplate,
+	"pages/admin/recovery-codes.tmpl":   mockTemplate,
+
+	"pages/admin/sessions.tmpl":   mockTemplate,
+	"pages/admin/api-tokens.tmpl": mockTemplate,
 }
 
 var mockLogger = new(MockLogger)
 var mockSessionManager = new(MockSessionManager)
 var mockService = new(MockUserService)
 var mockErrorHandlers = new(MockErrorHandlers)
+var mockThrottle = new(MockLoginThrottleService)
 
 func TestUserController(t *testing.T) {
 	scenarios := map[string]func(t *testing.T, ctrl UserController){
@@ -35,8 +50,11 @@ func TestUserController(t *testing.T) {
 		"get user login screen (not logged in)":              testGetUserLoginScreenHandlerNotLoggedIn,
 		"get user login screen (error - template rendering)": testGetUserLoginScreenHandlerTemplateError,
 		"post user login (success)":                          testPostUserLogin,
+		"post user login (success - two-factor pending)":     testPostUserLoginTwoFactorPending,
 		"post user login (error - login failed)":             testPostUserLoginUsernamePasswordFailed,
 		"post user login (error - renew token failed)":       testPostUserLoginRenewTokenFailed,
+		"post user login (error - locked)":                   testPostUserLoginLocked,
+		"post user unlock (success)":                         testPostUserUnlock,
 		"post user logout (success)":                         testPostUserLogout,
 		"get create user page (success)":                     testGetCreateUserPage,
 		"get create user page (error - template)":            testGetCreateUserPageTemplateError,
@@ -51,6 +69,19 @@ func TestUserController(t *testing.T) {
 		"post delete user (success)":                         testPostDeleteUser,
 		"post delete user (error - form error)":              testPostDeleteUserFormError,
 		"post delete user (error - service error)":           testPostDeleteUserServiceError,
+		"post delete user (error - last admin)":              testPostDeleteUserLastAdmin,
+		"post user role (success)":                           testPostUserRole,
+		"post user role (error - last admin)":                testPostUserRoleLastAdmin,
+		"post user role (error - validation)":                testPostUserRoleValidationError,
+		"post user profile (success)":                        testPostUserProfile,
+		"post user profile (error - validation)":             testPostUserProfileValidationError,
+		"get account (success)":                              testGetAccount,
+		"get account (error - not logged in)":                testGetAccountNotLoggedIn,
+		"post account (success)":                             testPostAccount,
+		"post account (error - validation)":                  testPostAccountValidationError,
+		"post account password (success)":                    testPostAccountPassword,
+		"post account password (error - incorrect password)": testPostAccountPasswordIncorrect,
+		"post account password (error - renew token)":        testPostAccountPasswordRenewTokenError,
 	}
 
 	for scenario, fn := range scenarios {
@@ -65,7 +96,7 @@ func TestUserController(t *testing.T) {
 				ErrorHandlers: mockErrorHandlers,
 			}
 
-			ctrl := NewUserController(mockService, config)
+			ctrl := NewUserController(mockService, mockThrottle, config)
 			fn(t, ctrl)
 		})
 
@@ -76,8 +107,8 @@ type MockErrorHandlers struct {
 	mock.Mock
 }
 
-func (e *MockErrorHandlers) InternalServerError(w http.ResponseWriter, r *http.Request) {
-	e.Called(w, r)
+func (e *MockErrorHandlers) InternalServerError(w http.ResponseWriter, r *http.Request, err error) {
+	e.Called(w, r, err)
 }
 
 func (e *MockErrorHandlers) NotFound(w http.ResponseWriter, r *http.Request) {
@@ -88,16 +119,62 @@ func (e *MockErrorHandlers) BadRequest(w http.ResponseWriter, r *http.Request) {
 	e.Called(w, r)
 }
 
+func (e *MockErrorHandlers) Forbidden(w http.ResponseWriter, r *http.Request) {
+	e.Called(w, r)
+}
+
 type MockLogger struct {
 	mock.Mock
 }
 
-func (l *MockLogger) Info(format string, values ...any) {
-	l.Called(format, values)
+func (l *MockLogger) Debug(ctx context.Context, msg string, args ...any) {
+	l.Called(msg, args)
+}
+
+func (l *MockLogger) Info(ctx context.Context, msg string, args ...any) {
+	l.Called(msg, args)
+}
+
+func (l *MockLogger) Warn(ctx context.Context, msg string, args ...any) {
+	l.Called(msg, args)
+}
+
+func (l *MockLogger) Error(ctx context.Context, msg string, args ...any) {
+	l.Called(msg, args)
+}
+
+type MockLoginThrottleService struct {
+	mock.Mock
+}
+
+func (l *MockLoginThrottleService) Check(username, ip string) error {
+	args := l.Called(username, ip)
+
+	return args.Error(0)
+}
+
+func (l *MockLoginThrottleService) Fail(ctx context.Context, username, ip string) error {
+	args := l.Called(username, ip)
+
+	return args.Error(0)
+}
+
+func (l *MockLoginThrottleService) Succeed(username string) error {
+	args := l.Called(username)
+
+	return args.Error(0)
+}
+
+func (l *MockLoginThrottleService) Get(username string) (*LoginFailures, error) {
+	args := l.Called(username)
+
+	return args.Get(0).(*LoginFailures), args.Error(1)
 }
 
-func (l *MockLogger) Error(format string, values ...any) {
-	l.Called(format, values)
+func (l *MockLoginThrottleService) Unlock(ctx context.Context, username string) error {
+	args := l.Called(username)
+
+	return args.Error(0)
 }
 
 var mockTemplate = new(MockTemplate)
@@ -138,6 +215,12 @@ func (s *MockSessionManager) GetString(ctx context.Context, key string) string {
 	return args.String(0)
 }
 
+func (s *MockSessionManager) GetInt(ctx context.Context, key string) int {
+	args := s.Called(ctx, key)
+
+	return args.Int(0)
+}
+
 func (s *MockSessionManager) LoadAndSave(next http.Handler) http.Handler {
 	args := s.Called(next)
 
@@ -186,10 +269,10 @@ func (u *MockUserService) Exists(username string) (bool, error) {
 	return args.Bool(0), args.Error(1)
 }
 
-func (u *MockUserService) GetAll() (*[]UserResponseDto, error) {
-	args := u.Called()
+func (u *MockUserService) GetAll(page pagination.Request) (*pagination.Page[UserResponseDto], error) {
+	args := u.Called(page)
 
-	return args.Get(0).(*[]UserResponseDto), args.Error(1)
+	return args.Get(0).(*pagination.Page[UserResponseDto]), args.Error(1)
 }
 
 func (u *MockUserService) GetByAttribute(
@@ -208,6 +291,30 @@ func (u *MockUserService) Update(
 	return args.Get(0).(*UserResponseDto), args.Error(1)
 }
 
+func (u *MockUserService) UpdateRole(id uint, role Role) (*UserResponseDto, error) {
+	args := u.Called(id, role)
+
+	return args.Get(0).(*UserResponseDto), args.Error(1)
+}
+
+func (u *MockUserService) UpdateProfile(
+	id uint,
+	profile *Profile,
+) (*UserResponseDto, error) {
+	args := u.Called(id, profile)
+
+	return args.Get(0).(*UserResponseDto), args.Error(1)
+}
+
+func (u *MockUserService) ChangePassword(
+	username string,
+	change *UserPasswordChangeRequestDto,
+) (int, error) {
+	args := u.Called(username, change)
+
+	return args.Int(0), args.Error(1)
+}
+
 func (u *MockUserService) LoginWithUsernamePassword(
 	username, password string,
 ) error {
@@ -336,7 +443,7 @@ func testGetUserLoginScreenHandlerTemplateError(
 	).Return(errors.New("template_error"))
 
 	mockErrorHandlersInternalServerError := mockErrorHandlers.
-		On("InternalServerError", rr, req.WithContext(ctx)).
+		On("InternalServerError", rr, req.WithContext(ctx), errors.New("template_error")).
 		Run(func(args mock.Arguments) {
 			rr.WriteHeader(http.StatusInternalServerError)
 		})
@@ -382,17 +489,25 @@ func testPostUserLogin(t *testing.T, ctrl UserController) {
 	}
 
 	req.Header.Add("content-type", "application/x-www-form-urlencoded")
+	req.RemoteAddr = "192.0.2.1:1234"
 
 	rr := httptest.NewRecorder()
 
 	handler := http.HandlerFunc(ctrl.UserLoginPost)
 
+	mockThrottleCheck := mockThrottle.On("Check", "janedoe", "192.0.2.1").Return(nil)
+
 	mockServiceLoginUsernamePassword := mockService.On("LoginWithUsernamePassword", "janedoe", "p4ssw0rd").
 		Return(nil)
 
 	mockSessionManagerRenewToken := mockSessionManager.On("RenewToken", req.Context()).
 		Return(nil)
 
+	mockServiceGetByAttribute := mockService.On("GetByAttribute", "username", "janedoe").
+		Return(&UserResponseDto{Id: 23, Username: "janedoe", SessionVersion: 3}, nil)
+
+	mockThrottleSucceed := mockThrottle.On("Succeed", "janedoe").Return(nil)
+
 	mockSessionManagerPut := mockSessionManager.On(
 		"Put",
 		req.Context(),
@@ -400,6 +515,13 @@ func testPostUserLogin(t *testing.T, ctrl UserController) {
 		"janedoe",
 	)
 
+	mockSessionManagerPutVersion := mockSessionManager.On(
+		"Put",
+		req.Context(),
+		session.SESSION_VERSION,
+		3,
+	)
+
 	handler.ServeHTTP(rr, req)
 
 	require.Equal(
@@ -424,8 +546,82 @@ func testPostUserLogin(t *testing.T, ctrl UserController) {
 		t.Error("should call session manager")
 	}
 
+	if res := mockThrottle.AssertExpectations(t); !res {
+		t.Error("should clear failed logins")
+	}
+
+	mockThrottleCheck.Unset()
+	mockThrottleSucceed.Unset()
+	mockServiceLoginUsernamePassword.Unset()
+	mockSessionManagerRenewToken.Unset()
+	mockServiceGetByAttribute.Unset()
+	mockSessionManagerPut.Unset()
+	mockSessionManagerPutVersion.Unset()
+}
+
+func testPostUserLoginTwoFactorPending(t *testing.T, ctrl UserController) {
+	form := url.Values{}
+	form.Add("username", "janedoe")
+	form.Add("password", "p4ssw0rd")
+
+	req, err := http.NewRequest(
+		"POST",
+		"/admin/login",
+		strings.NewReader(form.Encode()),
+	)
+	if err != nil {
+		t.Error("unable to construct request")
+	}
+
+	req.Header.Add("content-type", "application/x-www-form-urlencoded")
+	req.RemoteAddr = "192.0.2.1:1234"
+
+	rr := httptest.NewRecorder()
+
+	handler := http.HandlerFunc(ctrl.UserLoginPost)
+
+	mockThrottleCheck := mockThrottle.On("Check", "janedoe", "192.0.2.1").Return(nil)
+
+	mockServiceLoginUsernamePassword := mockService.On("LoginWithUsernamePassword", "janedoe", "p4ssw0rd").
+		Return(nil)
+
+	mockSessionManagerRenewToken := mockSessionManager.On("RenewToken", req.Context()).
+		Return(nil)
+
+	mockServiceGetByAttribute := mockService.On("GetByAttribute", "username", "janedoe").
+		Return(&UserResponseDto{Id: 23, Username: "janedoe", SessionVersion: 3, TwoFactorEnabled: true}, nil)
+
+	mockSessionManagerPut := mockSessionManager.On(
+		"Put",
+		req.Context(),
+		session.PENDING_TWO_FACTOR_USERNAME,
+		"janedoe",
+	)
+
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(
+		t,
+		http.StatusSeeOther,
+		rr.Result().StatusCode,
+		"should return status code see other",
+	)
+
+	require.Equal(
+		t,
+		"/admin/login/two-factor",
+		rr.Result().Header.Get("Location"),
+		"should redirect to two-factor login page",
+	)
+
+	if res := mockSessionManager.AssertExpectations(t); !res {
+		t.Error("should call session manager")
+	}
+
+	mockThrottleCheck.Unset()
 	mockServiceLoginUsernamePassword.Unset()
 	mockSessionManagerRenewToken.Unset()
+	mockServiceGetByAttribute.Unset()
 	mockSessionManagerPut.Unset()
 }
 
@@ -447,20 +643,25 @@ func testPostUserLoginUsernamePasswordFailed(
 	}
 
 	req.Header.Add("content-type", "application/x-www-form-urlencoded")
+	req.RemoteAddr = "192.0.2.1:1234"
 
 	rr := httptest.NewRecorder()
 
 	handler := http.HandlerFunc(ctrl.UserLoginPost)
 
+	mockThrottleCheck := mockThrottle.On("Check", "janedoe", "192.0.2.1").Return(nil)
+
 	mockServiceLoginUsernamePassword := mockService.On("LoginWithUsernamePassword", "janedoe", "p4ssw0rd").
 		Return(errors.New("username_password_error"))
 
 	mockLoggerError := mockLogger.On(
-		"Error",
-		"username_password_error",
-		mock.Anything,
+		"Warn",
+		"login failed",
+		[]any{"username", "janedoe", "err", errors.New("username_password_error")},
 	)
 
+	mockThrottleFail := mockThrottle.On("Fail", "janedoe", "192.0.2.1").Return(nil)
+
 	mockSessionManagerPut := mockSessionManager.On("Put", req.Context(), session.SESSION_KEY_MESSAGE, "Login failed.")
 
 	handler.ServeHTTP(rr, req)
@@ -486,9 +687,15 @@ func testPostUserLoginUsernamePasswordFailed(
 		t.Error("should put unauthorised message in session context")
 	}
 
+	if res := mockThrottle.AssertExpectations(t); !res {
+		t.Error("should record failed login")
+	}
+
 	mockSessionManager.AssertNotCalled(t, "RenewToken")
 
 	mockSessionManagerPut.Unset()
+	mockThrottleCheck.Unset()
+	mockThrottleFail.Unset()
 	mockServiceLoginUsernamePassword.Unset()
 	mockLoggerError.Unset()
 }
@@ -508,11 +715,14 @@ func testPostUserLoginRenewTokenFailed(t *testing.T, ctrl UserController) {
 	}
 
 	req.Header.Add("content-type", "application/x-www-form-urlencoded")
+	req.RemoteAddr = "192.0.2.1:1234"
 
 	rr := httptest.NewRecorder()
 
 	handler := http.HandlerFunc(ctrl.UserLoginPost)
 
+	mockThrottleCheck := mockThrottle.On("Check", "janedoe", "192.0.2.1").Return(nil)
+
 	mockServiceLoginUsernamePassword := mockService.On("LoginWithUsernamePassword", "janedoe", "p4ssw0rd").
 		Return(nil)
 
@@ -521,8 +731,8 @@ func testPostUserLoginRenewTokenFailed(t *testing.T, ctrl UserController) {
 
 	mockLoggerError := mockLogger.On(
 		"Error",
-		"renew_token_error",
-		mock.Anything,
+		"unable to renew session token",
+		[]any{"err", errors.New("renew_token_error")},
 	)
 
 	handler.ServeHTTP(rr, req)
@@ -546,11 +756,110 @@ func testPostUserLoginRenewTokenFailed(t *testing.T, ctrl UserController) {
 		t.Error("should log error")
 	}
 
+	mockThrottleCheck.Unset()
 	mockServiceLoginUsernamePassword.Unset()
 	mockSessionManagerRenewToken.Unset()
 	mockLoggerError.Unset()
 }
 
+func testPostUserLoginLocked(t *testing.T, ctrl UserController) {
+	form := url.Values{}
+	form.Add("username", "janedoe")
+	form.Add("password", "p4ssw0rd")
+
+	req, err := http.NewRequest(
+		"POST",
+		"/admin/login",
+		strings.NewReader(form.Encode()),
+	)
+	if err != nil {
+		t.Error("unable to construct request")
+	}
+
+	req.Header.Add("content-type", "application/x-www-form-urlencoded")
+	req.RemoteAddr = "192.0.2.1:1234"
+
+	rr := httptest.NewRecorder()
+
+	handler := http.HandlerFunc(ctrl.UserLoginPost)
+
+	mockThrottleCheck := mockThrottle.On("Check", "janedoe", "192.0.2.1").
+		Return(&LoginLockedError{Until: time.Now().Add(90 * time.Second)})
+
+	mockSessionManagerPut := mockSessionManager.On(
+		"Put",
+		req.Context(),
+		session.SESSION_KEY_MESSAGE,
+		"Too many failed logins. Try again in 2 minutes.",
+	)
+
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(
+		t,
+		http.StatusSeeOther,
+		rr.Result().StatusCode,
+		"should return status code see other",
+	)
+
+	require.Equal(t, "/admin/login", rr.Result().Header.Get("Location"), "should redirect back to login screen")
+
+	if res := mockThrottle.AssertExpectations(t); !res {
+		t.Error("should check failed logins")
+	}
+
+	if res := mockSessionManager.AssertExpectations(t); !res {
+		t.Error("should put locked message in session context")
+	}
+
+	mockThrottleCheck.Unset()
+	mockSessionManagerPut.Unset()
+}
+
+func testPostUserUnlock(t *testing.T, ctrl UserController) {
+	req, err := http.NewRequest("POST", "/admin/users/{slug}/unlock", nil)
+	if err != nil {
+		t.Error("unable to construct request")
+	}
+
+	req.SetPathValue("slug", "janedoe")
+
+	rr := httptest.NewRecorder()
+
+	handler := http.HandlerFunc(ctrl.UserUnlockPost)
+
+	mockThrottleUnlock := mockThrottle.On("Unlock", "janedoe").Return(nil)
+
+	mockSessionManagerPut := mockSessionManager.On(
+		"Put",
+		req.Context(),
+		session.SESSION_KEY_MESSAGE,
+		"Unlocked 'janedoe'.",
+	)
+
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(
+		t,
+		http.StatusSeeOther,
+		rr.Result().StatusCode,
+		"should return status code see other",
+	)
+
+	require.Equal(t, "/admin/users/janedoe", rr.Result().Header.Get("Location"), "should redirect to user page")
+
+	if res := mockThrottle.AssertExpectations(t); !res {
+		t.Error("should unlock user")
+	}
+
+	if res := mockSessionManager.AssertExpectations(t); !res {
+		t.Error("should put message in session context")
+	}
+
+	mockThrottleUnlock.Unset()
+	mockSessionManagerPut.Unset()
+}
+
 func testPostUserLogout(t *testing.T, ctrl UserController) {
 	req, err := http.NewRequest("POST", "/admin/logout", nil)
 	if err != nil {
@@ -567,6 +876,18 @@ func testPostUserLogout(t *testing.T, ctrl UserController) {
 		session.LOGGED_IN_USERNAME,
 	)
 
+	mockSessionManagerRemoveVersion := mockSessionManager.On(
+		"Remove",
+		req.Context(),
+		session.SESSION_VERSION,
+	)
+
+	mockSessionManagerRemovePending := mockSessionManager.On(
+		"Remove",
+		req.Context(),
+		session.PENDING_TWO_FACTOR_USERNAME,
+	)
+
 	mockSessionManagerPut := mockSessionManager.On(
 		"Put",
 		req.Context(),
@@ -594,11 +915,21 @@ func testPostUserLogout(t *testing.T, ctrl UserController) {
 		t.Error("should remove logged in user from session context")
 	}
 
+	if res := mockSessionManager.AssertCalled(t, "Remove", req.Context(), session.SESSION_VERSION); !res {
+		t.Error("should remove session version from session context")
+	}
+
+	if res := mockSessionManager.AssertCalled(t, "Remove", req.Context(), session.PENDING_TWO_FACTOR_USERNAME); !res {
+		t.Error("should remove pending two-factor login from session context")
+	}
+
 	if res := mockSessionManager.AssertCalled(t, "Put", req.Context(), session.SESSION_KEY_MESSAGE, "You've been logged out."); !res {
 		t.Error("should put message in session context")
 	}
 
 	mockSessionManagerRemove.Unset()
+	mockSessionManagerRemoveVersion.Unset()
+	mockSessionManagerRemovePending.Unset()
 	mockSessionManagerPut.Unset()
 }
 
@@ -617,6 +948,7 @@ func testGetCreateUserPage(t *testing.T, ctrl UserController) {
 		rr,
 		"admin",
 		UserCreateView{
+			Roles:           Roles,
 			CsrfToken:       "mock-token",
 			IsAuthenticated: true,
 		},
@@ -680,6 +1012,7 @@ func testGetCreateUserPageTemplateError(t *testing.T, ctrl UserController) {
 		rr,
 		"admin",
 		UserCreateView{
+			Roles:           Roles,
 			CsrfToken:       "mock-token",
 			IsAuthenticated: true,
 		},
@@ -688,7 +1021,7 @@ func testGetCreateUserPageTemplateError(t *testing.T, ctrl UserController) {
 	ctx := context.WithValue(req.Context(), session.IS_LOGGED_IN_CONTEXT_KEY, true)
 
 	mockErrorHandlersInternalServerError := mockErrorHandlers.
-		On("InternalServerError", rr, req.WithContext(ctx)).
+		On("InternalServerError", rr, req.WithContext(ctx), errors.New("template_error")).
 		Run(func(args mock.Arguments) {
 			rr.WriteHeader(http.StatusInternalServerError)
 		})
@@ -719,6 +1052,7 @@ func testPostCreateUser(t *testing.T, ctrl UserController) {
 	form.Add("username", "janedoe")
 	form.Add("password", "p4ssw0rd")
 	form.Add("email", "jane@example.org")
+	form.Add("role", "editor")
 
 	req, err := http.NewRequest(
 		"POST",
@@ -739,10 +1073,12 @@ func testPostCreateUser(t *testing.T, ctrl UserController) {
 		Username: "janedoe",
 		Password: "p4ssw0rd",
 		Email:    "jane@example.org",
+		Role:     RoleEditor,
 	}).Return(&UserResponseDto{
 		Id:       23,
 		Username: "janedoe",
 		Email:    "jane@example.org",
+		Role:     RoleEditor,
 	}, nil)
 
 	mockSessionManagerPut := mockSessionManager.On(
@@ -784,6 +1120,7 @@ func testPostCreateUserServiceError(t *testing.T, ctrl UserController) {
 	form.Add("username", "janedoe")
 	form.Add("password", "p4ssw0rd")
 	form.Add("email", "jane@example.org")
+	form.Add("role", "editor")
 
 	req, err := http.NewRequest(
 		"POST",
@@ -804,10 +1141,11 @@ func testPostCreateUserServiceError(t *testing.T, ctrl UserController) {
 		Username: "janedoe",
 		Password: "p4ssw0rd",
 		Email:    "jane@example.org",
+		Role:     RoleEditor,
 	}).Return(&UserResponseDto{}, errors.New("service_error"))
 
 	mockErrorHandlersInternalServerError := mockErrorHandlers.
-		On("InternalServerError", rr, req).
+		On("InternalServerError", rr, req, errors.New("service_error")).
 		Run(func(args mock.Arguments) {
 			rr.WriteHeader(http.StatusInternalServerError)
 		})
@@ -843,7 +1181,7 @@ func testGetAllUsers(t *testing.T, ctrl UserController) {
 
 	handler := http.HandlerFunc(ctrl.UsersGet)
 
-	mockServiceGetAll := mockService.On("GetAll").Return(&[]UserResponseDto{
+	mockServiceGetAll := mockService.On("GetAll", pagination.Request{}).Return(&pagination.Page[UserResponseDto]{Items: []UserResponseDto{
 		{
 			Id:       23,
 			Username: "janedoe",
@@ -852,13 +1190,13 @@ func testGetAllUsers(t *testing.T, ctrl UserController) {
 			Id:       42,
 			Username: "johndoe",
 		},
-	}, nil)
+	}}, nil)
 
 	mockSessionManagerPopString := mockSessionManager.
 		On("PopString", req.Context(), session.SESSION_KEY_MESSAGE).
 		Return("msg")
 
-	users := mockServiceGetAll.ReturnArguments[0].(*[]UserResponseDto)
+	users := mockServiceGetAll.ReturnArguments[0].(*pagination.Page[UserResponseDto])
 
 	mockTemplateExecuteTemplate := mockTemplate.On(
 		"ExecuteTemplate",
@@ -908,11 +1246,11 @@ func testGetAllUsersServiceError(t *testing.T, ctrl UserController) {
 
 	handler := http.HandlerFunc(ctrl.UsersGet)
 
-	mockServiceGetAll := mockService.On("GetAll").
-		Return(&[]UserResponseDto{}, errors.New("service_error"))
+	mockServiceGetAll := mockService.On("GetAll", pagination.Request{}).
+		Return((*pagination.Page[UserResponseDto])(nil), errors.New("service_error"))
 
 	mockErrorHandlersInternalServerError := mockErrorHandlers.
-		On("InternalServerError", rr, req).
+		On("InternalServerError", rr, req, errors.New("service_error")).
 		Run(func(args mock.Arguments) {
 			rr.WriteHeader(http.StatusInternalServerError)
 		})
@@ -948,7 +1286,7 @@ func testGetAllUsersTemplateError(t *testing.T, ctrl UserController) {
 
 	handler := http.HandlerFunc(ctrl.UsersGet)
 
-	mockServiceGetAll := mockService.On("GetAll").Return(&[]UserResponseDto{
+	mockServiceGetAll := mockService.On("GetAll", pagination.Request{}).Return(&pagination.Page[UserResponseDto]{Items: []UserResponseDto{
 		{
 			Id:       23,
 			Username: "janedoe",
@@ -957,13 +1295,13 @@ func testGetAllUsersTemplateError(t *testing.T, ctrl UserController) {
 			Id:       42,
 			Username: "johndoe",
 		},
-	}, nil)
+	}}, nil)
 
 	mockSessionManagerPopString := mockSessionManager.
 		On("PopString", req.Context(), session.SESSION_KEY_MESSAGE).
 		Return("msg")
 
-	users := mockServiceGetAll.ReturnArguments[0].(*[]UserResponseDto)
+	users := mockServiceGetAll.ReturnArguments[0].(*pagination.Page[UserResponseDto])
 
 	mockTemplateExecuteTemplate := mockTemplate.On(
 		"ExecuteTemplate",
@@ -978,7 +1316,7 @@ func testGetAllUsersTemplateError(t *testing.T, ctrl UserController) {
 	).Return(errors.New("template_error"))
 
 	mockErrorHandlersInternalServerError := mockErrorHandlers.
-		On("InternalServerError", rr, req).
+		On("InternalServerError", rr, req, errors.New("template_error")).
 		Run(func(args mock.Arguments) {
 			rr.WriteHeader(http.StatusInternalServerError)
 		})
@@ -1032,6 +1370,9 @@ func testGetUserByUsername(t *testing.T, ctrl UserController) {
 			Username: "janedoe",
 		}, nil)
 
+	mockThrottleGet := mockThrottle.On("Get", "janedoe").
+		Return(&LoginFailures{Failures: 3}, nil)
+
 	mockSessionManagerPopString := mockSessionManager.
 		On("PopString", req.Context(), session.SESSION_KEY_MESSAGE).
 		Return("msg")
@@ -1044,7 +1385,9 @@ func testGetUserByUsername(t *testing.T, ctrl UserController) {
 		"admin",
 		UserView{
 			User:            user,
-			Message:         "",
+			Message:         "msg",
+			Roles:           Roles,
+			LoginFailures:   &LoginFailures{Failures: 3},
 			CsrfToken:       "mock-token",
 			IsAuthenticated: false,
 		},
@@ -1074,6 +1417,7 @@ func testGetUserByUsername(t *testing.T, ctrl UserController) {
 	mockSessionManagerPopString.Unset()
 	mockTemplateExecuteTemplate.Unset()
 	mockServiceGetByAttribute.Unset()
+	mockThrottleGet.Unset()
 }
 
 func testGetUserByUsernameServiceError(t *testing.T, ctrl UserController) {
@@ -1092,7 +1436,7 @@ func testGetUserByUsernameServiceError(t *testing.T, ctrl UserController) {
 		Return(&UserResponseDto{}, errors.New("service_error"))
 
 	mockErrorHandlersInternalServerError := mockErrorHandlers.
-		On("InternalServerError", rr, req).
+		On("InternalServerError", rr, req, errors.New("service_error")).
 		Run(func(args mock.Arguments) {
 			rr.WriteHeader(http.StatusInternalServerError)
 		})
@@ -1136,9 +1480,12 @@ func testGetUserByUsernameTemplateError(t *testing.T, ctrl UserController) {
 			Username: "janedoe",
 		}, nil)
 
+	mockThrottleGet := mockThrottle.On("Get", "janedoe").
+		Return(&LoginFailures{Failures: 3}, nil)
+
 	mockSessionManagerPopString := mockSessionManager.
 		On("PopString", req.Context(), session.SESSION_KEY_MESSAGE).
-		Return("msg")
+		Return("")
 
 	user := mockServiceGetByAttribute.ReturnArguments[0].(*UserResponseDto)
 
@@ -1149,13 +1496,15 @@ func testGetUserByUsernameTemplateError(t *testing.T, ctrl UserController) {
 		UserView{
 			User:            user,
 			Message:         "",
+			Roles:           Roles,
+			LoginFailures:   &LoginFailures{Failures: 3},
 			CsrfToken:       "mock-token",
 			IsAuthenticated: false,
 		},
 	).Return(errors.New("template_error"))
 
 	mockErrorHandlersInternalServerError := mockErrorHandlers.
-		On("InternalServerError", rr, req).
+		On("InternalServerError", rr, req, errors.New("template_error")).
 		Run(func(args mock.Arguments) {
 			rr.WriteHeader(http.StatusInternalServerError)
 		})
@@ -1189,6 +1538,7 @@ func testGetUserByUsernameTemplateError(t *testing.T, ctrl UserController) {
 	mockSessionManagerPopString.Unset()
 	mockTemplateExecuteTemplate.Unset()
 	mockServiceGetByAttribute.Unset()
+	mockThrottleGet.Unset()
 }
 
 func testPostDeleteUser(t *testing.T, ctrl UserController) {
@@ -1321,7 +1671,7 @@ func testPostDeleteUserServiceError(t *testing.T, ctrl UserController) {
 		Return(errors.New("service_error"))
 
 	mockErrorHandlersInternalServerError := mockErrorHandlers.
-		On("InternalServerError", rr, req).
+		On("InternalServerError", rr, req, errors.New("service_error")).
 		Run(func(args mock.Arguments) {
 			rr.WriteHeader(http.StatusInternalServerError)
 		})
@@ -1346,3 +1696,683 @@ func testPostDeleteUserServiceError(t *testing.T, ctrl UserController) {
 	mockErrorHandlersInternalServerError.Unset()
 	mockServiceDeleteById.Unset()
 }
+
+func testPostUserProfile(t *testing.T, ctrl UserController) {
+	form := url.Values{}
+	form.Add("id", "23")
+	form.Add("display_name", " Jane Doe ")
+	form.Add("bio", "Writes about things.")
+	form.Add("avatar_url", "https://example.org/jane.png")
+
+	req, err := http.NewRequest(
+		"POST",
+		"/admin/users/{slug}/profile",
+		strings.NewReader(form.Encode()),
+	)
+	if err != nil {
+		t.Error("unable to construct request")
+	}
+
+	req.SetPathValue("slug", "janedoe")
+
+	req.Header.Add("content-type", "application/x-www-form-urlencoded")
+
+	rr := httptest.NewRecorder()
+
+	handler := http.HandlerFunc(ctrl.UserProfilePost)
+
+	profile := &Profile{
+		DisplayName: "Jane Doe",
+		Bio:         "Writes about things.",
+		AvatarUrl:   "https://example.org/jane.png",
+	}
+
+	mockServiceUpdateProfile := mockService.On("UpdateProfile", uint(23), profile).Return(&UserResponseDto{
+		Id:       23,
+		Username: "janedoe",
+		Email:    "jane@example.org",
+		Role:     RoleAuthor,
+		Profile:  *profile,
+	}, nil)
+
+	mockSessionManagerPut := mockSessionManager.On(
+		"Put",
+		req.Context(),
+		session.SESSION_KEY_MESSAGE,
+		"Updated profile for 'janedoe'.",
+	)
+
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(
+		t,
+		http.StatusSeeOther,
+		rr.Result().StatusCode,
+		"should return status code see other",
+	)
+
+	require.Equal(
+		t,
+		"/admin/users/janedoe",
+		rr.Result().Header.Get("Location"),
+		"should redirect to user admin page",
+	)
+
+	if res := mockService.AssertExpectations(t); !res {
+		t.Error("should call service to update profile")
+	}
+
+	if res := mockSessionManager.AssertExpectations(t); !res {
+		t.Error("should put updated message in session context")
+	}
+
+	mockServiceUpdateProfile.Unset()
+	mockSessionManagerPut.Unset()
+}
+
+func testPostDeleteUserLastAdmin(t *testing.T, ctrl UserController) {
+	form := url.Values{}
+	form.Add("id", "1")
+	form.Add("username", "admin")
+
+	req, err := http.NewRequest(
+		"POST",
+		"/admin/users/{username}/delete",
+		strings.NewReader(form.Encode()),
+	)
+	if err != nil {
+		t.Error("unable to construct request")
+	}
+
+	req.SetPathValue("username", "admin")
+
+	req.Header.Add("content-type", "application/x-www-form-urlencoded")
+
+	rr := httptest.NewRecorder()
+
+	handler := http.HandlerFunc(ctrl.DeleteUserPost)
+
+	mockServiceDeleteById := mockService.On("DeleteById", uint(1)).
+		Return(ErrLastAdmin)
+
+	mockSessionManagerPut := mockSessionManager.On(
+		"Put",
+		req.Context(),
+		session.SESSION_KEY_MESSAGE,
+		"Unable to delete user: 'admin' is the only admin.",
+	)
+
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusSeeOther, rr.Result().StatusCode, "should return status code see other")
+	require.Equal(t, "/admin/users/admin", rr.Result().Header.Get("Location"), "should redirect back to user admin page")
+
+	if res := mockSessionManager.AssertExpectations(t); !res {
+		t.Error("should put last admin message in session context")
+	}
+
+	mockServiceDeleteById.Unset()
+	mockSessionManagerPut.Unset()
+}
+
+func newUserRoleRequest(t *testing.T, username, id, role string) *http.Request {
+	form := url.Values{}
+	form.Add("id", id)
+	form.Add("role", role)
+
+	req, err := http.NewRequest(
+		"POST",
+		"/admin/users/{slug}/role",
+		strings.NewReader(form.Encode()),
+	)
+	if err != nil {
+		t.Error("unable to construct request")
+	}
+
+	req.SetPathValue("slug", username)
+
+	req.Header.Add("content-type", "application/x-www-form-urlencoded")
+
+	return req
+}
+
+func testPostUserRole(t *testing.T, ctrl UserController) {
+	req := newUserRoleRequest(t, "janedoe", "23", "editor")
+
+	rr := httptest.NewRecorder()
+
+	handler := http.HandlerFunc(ctrl.UserRolePost)
+
+	mockServiceUpdateRole := mockService.On("UpdateRole", uint(23), RoleEditor).Return(&UserResponseDto{
+		Id:       23,
+		Username: "janedoe",
+		Email:    "jane@example.org",
+		Role:     RoleEditor,
+	}, nil)
+
+	mockSessionManagerPut := mockSessionManager.On(
+		"Put",
+		req.Context(),
+		session.SESSION_KEY_MESSAGE,
+		"Changed role for 'janedoe' to editor.",
+	)
+
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusSeeOther, rr.Result().StatusCode, "should return status code see other")
+	require.Equal(t, "/admin/users/janedoe", rr.Result().Header.Get("Location"), "should redirect to user admin page")
+
+	if res := mockService.AssertExpectations(t); !res {
+		t.Error("should call service to update role")
+	}
+
+	if res := mockSessionManager.AssertExpectations(t); !res {
+		t.Error("should put updated message in session context")
+	}
+
+	mockServiceUpdateRole.Unset()
+	mockSessionManagerPut.Unset()
+}
+
+func testPostUserRoleLastAdmin(t *testing.T, ctrl UserController) {
+	req := newUserRoleRequest(t, "admin", "1", "viewer")
+
+	rr := httptest.NewRecorder()
+
+	handler := http.HandlerFunc(ctrl.UserRolePost)
+
+	mockServiceUpdateRole := mockService.On("UpdateRole", uint(1), RoleViewer).
+		Return((*UserResponseDto)(nil), ErrLastAdmin)
+
+	mockSessionManagerPut := mockSessionManager.On(
+		"Put",
+		req.Context(),
+		session.SESSION_KEY_MESSAGE,
+		"Unable to change role: 'admin' is the only admin.",
+	)
+
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusSeeOther, rr.Result().StatusCode, "should return status code see other")
+	require.Equal(t, "/admin/users/admin", rr.Result().Header.Get("Location"), "should redirect back to user admin page")
+
+	if res := mockSessionManager.AssertExpectations(t); !res {
+		t.Error("should put last admin message in session context")
+	}
+
+	mockServiceUpdateRole.Unset()
+	mockSessionManagerPut.Unset()
+}
+
+func testPostUserRoleValidationError(t *testing.T, ctrl UserController) {
+	req := newUserRoleRequest(t, "janedoe", "23", "owner")
+
+	rr := httptest.NewRecorder()
+
+	handler := http.HandlerFunc(ctrl.UserRolePost)
+
+	mockServiceUpdateRole := mockService.On("UpdateRole", uint(23), Role("owner")).
+		Return((*UserResponseDto)(nil), validator.ValidationErrors{})
+
+	mockSessionManagerPut := mockSessionManager.On(
+		"Put",
+		req.Context(),
+		session.SESSION_KEY_MESSAGE,
+		"Unable to change role: choose one of the roles.",
+	)
+
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusSeeOther, rr.Result().StatusCode, "should return status code see other")
+	require.Equal(t, "/admin/users/janedoe", rr.Result().Header.Get("Location"), "should redirect back to user admin page")
+
+	if res := mockSessionManager.AssertExpectations(t); !res {
+		t.Error("should put validation message in session context")
+	}
+
+	mockServiceUpdateRole.Unset()
+	mockSessionManagerPut.Unset()
+}
+
+func testPostUserProfileValidationError(t *testing.T, ctrl UserController) {
+	form := url.Values{}
+	form.Add("id", "23")
+	form.Add("avatar_url", "nonsense")
+
+	req, err := http.NewRequest(
+		"POST",
+		"/admin/users/{slug}/profile",
+		strings.NewReader(form.Encode()),
+	)
+	if err != nil {
+		t.Error("unable to construct request")
+	}
+
+	req.SetPathValue("slug", "janedoe")
+
+	req.Header.Add("content-type", "application/x-www-form-urlencoded")
+
+	rr := httptest.NewRecorder()
+
+	handler := http.HandlerFunc(ctrl.UserProfilePost)
+
+	mockServiceUpdateProfile := mockService.On(
+		"UpdateProfile",
+		uint(23),
+		&Profile{AvatarUrl: "nonsense"},
+	).Return(&UserResponseDto{}, validator.ValidationErrors{})
+
+	mockSessionManagerPut := mockSessionManager.On(
+		"Put",
+		req.Context(),
+		session.SESSION_KEY_MESSAGE,
+		"Unable to update profile: check the display name, bio and avatar URL.",
+	)
+
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(
+		t,
+		http.StatusSeeOther,
+		rr.Result().StatusCode,
+		"should return status code see other",
+	)
+
+	require.Equal(
+		t,
+		"/admin/users/janedoe",
+		rr.Result().Header.Get("Location"),
+		"should redirect back to user admin page",
+	)
+
+	if res := mockService.AssertExpectations(t); !res {
+		t.Error("should call service to update profile")
+	}
+
+	if res := mockSessionManager.AssertExpectations(t); !res {
+		t.Error("should put validation message in session context")
+	}
+
+	mockServiceUpdateProfile.Unset()
+	mockSessionManagerPut.Unset()
+}
+
+func withLoggedInUser(req *http.Request, user *UserResponseDto) *http.Request {
+	return req.WithContext(
+		context.WithValue(req.Context(), session.LOGGED_IN_USER_CONTEXT_KEY, user),
+	)
+}
+
+func testGetAccount(t *testing.T, ctrl UserController) {
+	req, err := http.NewRequest("GET", "/admin/account", nil)
+	if err != nil {
+		t.Error("unable to construct request")
+	}
+
+	account := &UserResponseDto{Id: 23, Username: "janedoe", Role: RoleAuthor}
+
+	req = withLoggedInUser(req, account)
+
+	rr := httptest.NewRecorder()
+
+	handler := http.HandlerFunc(ctrl.AccountGet)
+
+	mockSessionManagerPopString := mockSessionManager.
+		On("PopString", req.Context(), session.SESSION_KEY_MESSAGE).
+		Return("msg")
+
+	mockTemplateExecuteTemplate := mockTemplate.On(
+		"ExecuteTemplate",
+		rr,
+		"admin",
+		AccountView{
+			Message:         "msg",
+			User:            account,
+			CsrfToken:       "mock-token",
+			IsAuthenticated: false,
+		},
+	).Return(nil)
+
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(
+		t,
+		http.StatusOK,
+		rr.Result().StatusCode,
+		"should return status code ok",
+	)
+
+	if res := mockSessionManager.AssertExpectations(t); !res {
+		t.Error("should get message from session context")
+	}
+
+	if res := mockTemplate.AssertExpectations(t); !res {
+		t.Error("should execute template")
+	}
+
+	mockSessionManagerPopString.Unset()
+	mockTemplateExecuteTemplate.Unset()
+}
+
+func testGetAccountNotLoggedIn(t *testing.T, ctrl UserController) {
+	req, err := http.NewRequest("GET", "/admin/account", nil)
+	if err != nil {
+		t.Error("unable to construct request")
+	}
+
+	rr := httptest.NewRecorder()
+
+	handler := http.HandlerFunc(ctrl.AccountGet)
+
+	mockErrorHandlersForbidden := mockErrorHandlers.On("Forbidden", rr, req)
+
+	handler.ServeHTTP(rr, req)
+
+	if res := mockErrorHandlers.AssertExpectations(t); !res {
+		t.Error("should return forbidden error")
+	}
+
+	mockErrorHandlersForbidden.Unset()
+}
+
+func testPostAccount(t *testing.T, ctrl UserController) {
+	form := url.Values{}
+	form.Add("email", " jane@example.net ")
+	form.Add("display_name", "Jane Doe")
+	form.Add("bio", "Writes about things.")
+	form.Add("avatar_url", "")
+	// username and role can't be changed from the account page
+	form.Add("username", "admin")
+	form.Add("role", "admin")
+
+	req, err := http.NewRequest(
+		"POST",
+		"/admin/account",
+		strings.NewReader(form.Encode()),
+	)
+	if err != nil {
+		t.Error("unable to construct request")
+	}
+
+	req.Header.Add("content-type", "application/x-www-form-urlencoded")
+
+	req = withLoggedInUser(req, &UserResponseDto{
+		Id:       23,
+		Username: "janedoe",
+		Email:    "jane@example.org",
+		Role:     RoleAuthor,
+	})
+
+	rr := httptest.NewRecorder()
+
+	handler := http.HandlerFunc(ctrl.AccountPost)
+
+	mockServiceUpdate := mockService.On("Update", &User{
+		Id:       23,
+		Username: "janedoe",
+		Email:    "jane@example.net",
+		Role:     RoleAuthor,
+		Profile: Profile{
+			DisplayName: "Jane Doe",
+			Bio:         "Writes about things.",
+		},
+	}).Return(&UserResponseDto{Id: 23, Username: "janedoe"}, nil)
+
+	mockSessionManagerPut := mockSessionManager.On(
+		"Put",
+		req.Context(),
+		session.SESSION_KEY_MESSAGE,
+		"Updated your account.",
+	)
+
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(
+		t,
+		http.StatusSeeOther,
+		rr.Result().StatusCode,
+		"should return status code see other",
+	)
+
+	require.Equal(
+		t,
+		"/admin/account",
+		rr.Result().Header.Get("Location"),
+		"should redirect to account page",
+	)
+
+	if res := mockService.AssertExpectations(t); !res {
+		t.Error("should call service to update user")
+	}
+
+	if res := mockSessionManager.AssertExpectations(t); !res {
+		t.Error("should put updated message in session context")
+	}
+
+	mockServiceUpdate.Unset()
+	mockSessionManagerPut.Unset()
+}
+
+func testPostAccountValidationError(t *testing.T, ctrl UserController) {
+	form := url.Values{}
+	form.Add("email", "nonsense")
+
+	req, err := http.NewRequest(
+		"POST",
+		"/admin/account",
+		strings.NewReader(form.Encode()),
+	)
+	if err != nil {
+		t.Error("unable to construct request")
+	}
+
+	req.Header.Add("content-type", "application/x-www-form-urlencoded")
+
+	req = withLoggedInUser(req, &UserResponseDto{
+		Id:       23,
+		Username: "janedoe",
+		Role:     RoleAuthor,
+	})
+
+	rr := httptest.NewRecorder()
+
+	handler := http.HandlerFunc(ctrl.AccountPost)
+
+	mockServiceUpdate := mockService.On("Update", &User{
+		Id:       23,
+		Username: "janedoe",
+		Email:    "nonsense",
+		Role:     RoleAuthor,
+	}).Return(&UserResponseDto{}, validator.ValidationErrors{})
+
+	mockSessionManagerPut := mockSessionManager.On(
+		"Put",
+		req.Context(),
+		session.SESSION_KEY_MESSAGE,
+		"Unable to update account: check the email, display name, bio and avatar URL.",
+	)
+
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(
+		t,
+		http.StatusSeeOther,
+		rr.Result().StatusCode,
+		"should return status code see other",
+	)
+
+	require.Equal(
+		t,
+		"/admin/account",
+		rr.Result().Header.Get("Location"),
+		"should redirect back to account page",
+	)
+
+	if res := mockService.AssertExpectations(t); !res {
+		t.Error("should call service to update user")
+	}
+
+	if res := mockSessionManager.AssertExpectations(t); !res {
+		t.Error("should put validation message in session context")
+	}
+
+	mockServiceUpdate.Unset()
+	mockSessionManagerPut.Unset()
+}
+
+func newPasswordChangeRequest(t *testing.T) *http.Request {
+	form := url.Values{}
+	form.Add("current_password", "p4ssw0rd")
+	form.Add("new_password", "n3wp4ssw0rd")
+	form.Add("confirm_password", "n3wp4ssw0rd")
+
+	req, err := http.NewRequest(
+		"POST",
+		"/admin/account/password",
+		strings.NewReader(form.Encode()),
+	)
+	if err != nil {
+		t.Error("unable to construct request")
+	}
+
+	req.Header.Add("content-type", "application/x-www-form-urlencoded")
+
+	return withLoggedInUser(req, &UserResponseDto{Id: 23, Username: "janedoe"})
+}
+
+func testPostAccountPassword(t *testing.T, ctrl UserController) {
+	req := newPasswordChangeRequest(t)
+
+	rr := httptest.NewRecorder()
+
+	handler := http.HandlerFunc(ctrl.AccountPasswordPost)
+
+	mockServiceChangePassword := mockService.On("ChangePassword", "janedoe", &UserPasswordChangeRequestDto{
+		CurrentPassword: "p4ssw0rd",
+		NewPassword:     "n3wp4ssw0rd",
+		ConfirmPassword: "n3wp4ssw0rd",
+	}).Return(4, nil)
+
+	mockSessionManagerRenewToken := mockSessionManager.On("RenewToken", req.Context()).
+		Return(nil)
+
+	mockSessionManagerPutVersion := mockSessionManager.On(
+		"Put",
+		req.Context(),
+		session.SESSION_VERSION,
+		4,
+	)
+
+	mockSessionManagerPut := mockSessionManager.On(
+		"Put",
+		req.Context(),
+		session.SESSION_KEY_MESSAGE,
+		"Changed your password. Any other sessions have been logged out.",
+	)
+
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(
+		t,
+		http.StatusSeeOther,
+		rr.Result().StatusCode,
+		"should return status code see other",
+	)
+
+	require.Equal(
+		t,
+		"/admin/account",
+		rr.Result().Header.Get("Location"),
+		"should redirect to account page",
+	)
+
+	if res := mockService.AssertExpectations(t); !res {
+		t.Error("should call service to change password")
+	}
+
+	if res := mockSessionManager.AssertExpectations(t); !res {
+		t.Error("should renew session and keep it on the new session version")
+	}
+
+	mockServiceChangePassword.Unset()
+	mockSessionManagerRenewToken.Unset()
+	mockSessionManagerPutVersion.Unset()
+	mockSessionManagerPut.Unset()
+}
+
+func testPostAccountPasswordIncorrect(t *testing.T, ctrl UserController) {
+	req := newPasswordChangeRequest(t)
+
+	rr := httptest.NewRecorder()
+
+	handler := http.HandlerFunc(ctrl.AccountPasswordPost)
+
+	mockServiceChangePassword := mockService.On("ChangePassword", "janedoe", &UserPasswordChangeRequestDto{
+		CurrentPassword: "p4ssw0rd",
+		NewPassword:     "n3wp4ssw0rd",
+		ConfirmPassword: "n3wp4ssw0rd",
+	}).Return(0, ErrIncorrectPassword)
+
+	mockSessionManagerPut := mockSessionManager.On(
+		"Put",
+		req.Context(),
+		session.SESSION_KEY_MESSAGE,
+		"Unable to change password: current password is incorrect.",
+	)
+
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(
+		t,
+		http.StatusSeeOther,
+		rr.Result().StatusCode,
+		"should return status code see other",
+	)
+
+	require.Equal(
+		t,
+		"/admin/account",
+		rr.Result().Header.Get("Location"),
+		"should redirect back to account page",
+	)
+
+	if res := mockService.AssertExpectations(t); !res {
+		t.Error("should call service to change password")
+	}
+
+	if res := mockSessionManager.AssertExpectations(t); !res {
+		t.Error("should put incorrect password message in session context")
+	}
+
+	mockServiceChangePassword.Unset()
+	mockSessionManagerPut.Unset()
+}
+
+func testPostAccountPasswordRenewTokenError(t *testing.T, ctrl UserController) {
+	req := newPasswordChangeRequest(t)
+
+	rr := httptest.NewRecorder()
+
+	handler := http.HandlerFunc(ctrl.AccountPasswordPost)
+
+	mockServiceChangePassword := mockService.On("ChangePassword", "janedoe", &UserPasswordChangeRequestDto{
+		CurrentPassword: "p4ssw0rd",
+		NewPassword:     "n3wp4ssw0rd",
+		ConfirmPassword: "n3wp4ssw0rd",
+	}).Return(4, nil)
+
+	mockSessionManagerRenewToken := mockSessionManager.On("RenewToken", req.Context()).
+		Return(errors.New("renew_error"))
+
+	mockErrorHandlersInternalServerError := mockErrorHandlers.On("InternalServerError", rr, req, errors.New("renew_error"))
+
+	handler.ServeHTTP(rr, req)
+
+	if res := mockErrorHandlers.AssertExpectations(t); !res {
+		t.Error("should return internal server error")
+	}
+
+	mockServiceChangePassword.Unset()
+	mockSessionManagerRenewToken.Unset()
+	mockErrorHandlersInternalServerError.Unset()
+}
@@ -1,7 +1,34 @@
 package user
 
-import "errors"
+import (
+	"errors"
+	"time"
+)
 
 var (
 	ErrInvalidCredentials = errors.New("invalid credentials")
+
+	// ErrLastAdmin is returned for a change that would leave the site
+	// without an admin, so no one could manage users or settings.
+	ErrLastAdmin = errors.New("the site needs at least one admin")
 )
+
+// LoginLockedError is returned when there have been too many failed logins
+// for a username or from a client, so logins are blocked until Until.
+type LoginLockedError struct {
+	Until time.Time
+}
+
+func (e *LoginLockedError) Error() string {
+	return "too many failed logins"
+}
+
+// PasswordResetLockedError is returned when too many password resets have
+// been asked for an email or from a client, so more are refused until Until.
+type PasswordResetLockedError struct {
+	Until time.Time
+}
+
+func (e *PasswordResetLockedError) Error() string {
+	return "too many password reset requests"
+}
@@ -4,17 +4,33 @@ import (
 	"context"
 	"errors"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/nixpig/dunce/db"
+	"github.com/nixpig/dunce/pkg/pagination"
 )
 
 type UserRepository interface {
 	Create(user *User) (*User, error)
 	DeleteById(id uint) error
 	Exists(username string) (bool, error)
-	GetAll() (*[]User, error)
+	GetAll(page pagination.Request) (*pagination.Page[User], error)
 	GetByAttribute(attr, value string) (*User, error)
 	GetPasswordByUsername(username string) (string, error)
 	Update(user *User) (*User, error)
+	UpdateRole(id uint, role Role) (*User, error)
+	UpdatePassword(username, password string) (int, error)
+	UpdateProfile(id uint, profile *Profile) (*User, error)
+}
+
+var userSortable = pagination.Sortable{
+	Columns: map[string]pagination.Column{
+		"id":       {Expr: "id_", Type: "integer"},
+		"username": {Expr: "username_", Type: "text"},
+		"email":    {Expr: "email_", Type: "text"},
+	},
+	Id:               "id_",
+	DefaultSort:      "username",
+	DefaultDirection: pagination.Asc,
 }
 
 type userPostgresRepository struct {
@@ -28,23 +44,36 @@ func NewUserPostgresRepository(db db.Dbconn) userPostgresRepository {
 }
 
 func (u userPostgresRepository) Create(user *User) (*User, error) {
-	query := `insert into users_ (username_, email_, password_) values ($1, $2, $3) returning id_, username_, email_`
+	query := `insert into users_ (username_, email_, password_, role_) values ($1, $2, $3, $4) returning id_, username_, email_, role_`
 
 	var createdUser User
 
-	row := u.db.QueryRow(context.Background(), query, user.Username, user.Email, user.Password)
+	row := u.db.QueryRow(context.Background(), query, user.Username, user.Email, user.Password, user.Role)
 
-	if err := row.Scan(&createdUser.Id, &createdUser.Username, &createdUser.Email); err != nil {
+	if err := row.Scan(&createdUser.Id, &createdUser.Username, &createdUser.Email, &createdUser.Role); err != nil {
 		return nil, err
 	}
 
 	return &createdUser, nil
 }
 
+// DeleteById deletes the user with id, unless they're the last admin.
 func (u userPostgresRepository) DeleteById(id uint) error {
 	query := `delete from users_ where id_ = $1`
 
-	res, err := u.db.Exec(context.Background(), query, id)
+	tx, err := u.db.Begin(context.Background())
+	if err != nil {
+		return err
+	}
+
+	// does nothing once the transaction's committed
+	defer tx.Rollback(context.Background())
+
+	if err := guardLastAdmin(tx, id); err != nil {
+		return err
+	}
+
+	res, err := tx.Exec(context.Background(), query, id)
 	if err != nil {
 		return err
 	}
@@ -53,6 +82,42 @@ func (u userPostgresRepository) DeleteById(id uint) error {
 		return errors.New("no user deleted")
 	}
 
+	return tx.Commit(context.Background())
+}
+
+// guardLastAdmin returns ErrLastAdmin if the user with id is the only admin,
+// for anything that would stop them being one. Admins are locked until tx
+// ends, so two of them can't be removed at once, each leaving the other.
+func guardLastAdmin(tx pgx.Tx, id uint) error {
+	query := `select id_ from users_ where role_ = 'admin' for update`
+
+	rows, err := tx.Query(context.Background(), query)
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	var adminIds []uint
+
+	for rows.Next() {
+		var adminId uint
+
+		if err := rows.Scan(&adminId); err != nil {
+			return err
+		}
+
+		adminIds = append(adminIds, adminId)
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if len(adminIds) == 1 && adminIds[0] == id {
+		return ErrLastAdmin
+	}
+
 	return nil
 }
 
@@ -70,10 +135,20 @@ func (u userPostgresRepository) Exists(username string) (bool, error) {
 	return exists, nil
 }
 
-func (u userPostgresRepository) GetAll() (*[]User, error) {
-	query := `select id_, username_, email_ from users_`
+func (u userPostgresRepository) GetAll(page pagination.Request) (*pagination.Page[User], error) {
+	countQuery := `select count(*) from users_`
+
+	q := userSortable.Query(page, 1)
 
-	rows, err := u.db.Query(context.Background(), query)
+	query := `select id_, username_, email_, role_, ` + q.Cursor + ` from users_ where ` + q.Where + ` order by ` + q.OrderBy + ` ` + q.Limit
+
+	var total int
+
+	if err := u.db.QueryRow(context.Background(), countQuery).Scan(&total); err != nil {
+		return nil, err
+	}
+
+	rows, err := u.db.Query(context.Background(), query, q.Args...)
 	if err != nil {
 		return nil, err
 	}
@@ -81,18 +156,23 @@ func (u userPostgresRepository) GetAll() (*[]User, error) {
 	defer rows.Close()
 
 	var users []User
+	var keys []pagination.Key
 
 	for rows.Next() {
 		var user User
+		var key pagination.Key
 
-		if err := rows.Scan(&user.Id, &user.Username, &user.Email); err != nil {
+		if err := rows.Scan(&user.Id, &user.Username, &user.Email, &user.Role, &key.Value); err != nil {
 			return nil, err
 		}
 
+		key.Id = int64(user.Id)
+
 		users = append(users, user)
+		keys = append(keys, key)
 	}
 
-	return &users, nil
+	return pagination.NewPage(q, users, keys, total), nil
 }
 
 func (u userPostgresRepository) GetByAttribute(attr, value string) (*User, error) {
@@ -100,7 +180,7 @@ func (u userPostgresRepository) GetByAttribute(attr, value string) (*User, error
 
 	switch attr {
 	case "username":
-		query = `select id_, username_, email_ from users_ where username_ = $1`
+		query = `select id_, username_, email_, role_, display_name_, bio_, avatar_url_, session_version_, totp_enabled_ from users_ where username_ = $1`
 	default:
 		err := errors.New("attribute not supported")
 		return nil, err
@@ -110,7 +190,17 @@ func (u userPostgresRepository) GetByAttribute(attr, value string) (*User, error
 
 	var user User
 
-	if err := row.Scan(&user.Id, &user.Username, &user.Email); err != nil {
+	if err := row.Scan(
+		&user.Id,
+		&user.Username,
+		&user.Email,
+		&user.Role,
+		&user.Profile.DisplayName,
+		&user.Profile.Bio,
+		&user.Profile.AvatarUrl,
+		&user.SessionVersion,
+		&user.TwoFactorEnabled,
+	); err != nil {
 		return nil, err
 	}
 
@@ -131,6 +221,134 @@ func (u userPostgresRepository) GetPasswordByUsername(username string) (string,
 	return password, nil
 }
 
+// Update saves the user, unless it would take the admin role from the last
+// admin.
 func (u userPostgresRepository) Update(user *User) (*User, error) {
-	return nil, nil
+	query := `update users_ set username_ = $2, email_ = $3, role_ = $4, display_name_ = $5, bio_ = $6, avatar_url_ = $7 where id_ = $1 returning id_, username_, email_, role_, display_name_, bio_, avatar_url_`
+
+	tx, err := u.db.Begin(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	// does nothing once the transaction's committed
+	defer tx.Rollback(context.Background())
+
+	if user.Role != RoleAdmin {
+		if err := guardLastAdmin(tx, user.Id); err != nil {
+			return nil, err
+		}
+	}
+
+	row := tx.QueryRow(
+		context.Background(),
+		query,
+		user.Id,
+		user.Username,
+		user.Email,
+		user.Role,
+		user.Profile.DisplayName,
+		user.Profile.Bio,
+		user.Profile.AvatarUrl,
+	)
+
+	var updatedUser User
+
+	if err := row.Scan(
+		&updatedUser.Id,
+		&updatedUser.Username,
+		&updatedUser.Email,
+		&updatedUser.Role,
+		&updatedUser.Profile.DisplayName,
+		&updatedUser.Profile.Bio,
+		&updatedUser.Profile.AvatarUrl,
+	); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(context.Background()); err != nil {
+		return nil, err
+	}
+
+	return &updatedUser, nil
+}
+
+// UpdateRole changes the role of the user with id, unless it would take the
+// admin role from the last admin.
+func (u userPostgresRepository) UpdateRole(id uint, role Role) (*User, error) {
+	query := `update users_ set role_ = $2 where id_ = $1 returning id_, username_, email_, role_, display_name_, bio_, avatar_url_`
+
+	tx, err := u.db.Begin(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	// does nothing once the transaction's committed
+	defer tx.Rollback(context.Background())
+
+	if role != RoleAdmin {
+		if err := guardLastAdmin(tx, id); err != nil {
+			return nil, err
+		}
+	}
+
+	row := tx.QueryRow(context.Background(), query, id, role)
+
+	var updatedUser User
+
+	if err := row.Scan(
+		&updatedUser.Id,
+		&updatedUser.Username,
+		&updatedUser.Email,
+		&updatedUser.Role,
+		&updatedUser.Profile.DisplayName,
+		&updatedUser.Profile.Bio,
+		&updatedUser.Profile.AvatarUrl,
+	); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(context.Background()); err != nil {
+		return nil, err
+	}
+
+	return &updatedUser, nil
+}
+
+// UpdatePassword sets the hashed password for username and bumps their
+// session version, returning the new version.
+func (u userPostgresRepository) UpdatePassword(username, password string) (int, error) {
+	query := `update users_ set password_ = $2, session_version_ = session_version_ + 1 where username_ = $1 returning session_version_`
+
+	row := u.db.QueryRow(context.Background(), query, username, password)
+
+	var sessionVersion int
+
+	if err := row.Scan(&sessionVersion); err != nil {
+		return 0, err
+	}
+
+	return sessionVersion, nil
+}
+
+func (u userPostgresRepository) UpdateProfile(id uint, profile *Profile) (*User, error) {
+	query := `update users_ set display_name_ = $2, bio_ = $3, avatar_url_ = $4 where id_ = $1 returning id_, username_, email_, role_, display_name_, bio_, avatar_url_`
+
+	row := u.db.QueryRow(context.Background(), query, id, profile.DisplayName, profile.Bio, profile.AvatarUrl)
+
+	var user User
+
+	if err := row.Scan(
+		&user.Id,
+		&user.Username,
+		&user.Email,
+		&user.Role,
+		&user.Profile.DisplayName,
+		&user.Profile.Bio,
+		&user.Profile.AvatarUrl,
+	); err != nil {
+		return nil, err
+	}
+
+	return &user, nil
 }
@@ -5,10 +5,13 @@ import (
 	"regexp"
 	"testing"
 
+	"github.com/nixpig/dunce/pkg/pagination"
 	"github.com/pashagolub/pgxmock/v3"
 	"github.com/stretchr/testify/require"
 )
 
+const adminsQuery = `select id_ from users_ where role_ = 'admin' for update`
+
 func TestUserRepo(t *testing.T) {
 	scenarios := map[string]func(t *testing.T, mock pgxmock.PgxPoolIface, repo UserRepository){
 		"create user (success)":                              testUserRepoCreate,
@@ -16,6 +19,7 @@ func TestUserRepo(t *testing.T) {
 		"delete user (success)":                              testUserRepoDelete,
 		"delete user (error - db error)":                     testUserRepoDeleteDbError,
 		"delete user (error - zero rows)":                    testUserRepoDeleteNoRows,
+		"delete user (error - last admin)":                   testUserRepoDeleteLastAdmin,
 		"user exists (true)":                                 testUserRepoUserExists,
 		"user exists (false)":                                testUserRepoUserNotExists,
 		"user exists (false - db error)":                     testUserRepoExistsDbError,
@@ -27,6 +31,16 @@ func TestUserRepo(t *testing.T) {
 		"get user by attribute - username (error - db scan)": testUserRepoGetByAttributeDbError,
 		"get password by username (success)":                 testUserRepoGetPasswordByUsername,
 		"get password by username (error - db error)":        testUserRepoGetPasswordByUsernameDbError,
+		"update profile (success)":                           testUserRepoUpdateProfile,
+		"update user (success)":                              testUserRepoUpdate,
+		"update user (error - db error)":                     testUserRepoUpdateDbError,
+		"update user (error - last admin)":                   testUserRepoUpdateLastAdmin,
+		"update role (success)":                              testUserRepoUpdateRole,
+		"update role (success - to admin)":                   testUserRepoUpdateRoleToAdmin,
+		"update role (error - last admin)":                   testUserRepoUpdateRoleLastAdmin,
+		"update password (success)":                          testUserRepoUpdatePassword,
+		"update password (error - db error)":                 testUserRepoUpdatePasswordDbError,
+		"update profile (error - db error)":                  testUserRepoUpdateProfileDbError,
 	}
 
 	for scenario, fn := range scenarios {
@@ -46,21 +60,22 @@ func TestUserRepo(t *testing.T) {
 }
 
 func testUserRepoCreate(t *testing.T, mock pgxmock.PgxPoolIface, repo UserRepository) {
-	query := `insert into users_ (username_, email_, password_) values ($1, $2, $3) returning id_, username_, email_`
+	query := `insert into users_ (username_, email_, password_, role_) values ($1, $2, $3, $4) returning id_, username_, email_, role_`
 
 	mockRow := mock.
-		NewRows([]string{"id_", "username_", "email_"}).
-		AddRow(uint(23), "janedoe", "jane@example.org")
+		NewRows([]string{"id_", "username_", "email_", "role_"}).
+		AddRow(uint(23), "janedoe", "jane@example.org", RoleAuthor)
 
 	mock.
 		ExpectQuery(regexp.QuoteMeta(query)).
-		WithArgs("janedoe", "jane@example.org", "p4ssw0rd").
+		WithArgs("janedoe", "jane@example.org", "p4ssw0rd", RoleAuthor).
 		WillReturnRows(mockRow)
 
 	createdUser, err := repo.Create(&User{
 		Username: "janedoe",
 		Email:    "jane@example.org",
 		Password: "p4ssw0rd",
+		Role:     RoleAuthor,
 	})
 
 	if err := mock.ExpectationsWereMet(); err != nil {
@@ -72,22 +87,24 @@ func testUserRepoCreate(t *testing.T, mock pgxmock.PgxPoolIface, repo UserReposi
 		Id:       uint(23),
 		Username: "janedoe",
 		Email:    "jane@example.org",
+		Role:     RoleAuthor,
 	}, createdUser, "should return created user details")
 
 }
 
 func testUserRepoCreateError(t *testing.T, mock pgxmock.PgxPoolIface, repo UserRepository) {
-	query := `insert into users_ (username_, email_, password_) values ($1, $2, $3) returning id_, username_, email_`
+	query := `insert into users_ (username_, email_, password_, role_) values ($1, $2, $3, $4) returning id_, username_, email_, role_`
 
 	mock.
 		ExpectQuery(regexp.QuoteMeta(query)).
-		WithArgs("janedoe", "jane@example.com", "p4ssw0rd").
+		WithArgs("janedoe", "jane@example.com", "p4ssw0rd", RoleViewer).
 		WillReturnError(errors.New("db_error"))
 
 	user, err := repo.Create(&User{
 		Username: "janedoe",
 		Email:    "jane@example.com",
 		Password: "p4ssw0rd",
+		Role:     RoleViewer,
 	})
 
 	if err := mock.ExpectationsWereMet(); err != nil {
@@ -106,11 +123,19 @@ func testUserRepoDelete(t *testing.T, mock pgxmock.PgxPoolIface, repo UserReposi
 
 	var id uint = 23
 
+	mock.ExpectBegin()
+
+	mock.
+		ExpectQuery(regexp.QuoteMeta(adminsQuery)).
+		WillReturnRows(mock.NewRows([]string{"id_"}).AddRow(uint(1)).AddRow(uint(2)))
+
 	mock.
 		ExpectExec(regexp.QuoteMeta(query)).
 		WithArgs(id).
 		WillReturnResult(pgxmock.NewResult("delete", 1))
 
+	mock.ExpectCommit()
+
 	err := repo.DeleteById(id)
 
 	require.Nil(t, err, "should not return error")
@@ -127,11 +152,19 @@ func testUserRepoDeleteDbError(t *testing.T, mock pgxmock.PgxPoolIface, repo Use
 
 	var id uint = 69
 
+	mock.ExpectBegin()
+
+	mock.
+		ExpectQuery(regexp.QuoteMeta(adminsQuery)).
+		WillReturnRows(mock.NewRows([]string{"id_"}).AddRow(uint(1)).AddRow(uint(2)))
+
 	mock.
 		ExpectExec(regexp.QuoteMeta(query)).
 		WithArgs(id).
 		WillReturnError(errors.New("db_error"))
 
+	mock.ExpectRollback()
+
 	err := repo.DeleteById(id)
 
 	require.EqualError(t, err, "db_error", "should return db error")
@@ -148,11 +181,19 @@ func testUserRepoDeleteNoRows(t *testing.T, mock pgxmock.PgxPoolIface, repo User
 
 	var id uint = 69
 
+	mock.ExpectBegin()
+
+	mock.
+		ExpectQuery(regexp.QuoteMeta(adminsQuery)).
+		WillReturnRows(mock.NewRows([]string{"id_"}).AddRow(uint(1)).AddRow(uint(2)))
+
 	mock.
 		ExpectExec(regexp.QuoteMeta(query)).
 		WithArgs(id).
 		WillReturnResult(pgxmock.NewResult("delete", 0))
 
+	mock.ExpectRollback()
+
 	err := repo.DeleteById(id)
 
 	require.Error(t, err, "should return zero rows error")
@@ -227,31 +268,40 @@ func testUserRepoExistsDbError(t *testing.T, mock pgxmock.PgxPoolIface, repo Use
 }
 
 func testUserRepoGetAll(t *testing.T, mock pgxmock.PgxPoolIface, repo UserRepository) {
-	query := `select id_, username_, email_ from users_`
+	countQuery := `select count(*) from users_`
+	query := `select id_, username_, email_, role_, (username_)::text from users_ where true order by username_ asc, id_ asc limit $1 offset $2`
+
+	mock.ExpectQuery(regexp.QuoteMeta(countQuery)).
+		WillReturnRows(mock.NewRows([]string{"count"}).AddRow(2))
 
 	mockRows := mock.
-		NewRows([]string{"id_", "username_", "email_"}).
-		AddRow(uint(23), "janedoe", "jane@example.org").
-		AddRow(uint(42), "johndoe", "john@example.com")
+		NewRows([]string{"id_", "username_", "email_", "role_", "cursor"}).
+		AddRow(uint(23), "janedoe", "jane@example.org", RoleAdmin, "janedoe").
+		AddRow(uint(42), "johndoe", "john@example.com", RoleEditor, "johndoe")
 
-	mock.ExpectQuery(regexp.QuoteMeta(query)).WillReturnRows(mockRows)
+	mock.ExpectQuery(regexp.QuoteMeta(query)).
+		WithArgs(pagination.DefaultLimit+1, 0).
+		WillReturnRows(mockRows)
 
-	users, err := repo.GetAll()
+	users, err := repo.GetAll(pagination.Request{})
 
 	require.Nil(t, err, "should not return error")
 
-	require.Equal(t, &[]User{
+	require.Equal(t, []User{
 		{
 			Id:       uint(23),
 			Username: "janedoe",
 			Email:    "jane@example.org",
+			Role:     RoleAdmin,
 		},
 		{
 			Id:       uint(42),
 			Username: "johndoe",
 			Email:    "john@example.com",
+			Role:     RoleEditor,
 		},
-	}, users, "should return user list")
+	}, users.Items, "should return user list")
+	require.Equal(t, 2, users.Total, "should return total")
 
 	if err := mock.ExpectationsWereMet(); err != nil {
 		t.Fatal("expectations not met: ", err)
@@ -261,11 +311,17 @@ func testUserRepoGetAll(t *testing.T, mock pgxmock.PgxPoolIface, repo UserReposi
 }
 
 func testUserRepoGetAllDbError(t *testing.T, mock pgxmock.PgxPoolIface, repo UserRepository) {
-	query := `select id_, username_, email_ from users_`
+	countQuery := `select count(*) from users_`
+	query := `select id_, username_, email_, role_, (username_)::text from users_ where true order by username_ asc, id_ asc limit $1 offset $2`
+
+	mock.ExpectQuery(regexp.QuoteMeta(countQuery)).
+		WillReturnRows(mock.NewRows([]string{"count"}).AddRow(2))
 
-	mock.ExpectQuery(regexp.QuoteMeta(query)).WillReturnError(errors.New("db_error"))
+	mock.ExpectQuery(regexp.QuoteMeta(query)).
+		WithArgs(pagination.DefaultLimit+1, 0).
+		WillReturnError(errors.New("db_error"))
 
-	users, err := repo.GetAll()
+	users, err := repo.GetAll(pagination.Request{})
 
 	require.Nil(t, users, "should not return users")
 
@@ -280,15 +336,21 @@ func testUserRepoGetAllDbError(t *testing.T, mock pgxmock.PgxPoolIface, repo Use
 }
 
 func testUserRepoGetAllScanError(t *testing.T, mock pgxmock.PgxPoolIface, repo UserRepository) {
-	query := `select id_, username_, email_ from users_`
+	countQuery := `select count(*) from users_`
+	query := `select id_, username_, email_, role_, (username_)::text from users_ where true order by username_ asc, id_ asc limit $1 offset $2`
+
+	mock.ExpectQuery(regexp.QuoteMeta(countQuery)).
+		WillReturnRows(mock.NewRows([]string{"count"}).AddRow(2))
 
 	mockRows := mock.
-		NewRows([]string{"id_", "username_", "email_"}).
-		AddRow(uint(23), false, 69)
+		NewRows([]string{"id_", "username_", "email_", "role_", "cursor"}).
+		AddRow(uint(23), false, 69, RoleAdmin, "janedoe")
 
-	mock.ExpectQuery(regexp.QuoteMeta(query)).WillReturnRows(mockRows)
+	mock.ExpectQuery(regexp.QuoteMeta(query)).
+		WithArgs(pagination.DefaultLimit+1, 0).
+		WillReturnRows(mockRows)
 
-	users, err := repo.GetAll()
+	users, err := repo.GetAll(pagination.Request{})
 
 	require.Error(t, err, "should return scan error")
 
@@ -302,11 +364,11 @@ func testUserRepoGetAllScanError(t *testing.T, mock pgxmock.PgxPoolIface, repo U
 }
 
 func testUserRepoGetByUsername(t *testing.T, mock pgxmock.PgxPoolIface, repo UserRepository) {
-	query := `select id_, username_, email_ from users_ where username_ = $1`
+	query := `select id_, username_, email_, role_, display_name_, bio_, avatar_url_, session_version_, totp_enabled_ from users_ where username_ = $1`
 
 	mockRows := mock.
-		NewRows([]string{"id_", "username_", "email_"}).
-		AddRow(uint(23), "janedoe", "jane@example.org")
+		NewRows([]string{"id_", "username_", "email_", "role_", "display_name_", "bio_", "avatar_url_", "session_version_", "totp_enabled_"}).
+		AddRow(uint(23), "janedoe", "jane@example.org", RoleViewer, "Jane Doe", "Bio", "", 2, true)
 
 	mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs("janedoe").WillReturnRows(mockRows)
 
@@ -318,6 +380,13 @@ func testUserRepoGetByUsername(t *testing.T, mock pgxmock.PgxPoolIface, repo Use
 		Id:       23,
 		Username: "janedoe",
 		Email:    "jane@example.org",
+		Role:     RoleViewer,
+		Profile: Profile{
+			DisplayName: "Jane Doe",
+			Bio:         "Bio",
+		},
+		SessionVersion:   2,
+		TwoFactorEnabled: true,
 	}, user, "should return matching user")
 
 	if err := mock.ExpectationsWereMet(); err != nil {
@@ -335,7 +404,7 @@ func testUserRepoGetByUnknownAttribute(t *testing.T, mock pgxmock.PgxPoolIface,
 }
 
 func testUserRepoGetByAttributeDbError(t *testing.T, mock pgxmock.PgxPoolIface, repo UserRepository) {
-	query := `select id_, username_, email_ from users_ where username_ = $1`
+	query := `select id_, username_, email_, role_, display_name_, bio_, avatar_url_, session_version_, totp_enabled_ from users_ where username_ = $1`
 
 	mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs("janedoe").WillReturnError(errors.New("db_error"))
 
@@ -387,3 +456,299 @@ func testUserRepoGetPasswordByUsernameDbError(t *testing.T, mock pgxmock.PgxPool
 	mock.Reset()
 
 }
+
+func testUserRepoUpdateProfile(t *testing.T, mock pgxmock.PgxPoolIface, repo UserRepository) {
+	query := `update users_ set display_name_ = $2, bio_ = $3, avatar_url_ = $4 where id_ = $1 returning id_, username_, email_, role_, display_name_, bio_, avatar_url_`
+
+	mockRow := mock.
+		NewRows([]string{"id_", "username_", "email_", "role_", "display_name_", "bio_", "avatar_url_"}).
+		AddRow(uint(23), "janedoe", "jane@example.org", RoleAuthor, "Jane Doe", "Bio", "https://example.org/jane.png")
+
+	mock.
+		ExpectQuery(regexp.QuoteMeta(query)).
+		WithArgs(uint(23), "Jane Doe", "Bio", "https://example.org/jane.png").
+		WillReturnRows(mockRow)
+
+	user, err := repo.UpdateProfile(23, &Profile{
+		DisplayName: "Jane Doe",
+		Bio:         "Bio",
+		AvatarUrl:   "https://example.org/jane.png",
+	})
+
+	require.NoError(t, err, "should not return error")
+	require.Equal(t, &User{
+		Id:       23,
+		Username: "janedoe",
+		Email:    "jane@example.org",
+		Role:     RoleAuthor,
+		Profile: Profile{
+			DisplayName: "Jane Doe",
+			Bio:         "Bio",
+			AvatarUrl:   "https://example.org/jane.png",
+		},
+	}, user, "should return user with updated profile")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal("expectations not met: ", err)
+	}
+}
+
+func testUserRepoUpdateProfileDbError(t *testing.T, mock pgxmock.PgxPoolIface, repo UserRepository) {
+	query := `update users_ set display_name_ = $2, bio_ = $3, avatar_url_ = $4 where id_ = $1 returning id_, username_, email_, role_, display_name_, bio_, avatar_url_`
+
+	mock.
+		ExpectQuery(regexp.QuoteMeta(query)).
+		WithArgs(uint(23), "", "", "").
+		WillReturnError(errors.New("db_error"))
+
+	user, err := repo.UpdateProfile(23, &Profile{})
+
+	require.EqualError(t, err, "db_error", "should return db error")
+	require.Nil(t, user, "should not return user")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal("expectations not met: ", err)
+	}
+}
+
+func testUserRepoUpdate(t *testing.T, mock pgxmock.PgxPoolIface, repo UserRepository) {
+	query := `update users_ set username_ = $2, email_ = $3, role_ = $4, display_name_ = $5, bio_ = $6, avatar_url_ = $7 where id_ = $1 returning id_, username_, email_, role_, display_name_, bio_, avatar_url_`
+
+	mockRow := mock.
+		NewRows([]string{"id_", "username_", "email_", "role_", "display_name_", "bio_", "avatar_url_"}).
+		AddRow(uint(23), "janedoe", "jane@example.net", RoleAuthor, "Jane Doe", "Bio", "")
+
+	mock.ExpectBegin()
+
+	mock.
+		ExpectQuery(regexp.QuoteMeta(adminsQuery)).
+		WillReturnRows(mock.NewRows([]string{"id_"}).AddRow(uint(1)).AddRow(uint(2)))
+
+	mock.
+		ExpectQuery(regexp.QuoteMeta(query)).
+		WithArgs(uint(23), "janedoe", "jane@example.net", RoleAuthor, "Jane Doe", "Bio", "").
+		WillReturnRows(mockRow)
+
+	mock.ExpectCommit()
+
+	user, err := repo.Update(&User{
+		Id:       23,
+		Username: "janedoe",
+		Email:    "jane@example.net",
+		Role:     RoleAuthor,
+		Profile: Profile{
+			DisplayName: "Jane Doe",
+			Bio:         "Bio",
+		},
+	})
+
+	require.NoError(t, err, "should not return error")
+	require.Equal(t, &User{
+		Id:       23,
+		Username: "janedoe",
+		Email:    "jane@example.net",
+		Role:     RoleAuthor,
+		Profile: Profile{
+			DisplayName: "Jane Doe",
+			Bio:         "Bio",
+		},
+	}, user, "should return updated user")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal("expectations not met: ", err)
+	}
+}
+
+func testUserRepoUpdateDbError(t *testing.T, mock pgxmock.PgxPoolIface, repo UserRepository) {
+	query := `update users_ set username_ = $2, email_ = $3, role_ = $4, display_name_ = $5, bio_ = $6, avatar_url_ = $7 where id_ = $1 returning id_, username_, email_, role_, display_name_, bio_, avatar_url_`
+
+	mock.ExpectBegin()
+
+	mock.
+		ExpectQuery(regexp.QuoteMeta(adminsQuery)).
+		WillReturnRows(mock.NewRows([]string{"id_"}).AddRow(uint(1)).AddRow(uint(2)))
+
+	mock.
+		ExpectQuery(regexp.QuoteMeta(query)).
+		WithArgs(uint(23), "janedoe", "jane@example.net", RoleAuthor, "", "", "").
+		WillReturnError(errors.New("db_error"))
+
+	mock.ExpectRollback()
+
+	user, err := repo.Update(&User{
+		Id:       23,
+		Username: "janedoe",
+		Email:    "jane@example.net",
+		Role:     RoleAuthor,
+	})
+
+	require.EqualError(t, err, "db_error", "should return db error")
+	require.Nil(t, user, "should not return user")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal("expectations not met: ", err)
+	}
+}
+
+func testUserRepoUpdatePassword(t *testing.T, mock pgxmock.PgxPoolIface, repo UserRepository) {
+	query := `update users_ set password_ = $2, session_version_ = session_version_ + 1 where username_ = $1 returning session_version_`
+
+	mock.
+		ExpectQuery(regexp.QuoteMeta(query)).
+		WithArgs("janedoe", "h4shedp4ssw0rd").
+		WillReturnRows(mock.NewRows([]string{"session_version_"}).AddRow(4))
+
+	sessionVersion, err := repo.UpdatePassword("janedoe", "h4shedp4ssw0rd")
+
+	require.NoError(t, err, "should not return error")
+	require.Equal(t, 4, sessionVersion, "should return bumped session version")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal("expectations not met: ", err)
+	}
+}
+
+func testUserRepoUpdatePasswordDbError(t *testing.T, mock pgxmock.PgxPoolIface, repo UserRepository) {
+	query := `update users_ set password_ = $2, session_version_ = session_version_ + 1 where username_ = $1 returning session_version_`
+
+	mock.
+		ExpectQuery(regexp.QuoteMeta(query)).
+		WithArgs("janedoe", "h4shedp4ssw0rd").
+		WillReturnError(errors.New("db_error"))
+
+	sessionVersion, err := repo.UpdatePassword("janedoe", "h4shedp4ssw0rd")
+
+	require.EqualError(t, err, "db_error", "should return db error")
+	require.Zero(t, sessionVersion, "should not return a session version")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal("expectations not met: ", err)
+	}
+}
+
+func testUserRepoDeleteLastAdmin(t *testing.T, mock pgxmock.PgxPoolIface, repo UserRepository) {
+	mock.ExpectBegin()
+
+	mock.
+		ExpectQuery(regexp.QuoteMeta(adminsQuery)).
+		WillReturnRows(mock.NewRows([]string{"id_"}).AddRow(uint(23)))
+
+	mock.ExpectRollback()
+
+	err := repo.DeleteById(23)
+
+	require.ErrorIs(t, err, ErrLastAdmin, "should not delete last admin")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal("expectations not met: ", err)
+	}
+}
+
+func testUserRepoUpdateLastAdmin(t *testing.T, mock pgxmock.PgxPoolIface, repo UserRepository) {
+	mock.ExpectBegin()
+
+	mock.
+		ExpectQuery(regexp.QuoteMeta(adminsQuery)).
+		WillReturnRows(mock.NewRows([]string{"id_"}).AddRow(uint(23)))
+
+	mock.ExpectRollback()
+
+	user, err := repo.Update(&User{
+		Id:       23,
+		Username: "janedoe",
+		Email:    "jane@example.net",
+		Role:     RoleEditor,
+	})
+
+	require.ErrorIs(t, err, ErrLastAdmin, "should not take admin role from last admin")
+	require.Nil(t, user, "should not return user")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal("expectations not met: ", err)
+	}
+}
+
+func testUserRepoUpdateRole(t *testing.T, mock pgxmock.PgxPoolIface, repo UserRepository) {
+	query := `update users_ set role_ = $2 where id_ = $1 returning id_, username_, email_, role_, display_name_, bio_, avatar_url_`
+
+	mockRow := mock.
+		NewRows([]string{"id_", "username_", "email_", "role_", "display_name_", "bio_", "avatar_url_"}).
+		AddRow(uint(2), "janedoe", "jane@example.net", RoleEditor, "Jane Doe", "", "")
+
+	mock.ExpectBegin()
+
+	mock.
+		ExpectQuery(regexp.QuoteMeta(adminsQuery)).
+		WillReturnRows(mock.NewRows([]string{"id_"}).AddRow(uint(1)).AddRow(uint(2)))
+
+	mock.
+		ExpectQuery(regexp.QuoteMeta(query)).
+		WithArgs(uint(2), RoleEditor).
+		WillReturnRows(mockRow)
+
+	mock.ExpectCommit()
+
+	user, err := repo.UpdateRole(2, RoleEditor)
+
+	require.NoError(t, err, "should not return error")
+	require.Equal(t, &User{
+		Id:       2,
+		Username: "janedoe",
+		Email:    "jane@example.net",
+		Role:     RoleEditor,
+		Profile: Profile{
+			DisplayName: "Jane Doe",
+		},
+	}, user, "should return updated user")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal("expectations not met: ", err)
+	}
+}
+
+func testUserRepoUpdateRoleToAdmin(t *testing.T, mock pgxmock.PgxPoolIface, repo UserRepository) {
+	query := `update users_ set role_ = $2 where id_ = $1 returning id_, username_, email_, role_, display_name_, bio_, avatar_url_`
+
+	mockRow := mock.
+		NewRows([]string{"id_", "username_", "email_", "role_", "display_name_", "bio_", "avatar_url_"}).
+		AddRow(uint(2), "janedoe", "jane@example.net", RoleAdmin, "", "", "")
+
+	// making someone an admin can't leave the site without one, so admins
+	// aren't checked
+	mock.ExpectBegin()
+
+	mock.
+		ExpectQuery(regexp.QuoteMeta(query)).
+		WithArgs(uint(2), RoleAdmin).
+		WillReturnRows(mockRow)
+
+	mock.ExpectCommit()
+
+	user, err := repo.UpdateRole(2, RoleAdmin)
+
+	require.NoError(t, err, "should not return error")
+	require.Equal(t, RoleAdmin, user.Role, "should return updated user")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal("expectations not met: ", err)
+	}
+}
+
+func testUserRepoUpdateRoleLastAdmin(t *testing.T, mock pgxmock.PgxPoolIface, repo UserRepository) {
+	mock.ExpectBegin()
+
+	mock.
+		ExpectQuery(regexp.QuoteMeta(adminsQuery)).
+		WillReturnRows(mock.NewRows([]string{"id_"}).AddRow(uint(1)))
+
+	mock.ExpectRollback()
+
+	user, err := repo.UpdateRole(1, RoleViewer)
+
+	require.ErrorIs(t, err, ErrLastAdmin, "should not take admin role from last admin")
+	require.Nil(t, user, "should not return user")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal("expectations not met: ", err)
+	}
+}
@@ -1,17 +1,36 @@
 package user
 
 import (
+	"errors"
+	"fmt"
+
 	"github.com/go-playground/validator/v10"
+	"github.com/jackc/pgx/v5"
 	"github.com/nixpig/dunce/pkg/crypto"
+	"github.com/nixpig/dunce/pkg/metrics"
+	"github.com/nixpig/dunce/pkg/pagination"
 )
 
+// dummyPasswordHash is a bcrypt hash for comparing against when there's no
+// user. It's given the same cost as real passwords by dummyPasswordHashAt.
+const dummyPasswordHash = "$2a$14$llO.4wiMkisOX2z10i/uhOZzvlz.e.//XOBb4oMYHT9ABoh1wqRni"
+
+// dummyPasswordHashAt is the dummy hash with its cost set to cost, so it takes
+// as long to compare against as real passwords. It never matches anything.
+func dummyPasswordHashAt(cost int) string {
+	return fmt.Sprintf("$2a$%02d$%s", cost, dummyPasswordHash[7:])
+}
+
 type UserService interface {
 	Create(user *UserNewRequestDto) (*UserResponseDto, error)
 	DeleteById(id uint) error
 	Exists(username string) (bool, error)
-	GetAll() (*[]UserResponseDto, error)
+	GetAll(page pagination.Request) (*pagination.Page[UserResponseDto], error)
 	GetByAttribute(attr, value string) (*UserResponseDto, error)
 	Update(user *User) (*UserResponseDto, error)
+	UpdateRole(id uint, role Role) (*UserResponseDto, error)
+	UpdateProfile(id uint, profile *Profile) (*UserResponseDto, error)
+	ChangePassword(username string, change *UserPasswordChangeRequestDto) (int, error)
 	LoginWithUsernamePassword(username, password string) error
 }
 
@@ -19,22 +38,27 @@ type UserServiceImpl struct {
 	repo     UserRepository
 	validate *validator.Validate
 	crypto   crypto.Crypto
+	cost     int
 }
 
+// NewUserService creates the service, hashing passwords at the given bcrypt
+// cost.
 func NewUserService(
 	repo UserRepository,
 	validate *validator.Validate,
 	crypto crypto.Crypto,
+	cost int,
 ) UserServiceImpl {
 	return UserServiceImpl{
 		repo:     repo,
 		validate: validate,
 		crypto:   crypto,
+		cost:     cost,
 	}
 }
 
 func (u UserServiceImpl) Create(user *UserNewRequestDto) (*UserResponseDto, error) {
-	hashedPassword, err := u.crypto.GenerateFromPassword([]byte(user.Password), 14)
+	hashedPassword, err := u.crypto.GenerateFromPassword([]byte(user.Password), u.cost)
 	if err != nil {
 		return nil, err
 	}
@@ -43,6 +67,7 @@ func (u UserServiceImpl) Create(user *UserNewRequestDto) (*UserResponseDto, erro
 		Username: user.Username,
 		Email:    user.Email,
 		Password: string(hashedPassword),
+		Role:     user.Role,
 	}
 
 	if err := u.validate.Struct(userToCreate); err != nil {
@@ -58,26 +83,24 @@ func (u UserServiceImpl) Create(user *UserNewRequestDto) (*UserResponseDto, erro
 		Id:       createdUser.Id,
 		Username: createdUser.Username,
 		Email:    createdUser.Email,
+		Role:     createdUser.Role,
 	}, nil
 }
 
-func (u UserServiceImpl) GetAll() (*[]UserResponseDto, error) {
-	users, err := u.repo.GetAll()
+func (u UserServiceImpl) GetAll(page pagination.Request) (*pagination.Page[UserResponseDto], error) {
+	users, err := u.repo.GetAll(page)
 	if err != nil {
 		return nil, err
 	}
 
-	allUsers := make([]UserResponseDto, len(*users))
-
-	for index, user := range *users {
-		allUsers[index] = UserResponseDto{
+	return pagination.Map(users, func(user User) UserResponseDto {
+		return UserResponseDto{
 			Id:       user.Id,
 			Username: user.Username,
 			Email:    user.Email,
+			Role:     user.Role,
 		}
-	}
-
-	return &allUsers, nil
+	}), nil
 }
 
 func (u UserServiceImpl) GetByAttribute(attr, value string) (*UserResponseDto, error) {
@@ -86,19 +109,47 @@ func (u UserServiceImpl) GetByAttribute(attr, value string) (*UserResponseDto, e
 		return nil, err
 	}
 
+	return &UserResponseDto{
+		Id:               user.Id,
+		Username:         user.Username,
+		Email:            user.Email,
+		Role:             user.Role,
+		Profile:          user.Profile,
+		SessionVersion:   user.SessionVersion,
+		TwoFactorEnabled: user.TwoFactorEnabled,
+	}, nil
+}
+
+// Update saves everything about the user except their password, which can
+// only be changed with ChangePassword. ErrLastAdmin is returned if it would
+// take the admin role from the only admin.
+func (u UserServiceImpl) Update(user *User) (*UserResponseDto, error) {
+	if err := u.validate.StructExcept(user, "Password"); err != nil {
+		return nil, err
+	}
+
+	user, err := u.repo.Update(user)
+	if err != nil {
+		return nil, err
+	}
+
 	return &UserResponseDto{
 		Id:       user.Id,
 		Username: user.Username,
 		Email:    user.Email,
+		Role:     user.Role,
+		Profile:  user.Profile,
 	}, nil
 }
 
-func (u UserServiceImpl) Update(user *User) (*UserResponseDto, error) {
-	if err := u.validate.Struct(user); err != nil {
+// UpdateRole changes what the user with id can do. ErrLastAdmin is returned
+// if they're the only admin and role isn't admin.
+func (u UserServiceImpl) UpdateRole(id uint, role Role) (*UserResponseDto, error) {
+	if err := u.validate.Var(role, "required,oneof=admin editor author viewer"); err != nil {
 		return nil, err
 	}
 
-	user, err := u.repo.Update(user)
+	user, err := u.repo.UpdateRole(id, role)
 	if err != nil {
 		return nil, err
 	}
@@ -107,23 +158,90 @@ func (u UserServiceImpl) Update(user *User) (*UserResponseDto, error) {
 		Id:       user.Id,
 		Username: user.Username,
 		Email:    user.Email,
+		Role:     user.Role,
+		Profile:  user.Profile,
 	}, nil
 }
 
+// ChangePassword replaces the password of username once their current
+// password is confirmed, returning the new session version so the caller can
+// keep its own session valid.
+func (u UserServiceImpl) ChangePassword(
+	username string,
+	change *UserPasswordChangeRequestDto,
+) (int, error) {
+	if err := u.validate.Struct(change); err != nil {
+		return 0, err
+	}
+
+	currentPassword, err := u.repo.GetPasswordByUsername(username)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := u.crypto.CompareHashAndPassword(
+		[]byte(currentPassword),
+		[]byte(change.CurrentPassword),
+	); err != nil {
+		return 0, ErrIncorrectPassword
+	}
+
+	hashedPassword, err := u.crypto.GenerateFromPassword([]byte(change.NewPassword), u.cost)
+	if err != nil {
+		return 0, err
+	}
+
+	return u.repo.UpdatePassword(username, string(hashedPassword))
+}
+
+// UpdateProfile changes how the user with id is credited on the public site.
+func (u UserServiceImpl) UpdateProfile(id uint, profile *Profile) (*UserResponseDto, error) {
+	if err := u.validate.Struct(profile); err != nil {
+		return nil, err
+	}
+
+	user, err := u.repo.UpdateProfile(id, profile)
+	if err != nil {
+		return nil, err
+	}
+
+	return &UserResponseDto{
+		Id:       user.Id,
+		Username: user.Username,
+		Email:    user.Email,
+		Role:     user.Role,
+		Profile:  user.Profile,
+	}, nil
+}
+
+// DeleteById deletes the user with id. ErrLastAdmin is returned if they're
+// the only admin.
 func (u UserServiceImpl) DeleteById(id uint) error {
 	return u.repo.DeleteById(id)
 }
 
+// LoginWithUsernamePassword checks password is right for username. Unknown
+// usernames are still compared against a dummy hash, so they take as long as
+// wrong passwords and can't be told apart by timing.
 func (u UserServiceImpl) LoginWithUsernamePassword(username, password string) error {
 	hashedPassword, err := u.repo.GetPasswordByUsername(username)
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			u.crypto.CompareHashAndPassword([]byte(dummyPasswordHashAt(u.cost)), []byte(password))
+			metrics.FailedLogins.Inc()
+			return ErrIncorrectPassword
+		}
+
 		return err
 	}
 
 	if err := u.crypto.CompareHashAndPassword([]byte(hashedPassword), []byte(password)); err != nil {
+		metrics.FailedLogins.Inc()
 		return err
 	}
 
+	metrics.Logins.Inc()
+
 	return nil
 }
 
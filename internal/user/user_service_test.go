@@ -4,9 +4,12 @@ import (
 	"errors"
 	"testing"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/nixpig/dunce/pkg/pagination"
 	"github.com/nixpig/dunce/pkg/validation"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
 )
 
 var mockRepo = new(MockUserRepo)
@@ -32,9 +35,18 @@ func TestUserService(t *testing.T) {
 		"update user (success)":                                         testUserServiceUpdateUser,
 		"update user (error - repo)":                                    testUserServiceUpdateUserRepoError,
 		"update user (error - validation)":                              testUserServiceUpdateUserValidationError,
+		"update profile (success)":                                      testUserServiceUpdateProfile,
+		"update role (success)":                                         testUserServiceUpdateRole,
+		"update role (error - last admin)":                              testUserServiceUpdateRoleLastAdmin,
+		"update role (error - validation)":                              testUserServiceUpdateRoleValidationError,
+		"change password (success)":                                     testUserServiceChangePassword,
+		"change password (error - incorrect password)":                  testUserServiceChangePasswordIncorrect,
+		"change password (error - validation)":                          testUserServiceChangePasswordValidationError,
+		"update profile (error - validation)":                           testUserServiceUpdateProfileValidationError,
 		"login with username and password (success)":                    testUserServiceLoginUsernamePassword,
 		"login with username and password (error - repo)":               testUserServiceLoginUsernamePasswordRepoError,
 		"login with username and password (error - incorrect password)": testUserServiceLoginUsernamePasswordIncorrectPassword,
+		"login with username and password (error - unknown user)":       testUserServiceLoginUsernamePasswordUnknownUser,
 	}
 
 	for scenario, fn := range scenarios {
@@ -44,7 +56,7 @@ func TestUserService(t *testing.T) {
 				t.Fatal("unable to construct validator")
 			}
 
-			service := NewUserService(mockRepo, validator, mockCrypto)
+			service := NewUserService(mockRepo, validator, mockCrypto, 14)
 
 			fn(t, service)
 		})
@@ -89,10 +101,10 @@ func (mu *MockUserRepo) Exists(username string) (bool, error) {
 	return args.Get(0).(bool), args.Error(1)
 }
 
-func (mu *MockUserRepo) GetAll() (*[]User, error) {
-	args := mu.Called()
+func (mu *MockUserRepo) GetAll(page pagination.Request) (*pagination.Page[User], error) {
+	args := mu.Called(page)
 
-	return args.Get(0).(*[]User), args.Error(1)
+	return args.Get(0).(*pagination.Page[User]), args.Error(1)
 }
 
 func (mu *MockUserRepo) GetByAttribute(attr, value string) (*User, error) {
@@ -107,6 +119,24 @@ func (mu *MockUserRepo) Update(user *User) (*User, error) {
 	return args.Get(0).(*User), args.Error(1)
 }
 
+func (mu *MockUserRepo) UpdateRole(id uint, role Role) (*User, error) {
+	args := mu.Called(id, role)
+
+	return args.Get(0).(*User), args.Error(1)
+}
+
+func (mu *MockUserRepo) UpdatePassword(username, password string) (int, error) {
+	args := mu.Called(username, password)
+
+	return args.Int(0), args.Error(1)
+}
+
+func (mu *MockUserRepo) UpdateProfile(id uint, profile *Profile) (*User, error) {
+	args := mu.Called(id, profile)
+
+	return args.Get(0).(*User), args.Error(1)
+}
+
 func (mu *MockUserRepo) GetPasswordByUsername(username string) (string, error) {
 	args := mu.Called(username)
 
@@ -114,7 +144,7 @@ func (mu *MockUserRepo) GetPasswordByUsername(username string) (string, error) {
 }
 
 func testUserServiceGetAllMultiple(t *testing.T, service UserService) {
-	mockRepoGetAll := mockRepo.On("GetAll").Return(&[]User{
+	mockRepoGetAll := mockRepo.On("GetAll", pagination.Request{}).Return(&pagination.Page[User]{Items: []User{
 		{
 			Id:       23,
 			Username: "janedoe",
@@ -125,13 +155,13 @@ func testUserServiceGetAllMultiple(t *testing.T, service UserService) {
 			Username: "johndoe",
 			Email:    "john@example.net",
 		},
-	}, nil)
+	}}, nil)
 
-	users, err := service.GetAll()
+	users, err := service.GetAll(pagination.Request{})
 
 	require.NoError(t, err, "should not return an error")
 
-	require.Equal(t, &[]UserResponseDto{
+	require.Equal(t, []UserResponseDto{
 		{
 			Id:       23,
 			Username: "janedoe",
@@ -142,7 +172,7 @@ func testUserServiceGetAllMultiple(t *testing.T, service UserService) {
 			Username: "johndoe",
 			Email:    "john@example.net",
 		},
-	}, users, "should return users response")
+	}, users.Items, "should return users response")
 
 	if res := mockRepo.AssertExpectations(t); !res {
 		t.Error("expectations not met")
@@ -152,9 +182,10 @@ func testUserServiceGetAllMultiple(t *testing.T, service UserService) {
 }
 
 func testUserServiceRepoError(t *testing.T, service UserService) {
-	mockRepoGetAll := mockRepo.On("GetAll").Return(&[]User{}, errors.New("repo_error"))
+	mockRepoGetAll := mockRepo.On("GetAll", pagination.Request{}).
+		Return((*pagination.Page[User])(nil), errors.New("repo_error"))
 
-	users, err := service.GetAll()
+	users, err := service.GetAll(pagination.Request{})
 
 	require.Nil(t, users, "should not return users")
 
@@ -168,25 +199,25 @@ func testUserServiceRepoError(t *testing.T, service UserService) {
 }
 
 func testUserServiceGetAllSingle(t *testing.T, service UserService) {
-	mockRepoGetAll := mockRepo.On("GetAll").Return(&[]User{
+	mockRepoGetAll := mockRepo.On("GetAll", pagination.Request{}).Return(&pagination.Page[User]{Items: []User{
 		{
 			Id:       23,
 			Username: "janedoe",
 			Email:    "jane@example.org",
 		},
-	}, nil)
+	}}, nil)
 
-	users, err := service.GetAll()
+	users, err := service.GetAll(pagination.Request{})
 
 	require.NoError(t, err, "should not return an error")
 
-	require.Equal(t, &[]UserResponseDto{
+	require.Equal(t, []UserResponseDto{
 		{
 			Id:       23,
 			Username: "janedoe",
 			Email:    "jane@example.org",
 		},
-	}, users, "should return users response")
+	}, users.Items, "should return users response")
 
 	if res := mockRepo.AssertExpectations(t); !res {
 		t.Error("expectations not met")
@@ -196,13 +227,13 @@ func testUserServiceGetAllSingle(t *testing.T, service UserService) {
 }
 
 func testUserServiceGetAllZero(t *testing.T, service UserService) {
-	mockRepoGetAll := mockRepo.On("GetAll").Return(&[]User{}, nil)
+	mockRepoGetAll := mockRepo.On("GetAll", pagination.Request{}).Return(&pagination.Page[User]{Items: []User{}}, nil)
 
-	users, err := service.GetAll()
+	users, err := service.GetAll(pagination.Request{})
 
 	require.NoError(t, err, "should not return an error")
 
-	require.Equal(t, &[]UserResponseDto{}, users, "should return users response")
+	require.Equal(t, []UserResponseDto{}, users.Items, "should return users response")
 
 	if res := mockRepo.AssertExpectations(t); !res {
 		t.Error("expectations not met")
@@ -337,17 +368,20 @@ func testUserServiceCreateUser(t *testing.T, service UserService) {
 		On("Create", &User{
 			Username: "janedoe",
 			Email:    "jane@example.org",
+			Role:     RoleAuthor,
 			Password: "hashed_password",
 		}).
 		Return(&User{
 			Id:       23,
 			Username: "janedoe",
 			Email:    "jane@example.org",
+			Role:     RoleAuthor,
 		}, nil)
 
 	createdUser, err := service.Create(&UserNewRequestDto{
 		Username: "janedoe",
 		Email:    "jane@example.org",
+		Role:     RoleAuthor,
 		Password: "foo",
 	})
 
@@ -356,6 +390,7 @@ func testUserServiceCreateUser(t *testing.T, service UserService) {
 		Id:       23,
 		Username: "janedoe",
 		Email:    "jane@example.org",
+		Role:     RoleAuthor,
 	}, createdUser, "should return created user response")
 
 	if res := mockCrypto.AssertExpectations(t); !res {
@@ -378,6 +413,7 @@ func testUserServiceCreateUserPasswordHashingError(t *testing.T, service UserSer
 	createdUser, err := service.Create(&UserNewRequestDto{
 		Username: "janedoe",
 		Email:    "jane@example.org",
+		Role:     RoleAuthor,
 		Password: "foo",
 	})
 
@@ -417,6 +453,7 @@ func testUserServiceCreateUserRepoError(t *testing.T, service UserService) {
 		On("Create", &User{
 			Username: "janedoe",
 			Email:    "jane@example.org",
+			Role:     RoleAuthor,
 			Password: "hashed_password",
 		}).
 		Return(&User{}, errors.New("repo_error"))
@@ -424,6 +461,7 @@ func testUserServiceCreateUserRepoError(t *testing.T, service UserService) {
 	createdUser, err := service.Create(&UserNewRequestDto{
 		Username: "janedoe",
 		Email:    "jane@example.org",
+		Role:     RoleAuthor,
 		Password: "foo",
 	})
 
@@ -447,17 +485,20 @@ func testUserServiceUpdateUser(t *testing.T, service UserService) {
 		Id:       23,
 		Username: "janedoe",
 		Email:    "jane@example.org",
+		Role:     RoleAuthor,
 		Password: "p4ssw0rd",
 	}).Return(&User{
 		Id:       23,
 		Username: "janedoe",
 		Email:    "jane@example.org",
+		Role:     RoleAuthor,
 	}, nil)
 
 	updatedUser, err := service.Update(&User{
 		Id:       23,
 		Username: "janedoe",
 		Email:    "jane@example.org",
+		Role:     RoleAuthor,
 		Password: "p4ssw0rd",
 	})
 
@@ -467,6 +508,7 @@ func testUserServiceUpdateUser(t *testing.T, service UserService) {
 		Id:       23,
 		Username: "janedoe",
 		Email:    "jane@example.org",
+		Role:     RoleAuthor,
 	}, updatedUser, "should return updated user response")
 
 	if res := mockRepo.AssertExpectations(t); !res {
@@ -481,6 +523,7 @@ func testUserServiceUpdateUserRepoError(t *testing.T, service UserService) {
 		Id:       23,
 		Username: "janedoe",
 		Email:    "jane@example.org",
+		Role:     RoleAuthor,
 		Password: "p4ssw0rd",
 	}).Return(&User{}, errors.New("repo_error"))
 
@@ -488,6 +531,7 @@ func testUserServiceUpdateUserRepoError(t *testing.T, service UserService) {
 		Id:       23,
 		Username: "janedoe",
 		Email:    "jane@example.org",
+		Role:     RoleAuthor,
 		Password: "p4ssw0rd",
 	})
 
@@ -575,3 +619,221 @@ func testUserServiceLoginUsernamePasswordIncorrectPassword(t *testing.T, service
 	mockRepoGetPasswordByUserName.Unset()
 	mockCryptoCompareHashAndPassword.Unset()
 }
+
+func testUserServiceUpdateProfile(t *testing.T, service UserService) {
+	profile := &Profile{
+		DisplayName: "Jane Doe",
+		Bio:         "Writes about *things*.",
+		AvatarUrl:   "https://example.org/jane.png",
+	}
+
+	mockRepoUpdateProfile := mockRepo.On("UpdateProfile", uint(23), profile).Return(&User{
+		Id:       23,
+		Username: "janedoe",
+		Email:    "jane@example.org",
+		Role:     RoleAuthor,
+		Profile:  *profile,
+	}, nil)
+
+	updatedUser, err := service.UpdateProfile(23, profile)
+
+	require.NoError(t, err, "should not return error")
+	require.Equal(t, &UserResponseDto{
+		Id:       23,
+		Username: "janedoe",
+		Email:    "jane@example.org",
+		Role:     RoleAuthor,
+		Profile:  *profile,
+	}, updatedUser, "should return user with updated profile")
+	require.Equal(t, "Jane Doe", updatedUser.Name(), "should be credited by display name")
+
+	if res := mockRepo.AssertExpectations(t); !res {
+		t.Error("unmet expectations")
+	}
+
+	mockRepoUpdateProfile.Unset()
+}
+
+func testUserServiceUpdateRole(t *testing.T, service UserService) {
+	mockRepoUpdateRole := mockRepo.On("UpdateRole", uint(23), RoleEditor).Return(&User{
+		Id:       23,
+		Username: "janedoe",
+		Email:    "jane@example.org",
+		Role:     RoleEditor,
+	}, nil)
+
+	updatedUser, err := service.UpdateRole(23, RoleEditor)
+
+	require.NoError(t, err, "should not return error")
+	require.Equal(t, &UserResponseDto{
+		Id:       23,
+		Username: "janedoe",
+		Email:    "jane@example.org",
+		Role:     RoleEditor,
+	}, updatedUser, "should return user with updated role")
+
+	if res := mockRepo.AssertExpectations(t); !res {
+		t.Error("unmet expectations")
+	}
+
+	mockRepoUpdateRole.Unset()
+}
+
+func testUserServiceUpdateRoleLastAdmin(t *testing.T, service UserService) {
+	mockRepoUpdateRole := mockRepo.On("UpdateRole", uint(1), RoleViewer).Return((*User)(nil), ErrLastAdmin)
+
+	updatedUser, err := service.UpdateRole(1, RoleViewer)
+
+	require.ErrorIs(t, err, ErrLastAdmin, "should return last admin error")
+	require.Nil(t, updatedUser, "should not return user")
+
+	mockRepoUpdateRole.Unset()
+}
+
+func testUserServiceUpdateRoleValidationError(t *testing.T, service UserService) {
+	// no expectation on the repo, so saving the role would fail the test
+	updatedUser, err := service.UpdateRole(23, Role("owner"))
+
+	require.Error(t, err, "should return validation error")
+	require.Nil(t, updatedUser, "should not return user")
+}
+
+func testUserServiceUpdateProfileValidationError(t *testing.T, service UserService) {
+	updatedUser, err := service.UpdateProfile(23, &Profile{
+		DisplayName: "Jane Doe",
+		AvatarUrl:   "javascript:alert(1)",
+	})
+
+	require.Error(t, err, "should return validation error")
+	require.Nil(t, updatedUser, "should not return a user")
+}
+
+func testUserServiceChangePassword(t *testing.T, service UserService) {
+	mockRepoGetPasswordByUsername := mockRepo.
+		On("GetPasswordByUsername", "janedoe").
+		Return("h4shedp4ssw0rd", nil)
+
+	mockCryptoCompareHashAndPassword := mockCrypto.
+		On("CompareHashAndPassword", []byte("h4shedp4ssw0rd"), []byte("p4ssw0rd")).
+		Return(nil)
+
+	mockCryptoGenerateFromPassword := mockCrypto.
+		On("GenerateFromPassword", []byte("n3wp4ssw0rd"), 14).
+		Return([]byte("h4shedn3wp4ssw0rd"), nil)
+
+	mockRepoUpdatePassword := mockRepo.
+		On("UpdatePassword", "janedoe", "h4shedn3wp4ssw0rd").
+		Return(4, nil)
+
+	sessionVersion, err := service.ChangePassword("janedoe", &UserPasswordChangeRequestDto{
+		CurrentPassword: "p4ssw0rd",
+		NewPassword:     "n3wp4ssw0rd",
+		ConfirmPassword: "n3wp4ssw0rd",
+	})
+
+	require.NoError(t, err, "should not return error")
+	require.Equal(t, 4, sessionVersion, "should return new session version")
+
+	if res := mockRepo.AssertExpectations(t); !res {
+		t.Error("unmet expectations")
+	}
+
+	if res := mockCrypto.AssertExpectations(t); !res {
+		t.Error("unmet expectations")
+	}
+
+	mockRepoGetPasswordByUsername.Unset()
+	mockCryptoCompareHashAndPassword.Unset()
+	mockCryptoGenerateFromPassword.Unset()
+	mockRepoUpdatePassword.Unset()
+}
+
+func testUserServiceChangePasswordIncorrect(t *testing.T, service UserService) {
+	mockRepoGetPasswordByUsername := mockRepo.
+		On("GetPasswordByUsername", "janedoe").
+		Return("h4shedp4ssw0rd", nil)
+
+	mockCryptoCompareHashAndPassword := mockCrypto.
+		On("CompareHashAndPassword", []byte("h4shedp4ssw0rd"), []byte("wr0ng")).
+		Return(errors.New("mismatch"))
+
+	sessionVersion, err := service.ChangePassword("janedoe", &UserPasswordChangeRequestDto{
+		CurrentPassword: "wr0ng",
+		NewPassword:     "n3wp4ssw0rd",
+		ConfirmPassword: "n3wp4ssw0rd",
+	})
+
+	require.ErrorIs(t, err, ErrIncorrectPassword, "should return incorrect password error")
+	require.Zero(t, sessionVersion, "should not return a session version")
+
+	if res := mockRepo.AssertExpectations(t); !res {
+		t.Error("unmet expectations")
+	}
+
+	if res := mockCrypto.AssertExpectations(t); !res {
+		t.Error("unmet expectations")
+	}
+
+	mockRepoGetPasswordByUsername.Unset()
+	mockCryptoCompareHashAndPassword.Unset()
+}
+
+func testUserServiceChangePasswordValidationError(t *testing.T, service UserService) {
+	scenarios := map[string]*UserPasswordChangeRequestDto{
+		"too short": {
+			CurrentPassword: "p4ssw0rd",
+			NewPassword:     "short",
+			ConfirmPassword: "short",
+		},
+		"confirmation doesn't match": {
+			CurrentPassword: "p4ssw0rd",
+			NewPassword:     "n3wp4ssw0rd",
+			ConfirmPassword: "n3wp4ssw0rf",
+		},
+		"missing current password": {
+			NewPassword:     "n3wp4ssw0rd",
+			ConfirmPassword: "n3wp4ssw0rd",
+		},
+	}
+
+	for scenario, change := range scenarios {
+		_, err := service.ChangePassword("janedoe", change)
+
+		require.Error(t, err, "should return validation error: "+scenario)
+	}
+}
+
+func testUserServiceLoginUsernamePasswordUnknownUser(t *testing.T, service UserService) {
+	mockRepoGetPasswordByUserName := mockRepo.
+		On("GetPasswordByUsername", "nobody").
+		Return("", pgx.ErrNoRows)
+
+	mockCryptoCompareHashAndPassword := mockCrypto.
+		On("CompareHashAndPassword", []byte(dummyPasswordHash), []byte("p4ssw0rd")).
+		Return(errors.New("incorrect_password"))
+
+	err := service.LoginWithUsernamePassword("nobody", "p4ssw0rd")
+
+	require.Equal(t, ErrIncorrectPassword, err, "should return incorrect password error")
+
+	if res := mockCrypto.AssertExpectations(t); !res {
+		t.Error("should compare against dummy hash")
+	}
+
+	mockRepoGetPasswordByUserName.Unset()
+	mockCryptoCompareHashAndPassword.Unset()
+}
+
+func TestDummyPasswordHashAt(t *testing.T) {
+	require.Equal(t, dummyPasswordHash, dummyPasswordHashAt(14))
+
+	cost, err := bcrypt.Cost([]byte(dummyPasswordHashAt(4)))
+	require.NoError(t, err)
+	require.Equal(t, 4, cost)
+
+	require.ErrorIs(
+		t,
+		bcrypt.CompareHashAndPassword([]byte(dummyPasswordHashAt(4)), []byte("p4ssw0rd")),
+		bcrypt.ErrMismatchedHashAndPassword,
+	)
+}
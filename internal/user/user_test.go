@@ -0,0 +1,76 @@
+package user
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserRoles(t *testing.T) {
+	scenarios := map[string]func(t *testing.T){
+		"test role permissions": testRoleCan,
+		"test user can edit":    testUserCanEdit,
+		"test api token allows": testApiTokenAllows,
+	}
+
+	for scenario, fn := range scenarios {
+		t.Run(scenario, func(t *testing.T) {
+			fn(t)
+		})
+	}
+}
+
+func testRoleCan(t *testing.T) {
+	require.True(t, RoleAdmin.Can(PermissionManageUsers), "admin should manage users")
+	require.True(t, RoleAdmin.Can(PermissionManageSite), "admin should manage site")
+
+	require.True(t, RoleEditor.Can(PermissionManageContent), "editor should manage content")
+	require.True(t, RoleEditor.Can(PermissionEditAllArticles), "editor should edit all articles")
+	require.False(t, RoleEditor.Can(PermissionManageUsers), "editor should not manage users")
+	require.False(t, RoleEditor.Can(PermissionManageSite), "editor should not manage site")
+
+	require.True(t, RoleAuthor.Can(PermissionWriteArticles), "author should write articles")
+	require.False(t, RoleAuthor.Can(PermissionEditAllArticles), "author should not edit all articles")
+	require.False(t, RoleAuthor.Can(PermissionManageContent), "author should not manage content")
+
+	require.True(t, RoleViewer.Can(PermissionViewAdmin), "viewer should view admin")
+	require.False(t, RoleViewer.Can(PermissionWriteArticles), "viewer should not write articles")
+
+	require.False(t, Role("owner").Can(PermissionViewAdmin), "unknown role should not do anything")
+}
+
+func testUserCanEdit(t *testing.T) {
+	ownId := 23
+	otherId := 42
+
+	author := UserResponseDto{Id: 23, Role: RoleAuthor}
+	require.True(t, author.CanEdit(&ownId), "author should edit own content")
+	require.False(t, author.CanEdit(&otherId), "author should not edit others' content")
+	require.False(t, author.CanEdit(nil), "author should not edit content without an author")
+
+	editor := UserResponseDto{Id: 69, Role: RoleEditor}
+	require.True(t, editor.CanEdit(&otherId), "editor should edit others' content")
+	require.True(t, editor.CanEdit(nil), "editor should edit content without an author")
+
+	viewer := UserResponseDto{Id: 42, Role: RoleViewer}
+	require.False(t, viewer.CanEdit(&otherId), "viewer should not edit own content")
+}
+
+func testApiTokenAllows(t *testing.T) {
+	read := ApiToken{Scopes: []ApiScope{ApiScopeRead}}
+	require.True(t, read.Allows(PermissionViewAdmin), "read token should view admin")
+	require.False(t, read.Allows(PermissionWriteArticles), "read token should not write articles")
+
+	articles := ApiToken{Scopes: []ApiScope{ApiScopeWriteArticles}}
+	require.True(t, articles.Allows(PermissionWriteArticles), "articles token should write articles")
+	require.False(t, articles.Allows(PermissionManageContent), "articles token should not manage content")
+
+	tags := ApiToken{Scopes: []ApiScope{ApiScopeRead, ApiScopeManageTags}}
+	require.True(t, tags.Allows(PermissionManageContent), "tags token should manage content")
+	require.False(t, tags.Allows(PermissionManageUsers), "tags token should not manage users")
+
+	admin := ApiToken{Scopes: []ApiScope{ApiScopeAdmin}}
+	require.True(t, admin.Allows(PermissionManageSite), "admin token should manage site")
+
+	require.False(t, ApiToken{}.Allows(PermissionViewAdmin), "token without scopes should not do anything")
+}
@@ -0,0 +1,54 @@
+package cache
+
+import "sync"
+
+// Value caches a single value that's read far more often than it's written,
+// like the settings or menu every public page shows. It's safe to share
+// between goroutines, so a service and all its copies can hold a pointer to
+// the same one.
+//
+// A generation counter changes on every Invalidate, so a load that raced with
+// a write doesn't put the stale value it read back in the cache.
+type Value[T any] struct {
+	mu         sync.RWMutex
+	value      T
+	loaded     bool
+	generation uint64
+}
+
+// Get returns the cached value, or the value returned by load if nothing's
+// cached, keeping it for next time. Errors from load aren't cached. The value
+// is shared by every caller, so anything it refers to mustn't be changed.
+func (v *Value[T]) Get(load func() (T, error)) (T, error) {
+	v.mu.RLock()
+	value, loaded, generation := v.value, v.loaded, v.generation
+	v.mu.RUnlock()
+
+	if loaded {
+		return value, nil
+	}
+
+	value, err := load()
+	if err != nil {
+		return value, err
+	}
+
+	v.mu.Lock()
+	if v.generation == generation {
+		v.value = value
+		v.loaded = true
+	}
+	v.mu.Unlock()
+
+	return value, nil
+}
+
+// Invalidate clears the cached value, so the next Get loads it again.
+func (v *Value[T]) Invalidate() {
+	v.mu.Lock()
+	var zero T
+	v.value = zero
+	v.loaded = false
+	v.generation++
+	v.mu.Unlock()
+}
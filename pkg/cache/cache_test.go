@@ -0,0 +1,108 @@
+package cache
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache(t *testing.T) {
+	scenarios := map[string]func(t *testing.T){
+		"get loads once":           testGetLoadsOnce,
+		"get doesn't cache errors": testGetError,
+		"invalidate loads again":   testInvalidate,
+		"invalidate during load":   testInvalidateDuringLoad,
+		"get caches empty value":   testGetEmpty,
+	}
+
+	for scenario, fn := range scenarios {
+		t.Run(scenario, func(t *testing.T) {
+			fn(t)
+		})
+	}
+}
+
+// counter returns a load function that returns how many times it's been
+// called.
+func counter() func() (int, error) {
+	loads := 0
+
+	return func() (int, error) {
+		loads++
+		return loads, nil
+	}
+}
+
+func testGetLoadsOnce(t *testing.T) {
+	var v Value[int]
+
+	load := counter()
+
+	got, err := v.Get(load)
+	require.NoError(t, err, "should not return error")
+	require.Equal(t, 1, got, "should load value")
+
+	got, err = v.Get(load)
+	require.NoError(t, err, "should not return error")
+	require.Equal(t, 1, got, "should return cached value")
+}
+
+func testGetError(t *testing.T) {
+	var v Value[int]
+
+	_, err := v.Get(func() (int, error) {
+		return 0, errors.New("load_error")
+	})
+	require.EqualError(t, err, "load_error", "should return load error")
+
+	got, err := v.Get(counter())
+	require.NoError(t, err, "should not return error")
+	require.Equal(t, 1, got, "should load again after an error")
+}
+
+func testInvalidate(t *testing.T) {
+	var v Value[int]
+
+	load := counter()
+
+	v.Get(load)
+	v.Invalidate()
+
+	got, err := v.Get(load)
+	require.NoError(t, err, "should not return error")
+	require.Equal(t, 2, got, "should load value again")
+}
+
+func testInvalidateDuringLoad(t *testing.T) {
+	var v Value[int]
+
+	got, err := v.Get(func() (int, error) {
+		// a write that lands while the value's being read
+		v.Invalidate()
+		return 1, nil
+	})
+	require.NoError(t, err, "should not return error")
+	require.Equal(t, 1, got, "should return loaded value")
+
+	got, err = v.Get(func() (int, error) {
+		return 2, nil
+	})
+	require.NoError(t, err, "should not return error")
+	require.Equal(t, 2, got, "should not have cached value loaded before the write")
+}
+
+func testGetEmpty(t *testing.T) {
+	var v Value[[]string]
+
+	loads := 0
+	load := func() ([]string, error) {
+		loads++
+		return nil, nil
+	}
+
+	v.Get(load)
+	v.Get(load)
+
+	require.Equal(t, 1, loads, "should cache an empty value")
+}
@@ -0,0 +1,163 @@
+package diff
+
+import "strings"
+
+type Op int
+
+const (
+	Equal Op = iota
+	Insert
+	Delete
+)
+
+func (o Op) String() string {
+	switch o {
+	case Insert:
+		return "insert"
+	case Delete:
+		return "delete"
+	default:
+		return "equal"
+	}
+}
+
+type Line struct {
+	Op   Op
+	Text string
+}
+
+// Lines returns a line-level diff that turns a into b, using the longest
+// common subsequence of lines between the two. The subsequence is found with
+// Hirschberg's algorithm, so memory grows with the length of the texts rather
+// than with the product of their lengths.
+func Lines(a, b string) []Line {
+	from := splitLines(a)
+	to := splitLines(b)
+
+	lines := make([]Line, 0, max(len(from), len(to)))
+
+	// lines at the start and end that haven't changed are by far the
+	// commonest case, so they're taken off before doing any real work
+	prefix := 0
+	for prefix < len(from) && prefix < len(to) && from[prefix] == to[prefix] {
+		lines = append(lines, Line{Op: Equal, Text: from[prefix]})
+		prefix++
+	}
+
+	suffix := 0
+	for suffix < len(from)-prefix && suffix < len(to)-prefix &&
+		from[len(from)-1-suffix] == to[len(to)-1-suffix] {
+		suffix++
+	}
+
+	lines = appendDiff(lines, from[prefix:len(from)-suffix], to[prefix:len(to)-suffix])
+
+	for _, text := range from[len(from)-suffix:] {
+		lines = append(lines, Line{Op: Equal, Text: text})
+	}
+
+	return lines
+}
+
+// appendDiff appends the diff that turns from into to onto lines. It splits
+// from in half, finds where in to that split falls along a longest common
+// subsequence, and diffs each side on its own.
+func appendDiff(lines []Line, from, to []string) []Line {
+	switch {
+	case len(from) == 0:
+		for _, text := range to {
+			lines = append(lines, Line{Op: Insert, Text: text})
+		}
+
+		return lines
+
+	case len(to) == 0:
+		for _, text := range from {
+			lines = append(lines, Line{Op: Delete, Text: text})
+		}
+
+		return lines
+
+	case len(from) == 1:
+		for j, text := range to {
+			if text == from[0] {
+				lines = appendDiff(lines, nil, to[:j])
+				lines = append(lines, Line{Op: Equal, Text: text})
+
+				return appendDiff(lines, nil, to[j+1:])
+			}
+		}
+
+		lines = append(lines, Line{Op: Delete, Text: from[0]})
+
+		return appendDiff(lines, nil, to)
+	}
+
+	mid := len(from) / 2
+
+	head := prefixLengths(from[:mid], to)
+	tail := suffixLengths(from[mid:], to)
+
+	split := 0
+	for j := range head {
+		if head[j]+tail[j] > head[split]+tail[split] {
+			split = j
+		}
+	}
+
+	lines = appendDiff(lines, from[:mid], to[:split])
+
+	return appendDiff(lines, from[mid:], to[split:])
+}
+
+// prefixLengths returns, for each j, the length of the longest common
+// subsequence of from and to[:j], keeping only one row of the table at a time.
+func prefixLengths(from, to []string) []int {
+	prev := make([]int, len(to)+1)
+	curr := make([]int, len(to)+1)
+
+	for _, text := range from {
+		for j := 1; j <= len(to); j++ {
+			if text == to[j-1] {
+				curr[j] = prev[j-1] + 1
+			} else {
+				curr[j] = max(prev[j], curr[j-1])
+			}
+		}
+
+		prev, curr = curr, prev
+	}
+
+	return prev
+}
+
+// suffixLengths returns, for each j, the length of the longest common
+// subsequence of from and to[j:], keeping only one row of the table at a time.
+func suffixLengths(from, to []string) []int {
+	prev := make([]int, len(to)+1)
+	curr := make([]int, len(to)+1)
+
+	for i := len(from) - 1; i >= 0; i-- {
+		for j := len(to) - 1; j >= 0; j-- {
+			if from[i] == to[j] {
+				curr[j] = prev[j+1] + 1
+			} else {
+				curr[j] = max(prev[j], curr[j+1])
+			}
+		}
+
+		prev, curr = curr, prev
+	}
+
+	return prev
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
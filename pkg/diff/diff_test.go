@@ -0,0 +1,124 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	scenarios := map[string]func(t *testing.T){
+		"diff identical text":         testDiffIdentical,
+		"diff empty to text":          testDiffEmptyToText,
+		"diff text to empty":          testDiffTextToEmpty,
+		"diff changed line":           testDiffChangedLine,
+		"diff inserted and removed":   testDiffInsertedAndRemoved,
+		"diff normalises line ending": testDiffNormalisesLineEndings,
+		"diff interleaved changes":    testDiffInterleaved,
+		"diff large text":             testDiffLarge,
+		"op string":                   testOpString,
+	}
+
+	for scenario, fn := range scenarios {
+		t.Run(scenario, func(t *testing.T) {
+			fn(t)
+		})
+	}
+}
+
+func testDiffIdentical(t *testing.T) {
+	require.Equal(t, []Line{
+		{Op: Equal, Text: "one"},
+		{Op: Equal, Text: "two"},
+	}, Lines("one\ntwo", "one\ntwo"), "should mark every line as equal")
+}
+
+func testDiffEmptyToText(t *testing.T) {
+	require.Equal(t, []Line{
+		{Op: Insert, Text: "one"},
+		{Op: Insert, Text: "two"},
+	}, Lines("", "one\ntwo\n"), "should mark every line as inserted")
+}
+
+func testDiffTextToEmpty(t *testing.T) {
+	require.Equal(t, []Line{
+		{Op: Delete, Text: "one"},
+		{Op: Delete, Text: "two"},
+	}, Lines("one\ntwo", ""), "should mark every line as deleted")
+}
+
+func testDiffChangedLine(t *testing.T) {
+	require.Equal(t, []Line{
+		{Op: Equal, Text: "one"},
+		{Op: Delete, Text: "two"},
+		{Op: Insert, Text: "deux"},
+		{Op: Equal, Text: "three"},
+	}, Lines("one\ntwo\nthree", "one\ndeux\nthree"), "should replace changed line")
+}
+
+func testDiffInsertedAndRemoved(t *testing.T) {
+	require.Equal(t, []Line{
+		{Op: Delete, Text: "zero"},
+		{Op: Equal, Text: "one"},
+		{Op: Equal, Text: "two"},
+		{Op: Insert, Text: "three"},
+	}, Lines("zero\none\ntwo", "one\ntwo\nthree"), "should keep common lines in place")
+}
+
+func testDiffNormalisesLineEndings(t *testing.T) {
+	require.Equal(t, []Line{
+		{Op: Equal, Text: "one"},
+		{Op: Equal, Text: "two"},
+	}, Lines("one\r\ntwo\r\n", "one\ntwo"), "should treat CRLF and LF the same")
+}
+
+func testDiffInterleaved(t *testing.T) {
+	require.Equal(t, []Line{
+		{Op: Equal, Text: "a"},
+		{Op: Delete, Text: "b"},
+		{Op: Equal, Text: "c"},
+		{Op: Insert, Text: "x"},
+		{Op: Equal, Text: "d"},
+		{Op: Delete, Text: "e"},
+		{Op: Equal, Text: "f"},
+		{Op: Insert, Text: "y"},
+	}, Lines("a\nb\nc\nd\ne\nf", "a\nc\nx\nd\nf\ny"), "should keep the longest run of common lines")
+}
+
+func testDiffLarge(t *testing.T) {
+	var from, to strings.Builder
+
+	for i := 0; i < 5000; i++ {
+		fmt.Fprintf(&from, "line %d\n", i)
+		fmt.Fprintf(&to, "line %d\n", i*2)
+	}
+
+	var gotFrom, gotTo []string
+	equal := 0
+
+	for _, line := range Lines(from.String(), to.String()) {
+		if line.Op != Insert {
+			gotFrom = append(gotFrom, line.Text)
+		}
+
+		if line.Op != Delete {
+			gotTo = append(gotTo, line.Text)
+		}
+
+		if line.Op == Equal {
+			equal++
+		}
+	}
+
+	require.Equal(t, splitLines(from.String()), gotFrom, "should keep every line of the old text")
+	require.Equal(t, splitLines(to.String()), gotTo, "should keep every line of the new text")
+	require.Equal(t, 2500, equal, "should find every common line")
+}
+
+func testOpString(t *testing.T) {
+	require.Equal(t, "equal", Equal.String())
+	require.Equal(t, "insert", Insert.String())
+	require.Equal(t, "delete", Delete.String())
+}
@@ -0,0 +1,229 @@
+package listener
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FdsEnv is set for a process started by Handoff, to the names of the
+// listeners it's been given and their file descriptors, e.g. "app=3,metrics=4".
+const FdsEnv = "DUNCE_LISTENER_FDS"
+
+// ReadyEnv is set for a process started by Handoff, to the file descriptor
+// Ready writes to once it's serving.
+const ReadyEnv = "DUNCE_READY_FD"
+
+// Listen listens on addr, or takes over the listener called name if one was
+// handed to the process by Handoff. inherited is whether it was handed over.
+func Listen(name, addr string) (l net.Listener, inherited bool, err error) {
+	fd, ok, err := inheritedFd(name)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if !ok {
+		l, err := net.Listen("tcp", addr)
+		return l, false, err
+	}
+
+	f := os.NewFile(uintptr(fd), name)
+	if f == nil {
+		return nil, false, fmt.Errorf("invalid %s: %d isn't a file descriptor", FdsEnv, fd)
+	}
+
+	// the listener has its own copy of the file descriptor
+	defer f.Close()
+
+	l, err = net.FileListener(f)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return l, true, nil
+}
+
+func inheritedFd(name string) (int, bool, error) {
+	fds := os.Getenv(FdsEnv)
+	if fds == "" {
+		return 0, false, nil
+	}
+
+	for _, entry := range strings.Split(fds, ",") {
+		entryName, fd, _ := strings.Cut(entry, "=")
+		if entryName != name {
+			continue
+		}
+
+		n, err := strconv.Atoi(fd)
+		if err != nil {
+			return 0, false, fmt.Errorf("invalid %s '%s': %w", FdsEnv, fds, err)
+		}
+
+		return n, true, nil
+	}
+
+	return 0, false, nil
+}
+
+// Child is a process started by Handoff to take over listeners.
+type Child struct {
+	Process *os.Process
+	ready   *os.File
+}
+
+// Wait waits for the child to call Ready. If it exits first, or isn't ready
+// within timeout, it's killed and an error is returned, so this process can
+// carry on serving. Once the child's ready, it's released, and this process
+// can shut down.
+func (c *Child) Wait(timeout time.Duration) error {
+	defer c.ready.Close()
+
+	if err := c.ready.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		c.kill()
+		return err
+	}
+
+	// the child writes a byte when it's ready, and if it exits the read gets
+	// EOF, since it had the only other end of the pipe
+	_, err := c.ready.Read(make([]byte, 1))
+	if err == nil {
+		return c.Process.Release()
+	}
+
+	state := c.kill()
+
+	switch {
+	case errors.Is(err, os.ErrDeadlineExceeded):
+		return fmt.Errorf("new process wasn't ready within %s", timeout)
+	case errors.Is(err, io.EOF) && state != nil:
+		return fmt.Errorf("new process exited before it was ready: %s", state)
+	case errors.Is(err, io.EOF):
+		return errors.New("new process exited before it was ready")
+	default:
+		return err
+	}
+}
+
+// kill stops the child, in case it's still starting up, and waits for it, so
+// it doesn't take over or linger once it's been given up on.
+func (c *Child) kill() *os.ProcessState {
+	c.Process.Kill()
+
+	state, _ := c.Process.Wait()
+
+	return state
+}
+
+// Ready tells the process that handed this one its listeners, if there is
+// one, that it's serving them, so the other process can shut down.
+func Ready() error {
+	value := os.Getenv(ReadyEnv)
+	if value == "" {
+		return nil
+	}
+
+	// only the process that was handed over to is waiting
+	os.Unsetenv(ReadyEnv)
+
+	fd, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("invalid %s '%s': %w", ReadyEnv, value, err)
+	}
+
+	f := os.NewFile(uintptr(fd), "ready")
+	if f == nil {
+		return fmt.Errorf("invalid %s: %d isn't a file descriptor", ReadyEnv, fd)
+	}
+
+	defer f.Close()
+
+	_, err = f.Write([]byte{1})
+
+	return err
+}
+
+// Handoff starts a new copy of the running binary, with the same arguments,
+// and gives it listeners, which it takes over by calling Listen with the same
+// names. Connections that arrive while the new process starts up wait in the
+// listen backlog, or are served by this process, so none are dropped as long
+// as this process carries on serving until Wait reports the new one is ready,
+// then shuts down gracefully.
+func Handoff(listeners map[string]net.Listener) (*Child, error) {
+	if len(listeners) == 0 {
+		return nil, errors.New("no listeners to hand off")
+	}
+
+	names := make([]string, 0, len(listeners))
+	for name := range listeners {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	// files passed to the new process start at 3, after stdin, stdout and stderr
+	files := []*os.File{os.Stdin, os.Stdout, os.Stderr}
+	fds := make([]string, len(names))
+
+	for i, name := range names {
+		fileListener, ok := listeners[name].(interface{ File() (*os.File, error) })
+		if !ok {
+			return nil, fmt.Errorf("%s listener can't be handed off", name)
+		}
+
+		f, err := fileListener.File()
+		if err != nil {
+			return nil, err
+		}
+
+		defer f.Close()
+
+		fds[i] = fmt.Sprintf("%s=%d", name, len(files))
+		files = append(files, f)
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+
+	ready, readyWriter, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+
+	// the new process has its own copy, so it's the only one left once this
+	// one's closed
+	defer readyWriter.Close()
+
+	readyFd := len(files)
+	files = append(files, readyWriter)
+
+	// anything this process inherited is replaced by what it's handing off
+	env := slices.DeleteFunc(os.Environ(), func(v string) bool {
+		return strings.HasPrefix(v, FdsEnv+"=") || strings.HasPrefix(v, ReadyEnv+"=")
+	})
+
+	env = append(
+		env,
+		FdsEnv+"="+strings.Join(fds, ","),
+		ReadyEnv+"="+strconv.Itoa(readyFd),
+	)
+
+	process, err := os.StartProcess(executable, os.Args, &os.ProcAttr{
+		Env:   env,
+		Files: files,
+	})
+	if err != nil {
+		ready.Close()
+		return nil, err
+	}
+
+	return &Child{Process: process, ready: ready}, nil
+}
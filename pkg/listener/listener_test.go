@@ -0,0 +1,181 @@
+package listener
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestListener(t *testing.T) {
+	scenarios := map[string]func(t *testing.T){
+		"listen on address":      testListenOnAddress,
+		"listen inherits":        testListenInherits,
+		"listen other inherited": testListenOtherInherited,
+		"listen invalid fd":      testListenInvalidFd,
+		"handoff needs file":     testHandoffNeedsFile,
+		"handoff needs listener": testHandoffNeedsListener,
+		"child ready":            testChildReady,
+		"child exits first":      testChildExitsFirst,
+		"child ready too late":   testChildReadyTooLate,
+		"ready":                  testReady,
+		"ready without handoff":  testReadyWithoutHandoff,
+	}
+
+	for scenario, fn := range scenarios {
+		t.Run(scenario, func(t *testing.T) {
+			fn(t)
+		})
+	}
+}
+
+func testListenOnAddress(t *testing.T) {
+	t.Setenv(FdsEnv, "")
+
+	l, inherited, err := Listen("app", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+
+	require.False(t, inherited)
+	require.Equal(t, "tcp", l.Addr().Network())
+}
+
+func testListenInherits(t *testing.T) {
+	original, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer original.Close()
+
+	f, err := original.(*net.TCPListener).File()
+	require.NoError(t, err)
+	defer f.Close()
+
+	// Listen closes the descriptor it's given, so it gets a copy of f's
+	fd, err := syscall.Dup(int(f.Fd()))
+	require.NoError(t, err)
+
+	t.Setenv(FdsEnv, "metrics=99,app="+strconv.Itoa(fd))
+
+	l, inherited, err := Listen("app", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+
+	require.True(t, inherited)
+	require.Equal(t, original.Addr().String(), l.Addr().String())
+
+	// connections to the original address are accepted by the new listener
+	go func() {
+		conn, err := net.Dial("tcp", original.Addr().String())
+		if err == nil {
+			conn.Close()
+		}
+	}()
+
+	conn, err := l.Accept()
+	require.NoError(t, err)
+	conn.Close()
+}
+
+func testListenOtherInherited(t *testing.T) {
+	t.Setenv(FdsEnv, "app=99")
+
+	l, inherited, err := Listen("metrics", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+
+	require.False(t, inherited)
+}
+
+func testListenInvalidFd(t *testing.T) {
+	t.Setenv(FdsEnv, "app=three")
+
+	l, inherited, err := Listen("app", "127.0.0.1:0")
+
+	require.Nil(t, l)
+	require.False(t, inherited)
+	require.ErrorContains(t, err, "invalid DUNCE_LISTENER_FDS 'app=three'")
+}
+
+type fileless struct {
+	net.Listener
+}
+
+func testHandoffNeedsFile(t *testing.T) {
+	child, err := Handoff(map[string]net.Listener{"app": fileless{}})
+
+	require.Nil(t, child)
+	require.EqualError(t, err, "app listener can't be handed off")
+}
+
+func testHandoffNeedsListener(t *testing.T) {
+	child, err := Handoff(map[string]net.Listener{})
+
+	require.Nil(t, child)
+	require.EqualError(t, err, "no listeners to hand off")
+}
+
+// startChild runs script as though it were a process started by Handoff, with
+// the ready pipe on file descriptor 3.
+func startChild(t *testing.T, script string) *Child {
+	ready, readyWriter, err := os.Pipe()
+	require.NoError(t, err)
+	defer readyWriter.Close()
+
+	process, err := os.StartProcess("/bin/sh", []string{"sh", "-c", script}, &os.ProcAttr{
+		Files: []*os.File{nil, nil, nil, readyWriter},
+	})
+	require.NoError(t, err)
+
+	return &Child{Process: process, ready: ready}
+}
+
+func testChildReady(t *testing.T) {
+	child := startChild(t, "printf x >&3")
+
+	require.NoError(t, child.Wait(5*time.Second))
+}
+
+func testChildExitsFirst(t *testing.T) {
+	child := startChild(t, "exit 1")
+
+	require.EqualError(t, child.Wait(5*time.Second), "new process exited before it was ready: exit status 1")
+}
+
+func testChildReadyTooLate(t *testing.T) {
+	child := startChild(t, "sleep 10")
+
+	start := time.Now()
+
+	require.EqualError(t, child.Wait(50*time.Millisecond), "new process wasn't ready within 50ms")
+	require.Less(t, time.Since(start), 5*time.Second, "should kill the process rather than wait for it")
+}
+
+func testReady(t *testing.T) {
+	ready, readyWriter, err := os.Pipe()
+	require.NoError(t, err)
+	defer ready.Close()
+	defer readyWriter.Close()
+
+	// Ready closes the descriptor it's given, so it gets a copy
+	fd, err := syscall.Dup(int(readyWriter.Fd()))
+	require.NoError(t, err)
+
+	t.Setenv(ReadyEnv, strconv.Itoa(fd))
+
+	require.NoError(t, Ready())
+
+	b := make([]byte, 1)
+	_, err = ready.Read(b)
+	require.NoError(t, err, "should tell the waiting process it's ready")
+
+	require.Empty(t, os.Getenv(ReadyEnv), "should only be ready once")
+}
+
+func testReadyWithoutHandoff(t *testing.T) {
+	t.Setenv(ReadyEnv, "")
+
+	require.NoError(t, Ready())
+}
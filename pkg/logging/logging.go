@@ -1,41 +1,104 @@
 package logging
 
 import (
-	"log"
-	"os"
+	"context"
+	"io"
+	"log/slog"
 )
 
+const (
+	FormatText = "text"
+	FormatJson = "json"
+)
+
+// Logger logs a message along with key-value pairs of fields, e.g.
+// log.Error(ctx, "unable to get article", "slug", slug, "err", err). Fields
+// added to ctx with WithAttrs, such as the request ID, are logged too.
 type Logger interface {
-	Info(format string, values ...any)
-	Error(format string, values ...any)
+	Debug(ctx context.Context, msg string, args ...any)
+	Info(ctx context.Context, msg string, args ...any)
+	Warn(ctx context.Context, msg string, args ...any)
+	Error(ctx context.Context, msg string, args ...any)
 }
 
 type Log struct {
-	InfoLogger  *log.Logger
-	ErrorLogger *log.Logger
+	logger *slog.Logger
+}
+
+// NewLogger logs to w in format, either FormatText or FormatJson, leaving out
+// anything less severe than level.
+func NewLogger(w io.Writer, format string, level slog.Leveler) Log {
+	options := &slog.HandlerOptions{Level: level}
+
+	var handler slog.Handler
+	if format == FormatJson {
+		handler = slog.NewJSONHandler(w, options)
+	} else {
+		handler = slog.NewTextHandler(w, options)
+	}
+
+	return Log{slog.New(contextHandler{handler})}
+}
+
+// With is a logger that adds the fields in args to everything it logs.
+func (l Log) With(args ...any) Log {
+	return Log{l.logger.With(args...)}
+}
+
+// Handler is the handler the logger writes with, e.g. for slog.NewLogLogger.
+func (l Log) Handler() slog.Handler {
+	return l.logger.Handler()
+}
+
+func (l Log) Debug(ctx context.Context, msg string, args ...any) {
+	l.logger.Log(ctx, slog.LevelDebug, msg, args...)
 }
 
-func NewLogger() Log {
-	return newLogger(
-		log.New(os.Stdout, "INFO\t", log.Ldate|log.Ltime),
-		log.New(os.Stderr, "ERROR\t", log.Ldate|log.Ltime|log.Lshortfile),
-	)
+func (l Log) Info(ctx context.Context, msg string, args ...any) {
+	l.logger.Log(ctx, slog.LevelInfo, msg, args...)
 }
 
-func newLogger(
-	infoLogger *log.Logger,
-	errorLogger *log.Logger,
-) Log {
-	return Log{
-		InfoLogger:  infoLogger,
-		ErrorLogger: errorLogger,
+func (l Log) Warn(ctx context.Context, msg string, args ...any) {
+	l.logger.Log(ctx, slog.LevelWarn, msg, args...)
+}
+
+func (l Log) Error(ctx context.Context, msg string, args ...any) {
+	l.logger.Log(ctx, slog.LevelError, msg, args...)
+}
+
+type contextKey struct{}
+
+// WithAttrs adds attrs to the fields logged with ctx, after any it already
+// has.
+func WithAttrs(ctx context.Context, attrs ...slog.Attr) context.Context {
+	existing, _ := ctx.Value(contextKey{}).([]slog.Attr)
+
+	// copied so contexts derived from the same parent don't share fields
+	combined := make([]slog.Attr, 0, len(existing)+len(attrs))
+	combined = append(combined, existing...)
+	combined = append(combined, attrs...)
+
+	return context.WithValue(ctx, contextKey{}, combined)
+}
+
+// contextHandler adds the fields in a record's context to it before it's
+// handled.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if attrs, ok := ctx.Value(contextKey{}).([]slog.Attr); ok {
+		r.AddAttrs(attrs...)
 	}
+
+	return h.Handler.Handle(ctx, r)
 }
 
-func (l Log) Info(format string, values ...any) {
-	l.InfoLogger.Printf(format, values...)
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
 }
 
-func (l Log) Error(format string, values ...any) {
-	l.ErrorLogger.Printf(format, values...)
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
 }
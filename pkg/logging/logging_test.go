@@ -0,0 +1,125 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogger(t *testing.T) {
+	scenarios := map[string]func(t *testing.T){
+		"json":                    testLoggerJson,
+		"text":                    testLoggerText,
+		"level":                   testLoggerLevel,
+		"context fields":          testLoggerContextFields,
+		"context fields isolated": testLoggerContextFieldsIsolated,
+	}
+
+	for scenario, fn := range scenarios {
+		t.Run(scenario, func(t *testing.T) {
+			fn(t)
+		})
+	}
+}
+
+func decodeLines(t *testing.T, b *bytes.Buffer) []map[string]any {
+	var lines []map[string]any
+
+	for _, line := range strings.Split(strings.TrimSpace(b.String()), "\n") {
+		var decoded map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &decoded))
+
+		lines = append(lines, decoded)
+	}
+
+	return lines
+}
+
+func testLoggerJson(t *testing.T) {
+	var b bytes.Buffer
+
+	log := NewLogger(&b, FormatJson, slog.LevelInfo)
+
+	log.Error(context.Background(), "unable to get article", "slug", "hello", "err", errors.New("db_error"))
+
+	lines := decodeLines(t, &b)
+	require.Len(t, lines, 1)
+
+	require.Equal(t, "ERROR", lines[0]["level"])
+	require.Equal(t, "unable to get article", lines[0]["msg"])
+	require.Equal(t, "hello", lines[0]["slug"])
+	require.Equal(t, "db_error", lines[0]["err"])
+}
+
+func testLoggerText(t *testing.T) {
+	var b bytes.Buffer
+
+	log := NewLogger(&b, FormatText, slog.LevelInfo).With("app", "dunce")
+
+	log.Info(context.Background(), "starting server", "address", "127.0.0.1:8080")
+
+	require.Contains(t, b.String(), `level=INFO msg="starting server" app=dunce address=127.0.0.1:8080`)
+}
+
+func testLoggerLevel(t *testing.T) {
+	var b bytes.Buffer
+
+	log := NewLogger(&b, FormatJson, slog.LevelWarn)
+
+	log.Debug(context.Background(), "debug")
+	log.Info(context.Background(), "info")
+	log.Warn(context.Background(), "warn")
+
+	lines := decodeLines(t, &b)
+	require.Len(t, lines, 1, "should only log at or above level")
+	require.Equal(t, "warn", lines[0]["msg"])
+}
+
+func testLoggerContextFields(t *testing.T) {
+	var b bytes.Buffer
+
+	log := NewLogger(&b, FormatJson, slog.LevelInfo)
+
+	ctx := WithAttrs(context.Background(), slog.String("request_id", "abc123"))
+	ctx = WithAttrs(ctx, slog.String("user", "janedoe"))
+
+	log.Info(ctx, "logged in")
+	log.Info(context.Background(), "no request")
+
+	lines := decodeLines(t, &b)
+	require.Len(t, lines, 2)
+
+	require.Equal(t, "abc123", lines[0]["request_id"])
+	require.Equal(t, "janedoe", lines[0]["user"])
+
+	require.NotContains(t, lines[1], "request_id")
+}
+
+func testLoggerContextFieldsIsolated(t *testing.T) {
+	var b bytes.Buffer
+
+	log := NewLogger(&b, FormatJson, slog.LevelInfo)
+
+	parent := WithAttrs(context.Background(), slog.String("request_id", "abc123"))
+
+	// contexts from the same parent shouldn't see each other's fields
+	first := WithAttrs(parent, slog.String("user", "janedoe"))
+	second := WithAttrs(parent, slog.String("user", "johndoe"))
+
+	log.Info(first, "first")
+	log.Info(second, "second")
+	log.Info(parent, "parent")
+
+	lines := decodeLines(t, &b)
+	require.Len(t, lines, 3)
+
+	require.Equal(t, "janedoe", lines[0]["user"])
+	require.Equal(t, "johndoe", lines[1]["user"])
+	require.NotContains(t, lines[2], "user")
+}
@@ -0,0 +1,33 @@
+package mail
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// LogMailer writes emails to w instead of sending them, for development
+// without an SMTP server.
+type LogMailer struct {
+	w    io.Writer
+	from string
+	mu   *sync.Mutex
+}
+
+func NewLogMailer(w io.Writer, from string) LogMailer {
+	return LogMailer{
+		w:    w,
+		from: from,
+		mu:   &sync.Mutex{},
+	}
+}
+
+func (l LogMailer) Send(message Message) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	_, err := fmt.Fprintf(l.w, "%s\r\n\r\n", format(l.from, message, time.Now()))
+
+	return err
+}
@@ -0,0 +1,29 @@
+package mail
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogMailer(t *testing.T) {
+	var buf bytes.Buffer
+
+	mailer := NewLogMailer(&buf, "dunce@example.org")
+
+	err := mailer.Send(Message{
+		To:      []string{"jane@example.org", "john@example.org"},
+		Subject: "Reset\r\nBcc: evil@example.org",
+		Body:    "Follow the link",
+	})
+
+	require.NoError(t, err, "should not return error")
+
+	email := buf.String()
+
+	require.Contains(t, email, "To: jane@example.org, john@example.org\r\n", "should write recipients")
+	require.Contains(t, email, "Subject: ResetBcc: evil@example.org\r\n", "should not let subject add headers")
+	require.True(t, strings.Contains(email, "\r\n\r\nFollow the link"), "should write body after headers")
+}
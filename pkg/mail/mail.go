@@ -0,0 +1,40 @@
+package mail
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Message is a plain text email.
+type Message struct {
+	To      []string
+	Subject string
+	Body    string
+}
+
+// Mailer sends emails, e.g. over SMTP or, in development, to a log.
+type Mailer interface {
+	Send(message Message) error
+}
+
+// headerValue stops values breaking out of their header, e.g. a subject
+// injecting extra recipients
+var headerValue = strings.NewReplacer("\r", "", "\n", "")
+
+// format renders message as an RFC 5322 email from from, ready to be sent.
+func format(from string, message Message, now time.Time) []byte {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "From: %s\r\n", headerValue.Replace(from))
+	fmt.Fprintf(&buf, "To: %s\r\n", headerValue.Replace(strings.Join(message.To, ", ")))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", headerValue.Replace(message.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("\r\n")
+	buf.WriteString(strings.ReplaceAll(message.Body, "\n", "\r\n"))
+
+	return buf.Bytes()
+}
@@ -0,0 +1,45 @@
+package mail
+
+import (
+	"errors"
+	"net"
+	"net/smtp"
+	"time"
+)
+
+type SendMailFunc func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+
+type SMTPMailer struct {
+	addr     string
+	auth     smtp.Auth
+	from     string
+	sendMail SendMailFunc
+}
+
+// NewSMTPMailer returns a mailer that sends through the SMTP server at host
+// and port. Authentication is skipped when username is empty, e.g. for a
+// local SMTP sink in development.
+func NewSMTPMailer(
+	host, port, username, password, from string,
+	sendMail SendMailFunc,
+) SMTPMailer {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return SMTPMailer{
+		addr:     net.JoinHostPort(host, port),
+		auth:     auth,
+		from:     from,
+		sendMail: sendMail,
+	}
+}
+
+func (s SMTPMailer) Send(message Message) error {
+	if len(message.To) == 0 {
+		return errors.New("no recipients")
+	}
+
+	return s.sendMail(s.addr, s.auth, s.from, message.To, format(s.from, message, time.Now()))
+}
@@ -0,0 +1,130 @@
+package mail
+
+import (
+	"errors"
+	"net/smtp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var mockSender = new(MockSender)
+
+func TestSMTPMailer(t *testing.T) {
+	scenarios := map[string]func(t *testing.T){
+		"send (success - no auth)":     testSMTPMailerSendNoAuth,
+		"send (success - with auth)":   testSMTPMailerSendWithAuth,
+		"send (error - send mail)":     testSMTPMailerSendError,
+		"send (error - no recipients)": testSMTPMailerSendNoRecipients,
+	}
+
+	for scenario, fn := range scenarios {
+		t.Run(scenario, fn)
+	}
+}
+
+type MockSender struct {
+	mock.Mock
+}
+
+func (m *MockSender) sendMail(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+	args := m.Called(addr, a, from, to, msg)
+
+	return args.Error(0)
+}
+
+func testSMTPMailerSendNoAuth(t *testing.T) {
+	mailer := NewSMTPMailer("localhost", "1025", "", "", "dunce@example.org", mockSender.sendMail)
+
+	var email string
+
+	mockSenderSendMail := mockSender.On(
+		"sendMail",
+		"localhost:1025",
+		nil,
+		"dunce@example.org",
+		[]string{"jane@example.org"},
+		mock.Anything,
+	).Return(nil).Run(func(args mock.Arguments) {
+		email = string(args.Get(4).([]byte))
+	})
+
+	err := mailer.Send(Message{
+		To:      []string{"jane@example.org"},
+		Subject: "Hello",
+		Body:    "Line one\nLine two",
+	})
+
+	require.NoError(t, err, "should not return error")
+
+	require.Contains(t, email, "From: dunce@example.org\r\n", "should send from sender")
+	require.Contains(t, email, "To: jane@example.org\r\n", "should send to recipient")
+	require.Contains(t, email, "Subject: Hello\r\n", "should send subject")
+	require.True(t, strings.HasSuffix(email, "\r\n\r\nLine one\r\nLine two"), "should send body with CRLF line endings")
+
+	if res := mockSender.AssertExpectations(t); !res {
+		t.Error("should send formatted email to server without auth")
+	}
+
+	mockSenderSendMail.Unset()
+}
+
+func testSMTPMailerSendWithAuth(t *testing.T) {
+	mailer := NewSMTPMailer("smtp.example.org", "587", "user", "pass", "dunce@example.org", mockSender.sendMail)
+
+	mockSenderSendMail := mockSender.On(
+		"sendMail",
+		"smtp.example.org:587",
+		smtp.PlainAuth("", "user", "pass", "smtp.example.org"),
+		"dunce@example.org",
+		[]string{"jane@example.org"},
+		mock.Anything,
+	).Return(nil)
+
+	err := mailer.Send(Message{
+		To:      []string{"jane@example.org"},
+		Subject: "Hello",
+		Body:    "Hello",
+	})
+
+	require.NoError(t, err, "should not return error")
+
+	if res := mockSender.AssertExpectations(t); !res {
+		t.Error("should send email to server with plain auth")
+	}
+
+	mockSenderSendMail.Unset()
+}
+
+func testSMTPMailerSendError(t *testing.T) {
+	mailer := NewSMTPMailer("localhost", "1025", "", "", "dunce@example.org", mockSender.sendMail)
+
+	mockSenderSendMail := mockSender.On(
+		"sendMail",
+		"localhost:1025",
+		nil,
+		"dunce@example.org",
+		[]string{"jane@example.org"},
+		mock.Anything,
+	).Return(errors.New("send_error"))
+
+	err := mailer.Send(Message{
+		To:      []string{"jane@example.org"},
+		Subject: "Hello",
+		Body:    "Hello",
+	})
+
+	require.EqualError(t, err, "send_error", "should return send error")
+
+	mockSenderSendMail.Unset()
+}
+
+func testSMTPMailerSendNoRecipients(t *testing.T) {
+	mailer := NewSMTPMailer("localhost", "1025", "", "", "dunce@example.org", mockSender.sendMail)
+
+	err := mailer.Send(Message{Subject: "Hello", Body: "Hello"})
+
+	require.EqualError(t, err, "no recipients", "should not send without recipients")
+}
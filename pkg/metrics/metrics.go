@@ -0,0 +1,138 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/nixpig/dunce/pkg/templates"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "dunce"
+
+// Registry has every metric the app exports. It's separate from the
+// Prometheus default registry, so nothing registered by a dependency is
+// exported without being chosen.
+var Registry = prometheus.NewRegistry()
+
+var (
+	requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "How long requests took to serve, by route pattern.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route"})
+
+	requests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "Requests served, by route pattern and status code.",
+	}, []string{"route", "code"})
+
+	templateRenderDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "template",
+		Name:      "render_duration_seconds",
+		Help:      "How long pages took to render, by page template.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"page"})
+
+	// Logins counts logins with a correct username and password.
+	Logins = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "logins_total",
+		Help:      "Logins with a correct username and password.",
+	})
+
+	// FailedLogins counts logins with an unknown username or wrong password.
+	FailedLogins = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "failed_logins_total",
+		Help:      "Logins with an unknown username or wrong password.",
+	})
+
+	ArticlesCreated = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "articles_created_total",
+		Help:      "Articles created.",
+	})
+
+	ArticlesUpdated = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "articles_updated_total",
+		Help:      "Articles updated.",
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		requestDuration,
+		requests,
+		templateRenderDuration,
+		Logins,
+		FailedLogins,
+		ArticlesCreated,
+		ArticlesUpdated,
+	)
+}
+
+// Handler serves the metrics in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+// ObserveRequest records a request to route that was served with code in d.
+func ObserveRequest(route string, code int, d time.Duration) {
+	requestDuration.WithLabelValues(route).Observe(d.Seconds())
+	requests.WithLabelValues(route, strconv.Itoa(code)).Inc()
+}
+
+// PoolStater is a connection pool that reports its stats, i.e. a
+// pgxpool.Pool.
+type PoolStater interface {
+	Stat() *pgxpool.Stat
+}
+
+// RegisterPool exports the stats of pool, read whenever the metrics are
+// scraped.
+func RegisterPool(pool PoolStater) error {
+	return Registry.Register(newPoolCollector(pool))
+}
+
+// InstrumentTemplates wraps every template in cache to record how long it
+// takes to render.
+func InstrumentTemplates(cache templates.TemplateCache) templates.TemplateCache {
+	instrumented := make(templates.TemplateCache, len(cache))
+
+	for page, template := range cache {
+		instrumented[page] = instrumentedTemplate{
+			template: template,
+			observer: templateRenderDuration.WithLabelValues(page),
+		}
+	}
+
+	return instrumented
+}
+
+type instrumentedTemplate struct {
+	template templates.Template
+	observer prometheus.Observer
+}
+
+func (t instrumentedTemplate) ExecuteTemplate(wr io.Writer, name string, data any) error {
+	start := time.Now()
+	defer func() {
+		t.observer.Observe(time.Since(start).Seconds())
+	}()
+
+	return t.template.ExecuteTemplate(wr, name, data)
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/nixpig/dunce/pkg/session"
+)
+
+// lastSeenInterval is how often a session's last seen time is updated, so
+// that it isn't saved again on every request.
+const lastSeenInterval = time.Minute
+
+func NewSessionActivityMiddleware(sessionManager session.SessionManager) func(next http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return SessionActivityMiddleware(sessionManager, next)
+	}
+}
+
+// SessionActivityMiddleware records where logged in sessions are being used
+// from and when, for them to be listed on the user's account.
+func SessionActivityMiddleware(sessionManager session.SessionManager, next http.HandlerFunc) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !sessionManager.Exists(r.Context(), session.LOGGED_IN_USERNAME) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		now := time.Now()
+		lastSeen := time.Unix(int64(sessionManager.GetInt(r.Context(), session.SESSION_LAST_SEEN)), 0)
+
+		ip, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			ip = r.RemoteAddr
+		}
+
+		if now.Sub(lastSeen) >= lastSeenInterval ||
+			sessionManager.GetString(r.Context(), session.SESSION_IP) != ip ||
+			sessionManager.GetString(r.Context(), session.SESSION_USER_AGENT) != r.UserAgent() {
+			sessionManager.Put(r.Context(), session.SESSION_LAST_SEEN, int(now.Unix()))
+			sessionManager.Put(r.Context(), session.SESSION_IP, ip)
+			sessionManager.Put(r.Context(), session.SESSION_USER_AGENT, r.UserAgent())
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -2,19 +2,24 @@ package middleware
 
 import (
 	"context"
+	"log/slog"
 	"net/http"
+	"strings"
 
+	"github.com/nixpig/dunce/internal/app/errors"
+	"github.com/nixpig/dunce/internal/site"
 	"github.com/nixpig/dunce/internal/user"
+	"github.com/nixpig/dunce/pkg/logging"
 	"github.com/nixpig/dunce/pkg/session"
 )
 
-func NewAuthenticatedMiddleware(userService user.UserService, sessionManager session.SessionManager, sessionKey string) func(next http.HandlerFunc) http.HandlerFunc {
+func NewAuthenticatedMiddleware(userService user.UserService, siteService site.SiteService, sessionManager session.SessionManager, errorHandlers errors.ErrorHandlers, sessionKey string) func(next http.HandlerFunc) http.HandlerFunc {
 	return func(next http.HandlerFunc) http.HandlerFunc {
-		return AuthenticatedMiddleware(userService, sessionManager, sessionKey, next)
+		return AuthenticatedMiddleware(userService, siteService, sessionManager, errorHandlers, sessionKey, next)
 	}
 }
 
-func AuthenticatedMiddleware(userService user.UserService, sessionManager session.SessionManager, sessionKey string, next http.HandlerFunc) http.HandlerFunc {
+func AuthenticatedMiddleware(userService user.UserService, siteService site.SiteService, sessionManager session.SessionManager, errorHandlers errors.ErrorHandlers, sessionKey string, next http.HandlerFunc) http.HandlerFunc {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		username := sessionManager.GetString(r.Context(), sessionKey)
 
@@ -25,12 +30,43 @@ func AuthenticatedMiddleware(userService user.UserService, sessionManager sessio
 
 		exists, err := userService.Exists(username)
 		if err != nil {
-			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			errorHandlers.InternalServerError(w, r, err)
 			return
 		}
 
 		if exists {
+			loggedInUser, err := userService.GetByAttribute("username", username)
+			if err != nil {
+				errorHandlers.InternalServerError(w, r, err)
+				return
+			}
+
+			// the password has changed since this session logged in
+			if loggedInUser.SessionVersion != sessionManager.GetInt(r.Context(), session.SESSION_VERSION) {
+				sessionManager.Remove(r.Context(), sessionKey)
+				sessionManager.Put(r.Context(), session.SESSION_KEY_MESSAGE, "Your session has expired. Please log in again.")
+				http.Redirect(w, r, "/admin/login", http.StatusSeeOther)
+				return
+			}
+
+			// when the site requires two-factor authentication, users without
+			// it can only get as far as setting it up, or logging out
+			if !twoFactorSetupPath(r.URL.Path) {
+				missing, err := twoFactorMissing(siteService, loggedInUser)
+				if err != nil {
+					errorHandlers.InternalServerError(w, r, err)
+					return
+				}
+
+				if missing {
+					sessionManager.Put(r.Context(), session.SESSION_KEY_MESSAGE, "You need to set up two-factor authentication before you can continue.")
+					http.Redirect(w, r, "/admin/account/two-factor", http.StatusSeeOther)
+					return
+				}
+			}
+
 			ctx := context.WithValue(r.Context(), session.IS_LOGGED_IN_CONTEXT_KEY, true)
+			ctx = logging.WithAttrs(ctx, slog.String("user", username))
 
 			r = r.WithContext(ctx)
 
@@ -41,3 +77,27 @@ func AuthenticatedMiddleware(userService user.UserService, sessionManager sessio
 		http.Redirect(w, r, "/admin/login", http.StatusSeeOther)
 	})
 }
+
+// twoFactorSetupPath is whether path is somewhere users who need to set up
+// two-factor authentication can still go. It's kept to exactly what's needed,
+// since anything else, e.g. creating API tokens, could get around it.
+func twoFactorSetupPath(path string) bool {
+	return path == "/admin/logout" ||
+		path == "/admin/account/two-factor" ||
+		strings.HasPrefix(path, "/admin/account/two-factor/")
+}
+
+// twoFactorMissing is whether the site requires two-factor authentication and
+// u hasn't set it up.
+func twoFactorMissing(siteService site.SiteService, u *user.UserResponseDto) (bool, error) {
+	if u.TwoFactorEnabled {
+		return false, nil
+	}
+
+	s, err := siteService.Get()
+	if err != nil {
+		return false, err
+	}
+
+	return s.RequireTwoFactor, nil
+}
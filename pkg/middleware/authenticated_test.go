@@ -0,0 +1,354 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nixpig/dunce/internal/site"
+	"github.com/nixpig/dunce/internal/user"
+	"github.com/nixpig/dunce/pkg/pagination"
+	"github.com/nixpig/dunce/pkg/session"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var mockUserService = new(MockUserService)
+var mockSiteService = new(MockSiteService)
+var mockSessionManager = new(MockSessionManager)
+var mockErrorHandlers = new(MockErrorHandlers)
+
+func TestAuthenticatedMiddleware(t *testing.T) {
+	scenarios := map[string]func(t *testing.T){
+		"two-factor required (redirect - not set up)":      testAuthenticatedTwoFactorRequired,
+		"two-factor required (success - setting it up)":    testAuthenticatedTwoFactorSetup,
+		"two-factor required (success - set up)":           testAuthenticatedTwoFactorEnabled,
+		"two-factor not required (success - not set up)":   testAuthenticatedTwoFactorNotRequired,
+		"two-factor setup paths (only setup and logout)":   testTwoFactorSetupPath,
+		"two-factor required (redirect - creating tokens)": testAuthenticatedTwoFactorRequiredTokens,
+	}
+
+	for scenario, fn := range scenarios {
+		t.Run(scenario, func(t *testing.T) {
+			fn(t)
+		})
+	}
+}
+
+// serveAuthenticated serves a request for path from loggedInUser through the
+// authenticated middleware, returning the response and whether it got through
+// to the next handler.
+func serveAuthenticated(
+	t *testing.T,
+	method, path string,
+	loggedInUser *user.UserResponseDto,
+	s *site.Site,
+) (*httptest.ResponseRecorder, bool) {
+	mockSessionManagerGetString := mockSessionManager.
+		On("GetString", mock.Anything, session.LOGGED_IN_USERNAME).
+		Return(loggedInUser.Username)
+	mockSessionManagerGetInt := mockSessionManager.
+		On("GetInt", mock.Anything, session.SESSION_VERSION).
+		Return(loggedInUser.SessionVersion)
+	mockSessionManagerPut := mockSessionManager.
+		On("Put", mock.Anything, session.SESSION_KEY_MESSAGE, mock.Anything)
+	mockUserServiceExists := mockUserService.
+		On("Exists", loggedInUser.Username).
+		Return(true, nil)
+	mockUserServiceGetByAttribute := mockUserService.
+		On("GetByAttribute", "username", loggedInUser.Username).
+		Return(loggedInUser, nil)
+	mockSiteServiceGet := mockSiteService.
+		On("Get").
+		Return(s, nil)
+
+	defer func() {
+		mockSessionManagerGetString.Unset()
+		mockSessionManagerGetInt.Unset()
+		mockSessionManagerPut.Unset()
+		mockUserServiceExists.Unset()
+		mockUserServiceGetByAttribute.Unset()
+		mockSiteServiceGet.Unset()
+	}()
+
+	served := false
+
+	handler := NewAuthenticatedMiddleware(
+		mockUserService,
+		mockSiteService,
+		mockSessionManager,
+		mockErrorHandlers,
+		session.LOGGED_IN_USERNAME,
+	)(func(w http.ResponseWriter, r *http.Request) {
+		served = true
+	})
+
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, httptest.NewRequest(method, path, nil))
+
+	return rr, served
+}
+
+func testAuthenticatedTwoFactorRequired(t *testing.T) {
+	rr, served := serveAuthenticated(
+		t,
+		"GET",
+		"/admin/articles",
+		&user.UserResponseDto{Username: "janedoe", SessionVersion: 1},
+		&site.Site{RequireTwoFactor: true},
+	)
+
+	require.False(t, served, "should not serve request")
+	require.Equal(t, http.StatusSeeOther, rr.Code, "should return status code see other")
+	require.Equal(t, "/admin/account/two-factor", rr.Header().Get("Location"), "should redirect to set up two-factor")
+}
+
+func testAuthenticatedTwoFactorRequiredTokens(t *testing.T) {
+	for _, path := range []string{
+		"/admin/account",
+		"/admin/account/tokens",
+		"/admin/account/sessions",
+	} {
+		rr, served := serveAuthenticated(
+			t,
+			"POST",
+			path,
+			&user.UserResponseDto{Username: "janedoe", SessionVersion: 1},
+			&site.Site{RequireTwoFactor: true},
+		)
+
+		require.False(t, served, "should not serve '%s'", path)
+		require.Equal(t, "/admin/account/two-factor", rr.Header().Get("Location"), "should redirect '%s' to set up two-factor", path)
+	}
+}
+
+func testAuthenticatedTwoFactorSetup(t *testing.T) {
+	for _, path := range []string{
+		"/admin/account/two-factor",
+		"/admin/account/two-factor/recovery-codes",
+	} {
+		_, served := serveAuthenticated(
+			t,
+			"POST",
+			path,
+			&user.UserResponseDto{Username: "janedoe", SessionVersion: 1},
+			&site.Site{RequireTwoFactor: true},
+		)
+
+		require.True(t, served, "should serve '%s'", path)
+	}
+}
+
+func testAuthenticatedTwoFactorEnabled(t *testing.T) {
+	_, served := serveAuthenticated(
+		t,
+		"POST",
+		"/admin/account/tokens",
+		&user.UserResponseDto{Username: "janedoe", SessionVersion: 1, TwoFactorEnabled: true},
+		&site.Site{RequireTwoFactor: true},
+	)
+
+	require.True(t, served, "should serve request")
+}
+
+func testAuthenticatedTwoFactorNotRequired(t *testing.T) {
+	_, served := serveAuthenticated(
+		t,
+		"GET",
+		"/admin/articles",
+		&user.UserResponseDto{Username: "janedoe", SessionVersion: 1},
+		&site.Site{},
+	)
+
+	require.True(t, served, "should serve request")
+}
+
+func testTwoFactorSetupPath(t *testing.T) {
+	scenarios := map[string]bool{
+		"/admin/logout":                            true,
+		"/admin/account/two-factor":                true,
+		"/admin/account/two-factor/disable":        true,
+		"/admin/account/two-factor-something-else": false,
+		"/admin/account":                           false,
+		"/admin/account/tokens":                    false,
+		"/admin/account/tokens/1/revoke":           false,
+	}
+
+	for path, expected := range scenarios {
+		require.Equal(t, expected, twoFactorSetupPath(path), "should know whether '%s' is for setting up two-factor", path)
+	}
+}
+
+type MockUserService struct {
+	mock.Mock
+}
+
+func (u *MockUserService) Create(newUser *user.UserNewRequestDto) (*user.UserResponseDto, error) {
+	args := u.Called(newUser)
+
+	return args.Get(0).(*user.UserResponseDto), args.Error(1)
+}
+
+func (u *MockUserService) DeleteById(id uint) error {
+	args := u.Called(id)
+
+	return args.Error(0)
+}
+
+func (u *MockUserService) Exists(username string) (bool, error) {
+	args := u.Called(username)
+
+	return args.Bool(0), args.Error(1)
+}
+
+func (u *MockUserService) GetAll(page pagination.Request) (*pagination.Page[user.UserResponseDto], error) {
+	args := u.Called(page)
+
+	return args.Get(0).(*pagination.Page[user.UserResponseDto]), args.Error(1)
+}
+
+func (u *MockUserService) GetByAttribute(attr, value string) (*user.UserResponseDto, error) {
+	args := u.Called(attr, value)
+
+	return args.Get(0).(*user.UserResponseDto), args.Error(1)
+}
+
+func (u *MockUserService) Update(updated *user.User) (*user.UserResponseDto, error) {
+	args := u.Called(updated)
+
+	return args.Get(0).(*user.UserResponseDto), args.Error(1)
+}
+
+func (u *MockUserService) UpdateRole(id uint, role user.Role) (*user.UserResponseDto, error) {
+	args := u.Called(id, role)
+
+	return args.Get(0).(*user.UserResponseDto), args.Error(1)
+}
+
+func (u *MockUserService) UpdateProfile(id uint, profile *user.Profile) (*user.UserResponseDto, error) {
+	args := u.Called(id, profile)
+
+	return args.Get(0).(*user.UserResponseDto), args.Error(1)
+}
+
+func (u *MockUserService) ChangePassword(username string, change *user.UserPasswordChangeRequestDto) (int, error) {
+	args := u.Called(username, change)
+
+	return args.Int(0), args.Error(1)
+}
+
+func (u *MockUserService) LoginWithUsernamePassword(username, password string) error {
+	args := u.Called(username, password)
+
+	return args.Error(0)
+}
+
+type MockSiteService struct {
+	mock.Mock
+}
+
+func (m *MockSiteService) Create(key site.Key, value string) (*site.SiteItemResponseDto, error) {
+	args := m.Called(key, value)
+
+	return args.Get(0).(*site.SiteItemResponseDto), args.Error(1)
+}
+
+func (m *MockSiteService) Get() (*site.Site, error) {
+	args := m.Called()
+
+	return args.Get(0).(*site.Site), args.Error(1)
+}
+
+func (m *MockSiteService) GetAll() (*[]site.SettingResponseDto, error) {
+	args := m.Called()
+
+	return args.Get(0).(*[]site.SettingResponseDto), args.Error(1)
+}
+
+func (m *MockSiteService) GetByKey(key site.Key) (*site.SettingResponseDto, error) {
+	args := m.Called(key)
+
+	return args.Get(0).(*site.SettingResponseDto), args.Error(1)
+}
+
+func (m *MockSiteService) Update(values map[site.Key]string) error {
+	args := m.Called(values)
+
+	return args.Error(0)
+}
+
+func (m *MockSiteService) DeleteByKey(key site.Key) error {
+	args := m.Called(key)
+
+	return args.Error(0)
+}
+
+type MockSessionManager struct {
+	mock.Mock
+}
+
+func (s *MockSessionManager) Exists(ctx context.Context, key string) bool {
+	args := s.Called(ctx, key)
+
+	return args.Bool(0)
+}
+
+func (s *MockSessionManager) PopString(ctx context.Context, key string) string {
+	args := s.Called(ctx, key)
+
+	return args.String(0)
+}
+
+func (s *MockSessionManager) GetString(ctx context.Context, key string) string {
+	args := s.Called(ctx, key)
+
+	return args.String(0)
+}
+
+func (s *MockSessionManager) GetInt(ctx context.Context, key string) int {
+	args := s.Called(ctx, key)
+
+	return args.Int(0)
+}
+
+func (s *MockSessionManager) LoadAndSave(next http.Handler) http.Handler {
+	args := s.Called(next)
+
+	return args.Get(0).(http.Handler)
+}
+
+func (s *MockSessionManager) RenewToken(ctx context.Context) error {
+	args := s.Called(ctx)
+
+	return args.Error(0)
+}
+
+func (s *MockSessionManager) Put(ctx context.Context, key string, val interface{}) {
+	s.Called(ctx, key, val)
+}
+
+func (s *MockSessionManager) Remove(ctx context.Context, key string) {
+	s.Called(ctx, key)
+}
+
+type MockErrorHandlers struct {
+	mock.Mock
+}
+
+func (e *MockErrorHandlers) NotFound(w http.ResponseWriter, r *http.Request) {
+	e.Called(w, r)
+}
+
+func (e *MockErrorHandlers) InternalServerError(w http.ResponseWriter, r *http.Request, err error) {
+	e.Called(w, r, err)
+}
+
+func (e *MockErrorHandlers) BadRequest(w http.ResponseWriter, r *http.Request) {
+	e.Called(w, r)
+}
+
+func (e *MockErrorHandlers) Forbidden(w http.ResponseWriter, r *http.Request) {
+	e.Called(w, r)
+}
@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/nixpig/dunce/internal/site"
+	"github.com/nixpig/dunce/internal/user"
+	"github.com/nixpig/dunce/pkg/logging"
+	"github.com/nixpig/dunce/pkg/session"
+)
+
+func NewBearerTokenMiddleware(apiTokenService user.ApiTokenService, userService user.UserService, siteService site.SiteService, log logging.Logger) func(next http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return BearerTokenMiddleware(apiTokenService, userService, siteService, log, next)
+	}
+}
+
+// BearerTokenMiddleware authenticates API requests as the user whose token is
+// in the Authorization header. It's the API's counterpart to the
+// authenticated middleware, and the permission middleware applied inside it
+// checks the token's scopes as well as the user's role.
+func BearerTokenMiddleware(apiTokenService user.ApiTokenService, userService user.UserService, siteService site.SiteService, log logging.Logger, next http.HandlerFunc) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scheme, token, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+		if !strings.EqualFold(scheme, "bearer") || token == "" {
+			unauthorised(w, "missing api token")
+			return
+		}
+
+		apiToken, err := apiTokenService.Authenticate(strings.TrimSpace(token))
+		if err != nil {
+			if err == user.ErrInvalidApiToken {
+				unauthorised(w, "invalid or expired api token")
+				return
+			}
+
+			logInternalServerError(log, r, fmt.Errorf("unable to check api token: %w", err))
+			writeJsonError(w, http.StatusInternalServerError, "unable to check api token")
+			return
+		}
+
+		tokenUser, err := userService.GetByAttribute("username", apiToken.Username)
+		if err != nil {
+			logInternalServerError(log, r, fmt.Errorf("unable to get api token user: %w", err))
+			writeJsonError(w, http.StatusInternalServerError, "unable to get api token user")
+			return
+		}
+
+		// tokens don't get around the site requiring two-factor authentication,
+		// e.g. ones created before it was required
+		missing, err := twoFactorMissing(siteService, tokenUser)
+		if err != nil {
+			logInternalServerError(log, r, fmt.Errorf("unable to check two-factor authentication: %w", err))
+			writeJsonError(w, http.StatusInternalServerError, "unable to check two-factor authentication")
+			return
+		}
+
+		if missing {
+			writeJsonError(w, http.StatusForbidden, "two-factor authentication needs to be set up to use the api")
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), session.IS_LOGGED_IN_CONTEXT_KEY, true)
+		ctx = context.WithValue(ctx, session.LOGGED_IN_USER_CONTEXT_KEY, tokenUser)
+		ctx = context.WithValue(ctx, session.API_TOKEN_CONTEXT_KEY, apiToken)
+		ctx = logging.WithAttrs(ctx, slog.String("user", tokenUser.Username))
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func unauthorised(w http.ResponseWriter, message string) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+
+	writeJsonError(w, http.StatusUnauthorized, message)
+}
+
+func logInternalServerError(log logging.Logger, r *http.Request, err error) {
+	log.Error(
+		r.Context(),
+		"internal server error",
+		"method", r.Method,
+		"path", r.URL.Path,
+		"err", err,
+	)
+}
+
+func writeJsonError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
@@ -0,0 +1,238 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nixpig/dunce/internal/site"
+	"github.com/nixpig/dunce/internal/user"
+	"github.com/nixpig/dunce/pkg/session"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var mockApiTokenService = new(MockApiTokenService)
+var mockLogger = new(MockLogger)
+
+func TestBearerTokenMiddleware(t *testing.T) {
+	scenarios := map[string]func(t *testing.T){
+		"valid token (success)":                         testBearerValidToken,
+		"missing token (unauthorised)":                  testBearerMissingToken,
+		"invalid token (unauthorised)":                  testBearerInvalidToken,
+		"two-factor required (forbidden - not set up)":  testBearerTwoFactorRequired,
+		"two-factor required (success - set up)":        testBearerTwoFactorEnabled,
+		"two-factor check (error - unable to get site)": testBearerTwoFactorSiteError,
+	}
+
+	for scenario, fn := range scenarios {
+		t.Run(scenario, func(t *testing.T) {
+			fn(t)
+		})
+	}
+}
+
+// serveBearer serves a request with the token for tokenUser through the bearer
+// token middleware, returning the response and whether it got through to the
+// next handler.
+func serveBearer(
+	t *testing.T,
+	tokenUser *user.UserResponseDto,
+	s *site.Site,
+	siteErr error,
+) (*httptest.ResponseRecorder, bool) {
+	apiToken := &user.ApiToken{Id: 1, Username: tokenUser.Username}
+
+	mockApiTokenServiceAuthenticate := mockApiTokenService.
+		On("Authenticate", "dunce_token").
+		Return(apiToken, nil)
+	mockUserServiceGetByAttribute := mockUserService.
+		On("GetByAttribute", "username", tokenUser.Username).
+		Return(tokenUser, nil)
+	mockSiteServiceGet := mockSiteService.
+		On("Get").
+		Return(s, siteErr)
+	mockLoggerError := mockLogger.
+		On("Error", "internal server error", mock.Anything)
+
+	defer func() {
+		mockApiTokenServiceAuthenticate.Unset()
+		mockUserServiceGetByAttribute.Unset()
+		mockSiteServiceGet.Unset()
+		mockLoggerError.Unset()
+	}()
+
+	served := false
+
+	handler := NewBearerTokenMiddleware(
+		mockApiTokenService,
+		mockUserService,
+		mockSiteService,
+		mockLogger,
+	)(func(w http.ResponseWriter, r *http.Request) {
+		served = true
+
+		require.Equal(t, tokenUser, r.Context().Value(session.LOGGED_IN_USER_CONTEXT_KEY), "should add token user to context")
+		require.Equal(t, apiToken, r.Context().Value(session.API_TOKEN_CONTEXT_KEY), "should add token to context")
+	})
+
+	req := httptest.NewRequest("GET", "/api/articles", nil)
+	req.Header.Set("Authorization", "Bearer dunce_token")
+
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	return rr, served
+}
+
+func testBearerValidToken(t *testing.T) {
+	rr, served := serveBearer(
+		t,
+		&user.UserResponseDto{Username: "janedoe"},
+		&site.Site{},
+		nil,
+	)
+
+	require.True(t, served, "should serve request")
+	require.Equal(t, http.StatusOK, rr.Code, "should return status code ok")
+}
+
+func testBearerMissingToken(t *testing.T) {
+	handler := BearerTokenMiddleware(
+		mockApiTokenService,
+		mockUserService,
+		mockSiteService,
+		mockLogger,
+		func(w http.ResponseWriter, r *http.Request) {
+			t.Error("should not serve request")
+		},
+	)
+
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/api/articles", nil))
+
+	require.Equal(t, http.StatusUnauthorized, rr.Code, "should return status code unauthorised")
+	require.Equal(t, `Bearer realm="api"`, rr.Header().Get("WWW-Authenticate"), "should challenge for bearer token")
+}
+
+func testBearerInvalidToken(t *testing.T) {
+	mockApiTokenServiceAuthenticate := mockApiTokenService.
+		On("Authenticate", "not_a_token").
+		Return(&user.ApiToken{}, user.ErrInvalidApiToken)
+
+	defer mockApiTokenServiceAuthenticate.Unset()
+
+	handler := BearerTokenMiddleware(
+		mockApiTokenService,
+		mockUserService,
+		mockSiteService,
+		mockLogger,
+		func(w http.ResponseWriter, r *http.Request) {
+			t.Error("should not serve request")
+		},
+	)
+
+	req := httptest.NewRequest("GET", "/api/articles", nil)
+	req.Header.Set("Authorization", "Bearer not_a_token")
+
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusUnauthorized, rr.Code, "should return status code unauthorised")
+	require.JSONEq(t, `{"error":"invalid or expired api token"}`, rr.Body.String(), "should return error")
+}
+
+func testBearerTwoFactorRequired(t *testing.T) {
+	rr, served := serveBearer(
+		t,
+		&user.UserResponseDto{Username: "janedoe"},
+		&site.Site{RequireTwoFactor: true},
+		nil,
+	)
+
+	require.False(t, served, "should not serve request")
+	require.Equal(t, http.StatusForbidden, rr.Code, "should return status code forbidden")
+	require.JSONEq(
+		t,
+		`{"error":"two-factor authentication needs to be set up to use the api"}`,
+		rr.Body.String(),
+		"should return error",
+	)
+}
+
+func testBearerTwoFactorEnabled(t *testing.T) {
+	rr, served := serveBearer(
+		t,
+		&user.UserResponseDto{Username: "janedoe", TwoFactorEnabled: true},
+		&site.Site{RequireTwoFactor: true},
+		nil,
+	)
+
+	require.True(t, served, "should serve request")
+	require.Equal(t, http.StatusOK, rr.Code, "should return status code ok")
+}
+
+func testBearerTwoFactorSiteError(t *testing.T) {
+	rr, served := serveBearer(
+		t,
+		&user.UserResponseDto{Username: "janedoe"},
+		&site.Site{},
+		errors.New("db_error"),
+	)
+
+	require.False(t, served, "should not serve request")
+	require.Equal(t, http.StatusInternalServerError, rr.Code, "should return status code internal server error")
+}
+
+type MockApiTokenService struct {
+	mock.Mock
+}
+
+func (m *MockApiTokenService) GetAll(username string) ([]user.ApiToken, error) {
+	args := m.Called(username)
+
+	return args.Get(0).([]user.ApiToken), args.Error(1)
+}
+
+func (m *MockApiTokenService) Create(username string, token *user.ApiTokenNewRequestDto) (*user.ApiToken, string, error) {
+	args := m.Called(username, token)
+
+	return args.Get(0).(*user.ApiToken), args.String(1), args.Error(2)
+}
+
+func (m *MockApiTokenService) Authenticate(token string) (*user.ApiToken, error) {
+	args := m.Called(token)
+
+	return args.Get(0).(*user.ApiToken), args.Error(1)
+}
+
+func (m *MockApiTokenService) Revoke(username string, id uint) error {
+	args := m.Called(username, id)
+
+	return args.Error(0)
+}
+
+type MockLogger struct {
+	mock.Mock
+}
+
+func (l *MockLogger) Debug(ctx context.Context, msg string, args ...any) {
+	l.Called(msg, args)
+}
+
+func (l *MockLogger) Info(ctx context.Context, msg string, args ...any) {
+	l.Called(msg, args)
+}
+
+func (l *MockLogger) Warn(ctx context.Context, msg string, args ...any) {
+	l.Called(msg, args)
+}
+
+func (l *MockLogger) Error(ctx context.Context, msg string, args ...any) {
+	l.Called(msg, args)
+}
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/nixpig/dunce/pkg/metrics"
+)
+
+// unmatchedRoute is the route recorded for requests no route matched.
+const unmatchedRoute = "unmatched"
+
+func NewMetricsMiddleware(route func(r *http.Request) string) func(next http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return MetricsMiddleware(route, next)
+	}
+}
+
+// MetricsMiddleware records how long each request takes and the status it's
+// served with. Requests are recorded against the pattern of the route they
+// matched, e.g. "GET /articles/{slug}", rather than their path, so there's a
+// series per route rather than one per article.
+func MetricsMiddleware(route func(r *http.Request) string, next http.HandlerFunc) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		pattern := route(r)
+		if pattern == "" {
+			pattern = unmatchedRoute
+		}
+
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(recorder, r)
+
+		metrics.ObserveRequest(pattern, recorder.status, time.Since(start))
+	})
+}
+
+// statusRecorder records the status a response is written with.
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	if !s.wroteHeader {
+		s.status = status
+		s.wroteHeader = true
+	}
+
+	s.ResponseWriter.WriteHeader(status)
+}
+
+func (s *statusRecorder) Write(b []byte) (int, error) {
+	s.wroteHeader = true
+
+	return s.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer, e.g. to
+// flush it.
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/nixpig/dunce/internal/app/errors"
+	"github.com/nixpig/dunce/internal/user"
+	"github.com/nixpig/dunce/pkg/session"
+)
+
+// NewPermissionMiddleware returns a function to build middleware that only
+// lets through logged in users whose role allows the given permission. It
+// should be applied inside the protected middleware, or the bearer token
+// middleware for API routes.
+func NewPermissionMiddleware(
+	userService user.UserService,
+	sessionManager session.SessionManager,
+	errorHandlers errors.ErrorHandlers,
+) func(permission user.Permission) func(next http.HandlerFunc) http.HandlerFunc {
+	return func(permission user.Permission) func(next http.HandlerFunc) http.HandlerFunc {
+		return func(next http.HandlerFunc) http.HandlerFunc {
+			return PermissionMiddleware(userService, sessionManager, errorHandlers, permission, next)
+		}
+	}
+}
+
+func PermissionMiddleware(
+	userService user.UserService,
+	sessionManager session.SessionManager,
+	errorHandlers errors.ErrorHandlers,
+	permission user.Permission,
+	next http.HandlerFunc,
+) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// API requests were authenticated by the bearer token middleware, and
+		// are limited by their token's scopes as well as the user's role
+		if apiToken, ok := r.Context().Value(session.API_TOKEN_CONTEXT_KEY).(*user.ApiToken); ok {
+			tokenUser, ok := r.Context().Value(session.LOGGED_IN_USER_CONTEXT_KEY).(*user.UserResponseDto)
+			if !ok || !tokenUser.Role.Can(permission) || !apiToken.Allows(permission) {
+				writeJsonError(w, http.StatusForbidden, "api token doesn't allow this")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		username := sessionManager.GetString(r.Context(), session.LOGGED_IN_USERNAME)
+
+		loggedInUser, err := userService.GetByAttribute("username", username)
+		if err != nil {
+			errorHandlers.Forbidden(w, r)
+			return
+		}
+
+		if !loggedInUser.Role.Can(permission) {
+			errorHandlers.Forbidden(w, r)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), session.LOGGED_IN_USER_CONTEXT_KEY, loggedInUser)
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
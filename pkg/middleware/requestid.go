@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/nixpig/dunce/pkg/logging"
+)
+
+const RequestIdHeader = "X-Request-ID"
+
+// validRequestId is what a request ID given by a proxy in front of the app
+// has to look like to be used rather than replaced.
+var validRequestId = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+func NewRequestIdMiddleware(route func(r *http.Request) string) func(next http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return RequestIdMiddleware(route, next)
+	}
+}
+
+// RequestIdMiddleware gives each request an ID, which is returned in the
+// X-Request-ID header and logged, along with the route the request matched,
+// with everything logged while serving it. A proxy in front of the app can
+// set the ID, so its logs and the app's can be matched up.
+func RequestIdMiddleware(route func(r *http.Request) string, next http.HandlerFunc) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIdHeader)
+		if !validRequestId.MatchString(id) {
+			id = newRequestId()
+		}
+
+		pattern := route(r)
+		if pattern == "" {
+			pattern = unmatchedRoute
+		}
+
+		w.Header().Set(RequestIdHeader, id)
+
+		ctx := logging.WithAttrs(
+			r.Context(),
+			slog.String("request_id", id),
+			slog.String("route", pattern),
+		)
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func newRequestId() string {
+	b := make([]byte, 16)
+
+	// IDs only need to tell requests apart, so the time will do if there's no
+	// randomness to be had
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,426 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+type Direction string
+
+const (
+	Asc  Direction = "asc"
+	Desc Direction = "desc"
+)
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+
+	// MaxOffset is as deep as a list can be paged by offset. The database has
+	// to walk every skipped row, so anything further has to be paged by key
+	// with the cursors handed out on each page.
+	MaxOffset = 10000
+
+	// Unlimited returns every row. It's only for internal callers that really
+	// need the whole table, like the tag picker on the article form, and is
+	// never accepted from a query string.
+	Unlimited = -1
+)
+
+// Request describes the page of a list to fetch. Pages are addressed by
+// offset unless Keyset is set (or a cursor is given), in which case they're
+// addressed by the After/Before cursors handed out with the previous page.
+type Request struct {
+	Limit     int
+	Offset    int
+	Sort      string
+	Direction Direction
+	Keyset    bool
+	After     string
+	Before    string
+}
+
+// FromQuery reads a Request from the limit, offset, sort, dir, after and before
+// query string parameters. Anything missing or invalid is left for Normalise
+// to replace with a default.
+func FromQuery(values url.Values) Request {
+	limit, _ := strconv.Atoi(values.Get("limit"))
+	if limit < 1 {
+		limit = 0
+	}
+
+	offset, _ := strconv.Atoi(values.Get("offset"))
+
+	after := values.Get("after")
+	before := values.Get("before")
+
+	return Request{
+		Limit:     limit,
+		Offset:    offset,
+		Sort:      values.Get("sort"),
+		Direction: Direction(strings.ToLower(values.Get("dir"))),
+		Keyset:    after != "" || before != "",
+		After:     after,
+		Before:    before,
+	}
+}
+
+// Column is a field a list can be sorted by. Expr must never be null, so
+// nullable columns should be wrapped in a coalesce. Type is the Postgres type
+// used to cast cursor values back when comparing against Expr.
+type Column struct {
+	Expr string
+	Type string
+}
+
+// Sortable describes how a list can be ordered. Id is a unique column used to
+// break ties, so every row has a stable position for keyset pagination.
+type Sortable struct {
+	Columns          map[string]Column
+	Id               string
+	DefaultSort      string
+	DefaultDirection Direction
+}
+
+// Normalise replaces missing or invalid values in r with defaults.
+func (s Sortable) Normalise(r Request) Request {
+	if r.Limit == 0 || r.Limit < Unlimited {
+		r.Limit = DefaultLimit
+	}
+
+	if r.Limit > MaxLimit {
+		r.Limit = MaxLimit
+	}
+
+	if r.Offset < 0 {
+		r.Offset = 0
+	}
+
+	if r.Offset > MaxOffset {
+		r.Offset = MaxOffset
+	}
+
+	if _, ok := s.Columns[r.Sort]; !ok {
+		r.Sort = s.DefaultSort
+	}
+
+	if r.Direction != Asc && r.Direction != Desc {
+		r.Direction = s.DefaultDirection
+	}
+
+	if r.After != "" || r.Before != "" {
+		r.Keyset = true
+		r.Offset = 0
+	}
+
+	return r
+}
+
+// Query holds the SQL fragments for fetching a page. Repositories splice them
+// into their own queries:
+//
+//	select ..., <Cursor> from ... where ... and <Where> order by <OrderBy> <Limit>
+//
+// and pass Args after their own arguments.
+type Query struct {
+	Cursor  string
+	Where   string
+	OrderBy string
+	Limit   string
+	Args    []any
+
+	request Request
+	reverse bool
+}
+
+// Query builds the SQL fragments for r, numbering placeholders from argStart.
+// A cursor that can't be decoded, or was handed out for a different sort,
+// falls back to the first page rather than failing the whole request.
+func (s Sortable) Query(r Request, argStart int) Query {
+	r = s.Normalise(r)
+
+	column := s.Columns[r.Sort]
+
+	q := Query{
+		Cursor:  fmt.Sprintf("(%s)::text", column.Expr),
+		Where:   "true",
+		request: r,
+	}
+
+	direction := r.Direction
+	arg := argStart
+
+	if r.Keyset && (r.After != "" || r.Before != "") {
+		encoded := r.After
+		if encoded == "" {
+			encoded = r.Before
+			// walk backwards from the cursor, then put the rows back in order
+			q.reverse = true
+			direction = flip(direction)
+		}
+
+		c, err := decodeCursor(encoded)
+		if err != nil || c.Sort != r.Sort {
+			r.After, r.Before = "", ""
+
+			return s.Query(r, argStart)
+		}
+
+		comparison := ">"
+		if direction == Desc {
+			comparison = "<"
+		}
+
+		q.Where = fmt.Sprintf("(%s, %s) %s ($%d::%s, $%d)", column.Expr, s.Id, comparison, arg, column.Type, arg+1)
+		q.Args = append(q.Args, c.Value, c.Id)
+		arg += 2
+	}
+
+	q.OrderBy = fmt.Sprintf("%s %s, %s %s", column.Expr, direction, s.Id, direction)
+
+	// fetch one extra row to find out whether there's another page beyond
+	// this one, since a page fetched by cursor doesn't know its position
+	switch {
+	case r.Limit == Unlimited:
+		q.Limit = fmt.Sprintf("offset $%d", arg)
+		q.Args = append(q.Args, r.Offset)
+	case r.Keyset:
+		q.Limit = fmt.Sprintf("limit $%d", arg)
+		q.Args = append(q.Args, r.Limit+1)
+	default:
+		q.Limit = fmt.Sprintf("limit $%d offset $%d", arg, arg+1)
+		q.Args = append(q.Args, r.Limit+1, r.Offset)
+	}
+
+	return q
+}
+
+// Key identifies a row's position in a sorted list: the text value of the
+// sort column (selected with Query.Cursor) and the row's unique id.
+type Key struct {
+	Value string
+	Id    int64
+}
+
+type Page[T any] struct {
+	Items   []T
+	Total   int
+	Request Request
+
+	// Params are carried over into the links to other pages, for lists that
+	// take more than pagination from the query string, like a search term.
+	Params url.Values
+
+	hasNext bool
+	hasPrev bool
+	next    string
+	prev    string
+}
+
+// NewPage builds the page for q from the rows fetched with it, keys holding
+// the position of each item and total the size of the whole list.
+func NewPage[T any](q Query, items []T, keys []Key, total int) *Page[T] {
+	r := q.request
+
+	more := r.Limit != Unlimited && len(items) > r.Limit
+	if more {
+		items = items[:r.Limit]
+		keys = keys[:r.Limit]
+	}
+
+	if q.reverse {
+		for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+			items[i], items[j] = items[j], items[i]
+			keys[i], keys[j] = keys[j], keys[i]
+		}
+	}
+
+	if items == nil {
+		items = []T{}
+	}
+
+	p := Page[T]{
+		Items:   items,
+		Total:   total,
+		Request: r,
+	}
+
+	// offset pages get cursors too, so the page at MaxOffset can still link
+	// on to the one after it
+	if len(keys) > 0 {
+		p.prev = encodeCursor(cursor{Sort: r.Sort, Value: keys[0].Value, Id: keys[0].Id})
+		p.next = encodeCursor(cursor{Sort: r.Sort, Value: keys[len(keys)-1].Value, Id: keys[len(keys)-1].Id})
+	}
+
+	if !r.Keyset {
+		p.hasPrev = r.Offset > 0
+		p.hasNext = more || (r.Limit != Unlimited && r.Offset+len(items) < total)
+
+		return &p
+	}
+
+	if q.reverse {
+		p.hasPrev = more
+		p.hasNext = true
+	} else {
+		p.hasPrev = r.After != ""
+		p.hasNext = more
+	}
+
+	return &p
+}
+
+// Map converts the items on a page, keeping its position in the list.
+func Map[T, U any](p *Page[T], fn func(T) U) *Page[U] {
+	items := make([]U, len(p.Items))
+	for index, item := range p.Items {
+		items[index] = fn(item)
+	}
+
+	return &Page[U]{
+		Items:   items,
+		Total:   p.Total,
+		Request: p.Request,
+		Params:  p.Params,
+		hasNext: p.hasNext,
+		hasPrev: p.hasPrev,
+		next:    p.next,
+		prev:    p.prev,
+	}
+}
+
+func (p Page[T]) HasNext() bool {
+	return p.hasNext
+}
+
+func (p Page[T]) HasPrev() bool {
+	return p.hasPrev
+}
+
+// First is the 1-based position in the list of the first item on the page,
+// or 0 when the position isn't known because the page was fetched by cursor.
+func (p Page[T]) First() int {
+	if p.Request.Keyset || len(p.Items) == 0 {
+		return 0
+	}
+
+	return p.Request.Offset + 1
+}
+
+// Last is the 1-based position in the list of the last item on the page, or 0
+// when it isn't known.
+func (p Page[T]) Last() int {
+	if p.First() == 0 {
+		return 0
+	}
+
+	return p.Request.Offset + len(p.Items)
+}
+
+// NextQuery is the query string for the page after this one. Offset pages
+// switch to the cursor once the next offset would be past MaxOffset.
+func (p Page[T]) NextQuery() template.URL {
+	values := p.values(p.Request.Sort, p.Request.Direction)
+
+	if p.Request.Keyset || p.Request.Offset+p.Request.Limit > MaxOffset {
+		values.Set("after", p.next)
+	} else {
+		values.Set("offset", strconv.Itoa(p.Request.Offset+p.Request.Limit))
+	}
+
+	return template.URL(values.Encode())
+}
+
+// PrevQuery is the query string for the page before this one.
+func (p Page[T]) PrevQuery() template.URL {
+	values := p.values(p.Request.Sort, p.Request.Direction)
+
+	if p.Request.Keyset {
+		values.Set("before", p.prev)
+	} else if offset := p.Request.Offset - p.Request.Limit; offset > 0 {
+		values.Set("offset", strconv.Itoa(offset))
+	}
+
+	return template.URL(values.Encode())
+}
+
+// SortQuery is the query string for the first page sorted by field, toggling
+// the direction if the list is already sorted by it.
+func (p Page[T]) SortQuery(field string) template.URL {
+	direction := Asc
+	if field == p.Request.Sort && p.Request.Direction == Asc {
+		direction = Desc
+	}
+
+	return template.URL(p.values(field, direction).Encode())
+}
+
+// SortIndicator is an arrow showing the direction the list is sorted in, if
+// it's sorted by field.
+func (p Page[T]) SortIndicator(field string) string {
+	if field != p.Request.Sort {
+		return ""
+	}
+
+	if p.Request.Direction == Asc {
+		return "↑"
+	}
+
+	return "↓"
+}
+
+func (p Page[T]) values(sort string, direction Direction) url.Values {
+	values := url.Values{}
+	for key, value := range p.Params {
+		values[key] = value
+	}
+
+	if p.Request.Limit != DefaultLimit && p.Request.Limit != Unlimited {
+		values.Set("limit", strconv.Itoa(p.Request.Limit))
+	}
+
+	values.Set("sort", sort)
+	values.Set("dir", string(direction))
+
+	return values
+}
+
+type cursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	Id    int64  `json:"i"`
+}
+
+func encodeCursor(c cursor) string {
+	b, _ := json.Marshal(c)
+
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(encoded string) (cursor, error) {
+	var c cursor
+
+	b, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return c, err
+	}
+
+	if err := json.Unmarshal(b, &c); err != nil {
+		return c, err
+	}
+
+	return c, nil
+}
+
+func flip(d Direction) Direction {
+	if d == Asc {
+		return Desc
+	}
+
+	return Asc
+}
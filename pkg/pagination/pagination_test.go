@@ -0,0 +1,257 @@
+package pagination
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var testSortable = Sortable{
+	Columns: map[string]Column{
+		"id":   {Expr: "id_", Type: "integer"},
+		"name": {Expr: "name_", Type: "text"},
+	},
+	Id:               "id_",
+	DefaultSort:      "name",
+	DefaultDirection: Asc,
+}
+
+func TestPagination(t *testing.T) {
+	scenarios := map[string]func(t *testing.T){
+		"from query":                    testFromQuery,
+		"normalise defaults":            testNormaliseDefaults,
+		"normalise caps limit":          testNormaliseCapsLimit,
+		"normalise caps offset":         testNormaliseCapsOffset,
+		"offset query":                  testOffsetQuery,
+		"unlimited query":               testUnlimitedQuery,
+		"keyset after query":            testKeysetAfterQuery,
+		"keyset before query":           testKeysetBeforeQuery,
+		"invalid cursor first page":     testInvalidCursorFirstPage,
+		"cursor for other sort":         testCursorForOtherSort,
+		"offset page":                   testOffsetPage,
+		"offset page at max offset":     testOffsetPageAtMaxOffset,
+		"keyset page":                   testKeysetPage,
+		"keyset page before":            testKeysetPageBefore,
+		"empty page":                    testEmptyPage,
+		"map page":                      testMapPage,
+		"sort query and indicator":      testSortQueryAndIndicator,
+		"page queries carry params":     testPageQueriesCarryParams,
+		"first and last unknown by key": testFirstAndLastKeyset,
+	}
+
+	for scenario, fn := range scenarios {
+		t.Run(scenario, func(t *testing.T) {
+			fn(t)
+		})
+	}
+}
+
+func testFromQuery(t *testing.T) {
+	r := FromQuery(url.Values{
+		"limit":  {"5"},
+		"offset": {"10"},
+		"sort":   {"id"},
+		"dir":    {"DESC"},
+	})
+
+	require.Equal(t, Request{
+		Limit:     5,
+		Offset:    10,
+		Sort:      "id",
+		Direction: Desc,
+	}, r, "should read request from query")
+
+	r = FromQuery(url.Values{"limit": {"-1"}, "after": {"abc"}})
+
+	require.Equal(t, 0, r.Limit, "should not accept unlimited from query")
+	require.True(t, r.Keyset, "should page by key when given a cursor")
+}
+
+func testNormaliseDefaults(t *testing.T) {
+	r := testSortable.Normalise(Request{Sort: "nope", Direction: "sideways", Offset: -3})
+
+	require.Equal(t, Request{
+		Limit:     DefaultLimit,
+		Sort:      "name",
+		Direction: Asc,
+	}, r, "should replace invalid values with defaults")
+}
+
+func testNormaliseCapsLimit(t *testing.T) {
+	r := testSortable.Normalise(Request{Limit: 1000})
+
+	require.Equal(t, MaxLimit, r.Limit, "should cap limit")
+}
+
+func testNormaliseCapsOffset(t *testing.T) {
+	r := testSortable.Normalise(Request{Offset: 1000000000})
+
+	require.Equal(t, MaxOffset, r.Offset, "should cap offset")
+}
+
+func testOffsetQuery(t *testing.T) {
+	q := testSortable.Query(Request{Limit: 10, Offset: 20, Sort: "id", Direction: Desc}, 2)
+
+	require.Equal(t, "(id_)::text", q.Cursor)
+	require.Equal(t, "true", q.Where)
+	require.Equal(t, "id_ desc, id_ desc", q.OrderBy)
+	require.Equal(t, "limit $2 offset $3", q.Limit)
+	require.Equal(t, []any{11, 20}, q.Args, "should fetch one extra row")
+}
+
+func testUnlimitedQuery(t *testing.T) {
+	q := testSortable.Query(Request{Limit: Unlimited}, 1)
+
+	require.Equal(t, "name_ asc, id_ asc", q.OrderBy)
+	require.Equal(t, "offset $1", q.Limit)
+	require.Equal(t, []any{0}, q.Args)
+}
+
+func testKeysetAfterQuery(t *testing.T) {
+	after := encodeCursor(cursor{Sort: "name", Value: "go", Id: 7})
+
+	q := testSortable.Query(Request{Limit: 10, After: after}, 1)
+
+	require.Equal(t, "(name_, id_) > ($1::text, $2)", q.Where)
+	require.Equal(t, "name_ asc, id_ asc", q.OrderBy)
+	require.Equal(t, "limit $3", q.Limit)
+	require.Equal(t, []any{"go", int64(7), 11}, q.Args)
+}
+
+func testKeysetBeforeQuery(t *testing.T) {
+	before := encodeCursor(cursor{Sort: "name", Value: "go", Id: 7})
+
+	q := testSortable.Query(Request{Limit: 10, Before: before}, 1)
+
+	require.Equal(t, "(name_, id_) < ($1::text, $2)", q.Where, "should walk backwards")
+	require.Equal(t, "name_ desc, id_ desc", q.OrderBy, "should walk backwards")
+	require.True(t, q.reverse, "should reverse rows")
+}
+
+func testInvalidCursorFirstPage(t *testing.T) {
+	q := testSortable.Query(Request{Limit: 10, After: "!!not a cursor"}, 1)
+
+	require.Equal(t, "true", q.Where, "should fall back to first page")
+	require.Equal(t, "limit $1", q.Limit)
+	require.Equal(t, []any{11}, q.Args)
+}
+
+func testCursorForOtherSort(t *testing.T) {
+	after := encodeCursor(cursor{Sort: "id", Value: "7", Id: 7})
+
+	q := testSortable.Query(Request{Limit: 10, After: after}, 1)
+
+	require.Equal(t, "true", q.Where, "should fall back to first page")
+}
+
+func testOffsetPage(t *testing.T) {
+	q := testSortable.Query(Request{Limit: 2, Offset: 2}, 1)
+
+	p := NewPage(q, []string{"c", "d", "e"}, []Key{{"c", 3}, {"d", 4}, {"e", 5}}, 5)
+
+	require.Equal(t, []string{"c", "d"}, p.Items, "should trim extra row")
+	require.Equal(t, 5, p.Total)
+	require.True(t, p.HasPrev())
+	require.True(t, p.HasNext())
+	require.Equal(t, 3, p.First())
+	require.Equal(t, 4, p.Last())
+	require.Equal(t, "dir=asc&limit=2&offset=4&sort=name", string(p.NextQuery()))
+	require.Equal(t, "dir=asc&limit=2&sort=name", string(p.PrevQuery()))
+}
+
+func testOffsetPageAtMaxOffset(t *testing.T) {
+	q := testSortable.Query(Request{Limit: 2, Offset: MaxOffset}, 1)
+
+	p := NewPage(q, []string{"c", "d", "e"}, []Key{{"c", 3}, {"d", 4}, {"e", 5}}, MaxOffset+5)
+
+	require.True(t, p.HasNext())
+
+	next, err := url.ParseQuery(string(p.NextQuery()))
+	require.NoError(t, err)
+	require.Empty(t, next.Get("offset"), "should not link to an offset past the cap")
+	require.NotEmpty(t, next.Get("after"), "should link on by cursor")
+
+	nextQuery := testSortable.Query(FromQuery(next), 1)
+	require.Equal(t, []any{"d", int64(4), 3}, nextQuery.Args, "should carry on after the last item")
+}
+
+func testKeysetPage(t *testing.T) {
+	q := testSortable.Query(Request{Limit: 2, Keyset: true}, 1)
+
+	p := NewPage(q, []string{"a", "b", "c"}, []Key{{"a", 1}, {"b", 2}, {"c", 3}}, 5)
+
+	require.Equal(t, []string{"a", "b"}, p.Items)
+	require.False(t, p.HasPrev(), "should not have previous page")
+	require.True(t, p.HasNext(), "should have next page")
+
+	next, err := url.ParseQuery(string(p.NextQuery()))
+	require.NoError(t, err)
+
+	c, err := decodeCursor(next.Get("after"))
+	require.NoError(t, err)
+	require.Equal(t, cursor{Sort: "name", Value: "b", Id: 2}, c, "should point after last item")
+}
+
+func testKeysetPageBefore(t *testing.T) {
+	before := encodeCursor(cursor{Sort: "name", Value: "d", Id: 4})
+	q := testSortable.Query(Request{Limit: 2, Before: before}, 1)
+
+	// rows come back in reverse order when walking backwards
+	p := NewPage(q, []string{"c", "b"}, []Key{{"c", 3}, {"b", 2}}, 5)
+
+	require.Equal(t, []string{"b", "c"}, p.Items, "should put rows back in order")
+	require.False(t, p.HasPrev(), "should not have previous page")
+	require.True(t, p.HasNext(), "should have next page")
+}
+
+func testEmptyPage(t *testing.T) {
+	q := testSortable.Query(Request{}, 1)
+
+	p := NewPage[string](q, nil, nil, 0)
+
+	require.NotNil(t, p.Items, "should never return nil items")
+	require.Empty(t, p.Items)
+	require.False(t, p.HasNext())
+	require.False(t, p.HasPrev())
+	require.Equal(t, 0, p.First())
+}
+
+func testMapPage(t *testing.T) {
+	q := testSortable.Query(Request{Limit: 1}, 1)
+	p := NewPage(q, []int{1, 2}, []Key{{"1", 1}, {"2", 2}}, 2)
+	p.Params = url.Values{"q": {"go"}}
+
+	mapped := Map(p, func(i int) string { return string(rune('a' + i)) })
+
+	require.Equal(t, []string{"b"}, mapped.Items)
+	require.Equal(t, 2, mapped.Total)
+	require.True(t, mapped.HasNext(), "should keep position")
+	require.Equal(t, p.Params, mapped.Params)
+}
+
+func testSortQueryAndIndicator(t *testing.T) {
+	p := Page[string]{Request: testSortable.Normalise(Request{})}
+
+	require.Equal(t, "dir=desc&sort=name", string(p.SortQuery("name")), "should toggle direction")
+	require.Equal(t, "dir=asc&sort=id", string(p.SortQuery("id")))
+	require.Equal(t, "↑", p.SortIndicator("name"))
+	require.Equal(t, "", p.SortIndicator("id"))
+}
+
+func testPageQueriesCarryParams(t *testing.T) {
+	p := Page[string]{
+		Request: testSortable.Normalise(Request{Limit: 5}),
+		Params:  url.Values{"q": {"go"}},
+	}
+
+	require.Equal(t, "dir=asc&limit=5&offset=5&q=go&sort=name", string(p.NextQuery()))
+}
+
+func testFirstAndLastKeyset(t *testing.T) {
+	q := testSortable.Query(Request{Keyset: true}, 1)
+	p := NewPage(q, []string{"a"}, []Key{{"a", 1}}, 1)
+
+	require.Equal(t, 0, p.First(), "should not know position")
+	require.Equal(t, 0, p.Last(), "should not know position")
+}
@@ -3,3 +3,11 @@ package session
 type contextKey string
 
 const IS_LOGGED_IN_CONTEXT_KEY = contextKey(LOGGED_IN_USERNAME)
+
+// LOGGED_IN_USER_CONTEXT_KEY holds the logged in user on requests that have
+// had their permissions checked.
+const LOGGED_IN_USER_CONTEXT_KEY = contextKey("logged_in_user")
+
+// API_TOKEN_CONTEXT_KEY holds the API token an API request was authenticated
+// with.
+const API_TOKEN_CONTEXT_KEY = contextKey("api_token")
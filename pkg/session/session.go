@@ -10,6 +10,20 @@ import (
 const (
 	SESSION_KEY_MESSAGE = "message"
 	LOGGED_IN_USERNAME  = "logged_in_username"
+	// SESSION_VERSION is the logged in user's session version at login, which
+	// stops the session being valid once their password has changed
+	SESSION_VERSION = "session_version"
+	// PENDING_TWO_FACTOR_USERNAME is who has logged in with their password but
+	// still needs to give a two-factor code
+	PENDING_TWO_FACTOR_USERNAME = "pending_two_factor_username"
+	// TWO_FACTOR_SECRET is the secret being set up for two-factor
+	// authentication, until it's confirmed with a code
+	TWO_FACTOR_SECRET = "two_factor_secret"
+	// SESSION_USER_AGENT, SESSION_IP and SESSION_LAST_SEEN are where a logged
+	// in session was last used from and when, in seconds since the epoch
+	SESSION_USER_AGENT = "user_agent"
+	SESSION_IP         = "ip"
+	SESSION_LAST_SEEN  = "last_seen"
 )
 
 type SessionManager interface {
@@ -18,6 +32,7 @@ type SessionManager interface {
 	Remove(ctx context.Context, key string)
 	PopString(ctx context.Context, key string) string
 	GetString(ctx context.Context, key string) string
+	GetInt(ctx context.Context, key string) int
 	LoadAndSave(next http.Handler) http.Handler
 	RenewToken(ctx context.Context) error
 }
@@ -42,6 +57,10 @@ func (s SessionManagerImpl) GetString(ctx context.Context, key string) string {
 	return s.scs.GetString(ctx, key)
 }
 
+func (s SessionManagerImpl) GetInt(ctx context.Context, key string) int {
+	return s.scs.GetInt(ctx, key)
+}
+
 func (s SessionManagerImpl) LoadAndSave(next http.Handler) http.Handler {
 	return s.scs.LoadAndSave(next)
 }
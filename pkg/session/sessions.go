@@ -0,0 +1,150 @@
+package session
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+var ErrSessionNotFound = errors.New("session not found")
+
+// Session is a logged in session, as recorded by the session activity
+// middleware. Id identifies the session without giving away its token.
+type Session struct {
+	Id        string
+	Username  string
+	Version   int
+	UserAgent string
+	Ip        string
+	LastSeen  time.Time
+	Current   bool
+}
+
+// Device describes the browser and operating system the session is from,
+// going by its user agent.
+func (s Session) Device() string {
+	browser := firstContaining(s.UserAgent, [][2]string{
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"},
+		{"Safari/", "Safari"},
+	})
+
+	os := firstContaining(s.UserAgent, [][2]string{
+		{"Android", "Android"},
+		{"iPhone", "iOS"},
+		{"iPad", "iOS"},
+		{"Windows", "Windows"},
+		{"Mac OS X", "macOS"},
+		{"Linux", "Linux"},
+	})
+
+	switch {
+	case browser != "" && os != "":
+		return browser + " on " + os
+	case browser != "":
+		return browser
+	case os != "":
+		return os
+	case s.UserAgent != "":
+		return s.UserAgent
+	default:
+		return "Unknown device"
+	}
+}
+
+// SessionList lists and revokes users' sessions, which needs a session store
+// that can iterate over the sessions in it.
+type SessionList interface {
+	List(ctx context.Context, username string) ([]Session, error)
+	Revoke(ctx context.Context, username, id string) error
+}
+
+// List returns username's sessions, most recently seen first.
+func (s SessionManagerImpl) List(ctx context.Context, username string) ([]Session, error) {
+	current := s.scs.Token(ctx)
+
+	sessions := []Session{}
+
+	if err := s.scs.Iterate(ctx, func(ctx context.Context) error {
+		if s.scs.GetString(ctx, LOGGED_IN_USERNAME) != username {
+			return nil
+		}
+
+		token := s.scs.Token(ctx)
+
+		sessions = append(sessions, Session{
+			Id:        sessionId(token),
+			Username:  username,
+			Version:   s.scs.GetInt(ctx, SESSION_VERSION),
+			UserAgent: s.scs.GetString(ctx, SESSION_USER_AGENT),
+			Ip:        s.scs.GetString(ctx, SESSION_IP),
+			LastSeen:  time.Unix(int64(s.scs.GetInt(ctx, SESSION_LAST_SEEN)), 0),
+			Current:   token == current,
+		})
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeen.After(sessions[j].LastSeen)
+	})
+
+	return sessions, nil
+}
+
+// Revoke logs out username's session with the given id. The current session
+// can't be revoked, since it'd be saved again at the end of the request, so
+// should be logged out of instead.
+func (s SessionManagerImpl) Revoke(ctx context.Context, username, id string) error {
+	current := s.scs.Token(ctx)
+
+	found := false
+
+	if err := s.scs.Iterate(ctx, func(ctx context.Context) error {
+		token := s.scs.Token(ctx)
+
+		if found ||
+			token == current ||
+			sessionId(token) != id ||
+			s.scs.GetString(ctx, LOGGED_IN_USERNAME) != username {
+			return nil
+		}
+
+		found = true
+
+		return s.scs.Destroy(ctx)
+	}); err != nil {
+		return err
+	}
+
+	if !found {
+		return ErrSessionNotFound
+	}
+
+	return nil
+}
+
+func sessionId(token string) string {
+	sum := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(sum[:16])
+}
+
+func firstContaining(s string, candidates [][2]string) string {
+	for _, c := range candidates {
+		if strings.Contains(s, c[0]) {
+			return c[1]
+		}
+	}
+
+	return ""
+}
@@ -3,8 +3,8 @@ package templates
 import (
 	"html/template"
 	"io"
-	"os"
 	"path"
+	"path/filepath"
 	"strings"
 
 	"github.com/bmatcuk/doublestar/v4"
@@ -16,7 +16,7 @@ type Template interface {
 
 type TemplateCache map[string]Template
 
-func newTemplateCache(templateDir, pageGlob string) (TemplateCache, error) {
+func newTemplateCache(templateDir, pageGlob string, funcs template.FuncMap) (TemplateCache, error) {
 	cache := TemplateCache{}
 
 	glob := path.Join(templateDir, pageGlob)
@@ -28,14 +28,25 @@ func newTemplateCache(templateDir, pageGlob string) (TemplateCache, error) {
 	adminBaseTemplate := path.Join(templateDir, "base", "admin.tmpl")
 	publicBaseTemplate := path.Join(templateDir, "base", "public.tmpl")
 
+	partials, err := doublestar.FilepathGlob(path.Join(templateDir, "partials", "*.tmpl"))
+	if err != nil {
+		return nil, err
+	}
+
 	for _, page := range pages {
-		files := []string{
+		// partials are parsed along with every page, not as pages themselves
+		if strings.HasPrefix(page, path.Join(templateDir, "partials")+"/") {
+			continue
+		}
+
+		files := append([]string{
 			publicBaseTemplate,
 			adminBaseTemplate,
-			page,
-		}
+		}, partials...)
+
+		files = append(files, page)
 
-		ts, err := template.ParseFiles(files...)
+		ts, err := template.New(path.Base(page)).Funcs(funcs).ParseFiles(files...)
 		if err != nil {
 			return nil, err
 		}
@@ -52,11 +63,13 @@ func newTemplateCache(templateDir, pageGlob string) (TemplateCache, error) {
 	return cache, nil
 }
 
-func NewTemplateCache() (TemplateCache, error) {
-	pwd, err := os.Getwd()
+// NewTemplateCache parses every page in templateDir along with the base
+// templates and partials, making funcs available to all of them.
+func NewTemplateCache(templateDir string, funcs template.FuncMap) (TemplateCache, error) {
+	dir, err := filepath.Abs(templateDir)
 	if err != nil {
 		return nil, err
 	}
 
-	return newTemplateCache(path.Join(pwd, "web", "templates"), "**/*.tmpl")
+	return newTemplateCache(dir, "**/*.tmpl", funcs)
 }